@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	accessLogCommon = "common"
+	accessLogJSON   = "json"
+)
+
+// AccessLogConfig writes one line per HTTP request the proxy serves. Format
+// is "common" (default; Common Log Format followed by the dispatched
+// servers and the latency) or "json". Lines go to stderr unless File names
+// a log file, which is rotated like a server's logFile.
+type AccessLogConfig struct {
+	Format     string `json:"format,omitempty"`
+	File       string `json:"file,omitempty"`
+	MaxSizeMB  int    `json:"maxSizeMB,omitempty"`
+	MaxBackups int    `json:"maxBackups,omitempty"`
+}
+
+// accessLogger formats and writes access log lines.
+type accessLogger struct {
+	json bool
+	mu   sync.Mutex
+	out  io.Writer
+}
+
+// newAccessLogger opens the access log conf asks for; it returns nil when
+// conf is nil.
+func newAccessLogger(conf *AccessLogConfig) (*accessLogger, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	l := &accessLogger{out: os.Stderr}
+	switch strings.ToLower(conf.Format) {
+	case "", accessLogCommon:
+	case accessLogJSON:
+		l.json = true
+	default:
+		return nil, fmt.Errorf("accessLog.format: unknown format %q", conf.Format)
+	}
+	if conf.File != "" {
+		path, err := resolveLogFilePath(conf.File)
+		if err != nil {
+			return nil, fmt.Errorf("accessLog.file %s: %w", conf.File, err)
+		}
+		maxSizeMB := conf.MaxSizeMB
+		if maxSizeMB <= 0 {
+			maxSizeMB = defaultLogFileMaxSizeMB
+		}
+		backups := conf.MaxBackups
+		if backups <= 0 {
+			backups = defaultLogFileMaxBackups
+		}
+		rf, err := newRotatingFile(path, int64(maxSizeMB)<<20, backups)
+		if err != nil {
+			return nil, fmt.Errorf("accessLog.file %s: %w", conf.File, err)
+		}
+		logger("access").Info("Writing access log", "path", path)
+		l.out = rf
+	}
+	return l, nil
+}
+
+// Close closes the access log file, if any; nil-safe.
+func (l *accessLogger) Close() error {
+	if l == nil {
+		return nil
+	}
+	if c, ok := l.out.(io.Closer); ok && l.out != os.Stderr {
+		return c.Close()
+	}
+	return nil
+}
+
+// accessLogEntry is one served request.
+type accessLogEntry struct {
+	Time       time.Time `json:"time"`
+	Remote     string    `json:"remote"`
+	Caller     string    `json:"caller,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Proto      string    `json:"proto"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMs float64   `json:"durationMs"`
+	Servers    []string  `json:"servers,omitempty"`
+}
+
+func (l *accessLogger) write(entry accessLogEntry) {
+	var line []byte
+	if l.json {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		line = append(data, '\n')
+	} else {
+		line = []byte(formatCommonLogLine(entry))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.out.Write(line)
+}
+
+// formatCommonLogLine renders entry in Common Log Format, with the servers
+// the request was dispatched to and its latency appended.
+func formatCommonLogLine(entry accessLogEntry) string {
+	caller, servers := "-", "-"
+	if entry.Caller != "" {
+		caller = strings.ReplaceAll(entry.Caller, " ", "_")
+	}
+	if len(entry.Servers) > 0 {
+		servers = strings.Join(entry.Servers, ",")
+	}
+	return fmt.Sprintf("%s - %s [%s] %q %d %d server=%s duration=%.3fms\n",
+		entry.Remote, caller, entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		entry.Method+" "+entry.Path+" "+entry.Proto, entry.Status, entry.Bytes, servers, entry.DurationMs)
+}
+
+// accessLogContext is placed on the request context by the access log
+// middleware so handlers can say which servers served the request and for
+// whom.
+type accessLogContext struct {
+	mu      sync.Mutex
+	caller  string
+	servers map[string]bool
+}
+
+type accessLogContextKey struct{}
+
+// noteAccessServer records that the request of ctx was dispatched to
+// server, along with the caller it was made for; no-op without an access
+// log.
+func noteAccessServer(ctx context.Context, server string) {
+	ac, ok := ctx.Value(accessLogContextKey{}).(*accessLogContext)
+	if !ok {
+		return
+	}
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if server != "" {
+		ac.servers[server] = true
+	}
+	if info, ok := callerFromContext(ctx); ok && ac.caller == "" {
+		ac.caller = info.Identity
+	}
+}
+
+// accessServerMiddleware notes the server a per-server route belongs to.
+func accessServerMiddleware(server string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			noteAccessServer(r.Context(), server)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// middleware logs every request once it has been served; a nil logger
+// logs nothing.
+func (l *accessLogger) middleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ac := &accessLogContext{servers: make(map[string]bool)}
+			aw := &accessWriter{ResponseWriter: w}
+			defer func() {
+				entry := accessLogEntry{
+					Time:       start,
+					Remote:     r.RemoteAddr,
+					Method:     r.Method,
+					Path:       r.URL.Path,
+					Proto:      r.Proto,
+					Status:     aw.status,
+					Bytes:      aw.bytes,
+					DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+				}
+				if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+					entry.Remote = host
+				}
+				if r.URL.RawQuery != "" {
+					entry.Path += "?" + redactQuery(r.URL.RawQuery)
+				}
+				if entry.Status == 0 {
+					entry.Status = http.StatusOK
+				}
+				ac.mu.Lock()
+				entry.Caller = ac.caller
+				for server := range ac.servers {
+					entry.Servers = append(entry.Servers, server)
+				}
+				ac.mu.Unlock()
+				sort.Strings(entry.Servers)
+				l.write(entry)
+			}()
+			next.ServeHTTP(aw, r.WithContext(context.WithValue(r.Context(), accessLogContextKey{}, ac)))
+		})
+	}
+}
+
+// accessWriter counts the status and body bytes of a response.
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (aw *accessWriter) WriteHeader(code int) {
+	if aw.status == 0 && code >= 200 {
+		aw.status = code
+	}
+	aw.ResponseWriter.WriteHeader(code)
+}
+
+func (aw *accessWriter) Write(p []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	n, err := aw.ResponseWriter.Write(p)
+	aw.bytes += int64(n)
+	return n, err
+}
+
+func (aw *accessWriter) Flush() { _ = http.NewResponseController(aw.ResponseWriter).Flush() }
+
+func (aw *accessWriter) Unwrap() http.ResponseWriter { return aw.ResponseWriter }
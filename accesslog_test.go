@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	logger := &accessLogger{json: true, out: &out}
+	mux := http.NewServeMux()
+	mux.Handle("/fs/", chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("hello"))
+	}), accessServerMiddleware("fs")))
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		ctx := withCallerInfo(r.Context(), callerInfo{Identity: "alice"})
+		noteAccessServer(ctx, "web")
+		noteAccessServer(ctx, "git")
+		noteAccessServer(ctx, "web")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})
+	handler := logger.middleware()(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/fs/sse?token=abc&x=1", nil)
+	req.RemoteAddr = "10.0.0.7:5123"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", nil))
+
+	var entries []accessLogEntry
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry accessLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("logged:\n%s", out.String())
+	}
+	fs, facade := entries[0], entries[1]
+	if fs.Remote != "10.0.0.7" || fs.Status != http.StatusAccepted || fs.Bytes != 5 || strings.Contains(fs.Path, "abc") || !strings.Contains(fs.Path, "x=1") || len(fs.Servers) != 1 || fs.Servers[0] != "fs" {
+		t.Fatalf("server route entry = %+v", fs)
+	}
+	if facade.Status != http.StatusOK || facade.Bytes != 11 || facade.Caller != "alice" || strings.Join(facade.Servers, ",") != "git,web" {
+		t.Fatalf("facade entry = %+v", facade)
+	}
+
+	line := formatCommonLogLine(facade)
+	if !strings.Contains(line, ` - alice [`) || !strings.Contains(line, `] "POST /mcp HTTP/1.1" 200 11 server=git,web duration=`) {
+		t.Fatalf("common line = %q", line)
+	}
+
+	// without a logger the middleware is a pass-through
+	var none *accessLogger
+	if got := none.middleware()(mux); got != http.Handler(mux) {
+		t.Fatal("nil logger wrapped the handler")
+	}
+}
+
+func TestNewAccessLogger(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	if l, err := newAccessLogger(nil); l != nil || err != nil {
+		t.Fatalf("nil config = %v, %v", l, err)
+	}
+	if _, err := newAccessLogger(&AccessLogConfig{Format: "combined"}); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+	l, err := newAccessLogger(&AccessLogConfig{File: "access.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.middleware()(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(stateHome(), "logs", "access.log"))
+	if err != nil || !strings.Contains(string(data), `"GET /missing HTTP/1.1" 404 19 server=-`) {
+		t.Fatalf("access.log = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const rpcCodeCallCancelled = -32006
+
+var errCallCancelledByOperator = errors.New("call cancelled by operator")
+
+type activeCall struct {
+	ID        string    `json:"id"`
+	Method    string    `json:"method"`
+	Target    string    `json:"target"`
+	Server    string    `json:"server"`
+	Caller    string    `json:"caller,omitempty"`
+	SessionID string    `json:"sessionId,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+
+	cancel context.CancelCauseFunc
+}
+
+// activeCallRegistry tracks in-flight dispatches so operators can see and
+// cancel long-running calls without restarting the proxy.
+type activeCallRegistry struct {
+	mu    sync.Mutex
+	calls map[string]*activeCall
+}
+
+func newActiveCallRegistry() *activeCallRegistry {
+	return &activeCallRegistry{calls: make(map[string]*activeCall)}
+}
+
+// begin registers a call and returns a context cancelled when an operator
+// cancels it. The returned func must be called once the call finishes.
+func (reg *activeCallRegistry) begin(ctx context.Context, method, target, server string) (context.Context, func()) {
+	callCtx, cancel := context.WithCancelCause(ctx)
+	call := &activeCall{
+		ID:        uuid.New().String(),
+		Method:    method,
+		Target:    target,
+		Server:    server,
+		StartedAt: time.Now().UTC(),
+		cancel:    cancel,
+	}
+	if info, ok := callerFromContext(ctx); ok {
+		call.Caller = info.Identity
+		call.SessionID = info.SessionID
+	}
+	reg.mu.Lock()
+	reg.calls[call.ID] = call
+	reg.mu.Unlock()
+	return callCtx, func() {
+		reg.mu.Lock()
+		delete(reg.calls, call.ID)
+		reg.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+func (reg *activeCallRegistry) cancel(id string) bool {
+	reg.mu.Lock()
+	call, ok := reg.calls[id]
+	reg.mu.Unlock()
+	if !ok {
+		return false
+	}
+	call.cancel(errCallCancelledByOperator)
+	return true
+}
+
+func (reg *activeCallRegistry) list() []activeCall {
+	reg.mu.Lock()
+	out := make([]activeCall, 0, len(reg.calls))
+	for _, call := range reg.calls {
+		out = append(out, *call)
+	}
+	reg.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].StartedAt.Equal(out[j].StartedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].StartedAt.Before(out[j].StartedAt)
+	})
+	return out
+}
+
+// snapshot renders the admin view: individual calls plus concurrency per
+// server and per target.
+func (reg *activeCallRegistry) snapshot(now time.Time) map[string]any {
+	calls := reg.list()
+	byServer := make(map[string]int)
+	byTarget := make(map[string]int)
+	items := make([]map[string]any, 0, len(calls))
+	for _, call := range calls {
+		byServer[call.Server]++
+		byTarget[call.Target]++
+		items = append(items, map[string]any{
+			"id":        call.ID,
+			"method":    call.Method,
+			"target":    call.Target,
+			"server":    call.Server,
+			"caller":    call.Caller,
+			"sessionId": call.SessionID,
+			"startedAt": call.StartedAt.Format(time.RFC3339Nano),
+			"elapsedMs": now.Sub(call.StartedAt).Milliseconds(),
+		})
+	}
+	return map[string]any{
+		"total":    len(calls),
+		"byServer": byServer,
+		"byTarget": byTarget,
+		"calls":    items,
+	}
+}
+
+func cancelledByOperator(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errCallCancelledByOperator)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestActiveCallRegistryCancel(t *testing.T) {
+	reg := newActiveCallRegistry()
+	ctx := withCallerInfo(context.Background(), callerInfo{Identity: "token:abc", SessionID: "s1"})
+	callCtx, finish := reg.begin(ctx, "tools/call", "slow_tool", "alpha")
+	defer finish()
+
+	calls := reg.list()
+	if len(calls) != 1 || calls[0].Target != "slow_tool" || calls[0].Caller != "token:abc" {
+		t.Fatalf("unexpected registry contents: %+v", calls)
+	}
+	if !reg.cancel(calls[0].ID) {
+		t.Fatalf("expected cancel to find call")
+	}
+	select {
+	case <-callCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected call context to be cancelled")
+	}
+	if !cancelledByOperator(callCtx) {
+		t.Fatalf("expected cancellation cause to be operator")
+	}
+	finish()
+	if len(reg.list()) != 0 {
+		t.Fatalf("expected finished call to be removed")
+	}
+}
+
+func TestActiveCallAdminRoutesRequireToken(t *testing.T) {
+	mux := http.NewServeMux()
+	reg := newActiveCallRegistry()
+	_, finish := reg.begin(context.Background(), "tools/call", "echo", "alpha")
+	defer finish()
+	registerActiveCallRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), reg)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/active-calls", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var body struct {
+		Total    int            `json:"total"`
+		ByServer map[string]int `json:"byServer"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Total != 1 || body.ByServer["alpha"] != 1 {
+		t.Fatalf("unexpected snapshot: %+v", body)
+	}
+
+	del := httptest.NewRequest(http.MethodDelete, "/admin/active-calls/missing", nil)
+	del.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, del)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown call, got %d", w.Code)
+	}
+}
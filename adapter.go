@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"errors"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -38,7 +37,7 @@ func loadStatus(path string) (statusMap, error) {
 	}
 	var out statusMap
 	if err := json.Unmarshal(data, &out); err != nil {
-		log.Printf("<adapter> status parse error: %v", err)
+		logger("adapter").Warn("status parse error", "err", err)
 		return make(statusMap), nil
 	}
 	if out == nil {
@@ -66,7 +65,7 @@ func writeStatus(path string, st statusMap) error {
 func setStatus(path, server, tool, adapter string, consecutive int) {
 	st, err := loadStatus(path)
 	if err != nil {
-		log.Printf("<adapter> schema status load error for %s: %v", path, err)
+		logger("adapter").Warn("schema status load error", "path", path, "err", err)
 	}
 	if _, ok := st[server]; !ok {
 		st[server] = make(map[string]*toolStatusEntry)
@@ -77,7 +76,7 @@ func setStatus(path, server, tool, adapter string, consecutive int) {
 		UpdatedAt:          time.Now().Unix(),
 	}
 	if err := writeStatus(path, st); err != nil {
-		log.Printf("<adapter> schema status write error for %s: %v", path, err)
+		logger("adapter").Error("schema status write error", "path", path, "err", err)
 	}
 }
 
@@ -107,7 +106,8 @@ func logAdoptionTelemetry(server, tool, adapter string, prev *toolStatusEntry, s
 	if len(schema) > 0 {
 		hash = hashSchema(schema)
 	}
-	log.Printf("<adoption> state=%s server=%s tool=%s adapter=%s streak=%d schema=%s", state, server, tool, adapter, streak, hash)
+	logger("adapter").Info("adoption", "state", state, "server", server, "tool", tool, "adapter", adapter, "streak", streak, "schema", hash)
+	promAdoptions.inc(server, tool, adapter, state)
 }
 
 // ---- Override Writer ----
@@ -120,6 +120,14 @@ type overrideFile struct {
 }
 
 func writeServerToolOutputSchema(path, server, tool string, schema map[string]any) error {
+	return updateServerToolOverride(path, server, tool, func(cfg *ToolOverrideConfig) {
+		cfg.OutputSchema = copySchemaMap(schema)
+	})
+}
+
+// updateServerToolOverride applies update to the server's entry for tool in
+// the overrides file, creating the entry enabled if needed.
+func updateServerToolOverride(path, server, tool string, update func(cfg *ToolOverrideConfig)) error {
 	if path == "" {
 		return nil
 	}
@@ -154,7 +162,7 @@ func writeServerToolOutputSchema(path, server, tool string, schema map[string]an
 		cfg = &ToolOverrideConfig{Enabled: boolPtr(true)}
 		frag.Tools[tool] = cfg
 	}
-	cfg.OutputSchema = copySchemaMap(schema)
+	update(cfg)
 	// atomic write
 	tmp := abs + ".tmp"
 	data, _ := json.MarshalIndent(file, "", "  ")
@@ -181,8 +189,7 @@ func adaptCallResult(serverName, toolName string, overrides *ToolOverrideSet, ma
 	}
 	if sc, ok := res["structuredContent"].(map[string]any); ok && sc != nil {
 		// already structured
-		setStatus(statusPath, serverName, toolName, "pass_through", 0)
-		logAdoptionTelemetry(serverName, toolName, "pass_through", prevStatus, 1, sc)
+		recordPassThrough(statusPath, serverName, toolName, prevStatus, sc)
 		return false, "pass_through", sc, nil
 	}
 
@@ -229,6 +236,21 @@ func adaptCallResult(serverName, toolName string, overrides *ToolOverrideSet, ma
 	return true, "generic", gen, nil
 }
 
+func recordPassThrough(statusPath, serverName, toolName string, prevStatus *toolStatusEntry, sc map[string]any) {
+	setStatus(statusPath, serverName, toolName, "pass_through", 0)
+	logAdoptionTelemetry(serverName, toolName, "pass_through", prevStatus, 1, sc)
+}
+
+// adaptPassThrough records the adoption of a pass-through result the way
+// adaptCallResult does.
+func adaptPassThrough(serverName, toolName string, manifest *ManifestConfig, sc map[string]any) {
+	statusPath := ""
+	if manifest != nil {
+		statusPath = manifest.ToolSchemaStatusPath
+	}
+	recordPassThrough(statusPath, serverName, toolName, readStatusEntry(statusPath, serverName, toolName), sc)
+}
+
 func extractTextContent(result map[string]any) string {
 	if result == nil {
 		return ""
@@ -168,3 +168,19 @@ func TestAdapt_Generic_PersistsOverrideAndStatus(t *testing.T) {
 		t.Fatalf("expected returned schema for generic path")
 	}
 }
+
+func TestDigestStructuredContent(t *testing.T) {
+	sc := digestBytes([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"{\"ok\":true}"}],"structuredContent":{"ok":true}}}`)).StructuredContent
+	if sc["ok"] != true {
+		t.Fatalf("structured result not passed through: %v", sc)
+	}
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"hi"}]}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"boom"}}`,
+		`not json`,
+	} {
+		if sc := digestBytes([]byte(body)).StructuredContent; sc != nil {
+			t.Errorf("digestBytes(%s).StructuredContent = %v", body, sc)
+		}
+	}
+}
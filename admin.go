@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminAPI mounts operator endpoints under <baseURL>/admin. Every route requires
+// one of mcpProxy.adminTokens; the API is not mounted when none are configured.
+type adminAPI struct {
+	mux      *http.ServeMux
+	basePath string
+	auth     MiddlewareFunc
+	json     JSONEncodingConfig
+}
+
+func newAdminAPI(mux *http.ServeMux, basePath string, tokens []string, enc JSONEncodingConfig) *adminAPI {
+	if len(tokens) == 0 {
+		return nil
+	}
+	return &adminAPI{
+		mux:      mux,
+		basePath: basePath,
+		auth:     newAdminAuthMiddleware(tokens),
+		json:     enc,
+	}
+}
+
+// newAdminAuthMiddleware admits requests bearing one of the admin tokens.
+// Unlike newAuthMiddleware it trusts no identity already on the context,
+// such as a client certificate's: admin calls always carry an admin token.
+func newAdminAuthMiddleware(tokens []string) MiddlewareFunc {
+	tokenSet := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		tokenSet[token] = struct{}{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := tokenSet[bearerToken(r)]; !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (a *adminAPI) route(suffix string) string {
+	route := path.Join(a.basePath, "admin", suffix)
+	if !strings.HasPrefix(route, "/") {
+		route = "/" + route
+	}
+	return route
+}
+
+// handle registers "METHOD suffix" relative to the admin root.
+func (a *adminAPI) handle(method, suffix string, h http.HandlerFunc) {
+	if a == nil {
+		return
+	}
+	pattern := method + " " + a.route(suffix)
+	a.mux.Handle(pattern, chainMiddleware(h, a.auth, recoverMiddleware("admin")))
+	logger("admin").Info("Handling requests", "pattern", pattern)
+}
+
+func (a *adminAPI) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = a.json.encode(w, v)
+}
+
+func registerActiveCallRoutes(api *adminAPI, calls *activeCallRegistry) {
+	api.handle(http.MethodGet, "active-calls", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, calls.snapshot(time.Now().UTC()))
+	})
+	api.handle(http.MethodDelete, "active-calls/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if !calls.cancel(id) {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown call", "id": id})
+			return
+		}
+		logger("admin").Info("cancelled active call", "id", id)
+		api.writeJSON(w, http.StatusOK, map[string]any{"cancelled": id})
+	})
+}
+
+func registerSLORoutes(api *adminAPI, tracker *sloTracker) {
+	api.handle(http.MethodGet, "slos", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"slos": tracker.snapshot(time.Now())})
+	})
+}
+
+func registerFlakinessRoutes(api *adminAPI, detector *flakinessDetector) {
+	if detector == nil {
+		return
+	}
+	api.handle(http.MethodGet, "flakiness", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"tools": detector.report()})
+	})
+}
+
+// registerDeadToolRoutes lists tools with a run of failed calls; dead ones
+// come first.
+func registerDeadToolRoutes(api *adminAPI, detector *deadToolDetector) {
+	if detector == nil {
+		return
+	}
+	api.handle(http.MethodGet, "dead-tools", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"tools": detector.list()})
+	})
+}
+
+func registerProbeRoutes(api *adminAPI, probes *probeRunner) {
+	if probes == nil {
+		return
+	}
+	api.handle(http.MethodGet, "probes", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"probes": probes.list()})
+	})
+}
+
+// auditReplayFunc re-dispatches an audit entry and returns the raw response.
+// It honours the replayIsolation in ctx, returning errReplayHeld for a call
+// the isolation holds.
+type auditReplayFunc func(ctx context.Context, entry *auditEntry) ([]byte, error)
+
+var errReplayHeld = errors.New("calls a tool that is not read-only")
+
+func registerAuditRoutes(api *adminAPI, audit *auditLog, replay auditReplayFunc) {
+	if audit == nil {
+		return
+	}
+	api.handle(http.MethodGet, "audit", func(w http.ResponseWriter, r *http.Request) {
+		limit := 50
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+			limit = v
+		}
+		entries, err := audit.recent(limit)
+		if err != nil {
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		api.writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+	})
+	api.handle(http.MethodPost, "audit/{id}/replay", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		entry, err := audit.find(id)
+		if err != nil {
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if entry == nil {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown audit entry", "id": id})
+			return
+		}
+		var opts struct {
+			Force bool `json:"force"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+				return
+			}
+		}
+		start := time.Now()
+		replayed, err := replay(withReplayIsolation(r.Context(), &replayIsolation{force: opts.Force}), entry)
+		if errors.Is(err, errReplayHeld) {
+			api.writeJSON(w, http.StatusConflict, map[string]any{"error": "audit entry " + err.Error() + "; replay with force to run it", "id": id, "target": entry.Target})
+			return
+		}
+		if err != nil {
+			api.writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "id": id})
+			return
+		}
+		report := buildReplayReport(entry, replayed, time.Since(start).Milliseconds())
+		logger("admin").Info("replayed audit entry", "id", id, "target", entry.Target, "server", entry.Server, "forced", opts.Force, "identical", report.Identical)
+		api.writeJSON(w, http.StatusOK, report)
+	})
+}
+
+func registerNotificationRoutes(api *adminAPI, filter *notificationFilter) {
+	api.handle(http.MethodGet, "notifications", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"notifications": filter.snapshot()})
+	})
+}
+
+func registerPayloadRoutes(api *adminAPI, metrics *callMetrics) {
+	api.handle(http.MethodGet, "payloads", func(w http.ResponseWriter, r *http.Request) {
+		top := 10
+		if v, err := strconv.Atoi(r.URL.Query().Get("top")); err == nil && v > 0 {
+			top = v
+		}
+		window := metrics.window
+		if v, err := time.ParseDuration(r.URL.Query().Get("window")); err == nil && v > 0 && v < window {
+			window = v
+		}
+		targets, largest := metrics.payloadReport(time.Now().Add(-window), top)
+		api.writeJSON(w, http.StatusOK, map[string]any{
+			"window":  window.String(),
+			"targets": targets,
+			"largest": largest,
+		})
+	})
+}
+
+func registerViolationRoutes(api *adminAPI, violations *protocolViolations) {
+	api.handle(http.MethodGet, "violations", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"servers": violations.snapshot()})
+	})
+}
+
+func registerPanicRoutes(api *adminAPI, panics *panicRecorder) {
+	api.handle(http.MethodGet, "panics", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, panics.snapshot())
+	})
+}
+
+// registerToolStateRoutes exposes the catalog view and soft-delete/restore of
+// downstream tools; changes are attributed to the admin token's fingerprint.
+func registerToolStateRoutes(api *adminAPI, ops adminToolOps, store *toolOverrideStore) {
+	api.handle(http.MethodGet, "catalog", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, ops.Catalog())
+	})
+	api.handle(http.MethodGet, "tools/history", func(w http.ResponseWriter, r *http.Request) {
+		limit := 50
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+			limit = v
+		}
+		api.writeJSON(w, http.StatusOK, map[string]any{"changes": store.recentHistory(limit)})
+	})
+	toggle := func(enabled bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Reason string `json:"reason"`
+			}
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+					return
+				}
+			}
+			change := toolToggle{
+				Server:  r.PathValue("server"),
+				Tool:    r.PathValue("tool"),
+				Enabled: enabled,
+				By:      "admin:" + tokenFingerprint(bearerToken(r)),
+				At:      time.Now().UTC(),
+				Reason:  body.Reason,
+			}
+			if err := ops.SetToolEnabled(change); err != nil {
+				api.writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+				return
+			}
+			action := "disabled"
+			if enabled {
+				action = "restored"
+			}
+			logger("admin").Info(action+" tool", "server", change.Server, "tool", change.Tool, "by", change.By)
+			api.writeJSON(w, http.StatusOK, change)
+		}
+	}
+	api.handle(http.MethodPost, "tools/{server}/{tool}/disable", toggle(false))
+	api.handle(http.MethodPost, "tools/{server}/{tool}/restore", toggle(true))
+}
+
+// registerGrantRoutes manages temporary per-token or per-session tool grants.
+// The token in a create request is only kept as its fingerprint.
+func registerGrantRoutes(api *adminAPI, grants *toolGrantStore, validate func(server, tool string) error, audit *auditLog) {
+	api.handle(http.MethodGet, "grants", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"grants": grants.list()})
+	})
+	api.handle(http.MethodPost, "grants", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Server  string `json:"server"`
+			Tool    string `json:"tool"`
+			Token   string `json:"token"`
+			Session string `json:"session"`
+			TTL     string `json:"ttl"`
+			Reason  string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		ttl, err := time.ParseDuration(body.TTL)
+		if err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid ttl: " + err.Error()})
+			return
+		}
+		if err := validate(body.Server, body.Tool); err != nil {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+			return
+		}
+		grant := toolGrant{
+			Server:    body.Server,
+			Tool:      body.Tool,
+			SessionID: body.Session,
+			By:        "admin:" + tokenFingerprint(bearerToken(r)),
+			Reason:    body.Reason,
+		}
+		if body.Token != "" {
+			grant.Caller = "token:" + tokenFingerprint(body.Token)
+		}
+		grant, err = grants.add(grant, ttl)
+		if err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		audit.record(grantAuditEntry(grant, "create", grant.By))
+		logger("admin").Info("granted tool", "server", grant.Server, "tool", grant.Tool, "caller", grant.Caller, "session", grant.SessionID, "until", grant.ExpiresAt.Format(time.RFC3339), "by", grant.By)
+		api.writeJSON(w, http.StatusCreated, grant)
+	})
+	api.handle(http.MethodDelete, "grants/{id}", func(w http.ResponseWriter, r *http.Request) {
+		grant, ok := grants.revoke(r.PathValue("id"), "admin:"+tokenFingerprint(bearerToken(r)))
+		if !ok {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown grant", "id": r.PathValue("id")})
+			return
+		}
+		api.writeJSON(w, http.StatusOK, grant)
+	})
+}
+
+func registerReadOnlyRoutes(api *adminAPI, mode *readOnlyMode, events *eventBus) {
+	api.handle(http.MethodGet, "read-only", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, mode.snapshot())
+	})
+	api.handle(http.MethodPut, "read-only", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Enabled *bool  `json:"enabled"`
+			Reason  string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": `body must be {"enabled": true|false}`})
+			return
+		}
+		state := mode.set(*body.Enabled, "admin:"+tokenFingerprint(bearerToken(r)), body.Reason)
+		logger("admin").Info("read-only mode", "enabled", state.Enabled, "by", state.By)
+		events.emit("proxy.read_only", map[string]any{
+			"enabled": state.Enabled,
+			"by":      state.By,
+			"reason":  state.Reason,
+		})
+		api.writeJSON(w, http.StatusOK, state)
+	})
+}
+
+func registerSessionRoutes(api *adminAPI, sessions *facadeSessionTable) {
+	api.handle(http.MethodGet, "sessions", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"sessions": sessions.list()})
+	})
+	api.handle(http.MethodDelete, "sessions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if !sessions.close(id) {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown session", "id": id})
+			return
+		}
+		logger("admin").Info("terminated facade session", "session", id)
+		api.writeJSON(w, http.StatusOK, map[string]any{"id": id, "terminated": true})
+	})
+}
+
+// registerBudgetRoutes shows per-session budget usage and lets operators
+// raise or reset one session's budget.
+func registerBudgetRoutes(api *adminAPI, budgets *sessionBudgets) {
+	if budgets == nil {
+		return
+	}
+	api.handle(http.MethodGet, "budgets", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"limits": budgets.limits, "sessions": budgets.list()})
+	})
+	api.handle(http.MethodPut, "budgets/{session}", func(w http.ResponseWriter, r *http.Request) {
+		var limits SessionBudgetConfig
+		if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		usage := budgets.override(r.PathValue("session"), limits)
+		logger("admin").Info("budget override", "session", usage.Session, "maxCalls", usage.MaxCalls, "maxDestructiveCalls", usage.MaxDestructiveCalls, "by", "admin:"+tokenFingerprint(bearerToken(r)))
+		api.writeJSON(w, http.StatusOK, usage)
+	})
+	api.handle(http.MethodDelete, "budgets/{session}", func(w http.ResponseWriter, r *http.Request) {
+		session := r.PathValue("session")
+		if !budgets.reset(session) {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "no budget usage for session", "session": session})
+			return
+		}
+		logger("admin").Info("budget reset", "session", session)
+		api.writeJSON(w, http.StatusOK, map[string]any{"session": session, "reset": true})
+	})
+}
+
+// registerTranscriptRoutes lists recorded facade sessions and exports one
+// session's transcript as JSON Lines.
+func registerTranscriptRoutes(api *adminAPI, transcripts *transcriptRecorder) {
+	if transcripts == nil {
+		return
+	}
+	api.handle(http.MethodGet, "transcripts", func(w http.ResponseWriter, r *http.Request) {
+		list, err := transcripts.list()
+		if err != nil {
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		api.writeJSON(w, http.StatusOK, map[string]any{"sessions": list})
+	})
+	api.handle(http.MethodGet, "transcripts/{session}", func(w http.ResponseWriter, r *http.Request) {
+		session := r.PathValue("session")
+		var buf bytes.Buffer
+		found, err := transcripts.export(session, &buf)
+		switch {
+		case err != nil:
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		case !found:
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "no transcript for session", "session": session})
+		default:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", transcriptFileName(session)))
+			_, _ = w.Write(buf.Bytes())
+		}
+	})
+	api.handle(http.MethodDelete, "transcripts/{session}", func(w http.ResponseWriter, r *http.Request) {
+		session := r.PathValue("session")
+		if !transcripts.remove(session) {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "no transcript for session", "session": session})
+			return
+		}
+		logger("admin").Info("deleted transcript", "session", session)
+		api.writeJSON(w, http.StatusOK, map[string]any{"session": session, "deleted": true})
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// adminToolServerName is the internal server that owns the self-management
+// tools; every tool it exposes is named adminToolServerName + "_" + op.
+const adminToolServerName = "stelae_admin"
+
+var errAdminToolArgs = errors.New("invalid arguments")
+
+// adminToolOps are the proxy operations exposed as tools. They are closures
+// over the running facade so the tool layer stays independent of http.go.
+type adminToolOps struct {
+	ListServers     func() []map[string]any
+	SetToolEnabled  func(change toolToggle) error
+	Catalog         func() map[string]any
+	ReloadOverrides func() (map[string]any, error)
+	Health          func() map[string]any
+}
+
+// adminToolServer aggregates the proxy's management operations into the
+// facade catalog. The tools are only listed and callable for requests that
+// carry one of mcpProxy.adminTokens.
+type adminToolServer struct {
+	tokens map[string]struct{}
+	ops    adminToolOps
+}
+
+func newAdminToolServer(tokens []string, ops adminToolOps) *adminToolServer {
+	if len(tokens) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		set[token] = struct{}{}
+	}
+	return &adminToolServer{tokens: set, ops: ops}
+}
+
+func (s *adminToolServer) authorized(r *http.Request) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.tokens[bearerToken(r)]
+	return ok
+}
+
+func isAdminToolName(name string) bool {
+	return strings.HasPrefix(name, adminToolServerName+"_")
+}
+
+func adminToolName(op string) string {
+	return adminToolServerName + "_" + op
+}
+
+// adminToolTargetSchema is the input schema for tools acting on one
+// downstream tool.
+func adminToolTargetSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"server": map[string]any{"type": "string", "description": "Downstream server name."},
+			"tool":   map[string]any{"type": "string", "description": "Tool name as reported by the server (not its alias)."},
+			"reason": map[string]any{"type": "string", "description": "Why the tool is being hidden or restored; kept in the audit trail."},
+		},
+		"required": []string{"server", "tool"},
+	}
+}
+
+// tools returns the admin tool descriptors in catalog order.
+func (s *adminToolServer) tools() []map[string]any {
+	empty := map[string]any{"type": "object", "properties": map[string]any{}}
+	descriptors := []map[string]any{
+		{
+			"name":        adminToolName("list_servers"),
+			"description": "List configured downstream servers with their connection state and catalog sizes.",
+			"inputSchema": empty,
+			"annotations": map[string]any{"readOnlyHint": true},
+		},
+		{
+			"name":        adminToolName("catalog"),
+			"description": "List enabled and disabled downstream tools; disabled entries say who hid them, when, and why.",
+			"inputSchema": empty,
+			"annotations": map[string]any{"readOnlyHint": true},
+		},
+		{
+			"name":        adminToolName("health"),
+			"description": "Report proxy readiness, connected servers, and in-flight calls.",
+			"inputSchema": empty,
+			"annotations": map[string]any{"readOnlyHint": true},
+		},
+		{
+			"name":        adminToolName("reload_overrides"),
+			"description": "Reload tool overrides from the manifest and toolOverridesPath; runtime enable/disable toggles are kept.",
+			"inputSchema": empty,
+			"annotations": map[string]any{"idempotentHint": true},
+		},
+		{
+			"name":        adminToolName("enable_tool"),
+			"description": "Restore a hidden downstream tool to the facade catalog.",
+			"inputSchema": adminToolTargetSchema(),
+			"annotations": map[string]any{"idempotentHint": true},
+		},
+		{
+			"name":        adminToolName("disable_tool"),
+			"description": "Hide a downstream tool from the facade catalog; it stays listed as disabled and can be restored.",
+			"inputSchema": adminToolTargetSchema(),
+			"annotations": map[string]any{"idempotentHint": true},
+		},
+	}
+	for i, descriptor := range descriptors {
+		descriptors[i] = attachStelaeMetadata(descriptor, []string{adminToolServerName})
+	}
+	return descriptors
+}
+
+// call runs an admin tool on behalf of actor and returns a tools/call result.
+// Operation failures are reported as isError results; only an unknown tool
+// name is an error.
+func (s *adminToolServer) call(name, actor string, args json.RawMessage) (map[string]any, error) {
+	var target struct {
+		Server string `json:"server"`
+		Tool   string `json:"tool"`
+		Reason string `json:"reason"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &target); err != nil {
+			return adminToolResult(nil, fmt.Errorf("%w: %v", errAdminToolArgs, err)), nil
+		}
+	}
+	switch name {
+	case adminToolName("list_servers"):
+		return adminToolResult(map[string]any{"servers": s.ops.ListServers()}, nil), nil
+	case adminToolName("catalog"):
+		return adminToolResult(s.ops.Catalog(), nil), nil
+	case adminToolName("health"):
+		return adminToolResult(s.ops.Health(), nil), nil
+	case adminToolName("reload_overrides"):
+		out, err := s.ops.ReloadOverrides()
+		if err != nil {
+			logger("admin").Warn("tool reload_overrides failed", "err", err)
+		} else {
+			logger("admin").Info("tool reload_overrides")
+		}
+		return adminToolResult(out, err), nil
+	case adminToolName("enable_tool"), adminToolName("disable_tool"):
+		if target.Server == "" || target.Tool == "" {
+			return adminToolResult(nil, fmt.Errorf("%w: server and tool are required", errAdminToolArgs)), nil
+		}
+		change := toolToggle{
+			Server:  target.Server,
+			Tool:    target.Tool,
+			Enabled: name == adminToolName("enable_tool"),
+			By:      actor,
+			At:      time.Now().UTC(),
+			Reason:  target.Reason,
+		}
+		err := s.ops.SetToolEnabled(change)
+		msg := "tool " + strings.TrimPrefix(name, adminToolServerName+"_")
+		if err != nil {
+			logger("admin").Warn(msg+" failed", "server", target.Server, "tool", target.Tool, "by", actor, "err", err)
+		} else {
+			logger("admin").Info(msg, "server", target.Server, "tool", target.Tool, "by", actor)
+		}
+		return adminToolResult(map[string]any{"server": change.Server, "tool": change.Tool, "enabled": change.Enabled}, err), nil
+	}
+	return nil, fmt.Errorf("unknown admin tool %q", name)
+}
+
+func adminToolResult(value map[string]any, err error) map[string]any {
+	if err != nil {
+		return map[string]any{
+			"content": []map[string]any{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}
+	}
+	text, marshalErr := json.Marshal(value)
+	if marshalErr != nil {
+		text = []byte(marshalErr.Error())
+	}
+	return map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": string(text)}},
+		"structuredContent": value,
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminToolServerRequiresToken(t *testing.T) {
+	if newAdminToolServer(nil, adminToolOps{}) != nil {
+		t.Fatal("expected no admin tools without admin tokens")
+	}
+	tools := newAdminToolServer([]string{"secret"}, adminToolOps{})
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	if tools.authorized(r) {
+		t.Fatal("request without token must not be authorized")
+	}
+	r.Header.Set("Authorization", "Bearer secret")
+	if !tools.authorized(r) {
+		t.Fatal("admin token should be authorized")
+	}
+	for _, descriptor := range tools.tools() {
+		name, _ := descriptor["name"].(string)
+		if !isAdminToolName(name) {
+			t.Fatalf("tool %q missing %s prefix", name, adminToolServerName)
+		}
+	}
+}
+
+func TestAdminToolServerCall(t *testing.T) {
+	var toggled []string
+	tools := newAdminToolServer([]string{"secret"}, adminToolOps{
+		ListServers: func() []map[string]any {
+			return []map[string]any{{"name": "alpha"}}
+		},
+		SetToolEnabled: func(change toolToggle) error {
+			if change.Server != "alpha" {
+				return errors.New("unknown server")
+			}
+			toggled = append(toggled, change.Server+"/"+change.Tool+" by "+change.By+": "+change.Reason)
+			return nil
+		},
+		ReloadOverrides: func() (map[string]any, error) { return nil, errors.New("boom") },
+		Health:          func() map[string]any { return map[string]any{"ready": true} },
+	})
+
+	result, err := tools.call(adminToolName("list_servers"), "token:abc", nil)
+	if err != nil || result["isError"] == true {
+		t.Fatalf("list_servers: %v %#v", err, result)
+	}
+	if servers := result["structuredContent"].(map[string]any)["servers"].([]map[string]any); len(servers) != 1 {
+		t.Fatalf("servers = %#v", servers)
+	}
+
+	result, _ = tools.call(adminToolName("disable_tool"), "token:abc", []byte(`{"server":"alpha","tool":"echo","reason":"leaks paths"}`))
+	if result["isError"] == true || len(toggled) != 1 || toggled[0] != "alpha/echo by token:abc: leaks paths" {
+		t.Fatalf("disable_tool result=%#v toggled=%v", result, toggled)
+	}
+	if result["structuredContent"].(map[string]any)["enabled"] != false {
+		t.Fatalf("disable_tool should report enabled=false: %#v", result)
+	}
+
+	result, _ = tools.call(adminToolName("enable_tool"), "token:abc", []byte(`{"server":"beta","tool":"echo"}`))
+	if result["isError"] != true {
+		t.Fatalf("expected isError for unknown server: %#v", result)
+	}
+	result, _ = tools.call(adminToolName("enable_tool"), "token:abc", []byte(`{"server":"alpha"}`))
+	if result["isError"] != true {
+		t.Fatalf("expected isError for missing tool arg: %#v", result)
+	}
+	result, _ = tools.call(adminToolName("reload_overrides"), "token:abc", nil)
+	if result["isError"] != true {
+		t.Fatalf("expected reload failure to be reported: %#v", result)
+	}
+	if _, err := tools.call(adminToolName("nope"), "token:abc", nil); err == nil {
+		t.Fatal("expected error for unknown admin tool")
+	}
+}
@@ -0,0 +1,2001 @@
+// Admin API of mcp-proxy over gRPC. It mirrors the HTTP admin API under
+// <baseURL>/admin; every call needs one of mcpProxy.adminTokens as
+// "authorization: Bearer <token>" metadata.
+//
+// Regenerate the Go bindings with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative adminpb/admin.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: adminpb/admin.proto
+
+package adminpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListServersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServersRequest) Reset() {
+	*x = ListServersRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServersRequest) ProtoMessage() {}
+
+func (x *ListServersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServersRequest.ProtoReflect.Descriptor instead.
+func (*ListServersRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type Server struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Connected     bool                   `protobuf:"varint,2,opt,name=connected,proto3" json:"connected,omitempty"`
+	Enabled       bool                   `protobuf:"varint,3,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Transport     string                 `protobuf:"bytes,4,opt,name=transport,proto3" json:"transport,omitempty"`
+	DependsOn     []string               `protobuf:"bytes,5,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	Tools         int32                  `protobuf:"varint,6,opt,name=tools,proto3" json:"tools,omitempty"`
+	Prompts       int32                  `protobuf:"varint,7,opt,name=prompts,proto3" json:"prompts,omitempty"`
+	Resources     int32                  `protobuf:"varint,8,opt,name=resources,proto3" json:"resources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server) Reset() {
+	*x = Server{}
+	mi := &file_adminpb_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server) ProtoMessage() {}
+
+func (x *Server) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server.ProtoReflect.Descriptor instead.
+func (*Server) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Server) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Server) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+func (x *Server) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Server) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *Server) GetDependsOn() []string {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+func (x *Server) GetTools() int32 {
+	if x != nil {
+		return x.Tools
+	}
+	return 0
+}
+
+func (x *Server) GetPrompts() int32 {
+	if x != nil {
+		return x.Prompts
+	}
+	return 0
+}
+
+func (x *Server) GetResources() int32 {
+	if x != nil {
+		return x.Resources
+	}
+	return 0
+}
+
+type ListServersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Servers       []*Server              `protobuf:"bytes,1,rep,name=servers,proto3" json:"servers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServersResponse) Reset() {
+	*x = ListServersResponse{}
+	mi := &file_adminpb_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServersResponse) ProtoMessage() {}
+
+func (x *ListServersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServersResponse.ProtoReflect.Descriptor instead.
+func (*ListServersResponse) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListServersResponse) GetServers() []*Server {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+type GetCatalogRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCatalogRequest) Reset() {
+	*x = GetCatalogRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCatalogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCatalogRequest) ProtoMessage() {}
+
+func (x *GetCatalogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCatalogRequest.ProtoReflect.Descriptor instead.
+func (*GetCatalogRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{3}
+}
+
+type CatalogTool struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Server string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Tool   string                 `protobuf:"bytes,2,opt,name=tool,proto3" json:"tool,omitempty"`
+	// Why a disabled tool is hidden: "runtime", "overrides", or "server".
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	DisabledBy    string                 `protobuf:"bytes,4,opt,name=disabled_by,json=disabledBy,proto3" json:"disabled_by,omitempty"`
+	DisabledAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=disabled_at,json=disabledAt,proto3" json:"disabled_at,omitempty"`
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CatalogTool) Reset() {
+	*x = CatalogTool{}
+	mi := &file_adminpb_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatalogTool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatalogTool) ProtoMessage() {}
+
+func (x *CatalogTool) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CatalogTool.ProtoReflect.Descriptor instead.
+func (*CatalogTool) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CatalogTool) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *CatalogTool) GetTool() string {
+	if x != nil {
+		return x.Tool
+	}
+	return ""
+}
+
+func (x *CatalogTool) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *CatalogTool) GetDisabledBy() string {
+	if x != nil {
+		return x.DisabledBy
+	}
+	return ""
+}
+
+func (x *CatalogTool) GetDisabledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DisabledAt
+	}
+	return nil
+}
+
+func (x *CatalogTool) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type GetCatalogResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       []*CatalogTool         `protobuf:"bytes,1,rep,name=enabled,proto3" json:"enabled,omitempty"`
+	Disabled      []*CatalogTool         `protobuf:"bytes,2,rep,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCatalogResponse) Reset() {
+	*x = GetCatalogResponse{}
+	mi := &file_adminpb_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCatalogResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCatalogResponse) ProtoMessage() {}
+
+func (x *GetCatalogResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCatalogResponse.ProtoReflect.Descriptor instead.
+func (*GetCatalogResponse) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetCatalogResponse) GetEnabled() []*CatalogTool {
+	if x != nil {
+		return x.Enabled
+	}
+	return nil
+}
+
+func (x *GetCatalogResponse) GetDisabled() []*CatalogTool {
+	if x != nil {
+		return x.Disabled
+	}
+	return nil
+}
+
+type ToolToggleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Tool          string                 `protobuf:"bytes,2,opt,name=tool,proto3" json:"tool,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolToggleRequest) Reset() {
+	*x = ToolToggleRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolToggleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolToggleRequest) ProtoMessage() {}
+
+func (x *ToolToggleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolToggleRequest.ProtoReflect.Descriptor instead.
+func (*ToolToggleRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ToolToggleRequest) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *ToolToggleRequest) GetTool() string {
+	if x != nil {
+		return x.Tool
+	}
+	return ""
+}
+
+func (x *ToolToggleRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ToolToggle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Tool          string                 `protobuf:"bytes,2,opt,name=tool,proto3" json:"tool,omitempty"`
+	Enabled       bool                   `protobuf:"varint,3,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	By            string                 `protobuf:"bytes,4,opt,name=by,proto3" json:"by,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=at,proto3" json:"at,omitempty"`
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolToggle) Reset() {
+	*x = ToolToggle{}
+	mi := &file_adminpb_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolToggle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolToggle) ProtoMessage() {}
+
+func (x *ToolToggle) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolToggle.ProtoReflect.Descriptor instead.
+func (*ToolToggle) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ToolToggle) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *ToolToggle) GetTool() string {
+	if x != nil {
+		return x.Tool
+	}
+	return ""
+}
+
+func (x *ToolToggle) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *ToolToggle) GetBy() string {
+	if x != nil {
+		return x.By
+	}
+	return ""
+}
+
+func (x *ToolToggle) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *ToolToggle) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ReloadOverridesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadOverridesRequest) Reset() {
+	*x = ReloadOverridesRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadOverridesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadOverridesRequest) ProtoMessage() {}
+
+func (x *ReloadOverridesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadOverridesRequest.ProtoReflect.Descriptor instead.
+func (*ReloadOverridesRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{8}
+}
+
+type ReloadOverridesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tools         int32                  `protobuf:"varint,1,opt,name=tools,proto3" json:"tools,omitempty"`
+	Warnings      []string               `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadOverridesResponse) Reset() {
+	*x = ReloadOverridesResponse{}
+	mi := &file_adminpb_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadOverridesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadOverridesResponse) ProtoMessage() {}
+
+func (x *ReloadOverridesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadOverridesResponse.ProtoReflect.Descriptor instead.
+func (*ReloadOverridesResponse) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReloadOverridesResponse) GetTools() int32 {
+	if x != nil {
+		return x.Tools
+	}
+	return 0
+}
+
+func (x *ReloadOverridesResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type GetHealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHealthRequest) Reset() {
+	*x = GetHealthRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHealthRequest) ProtoMessage() {}
+
+func (x *GetHealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHealthRequest.ProtoReflect.Descriptor instead.
+func (*GetHealthRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{10}
+}
+
+type Health struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Ready             bool                   `protobuf:"varint,1,opt,name=ready,proto3" json:"ready,omitempty"`
+	ServersConfigured int32                  `protobuf:"varint,2,opt,name=servers_configured,json=serversConfigured,proto3" json:"servers_configured,omitempty"`
+	ServersConnected  int32                  `protobuf:"varint,3,opt,name=servers_connected,json=serversConnected,proto3" json:"servers_connected,omitempty"`
+	ServersMissing    []string               `protobuf:"bytes,4,rep,name=servers_missing,json=serversMissing,proto3" json:"servers_missing,omitempty"`
+	ActiveCalls       int32                  `protobuf:"varint,5,opt,name=active_calls,json=activeCalls,proto3" json:"active_calls,omitempty"`
+	ReadyAt           *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=ready_at,json=readyAt,proto3" json:"ready_at,omitempty"`
+	// The full health document, for fields added after this message.
+	Details       *structpb.Struct `protobuf:"bytes,7,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Health) Reset() {
+	*x = Health{}
+	mi := &file_adminpb_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Health) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Health) ProtoMessage() {}
+
+func (x *Health) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Health.ProtoReflect.Descriptor instead.
+func (*Health) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Health) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *Health) GetServersConfigured() int32 {
+	if x != nil {
+		return x.ServersConfigured
+	}
+	return 0
+}
+
+func (x *Health) GetServersConnected() int32 {
+	if x != nil {
+		return x.ServersConnected
+	}
+	return 0
+}
+
+func (x *Health) GetServersMissing() []string {
+	if x != nil {
+		return x.ServersMissing
+	}
+	return nil
+}
+
+func (x *Health) GetActiveCalls() int32 {
+	if x != nil {
+		return x.ActiveCalls
+	}
+	return 0
+}
+
+func (x *Health) GetReadyAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReadyAt
+	}
+	return nil
+}
+
+func (x *Health) GetDetails() *structpb.Struct {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+type GetUsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Method        string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	Target        string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageRequest) Reset() {
+	*x = GetUsageRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageRequest) ProtoMessage() {}
+
+func (x *GetUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetUsageRequest) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *GetUsageRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *GetUsageRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type Usage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Server           string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Method           string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	Target           string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	TotalCalls       uint64                 `protobuf:"varint,4,opt,name=total_calls,json=totalCalls,proto3" json:"total_calls,omitempty"`
+	TotalErrors      uint64                 `protobuf:"varint,5,opt,name=total_errors,json=totalErrors,proto3" json:"total_errors,omitempty"`
+	Calls            int32                  `protobuf:"varint,6,opt,name=calls,proto3" json:"calls,omitempty"`
+	Errors           int32                  `protobuf:"varint,7,opt,name=errors,proto3" json:"errors,omitempty"`
+	P50Ms            int64                  `protobuf:"varint,8,opt,name=p50_ms,json=p50Ms,proto3" json:"p50_ms,omitempty"`
+	P95Ms            int64                  `protobuf:"varint,9,opt,name=p95_ms,json=p95Ms,proto3" json:"p95_ms,omitempty"`
+	AvgRequestBytes  int32                  `protobuf:"varint,10,opt,name=avg_request_bytes,json=avgRequestBytes,proto3" json:"avg_request_bytes,omitempty"`
+	AvgResponseBytes int32                  `protobuf:"varint,11,opt,name=avg_response_bytes,json=avgResponseBytes,proto3" json:"avg_response_bytes,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_adminpb_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Usage) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *Usage) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Usage) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Usage) GetTotalCalls() uint64 {
+	if x != nil {
+		return x.TotalCalls
+	}
+	return 0
+}
+
+func (x *Usage) GetTotalErrors() uint64 {
+	if x != nil {
+		return x.TotalErrors
+	}
+	return 0
+}
+
+func (x *Usage) GetCalls() int32 {
+	if x != nil {
+		return x.Calls
+	}
+	return 0
+}
+
+func (x *Usage) GetErrors() int32 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *Usage) GetP50Ms() int64 {
+	if x != nil {
+		return x.P50Ms
+	}
+	return 0
+}
+
+func (x *Usage) GetP95Ms() int64 {
+	if x != nil {
+		return x.P95Ms
+	}
+	return 0
+}
+
+func (x *Usage) GetAvgRequestBytes() int32 {
+	if x != nil {
+		return x.AvgRequestBytes
+	}
+	return 0
+}
+
+func (x *Usage) GetAvgResponseBytes() int32 {
+	if x != nil {
+		return x.AvgResponseBytes
+	}
+	return 0
+}
+
+type GetUsageResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Length of the window the calls, errors, and latency figures cover.
+	Window        string   `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`
+	Usage         []*Usage `protobuf:"bytes,2,rep,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageResponse) Reset() {
+	*x = GetUsageResponse{}
+	mi := &file_adminpb_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageResponse) ProtoMessage() {}
+
+func (x *GetUsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageResponse.ProtoReflect.Descriptor instead.
+func (*GetUsageResponse) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetUsageResponse) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *GetUsageResponse) GetUsage() []*Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type GetReadOnlyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReadOnlyRequest) Reset() {
+	*x = GetReadOnlyRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReadOnlyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReadOnlyRequest) ProtoMessage() {}
+
+func (x *GetReadOnlyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReadOnlyRequest.ProtoReflect.Descriptor instead.
+func (*GetReadOnlyRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{15}
+}
+
+type SetReadOnlyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetReadOnlyRequest) Reset() {
+	*x = SetReadOnlyRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetReadOnlyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetReadOnlyRequest) ProtoMessage() {}
+
+func (x *SetReadOnlyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetReadOnlyRequest.ProtoReflect.Descriptor instead.
+func (*SetReadOnlyRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetReadOnlyRequest) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *SetReadOnlyRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ReadOnlyState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	By            string                 `protobuf:"bytes,2,opt,name=by,proto3" json:"by,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadOnlyState) Reset() {
+	*x = ReadOnlyState{}
+	mi := &file_adminpb_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadOnlyState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadOnlyState) ProtoMessage() {}
+
+func (x *ReadOnlyState) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadOnlyState.ProtoReflect.Descriptor instead.
+func (*ReadOnlyState) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReadOnlyState) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *ReadOnlyState) GetBy() string {
+	if x != nil {
+		return x.By
+	}
+	return ""
+}
+
+func (x *ReadOnlyState) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *ReadOnlyState) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{18}
+}
+
+type Session struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Transport     string                 `protobuf:"bytes,2,opt,name=transport,proto3" json:"transport,omitempty"`
+	Caller        string                 `protobuf:"bytes,3,opt,name=caller,proto3" json:"caller,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LastSeen      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Streams       int32                  `protobuf:"varint,6,opt,name=streams,proto3" json:"streams,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_adminpb_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Session) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Session) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *Session) GetCaller() string {
+	if x != nil {
+		return x.Caller
+	}
+	return ""
+}
+
+func (x *Session) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Session) GetLastSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *Session) GetStreams() int32 {
+	if x != nil {
+		return x.Streams
+	}
+	return 0
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*Session             `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_adminpb_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type TerminateSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminateSessionRequest) Reset() {
+	*x = TerminateSessionRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminateSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminateSessionRequest) ProtoMessage() {}
+
+func (x *TerminateSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminateSessionRequest.ProtoReflect.Descriptor instead.
+func (*TerminateSessionRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *TerminateSessionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type TerminateSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminateSessionResponse) Reset() {
+	*x = TerminateSessionResponse{}
+	mi := &file_adminpb_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminateSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminateSessionResponse) ProtoMessage() {}
+
+func (x *TerminateSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminateSessionResponse.ProtoReflect.Descriptor instead.
+func (*TerminateSessionResponse) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{22}
+}
+
+type ApplyServerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// The server's entry in mcpServers, in the config file's JSON shape.
+	Config        *structpb.Struct `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyServerRequest) Reset() {
+	*x = ApplyServerRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyServerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyServerRequest) ProtoMessage() {}
+
+func (x *ApplyServerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyServerRequest.ProtoReflect.Descriptor instead.
+func (*ApplyServerRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ApplyServerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ApplyServerRequest) GetConfig() *structpb.Struct {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type RemoveServerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveServerRequest) Reset() {
+	*x = RemoveServerRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveServerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveServerRequest) ProtoMessage() {}
+
+func (x *RemoveServerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveServerRequest.ProtoReflect.Descriptor instead.
+func (*RemoveServerRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *RemoveServerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ApplyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The complete mcpServers map; servers not listed are removed.
+	McpServers *structpb.Struct `protobuf:"bytes,1,opt,name=mcp_servers,json=mcpServers,proto3" json:"mcp_servers,omitempty"`
+	// Replaces manifest.toolOverrides when set; left alone when unset.
+	ToolOverrides *structpb.Struct `protobuf:"bytes,2,opt,name=tool_overrides,json=toolOverrides,proto3" json:"tool_overrides,omitempty"`
+	DryRun        bool             `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyRequest) Reset() {
+	*x = ApplyRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyRequest) ProtoMessage() {}
+
+func (x *ApplyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyRequest.ProtoReflect.Descriptor instead.
+func (*ApplyRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ApplyRequest) GetMcpServers() *structpb.Struct {
+	if x != nil {
+		return x.McpServers
+	}
+	return nil
+}
+
+func (x *ApplyRequest) GetToolOverrides() *structpb.Struct {
+	if x != nil {
+		return x.ToolOverrides
+	}
+	return nil
+}
+
+func (x *ApplyRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type PatchToolOverridesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Overrides by tool name; a null value removes that override.
+	ToolOverrides *structpb.Struct `protobuf:"bytes,1,opt,name=tool_overrides,json=toolOverrides,proto3" json:"tool_overrides,omitempty"`
+	DryRun        bool             `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PatchToolOverridesRequest) Reset() {
+	*x = PatchToolOverridesRequest{}
+	mi := &file_adminpb_admin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchToolOverridesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchToolOverridesRequest) ProtoMessage() {}
+
+func (x *PatchToolOverridesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchToolOverridesRequest.ProtoReflect.Descriptor instead.
+func (*PatchToolOverridesRequest) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PatchToolOverridesRequest) GetToolOverrides() *structpb.Struct {
+	if x != nil {
+		return x.ToolOverrides
+	}
+	return nil
+}
+
+func (x *PatchToolOverridesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ApplyPlan struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Add                  []string               `protobuf:"bytes,1,rep,name=add,proto3" json:"add,omitempty"`
+	Change               []string               `protobuf:"bytes,2,rep,name=change,proto3" json:"change,omitempty"`
+	Remove               []string               `protobuf:"bytes,3,rep,name=remove,proto3" json:"remove,omitempty"`
+	Unchanged            []string               `protobuf:"bytes,4,rep,name=unchanged,proto3" json:"unchanged,omitempty"`
+	ToolOverridesChanged bool                   `protobuf:"varint,5,opt,name=tool_overrides_changed,json=toolOverridesChanged,proto3" json:"tool_overrides_changed,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ApplyPlan) Reset() {
+	*x = ApplyPlan{}
+	mi := &file_adminpb_admin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyPlan) ProtoMessage() {}
+
+func (x *ApplyPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyPlan.ProtoReflect.Descriptor instead.
+func (*ApplyPlan) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ApplyPlan) GetAdd() []string {
+	if x != nil {
+		return x.Add
+	}
+	return nil
+}
+
+func (x *ApplyPlan) GetChange() []string {
+	if x != nil {
+		return x.Change
+	}
+	return nil
+}
+
+func (x *ApplyPlan) GetRemove() []string {
+	if x != nil {
+		return x.Remove
+	}
+	return nil
+}
+
+func (x *ApplyPlan) GetUnchanged() []string {
+	if x != nil {
+		return x.Unchanged
+	}
+	return nil
+}
+
+func (x *ApplyPlan) GetToolOverridesChanged() bool {
+	if x != nil {
+		return x.ToolOverridesChanged
+	}
+	return false
+}
+
+// The plan and outcome of an apply. A rolled-back apply is an error whose
+// message names the servers that failed to connect.
+type ApplyResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plan          *ApplyPlan             `protobuf:"bytes,1,opt,name=plan,proto3" json:"plan,omitempty"`
+	DryRun        bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Applied       bool                   `protobuf:"varint,3,opt,name=applied,proto3" json:"applied,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyResult) Reset() {
+	*x = ApplyResult{}
+	mi := &file_adminpb_admin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyResult) ProtoMessage() {}
+
+func (x *ApplyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_adminpb_admin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyResult.ProtoReflect.Descriptor instead.
+func (*ApplyResult) Descriptor() ([]byte, []int) {
+	return file_adminpb_admin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ApplyResult) GetPlan() *ApplyPlan {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
+func (x *ApplyResult) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *ApplyResult) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+var File_adminpb_admin_proto protoreflect.FileDescriptor
+
+const file_adminpb_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x13adminpb/admin.proto\x12\x0fstelae.admin.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x14\n" +
+	"\x12ListServersRequest\"\xdf\x01\n" +
+	"\x06Server\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tconnected\x18\x02 \x01(\bR\tconnected\x12\x18\n" +
+	"\aenabled\x18\x03 \x01(\bR\aenabled\x12\x1c\n" +
+	"\ttransport\x18\x04 \x01(\tR\ttransport\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\x05 \x03(\tR\tdependsOn\x12\x14\n" +
+	"\x05tools\x18\x06 \x01(\x05R\x05tools\x12\x18\n" +
+	"\aprompts\x18\a \x01(\x05R\aprompts\x12\x1c\n" +
+	"\tresources\x18\b \x01(\x05R\tresources\"H\n" +
+	"\x13ListServersResponse\x121\n" +
+	"\aservers\x18\x01 \x03(\v2\x17.stelae.admin.v1.ServerR\aservers\"\x13\n" +
+	"\x11GetCatalogRequest\"\xc7\x01\n" +
+	"\vCatalogTool\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04tool\x18\x02 \x01(\tR\x04tool\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x1f\n" +
+	"\vdisabled_by\x18\x04 \x01(\tR\n" +
+	"disabledBy\x12;\n" +
+	"\vdisabled_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"disabledAt\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\"\x86\x01\n" +
+	"\x12GetCatalogResponse\x126\n" +
+	"\aenabled\x18\x01 \x03(\v2\x1c.stelae.admin.v1.CatalogToolR\aenabled\x128\n" +
+	"\bdisabled\x18\x02 \x03(\v2\x1c.stelae.admin.v1.CatalogToolR\bdisabled\"W\n" +
+	"\x11ToolToggleRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04tool\x18\x02 \x01(\tR\x04tool\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xa6\x01\n" +
+	"\n" +
+	"ToolToggle\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x12\n" +
+	"\x04tool\x18\x02 \x01(\tR\x04tool\x12\x18\n" +
+	"\aenabled\x18\x03 \x01(\bR\aenabled\x12\x0e\n" +
+	"\x02by\x18\x04 \x01(\tR\x02by\x12*\n" +
+	"\x02at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\"\x18\n" +
+	"\x16ReloadOverridesRequest\"K\n" +
+	"\x17ReloadOverridesResponse\x12\x14\n" +
+	"\x05tools\x18\x01 \x01(\x05R\x05tools\x12\x1a\n" +
+	"\bwarnings\x18\x02 \x03(\tR\bwarnings\"\x12\n" +
+	"\x10GetHealthRequest\"\xb0\x02\n" +
+	"\x06Health\x12\x14\n" +
+	"\x05ready\x18\x01 \x01(\bR\x05ready\x12-\n" +
+	"\x12servers_configured\x18\x02 \x01(\x05R\x11serversConfigured\x12+\n" +
+	"\x11servers_connected\x18\x03 \x01(\x05R\x10serversConnected\x12'\n" +
+	"\x0fservers_missing\x18\x04 \x03(\tR\x0eserversMissing\x12!\n" +
+	"\factive_calls\x18\x05 \x01(\x05R\vactiveCalls\x125\n" +
+	"\bready_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\areadyAt\x121\n" +
+	"\adetails\x18\a \x01(\v2\x17.google.protobuf.StructR\adetails\"Y\n" +
+	"\x0fGetUsageRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\"\xc9\x02\n" +
+	"\x05Usage\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\x12\x1f\n" +
+	"\vtotal_calls\x18\x04 \x01(\x04R\n" +
+	"totalCalls\x12!\n" +
+	"\ftotal_errors\x18\x05 \x01(\x04R\vtotalErrors\x12\x14\n" +
+	"\x05calls\x18\x06 \x01(\x05R\x05calls\x12\x16\n" +
+	"\x06errors\x18\a \x01(\x05R\x06errors\x12\x15\n" +
+	"\x06p50_ms\x18\b \x01(\x03R\x05p50Ms\x12\x15\n" +
+	"\x06p95_ms\x18\t \x01(\x03R\x05p95Ms\x12*\n" +
+	"\x11avg_request_bytes\x18\n" +
+	" \x01(\x05R\x0favgRequestBytes\x12,\n" +
+	"\x12avg_response_bytes\x18\v \x01(\x05R\x10avgResponseBytes\"X\n" +
+	"\x10GetUsageResponse\x12\x16\n" +
+	"\x06window\x18\x01 \x01(\tR\x06window\x12,\n" +
+	"\x05usage\x18\x02 \x03(\v2\x16.stelae.admin.v1.UsageR\x05usage\"\x14\n" +
+	"\x12GetReadOnlyRequest\"F\n" +
+	"\x12SetReadOnlyRequest\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"}\n" +
+	"\rReadOnlyState\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x0e\n" +
+	"\x02by\x18\x02 \x01(\tR\x02by\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x15\n" +
+	"\x13ListSessionsRequest\"\xdd\x01\n" +
+	"\aSession\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\ttransport\x18\x02 \x01(\tR\ttransport\x12\x16\n" +
+	"\x06caller\x18\x03 \x01(\tR\x06caller\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x127\n" +
+	"\tlast_seen\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x12\x18\n" +
+	"\astreams\x18\x06 \x01(\x05R\astreams\"L\n" +
+	"\x14ListSessionsResponse\x124\n" +
+	"\bsessions\x18\x01 \x03(\v2\x18.stelae.admin.v1.SessionR\bsessions\")\n" +
+	"\x17TerminateSessionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1a\n" +
+	"\x18TerminateSessionResponse\"Y\n" +
+	"\x12ApplyServerRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12/\n" +
+	"\x06config\x18\x02 \x01(\v2\x17.google.protobuf.StructR\x06config\")\n" +
+	"\x13RemoveServerRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xa1\x01\n" +
+	"\fApplyRequest\x128\n" +
+	"\vmcp_servers\x18\x01 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"mcpServers\x12>\n" +
+	"\x0etool_overrides\x18\x02 \x01(\v2\x17.google.protobuf.StructR\rtoolOverrides\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"t\n" +
+	"\x19PatchToolOverridesRequest\x12>\n" +
+	"\x0etool_overrides\x18\x01 \x01(\v2\x17.google.protobuf.StructR\rtoolOverrides\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\"\xa1\x01\n" +
+	"\tApplyPlan\x12\x10\n" +
+	"\x03add\x18\x01 \x03(\tR\x03add\x12\x16\n" +
+	"\x06change\x18\x02 \x03(\tR\x06change\x12\x16\n" +
+	"\x06remove\x18\x03 \x03(\tR\x06remove\x12\x1c\n" +
+	"\tunchanged\x18\x04 \x03(\tR\tunchanged\x124\n" +
+	"\x16tool_overrides_changed\x18\x05 \x01(\bR\x14toolOverridesChanged\"p\n" +
+	"\vApplyResult\x12.\n" +
+	"\x04plan\x18\x01 \x01(\v2\x1a.stelae.admin.v1.ApplyPlanR\x04plan\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\x12\x18\n" +
+	"\aapplied\x18\x03 \x01(\bR\aapplied2\x99\n" +
+	"\n" +
+	"\fAdminService\x12X\n" +
+	"\vListServers\x12#.stelae.admin.v1.ListServersRequest\x1a$.stelae.admin.v1.ListServersResponse\x12U\n" +
+	"\n" +
+	"GetCatalog\x12\".stelae.admin.v1.GetCatalogRequest\x1a#.stelae.admin.v1.GetCatalogResponse\x12N\n" +
+	"\vDisableTool\x12\".stelae.admin.v1.ToolToggleRequest\x1a\x1b.stelae.admin.v1.ToolToggle\x12N\n" +
+	"\vRestoreTool\x12\".stelae.admin.v1.ToolToggleRequest\x1a\x1b.stelae.admin.v1.ToolToggle\x12d\n" +
+	"\x0fReloadOverrides\x12'.stelae.admin.v1.ReloadOverridesRequest\x1a(.stelae.admin.v1.ReloadOverridesResponse\x12G\n" +
+	"\tGetHealth\x12!.stelae.admin.v1.GetHealthRequest\x1a\x17.stelae.admin.v1.Health\x12O\n" +
+	"\bGetUsage\x12 .stelae.admin.v1.GetUsageRequest\x1a!.stelae.admin.v1.GetUsageResponse\x12R\n" +
+	"\vGetReadOnly\x12#.stelae.admin.v1.GetReadOnlyRequest\x1a\x1e.stelae.admin.v1.ReadOnlyState\x12R\n" +
+	"\vSetReadOnly\x12#.stelae.admin.v1.SetReadOnlyRequest\x1a\x1e.stelae.admin.v1.ReadOnlyState\x12[\n" +
+	"\fListSessions\x12$.stelae.admin.v1.ListSessionsRequest\x1a%.stelae.admin.v1.ListSessionsResponse\x12g\n" +
+	"\x10TerminateSession\x12(.stelae.admin.v1.TerminateSessionRequest\x1a).stelae.admin.v1.TerminateSessionResponse\x12P\n" +
+	"\vApplyServer\x12#.stelae.admin.v1.ApplyServerRequest\x1a\x1c.stelae.admin.v1.ApplyResult\x12R\n" +
+	"\fRemoveServer\x12$.stelae.admin.v1.RemoveServerRequest\x1a\x1c.stelae.admin.v1.ApplyResult\x12D\n" +
+	"\x05Apply\x12\x1d.stelae.admin.v1.ApplyRequest\x1a\x1c.stelae.admin.v1.ApplyResult\x12^\n" +
+	"\x12PatchToolOverrides\x12*.stelae.admin.v1.PatchToolOverridesRequest\x1a\x1c.stelae.admin.v1.ApplyResultB%Z#github.com/TBXark/mcp-proxy/adminpbb\x06proto3"
+
+var (
+	file_adminpb_admin_proto_rawDescOnce sync.Once
+	file_adminpb_admin_proto_rawDescData []byte
+)
+
+func file_adminpb_admin_proto_rawDescGZIP() []byte {
+	file_adminpb_admin_proto_rawDescOnce.Do(func() {
+		file_adminpb_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_adminpb_admin_proto_rawDesc), len(file_adminpb_admin_proto_rawDesc)))
+	})
+	return file_adminpb_admin_proto_rawDescData
+}
+
+var file_adminpb_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_adminpb_admin_proto_goTypes = []any{
+	(*ListServersRequest)(nil),        // 0: stelae.admin.v1.ListServersRequest
+	(*Server)(nil),                    // 1: stelae.admin.v1.Server
+	(*ListServersResponse)(nil),       // 2: stelae.admin.v1.ListServersResponse
+	(*GetCatalogRequest)(nil),         // 3: stelae.admin.v1.GetCatalogRequest
+	(*CatalogTool)(nil),               // 4: stelae.admin.v1.CatalogTool
+	(*GetCatalogResponse)(nil),        // 5: stelae.admin.v1.GetCatalogResponse
+	(*ToolToggleRequest)(nil),         // 6: stelae.admin.v1.ToolToggleRequest
+	(*ToolToggle)(nil),                // 7: stelae.admin.v1.ToolToggle
+	(*ReloadOverridesRequest)(nil),    // 8: stelae.admin.v1.ReloadOverridesRequest
+	(*ReloadOverridesResponse)(nil),   // 9: stelae.admin.v1.ReloadOverridesResponse
+	(*GetHealthRequest)(nil),          // 10: stelae.admin.v1.GetHealthRequest
+	(*Health)(nil),                    // 11: stelae.admin.v1.Health
+	(*GetUsageRequest)(nil),           // 12: stelae.admin.v1.GetUsageRequest
+	(*Usage)(nil),                     // 13: stelae.admin.v1.Usage
+	(*GetUsageResponse)(nil),          // 14: stelae.admin.v1.GetUsageResponse
+	(*GetReadOnlyRequest)(nil),        // 15: stelae.admin.v1.GetReadOnlyRequest
+	(*SetReadOnlyRequest)(nil),        // 16: stelae.admin.v1.SetReadOnlyRequest
+	(*ReadOnlyState)(nil),             // 17: stelae.admin.v1.ReadOnlyState
+	(*ListSessionsRequest)(nil),       // 18: stelae.admin.v1.ListSessionsRequest
+	(*Session)(nil),                   // 19: stelae.admin.v1.Session
+	(*ListSessionsResponse)(nil),      // 20: stelae.admin.v1.ListSessionsResponse
+	(*TerminateSessionRequest)(nil),   // 21: stelae.admin.v1.TerminateSessionRequest
+	(*TerminateSessionResponse)(nil),  // 22: stelae.admin.v1.TerminateSessionResponse
+	(*ApplyServerRequest)(nil),        // 23: stelae.admin.v1.ApplyServerRequest
+	(*RemoveServerRequest)(nil),       // 24: stelae.admin.v1.RemoveServerRequest
+	(*ApplyRequest)(nil),              // 25: stelae.admin.v1.ApplyRequest
+	(*PatchToolOverridesRequest)(nil), // 26: stelae.admin.v1.PatchToolOverridesRequest
+	(*ApplyPlan)(nil),                 // 27: stelae.admin.v1.ApplyPlan
+	(*ApplyResult)(nil),               // 28: stelae.admin.v1.ApplyResult
+	(*timestamppb.Timestamp)(nil),     // 29: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),           // 30: google.protobuf.Struct
+}
+var file_adminpb_admin_proto_depIdxs = []int32{
+	1,  // 0: stelae.admin.v1.ListServersResponse.servers:type_name -> stelae.admin.v1.Server
+	29, // 1: stelae.admin.v1.CatalogTool.disabled_at:type_name -> google.protobuf.Timestamp
+	4,  // 2: stelae.admin.v1.GetCatalogResponse.enabled:type_name -> stelae.admin.v1.CatalogTool
+	4,  // 3: stelae.admin.v1.GetCatalogResponse.disabled:type_name -> stelae.admin.v1.CatalogTool
+	29, // 4: stelae.admin.v1.ToolToggle.at:type_name -> google.protobuf.Timestamp
+	29, // 5: stelae.admin.v1.Health.ready_at:type_name -> google.protobuf.Timestamp
+	30, // 6: stelae.admin.v1.Health.details:type_name -> google.protobuf.Struct
+	13, // 7: stelae.admin.v1.GetUsageResponse.usage:type_name -> stelae.admin.v1.Usage
+	29, // 8: stelae.admin.v1.ReadOnlyState.at:type_name -> google.protobuf.Timestamp
+	29, // 9: stelae.admin.v1.Session.created_at:type_name -> google.protobuf.Timestamp
+	29, // 10: stelae.admin.v1.Session.last_seen:type_name -> google.protobuf.Timestamp
+	19, // 11: stelae.admin.v1.ListSessionsResponse.sessions:type_name -> stelae.admin.v1.Session
+	30, // 12: stelae.admin.v1.ApplyServerRequest.config:type_name -> google.protobuf.Struct
+	30, // 13: stelae.admin.v1.ApplyRequest.mcp_servers:type_name -> google.protobuf.Struct
+	30, // 14: stelae.admin.v1.ApplyRequest.tool_overrides:type_name -> google.protobuf.Struct
+	30, // 15: stelae.admin.v1.PatchToolOverridesRequest.tool_overrides:type_name -> google.protobuf.Struct
+	27, // 16: stelae.admin.v1.ApplyResult.plan:type_name -> stelae.admin.v1.ApplyPlan
+	0,  // 17: stelae.admin.v1.AdminService.ListServers:input_type -> stelae.admin.v1.ListServersRequest
+	3,  // 18: stelae.admin.v1.AdminService.GetCatalog:input_type -> stelae.admin.v1.GetCatalogRequest
+	6,  // 19: stelae.admin.v1.AdminService.DisableTool:input_type -> stelae.admin.v1.ToolToggleRequest
+	6,  // 20: stelae.admin.v1.AdminService.RestoreTool:input_type -> stelae.admin.v1.ToolToggleRequest
+	8,  // 21: stelae.admin.v1.AdminService.ReloadOverrides:input_type -> stelae.admin.v1.ReloadOverridesRequest
+	10, // 22: stelae.admin.v1.AdminService.GetHealth:input_type -> stelae.admin.v1.GetHealthRequest
+	12, // 23: stelae.admin.v1.AdminService.GetUsage:input_type -> stelae.admin.v1.GetUsageRequest
+	15, // 24: stelae.admin.v1.AdminService.GetReadOnly:input_type -> stelae.admin.v1.GetReadOnlyRequest
+	16, // 25: stelae.admin.v1.AdminService.SetReadOnly:input_type -> stelae.admin.v1.SetReadOnlyRequest
+	18, // 26: stelae.admin.v1.AdminService.ListSessions:input_type -> stelae.admin.v1.ListSessionsRequest
+	21, // 27: stelae.admin.v1.AdminService.TerminateSession:input_type -> stelae.admin.v1.TerminateSessionRequest
+	23, // 28: stelae.admin.v1.AdminService.ApplyServer:input_type -> stelae.admin.v1.ApplyServerRequest
+	24, // 29: stelae.admin.v1.AdminService.RemoveServer:input_type -> stelae.admin.v1.RemoveServerRequest
+	25, // 30: stelae.admin.v1.AdminService.Apply:input_type -> stelae.admin.v1.ApplyRequest
+	26, // 31: stelae.admin.v1.AdminService.PatchToolOverrides:input_type -> stelae.admin.v1.PatchToolOverridesRequest
+	2,  // 32: stelae.admin.v1.AdminService.ListServers:output_type -> stelae.admin.v1.ListServersResponse
+	5,  // 33: stelae.admin.v1.AdminService.GetCatalog:output_type -> stelae.admin.v1.GetCatalogResponse
+	7,  // 34: stelae.admin.v1.AdminService.DisableTool:output_type -> stelae.admin.v1.ToolToggle
+	7,  // 35: stelae.admin.v1.AdminService.RestoreTool:output_type -> stelae.admin.v1.ToolToggle
+	9,  // 36: stelae.admin.v1.AdminService.ReloadOverrides:output_type -> stelae.admin.v1.ReloadOverridesResponse
+	11, // 37: stelae.admin.v1.AdminService.GetHealth:output_type -> stelae.admin.v1.Health
+	14, // 38: stelae.admin.v1.AdminService.GetUsage:output_type -> stelae.admin.v1.GetUsageResponse
+	17, // 39: stelae.admin.v1.AdminService.GetReadOnly:output_type -> stelae.admin.v1.ReadOnlyState
+	17, // 40: stelae.admin.v1.AdminService.SetReadOnly:output_type -> stelae.admin.v1.ReadOnlyState
+	20, // 41: stelae.admin.v1.AdminService.ListSessions:output_type -> stelae.admin.v1.ListSessionsResponse
+	22, // 42: stelae.admin.v1.AdminService.TerminateSession:output_type -> stelae.admin.v1.TerminateSessionResponse
+	28, // 43: stelae.admin.v1.AdminService.ApplyServer:output_type -> stelae.admin.v1.ApplyResult
+	28, // 44: stelae.admin.v1.AdminService.RemoveServer:output_type -> stelae.admin.v1.ApplyResult
+	28, // 45: stelae.admin.v1.AdminService.Apply:output_type -> stelae.admin.v1.ApplyResult
+	28, // 46: stelae.admin.v1.AdminService.PatchToolOverrides:output_type -> stelae.admin.v1.ApplyResult
+	32, // [32:47] is the sub-list for method output_type
+	17, // [17:32] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_adminpb_admin_proto_init() }
+func file_adminpb_admin_proto_init() {
+	if File_adminpb_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_adminpb_admin_proto_rawDesc), len(file_adminpb_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   29,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_adminpb_admin_proto_goTypes,
+		DependencyIndexes: file_adminpb_admin_proto_depIdxs,
+		MessageInfos:      file_adminpb_admin_proto_msgTypes,
+	}.Build()
+	File_adminpb_admin_proto = out.File
+	file_adminpb_admin_proto_goTypes = nil
+	file_adminpb_admin_proto_depIdxs = nil
+}
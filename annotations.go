@@ -36,3 +36,48 @@ func normalizeToolAnnotations(tool mcp.Tool) map[string]any {
 
 	return annotations
 }
+
+// toolHints are the effective behaviour hints of a tool after overrides.
+type toolHints struct {
+	ReadOnly    bool
+	Destructive bool
+	Idempotent  bool
+	OpenWorld   bool
+}
+
+// resolveToolHints looks up toolName on srv and applies overrides so policy
+// code sees the same annotations clients are shown. Destructive follows the
+// MCP defaults rather than the listing, which shows an unset hint as false:
+// a tool that is not read-only is destructive unless its destructiveHint is
+// false.
+func resolveToolHints(srv *Server, overrides *ToolOverrideSet, toolName string) (toolHints, bool) {
+	if srv == nil {
+		return toolHints{}, false
+	}
+	for _, tool := range srv.tools {
+		if tool.Name != toolName {
+			continue
+		}
+		descriptor := toolDescriptorFromServer(tool)
+		annotations, _ := descriptor["annotations"].(map[string]any)
+		if tool.Annotations.DestructiveHint == nil {
+			// unset, so that an override can still set it
+			delete(annotations, "destructiveHint")
+		}
+		descriptor = applyToolOverride(toolName, descriptor, overrides)
+		annotations, _ = descriptor["annotations"].(map[string]any)
+		hint := func(key string) bool {
+			v, _ := toBool(annotations[key])
+			return v
+		}
+		hints := toolHints{
+			ReadOnly:   hint("readOnlyHint"),
+			Idempotent: hint("idempotentHint"),
+			OpenWorld:  hint("openWorldHint"),
+		}
+		destructive, set := toBool(annotations["destructiveHint"])
+		hints.Destructive = !hints.ReadOnly && (destructive || !set)
+		return hints, true
+	}
+	return toolHints{}, false
+}
@@ -47,3 +47,22 @@ func TestNormalizeToolAnnotationsPreservesExisting(t *testing.T) {
 		t.Fatalf("expected destructiveHint=false, got %v", annotations["destructiveHint"])
 	}
 }
+
+func TestResolveToolHintsDestructiveDefault(t *testing.T) {
+	srv := &Server{name: "fs", tools: []mcp.Tool{
+		mcp.NewTool("unannotated"),
+		mcp.NewTool("read", mcp.WithReadOnlyHintAnnotation(true)),
+		mcp.NewTool("append", mcp.WithDestructiveHintAnnotation(false)),
+		mcp.NewTool("overridden"),
+	}}
+	no := false
+	overrides := &ToolOverrideSet{ToolOverrides: map[string]*ToolOverrideConfig{
+		"overridden": {Annotations: &AnnotationOverrideConfig{DestructiveHint: &no}},
+	}}
+	for tool, want := range map[string]bool{"unannotated": true, "read": false, "append": false, "overridden": false} {
+		hints, ok := resolveToolHints(srv, overrides, tool)
+		if !ok || hints.Destructive != want {
+			t.Errorf("%s: destructive = %t, want %t", tool, hints.Destructive, want)
+		}
+	}
+}
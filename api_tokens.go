@@ -0,0 +1,397 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiTokenPrefix starts every managed token so leaked ones are easy to
+// recognize.
+const apiTokenPrefix = "stk_"
+
+// APITokensConfig enables proxy API tokens managed through the admin API
+// and kept under the state home. Managed tokens are accepted wherever
+// authTokens are; Require makes the facade and every per-server route
+// require a token even without authTokens.
+type APITokensConfig struct {
+	Require bool `json:"require,omitempty"`
+}
+
+// apiToken is a managed token as persisted. Only the SHA-256 of the secret
+// is kept; the secret is shown once, when it is issued.
+type apiToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"hash"`
+	Servers   []string   `json:"servers,omitempty"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	// PreviousHash is the secret replaced by the latest rotation, still
+	// valid until PreviousExpiresAt.
+	PreviousHash      string     `json:"previousHash,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previousExpiresAt,omitempty"`
+}
+
+// apiTokenView is a managed token as the admin API shows it.
+type apiTokenView struct {
+	ID                string     `json:"id"`
+	Name              string     `json:"name,omitempty"`
+	Prefix            string     `json:"prefix"`
+	Servers           []string   `json:"servers,omitempty"`
+	CreatedBy         string     `json:"createdBy"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+	RotatedAt         *time.Time `json:"rotatedAt,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previousExpiresAt,omitempty"`
+}
+
+func (t *apiToken) view() apiTokenView {
+	return apiTokenView{
+		ID:                t.ID,
+		Name:              t.Name,
+		Prefix:            t.Prefix,
+		Servers:           t.Servers,
+		CreatedBy:         t.CreatedBy,
+		CreatedAt:         t.CreatedAt,
+		ExpiresAt:         t.ExpiresAt,
+		RotatedAt:         t.RotatedAt,
+		PreviousExpiresAt: t.PreviousExpiresAt,
+	}
+}
+
+func (t *apiToken) expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// matches reports whether hash is that of the current secret, or of the
+// previous one within the rotation grace period.
+func (t *apiToken) matches(hash string, now time.Time) bool {
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(t.Hash)) == 1 {
+		return true
+	}
+	return t.PreviousHash != "" && t.PreviousExpiresAt != nil && now.Before(*t.PreviousExpiresAt) &&
+		subtle.ConstantTimeCompare([]byte(hash), []byte(t.PreviousHash)) == 1
+}
+
+// apiTokenStore keeps the managed tokens and writes every change to path.
+type apiTokenStore struct {
+	require bool
+	path    string
+	now     func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*apiToken
+}
+
+// newAPITokenStore loads the tokens kept at path; a missing file is an
+// empty store.
+func newAPITokenStore(conf *APITokensConfig, path string) (*apiTokenStore, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	s := &apiTokenStore{require: conf.Require, path: path, now: time.Now, tokens: make(map[string]*apiToken)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("api tokens: %w", err)
+	}
+	var list []*apiToken
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("api tokens: %s: %w", path, err)
+	}
+	for _, t := range list {
+		s.tokens[t.ID] = t
+	}
+	return s, nil
+}
+
+// required reports whether routes must require a token.
+func (s *apiTokenStore) required() bool {
+	return s != nil && s.require
+}
+
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAPITokenSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// create issues a token for servers (all when empty) that expires after
+// ttl (never when zero) and returns it with its secret.
+func (s *apiTokenStore) create(name string, servers []string, ttl time.Duration, by string) (apiTokenView, string, error) {
+	secret, err := newAPITokenSecret()
+	if err != nil {
+		return apiTokenView{}, "", err
+	}
+	now := s.now().UTC()
+	t := &apiToken{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Prefix:    secret[:len(apiTokenPrefix)+6],
+		Hash:      hashAPIToken(secret),
+		Servers:   servers,
+		CreatedBy: by,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		t.ExpiresAt = &expires
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[t.ID] = t
+	if err := s.save(now); err != nil {
+		delete(s.tokens, t.ID)
+		return apiTokenView{}, "", err
+	}
+	return t.view(), secret, nil
+}
+
+// rotate gives token id a new secret. The old one stays valid for grace.
+func (s *apiTokenStore) rotate(id string, grace time.Duration) (apiTokenView, string, bool, error) {
+	secret, err := newAPITokenSecret()
+	if err != nil {
+		return apiTokenView{}, "", false, err
+	}
+	now := s.now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[id]
+	if !ok || t.expired(now) {
+		return apiTokenView{}, "", false, nil
+	}
+	prev := *t
+	t.PreviousHash, t.PreviousExpiresAt = "", nil
+	if grace > 0 {
+		until := now.Add(grace)
+		t.PreviousHash, t.PreviousExpiresAt = t.Hash, &until
+	}
+	t.Hash = hashAPIToken(secret)
+	t.Prefix = secret[:len(apiTokenPrefix)+6]
+	t.RotatedAt = &now
+	if err := s.save(now); err != nil {
+		*t = prev
+		return apiTokenView{}, "", true, err
+	}
+	return t.view(), secret, true, nil
+}
+
+// revoke deletes token id at once.
+func (s *apiTokenStore) revoke(id string) (apiTokenView, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[id]
+	if !ok {
+		return apiTokenView{}, false, nil
+	}
+	delete(s.tokens, id)
+	if err := s.save(s.now()); err != nil {
+		s.tokens[id] = t
+		return apiTokenView{}, true, err
+	}
+	return t.view(), true, nil
+}
+
+// list returns the unexpired tokens, newest first.
+func (s *apiTokenStore) list() []apiTokenView {
+	now := s.now()
+	s.mu.Lock()
+	out := make([]apiTokenView, 0, len(s.tokens))
+	for _, t := range s.tokens {
+		if !t.expired(now) {
+			out = append(out, t.view())
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// accepts reports whether secret is a live token for server. The facade,
+// server "", only accepts tokens not limited to servers.
+func (s *apiTokenStore) accepts(secret, server string) bool {
+	if s == nil || len(secret) <= len(apiTokenPrefix) || secret[:len(apiTokenPrefix)] != apiTokenPrefix {
+		return false
+	}
+	hash := hashAPIToken(secret)
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tokens {
+		if t.expired(now) || !t.matches(hash, now) {
+			continue
+		}
+		return len(t.Servers) == 0 || (server != "" && slices.Contains(t.Servers, server))
+	}
+	return false
+}
+
+// save writes the unexpired tokens; it must be called with s.mu held.
+func (s *apiTokenStore) save(now time.Time) error {
+	list := make([]*apiToken, 0, len(s.tokens))
+	for id, t := range s.tokens {
+		if t.expired(now) {
+			delete(s.tokens, id)
+			continue
+		}
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// routeTokens are the tokens a route accepts as they are: its configured
+// authTokens and the managed tokens valid for it.
+type routeTokens struct {
+	set     map[string]struct{}
+	managed *apiTokenStore
+	server  string
+}
+
+func newRouteTokens(tokens []string, managed *apiTokenStore, server string) routeTokens {
+	set := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		set[token] = struct{}{}
+	}
+	return routeTokens{set: set, managed: managed, server: server}
+}
+
+// enforced reports whether the route requires a token at all.
+func (t routeTokens) enforced() bool {
+	return len(t.set) > 0 || t.managed.required()
+}
+
+func (t routeTokens) accepts(token string) bool {
+	if _, ok := t.set[token]; ok {
+		return true
+	}
+	return t.managed.accepts(token, t.server)
+}
+
+// registerAPITokenRoutes lets operators issue, list, rotate, and revoke
+// managed tokens. known reports whether a server a token is limited to is
+// configured.
+func registerAPITokenRoutes(api *adminAPI, store *apiTokenStore, known func(server string) bool, audit *auditLog) {
+	if store == nil {
+		return
+	}
+	record := func(r *http.Request, action string, t apiTokenView) {
+		params, _ := json.Marshal(t)
+		audit.record(auditEntry{At: time.Now(), Method: "stelae/token." + action, Target: t.ID, Caller: "admin:" + tokenFingerprint(bearerToken(r)), Params: params})
+	}
+	api.handle(http.MethodGet, "tokens", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"tokens": store.list()})
+	})
+	api.handle(http.MethodPost, "tokens", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Name    string   `json:"name"`
+			Servers []string `json:"servers"`
+			TTL     string   `json:"ttl"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		var ttl time.Duration
+		if body.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(body.TTL); err != nil || ttl <= 0 {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("invalid ttl %q", body.TTL)})
+				return
+			}
+		}
+		for _, server := range body.Servers {
+			if !known(server) {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unknown server", "server": server})
+				return
+			}
+		}
+		by := "admin:" + tokenFingerprint(bearerToken(r))
+		view, secret, err := store.create(body.Name, body.Servers, ttl, by)
+		if err != nil {
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		record(r, "create", view)
+		logger("admin").Info("issued api token", "id", view.ID, "name", view.Name, "servers", view.Servers, "by", by)
+		api.writeJSON(w, http.StatusCreated, map[string]any{"token": secret, "info": view})
+	})
+	api.handle(http.MethodPost, "tokens/{id}/rotate", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Grace string `json:"grace"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+				return
+			}
+		}
+		var grace time.Duration
+		if body.Grace != "" {
+			var err error
+			if grace, err = time.ParseDuration(body.Grace); err != nil || grace < 0 {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("invalid grace %q", body.Grace)})
+				return
+			}
+		}
+		view, secret, ok, err := store.rotate(r.PathValue("id"), grace)
+		switch {
+		case !ok:
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown token", "id": r.PathValue("id")})
+			return
+		case err != nil:
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		record(r, "rotate", view)
+		logger("admin").Info("rotated api token", "id", view.ID, "grace", grace)
+		api.writeJSON(w, http.StatusOK, map[string]any{"token": secret, "info": view})
+	})
+	api.handle(http.MethodDelete, "tokens/{id}", func(w http.ResponseWriter, r *http.Request) {
+		view, ok, err := store.revoke(r.PathValue("id"))
+		switch {
+		case !ok:
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown token", "id": r.PathValue("id")})
+			return
+		case err != nil:
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		record(r, "revoke", view)
+		logger("admin").Info("revoked api token", "id", view.ID)
+		api.writeJSON(w, http.StatusOK, view)
+	})
+}
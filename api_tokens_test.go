@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPITokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_tokens.json")
+	store, err := newAPITokenStore(&APITokensConfig{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	all, allSecret, err := store.create("ci", nil, time.Hour, "admin:x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, dbSecret, err := store.create("db only", []string{"db"}, 0, "admin:x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(allSecret, apiTokenPrefix) || !strings.HasPrefix(allSecret, all.Prefix) {
+		t.Fatalf("secret %q prefix %q", allSecret, all.Prefix)
+	}
+	cases := []struct {
+		secret, server string
+		want           bool
+	}{
+		{allSecret, "", true},
+		{allSecret, "fs", true},
+		{dbSecret, "db", true},
+		{dbSecret, "fs", false},
+		{dbSecret, "", false},
+		{apiTokenPrefix + "nope", "db", false},
+	}
+	for _, c := range cases {
+		if got := store.accepts(c.secret, c.server); got != c.want {
+			t.Errorf("accepts(%q, %q) = %v, want %v", c.secret, c.server, got, c.want)
+		}
+	}
+
+	// the file only holds hashes and reloads as is
+	reloaded, err := newAPITokenStore(&APITokensConfig{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.now = store.now
+	if !reloaded.accepts(dbSecret, "db") || len(reloaded.list()) != 2 {
+		t.Fatalf("reloaded store lost tokens: %+v", reloaded.list())
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), dbSecret) {
+		t.Fatal("token file holds a secret")
+	}
+
+	_, rotated, ok, err := store.rotate(all.ID, time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("rotate: %v %v", ok, err)
+	}
+	if !store.accepts(rotated, "") || !store.accepts(allSecret, "") {
+		t.Fatal("rotation grace not honored")
+	}
+	now = now.Add(2 * time.Minute)
+	if store.accepts(allSecret, "") || !store.accepts(rotated, "") {
+		t.Fatal("old secret outlived the grace period")
+	}
+	now = now.Add(time.Hour)
+	if store.accepts(rotated, "") || len(store.list()) != 1 {
+		t.Fatal("expired token still accepted")
+	}
+
+	if _, ok, err := store.revoke(all.ID); err != nil || !ok {
+		t.Fatalf("revoke: %v %v", ok, err)
+	}
+	if _, ok, _ := store.revoke("missing"); ok {
+		t.Fatal("revoked an unknown token")
+	}
+}
+
+func TestAPITokenRoutes(t *testing.T) {
+	store, err := newAPITokenStore(&APITokensConfig{Require: true}, filepath.Join(t.TempDir(), "api_tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	known := func(server string) bool { return server == "db" }
+	registerAPITokenRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), store, known, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/admin/tokens", `{"name":"ci","ttl":"1h"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token string       `json:"token"`
+		Info  apiTokenView `json:"info"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.Info.ExpiresAt == nil {
+		t.Fatalf("create body %s", w.Body.String())
+	}
+	if strings.Contains(do(http.MethodGet, "/admin/tokens", "").Body.String(), created.Token) {
+		t.Fatal("list leaked the secret")
+	}
+	if w := do(http.MethodPost, "/admin/tokens", `{"ttl":"soon"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad ttl: %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/admin/tokens", `{"servers":["db","bd"]}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"bd"`) {
+		t.Fatalf("unknown server: %d %s", w.Code, w.Body.String())
+	}
+	if len(store.list()) != 1 {
+		t.Fatalf("a token was issued for an unknown server: %+v", store.list())
+	}
+	if w := do(http.MethodPost, "/admin/tokens", `{"name":"db-only","servers":["db"]}`); w.Code != http.StatusCreated {
+		t.Fatalf("create for a known server: %d %s", w.Code, w.Body.String())
+	}
+
+	// a route accepts managed tokens alongside its own
+	tokens := newRouteTokens(nil, store, "")
+	reached := 0
+	handler := newAuthMiddleware(tokens, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached++ }))
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := call(created.Token); code != http.StatusOK || reached != 1 {
+		t.Fatalf("managed token refused: %d", code)
+	}
+	if code := call(""); code != http.StatusUnauthorized {
+		t.Fatalf("required token not enforced: %d", code)
+	}
+
+	w = do(http.MethodPost, "/admin/tokens/"+created.Info.ID+"/rotate", "")
+	var rotated struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &rotated); w.Code != http.StatusOK || err != nil || rotated.Token == created.Token {
+		t.Fatalf("rotate: %d %s", w.Code, w.Body.String())
+	}
+	if call(created.Token) != http.StatusUnauthorized || call(rotated.Token) != http.StatusOK {
+		t.Fatal("rotation without grace kept the old secret")
+	}
+
+	if w := do(http.MethodDelete, "/admin/tokens/"+created.Info.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke: %d", w.Code)
+	}
+	if call(rotated.Token) != http.StatusUnauthorized {
+		t.Fatal("revoked token still accepted")
+	}
+	if w := do(http.MethodDelete, "/admin/tokens/"+created.Info.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("revoke twice: %d", w.Code)
+	}
+}
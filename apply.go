@@ -0,0 +1,394 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// applyRequest is the desired state for POST /admin/apply: the complete
+// mcpServers map and, optionally, manifest.toolOverrides. Servers missing
+// from mcpServers are removed; omitting toolOverrides leaves them as they are.
+type applyRequest struct {
+	McpServers    map[string]*MCPClientConfigV2  `json:"mcpServers"`
+	ToolOverrides map[string]*ToolOverrideConfig `json:"toolOverrides,omitempty"`
+	DryRun        bool                           `json:"dryRun,omitempty"`
+}
+
+// applyPlan is the difference between the running servers and the desired
+// state. Changed servers are reconnected with their new config.
+type applyPlan struct {
+	Add                  []string `json:"add"`
+	Change               []string `json:"change"`
+	Remove               []string `json:"remove"`
+	Unchanged            []string `json:"unchanged"`
+	ToolOverridesChanged bool     `json:"toolOverridesChanged"`
+}
+
+func (p applyPlan) empty() bool {
+	return len(p.Add) == 0 && len(p.Change) == 0 && len(p.Remove) == 0 && !p.ToolOverridesChanged
+}
+
+type applyResult struct {
+	Plan    applyPlan `json:"plan"`
+	DryRun  bool      `json:"dryRun,omitempty"`
+	Applied bool      `json:"applied"`
+	// Failed maps servers that could not connect to their error; any
+	// failure rolls the whole apply back.
+	Failed map[string]string `json:"failed,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// planApply diffs desired against current server configs. Overrides are only
+// compared when the request carries them.
+func planApply(current, desired map[string]*MCPClientConfigV2, currentOverrides, desiredOverrides map[string]*ToolOverrideConfig) applyPlan {
+	plan := applyPlan{Add: []string{}, Change: []string{}, Remove: []string{}, Unchanged: []string{}}
+	for name, conf := range desired {
+		prev, ok := current[name]
+		switch {
+		case !ok:
+			plan.Add = append(plan.Add, name)
+		case reflect.DeepEqual(prev, conf):
+			plan.Unchanged = append(plan.Unchanged, name)
+		default:
+			plan.Change = append(plan.Change, name)
+		}
+	}
+	for name := range current {
+		if _, ok := desired[name]; !ok {
+			plan.Remove = append(plan.Remove, name)
+		}
+	}
+	for _, names := range [][]string{plan.Add, plan.Change, plan.Remove, plan.Unchanged} {
+		sort.Strings(names)
+	}
+	if desiredOverrides != nil {
+		plan.ToolOverridesChanged = !reflect.DeepEqual(currentOverrides, desiredOverrides) &&
+			(len(currentOverrides) > 0 || len(desiredOverrides) > 0)
+	}
+	return plan
+}
+
+// serverApplier applies declarative server and override state to a running
+// proxy. New and changed servers are connected before anything is swapped,
+// so a failure leaves the running set untouched.
+type serverApplier struct {
+	mu       sync.Mutex
+	registry *serverRegistry
+	store    *toolOverrideStore
+	// resolve normalizes desired servers and overrides the way config
+	// loading does and rejects invalid ones.
+	resolve func(servers map[string]*MCPClientConfigV2, overrides map[string]*ToolOverrideConfig) error
+	// connect builds and connects one server without publishing it.
+	connect func(name string, conf *MCPClientConfigV2) (*serverEntry, error)
+	// overrides returns the current manifest.toolOverrides; setOverrides
+	// replaces them once loadOverrides' result is published.
+	overrides     func() map[string]*ToolOverrideConfig
+	loadOverrides func(map[string]*ToolOverrideConfig) (*ToolOverrideSet, error)
+	setOverrides  func(map[string]*ToolOverrideConfig)
+	// applied runs after a successful apply, e.g. to rebuild indexes.
+	applied func(applyPlan)
+	// index builds the catalog index a rollout candidate routes with.
+	index func(servers map[string]*Server, overrides *ToolOverrideSet) catalogIndex
+
+	rollout atomic.Pointer[rolloutCandidate]
+}
+
+var (
+	errRolloutActive = errors.New("a rollout is in progress; promote or roll it back first")
+	errServerUnknown = errors.New("no such server")
+)
+
+// plan validates req and diffs it against the running state.
+func (a *serverApplier) plan(req applyRequest) (applyPlan, error) {
+	if req.McpServers == nil {
+		return applyPlan{}, errors.New("mcpServers is required")
+	}
+	for name, conf := range req.McpServers {
+		if err := validServerName(name); err != nil {
+			return applyPlan{}, err
+		}
+		if conf == nil {
+			return applyPlan{}, fmt.Errorf("mcpServers.%s is null", name)
+		}
+	}
+	if err := a.resolve(req.McpServers, req.ToolOverrides); err != nil {
+		return applyPlan{}, err
+	}
+	return planApply(a.registry.configs(), req.McpServers, a.overrides(), req.ToolOverrides), nil
+}
+
+// connectPlan mounts routes for added servers and connects every added or
+// changed one. On failure the servers that did connect are closed again.
+func (a *serverApplier) connectPlan(plan applyPlan, req applyRequest) (map[string]*serverEntry, map[string]string, error) {
+	for _, name := range plan.Add {
+		if err := a.registry.mount(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		connected = make(map[string]*serverEntry)
+		failed    = make(map[string]string)
+	)
+	for _, name := range append(append([]string{}, plan.Add...), plan.Change...) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := a.connect(name, req.McpServers[name])
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[name] = err.Error()
+				return
+			}
+			connected[name] = entry
+		}()
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		for _, entry := range connected {
+			entry.close()
+		}
+		return nil, failed, nil
+	}
+	return connected, nil, nil
+}
+
+func failedSummary(failed map[string]string) string {
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "rolled back: failed to connect " + strings.Join(names, ", ")
+}
+
+// commit swaps connected servers and overrides in and closes what they
+// replace.
+func (a *serverApplier) commit(plan applyPlan, connected map[string]*serverEntry, overrides map[string]*ToolOverrideConfig, loaded *ToolOverrideSet) {
+	for _, entry := range a.registry.swap(connected, plan.Remove) {
+		entry.close()
+	}
+	if plan.ToolOverridesChanged {
+		a.setOverrides(overrides)
+		a.store.replaceBase(loaded)
+	}
+	if a.applied != nil {
+		a.applied(plan)
+	}
+}
+
+// apply returns an error only for an invalid request or while a rollout is
+// in progress; connection failures are reported in the result.
+func (a *serverApplier) apply(req applyRequest) (applyResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.applyLocked(req)
+}
+
+// applyServer adds or replaces one server, or removes it when conf is nil,
+// leaving the others as they are.
+func (a *serverApplier) applyServer(name string, conf *MCPClientConfigV2) (applyResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	desired := a.registry.configs()
+	if conf == nil {
+		if _, ok := desired[name]; !ok {
+			return applyResult{}, errServerUnknown
+		}
+		delete(desired, name)
+	} else {
+		desired[name] = conf
+	}
+	return a.applyLocked(applyRequest{McpServers: desired})
+}
+
+// patchOverrides merges patch into manifest.toolOverrides, leaving the
+// servers as they are. A null entry removes that override.
+func (a *serverApplier) patchOverrides(patch map[string]*ToolOverrideConfig, dryRun bool) (applyResult, error) {
+	if len(patch) == 0 {
+		return applyResult{}, errors.New("toolOverrides is required")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	merged := make(map[string]*ToolOverrideConfig)
+	for name, conf := range a.overrides() {
+		merged[name] = conf
+	}
+	for name, conf := range patch {
+		if conf == nil {
+			delete(merged, name)
+		} else {
+			merged[name] = conf
+		}
+	}
+	return a.applyLocked(applyRequest{McpServers: a.registry.configs(), ToolOverrides: merged, DryRun: dryRun})
+}
+
+func (a *serverApplier) applyLocked(req applyRequest) (applyResult, error) {
+	if a.rollout.Load() != nil {
+		return applyResult{}, errRolloutActive
+	}
+	plan, err := a.plan(req)
+	if err != nil {
+		return applyResult{}, err
+	}
+	result := applyResult{Plan: plan, DryRun: req.DryRun}
+	if req.DryRun || plan.empty() {
+		return result, nil
+	}
+
+	var loaded *ToolOverrideSet
+	if plan.ToolOverridesChanged {
+		if loaded, err = a.loadOverrides(req.ToolOverrides); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+	connected, failed, err := a.connectPlan(plan, req)
+	if err != nil {
+		return applyResult{}, err
+	}
+	if len(failed) > 0 {
+		result.Failed = failed
+		result.Error = failedSummary(failed)
+		logger("apply").Warn("apply failed", "err", result.Error)
+		return result, nil
+	}
+	a.commit(plan, connected, req.ToolOverrides, loaded)
+	result.Applied = true
+	logger("apply").Info("applied", "add", plan.Add, "change", plan.Change, "remove", plan.Remove,
+		"toolOverridesChanged", plan.ToolOverridesChanged)
+	return result, nil
+}
+
+// registerServerRoutes adds, replaces, and removes single servers without
+// restating the whole mcpServers map. They share apply's validation and
+// rollback, and answer like it.
+func registerServerRoutes(api *adminAPI, applier *serverApplier, ready func() bool, events *eventBus) {
+	respond := func(w http.ResponseWriter, r *http.Request, name string, conf *MCPClientConfigV2) {
+		if !ready() {
+			api.writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "servers are still starting"})
+			return
+		}
+		result, err := applier.applyServer(name, conf)
+		switch {
+		case errors.Is(err, errServerUnknown):
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": fmt.Sprintf("%v: %s", err, name)})
+			return
+		case errors.Is(err, errRolloutActive):
+			api.writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+			return
+		case err != nil:
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		case result.Error != "":
+			api.writeJSON(w, http.StatusBadGateway, result)
+			return
+		}
+		status := http.StatusOK
+		if len(result.Plan.Add) > 0 {
+			status = http.StatusCreated
+		}
+		emitServerApplied(events, name, "admin:"+tokenFingerprint(bearerToken(r)), result)
+		api.writeJSON(w, status, result)
+	}
+	api.handle(http.MethodPost, "servers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var conf MCPClientConfigV2
+		if err := json.NewDecoder(r.Body).Decode(&conf); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		respond(w, r, r.PathValue("name"), &conf)
+	})
+	api.handle(http.MethodDelete, "servers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, r, r.PathValue("name"), nil)
+	})
+}
+
+// emitServerApplied reports what applyServer did to name.
+func emitServerApplied(events *eventBus, name, by string, result applyResult) {
+	switch {
+	case len(result.Plan.Add) > 0:
+		events.emit("server.registered", map[string]any{"server": name, "by": by})
+	case len(result.Plan.Change) > 0:
+		events.emit("server.registered", map[string]any{"server": name, "by": by, "replaced": true})
+	case len(result.Plan.Remove) > 0:
+		events.emit("server.unregistered", map[string]any{"server": name, "by": by})
+	}
+}
+
+// emitApplied reports an apply or override patch that changed something.
+func emitApplied(events *eventBus, by string, result applyResult) {
+	if !result.Applied {
+		return
+	}
+	events.emit("config.applied", map[string]any{
+		"by":                   by,
+		"add":                  result.Plan.Add,
+		"change":               result.Plan.Change,
+		"remove":               result.Plan.Remove,
+		"toolOverridesChanged": result.Plan.ToolOverridesChanged,
+	})
+}
+
+// toolOverridesPatch is the body of PATCH /admin/tool-overrides.
+type toolOverridesPatch struct {
+	ToolOverrides map[string]*ToolOverrideConfig `json:"toolOverrides"`
+	DryRun        bool                           `json:"dryRun,omitempty"`
+}
+
+// registerApplyRoutes exposes declarative apply and override patches. A dry
+// run only returns the plan; a rolled-back apply answers 502 with the plan
+// and the failures.
+func registerApplyRoutes(api *adminAPI, applier *serverApplier, ready func() bool, events *eventBus) {
+	respond := func(w http.ResponseWriter, r *http.Request, apply func() (applyResult, error)) {
+		result, err := apply()
+		if errors.Is(err, errRolloutActive) {
+			api.writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		if result.Error != "" {
+			api.writeJSON(w, http.StatusBadGateway, result)
+			return
+		}
+		emitApplied(events, "admin:"+tokenFingerprint(bearerToken(r)), result)
+		api.writeJSON(w, http.StatusOK, result)
+	}
+	api.handle(http.MethodPost, "apply", func(w http.ResponseWriter, r *http.Request) {
+		if !ready() {
+			api.writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "servers are still starting"})
+			return
+		}
+		var req applyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		respond(w, r, func() (applyResult, error) { return applier.apply(req) })
+	})
+	api.handle(http.MethodPatch, "tool-overrides", func(w http.ResponseWriter, r *http.Request) {
+		if !ready() {
+			api.writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "servers are still starting"})
+			return
+		}
+		var req toolOverridesPatch
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		respond(w, r, func() (applyResult, error) { return applier.patchOverrides(req.ToolOverrides, req.DryRun) })
+	})
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanApply(t *testing.T) {
+	current := map[string]*MCPClientConfigV2{
+		"fs":  {Command: "fs-server"},
+		"git": {Command: "git-server"},
+		"old": {URL: "http://old"},
+	}
+	desired := map[string]*MCPClientConfigV2{
+		"fs":  {Command: "fs-server"},
+		"git": {Command: "git-server", Args: []string{"--repo", "."}},
+		"new": {URL: "http://new"},
+	}
+	plan := planApply(current, desired, nil, nil)
+	want := applyPlan{Add: []string{"new"}, Change: []string{"git"}, Remove: []string{"old"}, Unchanged: []string{"fs"}}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("plan = %+v, want %+v", plan, want)
+	}
+
+	disabled := false
+	overrides := map[string]*ToolOverrideConfig{"fs_write": {Enabled: &disabled}}
+	if planApply(current, current, nil, map[string]*ToolOverrideConfig{}).ToolOverridesChanged {
+		t.Fatal("clearing absent overrides should not be a change")
+	}
+	if !planApply(current, current, nil, overrides).ToolOverridesChanged {
+		t.Fatal("expected an override change")
+	}
+	if plan := planApply(current, current, overrides, nil); !plan.empty() {
+		t.Fatalf("omitted overrides should leave them alone, got %+v", plan)
+	}
+}
+
+func TestServerApplierRollsBackAndApplies(t *testing.T) {
+	mux := http.NewServeMux()
+	registry := newServerRegistry(mux, "/")
+	closed := map[string]int{}
+	entry := func(name string, conf *MCPClientConfigV2) *serverEntry {
+		return &serverEntry{
+			server: &Server{name: name},
+			config: conf,
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(name + ":" + conf.URL))
+			}),
+			cancel: func() { closed[name+":"+conf.URL]++ },
+		}
+	}
+	for name, url := range map[string]string{"fs": "v1", "old": "v1"} {
+		conf := &MCPClientConfigV2{URL: url}
+		registry.add(name, entry(name, conf))
+		if err := registry.activate(name, registry.entries[name].handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	failNew := true
+	applied := 0
+	applier := &serverApplier{
+		registry: registry,
+		resolve: func(servers map[string]*MCPClientConfigV2, _ map[string]*ToolOverrideConfig) error {
+			_, err := startupStages(servers)
+			return err
+		},
+		connect: func(name string, conf *MCPClientConfigV2) (*serverEntry, error) {
+			if name == "new" && failNew {
+				return nil, errors.New("connection refused")
+			}
+			return entry(name, conf), nil
+		},
+		store:         newToolOverrideStore(nil),
+		overrides:     func() map[string]*ToolOverrideConfig { return nil },
+		loadOverrides: func(map[string]*ToolOverrideConfig) (*ToolOverrideSet, error) { return nil, nil },
+		setOverrides:  func(map[string]*ToolOverrideConfig) {},
+		applied:       func(applyPlan) { applied++ },
+	}
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+	desired := func() map[string]*MCPClientConfigV2 {
+		return map[string]*MCPClientConfigV2{"fs": {URL: "v2"}, "new": {URL: "v1"}}
+	}
+
+	for _, name := range []string{"..", "admin", "a/b", "{x}", "my server", ".hidden"} {
+		if _, err := applier.apply(applyRequest{McpServers: map[string]*MCPClientConfigV2{name: {URL: "v1"}}, DryRun: true}); err == nil {
+			t.Fatalf("expected server name %q to be rejected", name)
+		}
+	}
+	if _, err := applier.apply(applyRequest{McpServers: map[string]*MCPClientConfigV2{"fs": {DependsOn: []string{"db"}}}}); err == nil || !strings.Contains(err.Error(), "unknown server db") {
+		t.Fatalf("expected dependsOn validation error, got %v", err)
+	}
+
+	result, err := applier.apply(applyRequest{McpServers: desired(), DryRun: true})
+	if err != nil || result.Applied || len(result.Plan.Change) != 1 || len(result.Plan.Add) != 1 || len(result.Plan.Remove) != 1 {
+		t.Fatalf("dry run = %+v, %v", result, err)
+	}
+
+	result, err = applier.apply(applyRequest{McpServers: desired()})
+	if err != nil || result.Applied || result.Failed["new"] != "connection refused" {
+		t.Fatalf("expected rollback, got %+v, %v", result, err)
+	}
+	if closed["fs:v2"] != 1 {
+		t.Fatalf("expected the replacement connection to be closed on rollback, got %v", closed)
+	}
+	if code, body := get("/fs/"); code != http.StatusOK || body != "fs:v1" {
+		t.Fatalf("fs after rollback = %d %q", code, body)
+	}
+	if code, _ := get("/new/"); code != http.StatusNotFound {
+		t.Fatalf("new after rollback = %d", code)
+	}
+
+	failNew = false
+	result, err = applier.apply(applyRequest{McpServers: desired()})
+	if err != nil || !result.Applied || applied != 1 {
+		t.Fatalf("apply = %+v, %v", result, err)
+	}
+	if code, body := get("/fs/"); code != http.StatusOK || body != "fs:v2" {
+		t.Fatalf("fs after apply = %d %q", code, body)
+	}
+	if code, body := get("/new/"); code != http.StatusOK || body != "new:v1" {
+		t.Fatalf("new after apply = %d %q", code, body)
+	}
+	if code, _ := get("/old/"); code != http.StatusNotFound {
+		t.Fatalf("old after apply = %d", code)
+	}
+	if closed["fs:v1"] != 1 || closed["old:v1"] != 1 {
+		t.Fatalf("expected replaced and removed servers to be closed, got %v", closed)
+	}
+	if names := registry.names(); !reflect.DeepEqual(names, []string{"fs", "new"}) {
+		t.Fatalf("servers = %v", names)
+	}
+
+	result, err = applier.apply(applyRequest{McpServers: desired()})
+	if err != nil || result.Applied || !result.Plan.empty() || applied != 1 {
+		t.Fatalf("re-applying the same state should be a no-op, got %+v, %v", result, err)
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	registry := newServerRegistry(mux, "/")
+	applied := 0
+	applier := &serverApplier{
+		registry: registry,
+		resolve: func(servers map[string]*MCPClientConfigV2, _ map[string]*ToolOverrideConfig) error {
+			_, err := startupStages(servers)
+			return err
+		},
+		connect: func(name string, conf *MCPClientConfigV2) (*serverEntry, error) {
+			if conf.URL == "" {
+				return nil, errors.New("connection refused")
+			}
+			return &serverEntry{
+				server:  &Server{name: name},
+				config:  conf,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(name + ":" + conf.URL)) }),
+				cancel:  func() {},
+			}, nil
+		},
+		store:         newToolOverrideStore(nil),
+		overrides:     func() map[string]*ToolOverrideConfig { return nil },
+		loadOverrides: func(map[string]*ToolOverrideConfig) (*ToolOverrideSet, error) { return nil, nil },
+		setOverrides:  func(map[string]*ToolOverrideConfig) {},
+		applied:       func(applyPlan) { applied++ },
+	}
+	registerServerRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), applier, func() bool { return true }, nil)
+	do := func(method, path, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, body := do(http.MethodPost, "/admin/servers/web", `{"url":"http://web/mcp"}`); code != http.StatusCreated || applied != 1 {
+		t.Fatalf("register = %d %s", code, body)
+	}
+	if code, body := do(http.MethodGet, "/web/", ""); code != http.StatusOK || body != "web:http://web/mcp" {
+		t.Fatalf("web route = %d %q", code, body)
+	}
+	if code, body := do(http.MethodPost, "/admin/servers/broken", `{"command":"missing"}`); code != http.StatusBadGateway || !strings.Contains(body, "connection refused") {
+		t.Fatalf("failed register = %d %s", code, body)
+	}
+	if code, body := do(http.MethodPost, "/admin/servers/web", `{"url":"http://web/v2"}`); code != http.StatusOK || !strings.Contains(body, `"change":["web"]`) {
+		t.Fatalf("replace = %d %s", code, body)
+	}
+	if code, body := do(http.MethodDelete, "/admin/servers/web", ""); code != http.StatusOK || !strings.Contains(body, `"remove":["web"]`) {
+		t.Fatalf("unregister = %d %s", code, body)
+	}
+	if code, _ := do(http.MethodGet, "/web/", ""); code != http.StatusNotFound {
+		t.Fatalf("web route after unregister = %d", code)
+	}
+	if code, _ := do(http.MethodDelete, "/admin/servers/web", ""); code != http.StatusNotFound {
+		t.Fatalf("unregister unknown = %d", code)
+	}
+}
+
+func TestPatchToolOverridesRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	files, rows := "files", "rows"
+	current := map[string]*ToolOverrideConfig{"fs": {Description: &files}, "db": {Description: &rows}}
+	applier := &serverApplier{
+		registry:      newServerRegistry(mux, "/"),
+		resolve:       func(map[string]*MCPClientConfigV2, map[string]*ToolOverrideConfig) error { return nil },
+		store:         newToolOverrideStore(nil),
+		overrides:     func() map[string]*ToolOverrideConfig { return current },
+		loadOverrides: func(map[string]*ToolOverrideConfig) (*ToolOverrideSet, error) { return nil, nil },
+		setOverrides:  func(overrides map[string]*ToolOverrideConfig) { current = overrides },
+		applied:       func(applyPlan) {},
+	}
+	registerApplyRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), applier, func() bool { return true }, nil)
+	patch := func(body string) (int, string) {
+		req := httptest.NewRequest(http.MethodPatch, "/admin/tool-overrides", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, body := patch(`{"toolOverrides":{}}`); code != http.StatusBadRequest {
+		t.Fatalf("empty patch = %d %s", code, body)
+	}
+	if code, body := patch(`{"toolOverrides":{"db":null},"dryRun":true}`); code != http.StatusOK || !strings.Contains(body, `"toolOverridesChanged":true`) || len(current) != 2 {
+		t.Fatalf("dry run = %d %s (%d overrides)", code, body, len(current))
+	}
+	if code, body := patch(`{"toolOverrides":{"db":null,"web":{"description":"pages"}}}`); code != http.StatusOK || !strings.Contains(body, `"applied":true`) {
+		t.Fatalf("patch = %d %s", code, body)
+	}
+	if current["db"] != nil || *current["fs"].Description != "files" || *current["web"].Description != "pages" {
+		t.Fatalf("overrides after patch = %+v", current)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	auditFileMaxSize    = 50 << 20
+	auditFileMaxBackups = 5
+)
+
+// Ways the audit log keeps call arguments.
+const (
+	auditArgumentsVerbatim = "verbatim"
+	auditArgumentsRedacted = "redacted"
+	auditArgumentsHashed   = "hashed"
+)
+
+// AuditConfig records every dispatched call (method, target, params and the
+// downstream response) so it can be inspected or replayed later. Arguments
+// is verbatim (default, replayable), redacted (RedactKeys and the built-in
+// keys masked in params and response), or hashed (params keep only a
+// SHA-256 of the arguments; the response is redacted).
+type AuditConfig struct {
+	Enabled    bool     `json:"enabled,omitempty"`
+	File       string   `json:"file,omitempty"`
+	Arguments  string   `json:"arguments,omitempty"`
+	RedactKeys []string `json:"redactKeys,omitempty"`
+}
+
+type auditEntry struct {
+	ID         string    `json:"id"`
+	At         time.Time `json:"at"`
+	Method     string    `json:"method"`
+	Server     string    `json:"server"`
+	Target     string    `json:"target"`
+	Caller     string    `json:"caller,omitempty"`
+	SessionID  string    `json:"sessionId,omitempty"`
+	RequestID  string    `json:"requestId,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Failed     bool      `json:"failed,omitempty"`
+	// Status is ok, error, timeout, or cancelled for dispatched calls.
+	Status        string          `json:"status,omitempty"`
+	Params        json.RawMessage `json:"params,omitempty"`
+	ArgumentsHash string          `json:"argumentsHash,omitempty"`
+	Redacted      bool            `json:"redacted,omitempty"`
+	Response      json.RawMessage `json:"response,omitempty"`
+}
+
+type auditLog struct {
+	mu        sync.Mutex
+	path      string
+	out       *rotatingFile
+	arguments string
+	redact    map[string]struct{}
+}
+
+func newAuditLog(conf *AuditConfig) (*auditLog, error) {
+	if conf == nil || !conf.Enabled {
+		return nil, nil
+	}
+	arguments := conf.Arguments
+	switch arguments {
+	case "":
+		arguments = auditArgumentsVerbatim
+	case auditArgumentsVerbatim, auditArgumentsRedacted, auditArgumentsHashed:
+	default:
+		return nil, fmt.Errorf("audit.arguments %q: want verbatim, redacted, or hashed", conf.Arguments)
+	}
+	target := conf.File
+	if target == "" {
+		target = filepath.Join(stateHome(), "audit", "audit.jsonl")
+	}
+	path, err := resolveLogFilePath(target)
+	if err != nil {
+		return nil, fmt.Errorf("audit.file %s: %w", target, err)
+	}
+	out, err := newRotatingFile(path, auditFileMaxSize, auditFileMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	logger("audit").Info("Recording calls", "path", path)
+	return &auditLog{path: path, out: out, arguments: arguments, redact: newRedactKeySet(conf.RedactKeys)}, nil
+}
+
+// scrub applies the arguments mode to a dispatched call's entry. Entries
+// for proxy actions (stelae/...) carry no caller arguments and are kept.
+func (a *auditLog) scrub(entry *auditEntry) {
+	if a.arguments == auditArgumentsVerbatim || strings.HasPrefix(entry.Method, "stelae/") {
+		return
+	}
+	redact := func(raw json.RawMessage) json.RawMessage {
+		var decoded any
+		if len(raw) == 0 || json.Unmarshal(raw, &decoded) != nil {
+			return nil
+		}
+		out, _ := json.Marshal(redactValue(decoded, a.redact))
+		return out
+	}
+	entry.Response = redact(entry.Response)
+	entry.Redacted = true
+	if a.arguments == auditArgumentsRedacted {
+		entry.Params = redact(entry.Params)
+		return
+	}
+	var params map[string]any
+	if json.Unmarshal(entry.Params, &params) != nil {
+		entry.Params = nil
+		return
+	}
+	if args, ok := params["arguments"]; ok {
+		// map keys marshal sorted, so equal arguments hash alike
+		canonical, _ := json.Marshal(args)
+		sum := sha256.Sum256(canonical)
+		entry.ArgumentsHash = hex.EncodeToString(sum[:])
+		delete(params, "arguments")
+	}
+	entry.Params, _ = json.Marshal(params)
+}
+
+// record assigns the entry an id and appends it; nil-safe.
+func (a *auditLog) record(entry auditEntry) {
+	if a == nil {
+		return
+	}
+	entry.ID = uuid.New().String()
+	entry.At = entry.At.UTC()
+	a.scrub(&entry)
+	if len(entry.Response) > 0 && !json.Valid(entry.Response) {
+		entry.Response = nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger("audit").Error("failed to encode entry", "err", err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.out.Write(append(data, '\n')); err != nil {
+		logger("audit").Error("failed to write entry", "err", err)
+	}
+}
+
+// files lists the live file followed by rotated backups, newest first.
+func (a *auditLog) files() []string {
+	out := []string{a.path}
+	for i := 1; i <= auditFileMaxBackups; i++ {
+		out = append(out, fmt.Sprintf("%s.%d", a.path, i))
+	}
+	return out
+}
+
+func (a *auditLog) scan(path string, fn func(*auditEntry) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if !fn(&entry) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// find looks an entry up by id across the live file and its backups. It
+// reads without the writer's lock, so calls are recorded while it scans:
+// a line being appended fails to decode and is skipped, and a rotation
+// mid-scan at worst shows it the same entries twice.
+func (a *auditLog) find(id string) (*auditEntry, error) {
+	if a == nil {
+		return nil, nil
+	}
+	var found *auditEntry
+	for _, path := range a.files() {
+		err := a.scan(path, func(e *auditEntry) bool {
+			if e.ID == id {
+				found = e
+				return false
+			}
+			return true
+		})
+		if err != nil || found != nil {
+			return found, err
+		}
+	}
+	return nil, nil
+}
+
+// recent returns up to limit entries from the live file, newest first;
+// like find, it reads without the writer's lock.
+func (a *auditLog) recent(limit int) ([]auditEntry, error) {
+	if a == nil {
+		return nil, nil
+	}
+	var entries []auditEntry
+	err := a.scan(a.path, func(e *auditEntry) bool {
+		entries = append(entries, *e)
+		if len(entries) > limit {
+			entries = entries[1:]
+		}
+		return true
+	})
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, err
+}
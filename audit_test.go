@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAuditLogRecordFindRecent(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	audit, err := newAuditLog(&AuditConfig{Enabled: true})
+	if err != nil || audit == nil {
+		t.Fatalf("newAuditLog: %v", err)
+	}
+	for _, tool := range []string{"a", "b", "c"} {
+		audit.record(auditEntry{
+			At:       time.Now(),
+			Method:   "tools/call",
+			Server:   "srv",
+			Target:   tool,
+			Params:   json.RawMessage(`{"name":"` + tool + `"}`),
+			Response: []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`),
+		})
+	}
+	recent, err := audit.recent(2)
+	if err != nil {
+		t.Fatalf("recent: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Target != "c" || recent[1].Target != "b" {
+		t.Fatalf("unexpected recent entries: %+v", recent)
+	}
+	found, err := audit.find(recent[1].ID)
+	if err != nil || found == nil || found.Target != "b" {
+		t.Fatalf("find = %+v, %v", found, err)
+	}
+	if missing, _ := audit.find("nope"); missing != nil {
+		t.Fatalf("expected no entry, got %+v", missing)
+	}
+}
+
+func TestAuditLogDisabledIsNil(t *testing.T) {
+	audit, err := newAuditLog(&AuditConfig{})
+	if err != nil || audit != nil {
+		t.Fatalf("expected nil audit log, got %v, %v", audit, err)
+	}
+	audit.record(auditEntry{Method: "tools/call"})
+}
+
+func TestAuditLogArgumentModes(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	params := json.RawMessage(`{"name":"charge","arguments":{"amount":5,"apiKey":"k-1","card":"4242"}}`)
+	response := json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"token":"t-9","ok":true}}`)
+	recordOne := func(conf *AuditConfig) auditEntry {
+		audit, err := newAuditLog(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		audit.record(auditEntry{At: time.Now(), Method: "tools/call", Target: "charge", Status: "ok", Params: params, Response: response})
+		recent, err := audit.recent(1)
+		if err != nil || len(recent) != 1 {
+			t.Fatalf("recent: %v %v", recent, err)
+		}
+		return recent[0]
+	}
+
+	redacted := recordOne(&AuditConfig{Enabled: true, File: "redacted.jsonl", Arguments: "redacted", RedactKeys: []string{"card"}})
+	if !redacted.Redacted || redacted.Status != "ok" || string(redacted.Params) != `{"arguments":{"amount":5,"apiKey":"[REDACTED]","card":"[REDACTED]"},"name":"charge"}` {
+		t.Fatalf("redacted entry: %+v", redacted)
+	}
+	if string(redacted.Response) != `{"id":1,"jsonrpc":"2.0","result":{"ok":true,"token":"[REDACTED]"}}` {
+		t.Fatalf("redacted response: %s", redacted.Response)
+	}
+
+	hashed := recordOne(&AuditConfig{Enabled: true, File: "hashed.jsonl", Arguments: "hashed"})
+	same := recordOne(&AuditConfig{Enabled: true, File: "hashed2.jsonl", Arguments: "hashed"})
+	if string(hashed.Params) != `{"name":"charge"}` || len(hashed.ArgumentsHash) != 64 || hashed.ArgumentsHash != same.ArgumentsHash {
+		t.Fatalf("hashed entry: %+v", hashed)
+	}
+	if _, err := replayRequestBody(&hashed); err == nil {
+		t.Fatal("hashed entry replayable")
+	}
+
+	verbatim := recordOne(&AuditConfig{Enabled: true, File: "verbatim.jsonl"})
+	if verbatim.Redacted || string(verbatim.Params) != string(params) {
+		t.Fatalf("verbatim entry: %+v", verbatim)
+	}
+	if _, err := newAuditLog(&AuditConfig{Enabled: true, Arguments: "plain"}); err == nil {
+		t.Fatal("unknown arguments mode accepted")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	rpcCodeServerBusy = -32012
+
+	defaultBackpressureQueueTimeout  = 30 * time.Second
+	defaultBackpressureHighWatermark = 0.8
+
+	// backpressureHeader is set on facade POST responses while the server
+	// that handled the call is saturated.
+	backpressureHeader = "X-Stelae-Backpressure"
+	// backpressureNotification is pushed over facade SSE streams when a
+	// server becomes saturated and again when it clears.
+	backpressureNotification = "notifications/stelae/backpressure"
+)
+
+// BackpressureConfig caps concurrent calls per downstream server. Calls over
+// the cap wait in a queue; clients are told to slow down once in-flight plus
+// queued calls reach highWatermark of the cap.
+type BackpressureConfig struct {
+	MaxConcurrentCalls int `json:"maxConcurrentCalls,omitempty"`
+	// Servers overrides maxConcurrentCalls per server; 0 lifts the cap.
+	Servers       map[string]int `json:"servers,omitempty"`
+	QueueTimeout  time.Duration  `json:"queueTimeout,omitempty"`
+	HighWatermark float64        `json:"highWatermark,omitempty"`
+}
+
+var errServerBusy = errors.New("server busy")
+
+// backpressureState describes one server's load; State is "saturated" or
+// "cleared" in notifications and empty on calls made without pressure.
+type backpressureState struct {
+	Server   string `json:"server"`
+	State    string `json:"state,omitempty"`
+	InFlight int    `json:"inFlight"`
+	Limit    int    `json:"limit"`
+	Queued   int    `json:"queued"`
+}
+
+func (s backpressureState) header() string {
+	return fmt.Sprintf("%s; server=%s; inflight=%d; limit=%d; queued=%d", s.State, s.Server, s.InFlight, s.Limit, s.Queued)
+}
+
+type serverGate struct {
+	limit     int
+	slots     chan struct{}
+	inFlight  int
+	queued    int
+	saturated bool
+}
+
+// backpressureGate holds the per-server call slots and the facade streams
+// that receive backpressure notifications. A nil gate admits every call.
+type backpressureGate struct {
+	conf         BackpressureConfig
+	queueTimeout time.Duration
+	watermark    float64
+
+	mu      sync.Mutex
+	servers map[string]*serverGate
+	subs    map[int]chan []byte
+	nextSub int
+}
+
+func newBackpressureGate(conf *BackpressureConfig) *backpressureGate {
+	if conf == nil {
+		return nil
+	}
+	g := &backpressureGate{
+		conf:         *conf,
+		queueTimeout: conf.QueueTimeout,
+		watermark:    conf.HighWatermark,
+		servers:      make(map[string]*serverGate),
+		subs:         make(map[int]chan []byte),
+	}
+	if g.queueTimeout <= 0 {
+		g.queueTimeout = defaultBackpressureQueueTimeout
+	}
+	if g.watermark <= 0 || g.watermark > 1 {
+		g.watermark = defaultBackpressureHighWatermark
+	}
+	return g
+}
+
+// gateLocked returns the server's gate, or nil when it has no cap.
+func (g *backpressureGate) gateLocked(server string) *serverGate {
+	if sg, ok := g.servers[server]; ok {
+		return sg
+	}
+	limit := g.conf.MaxConcurrentCalls
+	if n, ok := g.conf.Servers[server]; ok {
+		limit = n
+	}
+	var sg *serverGate
+	if limit > 0 {
+		sg = &serverGate{limit: limit, slots: make(chan struct{}, limit)}
+	}
+	g.servers[server] = sg
+	return sg
+}
+
+// acquire takes a call slot on server, waiting up to the queue timeout. It
+// returns errServerBusy when the wait times out and ctx's error when ctx
+// ends first. The state is the server's load once the call was admitted,
+// or when it gave up.
+func (g *backpressureGate) acquire(ctx context.Context, server string) (release func(), state backpressureState, err error) {
+	if g == nil {
+		return func() {}, backpressureState{}, nil
+	}
+	g.mu.Lock()
+	sg := g.gateLocked(server)
+	if sg == nil {
+		g.mu.Unlock()
+		return func() {}, backpressureState{}, nil
+	}
+	sg.queued++
+	g.updateLocked(server, sg)
+	g.mu.Unlock()
+
+	timer := time.NewTimer(g.queueTimeout)
+	defer timer.Stop()
+	select {
+	case sg.slots <- struct{}{}:
+	case <-timer.C:
+		err = errServerBusy
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	sg.queued--
+	if err == nil {
+		sg.inFlight++
+	}
+	state = g.updateLocked(server, sg)
+	if err != nil {
+		return func() {}, state, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			sg.inFlight--
+			<-sg.slots
+			g.updateLocked(server, sg)
+		})
+	}, state, nil
+}
+
+// updateLocked re-evaluates saturation and notifies streams when it flips.
+// The returned state carries "saturated" while the server is saturated.
+func (g *backpressureGate) updateLocked(server string, sg *serverGate) backpressureState {
+	state := backpressureState{Server: server, InFlight: sg.inFlight, Limit: sg.limit, Queued: sg.queued}
+	saturated := sg.inFlight+sg.queued >= int(math.Ceil(g.watermark*float64(sg.limit)))
+	if saturated != sg.saturated {
+		sg.saturated = saturated
+		state.State = "cleared"
+		if saturated {
+			state.State = "saturated"
+		}
+		logger("facade").Warn("backpressure", "state", state.State, "server", server, "inflight", state.InFlight, "limit", state.Limit, "queued", state.Queued)
+		g.broadcastLocked(state)
+	}
+	state.State = ""
+	if sg.saturated {
+		state.State = "saturated"
+	}
+	return state
+}
+
+// broadcastLocked drops the notification for streams that are not keeping
+// up rather than blocking calls on them.
+func (g *backpressureGate) broadcastLocked(state backpressureState) {
+	if len(g.subs) == 0 {
+		return
+	}
+	msg, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": backpressureNotification, "params": state})
+	if err != nil {
+		return
+	}
+	for _, ch := range g.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// subscribe registers a facade stream for backpressure notifications. A nil
+// gate returns a nil channel, which never delivers.
+func (g *backpressureGate) subscribe() (<-chan []byte, func()) {
+	if g == nil {
+		return nil, func() {}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := g.nextSub
+	g.nextSub++
+	ch := make(chan []byte, 16)
+	g.subs[id] = ch
+	return ch, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		delete(g.subs, id)
+	}
+}
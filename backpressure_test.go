@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackpressureGate(t *testing.T) {
+	gate := newBackpressureGate(&BackpressureConfig{MaxConcurrentCalls: 2, Servers: map[string]int{"free": 0}, QueueTimeout: 20 * time.Millisecond, HighWatermark: 1})
+	notices, unsubscribe := gate.subscribe()
+	defer unsubscribe()
+	next := func() backpressureState {
+		t.Helper()
+		select {
+		case msg := <-notices:
+			var n struct {
+				Method string            `json:"method"`
+				Params backpressureState `json:"params"`
+			}
+			if err := json.Unmarshal(msg, &n); err != nil || n.Method != backpressureNotification {
+				t.Fatalf("notification = %s", msg)
+			}
+			return n.Params
+		case <-time.After(time.Second):
+			t.Fatal("no backpressure notification")
+		}
+		return backpressureState{}
+	}
+
+	releaseA, state, err := gate.acquire(context.Background(), "fs")
+	if err != nil || state.State != "" || state.InFlight != 1 {
+		t.Fatalf("first call = %+v, %v", state, err)
+	}
+	releaseB, state, err := gate.acquire(context.Background(), "fs")
+	if err != nil || state.State != "saturated" || state.InFlight != 2 {
+		t.Fatalf("second call = %+v, %v", state, err)
+	}
+	if n := next(); n.State != "saturated" || n.Server != "fs" || n.Limit != 2 {
+		t.Fatalf("notification = %+v", n)
+	}
+
+	if _, state, err := gate.acquire(context.Background(), "fs"); !errors.Is(err, errServerBusy) || state.InFlight != 2 {
+		t.Fatalf("expected a queue timeout, got %+v, %v", state, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := gate.acquire(ctx, "fs"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+
+	releaseA()
+	releaseA()
+	if n := next(); n.State != "cleared" || n.InFlight != 1 {
+		t.Fatalf("notification = %+v", n)
+	}
+	releaseB()
+
+	for range 5 {
+		if _, state, err := gate.acquire(context.Background(), "free"); err != nil || state.Limit != 0 {
+			t.Fatalf("uncapped server = %+v, %v", state, err)
+		}
+	}
+	var nilGate *backpressureGate
+	if release, _, err := nilGate.acquire(context.Background(), "fs"); err != nil {
+		t.Fatal(err)
+	} else {
+		release()
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultBatchParallelism = 4
+
+// batchEntryFunc handles one batch entry and returns its reply, or nil for a
+// notification.
+type batchEntryFunc func(ctx context.Context, entry []byte) []byte
+
+// dispatchBatch runs the entries of a JSON-RPC batch with at most
+// parallelism in flight and returns the replies in entry order, leaving out
+// notifications. Entries that are not request objects, and initialize
+// (which must not be batched), get an invalid_request reply without being
+// dispatched.
+func dispatchBatch(ctx context.Context, entries []json.RawMessage, parallelism int, dispatch batchEntryFunc) []json.RawMessage {
+	if parallelism <= 0 {
+		parallelism = defaultBatchParallelism
+	}
+	replies := make([][]byte, len(entries))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+	for i, entry := range entries {
+		var head struct {
+			ID     any    `json:"id"`
+			Method string `json:"method"`
+		}
+		entry = bytes.TrimSpace(entry)
+		if len(entry) == 0 || entry[0] != '{' || json.Unmarshal(entry, &head) != nil || head.Method == "" {
+			replies[i] = batchInvalidEntry(ctx, nil, "batch entries must be JSON-RPC request objects")
+			continue
+		}
+		if head.Method == "initialize" {
+			replies[i] = batchInvalidEntry(ctx, head.ID, "initialize cannot be part of a batch")
+			continue
+		}
+		g.Go(func() error {
+			replies[i] = dispatch(gctx, entry)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	out := make([]json.RawMessage, 0, len(replies))
+	for _, reply := range replies {
+		if len(reply) > 0 {
+			out = append(out, reply)
+		}
+	}
+	return out
+}
+
+func batchInvalidEntry(ctx context.Context, id any, detail string) []byte {
+	data, _ := json.Marshal(localizedErrors(ctx).response(id, errNameInvalidRequest, map[string]string{"detail": detail}))
+	return data
+}
+
+const (
+	// stelaeBatchMethod runs several tools/call and resources/read
+	// operations in one request.
+	stelaeBatchMethod = "stelae/batch"
+
+	maxBatchOperations = 64
+)
+
+// batchOperation is one stelae/batch operation.
+type batchOperation struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// batchOperationResult is the outcome of one operation, in operation order;
+// exactly one of Result and Error is set.
+type batchOperationResult struct {
+	Index  int             `json:"index"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *jsonrpcError   `json:"error,omitempty"`
+}
+
+// runBatchOperations dispatches stelae/batch operations as JSON-RPC requests
+// with at most parallelism in flight. Operations other than tools/call and
+// resources/read fail with invalid_request without being dispatched; one
+// failing operation does not affect the others.
+func runBatchOperations(ctx context.Context, ops []batchOperation, parallelism int, dispatch batchEntryFunc) []batchOperationResult {
+	if parallelism <= 0 {
+		parallelism = defaultBatchParallelism
+	}
+	results := make([]batchOperationResult, len(ops))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+	for i, op := range ops {
+		results[i].Index = i
+		if op.Method != "tools/call" && op.Method != "resources/read" {
+			results[i].Error = localizedErrors(ctx).response(i, errNameInvalidRequest, map[string]string{"detail": "stelae/batch runs only tools/call and resources/read"}).Error
+			continue
+		}
+		params := op.Params
+		if len(params) == 0 {
+			params = json.RawMessage(`{}`)
+		}
+		entry, err := json.Marshal(jsonrpcRequest{JSONRPC: "2.0", ID: i, Method: op.Method, Params: params})
+		if err != nil {
+			results[i].Error = localizedErrors(ctx).response(i, errNameInvalidRequest, map[string]string{"detail": err.Error()}).Error
+			continue
+		}
+		g.Go(func() error {
+			var reply struct {
+				Result json.RawMessage `json:"result"`
+				Error  *jsonrpcError   `json:"error"`
+			}
+			data := dispatch(gctx, entry)
+			switch {
+			case json.Unmarshal(data, &reply) != nil:
+				results[i].Error = localizedErrors(ctx).response(i, errNameInternal, map[string]string{"detail": "no reply"}).Error
+			case reply.Error != nil:
+				results[i].Error = reply.Error
+			default:
+				results[i].Result = reply.Result
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results
+}
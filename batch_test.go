@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatchBatchPreservesOrderAndCapsParallelism(t *testing.T) {
+	entries := []json.RawMessage{
+		json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"delay":30}}`),
+		json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/initialized"}`),
+		json.RawMessage(`{"jsonrpc":"2.0","id":"b","method":"tools/list"}`),
+		json.RawMessage(`42`),
+		json.RawMessage(`{"jsonrpc":"2.0","id":4,"method":"initialize"}`),
+		json.RawMessage(`{"jsonrpc":"2.0","id":5,"method":"ping","params":{"delay":10}}`),
+	}
+	var inFlight, peak, calls int32
+	replies := dispatchBatch(context.Background(), entries, 2, func(ctx context.Context, entry []byte) []byte {
+		atomic.AddInt32(&calls, 1)
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&inFlight, -1)
+		var req struct {
+			ID     any    `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Delay int `json:"delay"`
+			} `json:"params"`
+		}
+		_ = json.Unmarshal(entry, &req)
+		time.Sleep(time.Duration(req.Params.Delay) * time.Millisecond)
+		if req.ID == nil {
+			return nil
+		}
+		out, _ := json.Marshal(rpcOK(req.ID, map[string]any{"method": req.Method}))
+		return out
+	})
+
+	if calls != 4 {
+		t.Fatalf("expected 4 dispatched entries, got %d", calls)
+	}
+	if peak > 2 {
+		t.Fatalf("expected at most 2 entries in flight, saw %d", peak)
+	}
+	if len(replies) != 5 {
+		t.Fatalf("expected 5 replies, got %d: %s", len(replies), replies)
+	}
+	wantIDs := []string{`1`, `"b"`, `null`, `4`, `5`}
+	for i, reply := range replies {
+		var resp struct {
+			ID    json.RawMessage `json:"id"`
+			Error *struct {
+				Code int `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(reply, &resp); err != nil {
+			t.Fatalf("reply %d invalid: %v", i, err)
+		}
+		if string(resp.ID) != wantIDs[i] {
+			t.Fatalf("reply %d: expected id %s, got %s", i, wantIDs[i], resp.ID)
+		}
+		invalid := i == 2 || i == 3
+		if invalid != (resp.Error != nil && resp.Error.Code == -32600) {
+			t.Fatalf("reply %d: unexpected error state %s", i, reply)
+		}
+	}
+}
+
+func TestRunBatchOperations(t *testing.T) {
+	ops := []batchOperation{
+		{Method: "tools/call", Params: json.RawMessage(`{"name":"slow","arguments":{}}`)},
+		{Method: "resources/read", Params: json.RawMessage(`{"uri":"file:///missing"}`)},
+		{Method: "prompts/get", Params: json.RawMessage(`{"name":"p"}`)},
+		{Method: "tools/call", Params: json.RawMessage(`{"name":"fast"}`)},
+	}
+	var calls int32
+	results := runBatchOperations(context.Background(), ops, 2, func(ctx context.Context, entry []byte) []byte {
+		atomic.AddInt32(&calls, 1)
+		var req jsonrpcRequest
+		_ = json.Unmarshal(entry, &req)
+		var params struct {
+			Name string `json:"name"`
+			URI  string `json:"uri"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		if params.Name == "slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if params.URI != "" {
+			data, _ := json.Marshal(rpcErrors.response(req.ID, errNameUnknownResource, map[string]string{"uri": params.URI}))
+			return data
+		}
+		data, _ := json.Marshal(rpcOK(req.ID, map[string]any{"content": []any{map[string]any{"type": "text", "text": params.Name}}}))
+		return data
+	})
+	if calls != 3 || len(results) != 4 {
+		t.Fatalf("calls=%d results=%d", calls, len(results))
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Fatalf("result %d has index %d", i, result.Index)
+		}
+	}
+	if results[0].Error != nil || !strings.Contains(string(results[0].Result), `"slow"`) || !strings.Contains(string(results[3].Result), `"fast"`) {
+		t.Fatalf("tool results = %s / %s", results[0].Result, results[3].Result)
+	}
+	if results[1].Error == nil || results[1].Error.Code != -32601 || results[1].Result != nil {
+		t.Fatalf("resource error = %+v", results[1])
+	}
+	if results[2].Error == nil || results[2].Error.Code != -32600 {
+		t.Fatalf("prompts/get should be refused, got %+v", results[2])
+	}
+}
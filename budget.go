@@ -0,0 +1,120 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const rpcCodeBudgetExceeded = -32009
+
+// SessionBudgetConfig caps what one facade session may do. Zero means no
+// limit.
+type SessionBudgetConfig struct {
+	MaxCalls            int `json:"maxCalls,omitempty"`
+	MaxDestructiveCalls int `json:"maxDestructiveCalls,omitempty"`
+}
+
+// sessionBudgetUsage is one session's spend against its effective limits.
+type sessionBudgetUsage struct {
+	Session             string    `json:"session"`
+	Calls               int       `json:"calls"`
+	DestructiveCalls    int       `json:"destructiveCalls"`
+	MaxCalls            int       `json:"maxCalls"`
+	MaxDestructiveCalls int       `json:"maxDestructiveCalls"`
+	Override            bool      `json:"override,omitempty"`
+	LastCall            time.Time `json:"lastCall"`
+}
+
+// sessionBudgets charges downstream tools/call dispatches to the calling
+// session. Callers without a session id are charged by caller identity.
+// Usage is forgotten once a session has been idle for the session timeout.
+type sessionBudgets struct {
+	mu     sync.Mutex
+	limits SessionBudgetConfig
+	usage  map[string]*sessionBudgetUsage
+	idle   time.Duration
+	now    func() time.Time
+}
+
+func newSessionBudgets(conf *SessionBudgetConfig, idle time.Duration) *sessionBudgets {
+	if conf == nil || (conf.MaxCalls <= 0 && conf.MaxDestructiveCalls <= 0) {
+		return nil
+	}
+	if idle <= 0 {
+		idle = defaultSessionIdleTimeout
+	}
+	return &sessionBudgets{limits: *conf, usage: make(map[string]*sessionBudgetUsage), idle: idle, now: time.Now}
+}
+
+// entry must be called with b.mu held.
+func (b *sessionBudgets) entry(session string, now time.Time) *sessionBudgetUsage {
+	for key, u := range b.usage {
+		if key != session && !u.Override && now.Sub(u.LastCall) > b.idle {
+			delete(b.usage, key)
+		}
+	}
+	u, ok := b.usage[session]
+	if !ok {
+		u = &sessionBudgetUsage{Session: session, MaxCalls: b.limits.MaxCalls, MaxDestructiveCalls: b.limits.MaxDestructiveCalls}
+		b.usage[session] = u
+	}
+	return u
+}
+
+// charge books one call. When a limit would be exceeded nothing is booked
+// and the exhausted limit ("calls" or "destructiveCalls") is returned.
+// Nil-safe: without budgets every call passes.
+func (b *sessionBudgets) charge(session string, destructive bool) (string, sessionBudgetUsage) {
+	if b == nil {
+		return "", sessionBudgetUsage{}
+	}
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u := b.entry(session, now)
+	switch {
+	case u.MaxCalls > 0 && u.Calls >= u.MaxCalls:
+		return "calls", *u
+	case destructive && u.MaxDestructiveCalls > 0 && u.DestructiveCalls >= u.MaxDestructiveCalls:
+		return "destructiveCalls", *u
+	}
+	u.Calls++
+	if destructive {
+		u.DestructiveCalls++
+	}
+	u.LastCall = now.UTC()
+	return "", *u
+}
+
+// override replaces one session's limits; usage so far is kept.
+func (b *sessionBudgets) override(session string, limits SessionBudgetConfig) sessionBudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u := b.entry(session, b.now())
+	u.MaxCalls = limits.MaxCalls
+	u.MaxDestructiveCalls = limits.MaxDestructiveCalls
+	u.Override = true
+	return *u
+}
+
+// reset forgets a session's usage and any override.
+func (b *sessionBudgets) reset(session string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.usage[session]
+	delete(b.usage, session)
+	return ok
+}
+
+// list returns per-session usage, most recently active first.
+func (b *sessionBudgets) list() []sessionBudgetUsage {
+	b.mu.Lock()
+	out := make([]sessionBudgetUsage, 0, len(b.usage))
+	for _, u := range b.usage {
+		out = append(out, *u)
+	}
+	b.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].LastCall.After(out[j].LastCall) })
+	return out
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionBudgetsCharge(t *testing.T) {
+	if newSessionBudgets(&SessionBudgetConfig{}, 0) != nil {
+		t.Fatalf("expected budgets without limits to be disabled")
+	}
+	var disabled *sessionBudgets
+	if limit, _ := disabled.charge("s1", true); limit != "" {
+		t.Fatalf("expected nil budgets to allow every call")
+	}
+
+	budgets := newSessionBudgets(&SessionBudgetConfig{MaxCalls: 3, MaxDestructiveCalls: 1}, time.Hour)
+	if limit, _ := budgets.charge("s1", true); limit != "" {
+		t.Fatalf("first destructive call should pass")
+	}
+	limit, usage := budgets.charge("s1", true)
+	if limit != "destructiveCalls" || usage.DestructiveCalls != 1 || usage.Calls != 1 {
+		t.Fatalf("expected destructive limit, got %q %+v", limit, usage)
+	}
+	budgets.charge("s1", false)
+	budgets.charge("s1", false)
+	if limit, usage := budgets.charge("s1", false); limit != "calls" || usage.Calls != 3 {
+		t.Fatalf("expected call limit, got %q %+v", limit, usage)
+	}
+	if limit, _ := budgets.charge("s2", false); limit != "" {
+		t.Fatalf("other sessions have their own budget")
+	}
+
+	budgets.override("s1", SessionBudgetConfig{MaxCalls: 10})
+	if limit, usage := budgets.charge("s1", true); limit != "" || usage.Calls != 4 || !usage.Override {
+		t.Fatalf("expected override to lift the limits, got %q %+v", limit, usage)
+	}
+	if !budgets.reset("s1") || budgets.reset("s1") {
+		t.Fatalf("expected reset to succeed exactly once")
+	}
+	if _, usage := budgets.charge("s1", false); usage.Calls != 1 || usage.MaxCalls != 3 || usage.Override {
+		t.Fatalf("expected reset to restore configured limits, got %+v", usage)
+	}
+}
+
+func TestSessionBudgetsForgetIdleSessions(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	budgets := newSessionBudgets(&SessionBudgetConfig{MaxCalls: 1}, time.Minute)
+	budgets.now = func() time.Time { return now }
+	budgets.charge("idle", false)
+	now = now.Add(2 * time.Minute)
+	budgets.charge("active", false)
+	if got := budgets.list(); len(got) != 1 || got[0].Session != "active" {
+		t.Fatalf("expected idle session to be forgotten, got %+v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-Id"
+	sessionIDHeader = "Mcp-Session-Id"
+
+	defaultMetaPrefix = "stelae/"
+)
+
+// callerInfo identifies who issued a facade request. It travels on the request
+// context so dispatch code and downstream header funcs can read it.
+type callerInfo struct {
+	Identity  string
+	SessionID string
+	RequestID string
+}
+
+type callerContextKey struct{}
+
+func withCallerInfo(ctx context.Context, info callerInfo) context.Context {
+	return context.WithValue(ctx, callerContextKey{}, info)
+}
+
+func callerFromContext(ctx context.Context) (callerInfo, bool) {
+	if ctx == nil {
+		return callerInfo{}, false
+	}
+	info, ok := ctx.Value(callerContextKey{}).(callerInfo)
+	return info, ok
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+// tokenFingerprint never exposes the token itself, only a short stable digest.
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func facadeSessionID(r *http.Request) string {
+	if v := strings.TrimSpace(r.Header.Get(sessionIDHeader)); v != "" {
+		return v
+	}
+	return r.URL.Query().Get("sessionId")
+}
+
+func resolveCallerInfo(r *http.Request) callerInfo {
+	info := callerInfo{
+		Identity:  "anonymous",
+		SessionID: facadeSessionID(r),
+		RequestID: strings.TrimSpace(r.Header.Get(requestIDHeader)),
+	}
+	if cert, ok := clientCertFromContext(r.Context()); ok {
+		info.Identity = cert.identity()
+	} else if token := bearerToken(r); token != "" {
+		info.Identity = "token:" + tokenFingerprint(token)
+	}
+	if info.RequestID == "" {
+		info.RequestID = uuid.New().String()
+	}
+	return info
+}
+
+// callerContextMiddleware resolves caller identity once per facade request;
+// re-entrant requests keep the identity established by the outer request.
+func callerContextMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := callerFromContext(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			info := resolveCallerInfo(r)
+			w.Header().Set(requestIDHeader, info.RequestID)
+			next.ServeHTTP(w, r.WithContext(withCallerInfo(r.Context(), info)))
+		})
+	}
+}
+
+// sessionKey identifies the caller's session for per-session state, falling
+// back to the caller identity for clients that send no session id.
+func (c callerInfo) sessionKey() string {
+	if c.SessionID != "" {
+		return c.SessionID
+	}
+	return c.Identity
+}
+
+func (c callerInfo) fields() map[string]string {
+	return map[string]string{
+		"caller":    c.Identity,
+		"sessionId": c.SessionID,
+		"requestId": c.RequestID,
+	}
+}
+
+// stampRequestMeta writes caller fields into params._meta of a JSON-RPC body.
+// Bodies that cannot be parsed are returned untouched.
+func stampRequestMeta(body []byte, info callerInfo, conf *ContextStampingConfig) []byte {
+	if conf == nil || (conf.Meta != nil && !*conf.Meta) {
+		return body
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return body
+	}
+	params := make(map[string]any)
+	if raw, ok := envelope["params"]; ok && len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil || params == nil {
+			return body
+		}
+	}
+	meta, _ := params["_meta"].(map[string]any)
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	prefix := conf.MetaPrefix
+	if prefix == "" {
+		prefix = defaultMetaPrefix
+	}
+	for key, value := range info.fields() {
+		if value != "" {
+			meta[prefix+key] = value
+		}
+	}
+	params["_meta"] = meta
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return body
+	}
+	envelope["params"] = encoded
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// callerHeaders maps caller fields to the header names configured for a server.
+func callerHeaders(ctx context.Context, conf *ContextStampingConfig) map[string]string {
+	if conf == nil || len(conf.Headers) == 0 {
+		return nil
+	}
+	info, ok := callerFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	fields := info.fields()
+	out := make(map[string]string, len(conf.Headers))
+	for field, header := range conf.Headers {
+		if value := fields[field]; value != "" && header != "" {
+			out[header] = value
+		}
+	}
+	return out
+}
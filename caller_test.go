@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStampRequestMetaAddsCallerFields(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","_meta":{"progressToken":"p1"}}}`)
+	info := callerInfo{Identity: "token:abc", SessionID: "s-1", RequestID: "r-1"}
+
+	out := stampRequestMeta(body, info, &ContextStampingConfig{})
+
+	var decoded struct {
+		Params struct {
+			Name string         `json:"name"`
+			Meta map[string]any `json:"_meta"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("unmarshal stamped body: %v", err)
+	}
+	if decoded.Params.Name != "echo" {
+		t.Fatalf("name = %q, want echo", decoded.Params.Name)
+	}
+	if decoded.Params.Meta["progressToken"] != "p1" {
+		t.Fatalf("expected existing _meta keys to be preserved")
+	}
+	if decoded.Params.Meta["stelae/caller"] != "token:abc" || decoded.Params.Meta["stelae/sessionId"] != "s-1" || decoded.Params.Meta["stelae/requestId"] != "r-1" {
+		t.Fatalf("unexpected stamped meta: %v", decoded.Params.Meta)
+	}
+}
+
+func TestStampRequestMetaDisabled(t *testing.T) {
+	body := []byte(`{"params":{"name":"echo"}}`)
+	disabled := false
+	if out := stampRequestMeta(body, callerInfo{Identity: "x"}, &ContextStampingConfig{Meta: &disabled}); string(out) != string(body) {
+		t.Fatalf("expected body untouched when meta stamping disabled, got %s", out)
+	}
+	if out := stampRequestMeta(body, callerInfo{Identity: "x"}, nil); string(out) != string(body) {
+		t.Fatalf("expected body untouched without config, got %s", out)
+	}
+}
+
+func TestCallerContextMiddlewareResolvesIdentity(t *testing.T) {
+	var got callerInfo
+	h := chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = callerFromContext(r.Context())
+	}), callerContextMiddleware())
+
+	r := httptest.NewRequest(http.MethodPost, "/mcp?sessionId=abc", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if got.Identity != "token:"+tokenFingerprint("secret") {
+		t.Fatalf("identity = %q", got.Identity)
+	}
+	if got.SessionID != "abc" {
+		t.Fatalf("session = %q, want abc", got.SessionID)
+	}
+	if got.RequestID == "" || w.Header().Get(requestIDHeader) != got.RequestID {
+		t.Fatalf("expected request id echoed in response header")
+	}
+
+	headers := callerHeaders(withCallerInfo(context.Background(), got), &ContextStampingConfig{Headers: map[string]string{"caller": "X-Caller"}})
+	if headers["X-Caller"] != got.Identity {
+		t.Fatalf("expected caller header mapping, got %v", headers)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	catalogJournalFileMaxSize    = 5 << 20
+	catalogJournalFileMaxBackups = 3
+	// catalogJournalRecentLimit is how many changes are kept in memory for
+	// the admin API.
+	catalogJournalRecentLimit = 1000
+)
+
+// Kinds of catalog change.
+const (
+	catalogToolAdded         = "added"
+	catalogToolRemoved       = "removed"
+	catalogToolSchemaChanged = "schema_changed"
+)
+
+// catalogChange is one journal line: a tool appearing, disappearing, or
+// changing its schemas on one server. Tools of a server that went down are
+// removed and come back as added when it reconnects.
+type catalogChange struct {
+	At           time.Time `json:"at"`
+	Server       string    `json:"server"`
+	Tool         string    `json:"tool"`
+	Change       string    `json:"change"`
+	SchemaHash   string    `json:"schemaHash,omitempty"`
+	PreviousHash string    `json:"previousHash,omitempty"`
+}
+
+// catalogJournal diffs the catalog each time it is rebuilt against the
+// last one it saw and appends the differences to
+// <state home>/catalog/journal.jsonl. The last catalog seen is saved next
+// to it, so the first diff after a restart is against the previous run.
+type catalogJournal struct {
+	statePath string
+	events    *eventBus
+
+	mu     sync.Mutex
+	out    *rotatingFile
+	tools  map[string]map[string]string // server -> tool -> schema hash
+	loaded bool
+	recent []catalogChange
+}
+
+func newCatalogJournal(events *eventBus) *catalogJournal {
+	j := &catalogJournal{events: events}
+	dir := filepath.Join(stateHome(), "catalog")
+	path, err := resolveLogFilePath(filepath.Join(dir, "journal.jsonl"))
+	if err == nil {
+		j.recent = readCatalogJournal(path, catalogJournalRecentLimit)
+		j.out, err = newRotatingFile(path, catalogJournalFileMaxSize, catalogJournalFileMaxBackups)
+	}
+	if err != nil {
+		logger("catalog").Warn("change journal not persisted", "err", err)
+		return j
+	}
+	j.statePath = filepath.Join(dir, "journal_state.json")
+	return j
+}
+
+// readCatalogJournal returns the last limit changes of the journal at path.
+func readCatalogJournal(path string, limit int) []catalogChange {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var out []catalogChange
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var change catalogChange
+		if json.Unmarshal(scanner.Bytes(), &change) != nil {
+			continue
+		}
+		out = append(out, change)
+		if len(out) > 2*limit {
+			out = append(out[:0], out[len(out)-limit:]...)
+		}
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+// loadState reads the catalog saved by the previous run; the caller holds
+// j.mu.
+func (j *catalogJournal) loadState() {
+	j.loaded = true
+	j.tools = make(map[string]map[string]string)
+	if j.statePath == "" {
+		return
+	}
+	data, err := os.ReadFile(j.statePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger("catalog").Warn("change journal state unreadable", "err", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &j.tools); err != nil {
+		logger("catalog").Warn("change journal state unreadable", "err", err)
+		j.tools = make(map[string]map[string]string)
+	}
+}
+
+// observe records how servers' tools differ from the last catalog seen.
+func (j *catalogJournal) observe(servers map[string]*Server, now time.Time) []catalogChange {
+	if j == nil {
+		return nil
+	}
+	current := make(map[string]map[string]string, len(servers))
+	for name, srv := range servers {
+		if srv == nil {
+			continue
+		}
+		tools := make(map[string]string, len(srv.tools))
+		for _, tool := range srv.tools {
+			tools[tool.Name] = toolSchemaHash(tool)
+		}
+		current[name] = tools
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.loaded {
+		j.loadState()
+	}
+	changes := diffCatalogTools(j.tools, current, now.UTC())
+	j.tools = current
+	if len(changes) == 0 {
+		return nil
+	}
+	j.recent = append(j.recent, changes...)
+	if len(j.recent) > catalogJournalRecentLimit {
+		j.recent = append([]catalogChange(nil), j.recent[len(j.recent)-catalogJournalRecentLimit:]...)
+	}
+	if j.out != nil {
+		for _, change := range changes {
+			if data, err := json.Marshal(change); err == nil {
+				_, _ = j.out.Write(append(data, '\n'))
+			}
+		}
+	}
+	if j.statePath != "" {
+		if data, err := json.Marshal(current); err == nil {
+			if err := writeAtomic(j.statePath, data); err != nil {
+				logger("catalog").Error("failed to save change journal state", "err", err)
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Change]++
+	}
+	logger("catalog").Info("catalog changed", "added", counts[catalogToolAdded], "removed", counts[catalogToolRemoved], "schemaChanged", counts[catalogToolSchemaChanged])
+	j.events.emit("catalog.changed", map[string]any{
+		"added":         counts[catalogToolAdded],
+		"removed":       counts[catalogToolRemoved],
+		"schemaChanged": counts[catalogToolSchemaChanged],
+	})
+	return changes
+}
+
+// diffCatalogTools lists the changes from prev to next, by server and tool.
+func diffCatalogTools(prev, next map[string]map[string]string, at time.Time) []catalogChange {
+	var out []catalogChange
+	for server, tools := range next {
+		for tool, hash := range tools {
+			before, ok := prev[server][tool]
+			switch {
+			case !ok:
+				out = append(out, catalogChange{At: at, Server: server, Tool: tool, Change: catalogToolAdded, SchemaHash: hash})
+			case before != hash:
+				out = append(out, catalogChange{At: at, Server: server, Tool: tool, Change: catalogToolSchemaChanged, SchemaHash: hash, PreviousHash: before})
+			}
+		}
+	}
+	for server, tools := range prev {
+		for tool, hash := range tools {
+			if _, ok := next[server][tool]; !ok {
+				out = append(out, catalogChange{At: at, Server: server, Tool: tool, Change: catalogToolRemoved, PreviousHash: hash})
+			}
+		}
+	}
+	sort.Slice(out, func(i, k int) bool {
+		if out[i].Server != out[k].Server {
+			return out[i].Server < out[k].Server
+		}
+		return out[i].Tool < out[k].Tool
+	})
+	return out
+}
+
+// changes returns up to limit recent changes, newest first, optionally
+// only those of server or after since.
+func (j *catalogJournal) changes(limit int, server string, since time.Time) []catalogChange {
+	out := make([]catalogChange, 0)
+	if j == nil {
+		return out
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := len(j.recent) - 1; i >= 0 && len(out) < limit; i-- {
+		change := j.recent[i]
+		if (server != "" && change.Server != server) || !change.At.After(since) {
+			continue
+		}
+		out = append(out, change)
+	}
+	return out
+}
+
+func registerCatalogJournalRoutes(api *adminAPI, journal *catalogJournal) {
+	api.handle(http.MethodGet, "catalog/changes", func(w http.ResponseWriter, r *http.Request) {
+		limit := 50
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+			limit = v
+		}
+		var since time.Time
+		if v := r.URL.Query().Get("since"); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "since must be an RFC 3339 time"})
+				return
+			}
+			since = parsed
+		}
+		api.writeJSON(w, http.StatusOK, map[string]any{"changes": journal.changes(limit, r.URL.Query().Get("server"), since)})
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCatalogJournal(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	search := mcp.NewTool("search", mcp.WithString("query"))
+	read := mcp.NewTool("read", mcp.WithString("path"))
+	servers := map[string]*Server{
+		"web": {name: "web", tools: []mcp.Tool{search}},
+		"fs":  {name: "fs", tools: []mcp.Tool{read}},
+	}
+
+	j := newCatalogJournal(nil)
+	if changes := j.observe(servers, start); len(changes) != 2 || changes[0].Server != "fs" || changes[0].Change != catalogToolAdded {
+		t.Fatalf("first catalog = %+v", changes)
+	}
+	if changes := j.observe(servers, start.Add(time.Minute)); len(changes) != 0 {
+		t.Fatalf("unchanged catalog = %+v", changes)
+	}
+
+	// a restart diffs against the catalog the previous run saw
+	j = newCatalogJournal(nil)
+	widened := mcp.NewTool("search", mcp.WithString("query"), mcp.WithNumber("limit"))
+	servers = map[string]*Server{
+		"web": {name: "web", tools: []mcp.Tool{widened, mcp.NewTool("fetch")}},
+	}
+	changes := j.observe(servers, start.Add(time.Hour))
+	want := []string{"fs/read removed", "web/fetch added", "web/search schema_changed"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes after restart = %+v", changes)
+	}
+	for i, change := range changes {
+		if got := change.Server + "/" + change.Tool + " " + change.Change; got != want[i] {
+			t.Fatalf("change %d = %s, want %s", i, got, want[i])
+		}
+	}
+	if changes[2].PreviousHash != toolSchemaHash(search) || changes[2].SchemaHash != toolSchemaHash(widened) {
+		t.Fatalf("schema change = %+v", changes[2])
+	}
+
+	data, err := os.ReadFile(filepath.Join(stateHome(), "catalog", "journal.jsonl"))
+	if err != nil || strings.Count(string(data), "\n") != 5 {
+		t.Fatalf("journal = %q, %v", data, err)
+	}
+
+	mux := http.NewServeMux()
+	registerCatalogJournalRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), newCatalogJournal(nil))
+	get := func(query string) (int, []catalogChange) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/catalog/changes"+query, nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var body struct {
+			Changes []catalogChange `json:"changes"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Changes
+	}
+	if code, list := get("?limit=4"); code != http.StatusOK || len(list) != 4 || list[0].Tool != "search" || list[3].Server != "web" {
+		t.Fatalf("recent changes = %d %+v", code, list)
+	}
+	if _, list := get("?server=fs"); len(list) != 2 || list[0].Change != catalogToolRemoved {
+		t.Fatalf("fs changes = %+v", list)
+	}
+	if _, list := get("?since=" + start.Add(time.Minute).Format(time.RFC3339)); len(list) != 3 {
+		t.Fatalf("changes since = %+v", list)
+	}
+	if code, _ := get("?since=yesterday"); code != http.StatusBadRequest {
+		t.Fatalf("bad since = %d", code)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	liveCatalogResourceURI     = "stelae://catalog/live"
+	liveDescriptorsResourceURI = "stelae://catalog/descriptors"
+)
+
+// catalogResources lists the proxy's own introspection resources. They are
+// served by the facade itself and never routed to a downstream server.
+func catalogResources() []map[string]any {
+	return []map[string]any{
+		{
+			"uri":         liveCatalogResourceURI,
+			"name":        "Live catalog",
+			"description": "Tools currently exposed by the proxy after overrides, with their owning servers.",
+			"mimeType":    "application/json",
+		},
+		{
+			"uri":         liveDescriptorsResourceURI,
+			"name":        "Live descriptors",
+			"description": "Raw tool descriptors as reported by each downstream server.",
+			"mimeType":    "application/json",
+		},
+	}
+}
+
+func isCatalogResource(uri string) bool {
+	return uri == liveCatalogResourceURI || uri == liveDescriptorsResourceURI
+}
+
+// catalogResourceResult wraps a snapshot as a resources/read result.
+func (p *proxy) catalogResourceResult(uri string, snapshot map[string]any) (map[string]any, error) {
+	data, err := p.json.marshalSnapshot(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", uri, err)
+	}
+	return map[string]any{
+		"contents": []map[string]any{{
+			"uri":      uri,
+			"mimeType": "application/json",
+			"text":     string(data),
+		}},
+	}, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCatalogResourcesListed(t *testing.T) {
+	seen := map[string]bool{}
+	for _, item := range catalogResources() {
+		uri, _ := item["uri"].(string)
+		if !isCatalogResource(uri) {
+			t.Fatalf("unexpected catalog resource %q", uri)
+		}
+		if item["mimeType"] != "application/json" {
+			t.Fatalf("mimeType = %v for %s", item["mimeType"], uri)
+		}
+		seen[uri] = true
+	}
+	if !seen[liveCatalogResourceURI] || !seen[liveDescriptorsResourceURI] {
+		t.Fatalf("missing catalog resources: %v", seen)
+	}
+	if isCatalogResource("stelae://catalog/other") {
+		t.Fatal("unknown stelae uri treated as catalog resource")
+	}
+}
+
+func TestCatalogResourceResult(t *testing.T) {
+	snapshot := map[string]any{"generatedAt": "2025-01-01T00:00:00Z", "tools": []any{map[string]any{"name": "echo"}}}
+	result, err := testProxy().catalogResourceResult(liveCatalogResourceURI, snapshot)
+	if err != nil {
+		t.Fatalf("catalogResourceResult: %v", err)
+	}
+	contents := result["contents"].([]map[string]any)
+	if len(contents) != 1 || contents[0]["uri"] != liveCatalogResourceURI {
+		t.Fatalf("contents = %#v", contents)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(contents[0]["text"].(string)), &decoded); err != nil {
+		t.Fatalf("text is not JSON: %v", err)
+	}
+	if decoded["generatedAt"] != "2025-01-01T00:00:00Z" {
+		t.Fatalf("decoded = %#v", decoded)
+	}
+}
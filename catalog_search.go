@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	searchTypeTool     = "tool"
+	searchTypePrompt   = "prompt"
+	searchTypeResource = "resource"
+	searchTypeDocument = "document"
+
+	facadeServerName = "facade"
+)
+
+// searchFilters narrow facade search to a server, an item type, or a MIME type.
+type searchFilters struct {
+	Server   string `json:"server,omitempty"`
+	Type     string `json:"type,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+func (f searchFilters) empty() bool {
+	return f.Server == "" && f.Type == "" && f.MimeType == ""
+}
+
+func searchMode(f searchFilters) string {
+	if f.empty() {
+		return "static"
+	}
+	return "catalog"
+}
+
+// searchCandidate is one searchable item: a static facade document or a
+// catalog entry (tool, prompt, resource) from a downstream server.
+type searchCandidate struct {
+	ID          string
+	Title       string
+	Text        string
+	URL         string
+	Server      string
+	Type        string
+	MimeType    string
+	Descriptor  any
+	searchSpace string
+}
+
+func (c searchCandidate) facet() map[string]any {
+	facet := map[string]any{"server": c.Server, "type": c.Type}
+	if c.MimeType != "" {
+		facet["mimeType"] = c.MimeType
+	}
+	return facet
+}
+
+func (c searchCandidate) hit() map[string]any {
+	return map[string]any{
+		"id":    c.ID,
+		"title": c.Title,
+		"text":  c.Text,
+		"url":   c.URL,
+		"metadata": map[string]any{
+			"snippet": c.Text,
+			"facet":   c.facet(),
+		},
+	}
+}
+
+func staticSearchCandidates() []searchCandidate {
+	out := make([]searchCandidate, 0, len(defaultFacadeSearchHits))
+	for _, hit := range defaultFacadeSearchHits {
+		out = append(out, searchCandidate{
+			ID:       hit.ID,
+			Title:    hit.Title,
+			Text:     hit.Snippet,
+			URL:      hit.URL,
+			Server:   facadeServerName,
+			Type:     searchTypeDocument,
+			MimeType: "text/markdown",
+		})
+	}
+	return out
+}
+
+// collectSearchCandidates lists catalog items as exposed to clients: disabled
+// servers and tools are skipped and tool aliases are applied.
+func collectSearchCandidates(servers map[string]*Server, overrides *ToolOverrideSet) []searchCandidate {
+	out := staticSearchCandidates()
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, serverName := range names {
+		if !serverEnabled(overrides, serverName) {
+			continue
+		}
+		srv := servers[serverName]
+		for _, tool := range srv.tools {
+			if !toolEnabled(overrides, serverName, tool.Name) {
+				continue
+			}
+			name := tool.Name
+			if overrides != nil {
+				if alias, ok := overrides.AliasForTool(tool.Name); ok {
+					name = alias
+				}
+			}
+			descriptor := applyToolOverride(tool.Name, toolDescriptorFromServer(tool), overrides)
+			description, _ := descriptor["description"].(string)
+			out = append(out, searchCandidate{
+				ID:         "tool:" + serverName + "/" + name,
+				Title:      name,
+				Text:       description,
+				URL:        "stelae://tools/" + serverName + "/" + name,
+				Server:     serverName,
+				Type:       searchTypeTool,
+				Descriptor: descriptor,
+			})
+		}
+		for _, prompt := range srv.prompts {
+			out = append(out, searchCandidate{
+				ID:         "prompt:" + serverName + "/" + prompt.Name,
+				Title:      prompt.Name,
+				Text:       prompt.Description,
+				URL:        "stelae://prompts/" + serverName + "/" + prompt.Name,
+				Server:     serverName,
+				Type:       searchTypePrompt,
+				Descriptor: prompt,
+			})
+		}
+		for _, res := range srv.resources {
+			title := res.Name
+			if title == "" {
+				title = res.URI
+			}
+			out = append(out, searchCandidate{
+				ID:          "resource:" + res.URI,
+				Title:       title,
+				Text:        res.Description,
+				URL:         res.URI,
+				Server:      serverName,
+				Type:        searchTypeResource,
+				MimeType:    res.MIMEType,
+				Descriptor:  res,
+				searchSpace: res.URI,
+			})
+		}
+	}
+	return out
+}
+
+func (c searchCandidate) matches(query string, filters searchFilters) bool {
+	if filters.Server != "" && !strings.EqualFold(filters.Server, c.Server) {
+		return false
+	}
+	if filters.Type != "" && !strings.EqualFold(filters.Type, c.Type) {
+		return false
+	}
+	if filters.MimeType != "" && !mimeTypeMatches(filters.MimeType, c.MimeType) {
+		return false
+	}
+	haystack := strings.ToLower(c.ID + " " + c.Title + " " + c.Text + " " + c.searchSpace)
+	for _, token := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(haystack, token) {
+			return false
+		}
+	}
+	return true
+}
+
+// mimeTypeMatches accepts exact types and "text/*" style wildcards.
+func mimeTypeMatches(filter, mimeType string) bool {
+	filter, mimeType = strings.ToLower(filter), strings.ToLower(mimeType)
+	if prefix, ok := strings.CutSuffix(filter, "/*"); ok {
+		return strings.HasPrefix(mimeType, prefix+"/")
+	}
+	return filter == mimeType
+}
+
+// buildFilteredSearchPayload searches the catalog when filters are given;
+// without filters it keeps the deterministic connector-verification hits.
+func buildFilteredSearchPayload(query string, filters searchFilters, candidates func() []searchCandidate) map[string]any {
+	if filters.empty() {
+		return buildFacadeSearchPayload(query)
+	}
+	results := make([]map[string]any, 0)
+	for _, c := range candidates() {
+		if c.matches(query, filters) {
+			results = append(results, c.hit())
+		}
+	}
+	return map[string]any{"results": results}
+}
+
+// buildCatalogFetchPayload serves fetch for catalog hit ids (tool:, prompt:,
+// resource:) by returning the item's descriptor as JSON text.
+func buildCatalogFetchPayload(id string, candidates []searchCandidate) (map[string]any, bool) {
+	for _, c := range candidates {
+		if c.ID != id || c.Descriptor == nil {
+			continue
+		}
+		text, err := json.MarshalIndent(c.Descriptor, "", "  ")
+		if err != nil {
+			return nil, false
+		}
+		return map[string]any{
+			"id":    c.ID,
+			"title": c.Title,
+			"text":  string(text),
+			"url":   c.URL,
+			"metadata": map[string]any{
+				"snippet": c.Text,
+				"facet":   c.facet(),
+			},
+		}, true
+	}
+	return nil, false
+}
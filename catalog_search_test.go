@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func testSearchServers() map[string]*Server {
+	return map[string]*Server{
+		"docs": {
+			name:  "docs",
+			tools: []mcp.Tool{{Name: "lookup", Description: "Look up a document by title"}},
+			prompts: []mcp.Prompt{
+				{Name: "summarize", Description: "Summarize a document"},
+			},
+			resources: []mcp.Resource{
+				{URI: "file:///docs/readme.md", Name: "readme", Description: "Project readme", MIMEType: "text/markdown"},
+				{URI: "file:///docs/logo.png", Name: "logo", MIMEType: "image/png"},
+			},
+		},
+		"web": {
+			name:  "web",
+			tools: []mcp.Tool{{Name: "crawl", Description: "Crawl a document URL"}},
+		},
+	}
+}
+
+func searchIDs(payload map[string]any) []string {
+	var ids []string
+	for _, hit := range payload["results"].([]map[string]any) {
+		ids = append(ids, hit["id"].(string))
+	}
+	return ids
+}
+
+func TestFilteredSearchFacets(t *testing.T) {
+	servers := testSearchServers()
+	candidates := func() []searchCandidate { return collectSearchCandidates(servers, nil) }
+
+	cases := []struct {
+		name    string
+		query   string
+		filters searchFilters
+		want    []string
+	}{
+		{"server filter", "document", searchFilters{Server: "docs"}, []string{"tool:docs/lookup", "prompt:docs/summarize"}},
+		{"type filter", "document", searchFilters{Type: "tool"}, []string{"tool:docs/lookup", "tool:web/crawl"}},
+		{"mime wildcard", "", searchFilters{MimeType: "text/*"}, []string{"repo:docs/SPEC-v1.md", "repo:dev/chat_gpt_connector_compliant_reference.md", "repo:dev/compliance_handoff.md", "resource:file:///docs/readme.md"}},
+		{"exact mime", "", searchFilters{Type: "resource", MimeType: "image/png"}, []string{"resource:file:///docs/logo.png"}},
+	}
+	for _, tc := range cases {
+		got := searchIDs(buildFilteredSearchPayload(tc.query, tc.filters, candidates))
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+
+	payload := buildFilteredSearchPayload("crawl", searchFilters{Type: "tool"}, candidates)
+	facet := payload["results"].([]map[string]any)[0]["metadata"].(map[string]any)["facet"].(map[string]any)
+	if facet["server"] != "web" || facet["type"] != "tool" {
+		t.Fatalf("unexpected facet %v", facet)
+	}
+}
+
+func TestFilteredSearchWithoutFiltersKeepsStaticHits(t *testing.T) {
+	payload := buildFilteredSearchPayload("anything", searchFilters{}, func() []searchCandidate {
+		t.Fatal("catalog should not be consulted without filters")
+		return nil
+	})
+	if len(searchIDs(payload)) != len(defaultFacadeSearchHits) {
+		t.Fatalf("expected static hits, got %v", searchIDs(payload))
+	}
+}
+
+func TestCatalogFetchPayload(t *testing.T) {
+	candidates := collectSearchCandidates(testSearchServers(), nil)
+	payload, ok := buildCatalogFetchPayload("tool:web/crawl", candidates)
+	if !ok || payload["title"] != "crawl" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+	if _, ok := buildCatalogFetchPayload("repo:docs/SPEC-v1.md", candidates); ok {
+		t.Fatal("static documents are served by the static fetch path")
+	}
+}
@@ -84,7 +84,7 @@ func parseToolSlice(val any) []map[string]any {
 	}
 }
 
-func writeSnapshotWithHistory(home, basePath string, payload any, historyCount int, stamp time.Time) (string, error) {
+func (p *proxy) writeSnapshotWithHistory(home, basePath string, payload any, historyCount int, stamp time.Time) (string, error) {
 	if stamp.IsZero() {
 		stamp = time.Now().UTC()
 	}
@@ -92,7 +92,7 @@ func writeSnapshotWithHistory(home, basePath string, payload any, historyCount i
 	if err != nil {
 		return "", err
 	}
-	data, err := json.MarshalIndent(payload, "", "  ")
+	data, err := p.json.marshalSnapshot(payload)
 	if err != nil {
 		return "", err
 	}
@@ -154,10 +154,10 @@ func pruneHistory(basePath string, keep int) error {
 	return nil
 }
 
-func collectLiveDescriptors(servers map[string]*Server) []map[string]any {
+func (p *proxy) collectLiveDescriptors(servers map[string]*Server) []map[string]any {
 	seen := make(map[string]*aggregatedTool)
-	for serverName, srv := range servers {
-		for _, tool := range srv.tools {
+	for _, serverName := range sortedServerNames(servers) {
+		for _, tool := range servers[serverName].tools {
 			descriptor := toolDescriptorFromServer(tool)
 			entry, exists := seen[tool.Name]
 			if exists {
@@ -185,7 +185,7 @@ func collectLiveDescriptors(servers map[string]*Server) []map[string]any {
 			record = make(map[string]any)
 		}
 		record["name"] = name
-		record["servers"] = entry.serverList()
+		record["servers"] = entry.serverList(p.conflicts)
 		if hash := hashSchema(record); hash != "" {
 			record["schemaHash"] = hash
 		}
@@ -203,15 +203,15 @@ func hashSchema(record map[string]any) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func buildLiveCatalogSnapshot(config *Config, servers map[string]*Server, overrides *ToolOverrideSet, intended *catalogFile, generatedAt time.Time) map[string]any {
-	snapshot := buildInitializeResult(config, servers, overrides, intended)
+func (p *proxy) buildLiveCatalogSnapshot(config *Config, servers map[string]*Server, overrides *ToolOverrideSet, intended *catalogFile, generatedAt time.Time) map[string]any {
+	snapshot := p.buildInitializeResult(config, servers, overrides, intended)
 	snapshot["generatedAt"] = generatedAt.UTC().Format(time.RFC3339Nano)
 	return snapshot
 }
 
-func buildLiveDescriptorSnapshot(servers map[string]*Server, generatedAt time.Time) map[string]any {
+func (p *proxy) buildLiveDescriptorSnapshot(servers map[string]*Server, generatedAt time.Time) map[string]any {
 	return map[string]any{
 		"generatedAt": generatedAt.UTC().Format(time.RFC3339Nano),
-		"tools":       collectLiveDescriptors(servers),
+		"tools":       p.collectLiveDescriptors(servers),
 	}
 }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitCoolDown         = 30 * time.Second
+
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+// CircuitBreakerConfig stops dispatching to a server after FailureThreshold
+// calls in a row failed at the transport level (timeouts, dispatch errors,
+// -32603 responses). Tool results with isError do not count. After CoolDown
+// one trial call goes through; its outcome closes or reopens the circuit.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `json:"failureThreshold,omitempty"`
+	CoolDown         time.Duration `json:"coolDown,omitempty"`
+}
+
+// callOutcome is what a dispatch tells the breaker: callNeutral (say, an
+// operator cancellation) neither trips nor heals the circuit.
+type callOutcome int
+
+const (
+	callSucceeded callOutcome = iota
+	callFailed
+	callNeutral
+)
+
+type circuitState struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+	// RetryAt is when an open circuit lets a trial call through.
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+
+	trial bool
+}
+
+// retryAfterSeconds is the Retry-After for a refused call: the rest of the
+// cool-down, or a second while a trial call is out.
+func (c circuitState) retryAfterSeconds(now time.Time) int {
+	if c.RetryAt == nil {
+		return 1
+	}
+	return max(1, int((c.RetryAt.Sub(now)+time.Second-1)/time.Second))
+}
+
+// circuitBreaker keeps one circuit per server. A nil breaker admits every
+// call.
+type circuitBreaker struct {
+	threshold int
+	coolDown  time.Duration
+	events    *eventBus
+
+	mu       sync.Mutex
+	circuits map[string]*circuitState
+}
+
+func newCircuitBreaker(conf *CircuitBreakerConfig, events *eventBus) *circuitBreaker {
+	if conf == nil {
+		return nil
+	}
+	b := &circuitBreaker{
+		threshold: conf.FailureThreshold,
+		coolDown:  conf.CoolDown,
+		events:    events,
+		circuits:  make(map[string]*circuitState),
+	}
+	if b.threshold <= 0 {
+		b.threshold = defaultCircuitFailureThreshold
+	}
+	if b.coolDown <= 0 {
+		b.coolDown = defaultCircuitCoolDown
+	}
+	return b
+}
+
+func (b *circuitBreaker) circuitLocked(server string) *circuitState {
+	c := b.circuits[server]
+	if c == nil {
+		c = &circuitState{State: circuitClosed}
+		b.circuits[server] = c
+	}
+	return c
+}
+
+// allow reports whether a call to server may go out. Once an open
+// circuit's cool-down passes, exactly one caller is let through as the
+// trial; the rest are refused until it reports back.
+func (b *circuitBreaker) allow(server string, now time.Time) (bool, circuitState) {
+	if b == nil {
+		return true, circuitState{State: circuitClosed}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuitLocked(server)
+	switch c.State {
+	case circuitOpen:
+		if now.Before(*c.RetryAt) {
+			return false, *c
+		}
+		c.State = circuitHalfOpen
+		c.trial = true
+		logger("facade").Info("circuit half-open, sending a trial call", "server", server)
+		return true, *c
+	case circuitHalfOpen:
+		if c.trial {
+			return false, *c
+		}
+		c.trial = true
+	}
+	return true, *c
+}
+
+// record reports how an admitted call went.
+func (b *circuitBreaker) record(server string, outcome callOutcome, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	c := b.circuitLocked(server)
+	before := c.State
+	wasTrial := c.trial
+	c.trial = false
+	switch outcome {
+	case callSucceeded:
+		c.State, c.Failures, c.OpenedAt, c.RetryAt = circuitClosed, 0, nil, nil
+	case callFailed:
+		c.Failures++
+		if before == circuitHalfOpen && wasTrial || before == circuitClosed && c.Failures >= b.threshold {
+			openedAt, retryAt := now.UTC(), now.Add(b.coolDown).UTC()
+			c.State, c.OpenedAt, c.RetryAt = circuitOpen, &openedAt, &retryAt
+		}
+	}
+	after, failures := c.State, c.Failures
+	b.mu.Unlock()
+
+	switch {
+	case after == circuitOpen && before != circuitOpen:
+		logger("facade").Warn("circuit open", "server", server, "consecutiveFailures", failures, "retryIn", b.coolDown)
+		b.events.emit("circuit.opened", map[string]any{"server": server, "failures": failures, "coolDownMs": b.coolDown.Milliseconds()})
+	case after == circuitClosed && before != circuitClosed:
+		logger("facade").Info("circuit closed", "server", server)
+		b.events.emit("circuit.closed", map[string]any{"server": server})
+	}
+}
+
+// state returns server's circuit, closed when it has none.
+func (b *circuitBreaker) state(server string) circuitState {
+	if b == nil {
+		return circuitState{State: circuitClosed}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.circuits[server]; c != nil {
+		return *c
+	}
+	return circuitState{State: circuitClosed}
+}
+
+// dispatchOutcome classifies a finished dispatch for the breaker: non-2xx
+// statuses and -32603 errors, which dispatchToClient uses for client
+// failures, are the server's fault.
+func dispatchOutcome(status int, rr *responseRecorder) callOutcome {
+	if status < 200 || status > 204 {
+		return callFailed
+	}
+	if rr == nil || rr.Body.Spilled() || rr.Streamed() {
+		return callSucceeded
+	}
+	var envelope struct {
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(rr.Body.Bytes(), &envelope) == nil && envelope.Error != nil && envelope.Error.Code == -32603 {
+		return callFailed
+	}
+	return callSucceeded
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Minute}, nil)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	b.record("web", callFailed, now)
+	b.record("web", callSucceeded, now)
+	b.record("web", callFailed, now)
+	if ok, state := b.allow("web", now); !ok || state.State != circuitClosed {
+		t.Fatalf("a success should reset the failure count: %+v", state)
+	}
+	b.record("web", callFailed, now)
+	ok, state := b.allow("web", now.Add(time.Second))
+	if ok || state.State != circuitOpen || state.retryAfterSeconds(now.Add(time.Second)) != 59 {
+		t.Fatalf("expected an open circuit, got ok=%t %+v", ok, state)
+	}
+	if ok, _ := b.allow("fs", now); !ok {
+		t.Fatal("one server's circuit refused another server's call")
+	}
+
+	// after the cool-down one trial goes out; its failure reopens the circuit
+	if ok, state := b.allow("web", now.Add(time.Minute)); !ok || state.State != circuitHalfOpen {
+		t.Fatalf("trial call refused: %+v", state)
+	}
+	if ok, _ := b.allow("web", now.Add(time.Minute)); ok {
+		t.Fatal("a second call went out during the trial")
+	}
+	b.record("web", callFailed, now.Add(time.Minute))
+	if ok, _ := b.allow("web", now.Add(90*time.Second)); ok {
+		t.Fatal("a failed trial did not reopen the circuit")
+	}
+
+	// a neutral trial leaves the next call to try again; a success closes it
+	if ok, _ := b.allow("web", now.Add(3*time.Minute)); !ok {
+		t.Fatal("trial call refused")
+	}
+	b.record("web", callNeutral, now.Add(3*time.Minute))
+	if ok, _ := b.allow("web", now.Add(3*time.Minute)); !ok {
+		t.Fatal("a neutral trial blocked the next one")
+	}
+	b.record("web", callSucceeded, now.Add(3*time.Minute))
+	if state := b.state("web"); state.State != circuitClosed || state.Failures != 0 {
+		t.Fatalf("state = %+v", state)
+	}
+}
+
+func TestDispatchOutcome(t *testing.T) {
+	body := func(s string) *responseRecorder {
+		rr := newResponseRecorder()
+		_, _ = rr.Write([]byte(s))
+		return rr
+	}
+	cases := []struct {
+		status int
+		body   string
+		want   callOutcome
+	}{
+		{http.StatusBadGateway, ``, callFailed},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"connection reset"}}`, callFailed},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"bad params"}}`, callSucceeded},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"result":{"isError":true,"content":[]}}`, callSucceeded},
+	}
+	for _, c := range cases {
+		if got := dispatchOutcome(c.status, body(c.body)); got != c.want {
+			t.Errorf("dispatchOutcome(%d, %s) = %d, want %d", c.status, c.body, got, c.want)
+		}
+	}
+}
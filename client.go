@@ -2,11 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/client"
@@ -16,79 +17,146 @@ import (
 )
 
 type Client struct {
-	name            string
-	needPing        bool
-	needManualStart bool
-	client          *client.Client
-	options         *OptionsV2
+	name     string
+	needPing bool
+	client   *client.Client
+	// transport is the client's transport, wrapped to declare capabilities.
+	transport *capabilityTransport
+	options   *OptionsV2
+	callLog   *serverLog
+	stderr    *stderrTail
+	// sampling serves the server's sampling/createMessage requests; nil
+	// leaves sampling unadvertised.
+	sampling client.SamplingHandler
+	// roots serves the server's roots/list; nil leaves roots unadvertised.
+	roots rootsHandler
+	// elicitation serves the server's elicitation/create; nil leaves
+	// elicitation unadvertised.
+	elicitation elicitationHandler
+
+	// pingInterval and pingTimeout pace the ping task; onPing, when set,
+	// receives each result and makes every transport ping, not only the
+	// network ones that need it to stay alive.
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+	onPing       func(latency time.Duration, err error)
+
+	mu           sync.Mutex
+	closed       bool
+	onDisconnect func(error)
+	// initResult is the server's initialize result and connectedAt when it
+	// arrived.
+	initResult  *mcp.InitializeResult
+	connectedAt time.Time
 }
 
-func newMCPClient(name string, conf *MCPClientConfigV2) (*Client, error) {
+// newMCPClient creates the client of server name. Protocol violations in
+// its responses are quarantined in violations, which may be nil.
+func newMCPClient(name string, conf *MCPClientConfigV2, violations *protocolViolations) (*Client, error) {
 	clientInfo, pErr := parseMCPClientConfigV2(conf)
 	if pErr != nil {
 		return nil, pErr
 	}
+	callLog, lErr := openServerLog(name, conf.Options)
+	if lErr != nil {
+		return nil, lErr
+	}
+	wire := newWireTap(name, violations)
+	wire.sanitize = conf.Options != nil && conf.Options.SanitizeResponses.OrElse(false)
 	switch v := clientInfo.(type) {
 	case *StdioMCPClientConfig:
 		envs := make([]string, 0, len(v.Env))
 		for kk, vv := range v.Env {
 			envs = append(envs, fmt.Sprintf("%s=%s", kk, vv))
 		}
-		mcpClient, err := client.NewStdioMCPClient(v.Command, envs, v.Args...)
+		stdio, err := startStdioProcess(v.Command, envs, v.Args, wire)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to start stdio transport: %w", err)
 		}
-
-		return &Client{
-			name:    name,
-			client:  mcpClient,
-			options: conf.Options,
-		}, nil
+		c := newClient(name, stdio, wire, conf.Options, callLog)
+		c.stderr = &stderrTail{}
+		go func() {
+			// stderr closes when the process exits
+			drainStderr(stdio.Stderr(), c.stderr, callLog)
+			c.disconnected(errors.New("process exited"))
+		}()
+		return c, nil
 	case *SSEMCPClientConfig:
-		var options []transport.ClientOption
+		options := []transport.ClientOption{transport.WithHTTPClient(wireHTTPClient(wire))}
 		if len(v.Headers) > 0 {
 			options = append(options, client.WithHeaders(v.Headers))
 		}
-		mcpClient, err := client.NewSSEMCPClient(v.URL, options...)
+		options = append(options, transport.WithHeaderFunc(downstreamHeaderFunc(conf.Options)))
+		sse, err := transport.NewSSE(v.URL, options...)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to create SSE transport: %w", err)
 		}
-		return &Client{
-			name:            name,
-			needPing:        true,
-			needManualStart: true,
-			client:          mcpClient,
-			options:         conf.Options,
-		}, nil
+		c := newClient(name, sse, wire, conf.Options, callLog)
+		c.needPing = true
+		return c, nil
 	case *StreamableMCPClientConfig:
-		var options []transport.StreamableHTTPCOption
+		// the client goes first: the timeout option sets it
+		options := []transport.StreamableHTTPCOption{transport.WithHTTPBasicClient(wireHTTPClient(wire))}
 		if len(v.Headers) > 0 {
 			options = append(options, transport.WithHTTPHeaders(v.Headers))
 		}
 		if v.Timeout > 0 {
 			options = append(options, transport.WithHTTPTimeout(v.Timeout))
 		}
-		mcpClient, err := client.NewStreamableHttpClient(v.URL, options...)
+		options = append(options, transport.WithHTTPHeaderFunc(downstreamHeaderFunc(conf.Options)))
+		streamable, err := transport.NewStreamableHTTP(v.URL, options...)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to create streamable HTTP transport: %w", err)
 		}
-		return &Client{
-			name:            name,
-			needPing:        true,
-			needManualStart: true,
-			client:          mcpClient,
-			options:         conf.Options,
-		}, nil
+		c := newClient(name, streamable, wire, conf.Options, callLog)
+		c.needPing = true
+		return c, nil
 	}
 	return nil, errors.New("invalid client type")
 }
 
-func (c *Client) addToMCPServer(ctx context.Context, clientInfo mcp.Implementation, srv *Server) error {
-	if c.needManualStart {
-		err := c.client.Start(ctx)
-		if err != nil {
-			return err
+// newClient wraps inner so the client can declare the capabilities it
+// bridges to facade clients and fail calls whose responses wire rejected.
+func newClient(name string, inner transport.Interface, wire *wireTap, options *OptionsV2, callLog *serverLog) *Client {
+	c := &Client{name: name, options: options, callLog: callLog}
+	c.transport = &capabilityTransport{Interface: inner, extra: c.extraCapabilities, wire: wire}
+	c.client = client.NewClient(c.transport)
+	return c
+}
+
+// downstreamHeaderFunc builds per-request headers for HTTP downstreams from
+// values the facade placed on the call context.
+func downstreamHeaderFunc(options *OptionsV2) transport.HTTPHeaderFunc {
+	var stamping *ContextStampingConfig
+	if options != nil {
+		stamping = options.ContextStamping
+	}
+	return func(ctx context.Context) map[string]string {
+		headers := deadlineHeaders(ctx)
+		for k, v := range traceHeaders(ctx) {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[k] = v
+		}
+		for k, v := range callerHeaders(ctx, stamping) {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[k] = v
 		}
+		return headers
+	}
+}
+
+func (c *Client) addToMCPServer(ctx context.Context, clientInfo mcp.Implementation, srv *Server) error {
+	if c.sampling != nil {
+		client.WithSamplingHandler(c.sampling)(c.client)
+	}
+	// Start skips the already running stdio transport but still installs
+	// the notification and server-request handlers every transport needs
+	if err := c.client.Start(ctx); err != nil {
+		return err
 	}
 	initRequest := mcp.InitializeRequest{}
 	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
@@ -98,11 +166,27 @@ func (c *Client) addToMCPServer(ctx context.Context, clientInfo mcp.Implementati
 		Roots:        nil,
 		Sampling:     nil,
 	}
-	_, err := c.client.Initialize(ctx, initRequest)
+	if c.roots != nil || c.elicitation != nil {
+		// only transports that carry server requests can serve them
+		if c.transport.bidirectional() {
+			c.transport.SetRequestHandler(c.handleServerRequest)
+		} else {
+			c.roots, c.elicitation = nil, nil
+		}
+	}
+	if c.roots != nil {
+		initRequest.Params.Capabilities.Roots = &struct {
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{ListChanged: true}
+	}
+	result, err := c.client.Initialize(ctx, initRequest)
 	if err != nil {
 		return err
 	}
-	log.Printf("<%s> Successfully initialized MCP client", c.name)
+	c.mu.Lock()
+	c.initResult, c.connectedAt = result, time.Now()
+	c.mu.Unlock()
+	clientLogger(c.name).Info("Successfully initialized MCP client")
 
 	err = c.addToolsToServer(ctx, srv)
 	if err != nil {
@@ -112,14 +196,86 @@ func (c *Client) addToMCPServer(ctx context.Context, clientInfo mcp.Implementati
 	_ = c.addResourcesToServer(ctx, srv)
 	_ = c.addResourceTemplatesToServer(ctx, srv)
 
-	if c.needPing {
+	if c.needPing || c.onPing != nil {
 		go c.startPingTask(ctx)
 	}
 	return nil
 }
 
+// handleServerRequest answers the requests a downstream server sends its
+// client. It replaces the mcp-go client's own handler once roots or
+// elicitation are bridged, so it serves sampling as well.
+func (c *Client) handleServerRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	var result any
+	switch request.Method {
+	case rootsListMethod:
+		if c.roots == nil {
+			return nil, fmt.Errorf("roots not supported")
+		}
+		roots, err := c.roots(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result = roots
+	case string(mcp.MethodSamplingCreateMessage):
+		if c.sampling == nil {
+			return nil, fmt.Errorf("no sampling handler configured")
+		}
+		var params mcp.CreateMessageParams
+		if request.Params != nil {
+			data, err := json.Marshal(request.Params)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, fmt.Errorf("invalid sampling params: %w", err)
+			}
+		}
+		created, err := c.sampling.CreateMessage(ctx, mcp.CreateMessageRequest{
+			Request:             mcp.Request{Method: request.Method},
+			CreateMessageParams: params,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = created
+	case elicitationCreateMethod:
+		if c.elicitation == nil {
+			return nil, fmt.Errorf("elicitation not supported")
+		}
+		answer, err := c.elicitation(ctx, request.Params)
+		if err != nil {
+			return nil, err
+		}
+		return &transport.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID, Result: answer}, nil
+	default:
+		return nil, fmt.Errorf("unsupported request method: %s", request.Method)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &transport.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID, Result: data}, nil
+}
+
+// extraCapabilities are the capabilities the client declares beyond what
+// mcp-go's initialize request carries.
+func (c *Client) extraCapabilities() map[string]any {
+	if c.elicitation == nil {
+		return nil
+	}
+	return map[string]any{"elicitation": map[string]any{}}
+}
+
 func (c *Client) startPingTask(ctx context.Context) {
-	interval := 30 * time.Second
+	interval := c.pingInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	timeout := c.pingTimeout
+	if timeout <= 0 {
+		timeout = interval
+	}
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -127,17 +283,24 @@ func (c *Client) startPingTask(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("<%s> Context done, stopping ping", c.name)
+			clientLogger(c.name).Debug("Context done, stopping ping")
 			return
 		case <-ticker.C:
-			if err := c.client.Ping(ctx); err != nil {
-				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-					return
-				}
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			started := time.Now()
+			err := c.client.Ping(pingCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			if c.onPing != nil {
+				c.onPing(time.Since(started), err)
+			}
+			if err != nil {
 				failCount++
-				log.Printf("<%s> MCP Ping failed: %v (count=%d)", c.name, err, failCount)
+				clientLogger(c.name).Warn("MCP Ping failed", "err", err, "count", failCount)
 			} else if failCount > 0 {
-				log.Printf("<%s> MCP Ping recovered after %d failures", c.name, failCount)
+				clientLogger(c.name).Info("MCP Ping recovered", "failures", failCount)
 				failCount = 0
 			}
 		}
@@ -161,7 +324,7 @@ func (c *Client) addToolsToServer(ctx context.Context, srv *Server) error {
 			filterFunc = func(toolName string) bool {
 				_, inList := filterSet[toolName]
 				if !inList {
-					log.Printf("<%s> Ignoring tool %s as it is not in allow list", c.name, toolName)
+					clientLogger(c.name).Debug("Ignoring tool not in allow list", "tool", toolName)
 				}
 				return inList
 			}
@@ -169,12 +332,12 @@ func (c *Client) addToolsToServer(ctx context.Context, srv *Server) error {
 			filterFunc = func(toolName string) bool {
 				_, inList := filterSet[toolName]
 				if inList {
-					log.Printf("<%s> Ignoring tool %s as it is in block list", c.name, toolName)
+					clientLogger(c.name).Debug("Ignoring tool in block list", "tool", toolName)
 				}
 				return !inList
 			}
 		default:
-			log.Printf("<%s> Unknown tool filter mode: %s, skipping tool filter", c.name, mode)
+			clientLogger(c.name).Warn("Unknown tool filter mode, skipping tool filter", "mode", mode)
 		}
 	}
 
@@ -186,11 +349,11 @@ func (c *Client) addToolsToServer(ctx context.Context, srv *Server) error {
 		if len(tools.Tools) == 0 {
 			break
 		}
-		log.Printf("<%s> Successfully listed %d tools", c.name, len(tools.Tools))
+		clientLogger(c.name).Info("Successfully listed tools", "count", len(tools.Tools))
 		for _, tool := range tools.Tools {
 			if filterFunc(tool.Name) {
-				log.Printf("<%s> Adding tool %s", c.name, tool.Name)
-				srv.mcpServer.AddTool(tool, c.client.CallTool)
+				clientLogger(c.name).Debug("Adding tool", "tool", tool.Name)
+				srv.mcpServer.AddTool(tool, c.callTool)
 				srv.addTool(tool)
 			}
 		}
@@ -213,10 +376,10 @@ func (c *Client) addPromptsToServer(ctx context.Context, srv *Server) error {
 		if len(prompts.Prompts) == 0 {
 			break
 		}
-		log.Printf("<%s> Successfully listed %d prompts", c.name, len(prompts.Prompts))
+		clientLogger(c.name).Info("Successfully listed prompts", "count", len(prompts.Prompts))
 		for _, prompt := range prompts.Prompts {
-			log.Printf("<%s> Adding prompt %s", c.name, prompt.Name)
-			srv.mcpServer.AddPrompt(prompt, c.client.GetPrompt)
+			clientLogger(c.name).Debug("Adding prompt", "prompt", prompt.Name)
+			srv.mcpServer.AddPrompt(prompt, c.getPrompt)
 			srv.addPrompt(prompt)
 		}
 		if prompts.NextCursor == "" {
@@ -237,16 +400,10 @@ func (c *Client) addResourcesToServer(ctx context.Context, srv *Server) error {
 		if len(resources.Resources) == 0 {
 			break
 		}
-		log.Printf("<%s> Successfully listed %d resources", c.name, len(resources.Resources))
+		clientLogger(c.name).Info("Successfully listed resources", "count", len(resources.Resources))
 		for _, resource := range resources.Resources {
-			log.Printf("<%s> Adding resource %s", c.name, resource.Name)
-			srv.mcpServer.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-				readResource, e := c.client.ReadResource(ctx, request)
-				if e != nil {
-					return nil, e
-				}
-				return readResource.Contents, nil
-			})
+			clientLogger(c.name).Debug("Adding resource", "resource", resource.Name)
+			srv.mcpServer.AddResource(resource, c.readResource)
 			srv.addResource(resource)
 		}
 		if resources.NextCursor == "" {
@@ -268,16 +425,10 @@ func (c *Client) addResourceTemplatesToServer(ctx context.Context, srv *Server)
 		if len(resourceTemplates.ResourceTemplates) == 0 {
 			break
 		}
-		log.Printf("<%s> Successfully listed %d resource templates", c.name, len(resourceTemplates.ResourceTemplates))
+		clientLogger(c.name).Info("Successfully listed resource templates", "count", len(resourceTemplates.ResourceTemplates))
 		for _, resourceTemplate := range resourceTemplates.ResourceTemplates {
-			log.Printf("<%s> Adding resource template %s", c.name, resourceTemplate.Name)
-			srv.mcpServer.AddResourceTemplate(resourceTemplate, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-				readResource, e := c.client.ReadResource(ctx, request)
-				if e != nil {
-					return nil, e
-				}
-				return readResource.Contents, nil
-			})
+			clientLogger(c.name).Debug("Adding resource template", "resourceTemplate", resourceTemplate.Name)
+			srv.mcpServer.AddResourceTemplate(resourceTemplate, c.readResource)
 			srv.addResourceTemplate(resourceTemplate)
 		}
 		if resourceTemplates.NextCursor == "" {
@@ -288,7 +439,91 @@ func (c *Client) addResourceTemplatesToServer(ctx context.Context, srv *Server)
 	return nil
 }
 
+func (c *Client) callTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	start := time.Now()
+	result, err := c.client.CallTool(ctx, request)
+	c.callLog.record("tools/call", request.Params.Name, request.Params, result, err, time.Since(start))
+	return result, err
+}
+
+func (c *Client) getPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	start := time.Now()
+	result, err := c.client.GetPrompt(ctx, request)
+	c.callLog.record("prompts/get", request.Params.Name, request.Params, result, err, time.Since(start))
+	return result, err
+}
+
+func (c *Client) readResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	start := time.Now()
+	result, err := c.client.ReadResource(ctx, request)
+	c.callLog.record("resources/read", request.Params.URI, request.Params, result, err, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+func (c *Client) subscribe(ctx context.Context, uri string) error {
+	start := time.Now()
+	request := mcp.SubscribeRequest{Params: mcp.SubscribeParams{URI: uri}}
+	err := c.client.Subscribe(ctx, request)
+	c.callLog.record("resources/subscribe", uri, request.Params, nil, err, time.Since(start))
+	return err
+}
+
+func (c *Client) unsubscribe(ctx context.Context, uri string) error {
+	start := time.Now()
+	request := mcp.UnsubscribeRequest{Params: mcp.UnsubscribeParams{URI: uri}}
+	err := c.client.Unsubscribe(ctx, request)
+	c.callLog.record("resources/unsubscribe", uri, request.Params, nil, err, time.Since(start))
+	return err
+}
+
+func (c *Client) complete(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	start := time.Now()
+	result, err := c.client.Complete(ctx, request)
+	c.callLog.record("completion/complete", request.Params.Argument.Name, request.Params, result, err, time.Since(start))
+	return result, err
+}
+
+// initialized returns the server's initialize result and when it arrived;
+// the result is nil before the client connected.
+func (c *Client) initialized() (*mcp.InitializeResult, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.initResult, c.connectedAt
+}
+
+// setDisconnectHandler registers the function told, once, that the client
+// lost its server. Closing the client on purpose does not count.
+func (c *Client) setDisconnectHandler(handler func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDisconnect = handler
+}
+
+func (c *Client) disconnected(err error) {
+	c.mu.Lock()
+	handler := c.onDisconnect
+	c.onDisconnect = nil
+	if c.closed {
+		handler = nil
+	}
+	c.mu.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
 func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	defer func() { _ = c.callLog.Close() }()
 	if c.client != nil {
 		return c.client.Close()
 	}
@@ -296,11 +531,16 @@ func (c *Client) Close() error {
 }
 
 type Server struct {
-	name              string
-	transport         MCPServerType
-	tokens            []string
-	mcpServer         *server.MCPServer
-	handler           http.Handler
+	name       string
+	transport  MCPServerType
+	tokens     []string
+	mcpServer  *server.MCPServer
+	handler    http.Handler
+	provenance *ProvenanceConfig
+	// toolPrefix is prepended to tool names on the facade (options.prefixTools).
+	toolPrefix string
+	// client is the downstream connection the facade dispatches to in-process.
+	client            *Client
 	tools             []mcp.Tool
 	prompts           []mcp.Prompt
 	resources         []mcp.Resource
@@ -340,23 +580,35 @@ func newMCPServer(name string, serverConfig *MCPProxyConfigV2, clientConfig *MCP
 		return nil, fmt.Errorf("unknown server type: %s", serverConfig.Type)
 	}
 	srv := &Server{
-		name:      name,
-		transport: serverConfig.Type,
-		mcpServer: mcpServer,
-		handler:   handler,
+		name:       name,
+		transport:  serverConfig.Type,
+		mcpServer:  mcpServer,
+		handler:    handler,
+		provenance: clientConfig.Provenance,
 	}
 
 	if clientConfig.Options != nil && len(clientConfig.Options.AuthTokens) > 0 {
 		srv.tokens = clientConfig.Options.AuthTokens
 	}
+	if clientConfig.Options != nil && clientConfig.Options.PrefixTools.OrElse(false) {
+		srv.toolPrefix = name + "_"
+	}
 
 	return srv, nil
 }
 
+// addTool records a tool under its facade name. The per-server route keeps
+// the downstream name.
 func (s *Server) addTool(tool mcp.Tool) {
+	tool.Name = s.toolPrefix + tool.Name
 	s.tools = append(s.tools, tool)
 }
 
+// downstreamToolName maps a facade tool name back to the server's own.
+func (s *Server) downstreamToolName(name string) string {
+	return strings.TrimPrefix(name, s.toolPrefix)
+}
+
 func (s *Server) addPrompt(prompt mcp.Prompt) {
 	s.prompts = append(s.prompts, prompt)
 }
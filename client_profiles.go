@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+)
+
+// Facade features a client profile can omit.
+const (
+	clientFeatureTools       = "tools"
+	clientFeaturePrompts     = "prompts"
+	clientFeatureResources   = "resources"
+	clientFeatureSampling    = "sampling"
+	clientFeatureRoots       = "roots"
+	clientFeatureElicitation = "elicitation"
+)
+
+var clientFeatures = []string{
+	clientFeatureTools,
+	clientFeaturePrompts,
+	clientFeatureResources,
+	clientFeatureSampling,
+	clientFeatureRoots,
+	clientFeatureElicitation,
+}
+
+// ClientProfileConfig shapes the facade for the sessions of some clients.
+// Client is a path.Match pattern on the clientInfo.name sent at initialize;
+// empty matches every client. Lacks limits the profile to clients that
+// declare none of the listed capabilities. Omit lists the features the
+// facade leaves out for those sessions: "tools", "prompts", and "resources"
+// are dropped from initialize and listed empty, and "sampling", "roots",
+// and "elicitation" are not relayed to the client even when it declares
+// them.
+type ClientProfileConfig struct {
+	Client string   `json:"client,omitempty"`
+	Lacks  []string `json:"lacks,omitempty"`
+	Omit   []string `json:"omit"`
+}
+
+// sessionClient is what a facade client said about itself at initialize.
+type sessionClient struct {
+	Name            string                     `json:"name,omitempty"`
+	Version         string                     `json:"version,omitempty"`
+	ProtocolVersion string                     `json:"protocolVersion,omitempty"`
+	Capabilities    map[string]json.RawMessage `json:"capabilities,omitempty"`
+}
+
+// parseSessionClient reads the client of initialize params; params that do
+// not parse give an anonymous client declaring nothing.
+func parseSessionClient(params json.RawMessage) sessionClient {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ClientInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"clientInfo"`
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+	_ = json.Unmarshal(params, &p)
+	return sessionClient{
+		Name:            p.ClientInfo.Name,
+		Version:         p.ClientInfo.Version,
+		ProtocolVersion: p.ProtocolVersion,
+		Capabilities:    p.Capabilities,
+	}
+}
+
+// declares reports whether the client declared the capability name.
+func (c sessionClient) declares(name string) bool {
+	capability := c.Capabilities[name]
+	return len(capability) > 0 && string(capability) != "null"
+}
+
+// clientProfiles picks the features omitted for each client.
+type clientProfiles struct {
+	profiles []*ClientProfileConfig
+}
+
+func newClientProfiles(profiles []*ClientProfileConfig) (*clientProfiles, error) {
+	for i, profile := range profiles {
+		if profile == nil {
+			return nil, fmt.Errorf("clientProfiles[%d]: empty profile", i)
+		}
+		if _, err := path.Match(profile.Client, ""); err != nil {
+			return nil, fmt.Errorf("clientProfiles[%d]: client %q: %w", i, profile.Client, err)
+		}
+		for _, feature := range profile.Omit {
+			if !slices.Contains(clientFeatures, feature) {
+				return nil, fmt.Errorf("clientProfiles[%d]: unknown feature %q", i, feature)
+			}
+		}
+	}
+	return &clientProfiles{profiles: profiles}, nil
+}
+
+// omitted returns the features omitted for client by every matching
+// profile, in the order of clientFeatures.
+func (p *clientProfiles) omitted(client sessionClient) []string {
+	if p == nil {
+		return nil
+	}
+	omit := make(map[string]bool)
+	for _, profile := range p.profiles {
+		if profile.Client != "" {
+			if ok, _ := path.Match(profile.Client, client.Name); !ok {
+				continue
+			}
+		}
+		if slices.ContainsFunc(profile.Lacks, client.declares) {
+			continue
+		}
+		for _, feature := range profile.Omit {
+			omit[feature] = true
+		}
+	}
+	var out []string
+	for _, feature := range clientFeatures {
+		if omit[feature] {
+			out = append(out, feature)
+		}
+	}
+	return out
+}
+
+// shapeInitializeResult drops the omitted features from a facade
+// initialize result.
+func shapeInitializeResult(result map[string]any, omit []string) map[string]any {
+	if len(omit) == 0 {
+		return result
+	}
+	capabilities, _ := result["capabilities"].(map[string]any)
+	capabilities = copyStringAnyMap(capabilities)
+	for _, feature := range omit {
+		switch feature {
+		case clientFeatureTools:
+			delete(capabilities, "tools")
+			result["tools"] = []map[string]any{}
+		case clientFeaturePrompts:
+			delete(capabilities, "prompts")
+			delete(result, "prompts")
+		case clientFeatureResources:
+			delete(capabilities, "resources")
+			delete(result, "resources")
+			delete(result, "resourceTemplates")
+		}
+	}
+	if _, ok := capabilities["prompts"]; !ok {
+		if _, ok := capabilities["resources"]; !ok {
+			delete(capabilities, "completions")
+		}
+	}
+	if capabilities != nil {
+		result["capabilities"] = capabilities
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestClientProfilesOmitted(t *testing.T) {
+	profiles, err := newClientProfiles([]*ClientProfileConfig{
+		{Client: "legacy-*", Omit: []string{clientFeaturePrompts}},
+		{Lacks: []string{"roots"}, Omit: []string{clientFeatureResources}},
+		{Client: "legacy-ide", Omit: []string{clientFeatureElicitation}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := parseSessionClient(json.RawMessage(`{
+		"protocolVersion": "2025-06-18",
+		"clientInfo": {"name": "legacy-ide", "version": "2.1"},
+		"capabilities": {"elicitation": {}, "roots": null}
+	}`))
+	if client.Name != "legacy-ide" || client.Version != "2.1" || client.ProtocolVersion != "2025-06-18" {
+		t.Fatalf("client = %+v", client)
+	}
+	if !client.declares("elicitation") || client.declares("roots") {
+		t.Fatalf("declares: %+v", client.Capabilities)
+	}
+	want := []string{clientFeaturePrompts, clientFeatureResources, clientFeatureElicitation}
+	if got := profiles.omitted(client); !slices.Equal(got, want) {
+		t.Fatalf("omitted = %v, want %v", got, want)
+	}
+	rooted := parseSessionClient(json.RawMessage(`{"clientInfo": {"name": "desktop"}, "capabilities": {"roots": {"listChanged": true}}}`))
+	if got := profiles.omitted(rooted); len(got) != 0 {
+		t.Fatalf("omitted for a client with roots = %v", got)
+	}
+	if got := (*clientProfiles)(nil).omitted(client); got != nil {
+		t.Fatalf("nil profiles omitted %v", got)
+	}
+
+	for _, conf := range []*ClientProfileConfig{
+		{Client: "[", Omit: []string{clientFeatureTools}},
+		{Omit: []string{"logging"}},
+		nil,
+	} {
+		if _, err := newClientProfiles([]*ClientProfileConfig{conf}); err == nil {
+			t.Errorf("profile %+v accepted", conf)
+		}
+	}
+}
+
+func TestShapeInitializeResult(t *testing.T) {
+	result := map[string]any{
+		"capabilities": map[string]any{
+			"tools":       map[string]any{},
+			"prompts":     map[string]any{},
+			"resources":   map[string]any{},
+			"completions": map[string]any{},
+		},
+		"tools":             []map[string]any{{"name": "echo"}},
+		"prompts":           []map[string]any{{"name": "greet"}},
+		"resources":         []map[string]any{{"uri": "file:///a"}},
+		"resourceTemplates": []map[string]any{{"name": "files"}},
+	}
+	shaped := shapeInitializeResult(result, []string{clientFeatureResources})
+	capabilities := shaped["capabilities"].(map[string]any)
+	if capabilities["resources"] != nil || shaped["resources"] != nil || shaped["resourceTemplates"] != nil {
+		t.Fatalf("resources kept: %v", shaped)
+	}
+	if capabilities["completions"] == nil || shaped["prompts"] == nil {
+		t.Fatalf("prompts or completions dropped: %v", shaped)
+	}
+	shaped = shapeInitializeResult(shaped, []string{clientFeaturePrompts, clientFeatureTools})
+	capabilities = shaped["capabilities"].(map[string]any)
+	if len(capabilities) != 0 || len(shaped["tools"].([]map[string]any)) != 0 {
+		t.Fatalf("shaped = %v", shaped)
+	}
+}
+
+func TestFacadeSessionClient(t *testing.T) {
+	sessions := newFacadeSessionTable(0)
+	id := sessions.open("streamable-http", "anonymous")
+	sessions.setClient(id, sessionClient{Name: "legacy-ide"}, []string{clientFeatureResources})
+	if !sessions.omits(id, clientFeatureResources) || sessions.omits(id, clientFeaturePrompts) || sessions.omits("unknown", clientFeatureResources) {
+		t.Fatal("omits does not follow the session's profile")
+	}
+	if list := sessions.list(); list[0].Client == nil || list[0].Client.Name != "legacy-ide" || !slices.Equal(list[0].Omit, []string{clientFeatureResources}) {
+		t.Fatalf("session = %+v", list[0])
+	}
+}
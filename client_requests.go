@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// clientRequestPrefix marks the ids of requests the proxy sends to facade
+// clients over their event streams.
+const clientRequestPrefix = "stelae-request-"
+
+var errNoClientStream = errors.New("session has no open event stream")
+
+// clientReply is a facade client's response to a request from the proxy.
+type clientReply struct {
+	Result json.RawMessage
+	Error  *jsonrpcError
+}
+
+// clientRequests sends JSON-RPC requests to facade clients over the event
+// streams of their session and matches the responses they POST back.
+type clientRequests struct {
+	streams *sessionStreams
+
+	mu      sync.Mutex
+	next    uint64
+	pending map[string]chan clientReply
+}
+
+func newClientRequests(streams *sessionStreams) *clientRequests {
+	return &clientRequests{streams: streams, pending: make(map[string]chan clientReply)}
+}
+
+// call sends method to session and waits for the client's result until ctx
+// ends. It returns errNoClientStream when no stream of session took the
+// request.
+func (r *clientRequests) call(ctx context.Context, session, method string, params any) (json.RawMessage, error) {
+	r.mu.Lock()
+	r.next++
+	id := fmt.Sprintf("%s%d", clientRequestPrefix, r.next)
+	reply := make(chan clientReply, 1)
+	r.pending[id] = reply
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}()
+
+	msg := map[string]any{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if r.streams.send(session, data) == 0 {
+		return nil, errNoClientStream
+	}
+	select {
+	case rep := <-reply:
+		if rep.Error != nil {
+			return nil, fmt.Errorf("client error %d: %s", rep.Error.Code, rep.Error.Message)
+		}
+		return rep.Result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no response from client: %w", ctx.Err())
+	}
+}
+
+// first sends method to the first of sessions with an open stream and
+// returns that session's answer. It returns errNoClientStream when none of
+// them has a stream open.
+func (r *clientRequests) first(ctx context.Context, sessions []string, method string, params any) (json.RawMessage, string, error) {
+	for _, session := range sessions {
+		result, err := r.call(ctx, session, method, params)
+		if errors.Is(err, errNoClientStream) {
+			continue
+		}
+		return result, session, err
+	}
+	return nil, "", errNoClientStream
+}
+
+// deliver hands a facade client's JSON-RPC response to the request it
+// answers. It reports false for bodies that answer no pending request.
+func (r *clientRequests) deliver(body []byte) bool {
+	if r == nil {
+		return false
+	}
+	var resp struct {
+		ID     string          `json:"id"`
+		Method string          `json:"method"`
+		Result json.RawMessage `json:"result"`
+		Error  *jsonrpcError   `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Method != "" || resp.ID == "" {
+		return false
+	}
+	r.mu.Lock()
+	reply, ok := r.pending[resp.ID]
+	delete(r.pending, resp.ID)
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	reply <- clientReply{Result: resp.Result, Error: resp.Error}
+	return true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// capabilityTransport wraps a downstream transport to declare client
+// capabilities that mcp-go's ClientCapabilities cannot express, such as
+// elicitation, in the initialize request. It passes the optional transport
+// interfaces mcp-go probes for through to the wrapped transport.
+type capabilityTransport struct {
+	transport.Interface
+	// extra returns the capabilities to add, keyed by name; nil adds none.
+	extra func() map[string]any
+	// wire taps the transport's messages; nil taps nothing.
+	wire *wireTap
+}
+
+// Start skips a stdio transport, which is started when it is created.
+func (t *capabilityTransport) Start(ctx context.Context) error {
+	if _, ok := t.Interface.(*stdioProcess); ok {
+		return nil
+	}
+	return t.Interface.Start(ctx)
+}
+
+func (t *capabilityTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == "initialize" && t.extra != nil {
+		if extra := t.extra(); len(extra) > 0 {
+			request.Params = withCapabilities(request.Params, extra)
+		}
+	}
+	resp, err := t.Interface.SendRequest(ctx, request)
+	outcome, _ := t.wire.take(request.ID)
+	if outcome.violation != nil {
+		return nil, &downstreamViolation{server: t.wire.server, method: request.Method, violation: outcome.violation, quarantineID: outcome.quarantineID}
+	}
+	if len(outcome.fixes) > 0 {
+		fixes := strings.Join(outcome.fixes, ", ")
+		clientLogger(t.wire.server).Warn("sanitized response", "method", request.Method, "fixes", fixes)
+		warn(ctx, responseWarning{Code: warnResponseSanitized, Message: "downstream response repaired: " + fixes, Server: t.wire.server})
+	}
+	return resp, err
+}
+
+// bidirectional reports whether the wrapped transport carries requests
+// from the server.
+func (t *capabilityTransport) bidirectional() bool {
+	_, ok := t.Interface.(transport.BidirectionalInterface)
+	return ok
+}
+
+func (t *capabilityTransport) SetRequestHandler(handler transport.RequestHandler) {
+	if bidirectional, ok := t.Interface.(transport.BidirectionalInterface); ok {
+		bidirectional.SetRequestHandler(handler)
+	}
+}
+
+func (t *capabilityTransport) SetProtocolVersion(version string) {
+	if conn, ok := t.Interface.(transport.HTTPConnection); ok {
+		conn.SetProtocolVersion(version)
+	}
+}
+
+func (t *capabilityTransport) SetConnectionLostHandler(handler func(error)) {
+	if setter, ok := t.Interface.(interface{ SetConnectionLostHandler(func(error)) }); ok {
+		setter.SetConnectionLostHandler(handler)
+	}
+}
+
+// stdioProcess is a stdio server the client runs itself rather than through
+// mcp-go, so that the server's stdout passes through the wire tap before
+// mcp-go reads it.
+type stdioProcess struct {
+	*transport.Stdio
+	cmd  *exec.Cmd
+	wait sync.Once
+	err  error
+}
+
+func startStdioProcess(command string, env, args []string, wire *wireTap) (*stdioProcess, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	stdio := transport.NewIO(newWireLines(stdout, wire), wireWriter{WriteCloser: stdin, tap: wire}, stderr)
+	if err := stdio.Start(context.Background()); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	return &stdioProcess{Stdio: stdio, cmd: cmd}, nil
+}
+
+// Close closes the server's stdin and waits for it to exit, as mcp-go's own
+// stdio transport does.
+func (p *stdioProcess) Close() error {
+	if err := p.Stdio.Close(); err != nil {
+		return err
+	}
+	p.wait.Do(func() { p.err = p.cmd.Wait() })
+	return p.err
+}
+
+// withCapabilities adds extra to the capabilities of initialize params. The
+// params are returned unchanged if they do not round-trip through JSON.
+func withCapabilities(params any, extra map[string]any) any {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return params
+	}
+	var out map[string]any
+	if json.Unmarshal(data, &out) != nil || out == nil {
+		return params
+	}
+	capabilities, _ := out["capabilities"].(map[string]any)
+	if capabilities == nil {
+		capabilities = make(map[string]any)
+	}
+	for name, value := range extra {
+		capabilities[name] = value
+	}
+	out["capabilities"] = capabilities
+	return out
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultCompletionCacheTTL     = 5 * time.Second
+	defaultCompletionMaxPerSecond = 10
+)
+
+// CompletionConfig tunes how completion/complete requests reach downstream
+// servers. Results are cached per (server, reference, argument, value) for
+// cacheTTL (default 5s; negative disables caching), and each server gets at
+// most maxPerSecond uncached requests (default 10; negative lifts the cap).
+type CompletionConfig struct {
+	CacheTTL     time.Duration `json:"cacheTTL,omitempty"`
+	MaxPerSecond float64       `json:"maxPerSecond,omitempty"`
+}
+
+var errCompletionThrottled = errors.New("completion requests to this server are rate limited")
+
+// completionKey identifies one completion request. Ref is the prompt name
+// or resource template URI the argument belongs to.
+type completionKey struct {
+	Server   string
+	Ref      string
+	Argument string
+	Value    string
+}
+
+type completionEntry struct {
+	result  *mcp.CompleteResult
+	expires time.Time
+}
+
+// completionFlight is a downstream request that identical requests wait on
+// instead of sending their own.
+type completionFlight struct {
+	done   chan struct{}
+	result *mcp.CompleteResult
+	err    error
+}
+
+// completionBucket is a server's token bucket, refilled at maxPerSecond.
+type completionBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// completionCache absorbs the per-keystroke completion traffic of
+// interactive clients: repeated requests are answered from cache,
+// concurrent identical ones share a downstream call, and the rest are rate
+// limited per server. Cached results are shared and must not be modified.
+type completionCache struct {
+	ttl          time.Duration
+	maxPerSecond float64
+	now          func() time.Time
+
+	mu       sync.Mutex
+	entries  map[completionKey]completionEntry
+	inFlight map[completionKey]*completionFlight
+	buckets  map[string]*completionBucket
+}
+
+func newCompletionCache(conf *CompletionConfig) *completionCache {
+	c := &completionCache{
+		ttl:          defaultCompletionCacheTTL,
+		maxPerSecond: defaultCompletionMaxPerSecond,
+		now:          time.Now,
+		entries:      make(map[completionKey]completionEntry),
+		inFlight:     make(map[completionKey]*completionFlight),
+		buckets:      make(map[string]*completionBucket),
+	}
+	if conf != nil {
+		if conf.CacheTTL != 0 {
+			c.ttl = conf.CacheTTL
+		}
+		if conf.MaxPerSecond != 0 {
+			c.maxPerSecond = conf.MaxPerSecond
+		}
+	}
+	return c
+}
+
+// complete answers key from cache, from an identical request in flight, or
+// by calling fetch. It returns errCompletionThrottled when the server is
+// over its rate.
+func (c *completionCache) complete(ctx context.Context, key completionKey, fetch func(context.Context) (*mcp.CompleteResult, error)) (*mcp.CompleteResult, error) {
+	c.mu.Lock()
+	now := c.now()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.result, nil
+	}
+	if flight := c.inFlight[key]; flight != nil {
+		c.mu.Unlock()
+		select {
+		case <-flight.done:
+			return flight.result, flight.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if !c.allowLocked(key.Server, now) {
+		c.mu.Unlock()
+		return nil, errCompletionThrottled
+	}
+	flight := &completionFlight{done: make(chan struct{})}
+	c.inFlight[key] = flight
+	c.mu.Unlock()
+
+	flight.result, flight.err = fetch(ctx)
+
+	c.mu.Lock()
+	delete(c.inFlight, key)
+	if flight.err == nil && c.ttl > 0 {
+		now := c.now()
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.entries[key] = completionEntry{result: flight.result, expires: now.Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	close(flight.done)
+	return flight.result, flight.err
+}
+
+// allowLocked takes a token from server's bucket. The caller holds c.mu.
+func (c *completionCache) allowLocked(server string, now time.Time) bool {
+	if c.maxPerSecond < 0 {
+		return true
+	}
+	// a burst of one second's worth, and never less than one request
+	burst := math.Max(c.maxPerSecond, 1)
+	bucket := c.buckets[server]
+	if bucket == nil {
+		bucket = &completionBucket{tokens: burst, last: now}
+		c.buckets[server] = bucket
+	}
+	bucket.tokens = math.Min(bucket.tokens+now.Sub(bucket.last).Seconds()*c.maxPerSecond, burst)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// Reference types of a completion/complete request.
+const (
+	completionRefPrompt   = "ref/prompt"
+	completionRefResource = "ref/resource"
+)
+
+// completeParams is the params of a facade completion/complete request.
+type completeParams struct {
+	Ref struct {
+		Type string `json:"type"`
+		Name string `json:"name,omitempty"`
+		URI  string `json:"uri,omitempty"`
+	} `json:"ref"`
+	Argument struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"argument"`
+}
+
+// owners returns the catalog index kinds the reference may be found under,
+// in lookup order, and the key to look up: a resource reference names a
+// resource template or, failing that, a resource.
+func (p completeParams) owners() ([]string, string) {
+	switch p.Ref.Type {
+	case completionRefPrompt:
+		return []string{"prompt"}, p.Ref.Name
+	case completionRefResource:
+		return []string{"template", "resource"}, p.Ref.URI
+	}
+	return nil, ""
+}
+
+// request is the downstream completion/complete for p.
+func (p completeParams) request() mcp.CompleteRequest {
+	var req mcp.CompleteRequest
+	if p.Ref.Type == completionRefPrompt {
+		req.Params.Ref = mcp.PromptReference{Type: p.Ref.Type, Name: p.Ref.Name}
+	} else {
+		req.Params.Ref = mcp.ResourceReference{Type: p.Ref.Type, URI: p.Ref.URI}
+	}
+	req.Params.Argument.Name = p.Argument.Name
+	req.Params.Argument.Value = p.Argument.Value
+	return req
+}
+
+// emptyCompletion answers a throttled request: no suggestions rather than
+// an error, so clients simply try again on the next keystroke.
+func emptyCompletion() *mcp.CompleteResult {
+	result := &mcp.CompleteResult{}
+	result.Completion.Values = []string{}
+	return result
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCompletionCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newCompletionCache(&CompletionConfig{CacheTTL: time.Second, MaxPerSecond: 2})
+	c.now = func() time.Time { return now }
+	calls := 0
+	fetch := func(value string) func(context.Context) (*mcp.CompleteResult, error) {
+		return func(context.Context) (*mcp.CompleteResult, error) {
+			calls++
+			result := &mcp.CompleteResult{}
+			result.Completion.Values = []string{value + "1"}
+			return result, nil
+		}
+	}
+	key := func(value string) completionKey {
+		return completionKey{Server: "fs", Ref: "open", Argument: "path", Value: value}
+	}
+	ctx := context.Background()
+
+	for range 3 {
+		result, err := c.complete(ctx, key("a"), fetch("a"))
+		if err != nil || result.Completion.Values[0] != "a1" {
+			t.Fatalf("complete = %v, %v", result, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("cached request reached the server %d times", calls)
+	}
+	if _, err := c.complete(ctx, key("ab"), fetch("ab")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.complete(ctx, key("abc"), fetch("abc")); !errors.Is(err, errCompletionThrottled) {
+		t.Fatalf("over the rate: %v", err)
+	}
+	other := completionKey{Server: "git", Ref: "open", Argument: "path", Value: "abc"}
+	if _, err := c.complete(ctx, other, fetch("abc")); err != nil {
+		t.Fatalf("another server throttled: %v", err)
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, err := c.complete(ctx, key("a"), fetch("a")); err != nil || calls != 4 {
+		t.Fatalf("expired entry not refetched: calls=%d err=%v", calls, err)
+	}
+	if len(c.entries) != 1 {
+		t.Fatalf("expired entries kept: %d", len(c.entries))
+	}
+}
+
+func TestCompletionCacheSharesInFlight(t *testing.T) {
+	c := newCompletionCache(&CompletionConfig{CacheTTL: -1, MaxPerSecond: 1})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fetch := func(context.Context) (*mcp.CompleteResult, error) {
+		close(started)
+		<-release
+		return &mcp.CompleteResult{}, nil
+	}
+	key := completionKey{Server: "fs", Ref: "open", Argument: "path", Value: "a"}
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := c.complete(context.Background(), key, fetch)
+		errs <- err
+	}()
+	<-started
+	// the second request reads the clock under the lock, before it looks
+	// for the request in flight
+	looked := make(chan struct{}, 1)
+	c.now = func() time.Time {
+		select {
+		case looked <- struct{}{}:
+		default:
+		}
+		return time.Now()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// over the rate, but served by the request already in flight
+		_, err := c.complete(context.Background(), key, func(context.Context) (*mcp.CompleteResult, error) {
+			t.Error("duplicate request reached the server")
+			return nil, nil
+		})
+		errs <- err
+	}()
+	<-looked
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.entries) != 0 {
+		t.Fatal("cached with caching disabled")
+	}
+}
+
+// completionTransport is a downstream server that completes prompt
+// arguments, or fails every completion when broken.
+type completionTransport struct {
+	broken bool
+	calls  int
+	params []json.RawMessage
+}
+
+func (t *completionTransport) Start(context.Context) error { return nil }
+func (t *completionTransport) SendNotification(context.Context, mcp.JSONRPCNotification) error {
+	return nil
+}
+func (t *completionTransport) SetNotificationHandler(func(mcp.JSONRPCNotification)) {}
+func (t *completionTransport) Close() error                                         { return nil }
+func (t *completionTransport) GetSessionId() string                                 { return "" }
+
+func (t *completionTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	resp := &transport.JSONRPCResponse{JSONRPC: "2.0", ID: request.ID}
+	switch request.Method {
+	case "initialize":
+		resp.Result = json.RawMessage(`{"protocolVersion":"2025-06-18","capabilities":{},"serverInfo":{"name":"prompts","version":"1"}}`)
+	case "completion/complete":
+		t.calls++
+		data, _ := json.Marshal(request.Params)
+		t.params = append(t.params, data)
+		if t.broken {
+			return nil, errors.New("method not found")
+		}
+		resp.Result = json.RawMessage(`{"completion":{"values":["python","pytorch"],"hasMore":false}}`)
+	}
+	return resp, nil
+}
+
+func TestDispatchCompletion(t *testing.T) {
+	px := testProxy()
+	px.completions = newCompletionCache(&CompletionConfig{CacheTTL: time.Minute})
+
+	downstream := &completionTransport{}
+	c := client.NewClient(downstream)
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(context.Background(), initRequest); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{name: "prompts", client: &Client{name: "prompts", client: c}}
+	complete := func(body string) map[string]any {
+		rr, _ := px.dispatchToClient(context.Background(), srv, []byte(body))
+		var resp struct {
+			Result struct {
+				Completion map[string]any `json:"completion"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Result.Completion
+	}
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"completion/complete","params":{"ref":{"type":"ref/prompt","name":"code_review"},"argument":{"name":"language","value":"py"}}}`
+	for i := 0; i < 2; i++ {
+		if got := complete(body); len(got["values"].([]any)) != 2 {
+			t.Fatalf("completion = %v", got)
+		}
+	}
+	if downstream.calls != 1 {
+		t.Fatalf("downstream saw %d requests, want 1 (cached)", downstream.calls)
+	}
+	var sent completeParams
+	_ = json.Unmarshal(downstream.params[0], &sent)
+	if sent.Ref.Type != completionRefPrompt || sent.Ref.Name != "code_review" || sent.Argument.Name != "language" || sent.Argument.Value != "py" {
+		t.Fatalf("downstream params = %s", downstream.params[0])
+	}
+
+	downstream.broken = true
+	got := complete(`{"jsonrpc":"2.0","id":2,"method":"completion/complete","params":{"ref":{"type":"ref/resource","uri":"file:///{path}"},"argument":{"name":"path","value":"sr"}}}`)
+	if values, ok := got["values"].([]any); !ok || len(values) != 0 {
+		t.Fatalf("failed completion = %v", got)
+	}
+	if kinds, key := (completeParams{}).owners(); kinds != nil || key != "" {
+		t.Fatal("a request without a reference resolved")
+	}
+}
+
+func TestCompletionOwners(t *testing.T) {
+	srv := &Server{name: "fs"}
+	srv.addResourceTemplate(mcp.NewResourceTemplate("file:///{path}", "files"))
+	srv.addResource(mcp.NewResource("file:///readme", "readme"))
+	index := testProxy().indexCatalog(map[string]*Server{"fs": srv}, nil)
+
+	var p completeParams
+	p.Ref.Type, p.Ref.URI = completionRefResource, "file:///{path}"
+	kinds, key := p.owners()
+	if owner, ok := index.owner(kinds[0], key); !ok || owner != "fs" {
+		t.Fatalf("template owner = %q, %t", owner, ok)
+	}
+	p.Ref.URI = "file:///readme"
+	if _, ok := index.owner(kinds[0], p.Ref.URI); ok {
+		t.Fatal("a resource URI matched as a template")
+	}
+	if owner, ok := index.owner(kinds[1], p.Ref.URI); !ok || owner != "fs" {
+		t.Fatalf("resource owner = %q, %t", owner, ok)
+	}
+	if ref, ok := p.request().Params.Ref.(mcp.ResourceReference); !ok || ref.URI != "file:///readme" {
+		t.Fatalf("downstream ref = %#v", p.request().Params.Ref)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const defaultCompressionMinSize = 1024
+
+var defaultCompressionEncodings = []string{"zstd", "br", "gzip"}
+
+// CompressionConfig turns on Content-Encoding negotiation for JSON
+// responses. Encodings lists the codings the proxy offers, most preferred
+// first; among those a client accepts, the highest q-value wins and ties go
+// to the proxy's order.
+type CompressionConfig struct {
+	Encodings []string `json:"encodings,omitempty"`
+	// MinSize is the smallest response body, in bytes, worth compressing.
+	MinSize int `json:"minSize,omitempty"`
+}
+
+// streamEncoder is what gzip.Writer, brotli.Writer, and zstd.Encoder have in
+// common, so one encoder can be pooled and reused across responses.
+type streamEncoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// zstdEncoder adapts zstd.Encoder, whose Reset does not match.
+type zstdEncoder struct{ *zstd.Encoder }
+
+func (e zstdEncoder) Reset(w io.Writer) { e.Encoder.Reset(w) }
+
+var encoderFactories = map[string]func() streamEncoder{
+	"gzip": func() streamEncoder { return gzip.NewWriter(io.Discard) },
+	"br":   func() streamEncoder { return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression) },
+	"zstd": func() streamEncoder {
+		enc, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return zstdEncoder{enc}
+	},
+}
+
+// compressionHandler compresses JSON responses from next for clients that
+// accept one of the configured encodings. Event streams, upgrades, and
+// bodies under minSize go out as they are.
+type compressionHandler struct {
+	next      http.Handler
+	encodings []string
+	minSize   int
+	pools     map[string]*sync.Pool
+}
+
+func newCompressionHandler(next http.Handler, conf *CompressionConfig) (http.Handler, error) {
+	if conf == nil {
+		return next, nil
+	}
+	h := &compressionHandler{
+		next:      next,
+		encodings: conf.Encodings,
+		minSize:   conf.MinSize,
+		pools:     make(map[string]*sync.Pool),
+	}
+	if len(h.encodings) == 0 {
+		h.encodings = defaultCompressionEncodings
+	}
+	if h.minSize <= 0 {
+		h.minSize = defaultCompressionMinSize
+	}
+	for _, coding := range h.encodings {
+		factory, ok := encoderFactories[coding]
+		if !ok {
+			return nil, fmt.Errorf("compression: unsupported encoding %q (want zstd, br, or gzip)", coding)
+		}
+		h.pools[coding] = &sync.Pool{New: func() any { return factory() }}
+	}
+	logger("compression").Info("enabled", "encodings", strings.Join(h.encodings, ","), "minSize", h.minSize)
+	return h, nil
+}
+
+func (h *compressionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	coding := negotiateEncoding(r.Header.Get("Accept-Encoding"), h.encodings)
+	if coding == "" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	cw := &compressWriter{ResponseWriter: w, handler: h, coding: coding}
+	defer cw.close()
+	h.next.ServeHTTP(cw, r)
+}
+
+// negotiateEncoding picks the offered coding with the highest q-value in
+// accept, preferring earlier offers on ties. It returns "" when the client
+// accepts none of them.
+func negotiateEncoding(accept string, offered []string) string {
+	if accept == "" {
+		return ""
+	}
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		qs[coding] = q
+	}
+	best, bestQ := "", 0.0
+	for _, coding := range offered {
+		q, ok := qs[coding]
+		if !ok {
+			q = qs["*"]
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressWriter holds back the start of a response until it knows whether
+// to compress it: JSON bodies that reach minSize are encoded as they stream,
+// everything else is written through unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	handler *compressionHandler
+	coding  string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     streamEncoder
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	switch {
+	case cw.decided:
+		cw.ResponseWriter.WriteHeader(code)
+	case cw.status != 0:
+	case code < 200:
+		// informational responses go out before the real one
+		cw.ResponseWriter.WriteHeader(code)
+	case code == http.StatusNoContent || code == http.StatusNotModified:
+		cw.decided = true
+		cw.ResponseWriter.WriteHeader(code)
+	default:
+		cw.status = code
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+	if !cw.compressible() {
+		cw.passThrough()
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.handler.minSize {
+		if err := cw.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// compressible reports whether the response so far is JSON that nothing
+// else has encoded and that is not known to be too small.
+func (cw *compressWriter) compressible() bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return false
+	}
+	if n, err := strconv.Atoi(header.Get("Content-Length")); err == nil && n < cw.handler.minSize {
+		return false
+	}
+	return true
+}
+
+func (cw *compressWriter) writeStatus() {
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+}
+
+func (cw *compressWriter) passThrough() {
+	cw.decided = true
+	cw.writeStatus()
+	if len(cw.buf) > 0 {
+		_, _ = cw.ResponseWriter.Write(cw.buf)
+		cw.buf = nil
+	}
+}
+
+func (cw *compressWriter) startEncoding() error {
+	cw.decided = true
+	header := cw.Header()
+	header.Set("Content-Encoding", cw.coding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	cw.writeStatus()
+	cw.enc = cw.handler.pools[cw.coding].Get().(streamEncoder)
+	cw.enc.Reset(cw.ResponseWriter)
+	_, err := cw.enc.Write(cw.buf)
+	cw.buf = nil
+	return err
+}
+
+// FlushError sends what has been written so far. An undecided response is
+// flushed as is, since a handler that flushes is streaming.
+func (cw *compressWriter) FlushError() error {
+	if !cw.decided {
+		cw.passThrough()
+	}
+	if cw.enc != nil {
+		if err := cw.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) Flush() { _ = cw.FlushError() }
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter { return cw.ResponseWriter }
+
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		cw.passThrough()
+	}
+	if cw.enc != nil {
+		if err := cw.enc.Close(); err != nil {
+			logger("compression").Warn("failed to finish response", "coding", cw.coding, "err", err)
+		}
+		cw.enc.Reset(io.Discard)
+		cw.handler.pools[cw.coding].Put(cw.enc)
+		cw.enc = nil
+	}
+}
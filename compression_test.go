@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	offered := []string{"zstd", "br", "gzip"}
+	cases := map[string]string{
+		"":                            "",
+		"identity":                    "",
+		"gzip, deflate, br":           "br",
+		"gzip;q=1.0, br;q=0.5":        "gzip",
+		"zstd, br":                    "zstd",
+		"*":                           "zstd",
+		"*;q=0.1, gzip":               "gzip",
+		"br;q=0, gzip;q=0":            "",
+		"GZIP; q=0.8, deflate":        "gzip",
+		"zstd;q=0.5, br;q=0.5, *;q=0": "zstd",
+	}
+	for accept, want := range cases {
+		if got := negotiateEncoding(accept, offered); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestCompressionHandler(t *testing.T) {
+	large := `{"tools":[` + strings.Repeat(`{"name":"search","description":"find things"},`, 100) + `{}]}`
+	handler, err := newCompressionHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			// under minSize first, so the start of the body is held back
+			_, _ = io.WriteString(w, large[:100])
+			_, _ = io.WriteString(w, large[100:])
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"ok":true}`)
+		case "/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, strings.Repeat(":\n\n", 1000))
+			w.(http.Flusher).Flush()
+		}
+	}), &CompressionConfig{MinSize: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoders := map[string]func(io.Reader) io.Reader{
+		"gzip": func(r io.Reader) io.Reader { zr, _ := gzip.NewReader(r); return zr },
+		"br":   func(r io.Reader) io.Reader { return brotli.NewReader(r) },
+		"zstd": func(r io.Reader) io.Reader { zr, _ := zstd.NewReader(r); return zr },
+	}
+	for coding, decode := range decoders {
+		for range 2 { // the second round reuses a pooled encoder
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/large", nil)
+			req.Header.Set("Accept-Encoding", coding)
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusCreated || rr.Header().Get("Content-Encoding") != coding || rr.Header().Get("Vary") != "Accept-Encoding" {
+				t.Fatalf("%s: status=%d headers=%v", coding, rr.Code, rr.Header())
+			}
+			if rr.Body.Len() >= len(large) {
+				t.Fatalf("%s: body not compressed (%d bytes)", coding, rr.Body.Len())
+			}
+			body, err := io.ReadAll(decode(bytes.NewReader(rr.Body.Bytes())))
+			if err != nil || string(body) != large {
+				t.Fatalf("%s: round trip failed: %v", coding, err)
+			}
+		}
+	}
+
+	for _, path := range []string{"/small", "/events"} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "br, gzip")
+		handler.ServeHTTP(rr, req)
+		if rr.Header().Get("Content-Encoding") != "" || rr.Code != http.StatusOK {
+			t.Fatalf("%s was compressed: %d %v", path, rr.Code, rr.Header())
+		}
+		if path == "/events" && !rr.Flushed {
+			t.Fatal("event stream flush did not reach the client")
+		}
+	}
+
+	if _, err := newCompressionHandler(http.NotFoundHandler(), &CompressionConfig{Encodings: []string{"deflate"}}); err == nil {
+		t.Fatal("expected an unsupported encoding to be rejected")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-sphere/confstore/provider/file"
+)
+
+// mergeConfigDir adds the servers of conf.ConfigDir to conf.McpServers.
+// Each <name>.json file in the directory holds one mcpServers entry, the
+// server <name>. A file replaces a config entry of the same name whole;
+// files are read in name order, and hidden and non-.json files are
+// skipped. A relative configDir is taken from the config file's directory.
+func mergeConfigDir(conf *FullConfig, configPath string, expandEnv bool) error {
+	dir, names, err := configDirFiles(conf, configPath, expandEnv)
+	if err != nil || dir == "" {
+		return err
+	}
+	if conf.McpServers == nil {
+		conf.McpServers = make(map[string]*MCPClientConfigV2)
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("configDir: %w", err)
+		}
+		if expandEnv {
+			data = []byte(os.ExpandEnv(string(data)))
+		}
+		var server MCPClientConfigV2
+		if err := json.Unmarshal(data, &server); err != nil {
+			return fmt.Errorf("configDir %s: %w", name, err)
+		}
+		serverName := strings.TrimSuffix(name, ".json")
+		if _, ok := conf.McpServers[serverName]; ok {
+			logger("config").Info("configDir file replaces mcpServers entry", "file", name, "server", serverName)
+		}
+		conf.McpServers[serverName] = &server
+	}
+	logger("config").Info("loaded servers from configDir", "count", len(names), "dir", dir)
+	return nil
+}
+
+// configDirFiles returns the directory conf.ConfigDir names and the server
+// files in it, in name order; dir is empty when there is no configDir.
+func configDirFiles(conf *FullConfig, configPath string, expandEnv bool) (dir string, names []string, err error) {
+	dir = strings.TrimSpace(conf.ConfigDir)
+	if dir == "" {
+		return "", nil, nil
+	}
+	if expandEnv {
+		dir = os.ExpandEnv(dir)
+	}
+	if !filepath.IsAbs(dir) {
+		if !file.IsLocalPath(configPath) {
+			return "", nil, fmt.Errorf("configDir %s: must be absolute when the config is fetched over HTTP", dir)
+		}
+		dir = filepath.Join(filepath.Dir(configPath), dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("configDir: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return dir, names, nil
+}
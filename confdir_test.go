@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMergesConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("FS_ROOT", "/srv/data")
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config.json", `{
+		"configDir": "servers.d",
+		"mcpProxy": {"addr": ":9090", "options": {"logEnabled": true}},
+		"mcpServers": {
+			"fs": {"command": "old-fs"},
+			"web": {"url": "http://web/mcp"}
+		}
+	}`)
+	write("servers.d/fs.json", `{"command": "fs-server", "args": ["$FS_ROOT"]}`)
+	write("servers.d/git.json", `{"command": "git-server", "dependsOn": ["fs"]}`)
+	write("servers.d/README.md", "not a server")
+	write("servers.d/.draft.json", `{"command": "draft"}`)
+
+	config, err := load(filepath.Join(dir, "config.json"), false, true, "", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.McpServers) != 3 {
+		t.Fatalf("servers = %v", config.McpServers)
+	}
+	fs := config.McpServers["fs"]
+	if fs.Command != "fs-server" || len(fs.Args) != 1 || fs.Args[0] != "/srv/data" {
+		t.Fatalf("fs = %+v", fs)
+	}
+	if git := config.McpServers["git"]; git.Command != "git-server" || !git.Options.LogEnabled.OrElse(false) {
+		t.Fatalf("git did not inherit mcpProxy.options: %+v", git)
+	}
+
+	write("servers.d/broken.json", `{"command": `)
+	if _, err := load(filepath.Join(dir, "config.json"), false, false, "", 0, ""); err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Fatalf("broken file error = %v", err)
+	}
+	write("config.json", `{"configDir": "missing.d", "mcpProxy": {}}`)
+	if _, err := load(filepath.Join(dir, "config.json"), false, false, "", 0, ""); err == nil || !strings.Contains(err.Error(), "configDir") {
+		t.Fatalf("missing dir error = %v", err)
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"crypto/tls"
 	"errors"
-	"log"
 	nethttp "net/http"
 	"strings"
 	"time"
@@ -62,25 +61,66 @@ type ToolFilterConfig struct {
 	List []string       `json:"list,omitempty"`
 }
 
+// ContextStampingConfig controls how caller identity, session id, and request
+// id are passed to a downstream server: inside params._meta and/or as headers.
+type ContextStampingConfig struct {
+	Meta       *bool             `json:"meta,omitempty"`
+	MetaPrefix string            `json:"metaPrefix,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
 type OptionsV2 struct {
 	PanicIfInvalid optional.Field[bool] `json:"panicIfInvalid,omitempty"`
 	LogEnabled     optional.Field[bool] `json:"logEnabled,omitempty"`
 	AuthTokens     []string             `json:"authTokens,omitempty"`
-	ToolFilter     *ToolFilterConfig    `json:"toolFilter,omitempty"`
+	// JWT accepts JWTs validated against a JWKS besides AuthTokens.
+	JWT               *JWTConfig             `json:"jwt,omitempty"`
+	ToolFilter        *ToolFilterConfig      `json:"toolFilter,omitempty"`
+	ContextStamping   *ContextStampingConfig `json:"contextStamping,omitempty"`
+	SanitizeResponses optional.Field[bool]   `json:"sanitizeResponses,omitempty"`
+	// PrefixTools exposes the server's tools as <server>_<tool>.
+	PrefixTools optional.Field[bool] `json:"prefixTools,omitempty"`
+	// Timeout bounds this server's dispatched calls in place of
+	// mcpProxy.maxCallTimeout; tool overrides may set their own.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// SessionAffinity keeps a session's calls on one replica of the
+	// server's group.
+	SessionAffinity *SessionAffinityConfig `json:"sessionAffinity,omitempty"`
+	// Elicitation set to false keeps mcpProxy.elicitation from reaching
+	// this server.
+	Elicitation optional.Field[bool] `json:"elicitation,omitempty"`
+	// LogFile is per server and never inherited from mcpProxy.options.
+	LogFile           string `json:"logFile,omitempty"`
+	LogFileMaxSizeMB  int    `json:"logFileMaxSizeMB,omitempty"`
+	LogFileMaxBackups int    `json:"logFileMaxBackups,omitempty"`
+	// LogLevel and LogPrefix shape this server's lines in the proxy log;
+	// neither is inherited from mcpProxy.options.
+	LogLevel  string `json:"logLevel,omitempty"`
+	LogPrefix string `json:"logPrefix,omitempty"`
+}
+
+// ProvenanceConfig records where a downstream server comes from so
+// consumers can audit each capability in the aggregated catalog.
+type ProvenanceConfig struct {
+	Source         string `json:"source,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Maintainer     string `json:"maintainer,omitempty"`
+	SecurityReview string `json:"securityReview,omitempty"`
 }
 
 type ManifestConfig struct {
-	Name              string                         `json:"name"`
-	Version           string                         `json:"version"`
-	Description       string                         `json:"description,omitempty"`
-	PublicBaseURL     string                         `json:"publicBaseURL,omitempty"`
-	LocalBaseURL      string                         `json:"localBaseURL,omitempty"`
-	SSEEndpoint       string                         `json:"sseEndpoint"`
-	ServerName        string                         `json:"serverName,omitempty"`
-	Resources         []interface{}                  `json:"resources,omitempty"`
-	ToolOverrides     map[string]*ToolOverrideConfig `json:"toolOverrides,omitempty"`
-	ToolOverridesPath string                         `json:"toolOverridesPath,omitempty"`
-	ToolSchemaStatusPath string                      `json:"toolSchemaStatusPath,omitempty"`
+	Name                 string                         `json:"name"`
+	Version              string                         `json:"version"`
+	Description          string                         `json:"description,omitempty"`
+	PublicBaseURL        string                         `json:"publicBaseURL,omitempty"`
+	LocalBaseURL         string                         `json:"localBaseURL,omitempty"`
+	SSEEndpoint          string                         `json:"sseEndpoint"`
+	ServerName           string                         `json:"serverName,omitempty"`
+	Resources            []interface{}                  `json:"resources,omitempty"`
+	ToolOverrides        map[string]*ToolOverrideConfig `json:"toolOverrides,omitempty"`
+	ToolOverridesPath    string                         `json:"toolOverridesPath,omitempty"`
+	ToolSchemaStatusPath string                         `json:"toolSchemaStatusPath,omitempty"`
+	ToolDocsDir          string                         `json:"toolDocsDir,omitempty"`
 }
 
 type ToolOverrideConfig struct {
@@ -90,6 +130,37 @@ type ToolOverrideConfig struct {
 	Enabled      *bool                     `json:"enabled,omitempty"`
 	InputSchema  map[string]any            `json:"inputSchema,omitempty"`
 	OutputSchema map[string]any            `json:"outputSchema,omitempty"`
+	Profiles     []string                  `json:"profiles,omitempty"`
+	// Examples are shown to agents under x-stelae.examples.
+	Examples []ToolExampleConfig `json:"examples,omitempty"`
+	// Pin records the tool's schemas as last accepted; only honoured in a
+	// server's tools map.
+	Pin *ToolPinConfig `json:"pin,omitempty"`
+	// Timeout bounds tools/call for the tool, ahead of the server's
+	// options.timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Retry forces (true) or forbids (false) retrying failed dispatches,
+	// whatever the tool's idempotentHint says.
+	Retry *bool `json:"retry,omitempty"`
+}
+
+// ToolPinConfig is a downstream tool's schemas at pin time. A tool whose
+// live schemas hash differently has drifted; with Serve the facade keeps
+// advertising the pinned schemas until the tool is pinned again.
+type ToolPinConfig struct {
+	SchemaHash   string         `json:"schemaHash"`
+	InputSchema  map[string]any `json:"inputSchema,omitempty"`
+	OutputSchema map[string]any `json:"outputSchema,omitempty"`
+	PinnedAt     string         `json:"pinnedAt,omitempty"`
+	Serve        bool           `json:"serve,omitempty"`
+}
+
+// ToolExampleConfig is one example invocation of a tool: the arguments and,
+// optionally, the shape of the result to expect.
+type ToolExampleConfig struct {
+	Description string         `json:"description,omitempty"`
+	Arguments   map[string]any `json:"arguments"`
+	Result      any            `json:"result,omitempty"`
 }
 
 type AnnotationOverrideConfig struct {
@@ -101,12 +172,73 @@ type AnnotationOverrideConfig struct {
 }
 
 type MCPProxyConfigV2 struct {
-	BaseURL string        `json:"baseURL"`
-	Addr    string        `json:"addr"`
-	Name    string        `json:"name"`
-	Version string        `json:"version"`
-	Type    MCPServerType `json:"type,omitempty"`
-	Options *OptionsV2    `json:"options,omitempty"`
+	BaseURL             string                          `json:"baseURL"`
+	Addr                string                          `json:"addr"`
+	Name                string                          `json:"name"`
+	Version             string                          `json:"version"`
+	Type                MCPServerType                   `json:"type,omitempty"`
+	MaxCallTimeout      time.Duration                   `json:"maxCallTimeout,omitempty"`
+	AdminTokens         []string                        `json:"adminTokens,omitempty"`
+	EventWebhooks       []string                        `json:"eventWebhooks,omitempty"`
+	SLOs                []*SLOConfig                    `json:"slos,omitempty"`
+	FlakinessDetection  *FlakinessConfig                `json:"flakinessDetection,omitempty"`
+	StartupStageTimeout time.Duration                   `json:"startupStageTimeout,omitempty"`
+	SlowCalls           *SlowCallConfig                 `json:"slowCalls,omitempty"`
+	WireLog             *WireLogConfig                  `json:"wireLog,omitempty"`
+	Audit               *AuditConfig                    `json:"audit,omitempty"`
+	NotificationRules   []*NotificationRule             `json:"notificationRules,omitempty"`
+	Fetch               *FetchConfig                    `json:"fetch,omitempty"`
+	FanOut              *FanOutConfig                   `json:"fanOut,omitempty"`
+	VirtualServers      map[string]*VirtualServerConfig `json:"virtualServers,omitempty"`
+	Sampling            *SamplingConfig                 `json:"sampling,omitempty"`
+	Completion          *CompletionConfig               `json:"completion,omitempty"`
+	Roots               *RootsConfig                    `json:"roots,omitempty"`
+	Elicitation         *ElicitationConfig              `json:"elicitation,omitempty"`
+	PanicReports        *PanicReportConfig              `json:"panicReports,omitempty"`
+	Errors              *ErrorsConfig                   `json:"errors,omitempty"`
+	Messages            *MessagesConfig                 `json:"messages,omitempty"`
+	Instructions        *InstructionsConfig             `json:"instructions,omitempty"`
+	OAuth               *OAuthConfig                    `json:"oauth,omitempty"`
+	ToolScopes          []*ToolScopeRule                `json:"toolScopes,omitempty"`
+	APITokens           *APITokensConfig                `json:"apiTokens,omitempty"`
+	RequestLimits       *RequestLimitsConfig            `json:"requestLimits,omitempty"`
+	CORS                *CORSConfig                     `json:"cors,omitempty"`
+	TrustedProxies      []string                        `json:"trustedProxies,omitempty"`
+	Redaction           *RedactionConfig                `json:"redaction,omitempty"`
+	Metrics             *MetricsConfig                  `json:"metrics,omitempty"`
+	Tracing             *TracingConfig                  `json:"tracing,omitempty"`
+	Logging             *LoggingConfig                  `json:"logging,omitempty"`
+	ReadOnly            bool                            `json:"readOnly,omitempty"`
+	SessionIdleTimeout  time.Duration                   `json:"sessionIdleTimeout,omitempty"`
+	ClientProfiles      []*ClientProfileConfig          `json:"clientProfiles,omitempty"`
+	BatchParallelism    int                             `json:"batchParallelism,omitempty"`
+	SessionBudget       *SessionBudgetConfig            `json:"sessionBudget,omitempty"`
+	LoopDetection       *LoopDetectionConfig            `json:"loopDetection,omitempty"`
+	DeadTools           *DeadToolConfig                 `json:"deadTools,omitempty"`
+	Probes              []*ProbeConfig                  `json:"probes,omitempty"`
+	Backpressure        *BackpressureConfig             `json:"backpressure,omitempty"`
+	CircuitBreaker      *CircuitBreakerConfig           `json:"circuitBreaker,omitempty"`
+	HealthChecks        *HealthCheckConfig              `json:"healthChecks,omitempty"`
+	Readiness           *ReadinessConfig                `json:"readiness,omitempty"`
+	Reconnect           *ReconnectConfig                `json:"reconnect,omitempty"`
+	Retries             *RetryConfig                    `json:"retries,omitempty"`
+	Compression         *CompressionConfig              `json:"compression,omitempty"`
+	AccessLog           *AccessLogConfig                `json:"accessLog,omitempty"`
+	// ResponseSpillThreshold is the response size, in bytes, past which
+	// recorded downstream responses move to a temp file; -1 disables.
+	ResponseSpillThreshold int64 `json:"responseSpillThreshold,omitempty"`
+	// JSON configures the formatting of responses and snapshots.
+	JSON           *JSONEncodingConfig   `json:"json,omitempty"`
+	Transcripts    *TranscriptConfig     `json:"transcripts,omitempty"`
+	GraphQL        bool                  `json:"graphql,omitempty"`
+	HTTP2          *HTTP2Config          `json:"http2,omitempty"`
+	TLS            *TLSConfig            `json:"tls,omitempty"`
+	ACME           *ACMEConfig           `json:"acme,omitempty"`
+	GRPCAdmin      *GRPCAdminConfig      `json:"grpcAdmin,omitempty"`
+	GRPCTools      bool                  `json:"grpcTools,omitempty"`
+	LoadBalancing  *LoadBalancingConfig  `json:"loadBalancing,omitempty"`
+	ConflictPolicy *ConflictPolicyConfig `json:"conflictPolicy,omitempty"`
+	Options        *OptionsV2            `json:"options,omitempty"`
 }
 
 type MCPClientConfigV2 struct {
@@ -122,6 +254,16 @@ type MCPClientConfigV2 struct {
 	Headers map[string]string `json:"headers,omitempty"`
 	Timeout time.Duration     `json:"timeout,omitempty"`
 
+	// DependsOn lists servers that must connect before this one starts.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Profiles limits the server to the listed profiles; empty means always.
+	Profiles []string `json:"profiles,omitempty"`
+	// Provenance is reported with the server's tools and in the manifest.
+	Provenance *ProvenanceConfig `json:"provenance,omitempty"`
+	// Group names the logical server this one is a replica of; calls to
+	// the group's tools are balanced across its replicas.
+	Group string `json:"group,omitempty"`
+
 	Options *OptionsV2 `json:"options,omitempty"`
 }
 
@@ -156,18 +298,27 @@ func parseMCPClientConfigV2(conf *MCPClientConfigV2) (any, error) {
 // ---- Config ----
 
 type Config struct {
+	Profile    string                        `json:"profile,omitempty"`
 	McpProxy   *MCPProxyConfigV2             `json:"mcpProxy"`
 	Manifest   *ManifestConfig               `json:"manifest"`
 	McpServers map[string]*MCPClientConfigV2 `json:"mcpServers"`
+	Variables  map[string]string             `json:"variables,omitempty"`
 }
 
 type FullConfig struct {
+	// Schema lets editors check the file against docs/config.schema.json.
+	Schema string `json:"$schema,omitempty"`
+
 	DeprecatedServerV1  *MCPProxyConfigV1             `json:"server"`
 	DeprecatedClientsV1 map[string]*MCPClientConfigV1 `json:"clients"`
 
 	McpProxy   *MCPProxyConfigV2             `json:"mcpProxy"`
 	Manifest   *ManifestConfig               `json:"manifest"`
 	McpServers map[string]*MCPClientConfigV2 `json:"mcpServers"`
+	Profiles   map[string]*ProfileConfig     `json:"profiles,omitempty"`
+	Variables  map[string]string             `json:"variables,omitempty"`
+	// ConfigDir holds one file per extra server; see mergeConfigDir.
+	ConfigDir string `json:"configDir,omitempty"`
 }
 
 func newConfProvider(path string, insecure, expandEnv bool, httpHeaders string, httpTimeout int) (provider.Provider, error) {
@@ -214,7 +365,7 @@ func newConfProvider(path string, insecure, expandEnv bool, httpHeaders string,
 	return nil, errors.New("unsupported config path")
 }
 
-func load(path string, insecure, expandEnv bool, httpHeaders string, httpTimeout int) (*Config, error) {
+func load(path string, insecure, expandEnv bool, httpHeaders string, httpTimeout int, profile string) (*Config, error) {
 	pro, err := newConfProvider(path, insecure, expandEnv, httpHeaders, httpTimeout)
 	if err != nil {
 		return nil, err
@@ -224,6 +375,9 @@ func load(path string, insecure, expandEnv bool, httpHeaders string, httpTimeout
 		return nil, err
 	}
 	adaptMCPClientConfigV1ToV2(conf)
+	if err := mergeConfigDir(conf, path, expandEnv); err != nil {
+		return nil, err
+	}
 
 	if conf.McpProxy == nil {
 		return nil, errors.New("mcpProxy is required")
@@ -231,19 +385,14 @@ func load(path string, insecure, expandEnv bool, httpHeaders string, httpTimeout
 	if conf.McpProxy.Options == nil {
 		conf.McpProxy.Options = &OptionsV2{}
 	}
-	for _, clientConfig := range conf.McpServers {
-		if clientConfig.Options == nil {
-			clientConfig.Options = &OptionsV2{}
-		}
-		if clientConfig.Options.AuthTokens == nil {
-			clientConfig.Options.AuthTokens = conf.McpProxy.Options.AuthTokens
-		}
-		if !clientConfig.Options.PanicIfInvalid.Present() {
-			clientConfig.Options.PanicIfInvalid = conf.McpProxy.Options.PanicIfInvalid
-		}
-		if !clientConfig.Options.LogEnabled.Present() {
-			clientConfig.Options.LogEnabled = conf.McpProxy.Options.LogEnabled
-		}
+	applyProfile(conf, profile)
+	if err := expandServerTemplates(conf); err != nil {
+		return nil, err
+	}
+	inheritServerOptions(conf.McpProxy.Options, conf.McpServers)
+
+	if _, err := startupStages(conf.McpServers); err != nil {
+		return nil, err
 	}
 
 	if conf.McpProxy.Type == "" {
@@ -251,12 +400,75 @@ func load(path string, insecure, expandEnv bool, httpHeaders string, httpTimeout
 	}
 
 	if conf.Manifest == nil {
-		log.Printf("<manifest> no manifest configuration found in config file")
+		logger("manifest").Info("no manifest configuration found in config file")
 	}
 
 	return &Config{
+		Profile:    profile,
 		McpProxy:   conf.McpProxy,
 		Manifest:   conf.Manifest,
 		McpServers: conf.McpServers,
+		Variables:  conf.Variables,
 	}, nil
 }
+
+// inheritServerOptions fills unset server options from mcpProxy.options.
+func inheritServerOptions(defaults *OptionsV2, servers map[string]*MCPClientConfigV2) {
+	for _, clientConfig := range servers {
+		if clientConfig.Options == nil {
+			clientConfig.Options = &OptionsV2{}
+		}
+		if clientConfig.Options.AuthTokens == nil {
+			clientConfig.Options.AuthTokens = defaults.AuthTokens
+		}
+		if clientConfig.Options.JWT == nil {
+			clientConfig.Options.JWT = defaults.JWT
+		}
+		if !clientConfig.Options.PanicIfInvalid.Present() {
+			clientConfig.Options.PanicIfInvalid = defaults.PanicIfInvalid
+		}
+		if !clientConfig.Options.LogEnabled.Present() {
+			clientConfig.Options.LogEnabled = defaults.LogEnabled
+		}
+		if clientConfig.Options.ContextStamping == nil {
+			clientConfig.Options.ContextStamping = defaults.ContextStamping
+		}
+		if !clientConfig.Options.SanitizeResponses.Present() {
+			clientConfig.Options.SanitizeResponses = defaults.SanitizeResponses
+		}
+		if !clientConfig.Options.PrefixTools.Present() {
+			clientConfig.Options.PrefixTools = defaults.PrefixTools
+		}
+		if clientConfig.Options.Timeout == 0 {
+			clientConfig.Options.Timeout = defaults.Timeout
+		}
+		if clientConfig.Options.SessionAffinity == nil {
+			clientConfig.Options.SessionAffinity = defaults.SessionAffinity
+		}
+		if !clientConfig.Options.Elicitation.Present() {
+			clientConfig.Options.Elicitation = defaults.Elicitation
+		}
+	}
+}
+
+// resolveServerConfigs prepares servers and tool overrides supplied at
+// runtime the way load prepares the config file: other profiles' entries are
+// dropped, variables expanded, options inherited, and dependsOn checked.
+func resolveServerConfigs(config *Config, servers map[string]*MCPClientConfigV2, overrides map[string]*ToolOverrideConfig) error {
+	for name, server := range servers {
+		if server != nil && !inProfile(server.Profiles, config.Profile) {
+			delete(servers, name)
+		}
+	}
+	for name, override := range overrides {
+		if override != nil && !inProfile(override.Profiles, config.Profile) {
+			delete(overrides, name)
+		}
+	}
+	if err := expandServerTemplates(&FullConfig{Variables: config.Variables, McpServers: servers}); err != nil {
+		return err
+	}
+	inheritServerOptions(config.McpProxy.Options, servers)
+	_, err := startupStages(servers)
+	return err
+}
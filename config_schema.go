@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/invopop/jsonschema"
+)
+
+const configSchemaID = "https://github.com/TBXark/mcp-proxy/docs/config.schema.json"
+
+// configSchema is the JSON Schema of the config file, reflected from
+// FullConfig. Objects reject keys the proxy does not know; durations are
+// integers of nanoseconds, as the config decoder reads them.
+func configSchema() *jsonschema.Schema {
+	r := &jsonschema.Reflector{
+		RequiredFromJSONSchemaTags: true,
+		Anonymous:                  true,
+		Mapper:                     configSchemaType,
+	}
+	s := r.Reflect(&FullConfig{})
+	s.ID = configSchemaID
+	s.Title = "mcp-proxy config"
+	return s
+}
+
+// printConfigSchema writes the config schema as docs/config.schema.json
+// publishes it.
+func printConfigSchema(w io.Writer) error {
+	data, err := json.MarshalIndent(configSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// configSchemaType maps the types the reflector would get wrong.
+func configSchemaType(t reflect.Type) *jsonschema.Schema {
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		return &jsonschema.Schema{Type: "integer", Description: "Duration in nanoseconds."}
+	case t.PkgPath() == "github.com/TBXark/optional-go" && strings.HasPrefix(t.Name(), "Field["):
+		// optional.Field[T] reads and writes a bare T
+		orElse, _ := reflect.PointerTo(t).MethodByName("OrElse")
+		value := (&jsonschema.Reflector{DoNotReference: true, Anonymous: true}).ReflectFromType(orElse.Type.Out(0))
+		value.Version = ""
+		return value
+	}
+	return nil
+}
+
+// schemaWalker checks a JSON document against a schema, collecting every
+// type mismatch, unknown key, and duplicate key with its path.
+type schemaWalker struct {
+	dec      *json.Decoder
+	defs     jsonschema.Definitions
+	problems []string
+}
+
+// checkConfigSchema returns the problems of data against the config schema.
+func checkConfigSchema(data []byte) ([]string, error) {
+	s := configSchema()
+	return checkSchema(data, s, s.Definitions)
+}
+
+// checkServerConfigSchema returns the problems of data against the schema
+// of one mcpServers entry, the content of a configDir file.
+func checkServerConfigSchema(data []byte) ([]string, error) {
+	s := configSchema()
+	return checkSchema(data, &jsonschema.Schema{Ref: "#/$defs/MCPClientConfigV2"}, s.Definitions)
+}
+
+func checkSchema(data []byte, s *jsonschema.Schema, defs jsonschema.Definitions) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	w := &schemaWalker{dec: dec, defs: defs}
+	if err := w.value("", s); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("parse config: data after the top-level value")
+	}
+	return w.problems, nil
+}
+
+func (w *schemaWalker) problem(path, format string, args ...any) {
+	if path == "" {
+		path = "(root)"
+	}
+	w.problems = append(w.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (w *schemaWalker) resolve(s *jsonschema.Schema) *jsonschema.Schema {
+	for s != nil && s.Ref != "" {
+		s = w.defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+	}
+	return s
+}
+
+// value walks the next value of the document; s may be nil, which accepts
+// anything.
+func (w *schemaWalker) value(path string, s *jsonschema.Schema) error {
+	s = w.resolve(s)
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+	got := jsonTokenType(tok)
+	if s != nil && s.Type != "" && got != "null" && got != s.Type && !(s.Type == "number" && got == "integer") {
+		w.problem(path, "expected %s, got %s", s.Type, got)
+		s = nil
+	}
+	switch got {
+	case "object":
+		seen := make(map[string]bool)
+		for w.dec.More() {
+			tok, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			child := joinConfigPath(path, key)
+			if seen[key] {
+				w.problem(child, "duplicate key")
+			}
+			seen[key] = true
+			var next *jsonschema.Schema
+			if s != nil {
+				if prop := schemaProperty(s, key); prop != nil {
+					next = prop
+				} else if s.AdditionalProperties == jsonschema.FalseSchema {
+					w.problem(child, "unknown key")
+				} else {
+					next = s.AdditionalProperties
+				}
+			}
+			if err := w.value(child, next); err != nil {
+				return err
+			}
+		}
+		_, err = w.dec.Token()
+		return err
+	case "array":
+		var items *jsonschema.Schema
+		if s != nil {
+			items = s.Items
+		}
+		for i := 0; w.dec.More(); i++ {
+			if err := w.value(fmt.Sprintf("%s[%d]", path, i), items); err != nil {
+				return err
+			}
+		}
+		_, err = w.dec.Token()
+		return err
+	}
+	return nil
+}
+
+func schemaProperty(s *jsonschema.Schema, key string) *jsonschema.Schema {
+	if s.Properties == nil {
+		return nil
+	}
+	prop, _ := s.Properties.Get(key)
+	return prop
+}
+
+// jsonTokenType names a token's JSON Schema type; numbers without a
+// fraction or exponent are integers.
+func jsonTokenType(tok json.Token) string {
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return "object"
+		}
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return "null"
+}
+
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPublishedConfigSchemaIsCurrent(t *testing.T) {
+	published, err := os.ReadFile("docs/config.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var current bytes.Buffer
+	if err := printConfigSchema(&current); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(published, current.Bytes()) {
+		t.Fatal("docs/config.schema.json is stale; regenerate it with: go run . schema > docs/config.schema.json")
+	}
+}
+
+func TestCheckConfigSchema(t *testing.T) {
+	problems, err := checkConfigSchema([]byte(`{
+		"$schema": "docs/config.schema.json",
+		"mcpProxy": {
+			"addr": ":9090",
+			"maxCallTimeout": "30s",
+			"adminToken": ["secret"],
+			"options": {"logEnabled": "yes", "authTokens": ["a"]}
+		},
+		"mcpServers": {
+			"fs": {"command": "fs-server", "args": ["/srv", 2], "env": {"HOME": "/root"}},
+			"fs": {"url": "http://fs/mcp", "timeout": 5000000000}
+		},
+		"manifest": {"toolOverrides": {"fs": null}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"mcpProxy.maxCallTimeout: expected integer, got string",
+		"mcpProxy.adminToken: unknown key",
+		"mcpProxy.options.logEnabled: expected boolean, got string",
+		"mcpServers.fs.args[1]: expected string, got integer",
+		"mcpServers.fs: duplicate key",
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("problems =\n%s\nwant\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+
+	if problems, err := checkServerConfigSchema([]byte(`{"command": "git", "dependOn": ["fs"]}`)); err != nil || len(problems) != 1 || problems[0] != "dependOn: unknown key" {
+		t.Fatalf("server problems = %v, %v", problems, err)
+	}
+	if _, err := checkConfigSchema([]byte(`{"mcpProxy": {}} {}`)); err == nil {
+		t.Fatal("trailing data was accepted")
+	}
+}
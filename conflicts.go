@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Conflict policies decide what the facade does with a tool name that more
+// than one server exposes.
+const (
+	// conflictPolicyPrefer lists the tool once and routes calls to the first
+	// server in prefer order (then by name). This is the default.
+	conflictPolicyPrefer = "prefer"
+	// conflictPolicyError withholds the tool and rejects calls to it.
+	conflictPolicyError = "error"
+	// conflictPolicyPrefix exposes each server's copy as <server>_<tool>.
+	conflictPolicyPrefix = "prefix"
+	// conflictPolicyRoundRobin lists the tool once and rotates calls across
+	// the servers.
+	conflictPolicyRoundRobin = "round-robin"
+)
+
+const rpcCodeToolConflict = -32011
+
+// ConflictPolicyConfig is mcpProxy.conflictPolicy.
+type ConflictPolicyConfig struct {
+	Mode string `json:"mode,omitempty"`
+	// Prefer ranks servers for the prefer mode and orders x-stelae.servers
+	// in every mode; unlisted servers follow by name.
+	Prefer []string `json:"prefer,omitempty"`
+}
+
+type toolConflictPolicy struct {
+	mode string
+	rank map[string]int
+	// replicas folds a group's servers into one logical server.
+	replicas *replicaBalancer
+
+	mu   sync.Mutex
+	next map[string]uint64
+}
+
+func mustToolConflictPolicy(conf *ConflictPolicyConfig) *toolConflictPolicy {
+	p, err := newToolConflictPolicy(conf)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func newToolConflictPolicy(conf *ConflictPolicyConfig) (*toolConflictPolicy, error) {
+	p := &toolConflictPolicy{
+		mode:     conflictPolicyPrefer,
+		rank:     make(map[string]int),
+		replicas: mustReplicaBalancer(nil),
+		next:     make(map[string]uint64),
+	}
+	if conf == nil {
+		return p, nil
+	}
+	switch conf.Mode {
+	case "":
+	case conflictPolicyPrefer, conflictPolicyError, conflictPolicyPrefix, conflictPolicyRoundRobin:
+		p.mode = conf.Mode
+	default:
+		return nil, fmt.Errorf("conflictPolicy: unknown mode %q (want prefer, error, prefix, or round-robin)", conf.Mode)
+	}
+	for i, name := range conf.Prefer {
+		if _, dup := p.rank[name]; !dup {
+			p.rank[name] = i
+		}
+	}
+	return p, nil
+}
+
+// order returns servers sorted by prefer rank, then by name.
+func (p *toolConflictPolicy) order(servers []string) []string {
+	out := append([]string(nil), servers...)
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, iRanked := p.rank[out[i]]
+		rj, jRanked := p.rank[out[j]]
+		switch {
+		case iRanked && jRanked:
+			return ri < rj
+		case iRanked != jRanked:
+			return iRanked
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// pick chooses the server for one call to a tool the ordered servers share.
+func (p *toolConflictPolicy) pick(tool string, ordered []string) string {
+	if p.mode != conflictPolicyRoundRobin || len(ordered) < 2 {
+		return ordered[0]
+	}
+	p.mu.Lock()
+	n := p.next[tool]
+	p.next[tool] = n + 1
+	p.mu.Unlock()
+	return ordered[n%uint64(len(ordered))]
+}
+
+var errUnknownTool = errors.New("unknown tool")
+
+// toolConflictError is returned for calls to a shared tool name under the
+// error policy.
+type toolConflictError struct {
+	tool    string
+	servers []string
+}
+
+func (e *toolConflictError) Error() string {
+	return fmt.Sprintf("tool %s is exposed by %s", e.tool, strings.Join(e.servers, ", "))
+}
+
+// exposedTool is a server's tool under the name the facade lists it as.
+type exposedTool struct {
+	server string
+	tool   mcp.Tool
+	// original is the tool's name on the server when the prefix policy
+	// renamed it, and empty otherwise.
+	original string
+}
+
+// exposedTools applies the policy to the servers' tools, in server name
+// order. Shared names are dropped (error) or renamed to <server>_<tool>
+// (prefix); the other modes keep every copy for the caller to merge.
+// include filters tools before they count as shared. Replicas of a group
+// count as one server, named after the group.
+func (p *toolConflictPolicy) exposedTools(servers map[string]*Server, include func(server string, tool mcp.Tool) bool) []exposedTool {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []exposedTool
+	shared := make(map[string]map[string]bool)
+	for _, name := range names {
+		for _, tool := range servers[name].tools {
+			if include != nil && !include(name, tool) {
+				continue
+			}
+			if shared[tool.Name] == nil {
+				shared[tool.Name] = make(map[string]bool)
+			}
+			shared[tool.Name][p.replicas.logical(name)] = true
+			out = append(out, exposedTool{server: name, tool: tool})
+		}
+	}
+	if p.mode != conflictPolicyError && p.mode != conflictPolicyPrefix {
+		return out
+	}
+	kept := out[:0]
+	for _, et := range out {
+		if len(shared[et.tool.Name]) < 2 {
+			kept = append(kept, et)
+			continue
+		}
+		if p.mode == conflictPolicyPrefix {
+			et.original = et.tool.Name
+			et.tool.Name = p.replicas.logical(et.server) + "_" + et.tool.Name
+			kept = append(kept, et)
+		}
+	}
+	return kept
+}
+
+// toolRoute is where a facade tool call goes: the server and the tool's
+// name there.
+type toolRoute struct {
+	server string
+	tool   string
+	// shared is how many servers the policy chose among; 0 when only one
+	// exposes the tool.
+	shared int
+}
+
+// route resolves a facade tool name given the servers exposing each name.
+// include filters servers the caller cannot use, as exposedTools does, so
+// listing and routing agree on which names are shared. The policy chooses
+// among logical servers; a group then picks one of its replicas.
+func (p *toolConflictPolicy) route(tools map[string][]string, name string, include func(server, tool string) bool) (toolRoute, error) {
+	return p.routeFor(tools, name, callAffinity{}, include)
+}
+
+// routeFor is route for a call whose affinity sticky groups may hash on.
+func (p *toolConflictPolicy) routeFor(tools map[string][]string, name string, affinity callAffinity, include func(server, tool string) bool) (toolRoute, error) {
+	// candidates returns the servers exposing tool by logical server
+	candidates := func(tool string) ([]string, map[string][]string) {
+		var logical []string
+		members := make(map[string][]string)
+		for _, server := range tools[tool] {
+			if include != nil && !include(server, tool) {
+				continue
+			}
+			key := p.replicas.logical(server)
+			if members[key] == nil {
+				logical = append(logical, key)
+			}
+			members[key] = append(members[key], server)
+		}
+		return logical, members
+	}
+	if logical, members := candidates(name); len(logical) > 0 {
+		if len(logical) == 1 {
+			return toolRoute{server: p.replicas.pick(logical[0], members[logical[0]], affinity), tool: name}, nil
+		}
+		ordered := p.order(logical)
+		switch p.mode {
+		case conflictPolicyError:
+			return toolRoute{}, &toolConflictError{tool: name, servers: ordered}
+		case conflictPolicyPrefix:
+			return toolRoute{}, errUnknownTool
+		}
+		chosen := p.pick(name, ordered)
+		return toolRoute{server: p.replicas.pick(chosen, members[chosen], affinity), tool: name, shared: len(ordered)}, nil
+	}
+	if p.mode == conflictPolicyPrefix {
+		// server names may contain "_", so try every split
+		for i := 0; i < len(name); i++ {
+			if name[i] != '_' {
+				continue
+			}
+			server, tool := name[:i], name[i+1:]
+			if logical, members := candidates(tool); len(logical) > 1 && slices.Contains(logical, server) {
+				return toolRoute{server: p.replicas.pick(server, members[server], affinity), tool: tool}, nil
+			}
+		}
+	}
+	return toolRoute{}, errUnknownTool
+}
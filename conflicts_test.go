@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolConflictPolicyRoutes(t *testing.T) {
+	tools := map[string][]string{"search": {"web", "docs", "code"}, "read": {"fs"}}
+	policy := func(mode string, prefer ...string) *toolConflictPolicy {
+		p, err := newToolConflictPolicy(&ConflictPolicyConfig{Mode: mode, Prefer: prefer})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	if _, err := newToolConflictPolicy(&ConflictPolicyConfig{Mode: "random"}); err == nil {
+		t.Fatal("expected an unknown mode to be rejected")
+	}
+
+	if route, err := policy("").route(tools, "search", nil); err != nil || route.server != "code" || route.shared != 3 {
+		t.Fatalf("default should route to the first server by name, got %+v, %v", route, err)
+	}
+	if route, err := policy("prefer", "web").route(tools, "search", nil); err != nil || route.server != "web" {
+		t.Fatalf("prefer = %+v, %v", route, err)
+	}
+	if got := policy("prefer", "web").order([]string{"web", "docs", "code"}); !reflect.DeepEqual(got, []string{"web", "code", "docs"}) {
+		t.Fatalf("order = %v", got)
+	}
+
+	var conflict *toolConflictError
+	if _, err := policy("error").route(tools, "search", nil); !errors.As(err, &conflict) || !reflect.DeepEqual(conflict.servers, []string{"code", "docs", "web"}) {
+		t.Fatalf("error mode = %v", err)
+	}
+	onlyDocs := func(server, tool string) bool { return server == "docs" || tool != "search" }
+	if route, err := policy("error").route(tools, "search", onlyDocs); err != nil || route.server != "docs" || route.shared != 0 {
+		t.Fatalf("disabled copies should not conflict, got %+v, %v", route, err)
+	}
+
+	prefix := policy("prefix")
+	if _, err := prefix.route(tools, "search", nil); !errors.Is(err, errUnknownTool) {
+		t.Fatalf("prefix mode should not serve the bare name, got %v", err)
+	}
+	if route, err := prefix.route(tools, "docs_search", nil); err != nil || route != (toolRoute{server: "docs", tool: "search"}) {
+		t.Fatalf("docs_search = %+v, %v", route, err)
+	}
+	if _, err := prefix.route(tools, "fs_read", nil); !errors.Is(err, errUnknownTool) {
+		t.Fatalf("unshared tools keep their bare name, got %v", err)
+	}
+
+	rr := policy("round-robin")
+	var seen []string
+	for i := 0; i < 4; i++ {
+		route, _ := rr.route(tools, "search", nil)
+		seen = append(seen, route.server)
+	}
+	if !reflect.DeepEqual(seen, []string{"code", "docs", "web", "code"}) {
+		t.Fatalf("round-robin = %v", seen)
+	}
+}
+
+func TestCollectToolsAppliesConflictPolicy(t *testing.T) {
+	px := testProxy()
+	servers := map[string]*Server{
+		"docs": {name: "docs", tools: []mcp.Tool{{Name: "lookup", Description: "docs lookup"}, {Name: "outline"}}},
+		"code": {name: "code", tools: []mcp.Tool{{Name: "lookup", Description: "code lookup"}}},
+	}
+	names := func() map[string]map[string]any {
+		out := make(map[string]map[string]any)
+		for _, tool := range px.collectTools(servers, nil, nil) {
+			out[tool["name"].(string)] = tool
+		}
+		return out
+	}
+
+	px.conflicts = mustToolConflictPolicy(&ConflictPolicyConfig{Mode: conflictPolicyPrefer, Prefer: []string{"docs"}})
+	if meta := names()["lookup"]["x-stelae"].(map[string]any); meta["primaryServer"] != "docs" {
+		t.Fatalf("prefer: x-stelae = %v", meta)
+	}
+
+	px.conflicts = mustToolConflictPolicy(&ConflictPolicyConfig{Mode: conflictPolicyError})
+	if got := names(); got["lookup"] != nil || got["outline"] == nil {
+		t.Fatalf("error: shared tools should be withheld, got %v", got)
+	}
+
+	px.conflicts = mustToolConflictPolicy(&ConflictPolicyConfig{Mode: conflictPolicyPrefix})
+	got := names()
+	if got["lookup"] != nil || got["docs_lookup"]["description"] != "docs lookup" || got["code_lookup"]["description"] != "code lookup" || got["outline"] == nil {
+		t.Fatalf("prefix: got %v", got)
+	}
+}
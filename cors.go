@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodOptions}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "Accept", "Mcp-Session-Id", "Mcp-Protocol-Version", "Last-Event-ID"}
+	// clients need these to continue a session
+	defaultCORSExposedHeaders = []string{"Mcp-Session-Id", "Mcp-Protocol-Version", "WWW-Authenticate"}
+)
+
+// CORSConfig lets browser clients on AllowedOrigins reach the facade, the
+// manifest, and the per-server routes. Origins are path.Match patterns
+// such as "https://*.example.com"; "*" allows any origin but not together
+// with AllowCredentials. MaxAge is how long browsers may cache a preflight.
+type CORSConfig struct {
+	AllowedOrigins   []string      `json:"allowedOrigins"`
+	AllowedMethods   []string      `json:"allowedMethods,omitempty"`
+	AllowedHeaders   []string      `json:"allowedHeaders,omitempty"`
+	ExposedHeaders   []string      `json:"exposedHeaders,omitempty"`
+	AllowCredentials bool          `json:"allowCredentials,omitempty"`
+	MaxAge           time.Duration `json:"maxAge,omitempty"`
+}
+
+type corsPolicy struct {
+	origins     []string
+	anyOrigin   bool
+	methods     string
+	headers     map[string]struct{}
+	headerList  string
+	exposed     string
+	credentials bool
+	maxAge      string
+}
+
+func newCORSPolicy(conf *CORSConfig) (*corsPolicy, error) {
+	if conf == nil || len(conf.AllowedOrigins) == 0 {
+		return nil, nil
+	}
+	p := &corsPolicy{credentials: conf.AllowCredentials, headers: make(map[string]struct{})}
+	for _, origin := range conf.AllowedOrigins {
+		if origin == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		if _, err := path.Match(origin, ""); err != nil {
+			return nil, fmt.Errorf("cors.allowedOrigins: pattern %q: %w", origin, err)
+		}
+		p.origins = append(p.origins, strings.TrimSuffix(origin, "/"))
+	}
+	if p.anyOrigin && p.credentials {
+		return nil, errors.New(`cors: allowedOrigins "*" cannot be combined with allowCredentials`)
+	}
+	if conf.MaxAge < 0 {
+		return nil, errors.New("cors.maxAge must not be negative")
+	}
+	methods := conf.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	p.methods = strings.ToUpper(strings.Join(methods, ", "))
+	headers := conf.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	for _, h := range headers {
+		p.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	p.headerList = strings.Join(headers, ", ")
+	exposed := conf.ExposedHeaders
+	if len(exposed) == 0 {
+		exposed = defaultCORSExposedHeaders
+	}
+	p.exposed = strings.Join(exposed, ", ")
+	if conf.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(conf.MaxAge / time.Second))
+	}
+	return p, nil
+}
+
+// allows reports whether requests from origin may read responses.
+func (p *corsPolicy) allows(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	for _, pattern := range p.origins {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin reports whether the request's Origin is the host it was sent
+// to, as for a page the proxy serves itself.
+func sameOrigin(r *http.Request) bool {
+	u, err := url.Parse(r.Header.Get("Origin"))
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// allowsHeaders reports whether every header a preflight asks for is
+// allowed.
+func (p *corsPolicy) allowsHeaders(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		if h = strings.TrimSpace(h); h == "" {
+			continue
+		}
+		if _, ok := p.headers[http.CanonicalHeaderKey(h)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// middleware answers preflight requests itself, before authentication
+// (browsers send preflights without credentials), and marks other
+// responses to allowed origins as readable. Requests from other origins
+// are refused with 403, so a cross-origin form post cannot act on the
+// proxy either. Requests without an Origin, and same-origin ones, pass
+// through untouched.
+func (p *corsPolicy) middleware(prefix string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if p == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !p.allows(origin) {
+				if !preflight && sameOrigin(r) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				if preflight {
+					routeLogger(prefix).Warn("refused CORS preflight", "origin", origin)
+				} else {
+					routeLogger(prefix).Warn("refused cross-origin request", "method", r.Method, "origin", origin)
+				}
+				return
+			}
+			if p.anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if p.credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				h.Set("Access-Control-Expose-Headers", p.exposed)
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			if !strings.Contains(", "+p.methods+", ", ", "+method+", ") || !p.allowsHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				routeLogger(prefix).Warn("refused CORS preflight", "origin", origin, "method", method)
+				return
+			}
+			h.Set("Access-Control-Allow-Methods", p.methods)
+			h.Set("Access-Control-Allow-Headers", p.headerList)
+			if p.maxAge != "" {
+				h.Set("Access-Control-Max-Age", p.maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
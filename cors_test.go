@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	policy, err := newCORSPolicy(&CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.preview.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reached := 0
+	// preflights carry no credentials, so they must be answered before auth
+	handler := chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+		w.Header().Set("Mcp-Session-Id", "s-1")
+	}), newAuthMiddleware(newRouteTokens([]string{"secret"}, nil, "fs"), nil), policy.middleware("fs"))
+	do := func(method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/fs/mcp", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodOptions, "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "authorization, content-type, mcp-session-id",
+	})
+	h := w.Header()
+	if w.Code != http.StatusNoContent || reached != 0 ||
+		h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		h.Get("Access-Control-Allow-Credentials") != "true" ||
+		h.Get("Access-Control-Max-Age") != "600" ||
+		h.Get("Access-Control-Allow-Methods") != "GET, POST, DELETE, OPTIONS" {
+		t.Fatalf("preflight: %d %v", w.Code, h)
+	}
+	if w := do(http.MethodOptions, "https://pr-7.preview.example.com", map[string]string{"Access-Control-Request-Method": "DELETE"}); w.Code != http.StatusNoContent {
+		t.Fatalf("pattern origin preflight: %d", w.Code)
+	}
+	if w := do(http.MethodOptions, "https://evil.example.net", map[string]string{"Access-Control-Request-Method": "POST"}); w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unknown origin preflight: %d %v", w.Code, w.Header())
+	}
+	if w := do(http.MethodOptions, "https://app.example.com", map[string]string{"Access-Control-Request-Method": "PUT"}); w.Code != http.StatusForbidden {
+		t.Fatalf("disallowed method: %d", w.Code)
+	}
+	if w := do(http.MethodOptions, "https://app.example.com", map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Debug"}); w.Code != http.StatusForbidden {
+		t.Fatalf("disallowed header: %d", w.Code)
+	}
+
+	// actual requests still need credentials, and expose the session header
+	w = do(http.MethodPost, "https://app.example.com", map[string]string{"Authorization": "Bearer secret"})
+	if w.Code != http.StatusOK || reached != 1 || w.Header().Get("Access-Control-Expose-Headers") == "" || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("request: %d %v", w.Code, w.Header())
+	}
+	if w := do(http.MethodPost, "https://app.example.com", nil); w.Code != http.StatusUnauthorized || w.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Fatalf("unauthenticated request: %d %v", w.Code, w.Header())
+	}
+	if w := do(http.MethodPost, "https://evil.example.net", map[string]string{"Authorization": "Bearer secret"}); w.Code != http.StatusForbidden || reached != 1 || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unknown origin request: %d %v", w.Code, w.Header())
+	}
+	// httptest requests are sent to example.com
+	if w := do(http.MethodPost, "http://example.com", map[string]string{"Authorization": "Bearer secret"}); w.Code != http.StatusOK || reached != 2 || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("same-origin request: %d %v", w.Code, w.Header())
+	}
+	if w := do(http.MethodPost, "", map[string]string{"Authorization": "Bearer secret"}); w.Code != http.StatusOK || w.Header().Get("Vary") != "" {
+		t.Fatalf("non-browser request: %d %v", w.Code, w.Header())
+	}
+
+	wildcard, err := newCORSPolicy(&CORSConfig{AllowedOrigins: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wildcard.allows("http://localhost:5173") {
+		t.Fatal("wildcard origin refused")
+	}
+	if _, err := newCORSPolicy(&CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}); err == nil {
+		t.Fatal("wildcard origin with credentials accepted")
+	}
+	if p, err := newCORSPolicy(&CORSConfig{}); p != nil || err != nil {
+		t.Fatalf("empty config = %v, %v", p, err)
+	}
+}
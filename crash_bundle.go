@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const stderrTailLines = 50
+
+// stderrTail keeps the last lines a stdio server wrote to stderr so startup
+// failures can be reported with the server's own complaint.
+type stderrTail struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (t *stderrTail) add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > stderrTailLines {
+		t.lines = append(t.lines[:0], t.lines[len(t.lines)-stderrTailLines:]...)
+	}
+}
+
+func (t *stderrTail) snapshot() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.lines...)
+}
+
+// drainStderr reads a stdio server's stderr until EOF, keeping a tail and
+// copying lines into the per-server log when one is configured.
+func drainStderr(r io.Reader, tail *stderrTail, callLog *serverLog) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		tail.add(line)
+		callLog.recordStderr(line)
+	}
+}
+
+// startupDiagnostics collects what a crash bundle needs while the proxy boots.
+type startupDiagnostics struct {
+	mu           sync.Mutex
+	config       *Config
+	warnings     []string
+	serverErrors map[string]string
+	stderr       map[string]*stderrTail
+}
+
+var startupDiag = &startupDiagnostics{
+	serverErrors: make(map[string]string),
+	stderr:       make(map[string]*stderrTail),
+}
+
+func (d *startupDiagnostics) setConfig(config *Config) {
+	d.mu.Lock()
+	d.config = config
+	d.mu.Unlock()
+}
+
+func (d *startupDiagnostics) addWarnings(warnings []string) {
+	d.mu.Lock()
+	d.warnings = append(d.warnings, warnings...)
+	d.mu.Unlock()
+}
+
+func (d *startupDiagnostics) serverFailed(name string, err error) {
+	d.mu.Lock()
+	d.serverErrors[name] = err.Error()
+	d.mu.Unlock()
+}
+
+func (d *startupDiagnostics) trackStderr(name string, tail *stderrTail) {
+	if tail == nil {
+		return
+	}
+	d.mu.Lock()
+	d.stderr[name] = tail
+	d.mu.Unlock()
+}
+
+// bundle renders the diagnostics. Config secrets are redacted with the same
+// key list as the other diagnostic outputs.
+func (d *startupDiagnostics) bundle(reason string, now time.Time) map[string]any {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	servers := make(map[string]any)
+	names := make(map[string]struct{})
+	for name := range d.serverErrors {
+		names[name] = struct{}{}
+	}
+	for name := range d.stderr {
+		names[name] = struct{}{}
+	}
+	for name := range names {
+		entry := map[string]any{}
+		if msg, ok := d.serverErrors[name]; ok {
+			entry["error"] = msg
+		}
+		if lines := d.stderr[name].snapshot(); len(lines) > 0 {
+			entry["stderrTail"] = lines
+		}
+		if len(entry) > 0 {
+			servers[name] = entry
+		}
+	}
+
+	out := map[string]any{
+		"at":               now.UTC().Format(time.RFC3339Nano),
+		"version":          BuildVersion,
+		"reason":           reason,
+		"overrideWarnings": d.warnings,
+		"servers":          servers,
+		"environment":      environmentSummary(),
+	}
+	if d.config != nil {
+		out["config"] = redactBundleConfig(d.config)
+	}
+	return out
+}
+
+// redactBundleConfig drops every server env and header value on top of the
+// usual key redaction: their names rarely look like secrets but their values
+// often are.
+func redactBundleConfig(config *Config) any {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+	if servers, ok := decoded["mcpServers"].(map[string]any); ok {
+		for _, raw := range servers {
+			srv, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			for _, field := range []string{"env", "headers"} {
+				values, ok := srv[field].(map[string]any)
+				if !ok {
+					continue
+				}
+				for k := range values {
+					values[k] = redactedValue
+				}
+			}
+		}
+	}
+	return redactValue(decoded, newRedactKeySet([]string{"adminTokens", "authTokens"}))
+}
+
+// environmentSummary lists runtime facts and STELAE_* variables; other
+// environment variables are left out since they routinely carry credentials.
+func environmentSummary() map[string]any {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(key, "STELAE_") {
+			env[key] = value
+		}
+	}
+	cwd, _ := os.Getwd()
+	return map[string]any{
+		"goVersion":  runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"pid":        os.Getpid(),
+		"cwd":        cwd,
+		"args":       os.Args,
+		"stateHome":  stateHome(),
+		"configHome": configHome(),
+		"env":        redactValue(stringMapToAny(env), newRedactKeySet(nil)),
+	}
+}
+
+func stringMapToAny(in map[string]string) map[string]any {
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// writeCrashBundle stores the bundle under stateHome()/crash and returns its path.
+func writeCrashBundle(diag *startupDiagnostics, reason string, now time.Time) (string, error) {
+	home := stateHome()
+	name := fmt.Sprintf("startup-%s.json", now.UTC().Format("20060102-150405"))
+	path, err := mkdirAllUnder(home, filepath.Join(home, "crash", name))
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(diag.bundle(reason, now), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := writeAtomic(path, append(data, '\n')); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// fatalStartup replaces log.Fatalf on boot paths: it writes a crash bundle
+// first so the report carries config, server errors, and stderr tails.
+func fatalStartup(format string, args ...any) {
+	reason := fmt.Sprintf(format, args...)
+	if path, err := writeCrashBundle(startupDiag, reason, time.Now()); err != nil {
+		logger("startup").Error("failed to write crash bundle", "err", err)
+	} else {
+		logger("startup").Error("wrote crash bundle", "path", path)
+	}
+	log.Fatal(reason)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStderrTailKeepsLastLines(t *testing.T) {
+	tail := &stderrTail{}
+	var input strings.Builder
+	for i := 0; i < stderrTailLines+5; i++ {
+		fmt.Fprintf(&input, "line %d\n", i)
+	}
+	drainStderr(strings.NewReader(input.String()), tail, nil)
+	lines := tail.snapshot()
+	if len(lines) != stderrTailLines {
+		t.Fatalf("expected %d lines, got %d", stderrTailLines, len(lines))
+	}
+	if lines[0] != "line 5" || lines[len(lines)-1] != fmt.Sprintf("line %d", stderrTailLines+4) {
+		t.Fatalf("unexpected tail bounds %q .. %q", lines[0], lines[len(lines)-1])
+	}
+}
+
+func TestWriteCrashBundle(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("STELAE_STATE_HOME", base)
+	t.Setenv("STELAE_PROFILE", "dev")
+
+	diag := &startupDiagnostics{serverErrors: make(map[string]string), stderr: make(map[string]*stderrTail)}
+	diag.setConfig(&Config{
+		McpProxy: &MCPProxyConfigV2{AdminTokens: []string{"admin-secret"}},
+		McpServers: map[string]*MCPClientConfigV2{
+			"github": {Command: "gh-mcp", Env: map[string]string{"GITHUB_PAT": "ghp_secret"}},
+		},
+	})
+	diag.addWarnings([]string{"tool_overrides: something odd"})
+	diag.serverFailed("github", errors.New("exec: not found"))
+	tail := &stderrTail{}
+	tail.add("fatal: missing credentials")
+	diag.trackStderr("github", tail)
+
+	path, err := writeCrashBundle(diag, "Failed to initialize clients: boom", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("writeCrashBundle: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+	if strings.Contains(string(data), "ghp_secret") || strings.Contains(string(data), "admin-secret") {
+		t.Fatalf("bundle leaks secrets:\n%s", data)
+	}
+	var bundle struct {
+		Reason           string   `json:"reason"`
+		OverrideWarnings []string `json:"overrideWarnings"`
+		Servers          map[string]struct {
+			Error      string   `json:"error"`
+			StderrTail []string `json:"stderrTail"`
+		} `json:"servers"`
+		Environment struct {
+			Env map[string]string `json:"env"`
+		} `json:"environment"`
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("decode bundle: %v", err)
+	}
+	if bundle.Reason != "Failed to initialize clients: boom" || len(bundle.OverrideWarnings) != 1 {
+		t.Fatalf("unexpected bundle %+v", bundle)
+	}
+	gh := bundle.Servers["github"]
+	if gh.Error != "exec: not found" || len(gh.StderrTail) != 1 {
+		t.Fatalf("unexpected server entry %+v", gh)
+	}
+	if bundle.Environment.Env["STELAE_PROFILE"] != "dev" {
+		t.Fatalf("expected STELAE_* env in summary: %+v", bundle.Environment.Env)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// clients may send either header; the second mirrors common gateway naming
+	timeoutHeader        = "X-Stelae-Timeout-Ms"
+	requestTimeoutHeader = "Request-Timeout"
+	deadlineHeader       = "X-Stelae-Deadline"
+
+	defaultCallTimeout = 30 * time.Second
+
+	rpcCodeRequestTimeout = -32001
+)
+
+type callDeadline struct {
+	Timeout   time.Duration
+	StartedAt time.Time
+	Source    string
+}
+
+// parseTimeoutHint reads a client supplied timeout from params._meta.timeoutMs
+// or a timeout header. Params take precedence over headers.
+func parseTimeoutHint(r *http.Request, params json.RawMessage) (time.Duration, string) {
+	if len(params) > 0 {
+		var p struct {
+			Meta map[string]any `json:"_meta"`
+		}
+		if err := json.Unmarshal(params, &p); err == nil && p.Meta != nil {
+			if ms, ok := toMillis(p.Meta["timeoutMs"]); ok {
+				return ms, "params"
+			}
+		}
+	}
+	if r != nil {
+		for _, name := range []string{timeoutHeader, requestTimeoutHeader} {
+			raw := strings.TrimSpace(r.Header.Get(name))
+			if raw == "" {
+				continue
+			}
+			if ms, err := strconv.ParseFloat(raw, 64); err == nil && ms > 0 {
+				return time.Duration(ms * float64(time.Millisecond)), "header"
+			}
+		}
+	}
+	return 0, ""
+}
+
+func toMillis(v any) (time.Duration, bool) {
+	switch n := v.(type) {
+	case float64:
+		if n > 0 {
+			return time.Duration(n * float64(time.Millisecond)), true
+		}
+	case string:
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(n), 64); err == nil && parsed > 0 {
+			return time.Duration(parsed * float64(time.Millisecond)), true
+		}
+	}
+	return 0, false
+}
+
+// resolveCallDeadline clamps the client hint to the call's budget: the tool's
+// configured timeout, else the server's options.timeout, else the configured
+// maximum (or the built-in default). Without a hint the budget applies.
+func resolveCallDeadline(r *http.Request, params json.RawMessage, maxTimeout, serverTimeout, toolTimeout time.Duration) callDeadline {
+	if maxTimeout <= 0 {
+		maxTimeout = defaultCallTimeout
+	}
+	deadline := callDeadline{Timeout: maxTimeout, StartedAt: time.Now(), Source: "default"}
+	switch {
+	case toolTimeout > 0:
+		deadline.Timeout, deadline.Source = toolTimeout, "tool"
+	case serverTimeout > 0:
+		deadline.Timeout, deadline.Source = serverTimeout, "server"
+	}
+	if hint, source := parseTimeoutHint(r, params); hint > 0 {
+		if hint < deadline.Timeout {
+			deadline.Timeout = hint
+		}
+		deadline.Source = source
+	}
+	return deadline
+}
+
+func (d callDeadline) context(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(parent, d.StartedAt.Add(d.Timeout))
+}
+
+func (d callDeadline) expired(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+func (d callDeadline) timeoutError(ctx context.Context, id any, target string) jsonrpcResponse {
+	elapsed := time.Since(d.StartedAt)
+	return withErrorData(localizedErrors(ctx).response(id, errNameRequestTimeout, map[string]string{"target": target}), map[string]any{
+		"timeoutMs": d.Timeout.Milliseconds(),
+		"elapsedMs": elapsed.Milliseconds(),
+		"source":    d.Source,
+	})
+}
+
+// deadlineHeaders forwards the remaining budget of ctx to HTTP downstreams so
+// they can abandon work the proxy will no longer wait for.
+func deadlineHeaders(ctx context.Context) map[string]string {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return map[string]string{
+		deadlineHeader: deadline.UTC().Format(time.RFC3339Nano),
+		timeoutHeader:  strconv.FormatInt(remaining.Milliseconds(), 10),
+	}
+}
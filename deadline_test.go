@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResolveCallDeadlinePrefersParamsOverHeader(t *testing.T) {
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	r.Header.Set(timeoutHeader, "5000")
+	params := json.RawMessage(`{"name":"echo","_meta":{"timeoutMs":250}}`)
+
+	d := resolveCallDeadline(r, params, 10*time.Second, 0, 0)
+	if d.Timeout != 250*time.Millisecond {
+		t.Fatalf("timeout = %s, want 250ms", d.Timeout)
+	}
+	if d.Source != "params" {
+		t.Fatalf("source = %s, want params", d.Source)
+	}
+}
+
+func TestResolveCallDeadlineClampsToMaximum(t *testing.T) {
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	r.Header.Set(requestTimeoutHeader, "120000")
+
+	d := resolveCallDeadline(r, nil, 2*time.Second, 0, 0)
+	if d.Timeout != 2*time.Second {
+		t.Fatalf("timeout = %s, want clamp to 2s", d.Timeout)
+	}
+	if d.Source != "header" {
+		t.Fatalf("source = %s, want header", d.Source)
+	}
+}
+
+func TestResolveCallDeadlineDefaultsWithoutHint(t *testing.T) {
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	d := resolveCallDeadline(r, json.RawMessage(`{"name":"echo"}`), 0, 0, 0)
+	if d.Timeout != defaultCallTimeout || d.Source != "default" {
+		t.Fatalf("got %s/%s, want default", d.Timeout, d.Source)
+	}
+}
+
+func TestResolveCallDeadlineConfiguredTimeouts(t *testing.T) {
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	d := resolveCallDeadline(r, nil, 10*time.Second, 2*time.Minute, 0)
+	if d.Timeout != 2*time.Minute || d.Source != "server" {
+		t.Fatalf("got %s/%s, want the server timeout", d.Timeout, d.Source)
+	}
+	d = resolveCallDeadline(r, nil, 10*time.Second, 2*time.Minute, 5*time.Second)
+	if d.Timeout != 5*time.Second || d.Source != "tool" {
+		t.Fatalf("got %s/%s, want the tool timeout", d.Timeout, d.Source)
+	}
+	r.Header.Set(timeoutHeader, "600000")
+	d = resolveCallDeadline(r, nil, 10*time.Second, 2*time.Minute, 0)
+	if d.Timeout != 2*time.Minute {
+		t.Fatalf("timeout = %s, want a hint clamped to the server timeout", d.Timeout)
+	}
+}
+
+func TestCallDeadlineTimeoutErrorIncludesElapsed(t *testing.T) {
+	d := callDeadline{Timeout: time.Millisecond, StartedAt: time.Now(), Source: "params"}
+	ctx, cancel := d.context(context.Background())
+	defer cancel()
+	<-ctx.Done()
+	if !d.expired(ctx) {
+		t.Fatalf("expected deadline to be reported as expired")
+	}
+	resp := d.timeoutError(context.Background(), 7, "alpha")
+	if resp.Error == nil || resp.Error.Code != rpcCodeRequestTimeout {
+		t.Fatalf("expected timeout error, got %+v", resp.Error)
+	}
+	data, ok := resp.Error.Data.(map[string]any)
+	if !ok {
+		t.Fatalf("expected error data map, got %T", resp.Error.Data)
+	}
+	if data["timeoutMs"] != int64(1) {
+		t.Fatalf("timeoutMs = %v, want 1", data["timeoutMs"])
+	}
+	if _, ok := data["elapsedMs"]; !ok {
+		t.Fatalf("expected elapsedMs in error data")
+	}
+}
+
+func TestDeadlineHeadersOnlyWithDeadline(t *testing.T) {
+	if h := deadlineHeaders(context.Background()); h != nil {
+		t.Fatalf("expected no headers without deadline, got %v", h)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	h := deadlineHeaders(ctx)
+	if h[deadlineHeader] == "" || h[timeoutHeader] == "" {
+		t.Fatalf("expected deadline headers, got %v", h)
+	}
+}
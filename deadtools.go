@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDeadToolMinCalls      = 10
+	defaultDeadToolWindow        = time.Hour
+	defaultDeadToolProbeInterval = 5 * time.Minute
+)
+
+// DeadToolConfig flags tools whose every call has failed for a while.
+type DeadToolConfig struct {
+	Enabled  bool          `json:"enabled,omitempty"`
+	MinCalls int           `json:"minCalls,omitempty"`
+	Window   time.Duration `json:"window,omitempty"`
+	// AutoDisable hides dead tools from the live catalog until a probe call
+	// succeeds. The change is not persisted.
+	AutoDisable   bool          `json:"autoDisable,omitempty"`
+	ProbeInterval time.Duration `json:"probeInterval,omitempty"`
+}
+
+// deadToolState is one tool's run of failures since its last success.
+type deadToolState struct {
+	Server         string     `json:"server"`
+	Tool           string     `json:"tool"`
+	Failures       int        `json:"failures"`
+	FirstFailureAt time.Time  `json:"firstFailureAt"`
+	LastFailureAt  time.Time  `json:"lastFailureAt"`
+	LastError      string     `json:"lastError,omitempty"`
+	Dead           bool       `json:"dead"`
+	DeadSince      *time.Time `json:"deadSince,omitempty"`
+	LastProbeAt    *time.Time `json:"lastProbeAt,omitempty"`
+
+	// args are the last failing call's arguments, which probes replay.
+	args json.RawMessage
+}
+
+// deadToolDetector counts consecutive failed calls per (server, tool). A
+// tool is dead once it has failed at least minCalls times in a row over at
+// least window; its next success revives it.
+type deadToolDetector struct {
+	minCalls      int
+	window        time.Duration
+	autoDisable   bool
+	probeInterval time.Duration
+
+	mu    sync.Mutex
+	tools map[toolToggleKey]*deadToolState
+}
+
+func newDeadToolDetector(conf *DeadToolConfig) *deadToolDetector {
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+	d := &deadToolDetector{
+		minCalls:      conf.MinCalls,
+		window:        conf.Window,
+		autoDisable:   conf.AutoDisable,
+		probeInterval: conf.ProbeInterval,
+		tools:         make(map[toolToggleKey]*deadToolState),
+	}
+	if d.minCalls <= 0 {
+		d.minCalls = defaultDeadToolMinCalls
+	}
+	if d.window <= 0 {
+		d.window = defaultDeadToolWindow
+	}
+	if d.probeInterval <= 0 {
+		d.probeInterval = defaultDeadToolProbeInterval
+	}
+	return d
+}
+
+// observe records a call outcome. died is true for the failure that made
+// the tool dead; revived for the success that ended a dead run.
+func (d *deadToolDetector) observe(server, tool string, args json.RawMessage, digest responseDigest, failed bool, now time.Time) (state deadToolState, died, revived bool) {
+	if d == nil {
+		return deadToolState{}, false, false
+	}
+	key := toolToggleKey{Server: server, Tool: tool}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry := d.tools[key]
+	if !failed {
+		if entry == nil {
+			return deadToolState{}, false, false
+		}
+		delete(d.tools, key)
+		return *entry, false, entry.Dead
+	}
+	if entry == nil {
+		entry = &deadToolState{Server: server, Tool: tool, FirstFailureAt: now.UTC()}
+		d.tools[key] = entry
+	}
+	entry.LastError = digest.ErrorSummary
+	entry.Failures++
+	entry.LastFailureAt = now.UTC()
+	entry.args = append(json.RawMessage(nil), args...)
+	if !entry.Dead && entry.Failures >= d.minCalls && now.Sub(entry.FirstFailureAt) >= d.window {
+		entry.Dead = true
+		since := now.UTC()
+		entry.DeadSince = &since
+		return *entry, true, false
+	}
+	return *entry, false, false
+}
+
+// forget drops a tool's record, e.g. after an operator restored it.
+func (d *deadToolDetector) forget(server, tool string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.tools, toolToggleKey{Server: server, Tool: tool})
+}
+
+// dueProbes returns the dead tools not probed within probeInterval and
+// marks them probed.
+func (d *deadToolDetector) dueProbes(now time.Time) []deadToolState {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []deadToolState
+	for _, entry := range d.tools {
+		if !entry.Dead {
+			continue
+		}
+		last := *entry.DeadSince
+		if entry.LastProbeAt != nil {
+			last = *entry.LastProbeAt
+		}
+		if now.Sub(last) < d.probeInterval {
+			continue
+		}
+		at := now.UTC()
+		entry.LastProbeAt = &at
+		out = append(out, *entry)
+	}
+	return out
+}
+
+// list returns tools with a failure run, dead ones first.
+func (d *deadToolDetector) list() []deadToolState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]deadToolState, 0, len(d.tools))
+	for _, entry := range d.tools {
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Dead != out[j].Dead {
+			return out[i].Dead
+		}
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].Tool < out[j].Tool
+	})
+	return out
+}
+
+// runProbes calls probe for each dead tool that is due until ctx ends.
+func (d *deadToolDetector) runProbes(ctx context.Context, probe func(state deadToolState)) {
+	ticker := time.NewTicker(min(d.probeInterval, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, state := range d.dueProbes(now) {
+				probe(state)
+			}
+		}
+	}
+}
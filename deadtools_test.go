@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestDeadToolDetector(t *testing.T) {
+	d := newDeadToolDetector(&DeadToolConfig{Enabled: true, MinCalls: 3, Window: time.Minute, AutoDisable: true, ProbeInterval: 5 * time.Minute})
+	data, _ := json.Marshal(rpcError(json.RawMessage("1"), -32603, "connection refused"))
+	failure := digestBytes(data)
+	args := json.RawMessage(`{"q":"x"}`)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, at := range []time.Duration{0, 10 * time.Second, 20 * time.Second} {
+		if _, died, _ := d.observe("web", "search", args, failure, true, start.Add(at)); died {
+			t.Fatalf("call %d: tool declared dead before the window passed", i)
+		}
+	}
+	state, died, _ := d.observe("web", "search", args, failure, true, start.Add(2*time.Minute))
+	if !died || state.Failures != 4 || state.LastError == "" {
+		t.Fatalf("expected the tool to die on the fourth failure, got %+v died=%t", state, died)
+	}
+	if _, died, _ := d.observe("web", "search", args, failure, true, start.Add(3*time.Minute)); died {
+		t.Fatal("a dead tool died twice")
+	}
+
+	if due := d.dueProbes(start.Add(4 * time.Minute)); len(due) != 0 {
+		t.Fatalf("probe before probeInterval: %+v", due)
+	}
+	due := d.dueProbes(start.Add(8 * time.Minute))
+	if len(due) != 1 || string(due[0].args) != `{"q":"x"}` {
+		t.Fatalf("due probes = %+v", due)
+	}
+	if again := d.dueProbes(start.Add(9 * time.Minute)); len(again) != 0 {
+		t.Fatalf("tool probed twice within probeInterval: %+v", again)
+	}
+
+	if _, _, revived := d.observe("web", "search", args, digestBytes([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`)), false, start.Add(10*time.Minute)); !revived {
+		t.Fatal("expected a success to revive the tool")
+	}
+	if list := d.list(); len(list) != 0 {
+		t.Fatalf("expected the record to be dropped, got %+v", list)
+	}
+}
+
+func TestToolOverrideStoreSuspend(t *testing.T) {
+	store := newToolOverrideStore(nil)
+	servers := map[string]*Server{"web": {name: "web", tools: []mcp.Tool{mcp.NewTool("search")}}}
+
+	store.suspend(toolToggle{Server: "web", Tool: "search", By: "proxy:dead-tool", At: time.Now(), Reason: "10 consecutive failures"})
+	if toolEnabled(store.current(), "web", "search") {
+		t.Fatal("suspended tool is still enabled")
+	}
+	view := adminCatalogView(servers, store)
+	disabled, _ := view["disabled"].([]catalogTool)
+	if len(disabled) != 1 || disabled[0].Source != "proxy" || disabled[0].DisabledBy != "proxy:dead-tool" {
+		t.Fatalf("catalog view = %+v", view)
+	}
+	if history := store.recentHistory(10); len(history) != 0 {
+		t.Fatalf("suspension recorded in history: %+v", history)
+	}
+
+	if err := store.setToolEnabled(toolToggle{Server: "web", Tool: "search", Enabled: true, At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if !toolEnabled(store.current(), "web", "search") || store.resume("web", "search") {
+		t.Fatal("restoring the tool did not lift the suspension")
+	}
+}
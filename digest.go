@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"sort"
+)
+
+// responseDigest is what the call policies need from a JSON-RPC response
+// body: whether it failed, a signature of the failure, and a hash of the
+// result. digestResponse reads the body once as a token stream, so a
+// spilled body is summarized without loading it.
+type responseDigest struct {
+	// Failed is set for an error response, an isError result, or a body
+	// that is not a JSON-RPC response at all.
+	Failed bool
+	// ErrorHash and ErrorSummary identify the error, or the content of
+	// the result when there is none; ErrorSummary is a short excerpt.
+	ErrorHash    string
+	ErrorSummary string
+	// ResultHash hashes the result without its _meta, which routinely
+	// carries timing data; equal results hash alike whatever their key
+	// order.
+	ResultHash string
+	// StructuredContent is the result's structuredContent, which the
+	// adapter passes through as is.
+	StructuredContent map[string]any
+}
+
+const digestExcerptBytes = 1024
+
+// digestTap hashes the raw bytes of a body and keeps their start, for a
+// body that turns out not to be JSON.
+type digestTap struct {
+	hash   hash.Hash
+	prefix []byte
+}
+
+func (t *digestTap) Write(p []byte) (int, error) {
+	if room := digestExcerptBytes - len(t.prefix); room > 0 {
+		t.prefix = append(t.prefix, p[:min(room, len(p))]...)
+	}
+	return t.hash.Write(p)
+}
+
+// digestResponse summarizes the response body read from r.
+func digestResponse(r io.Reader) responseDigest {
+	tap := &digestTap{hash: sha256.New()}
+	src := io.TeeReader(r, tap)
+	w := &digestWalker{dec: json.NewDecoder(src)}
+	w.dec.UseNumber()
+	err := w.envelope()
+	_, _ = io.Copy(io.Discard, src)
+	rawHash := hex.EncodeToString(tap.hash.Sum(nil))
+	if err != nil {
+		return responseDigest{Failed: true, ErrorHash: rawHash, ErrorSummary: truncateRunes(string(tap.prefix), 200), ResultHash: rawHash}
+	}
+
+	d := responseDigest{
+		Failed:            w.err != nil || w.isError,
+		ErrorHash:         w.contentHash,
+		ErrorSummary:      truncateRunes(w.text, 200),
+		ResultHash:        w.resultHash,
+		StructuredContent: w.structured,
+	}
+	if w.err != nil {
+		data, _ := json.Marshal(map[string]any{"code": w.err.Code, "message": w.err.Message})
+		d.ErrorHash, d.ErrorSummary = canonicalHash(data), truncateRunes(w.err.Message, 200)
+	}
+	if d.ResultHash == "" {
+		d.ResultHash = rawHash
+	}
+	return d
+}
+
+// digestWriter digests a body as it is written, for a response sent to
+// the client without being recorded.
+type digestWriter struct {
+	pw   *io.PipeWriter
+	done chan responseDigest
+}
+
+func newDigestWriter() *digestWriter {
+	pr, pw := io.Pipe()
+	d := &digestWriter{pw: pw, done: make(chan responseDigest, 1)}
+	go func() {
+		d.done <- digestResponse(pr)
+		pr.Close()
+	}()
+	return d
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) { return d.pw.Write(p) }
+
+// Sum ends the body and returns the digest of everything written.
+func (d *digestWriter) Sum() responseDigest {
+	_ = d.pw.Close()
+	return <-d.done
+}
+
+// digestWalker walks a response envelope token by token, hashing values
+// as it goes: objects hash their members sorted, so memory stays in
+// proportion to the nesting and member count rather than the body size.
+type digestWalker struct {
+	dec *json.Decoder
+
+	err         *jsonrpcError
+	isError     bool
+	contentHash string
+	text        string
+	resultHash  string
+	structured  map[string]any
+}
+
+var errDigestShape = errors.New("not a JSON-RPC response object")
+
+func (w *digestWalker) envelope() error {
+	if err := w.delim('{'); err != nil {
+		return err
+	}
+	w.contentHash = hashHex([]byte("null"))
+	for w.dec.More() {
+		key, err := w.key()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "error":
+			if err := w.dec.Decode(&w.err); err != nil {
+				return err
+			}
+		case "result":
+			if err := w.result(); err != nil {
+				return err
+			}
+		default:
+			if _, err := w.value(io.Discard, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return w.delim('}')
+}
+
+func (w *digestWalker) result() error {
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return errDigestShape
+	}
+	var members [][]byte
+	for w.dec.More() {
+		key, err := w.key()
+		if err != nil {
+			return err
+		}
+		member := sha256.New()
+		writeDigestKey(member, key)
+		switch key {
+		case "isError":
+			tok, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+			isError, ok := tok.(bool)
+			if !ok && tok != nil {
+				return errDigestShape
+			}
+			w.isError = isError
+			writeDigestScalar(member, tok)
+		case "content":
+			content := sha256.New()
+			if _, err := w.value(io.MultiWriter(member, content), &w.text); err != nil {
+				return err
+			}
+			w.contentHash = hex.EncodeToString(content.Sum(nil))
+		case "structuredContent":
+			var raw json.RawMessage
+			if err := w.dec.Decode(&raw); err != nil {
+				return err
+			}
+			_ = json.Unmarshal(raw, &w.structured)
+			sub := &digestWalker{dec: json.NewDecoder(bytes.NewReader(raw))}
+			sub.dec.UseNumber()
+			if _, err := sub.value(member, nil); err != nil {
+				return err
+			}
+		default:
+			if _, err := w.value(member, nil); err != nil {
+				return err
+			}
+		}
+		if key != "_meta" {
+			members = append(members, member.Sum(nil))
+		}
+	}
+	w.resultHash = hex.EncodeToString(sortedDigest(members))
+	return w.delim('}')
+}
+
+// value hashes the next value into h and returns it when it is a scalar.
+// For an object, text receives its string text member; for an array, that
+// of its first element.
+func (w *digestWalker) value(h io.Writer, text *string) (any, error) {
+	tok, err := w.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		var members [][]byte
+		for w.dec.More() {
+			key, err := w.key()
+			if err != nil {
+				return nil, err
+			}
+			member := sha256.New()
+			writeDigestKey(member, key)
+			scalar, err := w.value(member, nil)
+			if err != nil {
+				return nil, err
+			}
+			if s, ok := scalar.(string); ok && key == "text" && text != nil {
+				*text = s
+			}
+			members = append(members, member.Sum(nil))
+		}
+		_, _ = h.Write(sortedDigest(members))
+		return nil, w.delim('}')
+	case json.Delim('['):
+		_, _ = h.Write([]byte{'['})
+		for i := 0; w.dec.More(); i++ {
+			first := text
+			if i > 0 {
+				first = nil
+			}
+			if _, err := w.value(h, first); err != nil {
+				return nil, err
+			}
+			_, _ = h.Write([]byte{','})
+		}
+		_, _ = h.Write([]byte{']'})
+		return nil, w.delim(']')
+	}
+	if _, ok := tok.(json.Delim); ok {
+		return nil, errDigestShape
+	}
+	writeDigestScalar(h, tok)
+	return tok, nil
+}
+
+func (w *digestWalker) key() (string, error) {
+	tok, err := w.dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", errDigestShape
+	}
+	return key, nil
+}
+
+func (w *digestWalker) delim(want json.Delim) error {
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return errDigestShape
+	}
+	return nil
+}
+
+func writeDigestKey(h io.Writer, key string) {
+	data, _ := json.Marshal(key)
+	_, _ = h.Write(append(data, ':'))
+}
+
+func writeDigestScalar(h io.Writer, tok any) {
+	data, _ := json.Marshal(tok)
+	_, _ = h.Write(data)
+}
+
+// sortedDigest hashes an object from its member hashes in sorted order.
+func sortedDigest(members [][]byte) []byte {
+	sort.Slice(members, func(i, j int) bool { return bytes.Compare(members[i], members[j]) < 0 })
+	h := sha256.New()
+	_, _ = h.Write([]byte{'{'})
+	for _, m := range members {
+		_, _ = h.Write(m)
+	}
+	_, _ = h.Write([]byte{'}'})
+	return h.Sum(nil)
+}
+
+// digestBytes summarizes a response body held in memory.
+func digestBytes(body []byte) responseDigest {
+	return digestResponse(bytes.NewReader(body))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestResponse(t *testing.T) {
+	cases := []struct {
+		body    string
+		failed  bool
+		summary string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"fine"}]}}`, false, "fine"},
+		{`{"jsonrpc":"2.0","id":1,"result":{"isError":true,"content":[{"type":"text","text":"ENOENT"},{"type":"text","text":"more"}]}}`, true, "ENOENT"},
+		{`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"boom"}}`, true, "boom"},
+		{`{"jsonrpc":"2.0","id":1,"error":null,"result":{}}`, false, ""},
+		{`{"jsonrpc":"2.0","id":1,"result":"text"}`, true, `{"jsonrpc":"2.0","id":1,"result":"text"}`},
+		{`not json`, true, "not json"},
+	}
+	for _, tc := range cases {
+		d := digestBytes([]byte(tc.body))
+		if d.Failed != tc.failed || d.ErrorSummary != tc.summary {
+			t.Errorf("digest(%s) = failed %t summary %q", tc.body, d.Failed, d.ErrorSummary)
+		}
+	}
+
+	a := digestBytes([]byte(`{"id":1,"result":{"content":[{"type":"text","text":"a"}],"_meta":{"took":1}}}`))
+	b := digestBytes([]byte(`{"result":{"_meta":{"took":2},"content":[{"text":"a","type":"text"}]},"id":2}`))
+	c := digestBytes([]byte(`{"id":1,"result":{"content":[{"type":"text","text":"b"}]}}`))
+	if a.ResultHash != b.ResultHash || a.ErrorHash != b.ErrorHash {
+		t.Fatalf("equal results hash differently: %+v %+v", a, b)
+	}
+	if a.ResultHash == c.ResultHash {
+		t.Fatal("different results hash alike")
+	}
+}
+
+func TestDigestOfSpilledBodyStaysBounded(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	defer func(old int64) { responseSpillThreshold = old }(responseSpillThreshold)
+	responseSpillThreshold = 1 << 20
+
+	rr := newResponseRecorder()
+	defer rr.Body.Close()
+	block := `{"type":"text","text":"` + strings.Repeat("x", 64<<10) + `"}`
+	_, _ = rr.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"isError":true,"content":[`))
+	for i := 0; i < 512; i++ {
+		if i > 0 {
+			_, _ = rr.Write([]byte(","))
+		}
+		_, _ = rr.Write([]byte(block))
+	}
+	_, _ = rr.Write([]byte(`]}}`))
+	if !rr.Body.Spilled() || rr.Body.Len() < 32<<20 {
+		t.Fatalf("body of %d bytes did not spill", rr.Body.Len())
+	}
+
+	// collect often, so the heap tracks what the digest keeps live
+	defer debug.SetGCPercent(debug.SetGCPercent(10))
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	base, peak := stats.HeapInuse, stats.HeapInuse
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			var s runtime.MemStats
+			runtime.ReadMemStats(&s)
+			peak = max(peak, s.HeapInuse)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	d := rr.Digest()
+	close(done)
+	<-sampled
+
+	if !d.Failed || d.ErrorSummary != strings.Repeat("x", 200)+"…" {
+		t.Fatalf("digest = failed %t summary %.20q", d.Failed, d.ErrorSummary)
+	}
+	if grown := peak - base; grown > 8<<20 {
+		t.Fatalf("digesting a %d byte body grew the heap by %d bytes", rr.Body.Len(), grown)
+	}
+}
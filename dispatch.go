@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// dispatchToClient runs a facade JSON-RPC request on the owning server's
+// client in-process, under the server's own tool name, and records the
+// response the server's own route would have written. Downstream errors
+// become -32603 responses carrying the client's message, as mcp-go reports
+// handler errors. The status is 502 when the server has no client or the
+// method is not one the facade forwards.
+func (p *proxy) dispatchToClient(ctx context.Context, srv *Server, body []byte) (*responseRecorder, int) {
+	return p.streamToClient(ctx, srv, body, nil)
+}
+
+// resultStream lets a tools/call result whose structuredContent is an
+// object, which the adapter passes through as is, go to the client as it
+// is encoded instead of being recorded. ready is asked once the result is
+// in hand; it may set headers on w and the result's _meta, and reports
+// false when the result must be recorded after all.
+type resultStream struct {
+	w     http.ResponseWriter
+	ready func(result *mcp.CallToolResult) bool
+}
+
+// streamToClient is dispatchToClient sending a pass-through result to a
+// non-nil stream. The recorder of a streamed result holds its digest and
+// size but no body.
+func (p *proxy) streamToClient(ctx context.Context, srv *Server, body []byte, stream *resultStream) (*responseRecorder, int) {
+	rr := newResponseRecorder()
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		rr.StatusCode = http.StatusBadRequest
+		return rr, rr.StatusCode
+	}
+	if srv == nil || srv.client == nil {
+		rr.StatusCode = http.StatusBadGateway
+		return rr, rr.StatusCode
+	}
+	params := req.Params
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+
+	var result any
+	var err error
+	switch req.Method {
+	case "tools/call":
+		var call mcp.CallToolRequest
+		if err = json.Unmarshal(params, &call.Params); err == nil {
+			call.Params.Name = srv.downstreamToolName(call.Params.Name)
+			result, err = srv.client.callTool(ctx, call)
+		}
+	case "prompts/get":
+		var get mcp.GetPromptRequest
+		if err = json.Unmarshal(params, &get.Params); err == nil {
+			result, err = srv.client.getPrompt(ctx, get)
+		}
+	case "completion/complete":
+		var complete completeParams
+		if err = json.Unmarshal(params, &complete); err == nil {
+			_, ref := complete.owners()
+			key := completionKey{Server: srv.name, Ref: ref, Argument: complete.Argument.Name, Value: complete.Argument.Value}
+			result, err = p.completions.complete(ctx, key, func(ctx context.Context) (*mcp.CompleteResult, error) {
+				return srv.client.complete(ctx, complete.request())
+			})
+			if err != nil {
+				// suggestions are advisory: a server that cannot complete
+				// must not trip the circuit of the tools it serves
+				if !errors.Is(err, errCompletionThrottled) {
+					clientLogger(srv.name).Warn("completion/complete failed", "ref", ref, "err", err)
+				}
+				result, err = emptyCompletion(), nil
+			}
+		}
+	case "resources/read":
+		var read mcp.ReadResourceRequest
+		if err = json.Unmarshal(params, &read.Params); err == nil {
+			var contents []mcp.ResourceContents
+			if contents, err = srv.client.readResource(ctx, read); err == nil {
+				result = mcp.ReadResourceResult{Contents: contents}
+			}
+		}
+	default:
+		rr.StatusCode = http.StatusBadGateway
+		return rr, rr.StatusCode
+	}
+
+	if call, ok := result.(*mcp.CallToolResult); ok && err == nil && stream != nil && call != nil {
+		if sc, ok := call.StructuredContent.(map[string]any); ok && sc != nil && stream.ready(call) {
+			p.sendResult(rr, stream.w, rpcOK(req.ID, call))
+			return rr, rr.StatusCode
+		}
+	}
+
+	resp := rpcOK(req.ID, result)
+	var violation *downstreamViolation
+	switch {
+	case errors.As(err, &violation):
+		resp = violationError(ctx, req.ID, srv.name, req.Method, violation.violation, violation.quarantineID)
+	case err != nil:
+		resp = rpcError(req.ID, -32603, err.Error())
+	}
+	// mcp-go hands over a decoded result, so there is no downstream byte
+	// stream to forward; encode it straight into the recorder, which
+	// spills a large result to disk, rather than marshal and copy it
+	rr.HeaderMap.Set("Content-Type", "application/json")
+	if mErr := p.json.encode(rr, resp); mErr != nil {
+		rr.Body.Reset()
+		_ = p.json.encode(rr, rpcError(req.ID, -32603, mErr.Error()))
+	}
+	return rr, rr.StatusCode
+}
+
+// sendResult encodes resp to w, digesting it on the way for the call
+// policies, and marks rr as streamed.
+func (p *proxy) sendResult(rr *responseRecorder, w http.ResponseWriter, resp jsonrpcResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	tee := &streamTee{client: w, digest: newDigestWriter()}
+	if err := p.json.encode(tee, resp); err != nil {
+		// the encoder writes nothing when a value fails to marshal
+		_ = p.json.encode(tee, rpcError(resp.ID, -32603, err.Error()))
+	}
+	digest := tee.digest.Sum()
+	rr.digest, rr.streamed, rr.sent = &digest, true, tee.n
+	if tee.err != nil {
+		logger("facade").Warn("client went away during a streamed result", "bytes", tee.n, "err", tee.err)
+	}
+}
+
+// streamTee writes a streamed result to the client and to its digest. A
+// client that goes away mid-body does not cut the digest short: the call
+// itself completed.
+type streamTee struct {
+	client io.Writer
+	digest *digestWriter
+	n      int
+	err    error
+}
+
+func (t *streamTee) Write(b []byte) (int, error) {
+	if _, err := t.digest.Write(b); err != nil {
+		return 0, err
+	}
+	if t.err == nil {
+		_, t.err = t.client.Write(b)
+	}
+	t.n += len(b)
+	return len(b), nil
+}
+
+// withToolName returns a tools/call body with params.name replaced, for
+// calls made through an alias.
+func withToolName(body []byte, name string) []byte {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return body
+	}
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(envelope["params"], &params); err != nil || params == nil {
+		return body
+	}
+	params["name"], _ = json.Marshal(name)
+	envelope["params"], _ = json.Marshal(params)
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return body
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestDispatchToClient(t *testing.T) {
+	downstream := server.NewMCPServer("fs", "1.0.0", server.WithResourceCapabilities(true, true))
+	downstream.AddTool(mcp.NewTool("echo"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(req.GetString("text", "")), nil
+	})
+	downstream.AddTool(mcp.NewTool("fail"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("disk full")
+	})
+	downstream.AddResource(mcp.NewResource("file:///readme", "readme"), func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, Text: "hello"}}, nil
+	})
+	inProcess, err := client.NewInProcessClient(downstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inProcess.Close()
+	if err := inProcess.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := inProcess.Initialize(context.Background(), initRequest); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{name: "fs", client: &Client{name: "fs", client: inProcess}}
+
+	dispatch := func(body string) (int, map[string]any) {
+		rr, status := testProxy().dispatchToClient(context.Background(), srv, []byte(body))
+		var resp map[string]any
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return status, resp
+	}
+
+	status, resp := dispatch(`{"jsonrpc":"2.0","id":"call-1","method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+	content, _ := resp["result"].(map[string]any)["content"].([]any)
+	if status != http.StatusOK || resp["id"] != "call-1" || len(content) != 1 || content[0].(map[string]any)["text"] != "hi" {
+		t.Fatalf("tools/call = %d %v", status, resp)
+	}
+
+	status, resp = dispatch(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fail"}}`)
+	rpcErr, _ := resp["error"].(map[string]any)
+	if status != http.StatusOK || rpcErr["code"] != float64(-32603) || !strings.Contains(fmt.Sprint(rpcErr["message"]), "disk full") {
+		t.Fatalf("failing tools/call = %d %v", status, resp)
+	}
+
+	status, resp = dispatch(`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"file:///readme"}}`)
+	contents, _ := resp["result"].(map[string]any)["contents"].([]any)
+	if status != http.StatusOK || len(contents) != 1 || contents[0].(map[string]any)["text"] != "hello" {
+		t.Fatalf("resources/read = %d %v", status, resp)
+	}
+
+	srv.toolPrefix = "fs_"
+	status, resp = dispatch(string(withToolName([]byte(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"fs_alias","arguments":{"text":"prefixed"}}}`), "fs_echo")))
+	content, _ = resp["result"].(map[string]any)["content"].([]any)
+	if status != http.StatusOK || len(content) != 1 || content[0].(map[string]any)["text"] != "prefixed" {
+		t.Fatalf("prefixed tools/call = %d %v", status, resp)
+	}
+
+	if rr, status := testProxy().dispatchToClient(context.Background(), &Server{name: "down"}, []byte(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo"}}`)); status != http.StatusBadGateway || rr.Body.Len() != 0 {
+		t.Fatalf("expected 502 for a server without a client, got %d %q", status, rr.Body.String())
+	}
+}
+
+func TestStreamToClientSendsPassThroughResults(t *testing.T) {
+	downstream := server.NewMCPServer("fs", "1.0.0")
+	downstream.AddTool(mcp.NewTool("stat"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultStructured(map[string]any{"size": 42}, "42 bytes"), nil
+	})
+	downstream.AddTool(mcp.NewTool("echo"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("hi"), nil
+	})
+	inProcess, err := client.NewInProcessClient(downstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inProcess.Close()
+	if err := inProcess.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := inProcess.Initialize(context.Background(), initRequest); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{name: "fs", client: &Client{name: "fs", client: inProcess}}
+
+	ctx := withWarnings(context.Background())
+	warn(ctx, responseWarning{Code: warnToolConflict, Server: "fs", Tool: "stat"})
+	asked := 0
+	call := func(tool string, ready bool) (*responseRecorder, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		stream := &resultStream{w: w, ready: func(result *mcp.CallToolResult) bool {
+			asked++
+			if ready {
+				result.Meta = attachWarningsToMeta(ctx, result.Meta)
+			}
+			return ready
+		}}
+		rr, _ := testProxy().streamToClient(ctx, srv, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+tool+`"}}`), stream)
+		return rr, w
+	}
+
+	rr, w := call("stat", true)
+	if !rr.Streamed() || rr.Body.Len() != 0 || rr.Len() != w.Body.Len() || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("streamed=%t recorded=%d sent=%d client=%d", rr.Streamed(), rr.Body.Len(), rr.Len(), w.Body.Len())
+	}
+	var resp struct {
+		Result struct {
+			StructuredContent map[string]any `json:"structuredContent"`
+			Meta              struct {
+				Stelae struct {
+					Warnings []responseWarning `json:"warnings"`
+				} `json:"x-stelae"`
+			} `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Result.StructuredContent["size"] != float64(42) || len(resp.Result.Meta.Stelae.Warnings) != 1 {
+		t.Fatalf("client got %s (%v)", w.Body.String(), err)
+	}
+	if got, want := rr.Digest(), digestBytes(w.Body.Bytes()); got.ResultHash != want.ResultHash || got.StructuredContent["size"] == nil || got.Failed {
+		t.Fatalf("streamed digest %+v, want %+v", got, want)
+	}
+	if dispatchOutcome(http.StatusOK, rr) != callSucceeded {
+		t.Fatal("a streamed result counted as a failure")
+	}
+
+	rr, w = call("stat", false)
+	if rr.Streamed() || w.Body.Len() != 0 || !strings.Contains(rr.Body.String(), `"structuredContent"`) {
+		t.Fatalf("declined stream: streamed=%t client=%q recorded=%q", rr.Streamed(), w.Body.String(), rr.Body.String())
+	}
+
+	asked = 0
+	rr, w = call("echo", true)
+	if asked != 0 || rr.Streamed() || w.Body.Len() != 0 || !strings.Contains(rr.Body.String(), `"hi"`) {
+		t.Fatalf("unstructured result: asked=%d streamed=%t client=%q", asked, rr.Streamed(), w.Body.String())
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultElicitationTimeout = 10 * time.Minute
+
+	elicitationCreateMethod = "elicitation/create"
+)
+
+// ElicitationConfig advertises elicitation to downstream servers and relays
+// their elicitation/create requests to a facade client that declared the
+// elicitation capability. Timeout bounds the wait for the user's answer
+// (default 10m).
+type ElicitationConfig struct {
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+var errNoElicitationClient = errors.New("no connected client can answer elicitation for this server")
+
+// elicitationHandler answers a downstream server's elicitation/create with
+// the client's result.
+type elicitationHandler func(ctx context.Context, params any) (json.RawMessage, error)
+
+// elicitationBridge relays elicitation/create from downstream servers to
+// the event stream of a facade session with a call in flight on that
+// server, and hands the session's POSTed response back. A nil bridge
+// advertises no elicitation.
+type elicitationBridge struct {
+	timeout  time.Duration
+	requests *clientRequests
+	// sessions returns the sessions that may answer for server, in order of
+	// preference.
+	sessions func(server string) []string
+}
+
+func newElicitationBridge(conf *ElicitationConfig, requests *clientRequests) *elicitationBridge {
+	if conf == nil {
+		return nil
+	}
+	b := &elicitationBridge{timeout: conf.Timeout, requests: requests}
+	if b.timeout <= 0 {
+		b.timeout = defaultElicitationTimeout
+	}
+	return b
+}
+
+// handler is the elicitation handler for server's client, nil without a
+// bridge.
+func (b *elicitationBridge) handler(server string) elicitationHandler {
+	if b == nil {
+		return nil
+	}
+	return func(ctx context.Context, params any) (json.RawMessage, error) {
+		return b.request(ctx, server, params)
+	}
+}
+
+// request sends an elicitation/create with params to the first session with
+// an open stream and waits for the user's answer.
+func (b *elicitationBridge) request(ctx context.Context, server string, params any) (json.RawMessage, error) {
+	var sessions []string
+	if b.sessions != nil {
+		sessions = b.sessions(server)
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	result, session, err := b.requests.first(ctx, sessions, elicitationCreateMethod, params)
+	if errors.Is(err, errNoClientStream) {
+		return nil, errNoElicitationClient
+	}
+	logger("facade").Info("elicitation/create", "server", server, "session", session)
+	if err != nil {
+		return nil, fmt.Errorf("elicitation: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestElicitationBridge(t *testing.T) {
+	if newElicitationBridge(nil, newClientRequests(newSessionStreams())).handler("deploy") != nil {
+		t.Fatal("a disabled bridge advertised elicitation")
+	}
+	streams := newSessionStreams()
+	b := newElicitationBridge(&ElicitationConfig{Timeout: time.Second}, newClientRequests(streams))
+	b.sessions = func(string) []string { return []string{"closed", "sess-1"} }
+	notices, unsubscribe := streams.subscribe("sess-1")
+	defer unsubscribe()
+
+	go func() {
+		var req struct {
+			ID     string         `json:"id"`
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		_ = json.Unmarshal(<-notices, &req)
+		if req.Method != elicitationCreateMethod || req.Params["message"] != "Deploy to production?" {
+			t.Errorf("client got %s %v", req.Method, req.Params)
+		}
+		b.requests.deliver([]byte(`{"jsonrpc":"2.0","id":"` + req.ID + `","result":{"action":"accept","content":{"confirm":true}}}`))
+	}()
+	c := &Client{name: "deploy", elicitation: b.handler("deploy")}
+	resp, err := c.handleServerRequest(context.Background(), transport.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      mcp.NewRequestId(int64(7)),
+		Method:  elicitationCreateMethod,
+		Params:  map[string]any{"message": "Deploy to production?", "requestedSchema": map[string]any{"type": "object"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Result) != `{"action":"accept","content":{"confirm":true}}` {
+		t.Fatalf("result = %s", resp.Result)
+	}
+
+	b.sessions = func(string) []string { return nil }
+	if _, err := b.handler("deploy")(context.Background(), nil); !errors.Is(err, errNoElicitationClient) {
+		t.Fatalf("no client: %v", err)
+	}
+	c.elicitation = nil
+	if _, err := c.handleServerRequest(context.Background(), transport.JSONRPCRequest{Method: elicitationCreateMethod}); err == nil {
+		t.Fatal("an opted-out server got an elicitation relayed")
+	}
+}
+
+type recordingTransport struct {
+	transport.Interface
+	sent []transport.JSONRPCRequest
+}
+
+func (t *recordingTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	t.sent = append(t.sent, request)
+	return &transport.JSONRPCResponse{}, nil
+}
+
+func TestCapabilityTransport(t *testing.T) {
+	inner := &recordingTransport{}
+	c := &Client{elicitation: func(context.Context, any) (json.RawMessage, error) { return nil, nil }}
+	wrapped := &capabilityTransport{Interface: inner, extra: c.extraCapabilities}
+	if wrapped.bidirectional() {
+		t.Fatal("a one-way transport reported as bidirectional")
+	}
+	params := struct {
+		Capabilities mcp.ClientCapabilities `json:"capabilities"`
+	}{Capabilities: mcp.ClientCapabilities{Sampling: &struct{}{}}}
+	_, _ = wrapped.SendRequest(context.Background(), transport.JSONRPCRequest{Method: "initialize", Params: params})
+	_, _ = wrapped.SendRequest(context.Background(), transport.JSONRPCRequest{Method: "tools/list", Params: params})
+
+	data, _ := json.Marshal(inner.sent[0].Params)
+	if !initializeDeclares(data, "elicitation") || !initializeDeclares(data, "sampling") {
+		t.Fatalf("initialize params = %s", data)
+	}
+	if data, _ := json.Marshal(inner.sent[1].Params); initializeDeclares(data, "elicitation") {
+		t.Fatal("a request other than initialize was rewritten")
+	}
+
+	c.elicitation = nil
+	if c.extraCapabilities() != nil {
+		t.Fatal("elicitation declared without a handler")
+	}
+}
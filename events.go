@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// proxyEvent is an operator-facing notification (SLO burn, loop detected, ...)
+// logged locally and delivered to configured webhooks.
+type proxyEvent struct {
+	Type string         `json:"type"`
+	At   time.Time      `json:"at"`
+	Data map[string]any `json:"data,omitempty"`
+}
+
+type eventBus struct {
+	webhooks []string
+	client   *http.Client
+}
+
+func newEventBus(webhooks []string) *eventBus {
+	return &eventBus{
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// emit never blocks the caller; webhook delivery is best effort.
+func (b *eventBus) emit(eventType string, data map[string]any) {
+	if b == nil {
+		return
+	}
+	ev := proxyEvent{Type: eventType, At: time.Now().UTC(), Data: data}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		logger("events").Error("failed to marshal event", "type", eventType, "err", err)
+		return
+	}
+	logger("events").Info(eventType, "event", string(payload))
+	for _, hook := range b.webhooks {
+		go b.deliver(hook, payload)
+	}
+}
+
+func (b *eventBus) deliver(hook string, payload []byte) {
+	resp, err := b.client.Post(hook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		logger("events").Warn("webhook failed", "url", redactURL(hook), "err", withoutURL(err))
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger("events").Warn("webhook refused event", "url", redactURL(hook), "status", resp.StatusCode)
+	}
+}
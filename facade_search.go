@@ -47,6 +47,7 @@ func buildFacadeSearchPayload(_ string) map[string]any {
 			"url":   hit.URL,
 			"metadata": map[string]any{
 				"snippet": hit.Snippet,
+				"facet":   staticHitFacet(),
 			},
 		})
 	}
@@ -65,8 +66,13 @@ func buildFacadeFetchPayload(id string) (map[string]any, bool) {
 			"url":   hit.URL,
 			"metadata": map[string]any{
 				"snippet": hit.Snippet,
+				"facet":   staticHitFacet(),
 			},
 		}, true
 	}
 	return nil, false
 }
+
+func staticHitFacet() map[string]any {
+	return map[string]any{"server": facadeServerName, "type": searchTypeDocument, "mimeType": "text/markdown"}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// facadeFanOutToolName calls one tool on every server that provides it.
+	facadeFanOutToolName = "fan_out"
+
+	defaultFanOutParallelism = 8
+)
+
+// FanOutConfig enables the facade fan_out tool, which calls the same tool on
+// every server exposing it and returns the results grouped by server.
+// Parallelism bounds the calls in flight for one fan_out (default 8).
+type FanOutConfig struct {
+	Parallelism int `json:"parallelism,omitempty"`
+}
+
+func fanOutToolDescriptor() map[string]any {
+	return map[string]any{
+		"name":        facadeFanOutToolName,
+		"description": messages.defaultText(msgFanOutDescription),
+		"inputSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"tool": map[string]any{
+					"title":       "Tool",
+					"type":        "string",
+					"description": messages.defaultText(msgFanOutTool),
+				},
+				"arguments": map[string]any{
+					"title":       "Arguments",
+					"type":        "object",
+					"description": messages.defaultText(msgFanOutArguments),
+				},
+				"servers": map[string]any{
+					"title":       "Servers",
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": messages.defaultText(msgFanOutServers),
+				},
+			},
+			"required": []string{"tool"},
+		},
+	}
+}
+
+type fanOutTargetKey struct{}
+
+// withFanOutTarget pins tools/call requests made with ctx to server, so one
+// fan_out leg reaches the server it was meant for whatever the conflict
+// policy would pick.
+func withFanOutTarget(ctx context.Context, server string) context.Context {
+	return context.WithValue(ctx, fanOutTargetKey{}, server)
+}
+
+func fanOutTargetFromContext(ctx context.Context) (string, bool) {
+	server, ok := ctx.Value(fanOutTargetKey{}).(string)
+	return server, ok
+}
+
+// pinnedRoute routes name to server if server exposes it to the caller.
+func pinnedRoute(tools map[string][]string, name, server string, include func(server, tool string) bool) (toolRoute, error) {
+	if !slices.Contains(tools[name], server) || (include != nil && !include(server, name)) {
+		return toolRoute{}, errUnknownTool
+	}
+	return toolRoute{server: server, tool: name}, nil
+}
+
+// fanOutServers returns one server per logical server exposing name, in
+// logical name order; replicas of a group are balanced as for tools/call.
+// only, when set, keeps the servers or groups it names.
+func (b *replicaBalancer) fanOutServers(tools map[string][]string, name string, only []string, affinity callAffinity, include func(server, tool string) bool) []string {
+	members := make(map[string][]string)
+	for _, server := range tools[name] {
+		if include != nil && !include(server, name) {
+			continue
+		}
+		logical := b.logical(server)
+		if len(only) > 0 && !slices.Contains(only, logical) && !slices.Contains(only, server) {
+			continue
+		}
+		members[logical] = append(members[logical], server)
+	}
+	logical := make([]string, 0, len(members))
+	for name := range members {
+		logical = append(logical, name)
+	}
+	sort.Strings(logical)
+	out := make([]string, 0, len(logical))
+	for _, group := range logical {
+		out = append(out, b.pick(group, members[group], affinity))
+	}
+	return out
+}
+
+// fanOutResult is one server's outcome; exactly one of Result and Error is
+// set.
+type fanOutResult struct {
+	Server string          `json:"server"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *jsonrpcError   `json:"error,omitempty"`
+}
+
+// failed reports whether the call errored or the tool reported isError.
+func (r fanOutResult) failed() bool {
+	if r.Error != nil {
+		return true
+	}
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	_ = json.Unmarshal(r.Result, &result)
+	return result.IsError
+}
+
+// runFanOut calls tool with args on each server through call, which
+// dispatches one tools/call request, with at most parallelism in flight.
+// Results are in server order; one failing server does not affect the
+// others.
+func runFanOut(ctx context.Context, servers []string, tool string, args json.RawMessage, parallelism int, call func(ctx context.Context, server string, entry []byte) []byte) []fanOutResult {
+	if parallelism <= 0 {
+		parallelism = defaultFanOutParallelism
+	}
+	if len(args) == 0 {
+		args = json.RawMessage(`{}`)
+	}
+	params, _ := json.Marshal(map[string]any{"name": tool, "arguments": args})
+	results := make([]fanOutResult, len(servers))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+	for i, server := range servers {
+		results[i].Server = server
+		entry, _ := json.Marshal(jsonrpcRequest{JSONRPC: "2.0", ID: i, Method: "tools/call", Params: params})
+		g.Go(func() error {
+			var reply struct {
+				Result json.RawMessage `json:"result"`
+				Error  *jsonrpcError   `json:"error"`
+			}
+			data := call(gctx, server, entry)
+			switch {
+			case json.Unmarshal(data, &reply) != nil:
+				results[i].Error = localizedErrors(ctx).response(i, errNameInternal, map[string]string{"detail": "no reply"}).Error
+			case reply.Error != nil:
+				results[i].Error = reply.Error
+			default:
+				results[i].Result = reply.Result
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results
+}
+
+// fanOutToolResult wraps the results as a tools/call result. It is an error
+// only when every server failed.
+func fanOutToolResult(tool string, results []fanOutResult) (map[string]any, int) {
+	failed := 0
+	for _, result := range results {
+		if result.failed() {
+			failed++
+		}
+	}
+	structured := map[string]any{"tool": tool, "results": results}
+	text, err := json.Marshal(structured)
+	if err != nil {
+		text = []byte(err.Error())
+	}
+	out := map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": string(text)}},
+		"structuredContent": structured,
+	}
+	if failed == len(results) {
+		out["isError"] = true
+	}
+	return out, failed
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFanOutServersAndPinnedRoute(t *testing.T) {
+	replicas := mustReplicaBalancer(nil)
+	groups := map[string]string{"kb-1": "kb", "kb-2": "kb"}
+	replicas.groupOf = func(server string) string { return groups[server] }
+
+	tools := map[string][]string{"search_code": {"repo-b", "kb-1", "repo-a", "kb-2", "repo-c"}}
+	hidden := func(server, tool string) bool { return server != "repo-c" }
+	got := replicas.fanOutServers(tools, "search_code", nil, callAffinity{}, hidden)
+	if len(got) != 3 || got[0] != "kb-1" && got[0] != "kb-2" || got[1] != "repo-a" || got[2] != "repo-b" {
+		t.Fatalf("targets = %v", got)
+	}
+	if got := replicas.fanOutServers(tools, "search_code", []string{"repo-b", "kb"}, callAffinity{}, hidden); len(got) != 2 || got[1] != "repo-b" {
+		t.Fatalf("filtered targets = %v", got)
+	}
+	if got := replicas.fanOutServers(tools, "missing", nil, callAffinity{}, nil); len(got) != 0 {
+		t.Fatalf("unknown tool targets = %v", got)
+	}
+
+	if route, err := pinnedRoute(tools, "search_code", "repo-a", hidden); err != nil || route != (toolRoute{server: "repo-a", tool: "search_code"}) {
+		t.Fatalf("pinned route = %+v, %v", route, err)
+	}
+	if _, err := pinnedRoute(tools, "search_code", "repo-c", hidden); err != errUnknownTool {
+		t.Fatalf("pinned to a hidden server: %v", err)
+	}
+}
+
+func TestRunFanOut(t *testing.T) {
+	servers := []string{"a", "b", "c"}
+	results := runFanOut(context.Background(), servers, "search_code", json.RawMessage(`{"q":"x"}`), 2, func(ctx context.Context, server string, entry []byte) []byte {
+		var req struct {
+			ID     int `json:"id"`
+			Params struct {
+				Name      string          `json:"name"`
+				Arguments json.RawMessage `json:"arguments"`
+			} `json:"params"`
+		}
+		_ = json.Unmarshal(entry, &req)
+		if req.Params.Name != "search_code" || string(req.Params.Arguments) != `{"q":"x"}` {
+			t.Errorf("%s got params %+v", server, req.Params)
+		}
+		switch server {
+		case "a":
+			return []byte(`{"jsonrpc":"2.0","id":0,"result":{"content":[{"type":"text","text":"hit"}]}}`)
+		case "b":
+			return []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Unknown tool"}}`)
+		}
+		return nil
+	})
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Server)
+	}
+	if !reflect.DeepEqual(names, servers) {
+		t.Fatalf("results out of server order: %v", names)
+	}
+	if results[0].Error != nil || results[1].Error == nil || results[1].Error.Code != -32601 || results[2].Error == nil {
+		t.Fatalf("results = %+v", results)
+	}
+
+	out, failed := fanOutToolResult("search_code", results)
+	if failed != 2 || out["isError"] != nil {
+		t.Fatalf("partial failure: failed=%d isError=%v", failed, out["isError"])
+	}
+	if _, failed := fanOutToolResult("search_code", results[1:]); failed != 2 {
+		t.Fatalf("failed = %d", failed)
+	}
+	if out, _ := fanOutToolResult("search_code", results[1:]); out["isError"] != true {
+		t.Fatal("a fan_out where every server failed is not an error")
+	}
+	toolError := fanOutResult{Server: "d", Result: json.RawMessage(`{"content":[],"isError":true}`)}
+	if !toolError.failed() {
+		t.Fatal("isError result not counted as a failure")
+	}
+}
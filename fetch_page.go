@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// fetchWindow selects a byte range of a fetched document's text. A zero
+// Length returns everything from Offset on.
+type fetchWindow struct {
+	Offset int
+	Length int
+}
+
+type fetchPageArgs struct {
+	Offset *int   `json:"offset,omitempty"`
+	Length int    `json:"length,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+func (a fetchPageArgs) requested() bool {
+	return a.Offset != nil || a.Length > 0 || a.Cursor != ""
+}
+
+// window resolves offset/length or a cursor from a previous page.
+func (a fetchPageArgs) window() (fetchWindow, error) {
+	win := fetchWindow{Length: a.Length}
+	if a.Length < 0 {
+		return win, errors.New("length must be positive")
+	}
+	if a.Cursor != "" {
+		offset, length, err := decodeFetchCursor(a.Cursor)
+		if err != nil {
+			return win, err
+		}
+		win.Offset = offset
+		if win.Length == 0 {
+			win.Length = length
+		}
+		return win, nil
+	}
+	if a.Offset != nil {
+		if *a.Offset < 0 {
+			return win, errors.New("offset must not be negative")
+		}
+		win.Offset = *a.Offset
+	}
+	return win, nil
+}
+
+func encodeFetchCursor(offset, length int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", offset, length)))
+}
+
+func decodeFetchCursor(cursor string) (int, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, errors.New("invalid cursor")
+	}
+	offsetText, lengthText, ok := strings.Cut(string(raw), ":")
+	offset, oErr := strconv.Atoi(offsetText)
+	length, lErr := strconv.Atoi(lengthText)
+	if !ok || oErr != nil || lErr != nil || offset < 0 || length < 0 {
+		return 0, 0, errors.New("invalid cursor")
+	}
+	return offset, length, nil
+}
+
+// runeStart moves i back to the start of the UTF-8 sequence containing it so
+// pages never split a character.
+func runeStart(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// applyFetchWindow slices payload["text"] to win and records paging metadata
+// (totalLength, offset, length, hasMore, nextCursor).
+func applyFetchWindow(payload map[string]any, win fetchWindow) map[string]any {
+	text, _ := payload["text"].(string)
+	total := len(text)
+	start := runeStart(text, min(win.Offset, total))
+	end := total
+	if win.Length > 0 && start+win.Length < total {
+		end = runeStart(text, start+win.Length)
+		if end <= start {
+			_, size := utf8.DecodeRuneInString(text[start:])
+			end = start + size
+		}
+	}
+
+	out := make(map[string]any, len(payload))
+	for k, v := range payload {
+		out[k] = v
+	}
+	out["text"] = text[start:end]
+	metadata := make(map[string]any)
+	if existing, ok := payload["metadata"].(map[string]any); ok {
+		for k, v := range existing {
+			metadata[k] = v
+		}
+	}
+	metadata["totalLength"] = total
+	metadata["offset"] = start
+	metadata["length"] = end - start
+	metadata["hasMore"] = end < total
+	if end < total {
+		metadata["nextCursor"] = encodeFetchCursor(end, win.Length)
+	}
+	out["metadata"] = metadata
+	return out
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyFetchWindowPagesWithCursor(t *testing.T) {
+	payload := map[string]any{"id": "doc", "text": "abcdefghij", "metadata": map[string]any{"snippet": "s"}}
+
+	var args struct {
+		ID string `json:"id"`
+		fetchPageArgs
+	}
+	if err := json.Unmarshal([]byte(`{"id":"doc","offset":2,"length":4}`), &args); err != nil {
+		t.Fatalf("decode args: %v", err)
+	}
+	win, err := args.window()
+	if err != nil || !args.requested() {
+		t.Fatalf("window: %v", err)
+	}
+	first := applyFetchWindow(payload, win)
+	meta := first["metadata"].(map[string]any)
+	if first["text"] != "cdef" || meta["totalLength"] != 10 || meta["hasMore"] != true || meta["snippet"] != "s" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+	if payload["text"] != "abcdefghij" {
+		t.Fatal("original payload must not be modified")
+	}
+
+	next, err := fetchPageArgs{Cursor: meta["nextCursor"].(string)}.window()
+	if err != nil {
+		t.Fatalf("cursor window: %v", err)
+	}
+	second := applyFetchWindow(payload, next)
+	if second["text"] != "ghij" || second["metadata"].(map[string]any)["hasMore"] != false {
+		t.Fatalf("unexpected second page %+v", second)
+	}
+	if _, ok := second["metadata"].(map[string]any)["nextCursor"]; ok {
+		t.Fatal("last page should not carry nextCursor")
+	}
+}
+
+func TestApplyFetchWindowKeepsRunesWhole(t *testing.T) {
+	payload := map[string]any{"text": "héllo"}
+	page := applyFetchWindow(payload, fetchWindow{Offset: 0, Length: 2})
+	if page["text"] != "h" {
+		t.Fatalf("expected page to stop before split rune, got %q", page["text"])
+	}
+	page = applyFetchWindow(payload, fetchWindow{Offset: 2, Length: 1})
+	if page["text"] != "é" {
+		t.Fatalf("expected whole rune when offset lands mid-rune, got %q", page["text"])
+	}
+}
+
+func TestFetchPageArgsRejectsBadInput(t *testing.T) {
+	neg := -1
+	if _, err := (fetchPageArgs{Offset: &neg}).window(); err == nil {
+		t.Fatal("expected negative offset error")
+	}
+	if _, err := (fetchPageArgs{Cursor: "%%%"}).window(); err == nil {
+		t.Fatal("expected invalid cursor error")
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFetchMaxBytes = 1 << 20
+	defaultFetchTimeout  = 10 * time.Second
+	maxFetchRedirects    = 5
+)
+
+// FetchConfig lets the facade fetch tool retrieve full URLs. URL fetching is
+// disabled unless at least one domain is allowed.
+type FetchConfig struct {
+	// AllowedSchemes defaults to ["https"].
+	AllowedSchemes []string `json:"allowedSchemes,omitempty"`
+	// AllowedDomains holds exact hosts or "*.example.com" for subdomains.
+	AllowedDomains []string      `json:"allowedDomains,omitempty"`
+	MaxBytes       int64         `json:"maxBytes,omitempty"`
+	Timeout        time.Duration `json:"timeout,omitempty"`
+}
+
+var errFetchNotAllowed = errors.New("url not allowed by mcpProxy.fetch")
+
+type urlFetcher struct {
+	schemes  []string
+	domains  []string
+	maxBytes int64
+	timeout  time.Duration
+	client   *http.Client
+}
+
+func newURLFetcher(conf *FetchConfig) *urlFetcher {
+	if conf == nil || len(conf.AllowedDomains) == 0 {
+		return nil
+	}
+	f := &urlFetcher{
+		schemes:  []string{"https"},
+		maxBytes: conf.MaxBytes,
+		timeout:  conf.Timeout,
+	}
+	if len(conf.AllowedSchemes) > 0 {
+		f.schemes = make([]string, 0, len(conf.AllowedSchemes))
+		for _, s := range conf.AllowedSchemes {
+			f.schemes = append(f.schemes, strings.ToLower(s))
+		}
+	}
+	for _, d := range conf.AllowedDomains {
+		f.domains = append(f.domains, strings.ToLower(strings.TrimSpace(d)))
+	}
+	if f.maxBytes <= 0 {
+		f.maxBytes = defaultFetchMaxBytes
+	}
+	if f.timeout <= 0 {
+		f.timeout = defaultFetchTimeout
+	}
+	f.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return errors.New("too many redirects")
+			}
+			return f.allowed(req.URL)
+		},
+	}
+	return f
+}
+
+// looksLikeURL distinguishes fetch ids that are absolute URLs from catalog ids
+// such as "repo:docs/SPEC-v1.md".
+func looksLikeURL(id string) bool {
+	u, err := url.Parse(id)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func (f *urlFetcher) allowed(u *url.URL) error {
+	if !slices.Contains(f.schemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: scheme %q", errFetchNotAllowed, u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range f.domains {
+		if suffix, ok := strings.CutPrefix(domain, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return nil
+			}
+			continue
+		}
+		if host == domain {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: host %q", errFetchNotAllowed, host)
+}
+
+// fetchedDocument is a URL retrieved and converted to text.
+type fetchedDocument struct {
+	URL         string
+	Title       string
+	Text        string
+	ContentType string
+	Bytes       int
+	Truncated   bool
+	FetchedAt   time.Time
+}
+
+func (f *urlFetcher) fetch(ctx context.Context, raw string) (*fetchedDocument, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.allowed(u); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html, text/plain, text/markdown, application/json;q=0.9, */*;q=0.1")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("GET %s returned %s", u, resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !textualMediaType(mediaType) {
+		return nil, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	doc := &fetchedDocument{
+		URL:         resp.Request.URL.String(),
+		ContentType: mediaType,
+		FetchedAt:   time.Now().UTC(),
+	}
+	if int64(len(body)) > f.maxBytes {
+		body = body[:f.maxBytes]
+		doc.Truncated = true
+	}
+	doc.Bytes = len(body)
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		doc.Title, doc.Text = htmlToText(string(body))
+	} else {
+		doc.Text = string(body)
+	}
+	if doc.Title == "" {
+		doc.Title = u.Host + u.Path
+	}
+	return doc, nil
+}
+
+func textualMediaType(mediaType string) bool {
+	if mediaType == "" || strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/xhtml+xml", "application/markdown":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+var (
+	htmlDropBlocks = regexp.MustCompile(`(?is)<(script|style|noscript|template|svg)\b.*?</(script|style|noscript|template|svg)\s*>`)
+	htmlComments   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTitle      = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlBreaks     = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr|/section|/article)\b[^>]*>`)
+	htmlTags       = regexp.MustCompile(`(?s)<[^>]+>`)
+	blankRuns      = regexp.MustCompile(`\n{3,}`)
+	spaceRuns      = regexp.MustCompile(`[ \t\r\f\v]+`)
+)
+
+// htmlToText is a dependency-free HTML flattener: it drops scripts and styles,
+// turns block ends into line breaks, strips tags, and unescapes entities.
+func htmlToText(doc string) (string, string) {
+	var title string
+	if m := htmlTitle.FindStringSubmatch(doc); m != nil {
+		title = strings.TrimSpace(html.UnescapeString(htmlTags.ReplaceAllString(m[1], "")))
+		doc = strings.Replace(doc, m[0], "", 1)
+	}
+	doc = htmlComments.ReplaceAllString(doc, "")
+	doc = htmlDropBlocks.ReplaceAllString(doc, "")
+	doc = htmlBreaks.ReplaceAllString(doc, "\n")
+	doc = htmlTags.ReplaceAllString(doc, "")
+	doc = html.UnescapeString(doc)
+	lines := strings.Split(doc, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRuns.ReplaceAllString(line, " "))
+	}
+	text := blankRuns.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return title, strings.TrimSpace(text)
+}
+
+// payload renders the document in the connector fetch shape.
+func (d *fetchedDocument) payload(id string) map[string]any {
+	return map[string]any{
+		"id":    id,
+		"title": d.Title,
+		"text":  d.Text,
+		"url":   d.URL,
+		"metadata": map[string]any{
+			"contentType": d.ContentType,
+			"bytes":       d.Bytes,
+			"truncated":   d.Truncated,
+			"fetchedAt":   d.FetchedAt.Format(time.RFC3339),
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestURLFetcherConvertsHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Guide &amp; FAQ</title><style>p{}</style></head>
+<body><script>alert(1)</script><h1>Intro</h1><p>Hello&nbsp;<b>world</b></p><!-- hidden --><p>Bye</p></body></html>`))
+	}))
+	defer srv.Close()
+
+	f := newURLFetcher(&FetchConfig{AllowedSchemes: []string{"http"}, AllowedDomains: []string{"127.0.0.1"}})
+	doc, err := f.fetch(context.Background(), srv.URL+"/guide")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if doc.Title != "Guide & FAQ" {
+		t.Fatalf("title = %q", doc.Title)
+	}
+	if doc.Text != "Intro\nHello world\nBye" {
+		t.Fatalf("text = %q", doc.Text)
+	}
+	payload := doc.payload(srv.URL + "/guide")
+	if payload["url"] != srv.URL+"/guide" || payload["metadata"].(map[string]any)["contentType"] != "text/html" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+}
+
+func TestURLFetcherEnforcesAllowlistAndLimits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "https://evil.example.net/", http.StatusFound)
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte{0, 1, 2})
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+		}
+	}))
+	defer srv.Close()
+
+	f := newURLFetcher(&FetchConfig{AllowedSchemes: []string{"http"}, AllowedDomains: []string{"127.0.0.1"}, MaxBytes: 10})
+	doc, err := f.fetch(context.Background(), srv.URL+"/big")
+	if err != nil || !doc.Truncated || doc.Bytes != 10 {
+		t.Fatalf("expected truncated doc, got %+v, %v", doc, err)
+	}
+	if _, err := f.fetch(context.Background(), srv.URL+"/redirect"); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("redirect off the allowlist should fail, got %v", err)
+	}
+	if _, err := f.fetch(context.Background(), srv.URL+"/binary"); err == nil || !strings.Contains(err.Error(), "unsupported content type") {
+		t.Fatalf("binary content should fail, got %v", err)
+	}
+	if _, err := f.fetch(context.Background(), "https://127.0.0.1/"); !errors.Is(err, errFetchNotAllowed) {
+		t.Fatalf("https scheme should be rejected, got %v", err)
+	}
+}
+
+func TestURLFetcherDomainMatching(t *testing.T) {
+	f := newURLFetcher(&FetchConfig{AllowedDomains: []string{"docs.example.com", "*.example.org"}})
+	cases := map[string]bool{
+		"https://docs.example.com/a":     true,
+		"https://DOCS.example.com:8443/": true,
+		"https://api.example.com/":       false,
+		"https://a.b.example.org/":       true,
+		"https://example.org/":           false,
+		"http://docs.example.com/":       false,
+	}
+	for raw, want := range cases {
+		u, _ := http.NewRequest(http.MethodGet, raw, nil)
+		if got := f.allowed(u.URL) == nil; got != want {
+			t.Errorf("allowed(%s) = %t, want %t", raw, got, want)
+		}
+	}
+	if newURLFetcher(&FetchConfig{}) != nil {
+		t.Fatal("fetcher without domains should be disabled")
+	}
+	if looksLikeURL("repo:docs/SPEC-v1.md") || !looksLikeURL("https://docs.example.com/x") {
+		t.Fatal("looksLikeURL misclassified ids")
+	}
+}
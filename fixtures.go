@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fixtureCaptureTimeout bounds connecting to and listing one server.
+const fixtureCaptureTimeout = 30 * time.Second
+
+// serverFixture is what -capture-fixtures records of one downstream server
+// and -serve-fixture plays back.
+type serverFixture struct {
+	Server            string                 `json:"server"`
+	CapturedAt        time.Time              `json:"capturedAt"`
+	Initialize        *mcp.InitializeResult  `json:"initialize"`
+	Tools             []mcp.Tool             `json:"tools"`
+	Prompts           []mcp.Prompt           `json:"prompts,omitempty"`
+	Resources         []mcp.Resource         `json:"resources,omitempty"`
+	ResourceTemplates []mcp.ResourceTemplate `json:"resourceTemplates,omitempty"`
+	Calls             []fixtureCall          `json:"calls,omitempty"`
+}
+
+// fixtureCall is one sample tools/call and its response, redacted with the
+// default redaction keys. Error is set instead of Result when the call
+// failed at the protocol level.
+type fixtureCall struct {
+	Tool      string          `json:"tool"`
+	Arguments map[string]any  `json:"arguments,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// captureFixtures connects to every configured server and writes a fixture
+// for each to dir, with a config.json that runs the proxy against them.
+// Sample calls are the configured probes, whose arguments are meant to be
+// safe to send repeatedly.
+func captureFixtures(ctx context.Context, config *Config, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(config.McpServers))
+	for name := range config.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	servers := make(map[string]any, len(names))
+	var failed []string
+	for _, name := range names {
+		fixture, err := captureServerFixture(ctx, config, name)
+		if err != nil {
+			clientLogger(name).Error("fixture capture failed", "err", err)
+			failed = append(failed, name)
+			continue
+		}
+		data, err := json.MarshalIndent(fixture, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(abs, name+".json")
+		if err := writeAtomic(path, append(data, '\n')); err != nil {
+			return err
+		}
+		clientLogger(name).Info("wrote fixture", "path", path, "tools", len(fixture.Tools), "calls", len(fixture.Calls))
+		servers[name] = map[string]any{
+			"transportType": MCPClientTypeStdio,
+			"command":       self,
+			"args":          []string{"-serve-fixture", path},
+		}
+	}
+	proxy := map[string]any{"name": "mcp-proxy-fixtures", "addr": ":9090", "baseURL": "http://localhost:9090"}
+	if config.McpProxy != nil {
+		proxy["name"], proxy["addr"], proxy["baseURL"] = config.McpProxy.Name, config.McpProxy.Addr, config.McpProxy.BaseURL
+	}
+	data, err := json.MarshalIndent(map[string]any{"mcpProxy": proxy, "mcpServers": servers}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeAtomic(filepath.Join(abs, "config.json"), append(data, '\n')); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("could not capture %d server(s): %v", len(failed), failed)
+	}
+	return nil
+}
+
+// captureServerFixture records the initialize result, catalog, and sample
+// calls of server name.
+func captureServerFixture(ctx context.Context, config *Config, name string) (*serverFixture, error) {
+	c, err := newMCPClient(name, config.McpServers[name], nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Close() }()
+	listCtx, cancel := context.WithTimeout(ctx, fixtureCaptureTimeout)
+	defer cancel()
+	if err := c.client.Start(listCtx); err != nil {
+		return nil, err
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "mcp-proxy-fixtures", Version: BuildVersion}
+	if config.McpProxy != nil && config.McpProxy.Name != "" {
+		initRequest.Params.ClientInfo.Name = config.McpProxy.Name
+	}
+	result, err := c.client.Initialize(listCtx, initRequest)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &serverFixture{Server: name, CapturedAt: time.Now().UTC(), Initialize: result, Tools: []mcp.Tool{}}
+
+	toolsRequest := mcp.ListToolsRequest{}
+	for {
+		page, err := c.client.ListTools(listCtx, toolsRequest)
+		if err != nil {
+			return nil, err
+		}
+		fixture.Tools = append(fixture.Tools, page.Tools...)
+		if page.NextCursor == "" {
+			break
+		}
+		toolsRequest.Params.Cursor = page.NextCursor
+	}
+	if result.Capabilities.Prompts != nil {
+		promptsRequest := mcp.ListPromptsRequest{}
+		for {
+			page, err := c.client.ListPrompts(listCtx, promptsRequest)
+			if err != nil {
+				return nil, err
+			}
+			fixture.Prompts = append(fixture.Prompts, page.Prompts...)
+			if page.NextCursor == "" {
+				break
+			}
+			promptsRequest.Params.Cursor = page.NextCursor
+		}
+	}
+	if result.Capabilities.Resources != nil {
+		resourcesRequest := mcp.ListResourcesRequest{}
+		for {
+			page, err := c.client.ListResources(listCtx, resourcesRequest)
+			if err != nil {
+				return nil, err
+			}
+			fixture.Resources = append(fixture.Resources, page.Resources...)
+			if page.NextCursor == "" {
+				break
+			}
+			resourcesRequest.Params.Cursor = page.NextCursor
+		}
+		templatesRequest := mcp.ListResourceTemplatesRequest{}
+		for {
+			page, err := c.client.ListResourceTemplates(listCtx, templatesRequest)
+			if err != nil {
+				return nil, err
+			}
+			fixture.ResourceTemplates = append(fixture.ResourceTemplates, page.ResourceTemplates...)
+			if page.NextCursor == "" {
+				break
+			}
+			templatesRequest.Params.Cursor = page.NextCursor
+		}
+	}
+
+	keys := newRedactKeySet(nil)
+	for _, probe := range config.McpProxy.probesFor(name) {
+		timeout := probe.Timeout
+		if timeout <= 0 {
+			timeout = defaultProbeTimeout
+		}
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		request := mcp.CallToolRequest{}
+		request.Params.Name = probe.Tool
+		request.Params.Arguments = probe.Arguments
+		res, err := c.client.CallTool(callCtx, request)
+		cancel()
+		call := fixtureCall{Tool: probe.Tool}
+		if args, ok := redactValue(map[string]any(probe.Arguments), keys).(map[string]any); ok && len(args) > 0 {
+			call.Arguments = args
+		}
+		if err != nil {
+			call.Error = err.Error()
+		} else if call.Result, err = redactedJSON(res, keys); err != nil {
+			return nil, err
+		}
+		fixture.Calls = append(fixture.Calls, call)
+	}
+	return fixture, nil
+}
+
+// probesFor lists the probes of server.
+func (c *MCPProxyConfigV2) probesFor(server string) []*ProbeConfig {
+	if c == nil {
+		return nil
+	}
+	var out []*ProbeConfig
+	for _, probe := range c.Probes {
+		if probe != nil && probe.Server == server && probe.Tool != "" {
+			out = append(out, probe)
+		}
+	}
+	return out
+}
+
+func loadServerFixture(path string) (*serverFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture serverFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if fixture.Initialize == nil {
+		return nil, fmt.Errorf("%s: no initialize result", path)
+	}
+	return &fixture, nil
+}
+
+// newFixtureServer builds an MCP server that answers like the captured one.
+// Tool calls get the recorded response whose arguments match, else the
+// tool's first recorded response; prompts and resources are listed but
+// cannot be fetched.
+func newFixtureServer(fixture *serverFixture) *server.MCPServer {
+	initResult := fixture.Initialize
+	opts := []server.ServerOption{server.WithToolCapabilities(false)}
+	if initResult.Instructions != "" {
+		opts = append(opts, server.WithInstructions(initResult.Instructions))
+	}
+	if len(fixture.Prompts) > 0 {
+		opts = append(opts, server.WithPromptCapabilities(false))
+	}
+	if len(fixture.Resources) > 0 || len(fixture.ResourceTemplates) > 0 {
+		opts = append(opts, server.WithResourceCapabilities(false, false))
+	}
+	srv := server.NewMCPServer(initResult.ServerInfo.Name, initResult.ServerInfo.Version, opts...)
+	for _, tool := range fixture.Tools {
+		srv.AddTool(tool, fixture.callTool)
+	}
+	for _, prompt := range fixture.Prompts {
+		srv.AddPrompt(prompt, func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return nil, fmt.Errorf("prompt %s: not captured in the fixture", request.Params.Name)
+		})
+	}
+	notCaptured := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return nil, fmt.Errorf("resource %s: not captured in the fixture", request.Params.URI)
+	}
+	for _, resource := range fixture.Resources {
+		srv.AddResource(resource, notCaptured)
+	}
+	for _, template := range fixture.ResourceTemplates {
+		srv.AddResourceTemplate(template, notCaptured)
+	}
+	return srv
+}
+
+func (f *serverFixture) callTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.Params.Name
+	args, _ := request.Params.Arguments.(map[string]any)
+	var recorded *fixtureCall
+	for i := range f.Calls {
+		call := &f.Calls[i]
+		if call.Tool != name {
+			continue
+		}
+		if recorded == nil {
+			recorded = call
+		}
+		if (len(args) == 0 && len(call.Arguments) == 0) || reflect.DeepEqual(args, call.Arguments) {
+			recorded = call
+			break
+		}
+	}
+	if recorded == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no response recorded for tool %s", name)), nil
+	}
+	if recorded.Error != "" {
+		return nil, errors.New(recorded.Error)
+	}
+	var result mcp.CallToolResult
+	if err := json.Unmarshal(recorded.Result, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// serveFixture plays back the fixture at path as a stdio MCP server.
+func serveFixture(ctx context.Context, path string, in io.Reader, out io.Writer) error {
+	fixture, err := loadServerFixture(path)
+	if err != nil {
+		return err
+	}
+	clientLogger(fixture.Server).Info("serving fixture", "path", path, "capturedAt", fixture.CapturedAt.Format(time.RFC3339))
+	return server.NewStdioServer(newFixtureServer(fixture)).Listen(ctx, in, out)
+}
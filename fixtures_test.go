@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestCaptureAndServeFixtures(t *testing.T) {
+	downstream := server.NewMCPServer("fs", "1.2.3", server.WithInstructions("read files"))
+	downstream.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultStructured(map[string]any{"text": req.GetString("text", ""), "token": "s3cret"}, req.GetString("text", "")), nil
+	})
+	downstream.AddTool(mcp.NewTool("stat"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	ts := httptest.NewServer(server.NewStreamableHTTPServer(downstream))
+	defer ts.Close()
+
+	dir := filepath.Join(t.TempDir(), "fixtures")
+	config := &Config{
+		McpProxy: &MCPProxyConfigV2{Name: "proxy", Probes: []*ProbeConfig{
+			{Server: "fs", Tool: "echo", Arguments: map[string]any{"text": "hi"}},
+			{Server: "other", Tool: "echo"},
+		}},
+		McpServers: map[string]*MCPClientConfigV2{
+			"fs": {TransportType: MCPClientTypeStreamable, URL: ts.URL + "/mcp", Options: &OptionsV2{}},
+		},
+	}
+	if err := captureFixtures(context.Background(), config, dir); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "fs.json")
+	fixture, err := loadServerFixture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixture.Initialize.ServerInfo.Version != "1.2.3" || len(fixture.Tools) != 2 || len(fixture.Calls) != 1 {
+		t.Fatalf("fixture = %+v", fixture)
+	}
+	if strings.Contains(string(fixture.Calls[0].Result), "s3cret") {
+		t.Fatalf("sample response not redacted: %s", fixture.Calls[0].Result)
+	}
+	var generated struct {
+		McpServers map[string]struct {
+			Args []string `json:"args"`
+		} `json:"mcpServers"`
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err := json.Unmarshal(data, &generated); err != nil || strings.Join(generated.McpServers["fs"].Args, " ") != "-serve-fixture "+path {
+		t.Fatalf("config.json = %s", data)
+	}
+
+	played, err := client.NewInProcessClient(newFixtureServer(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer played.Close()
+	if err := played.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initResult, err := played.Initialize(context.Background(), initRequest)
+	if err != nil || initResult.ServerInfo.Name != "fs" || initResult.Instructions != "read files" {
+		t.Fatalf("initialize = %+v, %v", initResult, err)
+	}
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name, request.Params.Arguments = name, args
+		res, err := played.CallTool(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	for _, args := range []map[string]any{{"text": "hi"}, {"text": "other"}} {
+		res := call("echo", args)
+		if text, ok := res.Content[0].(mcp.TextContent); !ok || text.Text != "hi" || res.IsError {
+			t.Fatalf("echo(%v) = %+v", args, res)
+		}
+	}
+	if res := call("stat", nil); !res.IsError {
+		t.Fatalf("tool without a recorded response = %+v", res)
+	}
+}
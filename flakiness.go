@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultFlakinessMaxKeys = 1000
+
+// FlakinessConfig enables result hashing for repeated identical read-only calls.
+type FlakinessConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	MaxKeys int  `json:"maxKeys,omitempty"`
+}
+
+type flakinessEntry struct {
+	resultHash string
+	lastSeen   time.Time
+}
+
+type flakinessStats struct {
+	Server         string    `json:"server"`
+	Tool           string    `json:"tool"`
+	Repeats        int       `json:"repeats"`
+	Mismatches     int       `json:"mismatches"`
+	Score          float64   `json:"score"`
+	LastMismatchAt time.Time `json:"lastMismatchAt,omitempty"`
+}
+
+// flakinessDetector remembers the last result hash per (server, tool, args)
+// and counts how often a repeated read-only call returns something different.
+type flakinessDetector struct {
+	mu      sync.Mutex
+	maxKeys int
+	entries map[string]*flakinessEntry
+	stats   map[callKey]*flakinessStats
+}
+
+func newFlakinessDetector(conf *FlakinessConfig) *flakinessDetector {
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+	maxKeys := conf.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultFlakinessMaxKeys
+	}
+	return &flakinessDetector{
+		maxKeys: maxKeys,
+		entries: make(map[string]*flakinessEntry),
+		stats:   make(map[callKey]*flakinessStats),
+	}
+}
+
+// canonicalHash hashes a JSON value after a decode/encode round trip so key
+// order and whitespace do not matter.
+func canonicalHash(raw []byte) string {
+	var v any
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &v); err != nil {
+			v = string(raw)
+		}
+	}
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (d *flakinessDetector) observe(server, tool string, args json.RawMessage, digest responseDigest, now time.Time) {
+	if d == nil {
+		return
+	}
+	callHash := server + "|" + tool + "|" + canonicalHash(args)
+	hash := digest.ResultHash
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := callKey{Server: server, Method: "tools/call", Target: tool}
+	stats := d.stats[key]
+	if stats == nil {
+		stats = &flakinessStats{Server: server, Tool: tool}
+		d.stats[key] = stats
+	}
+	entry, seen := d.entries[callHash]
+	if !seen {
+		d.evictLocked()
+		d.entries[callHash] = &flakinessEntry{resultHash: hash, lastSeen: now}
+		return
+	}
+	stats.Repeats++
+	if entry.resultHash != hash {
+		stats.Mismatches++
+		stats.LastMismatchAt = now.UTC()
+	}
+	stats.Score = float64(stats.Mismatches) / float64(stats.Repeats)
+	entry.resultHash = hash
+	entry.lastSeen = now
+}
+
+func (d *flakinessDetector) evictLocked() {
+	for len(d.entries) >= d.maxKeys {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range d.entries {
+			if oldestKey == "" || e.lastSeen.Before(oldest) {
+				oldestKey, oldest = k, e.lastSeen
+			}
+		}
+		delete(d.entries, oldestKey)
+	}
+}
+
+// report lists tools with repeated calls, flakiest first.
+func (d *flakinessDetector) report() []flakinessStats {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	out := make([]flakinessStats, 0, len(d.stats))
+	for _, s := range d.stats {
+		if s.Repeats > 0 {
+			out = append(out, *s)
+		}
+	}
+	d.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].Tool < out[j].Tool
+	})
+	return out
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFlakinessDetectorScoresChangingResults(t *testing.T) {
+	d := newFlakinessDetector(&FlakinessConfig{Enabled: true})
+	now := time.Now()
+	stable := digestBytes([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"a"}],"_meta":{"took":1}}}`))
+	stableAgain := digestBytes([]byte(`{"jsonrpc":"2.0","id":2,"result":{"_meta":{"took":9},"content":[{"text":"a","type":"text"}]}}`))
+	changed := digestBytes([]byte(`{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"b"}]}}`))
+
+	d.observe("srv", "read", json.RawMessage(`{"x":1,"y":2}`), stable, now)
+	d.observe("srv", "read", json.RawMessage(`{"y":2, "x":1}`), stableAgain, now)
+	d.observe("srv", "read", json.RawMessage(`{"x":1,"y":2}`), changed, now)
+	d.observe("srv", "read", json.RawMessage(`{"x":2}`), changed, now)
+
+	report := d.report()
+	if len(report) != 1 {
+		t.Fatalf("expected one tool, got %+v", report)
+	}
+	got := report[0]
+	if got.Repeats != 2 || got.Mismatches != 1 || got.Score != 0.5 {
+		t.Fatalf("unexpected stats %+v", got)
+	}
+	if got.LastMismatchAt.IsZero() {
+		t.Fatal("expected lastMismatchAt to be set")
+	}
+}
+
+func TestFlakinessDetectorEvictsOldestKey(t *testing.T) {
+	d := newFlakinessDetector(&FlakinessConfig{Enabled: true, MaxKeys: 2})
+	base := time.Now()
+	body := digestBytes([]byte(`{"result":{}}`))
+	d.observe("srv", "t", json.RawMessage(`{"k":1}`), body, base)
+	d.observe("srv", "t", json.RawMessage(`{"k":2}`), body, base.Add(time.Second))
+	d.observe("srv", "t", json.RawMessage(`{"k":3}`), body, base.Add(2*time.Second))
+	if len(d.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(d.entries))
+	}
+	d.observe("srv", "t", json.RawMessage(`{"k":1}`), body, base.Add(3*time.Second))
+	if r := d.report(); len(r) != 0 {
+		t.Fatalf("evicted key should not count as a repeat: %+v", r)
+	}
+}
+
+func TestNewFlakinessDetectorDisabled(t *testing.T) {
+	if newFlakinessDetector(nil) != nil || newFlakinessDetector(&FlakinessConfig{}) != nil {
+		t.Fatal("expected nil detector when disabled")
+	}
+}
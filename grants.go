@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const toolGrantMaxTTL = 24 * time.Hour
+
+// toolGrant temporarily enables one downstream tool for a single caller
+// token or facade session, on top of the active overrides.
+type toolGrant struct {
+	ID        string    `json:"id"`
+	Server    string    `json:"server"`
+	Tool      string    `json:"tool"`
+	Caller    string    `json:"caller,omitempty"`
+	SessionID string    `json:"sessionId,omitempty"`
+	By        string    `json:"by"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (g *toolGrant) covers(info callerInfo, now time.Time) bool {
+	if !now.Before(g.ExpiresAt) {
+		return false
+	}
+	if g.Caller != "" && g.Caller != info.Identity {
+		return false
+	}
+	if g.SessionID != "" && g.SessionID != info.SessionID {
+		return false
+	}
+	return true
+}
+
+// toolGrantStore keeps the active grants in memory; each one reverts on its
+// own timer. Grants do not survive a restart.
+type toolGrantStore struct {
+	mu     sync.Mutex
+	grants map[string]*toolGrant
+	timers map[string]*time.Timer
+	// ended is called outside the lock when a grant expires or is revoked;
+	// actor is empty for expiry.
+	ended func(grant toolGrant, reason, actor string)
+}
+
+func newToolGrantStore(ended func(grant toolGrant, reason, actor string)) *toolGrantStore {
+	return &toolGrantStore{
+		grants: make(map[string]*toolGrant),
+		timers: make(map[string]*time.Timer),
+		ended:  ended,
+	}
+}
+
+// add validates and activates a grant. Exactly one of Caller or SessionID
+// scopes it.
+func (s *toolGrantStore) add(grant toolGrant, ttl time.Duration) (toolGrant, error) {
+	if grant.Server == "" || grant.Tool == "" {
+		return toolGrant{}, errors.New("server and tool are required")
+	}
+	if (grant.Caller == "") == (grant.SessionID == "") {
+		return toolGrant{}, errors.New("exactly one of token or session is required")
+	}
+	if ttl <= 0 || ttl > toolGrantMaxTTL {
+		return toolGrant{}, fmt.Errorf("ttl must be positive and at most %s", toolGrantMaxTTL)
+	}
+	grant.ID = uuid.New().String()
+	grant.CreatedAt = time.Now().UTC()
+	grant.ExpiresAt = grant.CreatedAt.Add(ttl)
+	stored := grant
+	s.mu.Lock()
+	s.grants[grant.ID] = &stored
+	s.timers[grant.ID] = time.AfterFunc(ttl, func() { s.end(grant.ID, "expired", "") })
+	s.mu.Unlock()
+	return grant, nil
+}
+
+// revoke ends a grant early on behalf of actor.
+func (s *toolGrantStore) revoke(id, actor string) (toolGrant, bool) {
+	return s.end(id, "revoked", actor)
+}
+
+func (s *toolGrantStore) end(id, reason, actor string) (toolGrant, bool) {
+	s.mu.Lock()
+	grant, ok := s.grants[id]
+	if ok {
+		delete(s.grants, id)
+		if timer := s.timers[id]; timer != nil {
+			timer.Stop()
+		}
+		delete(s.timers, id)
+	}
+	s.mu.Unlock()
+	if !ok {
+		return toolGrant{}, false
+	}
+	if s.ended != nil {
+		s.ended(*grant, reason, actor)
+	}
+	return *grant, true
+}
+
+// list returns the active grants, soonest expiry first.
+func (s *toolGrantStore) list() []toolGrant {
+	s.mu.Lock()
+	out := make([]toolGrant, 0, len(s.grants))
+	for _, grant := range s.grants {
+		out = append(out, *grant)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ExpiresAt.Equal(out[j].ExpiresAt) {
+			return out[i].ExpiresAt.Before(out[j].ExpiresAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// overrides layers the grants covering info on top of set. Without a
+// matching grant set itself is returned.
+func (s *toolGrantStore) overrides(set *ToolOverrideSet, info callerInfo) *ToolOverrideSet {
+	if s == nil {
+		return set
+	}
+	now := time.Now()
+	s.mu.Lock()
+	var keys []toolToggleKey
+	for _, grant := range s.grants {
+		if grant.covers(info, now) {
+			keys = append(keys, toolToggleKey{Server: grant.Server, Tool: grant.Tool})
+		}
+	}
+	s.mu.Unlock()
+	if len(keys) == 0 {
+		return set
+	}
+	granted := cloneOverrideSet(set)
+	for _, key := range keys {
+		granted = applyToolToggle(granted, key, true)
+	}
+	return granted
+}
+
+// grantAuditEntry records a grant lifecycle step in the audit log. The
+// stelae/ method prefix keeps these entries out of replay.
+func grantAuditEntry(grant toolGrant, action, actor string) auditEntry {
+	params, _ := json.Marshal(grant)
+	return auditEntry{
+		At:        time.Now(),
+		Method:    "stelae/grant." + action,
+		Server:    grant.Server,
+		Target:    grant.Tool,
+		Caller:    actor,
+		SessionID: grant.SessionID,
+		Params:    params,
+	}
+}
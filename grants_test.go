@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToolGrantStoreScopesOverrides(t *testing.T) {
+	off := false
+	base := &ToolOverrideSet{
+		Servers: map[string]*toolOverrideFragment{
+			"db":     {Tools: map[string]*ToolOverrideConfig{"drop": {Enabled: &off}}},
+			"backup": {Tools: map[string]*ToolOverrideConfig{"drop": {Enabled: &off}}},
+		},
+	}
+	store := newToolGrantStore(nil)
+	if _, err := store.add(toolGrant{Server: "db", Tool: "drop"}, time.Minute); err == nil {
+		t.Fatalf("expected a grant without token or session to be rejected")
+	}
+	if _, err := store.add(toolGrant{Server: "db", Tool: "drop", Caller: "token:abc"}, 48*time.Hour); err == nil {
+		t.Fatalf("expected ttl above the maximum to be rejected")
+	}
+	grant, err := store.add(toolGrant{Server: "db", Tool: "drop", Caller: "token:abc"}, time.Minute)
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	granted := store.overrides(base, callerInfo{Identity: "token:abc"})
+	if !toolEnabled(granted, "db", "drop") {
+		t.Fatalf("expected granted caller to see the tool enabled")
+	}
+	if toolEnabled(granted, "backup", "drop") {
+		t.Fatalf("a grant of db/drop must not enable backup/drop")
+	}
+	if toolEnabled(base, "db", "drop") {
+		t.Fatalf("grant must not mutate the shared override set")
+	}
+	if other := store.overrides(base, callerInfo{Identity: "token:other"}); other != base {
+		t.Fatalf("expected other callers to get the base set unchanged")
+	}
+	if got := store.list(); len(got) != 1 || got[0].ID != grant.ID {
+		t.Fatalf("unexpected grants %+v", got)
+	}
+}
+
+func TestToolGrantStoreEndsGrants(t *testing.T) {
+	type ending struct{ id, reason, actor string }
+	ended := make(chan ending, 2)
+	store := newToolGrantStore(func(grant toolGrant, reason, actor string) {
+		ended <- ending{grant.ID, reason, actor}
+	})
+
+	revoked, _ := store.add(toolGrant{Server: "db", Tool: "drop", SessionID: "s1"}, time.Hour)
+	if _, ok := store.revoke(revoked.ID, "admin:ops"); !ok {
+		t.Fatalf("expected revoke to find the grant")
+	}
+	if got := <-ended; got != (ending{revoked.ID, "revoked", "admin:ops"}) {
+		t.Fatalf("unexpected revoke callback %+v", got)
+	}
+	if _, ok := store.revoke(revoked.ID, "admin:ops"); ok {
+		t.Fatalf("expected second revoke to miss")
+	}
+
+	expiring, _ := store.add(toolGrant{Server: "db", Tool: "drop", SessionID: "s1"}, 20*time.Millisecond)
+	select {
+	case got := <-ended:
+		if got != (ending{expiring.ID, "expired", ""}) {
+			t.Fatalf("unexpected expiry callback %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("grant did not expire")
+	}
+	if len(store.list()) != 0 {
+		t.Fatalf("expected no active grants after expiry")
+	}
+}
+
+func TestGrantAuditEntriesAreNotReplayable(t *testing.T) {
+	entry := grantAuditEntry(toolGrant{ID: "g1", Server: "db", Tool: "drop"}, "create", "admin:ops")
+	if entry.Method != "stelae/grant.create" || entry.Target != "drop" {
+		t.Fatalf("unexpected audit entry %+v", entry)
+	}
+	if _, err := replayRequestBody(&entry); err == nil {
+		t.Fatalf("expected grant audit entries to be rejected for replay")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// The read-only admin query API. graphql-go validates and executes queries
+// against graphqlSchemaSDL, including introspection, so standard GraphQL
+// clients and IDEs can explore it.
+
+const (
+	graphqlMaxQueryBytes = 64 << 10
+	graphqlMaxDepth      = 12
+)
+
+// graphqlSource is what the GraphQL API reads. Like adminToolOps, the
+// fields are closures over the running proxy.
+type graphqlSource struct {
+	Servers  func() []map[string]any
+	Tools    func() []graphqlTool
+	Health   func() map[string]any
+	Adapters func() statusMap
+	Metrics  *callMetrics
+	ReadOnly *readOnlyMode
+}
+
+// graphqlTool is one downstream tool with the tool overrides applied.
+type graphqlTool struct {
+	Server         string
+	OriginalName   string
+	Descriptor     map[string]any
+	Enabled        bool
+	DisabledSource string
+	DisabledBy     string
+	DisabledAt     *time.Time
+	DisabledReason string
+}
+
+// graphqlCatalogTools lists every tool of every connected server, sorted by
+// server and tool, using the same disabled attribution as the admin catalog.
+func graphqlCatalogTools(servers map[string]*Server, store *toolOverrideStore) []graphqlTool {
+	current := store.current()
+	var out []graphqlTool
+	for serverName, srv := range servers {
+		serverOn := serverEnabled(current, serverName)
+		for _, tool := range srv.tools {
+			row := graphqlTool{
+				Server:       serverName,
+				OriginalName: tool.Name,
+				Descriptor:   applyToolOverride(tool.Name, toolDescriptorFromServer(tool), current),
+				Enabled:      serverOn && toolEnabled(current, serverName, tool.Name),
+			}
+			if !row.Enabled {
+				switch toggle, ok := store.toggle(serverName, tool.Name); {
+				case !serverOn:
+					row.DisabledSource = "server"
+				case ok && !toggle.Enabled:
+					at := toggle.At
+					row.DisabledSource, row.DisabledBy, row.DisabledAt, row.DisabledReason = "runtime", toggle.By, &at, toggle.Reason
+				default:
+					row.DisabledSource = "overrides"
+				}
+			}
+			out = append(out, row)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].OriginalName < out[j].OriginalName
+	})
+	return out
+}
+
+// newGraphQLSchema parses the admin schema with resolvers over src.
+func newGraphQLSchema(src graphqlSource) (*graphql.Schema, error) {
+	return graphql.ParseSchema(graphqlSchemaSDL, &graphqlResolver{src: src},
+		graphql.MaxDepth(graphqlMaxDepth),
+		graphql.MaxQueryLength(graphqlMaxQueryBytes),
+	)
+}
+
+func graphqlErrorResponse(message string) *graphql.Response {
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{{Message: message}}}
+}
+
+// registerGraphQLRoutes mounts the query endpoint: POST a standard
+// {query, operationName, variables} body, or GET with those as query
+// parameters.
+func registerGraphQLRoutes(api *adminAPI, src graphqlSource) error {
+	schema, err := newGraphQLSchema(src)
+	if err != nil {
+		return err
+	}
+	serve := func(ctx context.Context, w http.ResponseWriter, query, operationName string, variables map[string]any) {
+		if strings.TrimSpace(query) == "" {
+			api.writeJSON(w, http.StatusBadRequest, graphqlErrorResponse("query is required"))
+			return
+		}
+		resp := schema.Exec(ctx, query, operationName, variables)
+		status := http.StatusOK
+		if resp.Data == nil {
+			status = http.StatusBadRequest
+		}
+		api.writeJSON(w, status, resp)
+	}
+	api.handle(http.MethodPost, "graphql", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query         string         `json:"query"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(io.LimitReader(r.Body, graphqlMaxQueryBytes*2)).Decode(&body); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, graphqlErrorResponse("invalid JSON body: "+err.Error()))
+			return
+		}
+		serve(r.Context(), w, body.Query, body.OperationName, body.Variables)
+	})
+	api.handle(http.MethodGet, "graphql", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var variables map[string]any
+		if raw := q.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &variables); err != nil {
+				api.writeJSON(w, http.StatusBadRequest, graphqlErrorResponse("invalid variables: "+err.Error()))
+				return
+			}
+		}
+		serve(r.Context(), w, q.Get("query"), q.Get("operationName"), variables)
+	})
+	api.handle(http.MethodGet, "graphql/schema", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, graphqlSchemaSDL)
+	})
+	return nil
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// graphqlSchemaSDL is the admin GraphQL schema, served at
+// GET /admin/graphql/schema. graphqlResolver and the types below resolve it.
+const graphqlSchemaSDL = `scalar JSON
+
+type Query {
+  servers(name: String): [Server!]!
+  server(name: String!): Server
+  tools(server: String, name: String, enabled: Boolean): [Tool!]!
+  usage(server: String, method: String, target: String): [Usage!]!
+  adapters(server: String): [AdapterStatus!]!
+  health: Health!
+  readOnly: ReadOnly!
+}
+
+type Server {
+  name: String!
+  connected: Boolean!
+  enabled: Boolean!
+  transport: String
+  dependsOn: [String!]
+  toolCount: Int!
+  promptCount: Int!
+  resourceCount: Int!
+  tools(enabled: Boolean): [Tool!]!
+  usage: [Usage!]!
+}
+
+type Tool {
+  name: String!
+  originalName: String!
+  server: String!
+  enabled: Boolean!
+  disabledSource: String
+  disabledBy: String
+  disabledAt: String
+  disabledReason: String
+  description: String
+  annotations: JSON
+  inputSchema: JSON
+  outputSchema: JSON
+  adapter: AdapterStatus
+  usage: Usage
+}
+
+type Usage {
+  server: String!
+  method: String!
+  target: String!
+  totalCalls: Int!
+  totalErrors: Int!
+  calls: Int!
+  errors: Int!
+  p50Ms: Int!
+  p95Ms: Int!
+  avgRequestBytes: Int!
+  avgResponseBytes: Int!
+}
+
+type AdapterStatus {
+  server: String!
+  tool: String!
+  lastAdapter: String!
+  consecutiveGeneric: Int!
+  updatedAt: String
+}
+
+type Health {
+  ready: Boolean!
+  serversConfigured: Int!
+  serversConnected: Int!
+  serversMissing: [String!]
+  activeCalls: Int!
+  readyAt: String
+}
+
+type ReadOnly {
+  enabled: Boolean!
+  by: String
+  at: String
+  reason: String
+}
+`
+
+// graphqlResolver resolves Query.
+type graphqlResolver struct {
+	src graphqlSource
+}
+
+func (r *graphqlResolver) usage(match func(callKey) bool) []*graphqlUsageResolver {
+	rows := r.src.Metrics.usageReport(time.Now().Add(-r.src.Metrics.window), match)
+	out := make([]*graphqlUsageResolver, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, &graphqlUsageResolver{row})
+	}
+	return out
+}
+
+func (r *graphqlResolver) Servers(args struct{ Name *string }) []*graphqlServerResolver {
+	out := []*graphqlServerResolver{}
+	for _, entry := range r.src.Servers() {
+		if args.Name == nil || entry["name"] == *args.Name {
+			out = append(out, &graphqlServerResolver{root: r, entry: entry})
+		}
+	}
+	return out
+}
+
+func (r *graphqlResolver) Server(args struct{ Name string }) *graphqlServerResolver {
+	for _, entry := range r.src.Servers() {
+		if entry["name"] == args.Name {
+			return &graphqlServerResolver{root: r, entry: entry}
+		}
+	}
+	return nil
+}
+
+func (r *graphqlResolver) Tools(args struct {
+	Server  *string
+	Name    *string
+	Enabled *bool
+}) []*graphqlToolResolver {
+	out := []*graphqlToolResolver{}
+	for _, tool := range r.src.Tools() {
+		if (args.Server != nil && tool.Server != *args.Server) || (args.Enabled != nil && tool.Enabled != *args.Enabled) {
+			continue
+		}
+		if args.Name != nil && tool.OriginalName != *args.Name && tool.Descriptor["name"] != *args.Name {
+			continue
+		}
+		out = append(out, &graphqlToolResolver{root: r, tool: tool})
+	}
+	return out
+}
+
+func (r *graphqlResolver) Usage(args struct{ Server, Method, Target *string }) []*graphqlUsageResolver {
+	return r.usage(func(key callKey) bool {
+		return (args.Server == nil || key.Server == *args.Server) &&
+			(args.Method == nil || key.Method == *args.Method) &&
+			(args.Target == nil || key.Target == *args.Target)
+	})
+}
+
+func (r *graphqlResolver) Adapters(args struct{ Server *string }) []*graphqlAdapterResolver {
+	status := r.src.Adapters()
+	servers := make([]string, 0, len(status))
+	for name := range status {
+		if args.Server == nil || name == *args.Server {
+			servers = append(servers, name)
+		}
+	}
+	sort.Strings(servers)
+	out := []*graphqlAdapterResolver{}
+	for _, name := range servers {
+		tools := make([]string, 0, len(status[name]))
+		for tool := range status[name] {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		for _, tool := range tools {
+			if entry := status[name][tool]; entry != nil {
+				out = append(out, &graphqlAdapterResolver{server: name, tool: tool, entry: entry})
+			}
+		}
+	}
+	return out
+}
+
+func (r *graphqlResolver) Health() *graphqlHealthResolver {
+	return &graphqlHealthResolver{r.src.Health()}
+}
+
+func (r *graphqlResolver) ReadOnly() *graphqlReadOnlyResolver {
+	return &graphqlReadOnlyResolver{r.src.ReadOnly.snapshot()}
+}
+
+// graphqlServerResolver resolves Server from an adminToolOps.ListServers
+// entry.
+type graphqlServerResolver struct {
+	root  *graphqlResolver
+	entry map[string]any
+}
+
+func (s *graphqlServerResolver) name() string {
+	name, _ := s.entry["name"].(string)
+	return name
+}
+
+func (s *graphqlServerResolver) count(key string) int32 {
+	n, _ := s.entry[key].(int)
+	return graphqlInt(int64(n))
+}
+
+func (s *graphqlServerResolver) Name() string { return s.name() }
+
+func (s *graphqlServerResolver) Connected() bool {
+	connected, _ := s.entry["connected"].(bool)
+	return connected
+}
+
+func (s *graphqlServerResolver) Enabled() bool {
+	enabled, _ := s.entry["enabled"].(bool)
+	return enabled
+}
+
+func (s *graphqlServerResolver) Transport() *string {
+	transport, _ := s.entry["transport"].(string)
+	return graphqlString(transport)
+}
+
+func (s *graphqlServerResolver) DependsOn() *[]string {
+	deps, ok := s.entry["dependsOn"].([]string)
+	if !ok {
+		return nil
+	}
+	return &deps
+}
+
+func (s *graphqlServerResolver) ToolCount() int32     { return s.count("tools") }
+func (s *graphqlServerResolver) PromptCount() int32   { return s.count("prompts") }
+func (s *graphqlServerResolver) ResourceCount() int32 { return s.count("resources") }
+
+func (s *graphqlServerResolver) Tools(args struct{ Enabled *bool }) []*graphqlToolResolver {
+	name := s.name()
+	return s.root.Tools(struct {
+		Server  *string
+		Name    *string
+		Enabled *bool
+	}{Server: &name, Enabled: args.Enabled})
+}
+
+func (s *graphqlServerResolver) Usage() []*graphqlUsageResolver {
+	name := s.name()
+	return s.root.usage(func(key callKey) bool { return key.Server == name })
+}
+
+type graphqlToolResolver struct {
+	root *graphqlResolver
+	tool graphqlTool
+}
+
+// descriptor returns a descriptor entry as plain JSON; descriptors hold
+// typed schema values.
+func (t *graphqlToolResolver) descriptor(key string) (*graphqlJSON, error) {
+	v, ok := t.tool.Descriptor[key]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := &graphqlJSON{}
+	if err := json.Unmarshal(data, &out.value); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (t *graphqlToolResolver) Name() string {
+	if name, _ := t.tool.Descriptor["name"].(string); name != "" {
+		return name
+	}
+	return t.tool.OriginalName
+}
+
+func (t *graphqlToolResolver) OriginalName() string    { return t.tool.OriginalName }
+func (t *graphqlToolResolver) Server() string          { return t.tool.Server }
+func (t *graphqlToolResolver) Enabled() bool           { return t.tool.Enabled }
+func (t *graphqlToolResolver) DisabledSource() *string { return graphqlString(t.tool.DisabledSource) }
+func (t *graphqlToolResolver) DisabledBy() *string     { return graphqlString(t.tool.DisabledBy) }
+func (t *graphqlToolResolver) DisabledReason() *string { return graphqlString(t.tool.DisabledReason) }
+func (t *graphqlToolResolver) DisabledAt() *string     { return graphqlTime(t.tool.DisabledAt) }
+
+func (t *graphqlToolResolver) Description() *string {
+	description, _ := t.tool.Descriptor["description"].(string)
+	return graphqlString(description)
+}
+
+func (t *graphqlToolResolver) Annotations() (*graphqlJSON, error) { return t.descriptor("annotations") }
+func (t *graphqlToolResolver) InputSchema() (*graphqlJSON, error) { return t.descriptor("inputSchema") }
+func (t *graphqlToolResolver) OutputSchema() (*graphqlJSON, error) {
+	return t.descriptor("outputSchema")
+}
+
+func (t *graphqlToolResolver) Adapter() *graphqlAdapterResolver {
+	if entry := t.root.src.Adapters()[t.tool.Server][t.tool.OriginalName]; entry != nil {
+		return &graphqlAdapterResolver{server: t.tool.Server, tool: t.tool.OriginalName, entry: entry}
+	}
+	return nil
+}
+
+func (t *graphqlToolResolver) Usage() *graphqlUsageResolver {
+	rows := t.root.usage(func(key callKey) bool {
+		return key.Server == t.tool.Server && key.Method == "tools/call" && key.Target == t.tool.OriginalName
+	})
+	if len(rows) == 0 {
+		return nil
+	}
+	return rows[0]
+}
+
+type graphqlUsageResolver struct {
+	u callUsage
+}
+
+func (u *graphqlUsageResolver) Server() string { return u.u.Server }
+func (u *graphqlUsageResolver) Method() string { return u.u.Method }
+func (u *graphqlUsageResolver) Target() string { return u.u.Target }
+func (u *graphqlUsageResolver) TotalCalls() int32 {
+	return graphqlInt(int64(min(u.u.TotalCalls, math.MaxInt32)))
+}
+func (u *graphqlUsageResolver) TotalErrors() int32 {
+	return graphqlInt(int64(min(u.u.TotalErrors, math.MaxInt32)))
+}
+func (u *graphqlUsageResolver) Calls() int32           { return graphqlInt(int64(u.u.Calls)) }
+func (u *graphqlUsageResolver) Errors() int32          { return graphqlInt(int64(u.u.Errors)) }
+func (u *graphqlUsageResolver) P50Ms() int32           { return graphqlInt(u.u.P50Ms) }
+func (u *graphqlUsageResolver) P95Ms() int32           { return graphqlInt(u.u.P95Ms) }
+func (u *graphqlUsageResolver) AvgRequestBytes() int32 { return graphqlInt(int64(u.u.AvgRequestBytes)) }
+func (u *graphqlUsageResolver) AvgResponseBytes() int32 {
+	return graphqlInt(int64(u.u.AvgResponseBytes))
+}
+
+type graphqlAdapterResolver struct {
+	server, tool string
+	entry        *toolStatusEntry
+}
+
+func (a *graphqlAdapterResolver) Server() string      { return a.server }
+func (a *graphqlAdapterResolver) Tool() string        { return a.tool }
+func (a *graphqlAdapterResolver) LastAdapter() string { return a.entry.LastAdapter }
+func (a *graphqlAdapterResolver) ConsecutiveGeneric() int32 {
+	return graphqlInt(int64(a.entry.ConsecutiveGeneric))
+}
+
+func (a *graphqlAdapterResolver) UpdatedAt() *string {
+	if a.entry.UpdatedAt <= 0 {
+		return nil
+	}
+	at := time.Unix(a.entry.UpdatedAt, 0)
+	return graphqlTime(&at)
+}
+
+// graphqlHealthResolver resolves Health from the admin health map.
+type graphqlHealthResolver struct {
+	health map[string]any
+}
+
+func (h *graphqlHealthResolver) count(key string) int32 {
+	n, _ := h.health[key].(int)
+	return graphqlInt(int64(n))
+}
+
+func (h *graphqlHealthResolver) Ready() bool {
+	ready, _ := h.health["ready"].(bool)
+	return ready
+}
+
+func (h *graphqlHealthResolver) ServersConfigured() int32 { return h.count("serversConfigured") }
+func (h *graphqlHealthResolver) ServersConnected() int32  { return h.count("serversConnected") }
+func (h *graphqlHealthResolver) ActiveCalls() int32       { return h.count("activeCalls") }
+
+func (h *graphqlHealthResolver) ServersMissing() *[]string {
+	missing, ok := h.health["serversMissing"].([]string)
+	if !ok {
+		return nil
+	}
+	return &missing
+}
+
+func (h *graphqlHealthResolver) ReadyAt() *string {
+	readyAt, _ := h.health["readyAt"].(string)
+	return graphqlString(readyAt)
+}
+
+type graphqlReadOnlyResolver struct {
+	state readOnlyState
+}
+
+func (r *graphqlReadOnlyResolver) Enabled() bool   { return r.state.Enabled }
+func (r *graphqlReadOnlyResolver) By() *string     { return graphqlString(r.state.By) }
+func (r *graphqlReadOnlyResolver) At() *string     { return graphqlTime(r.state.At) }
+func (r *graphqlReadOnlyResolver) Reason() *string { return graphqlString(r.state.Reason) }
+
+// graphqlJSON is the JSON scalar: any JSON value, passed through as is.
+type graphqlJSON struct {
+	value any
+}
+
+func (graphqlJSON) ImplementsGraphQLType(name string) bool { return name == "JSON" }
+
+func (j *graphqlJSON) UnmarshalGraphQL(input any) error {
+	j.value = input
+	return nil
+}
+
+func (j graphqlJSON) MarshalJSON() ([]byte, error) { return json.Marshal(j.value) }
+
+// graphqlString maps "" to null.
+func graphqlString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// graphqlTime formats t as RFC 3339; nil and zero times are null.
+func graphqlTime(t *time.Time) *string {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return graphqlString(t.UTC().Format(time.RFC3339Nano))
+}
+
+// graphqlInt clamps n to GraphQL's 32-bit Int.
+func graphqlInt(n int64) int32 {
+	return int32(max(min(n, math.MaxInt32), math.MinInt32))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func testGraphQLSource() graphqlSource {
+	servers := map[string]*Server{
+		"fs": {name: "fs", tools: []mcp.Tool{{Name: "read", Description: "Read a file", InputSchema: mcp.ToolInputSchema{Type: "object"}}, {Name: "write"}}},
+	}
+	store := newToolOverrideStore(nil)
+	_ = store.setToolEnabled(toolToggle{Server: "fs", Tool: "write", Enabled: false, By: "admin:ab12", At: time.Now(), Reason: "incident"})
+	metrics := newCallMetrics(time.Hour)
+	metrics.record(callKey{Server: "fs", Method: "tools/call", Target: "read"}, callSample{At: time.Now(), Duration: 20 * time.Millisecond, RequestBytes: 10, ResponseBytes: 100})
+	metrics.record(callKey{Server: "fs", Method: "tools/call", Target: "read"}, callSample{At: time.Now(), Duration: 40 * time.Millisecond, Failed: true})
+	return graphqlSource{
+		Servers: func() []map[string]any {
+			return []map[string]any{{"name": "fs", "connected": true, "enabled": true, "transport": "stdio", "tools": 2}}
+		},
+		Tools:  func() []graphqlTool { return graphqlCatalogTools(servers, store) },
+		Health: func() map[string]any { return map[string]any{"ready": true, "serversConnected": 1} },
+		Adapters: func() statusMap {
+			return statusMap{"fs": {"read": {LastAdapter: "generic", ConsecutiveGeneric: 2}}}
+		},
+		Metrics:  metrics,
+		ReadOnly: newReadOnlyMode(false),
+	}
+}
+
+func runGraphQL(t *testing.T, query string, variables map[string]any) (string, []*gqlerrors.QueryError) {
+	t.Helper()
+	schema, err := newGraphQLSchema(testGraphQLSource())
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	resp := schema.Exec(context.Background(), query, "", variables)
+	return string(resp.Data), resp.Errors
+}
+
+func TestGraphQLCatalogQuery(t *testing.T) {
+	data, errs := runGraphQL(t, `
+		query Catalog($server: String!, $disabled: Boolean = false) {
+			health { ready serversConnected }
+			servers(name: $server) {
+				name
+				toolCount
+				off: tools(enabled: $disabled) { ...ToolInfo }
+			}
+			tools(name: "read") { name description inputSchema adapter { lastAdapter consecutiveGeneric } usage { calls errors p95Ms } }
+		}
+		fragment ToolInfo on Tool { __typename name disabledSource disabledBy disabledReason }`,
+		map[string]any{"server": "fs"})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	for _, want := range []string{
+		`"health":{"ready":true,"serversConnected":1}`,
+		`"off":[{"__typename":"Tool","name":"write","disabledSource":"runtime","disabledBy":"admin:ab12","disabledReason":"incident"}]`,
+		`"toolCount":2`,
+		`"description":"Read a file"`,
+		`"inputSchema":{"type":"object"`,
+		`"adapter":{"lastAdapter":"generic","consecutiveGeneric":2}`,
+		`"usage":{"calls":2,"errors":1,"p95Ms":40}`,
+	} {
+		if !strings.Contains(data, want) {
+			t.Fatalf("expected %s in %s", want, data)
+		}
+	}
+}
+
+func TestGraphQLKeepsSelectionOrder(t *testing.T) {
+	data, _ := runGraphQL(t, `{ health { serversConnected ready } readOnly { enabled } }`, nil)
+	if data != `{"health":{"serversConnected":1,"ready":true},"readOnly":{"enabled":false}}` {
+		t.Fatalf("unexpected data %s", data)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{`{ servers { nope } }`, `Cannot query field "nope" on type "Server"`},
+		{`{ servers }`, `must have a selection of subfields`},
+		{`{ tools(bogus: 1) { name } }`, `Unknown argument "bogus"`},
+		{`{ tools(server: 1) { name } }`, `Expected type "String"`},
+		{`mutation { health { ready } }`, `no mutations are offered by the schema`},
+		{`query Q($s: String!) { server(name: $s) { name } }`, `Variable "s" has invalid value null`},
+		{`{ health { ready `, `syntax error`},
+		{`{ health @defer { ready } }`, `Unknown directive "@defer"`},
+		{`{ servers { tools { name } } }` + strings.Repeat(" ", graphqlMaxQueryBytes), `query length`},
+	}
+	for _, tc := range cases {
+		_, errs := runGraphQL(t, tc.query, nil)
+		if len(errs) == 0 || !strings.Contains(errs[0].Message, tc.want) {
+			t.Errorf("%s: errors = %+v, want %q", tc.query, errs, tc.want)
+		}
+	}
+}
+
+func TestGraphQLIntrospection(t *testing.T) {
+	data, errs := runGraphQL(t, `{ __schema { queryType { name } } __type(name: "Tool") { fields { name } } }`, nil)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	for _, want := range []string{`"queryType":{"name":"Query"}`, `{"name":"disabledReason"}`, `{"name":"inputSchema"}`} {
+		if !strings.Contains(data, want) {
+			t.Fatalf("expected %s in %s", want, data)
+		}
+	}
+}
+
+func TestGraphQLSkipAndInclude(t *testing.T) {
+	data, errs := runGraphQL(t, `query($yes: Boolean!) { health @skip(if: $yes) { ready } readOnly @include(if: $yes) { enabled } }`, map[string]any{"yes": true})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	if data != `{"readOnly":{"enabled":false}}` {
+		t.Fatalf("unexpected data %s", data)
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TBXark/mcp-proxy/adminpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCAdminConfig serves the admin API over gRPC on its own listener (see
+// adminpb/admin.proto). It uses the main listener's TLS setup when there is
+// one and requires mcpProxy.adminTokens.
+type GRPCAdminConfig struct {
+	Addr string `json:"addr,omitempty"`
+	// ClientAuth requires client certificates on the gRPC listener, on top
+	// of admin tokens.
+	ClientAuth *ClientAuthConfig `json:"clientAuth,omitempty"`
+}
+
+// grpcAdminServer implements adminpb.AdminServiceServer over the same
+// operations as the HTTP admin API.
+type grpcAdminServer struct {
+	adminpb.UnimplementedAdminServiceServer
+	ops      adminToolOps
+	metrics  *callMetrics
+	readOnly *readOnlyMode
+	sessions *facadeSessionTable
+	events   *eventBus
+	// applier and ready back the server and override changes; ready
+	// reports whether startup has finished connecting servers.
+	applier *serverApplier
+	ready   func() bool
+}
+
+type grpcTokenKey struct{}
+
+// grpcAdminAuth accepts calls carrying one of tokens as bearer metadata and
+// records the token's fingerprint for attribution.
+func grpcAdminAuth(tokens []string) grpc.UnaryServerInterceptor {
+	tokenSet := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		tokenSet[token] = struct{}{}
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				token = strings.TrimSpace(strings.TrimPrefix(values[0], "Bearer "))
+			}
+		}
+		if _, ok := tokenSet[token]; !ok {
+			return nil, status.Error(codes.Unauthenticated, "admin token required")
+		}
+		return handler(context.WithValue(ctx, grpcTokenKey{}, tokenFingerprint(token)), req)
+	}
+}
+
+func grpcActor(ctx context.Context) string {
+	fingerprint, _ := ctx.Value(grpcTokenKey{}).(string)
+	return "admin:" + fingerprint
+}
+
+// startGRPCAdmin listens on conf.Addr and returns a stop function. It does
+// nothing without an address or admin tokens.
+func startGRPCAdmin(conf *GRPCAdminConfig, tokens []string, tlsConfig *tls.Config, srv *grpcAdminServer) (func(), error) {
+	if conf == nil || conf.Addr == "" {
+		return func() {}, nil
+	}
+	if len(tokens) == 0 {
+		logger("grpc").Warn("grpcAdmin.addr is set but adminTokens is empty; not serving")
+		return func() {}, nil
+	}
+	listener, err := net.Listen("tcp", conf.Addr)
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(grpcAdminAuth(tokens))}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig.Clone())))
+	}
+	server := grpc.NewServer(opts...)
+	adminpb.RegisterAdminServiceServer(server, srv)
+	go func() {
+		logger("grpc").Info("admin API listening", "addr", listener.Addr().String())
+		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logger("grpc").Error("admin API stopped", "err", err)
+		}
+	}()
+	return server.GracefulStop, nil
+}
+
+func grpcTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func (s *grpcAdminServer) ListServers(context.Context, *adminpb.ListServersRequest) (*adminpb.ListServersResponse, error) {
+	out := &adminpb.ListServersResponse{}
+	for _, entry := range s.ops.ListServers() {
+		server := &adminpb.Server{}
+		server.Name, _ = entry["name"].(string)
+		server.Connected, _ = entry["connected"].(bool)
+		server.Enabled, _ = entry["enabled"].(bool)
+		server.Transport, _ = entry["transport"].(string)
+		server.DependsOn, _ = entry["dependsOn"].([]string)
+		for key, field := range map[string]*int32{"tools": &server.Tools, "prompts": &server.Prompts, "resources": &server.Resources} {
+			n, _ := entry[key].(int)
+			*field = int32(n)
+		}
+		out.Servers = append(out.Servers, server)
+	}
+	return out, nil
+}
+
+func (s *grpcAdminServer) GetCatalog(context.Context, *adminpb.GetCatalogRequest) (*adminpb.GetCatalogResponse, error) {
+	view := s.ops.Catalog()
+	convert := func(rows []catalogTool) []*adminpb.CatalogTool {
+		out := make([]*adminpb.CatalogTool, 0, len(rows))
+		for _, row := range rows {
+			tool := &adminpb.CatalogTool{Server: row.Server, Tool: row.Tool, Source: row.Source, DisabledBy: row.DisabledBy, Reason: row.Reason}
+			if row.DisabledAt != nil {
+				tool.DisabledAt = grpcTimestamp(*row.DisabledAt)
+			}
+			out = append(out, tool)
+		}
+		return out
+	}
+	enabled, _ := view["enabled"].([]catalogTool)
+	disabled, _ := view["disabled"].([]catalogTool)
+	return &adminpb.GetCatalogResponse{Enabled: convert(enabled), Disabled: convert(disabled)}, nil
+}
+
+func (s *grpcAdminServer) toggle(ctx context.Context, req *adminpb.ToolToggleRequest, enabled bool) (*adminpb.ToolToggle, error) {
+	change := toolToggle{
+		Server:  req.GetServer(),
+		Tool:    req.GetTool(),
+		Enabled: enabled,
+		By:      grpcActor(ctx),
+		At:      time.Now().UTC(),
+		Reason:  req.GetReason(),
+	}
+	if err := s.ops.SetToolEnabled(change); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	action := "disabled"
+	if enabled {
+		action = "restored"
+	}
+	logger("grpc").Info(action+" tool", "server", change.Server, "tool", change.Tool, "by", change.By)
+	return &adminpb.ToolToggle{
+		Server:  change.Server,
+		Tool:    change.Tool,
+		Enabled: change.Enabled,
+		By:      change.By,
+		At:      grpcTimestamp(change.At),
+		Reason:  change.Reason,
+	}, nil
+}
+
+func (s *grpcAdminServer) DisableTool(ctx context.Context, req *adminpb.ToolToggleRequest) (*adminpb.ToolToggle, error) {
+	return s.toggle(ctx, req, false)
+}
+
+func (s *grpcAdminServer) RestoreTool(ctx context.Context, req *adminpb.ToolToggleRequest) (*adminpb.ToolToggle, error) {
+	return s.toggle(ctx, req, true)
+}
+
+func (s *grpcAdminServer) ReloadOverrides(ctx context.Context, _ *adminpb.ReloadOverridesRequest) (*adminpb.ReloadOverridesResponse, error) {
+	result, err := s.ops.ReloadOverrides()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	logger("grpc").Info("reloaded tool overrides", "by", grpcActor(ctx))
+	tools, _ := result["tools"].(int)
+	warnings, _ := result["warnings"].([]string)
+	return &adminpb.ReloadOverridesResponse{Tools: int32(tools), Warnings: warnings}, nil
+}
+
+func (s *grpcAdminServer) GetHealth(context.Context, *adminpb.GetHealthRequest) (*adminpb.Health, error) {
+	health := s.ops.Health()
+	out := &adminpb.Health{}
+	out.Ready, _ = health["ready"].(bool)
+	out.ServersMissing, _ = health["serversMissing"].([]string)
+	for key, field := range map[string]*int32{"serversConfigured": &out.ServersConfigured, "serversConnected": &out.ServersConnected, "activeCalls": &out.ActiveCalls} {
+		n, _ := health[key].(int)
+		*field = int32(n)
+	}
+	if readyAt, ok := health["readyAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, readyAt); err == nil {
+			out.ReadyAt = grpcTimestamp(t)
+		}
+	}
+	// structpb only takes plain JSON values
+	var details map[string]any
+	if data, err := json.Marshal(health); err == nil && json.Unmarshal(data, &details) == nil {
+		out.Details, _ = structpb.NewStruct(details)
+	}
+	return out, nil
+}
+
+func (s *grpcAdminServer) GetUsage(_ context.Context, req *adminpb.GetUsageRequest) (*adminpb.GetUsageResponse, error) {
+	rows := s.metrics.usageReport(time.Now().Add(-s.metrics.window), func(key callKey) bool {
+		return (req.GetServer() == "" || key.Server == req.GetServer()) &&
+			(req.GetMethod() == "" || key.Method == req.GetMethod()) &&
+			(req.GetTarget() == "" || key.Target == req.GetTarget())
+	})
+	out := &adminpb.GetUsageResponse{Window: s.metrics.window.String()}
+	for _, u := range rows {
+		out.Usage = append(out.Usage, &adminpb.Usage{
+			Server:           u.Server,
+			Method:           u.Method,
+			Target:           u.Target,
+			TotalCalls:       u.TotalCalls,
+			TotalErrors:      u.TotalErrors,
+			Calls:            int32(u.Calls),
+			Errors:           int32(u.Errors),
+			P50Ms:            u.P50Ms,
+			P95Ms:            u.P95Ms,
+			AvgRequestBytes:  int32(u.AvgRequestBytes),
+			AvgResponseBytes: int32(u.AvgResponseBytes),
+		})
+	}
+	return out, nil
+}
+
+func grpcReadOnlyState(state readOnlyState) *adminpb.ReadOnlyState {
+	out := &adminpb.ReadOnlyState{Enabled: state.Enabled, By: state.By, Reason: state.Reason}
+	if state.At != nil {
+		out.At = grpcTimestamp(*state.At)
+	}
+	return out
+}
+
+func (s *grpcAdminServer) GetReadOnly(context.Context, *adminpb.GetReadOnlyRequest) (*adminpb.ReadOnlyState, error) {
+	return grpcReadOnlyState(s.readOnly.snapshot()), nil
+}
+
+func (s *grpcAdminServer) SetReadOnly(ctx context.Context, req *adminpb.SetReadOnlyRequest) (*adminpb.ReadOnlyState, error) {
+	state := s.readOnly.set(req.GetEnabled(), grpcActor(ctx), req.GetReason())
+	logger("grpc").Info("read-only mode", "enabled", state.Enabled, "by", state.By)
+	s.events.emit("proxy.read_only", map[string]any{
+		"enabled": state.Enabled,
+		"by":      state.By,
+		"reason":  state.Reason,
+	})
+	return grpcReadOnlyState(state), nil
+}
+
+func (s *grpcAdminServer) ListSessions(context.Context, *adminpb.ListSessionsRequest) (*adminpb.ListSessionsResponse, error) {
+	out := &adminpb.ListSessionsResponse{}
+	for _, session := range s.sessions.list() {
+		out.Sessions = append(out.Sessions, &adminpb.Session{
+			Id:        session.ID,
+			Transport: session.Transport,
+			Caller:    session.Caller,
+			CreatedAt: grpcTimestamp(session.CreatedAt),
+			LastSeen:  grpcTimestamp(session.LastSeen),
+			Streams:   int32(session.Streams),
+		})
+	}
+	return out, nil
+}
+
+func (s *grpcAdminServer) TerminateSession(_ context.Context, req *adminpb.TerminateSessionRequest) (*adminpb.TerminateSessionResponse, error) {
+	if !s.sessions.close(req.GetId()) {
+		return nil, status.Errorf(codes.NotFound, "unknown session %q", req.GetId())
+	}
+	logger("grpc").Info("terminated facade session", "session", req.GetId())
+	return &adminpb.TerminateSessionResponse{}, nil
+}
+
+// grpcDecode converts a Struct into the config type v.
+func grpcDecode(in *structpb.Struct, v any) error {
+	data, err := json.Marshal(in.AsMap())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// applied answers an apply the way the HTTP routes do: invalid requests
+// are InvalidArgument, a rollout in progress FailedPrecondition, and a
+// rolled-back apply Unavailable with the servers' errors.
+func (s *grpcAdminServer) applied(result applyResult, err error) (*adminpb.ApplyResult, error) {
+	switch {
+	case errors.Is(err, errServerUnknown):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errRolloutActive):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case len(result.Failed) > 0:
+		names := make([]string, 0, len(result.Failed))
+		for name := range result.Failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		details := make([]string, 0, len(names))
+		for _, name := range names {
+			details = append(details, fmt.Sprintf("%s: %s", name, result.Failed[name]))
+		}
+		return nil, status.Errorf(codes.Unavailable, "%s (%s)", result.Error, strings.Join(details, "; "))
+	case result.Error != "":
+		return nil, status.Error(codes.InvalidArgument, result.Error)
+	}
+	return &adminpb.ApplyResult{
+		Plan: &adminpb.ApplyPlan{
+			Add:                  result.Plan.Add,
+			Change:               result.Plan.Change,
+			Remove:               result.Plan.Remove,
+			Unchanged:            result.Plan.Unchanged,
+			ToolOverridesChanged: result.Plan.ToolOverridesChanged,
+		},
+		DryRun:  result.DryRun,
+		Applied: result.Applied,
+	}, nil
+}
+
+func (s *grpcAdminServer) starting() error {
+	if s.applier == nil {
+		return status.Error(codes.Unimplemented, "server changes are not available")
+	}
+	if s.ready != nil && !s.ready() {
+		return status.Error(codes.Unavailable, "servers are still starting")
+	}
+	return nil
+}
+
+func (s *grpcAdminServer) ApplyServer(ctx context.Context, req *adminpb.ApplyServerRequest) (*adminpb.ApplyResult, error) {
+	if err := s.starting(); err != nil {
+		return nil, err
+	}
+	if req.GetConfig() == nil {
+		return nil, status.Error(codes.InvalidArgument, "config is required")
+	}
+	var conf MCPClientConfigV2
+	if err := grpcDecode(req.GetConfig(), &conf); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "config: %v", err)
+	}
+	result, err := s.applier.applyServer(req.GetName(), &conf)
+	out, err := s.applied(result, err)
+	if err == nil {
+		logger("grpc").Info("applied server", "server", req.GetName(), "by", grpcActor(ctx))
+		emitServerApplied(s.events, req.GetName(), grpcActor(ctx), result)
+	}
+	return out, err
+}
+
+func (s *grpcAdminServer) RemoveServer(ctx context.Context, req *adminpb.RemoveServerRequest) (*adminpb.ApplyResult, error) {
+	if err := s.starting(); err != nil {
+		return nil, err
+	}
+	result, err := s.applier.applyServer(req.GetName(), nil)
+	out, err := s.applied(result, err)
+	if err == nil {
+		logger("grpc").Info("removed server", "server", req.GetName(), "by", grpcActor(ctx))
+		emitServerApplied(s.events, req.GetName(), grpcActor(ctx), result)
+	}
+	return out, err
+}
+
+func (s *grpcAdminServer) Apply(ctx context.Context, req *adminpb.ApplyRequest) (*adminpb.ApplyResult, error) {
+	if err := s.starting(); err != nil {
+		return nil, err
+	}
+	apply := applyRequest{DryRun: req.GetDryRun()}
+	if req.GetMcpServers() != nil {
+		if err := grpcDecode(req.GetMcpServers(), &apply.McpServers); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "mcp_servers: %v", err)
+		}
+	}
+	if req.GetToolOverrides() != nil {
+		if err := grpcDecode(req.GetToolOverrides(), &apply.ToolOverrides); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "tool_overrides: %v", err)
+		}
+	}
+	result, err := s.applier.apply(apply)
+	out, err := s.applied(result, err)
+	if err == nil {
+		logger("grpc").Info("applied config", "dryRun", result.DryRun, "by", grpcActor(ctx))
+		emitApplied(s.events, grpcActor(ctx), result)
+	}
+	return out, err
+}
+
+func (s *grpcAdminServer) PatchToolOverrides(ctx context.Context, req *adminpb.PatchToolOverridesRequest) (*adminpb.ApplyResult, error) {
+	if err := s.starting(); err != nil {
+		return nil, err
+	}
+	var patch map[string]*ToolOverrideConfig
+	if req.GetToolOverrides() != nil {
+		if err := grpcDecode(req.GetToolOverrides(), &patch); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "tool_overrides: %v", err)
+		}
+	}
+	result, err := s.applier.patchOverrides(patch, req.GetDryRun())
+	out, err := s.applied(result, err)
+	if err == nil {
+		logger("grpc").Info("patched tool overrides", "dryRun", result.DryRun, "by", grpcActor(ctx))
+		emitApplied(s.events, grpcActor(ctx), result)
+	}
+	return out, err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/TBXark/mcp-proxy/adminpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGRPCAdminServer(t *testing.T) {
+	var toggled []toolToggle
+	sessions := newFacadeSessionTable(0)
+	sessionID := sessions.open("http", "token:ab12")
+	srv := &grpcAdminServer{
+		ops: adminToolOps{
+			ListServers: func() []map[string]any {
+				return []map[string]any{{"name": "fs", "connected": true, "enabled": true, "tools": 3, "dependsOn": []string{"db"}}}
+			},
+			SetToolEnabled: func(change toolToggle) error {
+				toggled = append(toggled, change)
+				return nil
+			},
+			Health: func() map[string]any {
+				return map[string]any{"ready": true, "serversConfigured": 2, "serversConnected": 1, "serversMissing": []string{"db"}}
+			},
+		},
+		metrics:  newCallMetrics(0),
+		readOnly: newReadOnlyMode(false),
+		sessions: sessions,
+		events:   newEventBus(nil),
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcAdminAuth([]string{"secret"})))
+	adminpb.RegisterAdminServiceServer(server, srv)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := adminpb.NewAdminServiceClient(conn)
+
+	if _, err := client.ListServers(context.Background(), &adminpb.ListServersRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without token, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	servers, err := client.ListServers(ctx, &adminpb.ListServersRequest{})
+	if err != nil || len(servers.Servers) != 1 || servers.Servers[0].Tools != 3 || servers.Servers[0].DependsOn[0] != "db" {
+		t.Fatalf("ListServers = %v, %v", servers, err)
+	}
+
+	toggle, err := client.DisableTool(ctx, &adminpb.ToolToggleRequest{Server: "fs", Tool: "write", Reason: "incident"})
+	if err != nil || toggle.Enabled || len(toggled) != 1 || toggled[0].By != "admin:"+tokenFingerprint("secret") {
+		t.Fatalf("DisableTool = %v, %v (toggled %+v)", toggle, err, toggled)
+	}
+
+	health, err := client.GetHealth(ctx, &adminpb.GetHealthRequest{})
+	if err != nil || !health.Ready || health.ServersConfigured != 2 || health.Details.Fields["serversMissing"] == nil {
+		t.Fatalf("GetHealth = %v, %v", health, err)
+	}
+
+	state, err := client.SetReadOnly(ctx, &adminpb.SetReadOnlyRequest{Enabled: true, Reason: "maintenance"})
+	if err != nil || !state.Enabled || state.At == nil || !srv.readOnly.active() {
+		t.Fatalf("SetReadOnly = %v, %v", state, err)
+	}
+
+	list, err := client.ListSessions(ctx, &adminpb.ListSessionsRequest{})
+	if err != nil || len(list.Sessions) != 1 || list.Sessions[0].Id != sessionID {
+		t.Fatalf("ListSessions = %v, %v", list, err)
+	}
+	if _, err := client.TerminateSession(ctx, &adminpb.TerminateSessionRequest{Id: sessionID}); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+	if _, err := client.TerminateSession(ctx, &adminpb.TerminateSessionRequest{Id: sessionID}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for a terminated session, got %v", err)
+	}
+}
+
+func TestGRPCAdminApply(t *testing.T) {
+	mux := http.NewServeMux()
+	overrides := map[string]*ToolOverrideConfig{}
+	srv := &grpcAdminServer{
+		events: newEventBus(nil),
+		ready:  func() bool { return true },
+		applier: &serverApplier{
+			registry: newServerRegistry(mux, "/"),
+			resolve: func(servers map[string]*MCPClientConfigV2, _ map[string]*ToolOverrideConfig) error {
+				_, err := startupStages(servers)
+				return err
+			},
+			connect: func(name string, conf *MCPClientConfigV2) (*serverEntry, error) {
+				if conf.URL == "" {
+					return nil, errors.New("connection refused")
+				}
+				return &serverEntry{server: &Server{name: name}, config: conf, handler: http.NotFoundHandler(), cancel: func() {}}, nil
+			},
+			store:         newToolOverrideStore(nil),
+			overrides:     func() map[string]*ToolOverrideConfig { return overrides },
+			loadOverrides: func(map[string]*ToolOverrideConfig) (*ToolOverrideSet, error) { return nil, nil },
+			setOverrides:  func(next map[string]*ToolOverrideConfig) { overrides = next },
+			applied:       func(applyPlan) {},
+		},
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcAdminAuth([]string{"secret"})))
+	adminpb.RegisterAdminServiceServer(server, srv)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := adminpb.NewAdminServiceClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	mustStruct := func(v map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(v)
+		if err != nil {
+			t.Fatalf("struct: %v", err)
+		}
+		return s
+	}
+
+	result, err := client.ApplyServer(ctx, &adminpb.ApplyServerRequest{Name: "web", Config: mustStruct(map[string]any{"url": "http://web/mcp"})})
+	if err != nil || !result.Applied || len(result.Plan.Add) != 1 || result.Plan.Add[0] != "web" {
+		t.Fatalf("ApplyServer = %v, %v", result, err)
+	}
+	if _, err := client.ApplyServer(ctx, &adminpb.ApplyServerRequest{Name: "broken", Config: mustStruct(map[string]any{"command": "missing"})}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable for a failed server, got %v", err)
+	}
+
+	dryRun, err := client.Apply(ctx, &adminpb.ApplyRequest{
+		McpServers: mustStruct(map[string]any{"web": map[string]any{"url": "http://web/v2"}}),
+		DryRun:     true,
+	})
+	if err != nil || dryRun.Applied || !dryRun.DryRun || len(dryRun.Plan.Change) != 1 {
+		t.Fatalf("Apply dry run = %v, %v", dryRun, err)
+	}
+	if _, err := client.Apply(ctx, &adminpb.ApplyRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without mcp_servers, got %v", err)
+	}
+
+	patched, err := client.PatchToolOverrides(ctx, &adminpb.PatchToolOverridesRequest{
+		ToolOverrides: mustStruct(map[string]any{"web": map[string]any{"description": "pages"}}),
+	})
+	if err != nil || !patched.Plan.ToolOverridesChanged || overrides["web"] == nil {
+		t.Fatalf("PatchToolOverrides = %v, %v (%+v)", patched, err, overrides)
+	}
+
+	if _, err := client.RemoveServer(ctx, &adminpb.RemoveServerRequest{Name: "web"}); err != nil {
+		t.Fatalf("RemoveServer: %v", err)
+	}
+	if _, err := client.RemoveServer(ctx, &adminpb.RemoveServerRequest{Name: "web"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for an unknown server, got %v", err)
+	}
+}
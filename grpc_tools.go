@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/TBXark/mcp-proxy/toolspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// grpcToolServer implements toolspb.ToolServiceServer by sending each call
+// through the facade as a JSON-RPC request with the caller's metadata as
+// headers.
+type grpcToolServer struct {
+	toolspb.UnimplementedToolServiceServer
+	handler http.Handler
+	mcpPath string
+	nextID  atomic.Uint64
+}
+
+// grpcForwardedMetadata are the metadata keys passed to the facade as headers.
+var grpcForwardedMetadata = []string{"authorization", sessionIDHeader, requestIDHeader}
+
+// facadeCall runs one JSON-RPC request through the facade and decodes its
+// result into out.
+func (s *grpcToolServer) facadeCall(ctx context.Context, method string, params any, out any) error {
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      s.nextID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, s.mcpPath, bytes.NewReader(body))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json")
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range grpcForwardedMetadata {
+			if values := md.Get(key); len(values) > 0 {
+				r.Header.Set(key, values[0])
+			}
+		}
+	}
+	rr := newResponseRecorder()
+	s.handler.ServeHTTP(rr, r)
+
+	switch rr.StatusCode {
+	case http.StatusUnauthorized:
+		return status.Error(codes.Unauthenticated, "facade token required")
+	case http.StatusForbidden:
+		return status.Error(codes.PermissionDenied, strings.TrimSpace(rr.Body.String()))
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *jsonrpcError   `json:"error"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		return status.Errorf(codes.Internal, "facade returned status %d: %s", rr.StatusCode, strings.TrimSpace(rr.Body.String()))
+	}
+	if resp.Error != nil {
+		return status.Error(grpcCodeForRPCError(resp.Error), fmt.Sprintf("%s (JSON-RPC %d)", resp.Error.Message, resp.Error.Code))
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return status.Errorf(codes.Internal, "decode %s result: %v", method, err)
+	}
+	return nil
+}
+
+// grpcCodesByErrorName maps the registry's error names to gRPC codes.
+var grpcCodesByErrorName = map[string]codes.Code{
+	errNameMethodNotFound:    codes.NotFound,
+	errNameUnknownTool:       codes.NotFound,
+	errNameUnknownPrompt:     codes.NotFound,
+	errNameUnknownResource:   codes.NotFound,
+	errNameUnknownFetchID:    codes.NotFound,
+	errNameParseError:        codes.InvalidArgument,
+	errNameInvalidRequest:    codes.InvalidArgument,
+	errNameMissingParam:      codes.InvalidArgument,
+	errNameInvalidFetchRange: codes.InvalidArgument,
+	errNameFetchNotAllowed:   codes.PermissionDenied,
+	errNameInsufficientScope: codes.PermissionDenied,
+	errNameRequestTimeout:    codes.DeadlineExceeded,
+	errNameCallCancelled:     codes.Canceled,
+	errNameUpstreamRejected:  codes.Unavailable,
+	errNameCircuitOpen:       codes.Unavailable,
+	errNameServerBusy:        codes.Unavailable,
+	errNameFetchFailed:       codes.Unavailable,
+	errNameReadOnlyMode:      codes.FailedPrecondition,
+	errNameLoopDetected:      codes.FailedPrecondition,
+	errNameToolConflict:      codes.FailedPrecondition,
+	errNameBudgetExceeded:    codes.ResourceExhausted,
+	errNameProtocolViolation: codes.Internal,
+	errNameInternal:          codes.Internal,
+}
+
+// grpcCodeForRPCError maps a facade JSON-RPC error to a gRPC code by the
+// error.data.name the registry sets, since operators may renumber custom
+// codes. Errors without a known name map by their standard code.
+func grpcCodeForRPCError(e *jsonrpcError) codes.Code {
+	if data, ok := e.Data.(map[string]any); ok {
+		if name, _ := data["name"].(string); name != "" {
+			if code, ok := grpcCodesByErrorName[name]; ok {
+				return code
+			}
+		}
+	}
+	switch e.Code {
+	case rpcCodeParseError, -32600, -32602:
+		return codes.InvalidArgument
+	case -32601:
+		return codes.NotFound
+	}
+	return codes.Internal
+}
+
+// grpcStruct converts a JSON object; anything else yields nil.
+func grpcStruct(v any) *structpb.Struct {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	out, err := structpb.NewStruct(m)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func (s *grpcToolServer) ListTools(ctx context.Context, _ *toolspb.ListToolsRequest) (*toolspb.ListToolsResponse, error) {
+	var result struct {
+		Tools []map[string]any `json:"tools"`
+	}
+	if err := s.facadeCall(ctx, "tools/list", map[string]any{}, &result); err != nil {
+		return nil, err
+	}
+	out := &toolspb.ListToolsResponse{}
+	for _, tool := range result.Tools {
+		entry := &toolspb.Tool{
+			InputSchema:  grpcStruct(tool["inputSchema"]),
+			OutputSchema: grpcStruct(tool["outputSchema"]),
+			Annotations:  grpcStruct(tool["annotations"]),
+			Descriptor_:  grpcStruct(tool),
+		}
+		entry.Name, _ = tool["name"].(string)
+		entry.Description, _ = tool["description"].(string)
+		out.Tools = append(out.Tools, entry)
+	}
+	return out, nil
+}
+
+func (s *grpcToolServer) CallTool(ctx context.Context, req *toolspb.CallToolRequest) (*toolspb.CallToolResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	arguments := map[string]any{}
+	if req.GetArguments() != nil {
+		arguments = req.GetArguments().AsMap()
+	}
+	var result struct {
+		Content           []map[string]any `json:"content"`
+		StructuredContent any              `json:"structuredContent"`
+		IsError           bool             `json:"isError"`
+	}
+	if err := s.facadeCall(ctx, "tools/call", map[string]any{"name": req.GetName(), "arguments": arguments}, &result); err != nil {
+		return nil, err
+	}
+	out := &toolspb.CallToolResponse{
+		StructuredContent: grpcStruct(result.StructuredContent),
+		IsError:           result.IsError,
+	}
+	for _, block := range result.Content {
+		content := &toolspb.Content{Raw: grpcStruct(block)}
+		content.Type, _ = block["type"].(string)
+		content.Text, _ = block["text"].(string)
+		content.MimeType, _ = block["mimeType"].(string)
+		content.Data, _ = block["data"].(string)
+		out.Content = append(out.Content, content)
+	}
+	return out, nil
+}
+
+// newGRPCToolHandler serves ToolService on the main listener: HTTP/2
+// requests with a gRPC content type go to the gRPC server, everything else
+// to next.
+func newGRPCToolHandler(next http.Handler, mcpPath string) http.Handler {
+	server := grpc.NewServer()
+	toolspb.RegisterToolServiceServer(server, &grpcToolServer{handler: next, mcpPath: mcpPath})
+	logger("grpc").Info("tool service enabled on the main listener")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			server.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TBXark/mcp-proxy/toolspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGRPCToolBridge(t *testing.T) {
+	var sessions []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer facade" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		sessions = append(sessions, r.Header.Get(sessionIDHeader))
+		var req jsonrpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		resp := rpcOK(req.ID, nil)
+		switch req.Method {
+		case "tools/list":
+			resp.Result = map[string]any{"tools": []map[string]any{{
+				"name":        "echo",
+				"description": "Echo the input",
+				"inputSchema": map[string]any{"type": "object"},
+				"annotations": map[string]any{"readOnlyHint": true},
+			}}}
+		case "tools/call":
+			var p struct {
+				Name      string         `json:"name"`
+				Arguments map[string]any `json:"arguments"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			if p.Name != "echo" {
+				resp = rpcErrors.response(req.ID, errNameUnknownTool, map[string]string{"name": p.Name})
+				break
+			}
+			resp.Result = map[string]any{"content": []map[string]any{{"type": "text", "text": p.Arguments["text"]}}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	server := httptest.NewUnstartedServer(newGRPCToolHandler(mux, "/mcp"))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	if resp, err := http.Get(server.URL + "/health"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("plain HTTP on the shared listener = %v, %v", resp, err)
+	}
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := toolspb.NewToolServiceClient(conn)
+
+	if _, err := client.ListTools(context.Background(), &toolspb.ListToolsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without token, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer facade", "mcp-session-id", "s1")
+	list, err := client.ListTools(ctx, &toolspb.ListToolsRequest{})
+	if err != nil || len(list.Tools) != 1 || list.Tools[0].Name != "echo" || !list.Tools[0].Annotations.Fields["readOnlyHint"].GetBoolValue() {
+		t.Fatalf("ListTools = %v, %v", list, err)
+	}
+
+	args, _ := structpb.NewStruct(map[string]any{"text": "hello"})
+	call, err := client.CallTool(ctx, &toolspb.CallToolRequest{Name: "echo", Arguments: args})
+	if err != nil || len(call.Content) != 1 || call.Content[0].Text != "hello" || call.IsError {
+		t.Fatalf("CallTool = %v, %v", call, err)
+	}
+	if _, err := client.CallTool(ctx, &toolspb.CallToolRequest{Name: "nope"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for an unknown tool, got %v", err)
+	}
+	if len(sessions) == 0 || sessions[0] != "s1" {
+		t.Fatalf("expected the session metadata to reach the facade, got %v", sessions)
+	}
+}
+
+func TestGRPCToolsKeepCompression(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		tools := make([]map[string]any, 50)
+		for i := range tools {
+			tools[i] = map[string]any{"name": fmt.Sprintf("tool_%d", i), "description": "does one thing", "inputSchema": map[string]any{"type": "object"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rpcOK(req.ID, map[string]any{"tools": tools}))
+	})
+	handler, err := newListenerHandler(mux, &MCPProxyConfigV2{GRPCTools: true, Compression: &CompressionConfig{}}, "/mcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q with grpcTools on", resp.Header.Get("Content-Encoding"))
+	}
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	list, err := toolspb.NewToolServiceClient(conn).ListTools(context.Background(), &toolspb.ListToolsRequest{})
+	if err != nil || len(list.Tools) != 50 {
+		t.Fatalf("ListTools behind compression = %d tools, %v", len(list.GetTools()), err)
+	}
+}
+
+func TestGRPCCodeForRPCErrorUsesErrorName(t *testing.T) {
+	for _, def := range defaultRPCErrorDefs {
+		if _, ok := grpcCodesByErrorName[def.Name]; !ok {
+			t.Errorf("no gRPC code for %s", def.Name)
+		}
+	}
+
+	// renumbered codes keep their mapping
+	reg, err := newRPCErrorRegistry(&ErrorsConfig{Codes: map[string]int{errNameRequestTimeout: -32050, errNameServerBusy: -32003}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		resp jsonrpcResponse
+		want codes.Code
+	}{
+		{reg.response(1, errNameRequestTimeout, nil), codes.DeadlineExceeded},
+		{reg.response(1, errNameServerBusy, nil), codes.Unavailable},
+		{reg.response(1, errNameUnknownTool, nil), codes.NotFound},
+		{rpcError(1, -32602, "bad params from downstream"), codes.InvalidArgument},
+		{rpcError(1, -32000, "downstream failure"), codes.Internal},
+	}
+	for _, tc := range cases {
+		if got := grpcCodeForRPCError(tc.resp.Error); got != tc.want {
+			t.Errorf("%+v: code = %s, want %s", tc.resp.Error, got, tc.want)
+		}
+	}
+}
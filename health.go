@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthCheckInterval  = 30 * time.Second
+	defaultHealthCheckTimeout   = 10 * time.Second
+	defaultHealthCheckDownAfter = 3
+
+	serverStatusConnected = "connected"
+	serverStatusDegraded  = "degraded"
+	serverStatusDown      = "down"
+)
+
+// HealthCheckConfig tunes the pings sent to every downstream server.
+type HealthCheckConfig struct {
+	Interval time.Duration `json:"interval,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty"`
+	// DownAfter is how many pings in a row must fail before a server counts
+	// as down; fewer failures mark it degraded.
+	DownAfter int `json:"downAfter,omitempty"`
+}
+
+// serverHealth is one server's status and its latest ping.
+type serverHealth struct {
+	Server              string     `json:"server"`
+	Status              string     `json:"status"`
+	LastPingAt          *time.Time `json:"lastPingAt,omitempty"`
+	Latency             float64    `json:"latencyMs,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	// Circuit is the server's circuit breaker state when it is not closed.
+	Circuit string `json:"circuit,omitempty"`
+}
+
+// healthMonitor keeps the ping results of each downstream client. A server
+// with no pings yet is as healthy as its connection.
+type healthMonitor struct {
+	interval  time.Duration
+	timeout   time.Duration
+	downAfter int
+	events    *eventBus
+	// circuits, when set, marks servers with an open circuit degraded.
+	circuits *circuitBreaker
+
+	mu      sync.Mutex
+	servers map[string]*serverHealth
+}
+
+func newHealthMonitor(conf *HealthCheckConfig, events *eventBus) *healthMonitor {
+	h := &healthMonitor{
+		interval:  defaultHealthCheckInterval,
+		timeout:   defaultHealthCheckTimeout,
+		downAfter: defaultHealthCheckDownAfter,
+		events:    events,
+		servers:   make(map[string]*serverHealth),
+	}
+	if conf != nil {
+		if conf.Interval > 0 {
+			h.interval = conf.Interval
+		}
+		if conf.Timeout > 0 {
+			h.timeout = conf.Timeout
+		}
+		if conf.DownAfter > 0 {
+			h.downAfter = conf.DownAfter
+		}
+	}
+	return h
+}
+
+// reset drops the ping history of server, for a client that (re)connects.
+func (h *healthMonitor) reset(server string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.servers, server)
+}
+
+// record stores one ping result, emits server.health when the server's
+// status changes, and returns the status.
+func (h *healthMonitor) record(server string, latency time.Duration, err error, at time.Time) string {
+	h.mu.Lock()
+	state := h.servers[server]
+	if state == nil {
+		state = &serverHealth{Server: server, Status: serverStatusConnected}
+		h.servers[server] = state
+	}
+	before := state.Status
+	at = at.UTC()
+	state.LastPingAt = &at
+	state.Latency = float64(latency.Microseconds()) / 1000
+	if err != nil {
+		state.ConsecutiveFailures++
+		state.LastError = err.Error()
+	} else {
+		state.ConsecutiveFailures = 0
+		state.LastError = ""
+	}
+	state.Status = h.statusLocked(state)
+	snapshot := *state
+	h.mu.Unlock()
+
+	if snapshot.Status != before {
+		level := slog.LevelWarn
+		if snapshot.Status == serverStatusConnected {
+			level = slog.LevelInfo
+		}
+		logger("health").Log(context.Background(), level, "status changed", "server", server, "from", before, "to", snapshot.Status, "failures", snapshot.ConsecutiveFailures)
+		h.events.emit("server.health", map[string]any{
+			"server":   server,
+			"status":   snapshot.Status,
+			"previous": before,
+			"failures": snapshot.ConsecutiveFailures,
+			"error":    snapshot.LastError,
+		})
+	}
+	return snapshot.Status
+}
+
+func (h *healthMonitor) statusLocked(state *serverHealth) string {
+	switch {
+	case state.ConsecutiveFailures >= h.downAfter:
+		return serverStatusDown
+	case state.ConsecutiveFailures > 0:
+		return serverStatusDegraded
+	default:
+		return serverStatusConnected
+	}
+}
+
+// report returns each named server's health, by name. Servers whose client
+// is not connected are down whatever their pings said; an open circuit makes
+// a connected server degraded.
+func (h *healthMonitor) report(names []string, connected func(string) bool) []serverHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]serverHealth, 0, len(names))
+	for _, name := range names {
+		entry := serverHealth{Server: name, Status: serverStatusConnected}
+		if state := h.servers[name]; state != nil {
+			entry = *state
+		}
+		if circuit := h.circuits.state(name).State; circuit != circuitClosed {
+			entry.Circuit = circuit
+			if entry.Status == serverStatusConnected {
+				entry.Status = serverStatusDegraded
+			}
+		}
+		if !connected(name) {
+			entry.Status = serverStatusDown
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Server < out[j].Server })
+	return out
+}
+
+// overallHealth folds server statuses into one: ok when every server is
+// connected, down when none is reachable, degraded otherwise. A proxy with
+// no servers is ok.
+func overallHealth(servers []serverHealth) string {
+	down, degraded := 0, 0
+	for _, s := range servers {
+		switch s.Status {
+		case serverStatusDown:
+			down++
+		case serverStatusDegraded:
+			degraded++
+		}
+	}
+	switch {
+	case len(servers) > 0 && down == len(servers):
+		return serverStatusDown
+	case down > 0 || degraded > 0:
+		return serverStatusDegraded
+	default:
+		return "ok"
+	}
+}
+
+// healthzHandler serves the aggregated server health. It answers 503 until
+// startup finishes and while every server is down, and 200 otherwise, so
+// one failing downstream does not take the whole proxy out of rotation.
+func healthzHandler(enc JSONEncodingConfig, ready func() bool, names func() []string, connected func(string) bool, monitor *healthMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		servers := monitor.report(names(), connected)
+		status := overallHealth(servers)
+		code := http.StatusOK
+		if !ready() {
+			status = "starting"
+			code = http.StatusServiceUnavailable
+		} else if status == serverStatusDown {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if r.Method == http.MethodHead {
+			return
+		}
+		_ = enc.encode(w, map[string]any{
+			"status":  status,
+			"servers": servers,
+		})
+	}
+}
+
+// ReadinessConfig sets what /readyz needs besides startup having finished:
+// at least MinConnected servers connected (default 1, or every server when
+// fewer are configured) and every server in RequiredServers connected. A
+// degraded server still counts as connected.
+type ReadinessConfig struct {
+	MinConnected    int      `json:"minConnected,omitempty"`
+	RequiredServers []string `json:"requiredServers,omitempty"`
+}
+
+// readinessReport is the body of /readyz.
+type readinessReport struct {
+	Ready        bool       `json:"ready"`
+	ReadyAt      *time.Time `json:"readyAt,omitempty"`
+	Connected    int        `json:"connected"`
+	Configured   int        `json:"configured"`
+	MinConnected int        `json:"minConnected"`
+	Missing      []string   `json:"missing,omitempty"`
+	Reasons      []string   `json:"reasons,omitempty"`
+}
+
+// readiness checks the criteria against the startup snapshot and the
+// current server health.
+func readiness(conf *ReadinessConfig, snapshot *readinessSnapshot, servers []serverHealth) readinessReport {
+	if conf == nil {
+		conf = &ReadinessConfig{}
+	}
+	report := readinessReport{Configured: len(servers), MinConnected: conf.MinConnected}
+	if report.MinConnected <= 0 {
+		report.MinConnected = 1
+	}
+	report.MinConnected = min(report.MinConnected, len(servers))
+	up := make(map[string]bool, len(servers))
+	for _, s := range servers {
+		if s.Status != serverStatusDown {
+			up[s.Server] = true
+			report.Connected++
+		}
+	}
+	if snapshot == nil {
+		report.Reasons = append(report.Reasons, "startup has not finished")
+	} else {
+		readyAt := snapshot.ReadyAt
+		report.ReadyAt = &readyAt
+	}
+	if report.Connected < report.MinConnected {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("%d of %d servers connected, %d required", report.Connected, report.Configured, report.MinConnected))
+	}
+	for _, name := range conf.RequiredServers {
+		if !up[name] {
+			report.Missing = append(report.Missing, name)
+		}
+	}
+	if len(report.Missing) > 0 {
+		report.Reasons = append(report.Reasons, "required servers not connected: "+strings.Join(report.Missing, ", "))
+	}
+	report.Ready = len(report.Reasons) == 0
+	return report
+}
+
+// readyzHandler answers 200 once the proxy can serve calls by the
+// readiness criteria and 503 otherwise, so orchestrators need not watch
+// the facade's SSE ready event.
+func readyzHandler(enc JSONEncodingConfig, conf *ReadinessConfig, snapshot func() *readinessSnapshot, names func() []string, connected func(string) bool, monitor *healthMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		report := readiness(conf, snapshot(), monitor.report(names(), connected))
+		code := http.StatusOK
+		if !report.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if r.Method == http.MethodHead {
+			return
+		}
+		_ = enc.encode(w, report)
+	}
+}
+
+// livezHandler answers 200 while the process serves HTTP at all; it checks
+// no downstream, so a restart cannot fix what it reports.
+func livezHandler(enc JSONEncodingConfig, started time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if r.Method == http.MethodHead {
+			return
+		}
+		_ = enc.encode(w, map[string]any{
+			"status":    "alive",
+			"startedAt": started.UTC(),
+		})
+	}
+}
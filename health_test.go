@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	monitor := newHealthMonitor(&HealthCheckConfig{DownAfter: 2}, nil)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	connected := func(name string) bool { return name != "gone" }
+	names := []string{"web", "fs", "gone"}
+
+	monitor.record("fs", 5*time.Millisecond, nil, now)
+	monitor.record("web", time.Second, errors.New("timeout"), now)
+	report := monitor.report(names, connected)
+	want := map[string]string{"fs": serverStatusConnected, "web": serverStatusDegraded, "gone": serverStatusDown}
+	for _, s := range report {
+		if s.Status != want[s.Server] {
+			t.Fatalf("%s status = %s, want %s", s.Server, s.Status, want[s.Server])
+		}
+	}
+	if report[0].Server != "fs" || report[0].Latency != 5 {
+		t.Fatalf("report = %+v", report)
+	}
+	if got := overallHealth(report); got != serverStatusDegraded {
+		t.Fatalf("overall = %s", got)
+	}
+
+	monitor.record("web", time.Second, errors.New("timeout"), now.Add(time.Minute))
+	if s := monitor.report([]string{"web"}, connected)[0]; s.Status != serverStatusDown || s.ConsecutiveFailures != 2 || s.LastError != "timeout" {
+		t.Fatalf("web = %+v", s)
+	}
+	monitor.reset("web")
+	if s := monitor.report([]string{"web"}, connected)[0]; s.Status != serverStatusConnected {
+		t.Fatalf("reset did not clear the ping history: %+v", s)
+	}
+	if got := overallHealth(nil); got != "ok" {
+		t.Fatalf("overall with no servers = %s", got)
+	}
+
+	monitor.circuits = newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 1}, nil)
+	monitor.circuits.record("fs", callFailed, now)
+	if s := monitor.report([]string{"fs"}, connected)[0]; s.Status != serverStatusDegraded || s.Circuit != circuitOpen {
+		t.Fatalf("open circuit not reported: %+v", s)
+	}
+}
+
+func TestHealthzHandler(t *testing.T) {
+	monitor := newHealthMonitor(nil, nil)
+	ready := false
+	up := map[string]bool{"web": true, "fs": true}
+	handler := healthzHandler(JSONEncodingConfig{}, func() bool { return ready }, func() []string { return []string{"fs", "web"} }, func(name string) bool { return up[name] }, monitor)
+	get := func() (int, map[string]any) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var body map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return rr.Code, body
+	}
+
+	if code, body := get(); code != http.StatusServiceUnavailable || body["status"] != "starting" {
+		t.Fatalf("before ready: %d %v", code, body)
+	}
+	ready = true
+	if code, body := get(); code != http.StatusOK || body["status"] != "ok" {
+		t.Fatalf("all connected: %d %v", code, body)
+	}
+	up["web"] = false
+	if code, body := get(); code != http.StatusOK || body["status"] != serverStatusDegraded {
+		t.Fatalf("one down: %d %v", code, body)
+	}
+	up["fs"] = false
+	if code, body := get(); code != http.StatusServiceUnavailable || body["status"] != serverStatusDown {
+		t.Fatalf("all down: %d %v", code, body)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	monitor := newHealthMonitor(nil, nil)
+	var snapshot *readinessSnapshot
+	up := map[string]bool{}
+	handler := readyzHandler(JSONEncodingConfig{}, &ReadinessConfig{MinConnected: 2, RequiredServers: []string{"fs"}}, func() *readinessSnapshot { return snapshot },
+		func() []string { return []string{"fs", "git", "web"} }, func(name string) bool { return up[name] }, monitor)
+	get := func() (int, readinessReport) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body readinessReport
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return rr.Code, body
+	}
+
+	up["web"], up["git"] = true, true
+	if code, body := get(); code != http.StatusServiceUnavailable || body.Ready || len(body.Reasons) != 2 || body.Missing[0] != "fs" {
+		t.Fatalf("before startup finished: %d %+v", code, body)
+	}
+	snapshot = &readinessSnapshot{ReadyAt: time.Now(), ServerCount: 3}
+	up["fs"], up["git"] = true, false
+	if code, body := get(); code != http.StatusOK || !body.Ready || body.Connected != 2 || body.ReadyAt == nil {
+		t.Fatalf("ready: %d %+v", code, body)
+	}
+	up["web"] = false
+	if code, body := get(); code != http.StatusServiceUnavailable || body.Connected != 1 || body.MinConnected != 2 {
+		t.Fatalf("below minimum: %d %+v", code, body)
+	}
+
+	// the minimum never exceeds the configured servers
+	if r := readiness(&ReadinessConfig{MinConnected: 5}, snapshot, []serverHealth{{Server: "fs", Status: serverStatusDegraded}}); !r.Ready || r.MinConnected != 1 {
+		t.Fatalf("capped minimum: %+v", r)
+	}
+
+	rr := httptest.NewRecorder()
+	livezHandler(JSONEncodingConfig{}, time.Now())(rr, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"alive"`) {
+		t.Fatalf("livez: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	livezHandler(JSONEncodingConfig{}, time.Now())(rr, httptest.NewRequest(http.MethodPost, "/livez", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("livez POST: %d", rr.Code)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSSEHeartbeat = 15 * time.Second
+	minSSEHeartbeat     = time.Second
+	maxSSEHeartbeat     = time.Minute
+	// heartbeatMemory is how long a shortened interval outlives the last
+	// drop on its path before the path goes back to the default.
+	heartbeatMemory = time.Hour
+)
+
+// heartbeatTuner picks the SSE heartbeat interval per network path. When a
+// heartbeat write fails, something on the path closed the idle stream, so
+// later streams on that path beat at half the idle time that was lost.
+type heartbeatTuner struct {
+	mu    sync.Mutex
+	paths map[string]*heartbeatPath
+	// trusted are the reverse proxies, from mcpProxy.trustedProxies, whose
+	// X-Forwarded-For names the client; other peers' is ignored.
+	trusted []netip.Prefix
+}
+
+type heartbeatPath struct {
+	interval   time.Duration
+	lastDropAt time.Time
+}
+
+// newHeartbeatTuner builds a tuner trusting X-Forwarded-For from
+// trustedProxies, addresses or CIDR prefixes.
+func newHeartbeatTuner(trustedProxies []string) (*heartbeatTuner, error) {
+	t := &heartbeatTuner{paths: make(map[string]*heartbeatPath)}
+	for _, entry := range trustedProxies {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry)
+			if addrErr != nil {
+				return nil, fmt.Errorf("trustedProxies: %q is not an address or CIDR prefix", entry)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		t.trusted = append(t.trusted, prefix.Masked())
+	}
+	return t, nil
+}
+
+// interval returns the heartbeat for a new stream on path. A client hint,
+// clamped to a sane range, replaces the default but never lengthens an
+// interval learned from drops.
+func (t *heartbeatTuner) interval(path string, hint time.Duration, now time.Time) time.Duration {
+	interval, learned := defaultSSEHeartbeat, false
+	if t != nil {
+		t.mu.Lock()
+		if p := t.paths[path]; p != nil {
+			if now.Sub(p.lastDropAt) > heartbeatMemory {
+				delete(t.paths, path)
+			} else {
+				interval, learned = p.interval, true
+			}
+		}
+		t.mu.Unlock()
+	}
+	if hint > 0 {
+		hint = min(max(hint, minSSEHeartbeat), maxSSEHeartbeat)
+		if !learned || hint < interval {
+			interval = hint
+		}
+	}
+	return interval
+}
+
+// dropped records that a stream on path failed a write after idle without
+// output, and returns the interval later streams on the path will use.
+// Paths whose last drop is older than heartbeatMemory are forgotten.
+func (t *heartbeatTuner) dropped(path string, idle time.Duration, now time.Time) time.Duration {
+	if t == nil {
+		return defaultSSEHeartbeat
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, p := range t.paths {
+		if now.Sub(p.lastDropAt) > heartbeatMemory {
+			delete(t.paths, key)
+		}
+	}
+	p := t.paths[path]
+	if p == nil {
+		p = &heartbeatPath{interval: defaultSSEHeartbeat}
+		t.paths[path] = p
+	}
+	p.interval = max(min(p.interval, idle/2), minSSEHeartbeat)
+	p.lastDropAt = now
+	return p.interval
+}
+
+// pathKey names the network path a stream arrived over: the peer, or,
+// when the peer is a trusted proxy, the nearest X-Forwarded-For hop that
+// is not one.
+func (t *heartbeatTuner) pathKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if t == nil || !t.trusts(host) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		host = hop
+		if !t.trusts(hop) {
+			break
+		}
+	}
+	return host
+}
+
+func (t *heartbeatTuner) trusts(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepaliveHint reads the client's ?keepalive= hint, a Go duration ("5s")
+// or a number of seconds. It returns 0 without a valid hint.
+func keepaliveHint(r *http.Request) time.Duration {
+	raw := r.URL.Query().Get("keepalive")
+	if raw == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(raw); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return d
+	}
+	return 0
+}
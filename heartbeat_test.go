@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatTuner(t *testing.T) {
+	tuner, _ := newHeartbeatTuner(nil)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if got := tuner.interval("10.0.0.1", 0, now); got != defaultSSEHeartbeat {
+		t.Fatalf("default interval = %s", got)
+	}
+	if got := tuner.interval("10.0.0.1", 100*time.Millisecond, now); got != minSSEHeartbeat {
+		t.Fatalf("hint not clamped: %s", got)
+	}
+	if got := tuner.interval("10.0.0.1", 30*time.Second, now); got != 30*time.Second {
+		t.Fatalf("hint without drops = %s", got)
+	}
+
+	if next := tuner.dropped("10.0.0.1", 15*time.Second, now); next != 7500*time.Millisecond {
+		t.Fatalf("interval after a drop = %s", next)
+	}
+	if next := tuner.dropped("10.0.0.1", 7500*time.Millisecond, now); next != 3750*time.Millisecond {
+		t.Fatalf("interval after a second drop = %s", next)
+	}
+	if got := tuner.interval("10.0.0.1", 30*time.Second, now); got != 3750*time.Millisecond {
+		t.Fatalf("a hint lengthened a learned interval: %s", got)
+	}
+	if got := tuner.interval("10.0.0.2", 0, now); got != defaultSSEHeartbeat {
+		t.Fatalf("drops leaked to another path: %s", got)
+	}
+	if got := tuner.interval("10.0.0.1", 0, now.Add(2*heartbeatMemory)); got != defaultSSEHeartbeat {
+		t.Fatalf("learned interval did not expire: %s", got)
+	}
+
+	// a drop forgets stale paths never asked about again
+	tuner.dropped("198.51.100.1", time.Minute, now)
+	tuner.dropped("198.51.100.2", time.Minute, now.Add(2*heartbeatMemory))
+	if len(tuner.paths) != 1 || tuner.paths["198.51.100.2"] == nil {
+		t.Fatalf("paths after a later drop = %v", tuner.paths)
+	}
+}
+
+func TestHeartbeatRequestHelpers(t *testing.T) {
+	r := httptest.NewRequest("GET", "/mcp?keepalive=5", nil)
+	r.RemoteAddr = "192.0.2.7:5123"
+	if got := keepaliveHint(r); got != 5*time.Second {
+		t.Fatalf("numeric hint = %s", got)
+	}
+	tuner, err := newHeartbeatTuner([]string{"192.0.2.1", "10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tuner.pathKey(r); got != "192.0.2.7" {
+		t.Fatalf("path = %s", got)
+	}
+	r = httptest.NewRequest("GET", "/mcp?keepalive=2500ms", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.4, 203.0.113.9, 10.0.0.1")
+	if got := keepaliveHint(r); got != 2500*time.Millisecond {
+		t.Fatalf("duration hint = %s", got)
+	}
+	if got := tuner.pathKey(r); got != "203.0.113.9" {
+		t.Fatalf("forwarded path = %s", got)
+	}
+	r.RemoteAddr = "192.0.2.7:5123"
+	if got := tuner.pathKey(r); got != "192.0.2.7" {
+		t.Fatalf("forwarded path from an untrusted peer = %s", got)
+	}
+	if _, err := newHeartbeatTuner([]string{"proxy.internal"}); err == nil {
+		t.Fatal("expected a host name to be rejected")
+	}
+	if got := keepaliveHint(httptest.NewRequest("GET", "/mcp?keepalive=soon", nil)); got != 0 {
+		t.Fatalf("invalid hint = %s", got)
+	}
+}
@@ -1,20 +1,21 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"path"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -23,6 +24,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -37,28 +40,39 @@ func chainMiddleware(h http.Handler, middlewares ...MiddlewareFunc) http.Handler
 	return h
 }
 
-func newAuthMiddleware(tokens []string) MiddlewareFunc {
-	tokenSet := make(map[string]struct{}, len(tokens))
-	for _, token := range tokens {
-		tokenSet[token] = struct{}{}
-	}
+// newAuthMiddleware admits requests bearing one of tokens or, with jwt set,
+// a JWT it validates.
+func newAuthMiddleware(tokens routeTokens, jwt *jwtValidator) MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// allow internal re-entry from the facade
-			if r.Header.Get("X-Proxy-Internal") == "1" {
+			// already admitted, e.g. by a client certificate
+			if _, ok := oauthTokenFromContext(r.Context()); ok {
 				next.ServeHTTP(w, r)
 				return
 			}
-			if len(tokens) != 0 {
+			if tokens.enforced() || jwt != nil {
 				token := r.Header.Get("Authorization")
 				token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
 				if token == "" {
 					http.Error(w, "Unauthorized", http.StatusUnauthorized)
 					return
 				}
-				if _, ok := tokenSet[token]; !ok {
-					http.Error(w, "Unauthorized", http.StatusUnauthorized)
-					return
+				if !tokens.accepts(token) {
+					if jwt == nil || !looksLikeJWT(token) {
+						http.Error(w, "Unauthorized", http.StatusUnauthorized)
+						return
+					}
+					tok, err := jwt.validate(r.Context(), token)
+					switch {
+					case errors.Is(err, errOAuthInvalidToken):
+						http.Error(w, "Unauthorized", http.StatusUnauthorized)
+						return
+					case err != nil:
+						logger("auth").Error("jwt validation failed", "err", err)
+						http.Error(w, "Authorization server unavailable", http.StatusServiceUnavailable)
+						return
+					}
+					r = r.WithContext(withOAuthToken(r.Context(), tok))
 				}
 			}
 			next.ServeHTTP(w, r)
@@ -69,21 +83,7 @@ func newAuthMiddleware(tokens []string) MiddlewareFunc {
 func loggerMiddleware(prefix string) MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("<%s> %s %s", prefix, r.Method, r.URL.Path)
-			next.ServeHTTP(w, r)
-		})
-	}
-}
-
-func recoverMiddleware(prefix string) MiddlewareFunc {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					log.Printf("<%s> panic: %v", prefix, err)
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				}
-			}()
+			routeLogger(prefix).Debug("request", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "headers", redactHeaders(r.Header))
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -101,11 +101,19 @@ func routeFor(basePath, name string) string {
 	return route
 }
 
-// capture-and-defer writer for internal mux re-entry
+// capture-and-defer writer for in-process dispatch to a server's client
+// and facade re-entry (batches, stdio, WebSocket); large bodies spill to
+// disk (see spillBuffer)
 type responseRecorder struct {
 	HeaderMap  http.Header
-	Body       bytes.Buffer
+	Body       spillBuffer
 	StatusCode int
+	// digest caches Digest until the next Write.
+	digest *responseDigest
+	// streamed is set for a result sent to the client as it was encoded
+	// (see resultStream), sent bytes long; Body is then empty.
+	streamed bool
+	sent     int
 }
 
 func newResponseRecorder() *responseRecorder {
@@ -115,9 +123,35 @@ func newResponseRecorder() *responseRecorder {
 	}
 }
 
-func (rr *responseRecorder) Header() http.Header         { return rr.HeaderMap }
-func (rr *responseRecorder) WriteHeader(statusCode int)  { rr.StatusCode = statusCode }
-func (rr *responseRecorder) Write(b []byte) (int, error) { return rr.Body.Write(b) }
+func (rr *responseRecorder) Header() http.Header        { return rr.HeaderMap }
+func (rr *responseRecorder) WriteHeader(statusCode int) { rr.StatusCode = statusCode }
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.digest = nil
+	return rr.Body.Write(b)
+}
+
+// Digest summarizes the recorded body for the call policies. The body is
+// read once however many policies ask, and a spilled one is not loaded.
+func (rr *responseRecorder) Digest() responseDigest {
+	if rr.digest == nil {
+		d := digestResponse(rr.Body.Reader())
+		rr.digest = &d
+	}
+	return *rr.digest
+}
+
+// Streamed reports whether the response already went to the client.
+func (rr *responseRecorder) Streamed() bool { return rr.streamed }
+
+// Len is the size of the response body, recorded or streamed.
+func (rr *responseRecorder) Len() int {
+	if rr.streamed {
+		return rr.sent
+	}
+	return rr.Body.Len()
+}
+
+// FlushTo writes the recorded response to w and releases a spilled body.
 func (rr *responseRecorder) FlushTo(w http.ResponseWriter) {
 	for k, vv := range rr.HeaderMap {
 		for _, v := range vv {
@@ -128,7 +162,8 @@ func (rr *responseRecorder) FlushTo(w http.ResponseWriter) {
 		w.Header().Set("Content-Type", "application/json")
 	}
 	w.WriteHeader(rr.StatusCode)
-	_, _ = w.Write(rr.Body.Bytes())
+	_, _ = rr.Body.WriteTo(w)
+	rr.Body.Close()
 }
 
 type readinessSnapshot struct {
@@ -152,7 +187,7 @@ func emitReadinessEvent(w http.ResponseWriter, flusher http.Flusher) bool {
 	}
 	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("<facade> failed to marshal readiness payload: %v", err)
+		logger("facade").Error("failed to marshal readiness payload", "err", err)
 		return false
 	}
 	fmt.Fprintf(w, "event: ready\ndata: %s\n\n", data)
@@ -160,7 +195,10 @@ func emitReadinessEvent(w http.ResponseWriter, flusher http.Flusher) bool {
 	return true
 }
 
-func handleSSE(w http.ResponseWriter, r *http.Request, endpoint string) {
+// handleSSE serves a facade event stream. Messages received on notices are
+// sent as JSON-RPC notifications; a nil channel sends none. heartbeats picks
+// the keepalive interval and learns from streams whose heartbeat failed.
+func handleSSE(w http.ResponseWriter, r *http.Request, endpoint string, notices <-chan []byte, heartbeats *heartbeatTuner) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("Connection", "keep-alive")
@@ -176,37 +214,20 @@ func handleSSE(w http.ResponseWriter, r *http.Request, endpoint string) {
 	_, _ = io.WriteString(w, ":\n\n")
 	flusher.Flush()
 
+	// HTTP+SSE clients learn where to POST from the endpoint event
 	if endpoint != "" {
-		if parsed, err := url.Parse(endpoint); err == nil {
-			mountPath := parsed.Path
-			if mountPath == "" {
-				mountPath = "/mcp"
-			}
-			session := parsed.Query().Get("sessionId")
-			if session == "" {
-				session = parsed.Query().Get("session_id")
-			}
-			if session != "" {
-				sessionHex := strings.ReplaceAll(session, "-", "")
-				if mountPath == "" || mountPath[0] != '/' {
-					mountPath = "/" + mountPath
-				}
-				endpointPath := fmt.Sprintf("%s?session_id=%s", mountPath, sessionHex)
-				fmt.Fprintf(w, "event: endpoint\ndata: %s\n\n", endpointPath)
-				flusher.Flush()
-				goto endpointDone
-			}
-		}
 		fmt.Fprintf(w, "event: endpoint\ndata: %s\n\n", endpoint)
 		flusher.Flush()
 	}
 
-endpointDone:
-
 	readyAnnounced := emitReadinessEvent(w, flusher)
 
-	ticker := time.NewTicker(15 * time.Second)
+	pathKey := heartbeats.pathKey(r)
+	interval := heartbeats.interval(pathKey, keepaliveHint(r), time.Now())
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	rc := http.NewResponseController(w)
+	lastWrite := time.Now()
 
 	var (
 		readyTicker *time.Ticker
@@ -225,9 +246,34 @@ endpointDone:
 				readyTicker.Stop()
 			}
 			return
-		case <-ticker.C:
-			_, _ = io.WriteString(w, ":\n\n")
+		case msg := <-notices:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
 			flusher.Flush()
+			lastWrite = time.Now()
+		case <-ticker.C:
+			_, err := io.WriteString(w, ":\n\n")
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil && r.Context().Err() != nil {
+				// the client went away; nothing to learn about the path
+				logger("facade").Debug("SSE stream closed by client", "path", pathKey, "err", err)
+				if readyTicker != nil {
+					readyTicker.Stop()
+				}
+				return
+			}
+			if err != nil {
+				// an intermediary dropped the idle stream
+				idle := time.Since(lastWrite)
+				next := heartbeats.dropped(pathKey, idle, time.Now())
+				logger("facade").Warn("SSE stream dropped", "path", pathKey, "idle", idle.Round(time.Millisecond), "heartbeat", interval, "nextHeartbeat", next, "err", err)
+				if readyTicker != nil {
+					readyTicker.Stop()
+				}
+				return
+			}
+			lastWrite = time.Now()
 			if !readyAnnounced {
 				if emitReadinessEvent(w, flusher) {
 					readyAnnounced = true
@@ -264,6 +310,7 @@ type jsonrpcRequest struct {
 type jsonrpcError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
 }
 
 type jsonrpcResponse struct {
@@ -289,7 +336,7 @@ func rpcOK(id any, result any) jsonrpcResponse {
 	}
 }
 
-func buildManifestDocument(
+func (p *proxy) buildManifestDocument(
 	manifestCfg *ManifestConfig,
 	baseURL *url.URL,
 	r *http.Request,
@@ -305,10 +352,10 @@ func buildManifestDocument(
 			Servers:       make(map[string]*toolOverrideFragment),
 		}
 	}
-	return buildManifestDocumentWithOverrides(manifestCfg, baseURL, r, tools, prompts, resources, templates, overrides)
+	return p.buildManifestDocumentWithOverrides(manifestCfg, baseURL, r, tools, prompts, resources, templates, overrides)
 }
 
-func buildManifestDocumentWithOverrides(
+func (p *proxy) buildManifestDocumentWithOverrides(
 	manifestCfg *ManifestConfig,
 	baseURL *url.URL,
 	r *http.Request,
@@ -349,6 +396,14 @@ func buildManifestDocumentWithOverrides(
 
 	endpointURL := (&url.URL{Scheme: requestScheme, Host: requestHost, Path: endpointPath}).String()
 
+	// servers are aggregated from a map; order what they contributed
+	prompts = slices.Clone(prompts)
+	sort.SliceStable(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	resources = slices.Clone(resources)
+	sort.SliceStable(resources, func(i, j int) bool { return resources[i].URI < resources[j].URI })
+	templates = slices.Clone(templates)
+	sort.SliceStable(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+
 	resourceEntries := make([]any, 0, len(manifestCfg.Resources)+len(resources))
 	for _, res := range manifestCfg.Resources {
 		resourceEntries = append(resourceEntries, res)
@@ -384,6 +439,9 @@ func buildManifestDocumentWithOverrides(
 	if _, ok := toolDescriptors[facadeFetchToolName]; !ok {
 		toolDescriptors[facadeFetchToolName] = fetchManifestDescriptor()
 	}
+	if _, ok := toolDescriptors[facadeFanOutToolName]; !ok && p.fanOut != nil {
+		toolDescriptors[facadeFanOutToolName] = applyToolOverride(facadeFanOutToolName, fanOutToolDescriptor(), overrides)
+	}
 	toolDescriptors[facadeSearchToolName] = applyToolOverride(facadeSearchToolName, toolDescriptors[facadeSearchToolName], overrides)
 	toolDescriptors[facadeFetchToolName] = applyToolOverride(facadeFetchToolName, toolDescriptors[facadeFetchToolName], overrides)
 
@@ -478,7 +536,7 @@ func resolveManifestVersion(config *Config, manifestCfg *ManifestConfig, doc map
 	return ""
 }
 
-func toolsListHTTPHandler(clientsReady *atomic.Bool, servers map[string]*Server, overrides *ToolOverrideSet, intended *catalogFile) http.HandlerFunc {
+func (p *proxy) toolsListHTTPHandler(clientsReady *atomic.Bool, servers func() map[string]*Server, overrides *toolOverrideStore, intended *catalogFile) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			w.Header().Set("Allow", http.MethodGet)
@@ -490,9 +548,9 @@ func toolsListHTTPHandler(clientsReady *atomic.Bool, servers map[string]*Server,
 			w.Header().Set("X-Proxy-Waited-For-Init", "true")
 		}
 
-		items := collectTools(servers, overrides, intended)
+		items := p.collectTools(servers(), overrides.current(), intended)
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]any{"tools": items})
+		_ = p.json.encode(w, map[string]any{"tools": items})
 	}
 }
 
@@ -546,11 +604,119 @@ func handleNotification(w http.ResponseWriter, req *jsonrpcRequest) bool {
 
 // ===== main HTTP server =====
 
+// proxyServeFunc exposes the assembled facade mux on some transport and
+// blocks until the proxy should shut down.
+type proxyServeFunc func(ctx context.Context, mux *http.ServeMux, mcpPath string) error
+
+// newListenerHandler serves mux on the main listener, compressing
+// responses per mcpProxy.compression. With grpcTools, gRPC requests are
+// split off ahead of compression, which leaves them alone either way.
+func newListenerHandler(mux http.Handler, conf *MCPProxyConfigV2, mcpPath string) (http.Handler, error) {
+	handler, err := newCompressionHandler(mux, conf.Compression)
+	if err != nil {
+		return nil, err
+	}
+	if conf.GRPCTools {
+		handler = newGRPCToolHandler(handler, mcpPath)
+	}
+	return handler, nil
+}
+
 func startHTTPServer(config *Config) error {
+	serverTLS, err := newProxyTLS(config.McpProxy.TLS, config.McpProxy.ACME)
+	if err != nil {
+		return err
+	}
+	http2Conf := config.McpProxy.HTTP2
+	if config.McpProxy.GRPCTools {
+		// gRPC needs HTTP/2, including h2c for plaintext clients
+		if http2Conf != nil && http2Conf.Enabled != nil && !*http2Conf.Enabled {
+			return errors.New("grpcTools needs HTTP/2; remove http2.enabled: false")
+		}
+		enabled := true
+		http2Conf = &HTTP2Config{Enabled: &enabled}
+		if config.McpProxy.HTTP2 != nil {
+			http2Conf.MaxConcurrentStreams = config.McpProxy.HTTP2.MaxConcurrentStreams
+		}
+	}
+	return runProxy(config, serverTLS, func(ctx context.Context, mux *http.ServeMux, mcpPath string) error {
+		handler, err := newListenerHandler(mux, config.McpProxy, mcpPath)
+		if err != nil {
+			return err
+		}
+		accessLog, err := newAccessLogger(config.McpProxy.AccessLog)
+		if err != nil {
+			return err
+		}
+		defer accessLog.Close()
+		if serverTLS != nil {
+			handler = serverTLS.clientAuth.middleware()(handler)
+		}
+		handler = accessLog.middleware()(handler)
+		httpServer := &http.Server{
+			Addr:    config.McpProxy.Addr,
+			Handler: handler,
+		}
+		configureHTTP2(httpServer, http2Conf)
+
+		go func() {
+			logger("proxy").Info("Starting server", "type", config.McpProxy.Type)
+			logger("proxy").Info("Server listening", "type", config.McpProxy.Type, "addr", config.McpProxy.Addr)
+			var err error
+			if serverTLS != nil {
+				httpServer.TLSConfig = serverTLS.config
+				err = httpServer.ListenAndServeTLS("", "")
+			} else {
+				err = httpServer.ListenAndServe()
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fatalStartup("ListenAndServe: %v", err)
+			}
+		}()
+		if serverTLS != nil && serverTLS.challenge != nil {
+			challenge := serverTLS.challenge
+			defer challenge.Close()
+			go func() {
+				logger("tls").Info("ACME challenge listener", "addr", challenge.Addr)
+				if err := challenge.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					fatalStartup("ACME challenge listener: %v", err)
+				}
+			}()
+		}
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+		logger("proxy").Info("Shutdown signal received")
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 5*time.Second)
+		defer cancelShutdown()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
+}
+
+// runProxy connects downstream servers and assembles the facade, per-server
+// routes, and admin API on one mux, then hands it to serve. serverTLS, when
+// set, is the main listener's TLS setup, shared with the gRPC admin listener.
+func runProxy(config *Config, serverTLS *proxyTLS, serve proxyServeFunc) error {
 	baseURL, uErr := url.Parse(config.McpProxy.BaseURL)
 	if uErr != nil {
 		return uErr
 	}
+	// before any component builds its redaction keys
+	configureRedaction(config.McpProxy.Redaction)
+	shutdownTracing, err := setupTracing(context.Background(), config.McpProxy.Tracing)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	px, err := newProxy(config.McpProxy, config.McpServers)
+	if err != nil {
+		return err
+	}
 
 	stateDir, err := requireHomePath(stateHome(), stateHome())
 	if err != nil {
@@ -583,45 +749,181 @@ func startHTTPServer(config *Config) error {
 		mcpPath = "/" + mcpPath
 	}
 
-	// all connected servers
-	servers := make(map[string]*Server)
-	var toolOverrides *ToolOverrideSet
+	// all configured servers; admin apply changes the set at runtime
+	servers := newServerRegistry(httpMux, baseURL.Path)
+	overrideStore := newToolOverrideStore(nil)
+
+	// in-flight dispatches, call metrics, SLOs + operator API
+	activeCalls := newActiveCallRegistry()
+	events := newEventBus(config.McpProxy.EventWebhooks)
+	pins := newSchemaPinMonitor(events)
+	catalogChanges := newCatalogJournal(events)
+	readOnly := newReadOnlyMode(config.McpProxy.ReadOnly)
+	callStats := newCallMetrics(defaultMetricsWindow)
+	sloTracker := newSLOTracker(config.McpProxy.SLOs, events)
+	admin := newAdminAPI(httpMux, baseURL.Path, config.McpProxy.AdminTokens, px.json)
+	registerActiveCallRoutes(admin, activeCalls)
+	registerSLORoutes(admin, sloTracker)
+	registerPayloadRoutes(admin, callStats)
+	flakiness := newFlakinessDetector(config.McpProxy.FlakinessDetection)
+	loops := newLoopDetector(config.McpProxy.LoopDetection)
+	deadTools := newDeadToolDetector(config.McpProxy.DeadTools)
+	registerDeadToolRoutes(admin, deadTools)
+	// observeDeadTool feeds a call outcome to the dead-tool detector and
+	// suspends or restores the tool as it dies or recovers.
+	observeDeadTool := func(server, tool string, args json.RawMessage, digest responseDigest, failed bool) {
+		state, died, revived := deadTools.observe(server, tool, args, digest, failed, time.Now())
+		switch {
+		case died:
+			logger("facade").Warn("dead tool", "server", server, "tool", tool, "failures", state.Failures, "since", state.FirstFailureAt.Format(time.RFC3339), "autoDisable", deadTools.autoDisable)
+			events.emit("tool.dead", map[string]any{
+				"server":         server,
+				"tool":           tool,
+				"failures":       state.Failures,
+				"firstFailureAt": state.FirstFailureAt,
+				"lastError":      state.LastError,
+				"autoDisabled":   deadTools.autoDisable,
+			})
+			if deadTools.autoDisable {
+				overrideStore.suspend(toolToggle{
+					Server: server,
+					Tool:   tool,
+					By:     "proxy:dead-tool",
+					At:     time.Now().UTC(),
+					Reason: fmt.Sprintf("%d consecutive failures; last: %s", state.Failures, state.LastError),
+				})
+			}
+		case revived:
+			logger("facade").Info("dead tool recovered", "server", server, "tool", tool)
+			overrideStore.resume(server, tool)
+			events.emit("tool.revived", map[string]any{"server": server, "tool": tool})
+		}
+	}
+	registerFlakinessRoutes(admin, flakiness)
+	slowCalls, err := newSlowCallLogger(config.McpProxy.SlowCalls)
+	if err != nil {
+		return err
+	}
+	wireLog, err := newWireLogger(config.McpProxy.WireLog)
+	if err != nil {
+		return err
+	}
+	audit, err := newAuditLog(config.McpProxy.Audit)
+	if err != nil {
+		return err
+	}
+	notifications := newNotificationFilter(config.McpProxy.NotificationRules)
+	registerNotificationRoutes(admin, notifications)
+	violations := newProtocolViolations()
+	registerViolationRoutes(admin, violations)
+	if err := panicStats.configure(config.McpProxy.PanicReports); err != nil {
+		return err
+	}
+	registerPanicRoutes(admin, panicStats)
+	if rpcErrors, err = newRPCErrorRegistry(config.McpProxy.Errors); err != nil {
+		return err
+	}
+	if messages, err = newMessageCatalog(config.McpProxy.Messages); err != nil {
+		return err
+	}
+	if rpcErrors, err = rpcErrors.withLocales(config.McpProxy.Messages); err != nil {
+		return err
+	}
+	oauth, err := newOAuthResource(config.McpProxy.OAuth)
+	if err != nil {
+		return err
+	}
+	scopes, err := newToolScopes(config.McpProxy.ToolScopes)
+	if err != nil {
+		return err
+	}
+	parser, err := newRPCParser(config.McpProxy.RequestLimits)
+	if err != nil {
+		return err
+	}
+	cors, err := newCORSPolicy(config.McpProxy.CORS)
+	if err != nil {
+		return err
+	}
+	apiTokens, err := newAPITokenStore(config.McpProxy.APITokens, filepath.Join(stateDir, "api_tokens.json"))
+	if err != nil {
+		return err
+	}
+	if oauth != nil {
+		oauth.register(httpMux)
+	}
+	px.replicas.groupOf = func(server string) string {
+		if clientConfig := servers.config(server); clientConfig != nil {
+			return clientConfig.Group
+		}
+		return ""
+	}
+	px.replicas.affinityOf = func(server string) *SessionAffinityConfig {
+		if clientConfig := servers.config(server); clientConfig != nil && clientConfig.Options != nil {
+			return clientConfig.Options.SessionAffinity
+		}
+		return nil
+	}
+	if config.McpProxy.ResponseSpillThreshold != 0 {
+		responseSpillThreshold = config.McpProxy.ResponseSpillThreshold
+	}
+	clearSpillDir()
+	fetcher := newURLFetcher(config.McpProxy.Fetch)
+	backpressure := newBackpressureGate(config.McpProxy.Backpressure)
+	streams := newSessionStreams()
+	progress := newProgressRelay(streams)
+	clientCalls := newClientRequests(streams)
+	sampling := newSamplingBridge(config.McpProxy.Sampling, clientCalls)
+	roots := newRootsBridge(config.McpProxy.Roots, clientCalls)
+	elicitation := newElicitationBridge(config.McpProxy.Elicitation, clientCalls)
+	sseHeartbeats, err := newHeartbeatTuner(config.McpProxy.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	breakers := newCircuitBreaker(config.McpProxy.CircuitBreaker, events)
+	retries := newRetryPolicy(config.McpProxy.Retries)
+	healthChecks := newHealthMonitor(config.McpProxy.HealthChecks, events)
+	serverErrors := newServerErrorHistory(serverErrorHistoryLimit)
+	healthChecks.circuits = breakers
+	px.replicas.healthy = func(server string) bool {
+		return healthChecks.report([]string{server}, servers.connected)[0].Status == serverStatusConnected
+	}
+	supervisor := newServerSupervisor(ctx, config.McpProxy.Reconnect, servers, events)
+	probes, err := newProbeRunner(config.McpProxy.Probes, func(ctx context.Context, server string, body []byte) ([]byte, int) {
+		rr, status := px.dispatchToClient(ctx, servers.get(server), body)
+		return rr.Body.Bytes(), status
+	}, callStats, events)
+	if err != nil {
+		return err
+	}
+	registerProbeRoutes(admin, probes)
 
 	// catalog indexes (name/uri -> serverName) + readiness state
 	var (
-		indexMu       sync.RWMutex
-		toolIndex     = make(map[string]string)
-		promptIndex   = make(map[string]string)
-		resourceIndex = make(map[string]string)
-		clientsReady  atomic.Bool
+		indexMu      sync.RWMutex
+		index        = px.indexCatalog(nil, nil)
+		clientsReady atomic.Bool
 	)
 
 	// helper to rebuild index from current servers
+	// catalogServers is the servers as the catalog lists them, with
+	// mcpProxy.virtualServers composed in.
+	catalogServers := func() map[string]*Server {
+		return px.virtual.compose(servers.snapshot())
+	}
 	rebuildIndex := func() {
-		tmpTools := make(map[string]string)
-		tmpPrompts := make(map[string]string)
-		tmpResources := make(map[string]string)
-		for name, srv := range servers {
-			for _, t := range srv.tools {
-				tmpTools[t.Name] = name
-				if toolOverrides != nil {
-					if alias, ok := toolOverrides.AliasForTool(t.Name); ok {
-						tmpTools[alias] = name
-					}
-				}
-			}
-			for _, p := range srv.prompts {
-				tmpPrompts[p.Name] = name
-			}
-			for _, res := range srv.resources {
-				tmpResources[res.URI] = name
-			}
-		}
+		snapshot := servers.snapshot()
+		next := px.indexCatalog(snapshot, overrideStore.current())
 		indexMu.Lock()
-		toolIndex = tmpTools
-		promptIndex = tmpPrompts
-		resourceIndex = tmpResources
+		index = next
 		indexMu.Unlock()
+		pins.check(snapshot, overrideStore.current())
+		if clientsReady.Load() {
+			catalogChanges.observe(snapshot, time.Now())
+		}
+	}
+	if supervisor != nil {
+		supervisor.changed = rebuildIndex
 	}
 
 	// ---- manifest handler (single public endpoint) ----
@@ -635,7 +937,7 @@ func startHTTPServer(config *Config) error {
 	}
 	if manifestCfg.ToolOverridesPath != "" {
 		if guarded, err := resolveGuardedPath(manifestCfg.ToolOverridesPath); err != nil {
-			log.Printf("<manifest> rejecting toolOverridesPath outside config/state home: %v", err)
+			logger("manifest").Error("rejecting toolOverridesPath outside config/state home", "err", err)
 			manifestCfg.ToolOverridesPath = ""
 		} else {
 			manifestCfg.ToolOverridesPath = guarded
@@ -643,81 +945,108 @@ func startHTTPServer(config *Config) error {
 	}
 	if manifestCfg.ToolSchemaStatusPath != "" {
 		if guarded, err := resolveGuardedPath(manifestCfg.ToolSchemaStatusPath); err != nil {
-			log.Printf("<manifest> rejecting toolSchemaStatusPath outside config/state home: %v", err)
+			logger("manifest").Error("rejecting toolSchemaStatusPath outside config/state home", "err", err)
 			manifestCfg.ToolSchemaStatusPath = ""
 		} else {
 			manifestCfg.ToolSchemaStatusPath = guarded
 		}
 	}
-	if len(manifestCfg.ToolOverrides) > 0 {
-		toolOverrides = &ToolOverrideSet{
-			ToolOverrides: copyToolOverrideMap(manifestCfg.ToolOverrides),
-			Servers:       make(map[string]*toolOverrideFragment),
-			Aliases:       make(map[string]string),
-			Renamed:       make(map[string]string),
-		}
-		sanitizeToolOverrideSet(toolOverrides)
-	}
-	if manifestCfg.ToolOverridesPath != "" {
-		if fileOverrides, err := loadToolOverridesFromPath(manifestCfg.ToolOverridesPath); err != nil {
-			log.Printf("<manifest> failed to load tool overrides from %s: %v", manifestCfg.ToolOverridesPath, err)
+	if manifestCfg.ToolDocsDir != "" {
+		if guarded, err := resolveGuardedPath(manifestCfg.ToolDocsDir); err != nil {
+			logger("manifest").Error("rejecting toolDocsDir outside config/state home", "err", err)
+			manifestCfg.ToolDocsDir = ""
 		} else {
-			toolOverrides = mergeOverrideSets(toolOverrides, fileOverrides)
+			manifestCfg.ToolDocsDir = guarded
 		}
 	}
-	if toolOverrides != nil {
-		for _, msg := range toolOverrides.Warnings {
-			log.Printf("<manifest> %s", msg)
+	initialOverrides, err := loadManifestToolOverrides(manifestCfg)
+	if err != nil {
+		logger("manifest").Error("failed to load tool overrides", "err", err)
+	}
+	if initialOverrides != nil {
+		for _, msg := range initialOverrides.Warnings {
+			logger("manifest").Warn(msg)
 		}
+		startupDiag.addWarnings(initialOverrides.Warnings)
+	}
+	overrideStore.replaceBase(initialOverrides)
+	if err := overrideStore.loadState(defaultToolStatePath()); err != nil {
+		logger("manifest").Error("failed to restore tool state", "err", err)
 	}
 	if useIntendedCatalog {
 		intendedPath, pathErr := requireHomePath(stateDir, filepath.Join(stateDir, "intended_catalog.json"))
 		if pathErr != nil {
-			log.Printf("<catalog> STELAE_USE_INTENDED_CATALOG=1 rejected intended catalog path: %v", pathErr)
+			logger("catalog").Error("STELAE_USE_INTENDED_CATALOG=1 rejected intended catalog path", "err", pathErr)
 		} else if loaded, loadErr := loadCatalogFile(intendedPath); loadErr != nil {
-			log.Printf("<catalog> STELAE_USE_INTENDED_CATALOG=1 but failed to load %s: %v", intendedPath, loadErr)
+			logger("catalog").Error("STELAE_USE_INTENDED_CATALOG=1 but failed to load intended catalog", "path", intendedPath, "err", loadErr)
 		} else {
 			intendedCatalog = loaded
-			log.Printf("<catalog> using intended catalog %s (tools=%d)", intendedPath, len(loaded.ToolsByName))
+			logger("catalog").Info("using intended catalog", "path", intendedPath, "tools", len(loaded.ToolsByName))
 		}
 		if intendedCatalog == nil {
-			log.Printf("<catalog> STELAE_USE_INTENDED_CATALOG=1 but intended catalog unavailable, using runtime overrides")
+			logger("catalog").Warn("STELAE_USE_INTENDED_CATALOG=1 but intended catalog unavailable, using runtime overrides")
 		}
 	}
 
-	httpMux.HandleFunc("/.well-known/mcp/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+	httpMux.Handle("/.well-known/mcp/manifest.json", cors.middleware("manifest")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		allTools := make([]mcp.Tool, 0)
 		allPrompts := make([]mcp.Prompt, 0)
 		allResources := make([]mcp.Resource, 0)
 		allResourceTemplates := make([]mcp.ResourceTemplate, 0)
+		toolOverrides := overrideStore.current()
+		snapshot := catalogServers()
+		enabled := make([]string, 0, len(snapshot))
 
-		for name, srv := range servers {
+		for _, name := range sortedServerNames(snapshot) {
+			srv := snapshot[name]
 			if !serverEnabled(toolOverrides, name) {
 				continue
 			}
-			for _, tool := range srv.tools {
-				if !toolEnabled(toolOverrides, name, tool.Name) {
-					continue
-				}
-				allTools = append(allTools, tool)
-			}
+			enabled = append(enabled, name)
 			allPrompts = append(allPrompts, srv.prompts...)
 			allResources = append(allResources, srv.resources...)
 			allResourceTemplates = append(allResourceTemplates, srv.resourceTemplates...)
 		}
 
-		doc := buildManifestDocumentWithOverrides(manifestCfg, baseURL, r, allTools, allPrompts, allResources, allResourceTemplates, toolOverrides)
+		for _, et := range px.conflicts.exposedTools(snapshot, func(server string, tool mcp.Tool) bool {
+			return serverEnabled(toolOverrides, server) && toolEnabled(toolOverrides, server, tool.Name)
+		}) {
+			allTools = append(allTools, et.tool)
+		}
+
+		doc := px.buildManifestDocumentWithOverrides(manifestCfg, baseURL, r, allTools, allPrompts, allResources, allResourceTemplates, toolOverrides)
 		doc["servers"] = manifestServerEntries(config, manifestCfg, doc)
+		if entries := doc["servers"].([]map[string]any); len(entries) > 0 {
+			doc["servers"] = append(entries, px.virtual.manifestEntries(entries[0], snapshot)...)
+		}
+		stelae := map[string]any{"errors": rpcErrors.manifestEntries()}
+		if provenance := serverProvenance(snapshot, enabled); provenance != nil {
+			stelae["provenance"] = provenance
+		}
+		doc["x-stelae"] = stelae
 
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(doc)
-	})
+		_ = px.json.encode(w, doc)
+	})))
 
 	toolsPath := path.Join(baseURL.Path, "tools/list")
 	if !strings.HasPrefix(toolsPath, "/") {
 		toolsPath = "/" + toolsPath
 	}
-	httpMux.HandleFunc(toolsPath, toolsListHTTPHandler(&clientsReady, servers, toolOverrides, intendedCatalog))
+	httpMux.Handle(toolsPath, cors.middleware("tools")(px.toolsListHTTPHandler(&clientsReady, catalogServers, overrideStore, intendedCatalog)))
+	httpMux.HandleFunc("/healthz", healthzHandler(px.json, clientsReady.Load, servers.names, servers.connected, healthChecks))
+	httpMux.HandleFunc("/readyz", readyzHandler(px.json, config.McpProxy.Readiness, readyState.Load, servers.names, servers.connected, healthChecks))
+	httpMux.HandleFunc("/livez", livezHandler(px.json, time.Now()))
+	if conf := config.McpProxy.Metrics; conf == nil || !conf.Disabled {
+		metricsPath, tokens := defaultMetricsPath, []string(nil)
+		if conf != nil {
+			tokens = conf.AuthTokens
+			if conf.Path != "" {
+				metricsPath = conf.Path
+			}
+		}
+		httpMux.HandleFunc(metricsPath, metricsHandler(promMetrics, tokens))
+	}
 
 	streamPath := path.Join(baseURL.Path, "stream")
 	if !strings.HasPrefix(streamPath, "/") {
@@ -774,211 +1103,1061 @@ func startHTTPServer(config *Config) error {
 			diagMu.RUnlock()
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(status)
-			_ = json.NewEncoder(w).Encode(resp)
+			_ = px.json.encode(w, resp)
 		})
 	}
 
 	// ---- build servers and mount per-server handlers ----
 	info := mcp.Implementation{Name: config.McpProxy.Name}
 
-	for name, clientConfig := range config.McpServers {
-		mcpClient, err := newMCPClient(name, clientConfig)
+	// connectServer starts a client, fills its server's catalog, and returns
+	// the handler for the server's route.
+	connectServer := func(name string, entry *serverEntry) (http.Handler, error) {
+		clientLogger(name).Info("Connecting")
+		serverCtx, cancelServer := context.WithCancel(ctx)
+		entry.cancel = cancelServer
+		healthChecks.reset(name)
+		entry.client.pingInterval = healthChecks.interval
+		entry.client.pingTimeout = healthChecks.timeout
+		entry.client.onPing = func(latency time.Duration, err error) {
+			if err != nil {
+				serverErrors.record(name, serverErrorPing, err.Error(), time.Now())
+			}
+			if healthChecks.record(name, latency, err, time.Now()) == serverStatusDown {
+				entry.client.disconnected(err)
+			}
+		}
+		entry.client.setDisconnectHandler(func(err error) {
+			if err != nil {
+				serverErrors.record(name, serverErrorDisconnect, err.Error(), time.Now())
+			}
+			supervisor.lost(name, entry, err)
+		})
+		entry.client.client.OnConnectionLost(entry.client.disconnected)
+		entry.client.client.OnNotification(func(n mcp.JSONRPCNotification) {
+			if notifications.observe(name, n) && !progress.forward(n) {
+				notifications.relayUpdate(streams, name, n)
+			}
+		})
+		if err := entry.client.addToMCPServer(serverCtx, info, entry.server); err != nil {
+			serverErrors.record(name, serverErrorConnect, err.Error(), time.Now())
+			return nil, err
+		}
+		clientLogger(name).Info("Connected")
+
+		mws := []MiddlewareFunc{readOnly.middleware(px.json, entry.server, overrideStore), scopes.middleware(px.json, entry.server, overrideStore), parser.middleware(px.json, name), recoverMiddleware(name)}
+		if entry.config.Options.LogEnabled.OrElse(false) {
+			mws = append(mws, loggerMiddleware(name))
+		}
+		mws = append(mws, accessServerMiddleware(name))
+		tokens := newRouteTokens(entry.config.Options.AuthTokens, apiTokens, name)
+		if oauth != nil {
+			mws = append(mws, oauth.middleware(name, tokens))
+		} else if tokens.enforced() || entry.config.Options.JWT != nil {
+			jwt, err := sharedJWTValidator(entry.config.Options.JWT)
+			if err != nil {
+				return nil, err
+			}
+			mws = append(mws, newAuthMiddleware(tokens, jwt))
+		}
+		mws = append(mws, cors.middleware(name))
+		return chainMiddleware(entry.server.handler, mws...), nil
+	}
+	newServerEntry := func(name string, clientConfig *MCPClientConfigV2) (*serverEntry, error) {
+		mcpClient, err := newMCPClient(name, clientConfig, violations)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		server, err := newMCPServer(name, config.McpProxy, clientConfig)
 		if err != nil {
-			return err
+			_ = mcpClient.Close()
+			return nil, err
 		}
-		servers[name] = server
-
-		nameCopy := name
-		clientConfigCopy := clientConfig
-		mcpClientCopy := mcpClient
-		serverCopy := server
+		server.client = mcpClient
+		mcpClient.sampling = sampling.handler(name)
+		mcpClient.roots = roots.handler(name)
+		if clientConfig.Options.Elicitation.OrElse(true) {
+			mcpClient.elicitation = elicitation.handler(name)
+		}
+		return &serverEntry{server: server, client: mcpClient, config: clientConfig}, nil
+	}
 
-		eg.Go(func() error {
-			log.Printf("<%s> Connecting", nameCopy)
-			if addErr := mcpClientCopy.addToMCPServer(ctx, info, serverCopy); addErr != nil {
-				log.Printf("<%s> Failed to add client to server: %v", nameCopy, addErr)
-				if clientConfigCopy.Options.PanicIfInvalid.OrElse(false) {
-					return addErr
-				}
-				return nil
-			}
-			log.Printf("<%s> Connected", nameCopy)
+	connectFns := make(map[string]func() (bool, error), len(config.McpServers))
+	for name, clientConfig := range config.McpServers {
+		entry, err := newServerEntry(name, clientConfig)
+		if err != nil {
+			return err
+		}
+		servers.add(name, entry)
+		startupDiag.trackStderr(name, entry.client.stderr)
 
-			// add route for this server
-			mws := []MiddlewareFunc{recoverMiddleware(nameCopy)}
-			if clientConfigCopy.Options.LogEnabled.OrElse(false) {
-				mws = append(mws, loggerMiddleware(nameCopy))
+		connectFns[name] = func() (bool, error) {
+			handler, addErr := connectServer(name, entry)
+			if addErr == nil {
+				addErr = servers.activate(name, handler)
 			}
-			if len(clientConfigCopy.Options.AuthTokens) > 0 {
-				mws = append(mws, newAuthMiddleware(clientConfigCopy.Options.AuthTokens))
+			if addErr != nil {
+				clientLogger(name).Error("Failed to add client to server", "err", addErr)
+				startupDiag.serverFailed(name, addErr)
+				if clientConfig.Options.PanicIfInvalid.OrElse(false) {
+					return false, addErr
+				}
+				return false, nil
 			}
-			mcpRoute := routeFor(baseURL.Path, nameCopy)
-			log.Printf("<%s> Handling requests at %s", nameCopy, mcpRoute)
-			httpMux.Handle(mcpRoute, chainMiddleware(serverCopy.handler, mws...))
 
 			// index catalog entries for this server
 			indexMu.Lock()
-			for _, t := range serverCopy.tools {
-				toolIndex[t.Name] = nameCopy
-				if toolOverrides != nil {
-					if alias, ok := toolOverrides.AliasForTool(t.Name); ok {
-						toolIndex[alias] = nameCopy
+			index.add(name, entry.server, overrideStore.current())
+			indexMu.Unlock()
+			pins.check(map[string]*Server{name: entry.server}, overrideStore.current())
+
+			return true, nil
+		}
+	}
+
+	// connect servers stage by stage so dependsOn targets are up first
+	stages, err := startupStages(config.McpServers)
+	if err != nil {
+		return err
+	}
+	eg.Go(func() error {
+		var connected sync.Map
+		for i, stage := range stages {
+			if len(stages) > 1 {
+				logger("startup").Info("stage", "stage", i+1, "stages", len(stages), "servers", strings.Join(stage, ", "))
+			}
+			var wg sync.WaitGroup
+			for _, name := range stage {
+				var unmet []string
+				for _, dep := range config.McpServers[name].DependsOn {
+					if _, ok := connected.Load(dep); !ok {
+						unmet = append(unmet, dep)
 					}
 				}
+				if len(unmet) > 0 {
+					clientLogger(name).Warn("Skipping startup: dependencies not connected", "unmet", strings.Join(unmet, ", "))
+					continue
+				}
+				connect := connectFns[name]
+				wg.Add(1)
+				eg.Go(func() error {
+					defer wg.Done()
+					ok, err := connect()
+					if ok {
+						connected.Store(name, true)
+					}
+					return err
+				})
 			}
-			for _, p := range serverCopy.prompts {
-				promptIndex[p.Name] = nameCopy
-			}
-			for _, res := range serverCopy.resources {
-				resourceIndex[res.URI] = nameCopy
+			if !waitWithTimeout(&wg, config.McpProxy.StartupStageTimeout) {
+				logger("startup").Warn("stage timed out; continuing with servers connected so far", "stage", i+1, "timeout", config.McpProxy.StartupStageTimeout)
 			}
-			indexMu.Unlock()
+		}
+		return nil
+	})
 
-			return nil
+	// auto-disabled dead tools come back once a probe succeeds; only
+	// read-only tools are probed, by replaying their last failing call
+	if deadTools != nil && deadTools.autoDisable {
+		go deadTools.runProbes(ctx, func(state deadToolState) {
+			srv := servers.get(state.Server)
+			if hints, ok := resolveToolHints(srv, overrideStore.current(), state.Tool); !ok || !hints.ReadOnly {
+				return
+			}
+			params := map[string]any{"name": state.Tool}
+			if len(state.args) > 0 {
+				params["arguments"] = state.args
+			}
+			body, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": "dead-tool-probe", "method": "tools/call", "params": params})
+			probeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			rr, status := px.dispatchToClient(probeCtx, srv, body)
+			failed := status < 200 || status > 204 || rr.Digest().Failed
+			logger("facade").Info("dead tool probe", "server", state.Server, "tool", state.Tool, "failed", failed)
+			observeDeadTool(state.Server, state.Tool, state.args, rr.Digest(), failed)
+			rr.Body.Close()
 		})
 	}
 
 	// mark ready once all client goroutines return (success or tolerated failure)
 	go func() {
 		if err := eg.Wait(); err != nil {
-			log.Fatalf("Failed to initialize clients: %v", err)
+			fatalStartup("Failed to initialize clients: %v", err)
 		}
 		clientsReady.Store(true)
-		log.Printf("All clients initialized")
+		logger("proxy").Info("All clients initialized")
+		probes.run(ctx)
 		snapshot := &readinessSnapshot{
 			ReadyAt:     time.Now().UTC(),
 			ServerCount: len(config.McpServers),
 		}
 		readyState.Store(snapshot)
-		log.Printf("<facade> Ready: downstream servers=%d readyAt=%s", snapshot.ServerCount, snapshot.ReadyAt.Format(time.RFC3339Nano))
+		catalogChanges.observe(servers.snapshot(), snapshot.ReadyAt)
+		logger("facade").Info("Ready", "servers", snapshot.ServerCount, "readyAt", snapshot.ReadyAt.Format(time.RFC3339Nano))
 
 		if emitLiveCatalog {
 			now := time.Now().UTC()
-			liveCatalogSnapshot := buildLiveCatalogSnapshot(config, servers, toolOverrides, intendedCatalog, now)
-			if path, err := writeSnapshotWithHistory(stateDir, filepath.Join(stateDir, "live_catalog.json"), liveCatalogSnapshot, liveHistoryCount, now); err != nil {
-				log.Printf("<catalog> failed to write live catalog snapshot: %v", err)
+			liveCatalogSnapshot := px.buildLiveCatalogSnapshot(config, catalogServers(), overrideStore.current(), intendedCatalog, now)
+			if path, err := px.writeSnapshotWithHistory(stateDir, filepath.Join(stateDir, "live_catalog.json"), liveCatalogSnapshot, liveHistoryCount, now); err != nil {
+				logger("catalog").Error("failed to write live catalog snapshot", "err", err)
 			} else {
 				diagMu.Lock()
 				liveState.liveCatalog = liveCatalogSnapshot
 				liveState.liveCatalogPath = path
 				diagMu.Unlock()
-				log.Printf("<catalog> wrote live catalog snapshot to %s", path)
+				logger("catalog").Info("wrote live catalog snapshot", "path", path)
 			}
 
-			descriptorSnapshot := buildLiveDescriptorSnapshot(servers, now)
-			if path, err := writeSnapshotWithHistory(stateDir, filepath.Join(stateDir, "live_descriptors.json"), descriptorSnapshot, descriptorHistoryCount, now); err != nil {
-				log.Printf("<catalog> failed to write live descriptors snapshot: %v", err)
+			descriptorSnapshot := px.buildLiveDescriptorSnapshot(servers.snapshot(), now)
+			if path, err := px.writeSnapshotWithHistory(stateDir, filepath.Join(stateDir, "live_descriptors.json"), descriptorSnapshot, descriptorHistoryCount, now); err != nil {
+				logger("catalog").Error("failed to write live descriptors snapshot", "err", err)
 			} else {
 				diagMu.Lock()
 				liveState.liveDescriptors = descriptorSnapshot
 				liveState.liveDescriptorsPath = path
 				diagMu.Unlock()
-				log.Printf("<catalog> wrote live descriptors snapshot to %s", path)
+				logger("catalog").Info("wrote live descriptors snapshot", "path", path)
 			}
 		}
 	}()
 
-	// helper: try multiple internal POST targets for a server and return the first 2xx
-	tryDispatch := func(serverName string, body []byte, r *http.Request, rr *responseRecorder) (chosen string, status int) {
-		base := routeFor(baseURL.Path, serverName)
-		paths := []string{
-			path.Join(base, "mcp"),
-			base,                          // "/<name>/"
-			strings.TrimSuffix(base, "/"), // "/<name>"
-			path.Join(base, "message"),
-			path.Join(base, "messages"),
-			path.Join(base, "send"),
-			path.Join(base, "rpc"),
-			path.Join(base, "jsonrpc"),
-		}
-		for _, p := range paths {
-			ctxTimeout, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-			defer cancel()
-			r2 := r.Clone(ctxTimeout)
-			r2.Method = http.MethodPost
-			r2.URL = &url.URL{Path: p}
-			r2.RequestURI = ""
-			r2.Body = io.NopCloser(bytes.NewReader(body))
-			r2.Header = r.Header.Clone()
-			r2.Header.Set("X-Proxy-Internal", "1") // <— add this line
-			if r2.Header.Get("Content-Type") == "" {
-				r2.Header.Set("Content-Type", "application/json")
+	// catalogSnapshot returns the last emitted snapshot for a catalog resource,
+	// building a fresh one when STELAE_EMIT_LIVE_CATALOG is off or not yet ready.
+	catalogSnapshot := func(uri string) map[string]any {
+		diagMu.RLock()
+		catalog, descriptors := liveState.liveCatalog, liveState.liveDescriptors
+		diagMu.RUnlock()
+		now := time.Now().UTC()
+		if uri == liveDescriptorsResourceURI {
+			if descriptors != nil {
+				return descriptors
 			}
-
-			tmp := newResponseRecorder()
-			httpMux.ServeHTTP(tmp, r2)
-			if tmp.StatusCode >= 200 && tmp.StatusCode <= 204 {
-				*rr = *tmp
-				return p, tmp.StatusCode
+			return px.buildLiveDescriptorSnapshot(servers.snapshot(), now)
+		}
+		if catalog != nil {
+			return catalog
+		}
+		return px.buildLiveCatalogSnapshot(config, catalogServers(), overrideStore.current(), intendedCatalog, now)
+	}
+
+	// guards manifestCfg.ToolOverrides, which admin apply replaces
+	var manifestOverridesMu sync.Mutex
+	// applier changes the server set and overrides at runtime: admin apply
+	// and blue/green rollouts
+	applier := &serverApplier{
+		registry: servers,
+		store:    overrideStore,
+		resolve: func(desired map[string]*MCPClientConfigV2, overrides map[string]*ToolOverrideConfig) error {
+			if err := resolveServerConfigs(config, desired, overrides); err != nil {
+				return err
 			}
+			return px.checkServers(desired)
+		},
+		connect: func(name string, clientConfig *MCPClientConfigV2) (*serverEntry, error) {
+			entry, err := newServerEntry(name, clientConfig)
+			if err != nil {
+				return nil, err
+			}
+			handler, err := connectServer(name, entry)
+			if err != nil {
+				entry.close()
+				return nil, err
+			}
+			entry.handler = handler
+			startupDiag.trackStderr(name, entry.client.stderr)
+			return entry, nil
+		},
+		overrides: func() map[string]*ToolOverrideConfig {
+			manifestOverridesMu.Lock()
+			defer manifestOverridesMu.Unlock()
+			return manifestCfg.ToolOverrides
+		},
+		loadOverrides: func(desired map[string]*ToolOverrideConfig) (*ToolOverrideSet, error) {
+			manifestOverridesMu.Lock()
+			next := *manifestCfg
+			manifestOverridesMu.Unlock()
+			next.ToolOverrides = desired
+			return loadManifestToolOverrides(&next)
+		},
+		setOverrides: func(desired map[string]*ToolOverrideConfig) {
+			manifestOverridesMu.Lock()
+			manifestCfg.ToolOverrides = desired
+			manifestOverridesMu.Unlock()
+		},
+		applied: func(applyPlan) { rebuildIndex() },
+		index:   px.indexCatalog,
+	}
+	if supervisor != nil {
+		supervisor.connect = applier.connect
+	}
+
+	// facadeServers and facadeOverrides are what a facade request sees: the
+	// active config, or the rollout candidate's for sessions routed to it.
+	facadeServers := func(r *http.Request) map[string]*Server {
+		if c := rolloutFromContext(r.Context()); c != nil {
+			return c.servers
+		}
+		return servers.snapshot()
+	}
+	// facadeCatalog is facadeServers as the catalog lists them.
+	facadeCatalog := func(r *http.Request) map[string]*Server {
+		return px.virtual.compose(facadeServers(r))
+	}
+	facadeOverrides := func(r *http.Request) *ToolOverrideSet {
+		if c := rolloutFromContext(r.Context()); c != nil {
+			return applier.candidateOverrides(c)
 		}
-		// none matched; surface the best info from the last attempt
-		last := paths[len(paths)-1]
-		return last, http.StatusNotFound
+		return overrideStore.current()
 	}
 
-	// ---- /mcp facade ----
-	httpMux.HandleFunc(mcpPath, func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("<facade> %s %s?%s", r.Method, r.URL.Path, r.URL.RawQuery)
-		switch r.Method {
-		case http.MethodHead:
-			w.Header().Set("Content-Type", "text/event-stream")
-			w.Header().Set("Cache-Control", "no-store")
-			w.Header().Set("Connection", "keep-alive")
-			w.Header().Set("X-Accel-Buffering", "no")
-			w.Header().Set("mcp-session-id", uuid.New().String())
-			w.WriteHeader(http.StatusOK)
-			log.Printf("<facade> %s %s?%s -> %d", r.Method, r.URL.Path, r.URL.RawQuery, http.StatusOK)
-			return
+	// helper: stamp caller metadata into the forwarded body when the target server opts in
+	stampForServer := func(serverName string, body []byte, r *http.Request) []byte {
+		clientConfig := servers.config(serverName)
+		if clientConfig == nil || clientConfig.Options == nil {
+			return body
+		}
+		info, ok := callerFromContext(r.Context())
+		if !ok {
+			return body
+		}
+		return stampRequestMeta(body, info, clientConfig.Options.ContextStamping)
+	}
 
-		case http.MethodGet:
-			publicEndpoint := baseURL.ResolveReference(&url.URL{Path: path.Join(baseURL.Path, "mcp")})
-			sessionID := uuid.New().String()
-			messageEndpoint := fmt.Sprintf("%s?sessionId=%s", publicEndpoint.String(), sessionID)
-			w.Header().Set("mcp-session-id", sessionID)
-			log.Printf("<facade> SSE session=%s endpoint=%s", sessionID, messageEndpoint)
-			handleSSE(w, r, messageEndpoint)
-			log.Printf("<facade> %s %s?%s -> %d", r.Method, r.URL.Path, r.URL.RawQuery, http.StatusOK)
-			return
+	// helper: the server's options.timeout, or 0
+	serverCallTimeout := func(serverName string) time.Duration {
+		if clientConfig := servers.config(serverName); clientConfig != nil && clientConfig.Options != nil {
+			return clientConfig.Options.Timeout
+		}
+		return 0
+	}
 
-		case http.MethodPost:
-			body, _ := io.ReadAll(r.Body)
-			_ = r.Body.Close()
-			if len(body) == 0 {
-				body = []byte(`{}`)
-			}
-
-			// if batch, politely decline (facade can add later)
-			if len(body) > 0 && (body[0] == '[') {
-				var batch []jsonrpcRequest
-				if err := json.Unmarshal(body, &batch); err != nil {
-					http.Error(w, "Bad Request", http.StatusBadRequest)
-					log.Printf("<facade> %s %s?%s invalid batch: %v", r.Method, r.URL.Path, r.URL.RawQuery, err)
-					return
+	// helper: dispatch a facade request to its owning server under the call
+	// deadline, tracked in the active-call registry. policy carries the
+	// tool's configured timeout and whether failed attempts are retried.
+	// handled reports that an error response (timeout, operator
+	// cancellation, or protocol violation) was already written.
+	dispatchCall := func(w http.ResponseWriter, r *http.Request, req *jsonrpcRequest, body []byte, serverName, target string, policy callPolicy) (rr *responseRecorder, status int, handled bool) {
+		deadline := resolveCallDeadline(r, req.Params, config.McpProxy.MaxCallTimeout, serverCallTimeout(serverName), policy.timeout)
+		callCtx, cancelCall := deadline.context(r.Context())
+		defer cancelCall()
+		callCtx, span := tracer.Start(callCtx, "downstream "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(traceKeyMethod.String(req.Method), traceKeyServer.String(serverName), traceKeyTarget.String(target)))
+		spanStatus := "ok"
+		defer func() { endSpanWithStatus(span, spanStatus) }()
+		noteAccessServer(r.Context(), serverName)
+		callCtx, finishCall := activeCalls.begin(callCtx, req.Method, target, serverName)
+		defer finishCall()
+		defer px.replicas.begin(serverName)()
+
+		if ok, circuit := breakers.allow(serverName, time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(circuit.retryAfterSeconds(time.Now())))
+			w.Header().Set("Content-Type", "application/json")
+			_ = px.json.encode(w, withErrorData(localizedErrors(r.Context()).response(req.ID, errNameCircuitOpen, map[string]string{"server": serverName}), map[string]any{"retryAt": circuit.RetryAt, "failures": circuit.Failures}))
+			logger("facade").Warn("circuit open", "method", req.Method, "target", target, "server", serverName)
+			promDispatchOutcomes.inc(serverName, "circuit_open")
+			spanStatus = "circuit_open"
+			return nil, http.StatusServiceUnavailable, true
+		}
+		release, pressure, gateErr := backpressure.acquire(callCtx, serverName)
+		if pressure.State != "" {
+			w.Header().Set(backpressureHeader, pressure.header())
+		}
+		if errors.Is(gateErr, errServerBusy) {
+			breakers.record(serverName, callNeutral, time.Now())
+			w.Header().Set("Retry-After", "1")
+			w.Header().Set("Content-Type", "application/json")
+			_ = px.json.encode(w, withErrorData(localizedErrors(r.Context()).response(req.ID, errNameServerBusy, map[string]string{"server": serverName}), map[string]any{"inFlight": pressure.InFlight, "limit": pressure.Limit, "queued": pressure.Queued}))
+			logger("facade").Warn("server busy", "method", req.Method, "target", target, "server", serverName, "inflight", pressure.InFlight, "limit", pressure.Limit, "queued", pressure.Queued)
+			promDispatchOutcomes.inc(serverName, "busy")
+			spanStatus = "busy"
+			return nil, http.StatusServiceUnavailable, true
+		}
+		if gateErr != nil {
+			// the deadline or an operator ended the call while it queued
+			rr, status = newResponseRecorder(), http.StatusGatewayTimeout
+		} else {
+			// progress for the call reaches the caller's event stream
+			caller, _ := callerFromContext(r.Context())
+			tracked, untrack := progress.track(caller.SessionID, body)
+			defer untrack()
+			forwarded := stampForServer(serverName, tracked, r)
+			var stream *resultStream
+			attempt := 0
+			if policy.stream {
+				stream = &resultStream{w: w, ready: func(result *mcp.CallToolResult) bool {
+					// stream only a body no observer keeps, into a call
+					// that is still live
+					if callCtx.Err() != nil || audit != nil || wireLog.watches(serverName, target) || slowCalls.due(serverName, target, time.Since(deadline.StartedAt)) {
+						return false
+					}
+					w.Header().Set("X-Proxy-Dispatched-Server", serverName)
+					if attempt > 1 {
+						w.Header().Set("X-Proxy-Attempts", strconv.Itoa(attempt))
+					}
+					result.Meta = attachWarningsToMeta(r.Context(), result.Meta)
+					return true
+				}}
+			}
+			dispatch := func() (*responseRecorder, int) {
+				attempt++
+				return px.streamToClient(callCtx, facadeServers(r)[serverName], forwarded, stream)
+			}
+			if policy.retry {
+				var attempts int
+				rr, status, attempts = retries.run(callCtx, target, dispatch)
+				if attempts > 1 {
+					w.Header().Set("X-Proxy-Attempts", strconv.Itoa(attempts))
 				}
-				out := make([]jsonrpcResponse, 0, len(batch))
-				for _, req := range batch {
-					out = append(out, rpcError(req.ID, -32601, "Batch not supported by facade"))
+			} else {
+				rr, status = dispatch()
+			}
+			release()
+		}
+		w.Header().Set("X-Proxy-Dispatched-Server", serverName)
+
+		key := callKey{Server: serverName, Method: req.Method, Target: target}
+		observe := func(failed bool) {
+			now := time.Now()
+			elapsed := now.Sub(deadline.StartedAt)
+			sample := callSample{
+				At:            now,
+				Duration:      elapsed,
+				Failed:        failed,
+				RequestBytes:  len(body),
+				ResponseBytes: rr.Len(),
+			}
+			callStats.record(key, sample)
+			sloTracker.observe(key, sample)
+			// a spilled body stays on disk: the audit entry and the
+			// excerpts go without it
+			var response []byte
+			if !rr.Body.Spilled() {
+				response = rr.Body.Bytes()
+			}
+			slowCalls.observe(callCtx, req.Method, serverName, target, req.Params, response, elapsed)
+			wireLog.observe(callCtx, req.Method, serverName, target, body, response, elapsed)
+			entry := auditEntry{
+				At:         deadline.StartedAt,
+				Method:     req.Method,
+				Server:     serverName,
+				Target:     target,
+				DurationMs: elapsed.Milliseconds(),
+				Failed:     failed,
+				Status:     "ok",
+				Params:     req.Params,
+				Response:   response,
+			}
+			switch {
+			case cancelledByOperator(callCtx) && !rr.Streamed():
+				entry.Status = "cancelled"
+			case deadline.expired(callCtx) && !rr.Streamed():
+				entry.Status = "timeout"
+			case failed:
+				entry.Status = "error"
+			}
+			recordCallMetrics(key, entry.Status, elapsed)
+			spanStatus = entry.Status
+			if info, ok := callerFromContext(callCtx); ok {
+				entry.Caller, entry.SessionID, entry.RequestID = info.Identity, info.SessionID, info.RequestID
+			}
+			audit.record(entry)
+			applier.observeCall(callCtx, failed)
+		}
+
+		// a streamed result was sent complete, whatever the deadline says
+		// by the time it was written
+		switch {
+		case rr.Streamed():
+			breakers.record(serverName, dispatchOutcome(status, rr), time.Now())
+		case cancelledByOperator(callCtx):
+			breakers.record(serverName, callNeutral, time.Now())
+		case deadline.expired(callCtx):
+			breakers.record(serverName, callFailed, time.Now())
+		default:
+			breakers.record(serverName, dispatchOutcome(status, rr), time.Now())
+		}
+
+		switch {
+		case rr.Streamed():
+		case cancelledByOperator(callCtx):
+			observe(true)
+			w.Header().Set("Content-Type", "application/json")
+			_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameCallCancelled, nil))
+			logger("facade").Info("cancelled", "method", req.Method, "target", target, "server", serverName, "after", time.Since(deadline.StartedAt))
+			return rr, status, true
+		case deadline.expired(callCtx):
+			observe(true)
+			serverErrors.record(serverName, serverErrorCall, fmt.Sprintf("%s %s timed out after %s", req.Method, target, time.Since(deadline.StartedAt).Round(time.Millisecond)), time.Now())
+			w.Header().Set("Content-Type", "application/json")
+			_ = px.json.encode(w, deadline.timeoutError(r.Context(), req.ID, serverName))
+			logger("facade").Warn("timeout", "method", req.Method, "target", target, "server", serverName, "after", time.Since(deadline.StartedAt))
+			return rr, status, true
+		}
+		failed := status < 200 || status > 204 || rr.Digest().Failed
+		observe(failed)
+		if failed {
+			summary := rr.Digest().ErrorSummary
+			if status < 200 || status > 204 {
+				summary = fmt.Sprintf("HTTP %d %s", status, summary)
+			}
+			serverErrors.record(serverName, serverErrorCall, fmt.Sprintf("%s %s: %s", req.Method, target, summary), time.Now())
+		}
+		return rr, status, false
+	}
+
+	// helper: re-run an audit entry against its server for the admin replay action
+	replayAudit := func(ctx context.Context, entry *auditEntry) ([]byte, error) {
+		srv := servers.get(entry.Server)
+		if srv == nil {
+			return nil, fmt.Errorf("server %s is not configured", entry.Server)
+		}
+		body, err := replayRequestBody(entry)
+		if err != nil {
+			return nil, err
+		}
+		if iso, ok := replayIsolationFromContext(ctx); ok && entry.Method == "tools/call" && iso.holds(srv, overrideStore.current(), replayTarget(entry.Params)) {
+			return nil, errReplayHeld
+		}
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, mcpPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		deadline := resolveCallDeadline(r, entry.Params, config.McpProxy.MaxCallTimeout, serverCallTimeout(entry.Server), 0)
+		callCtx, cancel := deadline.context(withCallerInfo(ctx, callerInfo{Identity: "admin:replay", RequestID: "replay-" + entry.ID}))
+		defer cancel()
+		rr, status := px.dispatchToClient(callCtx, srv, body)
+		if status < 200 || status > 204 {
+			return nil, fmt.Errorf("dispatch to %s failed with status %d", entry.Server, status)
+		}
+		return rr.Body.Bytes(), nil
+	}
+	registerAuditRoutes(admin, audit, replayAudit)
+
+	// helper: catalog items searchable through the facade search tool
+	searchCandidates := func() []searchCandidate {
+		return collectSearchCandidates(catalogServers(), overrideStore.current())
+	}
+
+	// self-management tools, visible to admin-token callers only
+	checkServerTool := func(server, tool string) error {
+		srv := servers.get(server)
+		if srv == nil {
+			return fmt.Errorf("unknown or disconnected server %q", server)
+		}
+		for _, t := range srv.tools {
+			if t.Name == tool {
+				return nil
+			}
+		}
+		return fmt.Errorf("server %q has no tool %q", server, tool)
+	}
+	adminOps := adminToolOps{
+		ListServers: func() []map[string]any {
+			overrides := overrideStore.current()
+			configs := servers.configs()
+			names := make([]string, 0, len(configs))
+			for name := range configs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			out := make([]map[string]any, 0, len(names))
+			for _, name := range names {
+				entry := map[string]any{
+					"name":      name,
+					"connected": false,
+					"enabled":   serverEnabled(overrides, name),
 				}
-				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(out)
-				log.Printf("<facade> %s %s?%s batch -> %d", r.Method, r.URL.Path, r.URL.RawQuery, http.StatusOK)
+				if deps := configs[name].DependsOn; len(deps) > 0 {
+					entry["dependsOn"] = deps
+				}
+				if srv := servers.get(name); srv != nil {
+					entry["connected"] = true
+					entry["transport"] = srv.transport
+					entry["tools"] = len(srv.tools)
+					entry["prompts"] = len(srv.prompts)
+					entry["resources"] = len(srv.resources)
+				}
+				out = append(out, entry)
+			}
+			return out
+		},
+		SetToolEnabled: func(change toolToggle) error {
+			if err := checkServerTool(change.Server, change.Tool); err != nil {
+				return err
+			}
+			if err := overrideStore.setToolEnabled(change); err != nil {
+				logger("admin").Error("failed to save tool state", "err", err)
+			}
+			if change.Enabled {
+				deadTools.forget(change.Server, change.Tool)
+			}
+			eventType := "tool.disabled"
+			if change.Enabled {
+				eventType = "tool.restored"
+			}
+			events.emit(eventType, map[string]any{
+				"server": change.Server,
+				"tool":   change.Tool,
+				"by":     change.By,
+				"reason": change.Reason,
+			})
+			return nil
+		},
+		Catalog: func() map[string]any {
+			return adminCatalogView(catalogServers(), overrideStore)
+		},
+		ReloadOverrides: func() (map[string]any, error) {
+			manifestOverridesMu.Lock()
+			reloaded, err := loadManifestToolOverrides(manifestCfg)
+			manifestOverridesMu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			var warnings []string
+			if reloaded != nil {
+				warnings = reloaded.Warnings
+				for _, msg := range warnings {
+					logger("manifest").Warn(msg)
+				}
+			}
+			overrideStore.replaceBase(reloaded)
+			rebuildIndex()
+			return map[string]any{
+				"tools":    len(px.collectTools(catalogServers(), overrideStore.current(), intendedCatalog)),
+				"warnings": warnings,
+			}, nil
+		},
+		Health: func() map[string]any {
+			connected := 0
+			var missing []string
+			names := servers.names()
+			for _, name := range names {
+				if servers.get(name) != nil {
+					connected++
+				} else {
+					missing = append(missing, name)
+				}
+			}
+			sort.Strings(missing)
+			health := map[string]any{
+				"ready":             clientsReady.Load(),
+				"serversConfigured": len(names),
+				"serversConnected":  connected,
+				"serversMissing":    missing,
+				"activeCalls":       len(activeCalls.list()),
+			}
+			if snapshot := readyState.Load(); snapshot != nil {
+				health["readyAt"] = snapshot.ReadyAt.Format(time.RFC3339Nano)
+			}
+			health["servers"] = healthChecks.report(names, servers.connected)
+			if reconnecting := supervisor.list(); len(reconnecting) > 0 {
+				health["reconnecting"] = reconnecting
+			}
+			if probes != nil {
+				health["probes"] = probes.health()
+			}
+			return health
+		},
+	}
+	adminTools := newAdminToolServer(config.McpProxy.AdminTokens, adminOps)
+	registerToolStateRoutes(admin, adminOps, overrideStore)
+	registerCatalogJournalRoutes(admin, catalogChanges)
+	registerPinRoutes(admin, pins, func(server, tool string, serve bool) (*ToolPinConfig, error) {
+		if manifestCfg.ToolOverridesPath == "" {
+			return nil, errNoOverridesPath
+		}
+		srv := servers.get(server)
+		if srv == nil {
+			return nil, fmt.Errorf("%w: unknown or disconnected server %q", errUnknownTool, server)
+		}
+		for _, t := range srv.tools {
+			t.Name = srv.downstreamToolName(t.Name)
+			if t.Name != tool {
+				continue
+			}
+			pin := newToolPin(t, serve, time.Now())
+			manifestOverridesMu.Lock()
+			err := updateServerToolOverride(manifestCfg.ToolOverridesPath, server, tool, func(cfg *ToolOverrideConfig) {
+				cfg.Pin = pin
+			})
+			manifestOverridesMu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := adminOps.ReloadOverrides(); err != nil {
+				return nil, err
+			}
+			return pin, nil
+		}
+		return nil, fmt.Errorf("%w: server %q has no tool %q", errUnknownTool, server, tool)
+	})
+	registerApplyRoutes(admin, applier, clientsReady.Load, events)
+	registerServerRoutes(admin, applier, clientsReady.Load, events)
+	registerServerDetailRoutes(admin, func(name string) (serverDetail, bool) {
+		if servers.config(name) == nil {
+			return serverDetail{}, false
+		}
+		var reconnect *reconnectState
+		for _, state := range supervisor.list() {
+			if state.Server == name {
+				reconnect = &state
+			}
+		}
+		health := healthChecks.report([]string{name}, servers.connected)[0]
+		return newServerDetail(name, servers.client(name), health, reconnect, serverErrors.recent(name), time.Now()), true
+	})
+	registerServerStatsRoutes(admin, callStats.window, func(since time.Time) []serverStats {
+		names := servers.names()
+		health := make(map[string]serverHealth, len(names))
+		for _, h := range healthChecks.report(names, servers.connected) {
+			health[h.Server] = h
+		}
+		reconnecting := make(map[string]bool)
+		for _, state := range supervisor.list() {
+			reconnecting[state.Server] = true
+		}
+		usage := callStats.serverUsageReport(since)
+		now := time.Now()
+		out := make([]serverStats, 0, len(names))
+		for _, name := range names {
+			tools := 0
+			if srv := servers.get(name); srv != nil {
+				tools = len(srv.tools)
+			}
+			reconnects, _ := supervisor.lastReconnect(name)
+			out = append(out, newServerStats(name, servers.client(name), health[name], reconnecting[name], tools, usage[name], reconnects, now))
+		}
+		return out
+	})
+	registerRolloutRoutes(admin, applier, clientsReady.Load, events)
+	if config.McpProxy.GraphQL {
+		err := registerGraphQLRoutes(admin, graphqlSource{
+			Servers: adminOps.ListServers,
+			Tools:   func() []graphqlTool { return graphqlCatalogTools(catalogServers(), overrideStore) },
+			Health:  adminOps.Health,
+			Adapters: func() statusMap {
+				status, _ := loadStatus(manifestCfg.ToolSchemaStatusPath)
+				return status
+			},
+			Metrics:  callStats,
+			ReadOnly: readOnly,
+		})
+		if err != nil {
+			return fmt.Errorf("graphql: %w", err)
+		}
+	}
+
+	grants := newToolGrantStore(func(grant toolGrant, reason, actor string) {
+		if actor == "" {
+			actor = "system"
+		}
+		audit.record(grantAuditEntry(grant, reason, actor))
+		logger("admin").Info("grant "+reason, "id", grant.ID, "server", grant.Server, "tool", grant.Tool, "caller", grant.Caller, "session", grant.SessionID)
+		events.emit("tool.grant_"+reason, map[string]any{
+			"id":     grant.ID,
+			"server": grant.Server,
+			"tool":   grant.Tool,
+			"by":     actor,
+		})
+	})
+	registerGrantRoutes(admin, grants, checkServerTool, audit)
+	registerAPITokenRoutes(admin, apiTokens, func(server string) bool { return servers.config(server) != nil }, audit)
+	registerWireLogRoutes(admin, wireLog, func(server string) bool { return servers.config(server) != nil }, audit)
+	registerReadOnlyRoutes(admin, readOnly, events)
+	// catalogOwner resolves the server owning a tool, prompt, or resource
+	// for r, rebuilding the active index once on a miss.
+	catalogOwner := func(r *http.Request, kind, key string) (string, bool) {
+		if c := rolloutFromContext(r.Context()); c != nil {
+			return c.index.owner(kind, key)
+		}
+		indexMu.RLock()
+		serverName, ok := index.owner(kind, key)
+		indexMu.RUnlock()
+		if !ok {
+			rebuildIndex()
+			indexMu.RLock()
+			serverName, ok = index.owner(kind, key)
+			indexMu.RUnlock()
+		}
+		return serverName, ok
+	}
+	// callerOverrides is the override set as seen by one facade caller, with
+	// any temporary grants for its token or session applied.
+	callerOverrides := func(r *http.Request) *ToolOverrideSet {
+		info, _ := callerFromContext(r.Context())
+		return grants.overrides(facadeOverrides(r), info)
+	}
+	// catalogToolRoute resolves a facade tool name to the server and tool a
+	// call goes to, skipping copies disabled for the caller, rebuilding the
+	// active index once on a miss.
+	catalogToolRoute := func(r *http.Request, name string) (toolRoute, error) {
+		overrides := callerOverrides(r)
+		include := func(server, tool string) bool {
+			return serverEnabled(overrides, server) && toolEnabled(overrides, server, tool)
+		}
+		if server, ok := fanOutTargetFromContext(r.Context()); ok {
+			if c := rolloutFromContext(r.Context()); c != nil {
+				return pinnedRoute(c.index.tools, name, server, include)
+			}
+			indexMu.RLock()
+			defer indexMu.RUnlock()
+			return pinnedRoute(index.tools, name, server, include)
+		}
+		caller, _ := callerFromContext(r.Context())
+		affinity := callAffinity{caller: caller, header: r.Header}
+		if c := rolloutFromContext(r.Context()); c != nil {
+			return c.index.routeTool(name, affinity, include)
+		}
+		indexMu.RLock()
+		route, err := index.routeTool(name, affinity, include)
+		indexMu.RUnlock()
+		if errors.Is(err, errUnknownTool) {
+			rebuildIndex()
+			indexMu.RLock()
+			route, err = index.routeTool(name, affinity, include)
+			indexMu.RUnlock()
+		}
+		return route, err
+	}
+	// fanOutTargets picks the servers a fan_out of tool calls: one replica
+	// of each logical server exposing it to the caller.
+	fanOutTargets := func(r *http.Request, tool string, only []string) []string {
+		overrides := callerOverrides(r)
+		include := func(server, tool string) bool {
+			return serverEnabled(overrides, server) && toolEnabled(overrides, server, tool)
+		}
+		caller, _ := callerFromContext(r.Context())
+		affinity := callAffinity{caller: caller, header: r.Header}
+		if c := rolloutFromContext(r.Context()); c != nil {
+			return px.replicas.fanOutServers(c.index.tools, tool, only, affinity, include)
+		}
+		indexMu.RLock()
+		defer indexMu.RUnlock()
+		return px.replicas.fanOutServers(index.tools, tool, only, affinity, include)
+	}
+
+	// ---- facade sessions ----
+	sessions := newFacadeSessionTable(config.McpProxy.SessionIdleTimeout)
+	sessions.ended = func(id string) {
+		for _, sub := range notifications.dropSession(id) {
+			go unsubscribeResource(servers.client(sub.Server), sub)
+		}
+	}
+	collectSessionMetrics(sessions.list)
+	if sampling != nil {
+		sampling.sessions = func(server string) []string {
+			return activeSessions(activeCalls.list(), server, sessions.sampling)
+		}
+	}
+	if elicitation != nil {
+		elicitation.sessions = func(server string) []string {
+			return activeSessions(activeCalls.list(), server, sessions.elicitation)
+		}
+	}
+	if roots != nil {
+		roots.table = sessions
+		roots.sessions = func(server string) []string {
+			return rootsSessions(activeCalls.list(), server, sessions.list())
+		}
+		roots.notify = func() {
+			for _, srv := range servers.snapshot() {
+				if srv.client != nil {
+					go srv.client.notifyRootsChanged(context.Background())
+				}
+			}
+		}
+	}
+	clientShapes, err := newClientProfiles(config.McpProxy.ClientProfiles)
+	if err != nil {
+		return err
+	}
+	registerSessionRoutes(admin, sessions)
+	budgets := newSessionBudgets(config.McpProxy.SessionBudget, config.McpProxy.SessionIdleTimeout)
+	registerBudgetRoutes(admin, budgets)
+	transcripts, err := newTranscriptRecorder(config.McpProxy.Transcripts)
+	if err != nil {
+		return err
+	}
+	registerTranscriptRoutes(admin, transcripts)
+	registerSessionReplayRoutes(admin, transcripts, &sessionReplayer{
+		dispatch: func(ctx context.Context, header http.Header, msg []byte) ([]byte, http.Header) {
+			return dispatchFacadeMessage(ctx, httpMux, mcpPath, header, msg)
+		},
+		closeSession: func(id string) { sessions.close(id) },
+	})
+	var grpcTLS *tls.Config
+	if grpcConf := config.McpProxy.GRPCAdmin; grpcConf != nil {
+		switch {
+		case serverTLS != nil:
+			if grpcTLS, err = serverTLS.listenerConfig(grpcConf.ClientAuth); err != nil {
+				return fmt.Errorf("grpcAdmin.%w", err)
+			}
+		case grpcConf.ClientAuth != nil:
+			return errors.New("grpcAdmin.clientAuth needs tls certificate files or acme")
+		}
+	}
+	stopGRPCAdmin, err := startGRPCAdmin(config.McpProxy.GRPCAdmin, config.McpProxy.AdminTokens, grpcTLS, &grpcAdminServer{
+		ops:      adminOps,
+		metrics:  callStats,
+		readOnly: readOnly,
+		sessions: sessions,
+		events:   events,
+		applier:  applier,
+		ready:    clientsReady.Load,
+	})
+	if err != nil {
+		return err
+	}
+	defer stopGRPCAdmin()
+	callerIdentity := func(r *http.Request) string {
+		info, _ := callerFromContext(r.Context())
+		return info.Identity
+	}
+	// streamSession resolves the session a GET stream belongs to. A stream
+	// opened without a session id, and every HTTP+SSE stream, gets its own,
+	// which ends with the stream.
+	streamSession := func(w http.ResponseWriter, r *http.Request, transport string) (string, func(), bool) {
+		if id := facadeSessionID(r); id != "" && transport != legacySSETransport {
+			if !sessions.touch(id) {
+				http.Error(w, "Session not found", http.StatusNotFound)
+				logger("facade").Warn("unknown session", "method", r.Method, "path", r.URL.Path, "session", id)
+				return "", nil, false
+			}
+			return id, sessions.attach(id), true
+		}
+		id := sessions.open(transport, callerIdentity(r))
+		detach := sessions.attach(id)
+		return id, func() {
+			detach()
+			sessions.close(id)
+		}, true
+	}
+
+	// ---- /mcp facade ----
+	facadeTokens := newRouteTokens(nil, apiTokens, "")
+	facadeAuth := oauth.middleware("facade", facadeTokens)
+	if oauth == nil && facadeTokens.enforced() {
+		facadeAuth = newAuthMiddleware(facadeTokens, nil)
+	}
+	httpMux.Handle(mcpPath, chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger("facade").Debug("request", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery))
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Connection", "keep-alive")
+			w.Header().Set("X-Accel-Buffering", "no")
+			// a probe opens nothing; the session starts on initialize
+			w.Header().Set("mcp-session-id", uuid.New().String())
+			w.WriteHeader(http.StatusOK)
+			logger("facade").Debug("response", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "status", http.StatusOK)
+			return
+
+		case http.MethodGet:
+			transport := "streamable-http"
+			switch {
+			case isWebSocketUpgrade(r):
+				transport = "websocket"
+			case isLegacySSEStream(r):
+				transport = legacySSETransport
+			}
+			sessionID, release, ok := streamSession(w, r, transport)
+			if !ok {
 				return
 			}
+			defer release()
+			if transport == "websocket" {
+				serveFacadeWebSocket(w, r, httpMux, mcpPath, sessionID, cors)
+				return
+			}
+			var messageEndpoint string
+			if transport == legacySSETransport {
+				messageEndpoint = legacySSEEndpoint(mcpPath, sessionID)
+			}
+			w.Header().Set("mcp-session-id", sessionID)
+			logger("facade").Info("SSE session", "session", sessionID, "transport", transport, "endpoint", messageEndpoint)
+			notices, unsubscribe := backpressure.subscribe()
+			defer unsubscribe()
+			progressNotices, unsubscribeProgress := streams.subscribe(sessionID)
+			defer unsubscribeProgress()
+			handleSSE(w, r, messageEndpoint, mergeNotices(r.Context(), notices, progressNotices), sseHeartbeats)
+			logger("facade").Debug("response", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "status", http.StatusOK)
+			return
 
-			var req jsonrpcRequest
-			if err := json.Unmarshal(body, &req); err != nil {
+		case http.MethodPost:
+			body, err := parser.read(w, r)
+			if errors.Is(err, errRequestTooLarge) {
+				http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+				logger("facade").Warn("request body too large", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "status", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
 				http.Error(w, "Bad Request", http.StatusBadRequest)
-				log.Printf("<facade> %s %s?%s invalid json: %v", r.Method, r.URL.Path, r.URL.RawQuery, err)
+				logger("facade").Warn("read body failed", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "err", err)
+				return
+			}
+			locale := messages.negotiate(r.Header.Get(acceptLanguageHeader))
+			r = r.WithContext(withWarnings(withLocale(r.Context(), locale)))
+			w.Header().Set(contentLanguageHeader, locale)
+			parsed, batch, perr := parser.parse(body)
+			if perr != nil {
+				parser.reject(px.json, w, r, perr)
+				logger("facade").Warn("refused message", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "err", perr)
 				return
 			}
 
+			if id, ok := legacySSESession(r, sessions); ok {
+				serveLegacySSEMessage(w, r, id, body, httpMux, mcpPath, streams)
+				logger("facade").Debug("SSE message accepted", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "session", id, "status", http.StatusAccepted)
+				return
+			}
+
+			// a session id must be one we issued; initialize may start over
+			if id := facadeSessionID(r); id != "" && !sessions.touch(id) && !isInitializeRequest(body) {
+				http.Error(w, "Session not found", http.StatusNotFound)
+				logger("facade").Warn("unknown session", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "session", id, "status", http.StatusNotFound)
+				return
+			}
+
+			// batch: every entry goes back through the facade as its own
+			// request with this request's headers and caller context
+			if parsed == nil {
+				if len(batch) == 0 {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(nil, errNameInvalidRequest, map[string]string{"detail": "empty batch"}))
+					return
+				}
+				out := dispatchBatch(r.Context(), batch, config.McpProxy.BatchParallelism, func(ctx context.Context, entry []byte) []byte {
+					reply, _ := dispatchFacadeMessage(ctx, httpMux, mcpPath, r.Header, entry)
+					return reply
+				})
+				if len(out) == 0 {
+					w.WriteHeader(http.StatusAccepted)
+				} else {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, out)
+				}
+				logger("facade").Info("batch", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "entries", len(batch), "replies", len(out))
+				return
+			}
+
+			req := *parsed
+
+			annotatePanicContext(r.Context(), req.Method, "")
+			if req.Method != "" {
+				trace.SpanFromContext(r.Context()).SetName("facade " + req.Method)
+			}
+			// a client's answer to a request the proxy sent it
+			if req.Method == "" && clientCalls.deliver(body) {
+				w.WriteHeader(http.StatusAccepted)
+				logger("facade").Debug("client response", "id", req.ID)
+				return
+			}
+			if req.Method == rootsListChangedNotification && req.ID == nil {
+				roots.changed(facadeSessionID(r))
+			}
 			if handleNotification(w, &req) {
-				log.Printf("<facade> notification %s", req.Method)
+				logger("facade").Debug("notification", "method", req.Method)
 				return
 			}
 
@@ -995,9 +2174,26 @@ func startHTTPServer(config *Config) error {
 					w.Header().Set("X-Proxy-Waited-For-Init", "true")
 				}
 
-				result := buildInitializeResult(config, servers, toolOverrides, intendedCatalog)
+				sessionID := facadeSessionID(r)
+				if sessionID == "" || !sessions.touch(sessionID) {
+					sessionID = sessions.open("streamable-http", callerIdentity(r))
+				}
+				w.Header().Set(sessionIDHeader, sessionID)
+				client := parseSessionClient(req.Params)
+				omit := clientShapes.omitted(client)
+				relays := func(feature string) bool {
+					return client.declares(feature) && !slices.Contains(omit, feature)
+				}
+				sessions.setClient(sessionID, client, omit)
+				sessions.setSampling(sessionID, sampling != nil && relays(clientFeatureSampling))
+				sessions.setRoots(sessionID, roots != nil && relays(clientFeatureRoots))
+				sessions.setElicitation(sessionID, elicitation != nil && relays(clientFeatureElicitation))
+				result := shapeInitializeResult(px.buildInitializeResult(config, facadeCatalog(r), facadeOverrides(r), intendedCatalog), omit)
+				if tools, ok := result["tools"].([]map[string]any); ok {
+					result["tools"] = messages.localizeTools(tools, locale)
+				}
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcOK(req.ID, result))
+				_ = px.json.encode(w, rpcOK(req.ID, result))
 				return
 
 			case "tools/list":
@@ -1012,9 +2208,17 @@ func startHTTPServer(config *Config) error {
 					w.Header().Set("X-Proxy-Waited-For-Init", "true")
 				}
 
-				items := collectTools(servers, toolOverrides, intendedCatalog)
+				items := px.collectTools(facadeCatalog(r), callerOverrides(r), intendedCatalog)
+				if adminTools.authorized(r) {
+					items = append(items, adminTools.tools()...)
+				}
+				if sessions.omits(facadeSessionID(r), clientFeatureTools) {
+					items = []map[string]any{}
+				}
+				items = messages.localizeTools(items, locale)
+				warnDrift(r.Context(), items)
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcOK(req.ID, map[string]any{"tools": items}))
+				_ = px.json.encode(w, rpcOK(req.ID, attachWarnings(r.Context(), map[string]any{"tools": items})))
 				return
 
 			case "prompts/list":
@@ -1027,9 +2231,12 @@ func startHTTPServer(config *Config) error {
 				if waited {
 					w.Header().Set("X-Proxy-Waited-For-Init", "true")
 				}
-				items := collectPrompts(servers)
+				items := collectPrompts(facadeServers(r))
+				if sessions.omits(facadeSessionID(r), clientFeaturePrompts) {
+					items = []map[string]any{}
+				}
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcOK(req.ID, map[string]any{"prompts": items}))
+				_ = px.json.encode(w, rpcOK(req.ID, map[string]any{"prompts": items}))
 				return
 
 			case "prompts/get":
@@ -1042,37 +2249,74 @@ func startHTTPServer(config *Config) error {
 				}
 				if p.Name == "" {
 					w.Header().Set("Content-Type", "application/json")
-					_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32602, "Missing prompt name"))
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": "prompt name"}))
 					return
 				}
-				indexMu.RLock()
-				serverName, ok := promptIndex[p.Name]
-				indexMu.RUnlock()
+				serverName, ok := catalogOwner(r, "prompt", p.Name)
 				if !ok {
-					rebuildIndex()
-					indexMu.RLock()
-					serverName, ok = promptIndex[p.Name]
-					indexMu.RUnlock()
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownPrompt, map[string]string{"name": p.Name}))
+					logger("facade").Warn("prompts/get unknown prompt", "prompt", p.Name)
+					return
 				}
-				if !ok {
+				rr, status, handled := dispatchCall(w, r, &req, body, serverName, p.Name, callPolicy{})
+				if handled {
+					return
+				}
+				if status >= 200 && status <= 204 {
+					attachWarningsToRecorder(r.Context(), rr)
+					rr.FlushTo(w)
+					logger("facade").Info("prompts/get", "prompt", p.Name, "server", serverName, "status", status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUpstreamRejected, map[string]string{"server": serverName}))
+				logger("facade").Warn("prompts/get failed", "prompt", p.Name, "server", serverName, "status", status)
+				return
+
+			case "completion/complete":
+				var p completeParams
+				if len(req.Params) > 0 {
+					_ = json.Unmarshal(req.Params, &p)
+				}
+				kinds, key := p.owners()
+				if key == "" || p.Argument.Name == "" {
+					param := "ref"
+					if key != "" {
+						param = "argument name"
+					}
 					w.Header().Set("Content-Type", "application/json")
-					_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32601, "Unknown prompt: "+p.Name))
-					log.Printf("<facade> prompts/get unknown prompt=%s", p.Name)
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": param}))
+					return
+				}
+				var serverName string
+				found := false
+				for _, kind := range kinds {
+					if serverName, found = catalogOwner(r, kind, key); found {
+						break
+					}
+				}
+				if !found {
+					w.Header().Set("Content-Type", "application/json")
+					if p.Ref.Type == completionRefPrompt {
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownPrompt, map[string]string{"name": key}))
+					} else {
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownResource, map[string]string{"uri": key}))
+					}
+					logger("facade").Warn("completion/complete unknown ref", "ref", key)
+					return
+				}
+				rr, status, handled := dispatchCall(w, r, &req, body, serverName, key, callPolicy{})
+				if handled {
 					return
 				}
-				rr := newResponseRecorder()
-				chosen, status := tryDispatch(serverName, body, r, rr)
-				w.Header().Set("X-Proxy-Dispatched-Server", serverName)
-				w.Header().Set("X-Proxy-Internal-Path", chosen)
-				w.Header().Set("X-Proxy-Internal-Status", http.StatusText(status))
 				if status >= 200 && status <= 204 {
 					rr.FlushTo(w)
-					log.Printf("<facade> prompts/get prompt=%s server=%s path=%s status=%d", p.Name, serverName, chosen, status)
 					return
 				}
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32004, "Upstream rejected all candidate endpoints for server "+serverName))
-				log.Printf("<facade> prompts/get failed prompt=%s server=%s path=%s status=%d", p.Name, serverName, chosen, status)
+				_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUpstreamRejected, map[string]string{"server": serverName}))
+				logger("facade").Warn("completion/complete failed", "ref", key, "server", serverName, "status", status)
 				return
 
 			case "resources/list":
@@ -1085,9 +2329,12 @@ func startHTTPServer(config *Config) error {
 				if waited {
 					w.Header().Set("X-Proxy-Waited-For-Init", "true")
 				}
-				items := collectResources(servers)
+				items := append(collectResources(facadeServers(r)), catalogResources()...)
+				if sessions.omits(facadeSessionID(r), clientFeatureResources) {
+					items = []map[string]any{}
+				}
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcOK(req.ID, map[string]any{"resources": items}))
+				_ = px.json.encode(w, rpcOK(req.ID, map[string]any{"resources": items}))
 				return
 
 			case "resources/read":
@@ -1099,37 +2346,83 @@ func startHTTPServer(config *Config) error {
 				}
 				if p.URI == "" {
 					w.Header().Set("Content-Type", "application/json")
-					_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32602, "Missing resource uri"))
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": "resource uri"}))
 					return
 				}
-				indexMu.RLock()
-				serverName, ok := resourceIndex[p.URI]
-				indexMu.RUnlock()
-				if !ok {
-					rebuildIndex()
-					indexMu.RLock()
-					serverName, ok = resourceIndex[p.URI]
-					indexMu.RUnlock()
+				if isCatalogResource(p.URI) {
+					result, err := px.catalogResourceResult(p.URI, catalogSnapshot(p.URI))
+					w.Header().Set("Content-Type", "application/json")
+					if err != nil {
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameInternal, map[string]string{"detail": err.Error()}))
+						return
+					}
+					_ = px.json.encode(w, rpcOK(req.ID, result))
+					logger("facade").Info("resources/read", "uri", p.URI, "server", facadeServerName)
+					return
 				}
+				serverName, ok := catalogOwner(r, "resource", p.URI)
 				if !ok {
 					w.Header().Set("Content-Type", "application/json")
-					_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32601, "Unknown resource: "+p.URI))
-					log.Printf("<facade> resources/read unknown uri=%s", p.URI)
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownResource, map[string]string{"uri": p.URI}))
+					logger("facade").Warn("resources/read unknown uri", "uri", p.URI)
+					return
+				}
+				rr, status, handled := dispatchCall(w, r, &req, body, serverName, p.URI, callPolicy{})
+				if handled {
 					return
 				}
-				rr := newResponseRecorder()
-				chosen, status := tryDispatch(serverName, body, r, rr)
-				w.Header().Set("X-Proxy-Dispatched-Server", serverName)
-				w.Header().Set("X-Proxy-Internal-Path", chosen)
-				w.Header().Set("X-Proxy-Internal-Status", http.StatusText(status))
 				if status >= 200 && status <= 204 {
+					attachWarningsToRecorder(r.Context(), rr)
 					rr.FlushTo(w)
-					log.Printf("<facade> resources/read uri=%s server=%s path=%s status=%d", p.URI, serverName, chosen, status)
+					logger("facade").Info("resources/read", "uri", p.URI, "server", serverName, "status", status)
 					return
 				}
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32004, "Upstream rejected all candidate endpoints for server "+serverName))
-				log.Printf("<facade> resources/read failed uri=%s server=%s path=%s status=%d", p.URI, serverName, chosen, status)
+				_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUpstreamRejected, map[string]string{"server": serverName}))
+				logger("facade").Warn("resources/read failed", "uri", p.URI, "server", serverName, "status", status)
+				return
+
+			case "resources/subscribe", "resources/unsubscribe":
+				var p struct {
+					URI string `json:"uri"`
+				}
+				if len(req.Params) > 0 {
+					_ = json.Unmarshal(req.Params, &p)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				sessionID := facadeSessionID(r)
+				switch {
+				case p.URI == "":
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": "resource uri"}))
+					return
+				case sessionID == "":
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameInvalidRequest, map[string]string{"detail": req.Method + " needs a session"}))
+					return
+				}
+				serverName, ok := catalogOwner(r, "resource", p.URI)
+				if !ok {
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownResource, map[string]string{"uri": p.URI}))
+					logger("facade").Warn(req.Method+" unknown uri", "uri", p.URI)
+					return
+				}
+				sub := resourceSubscription{Server: serverName, URI: p.URI}
+				var downstream *Client
+				if srv := facadeServers(r)[serverName]; srv != nil {
+					downstream = srv.client
+				}
+				if req.Method == "resources/unsubscribe" {
+					if notifications.unsubscribe(sessionID, serverName, p.URI) {
+						go unsubscribeResource(downstream, sub)
+					}
+				} else if err := subscribeResource(r.Context(), downstream, sub); err != nil {
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUpstreamRejected, map[string]string{"server": serverName}))
+					logger("facade").Warn("resources/subscribe failed", "uri", p.URI, "server", serverName, "err", err)
+					return
+				} else {
+					notifications.subscribe(sessionID, serverName, p.URI)
+				}
+				_ = px.json.encode(w, rpcOK(req.ID, map[string]any{}))
+				logger("facade").Info(req.Method, "uri", p.URI, "server", serverName, "session", sessionID)
 				return
 
 			case "resources/templates/list":
@@ -1142,37 +2435,41 @@ func startHTTPServer(config *Config) error {
 				if waited {
 					w.Header().Set("X-Proxy-Waited-For-Init", "true")
 				}
-				items := collectResourceTemplates(servers)
+				items := collectResourceTemplates(facadeServers(r))
+				if sessions.omits(facadeSessionID(r), clientFeatureResources) {
+					items = []map[string]any{}
+				}
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcOK(req.ID, map[string]any{"resourceTemplates": items}))
+				_ = px.json.encode(w, rpcOK(req.ID, map[string]any{"resourceTemplates": items}))
 				return
 
 			case "ping":
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcOK(req.ID, map[string]any{}))
+				_ = px.json.encode(w, rpcOK(req.ID, map[string]any{}))
 				return
 
 			case facadeSearchToolName:
 				var p struct {
 					Query string `json:"query"`
+					searchFilters
 				}
 				if len(req.Params) > 0 {
 					_ = json.Unmarshal(req.Params, &p)
 				}
 				w.Header().Set("Content-Type", "application/json")
-				payload := buildFacadeSearchPayload(p.Query)
-				_ = json.NewEncoder(w).Encode(rpcOK(req.ID, payload))
+				payload := buildFilteredSearchPayload(p.Query, p.searchFilters, searchCandidates)
+				_ = px.json.encode(w, rpcOK(req.ID, payload))
 				if results, ok := payload["results"].([]map[string]any); ok {
-					log.Printf("<facade> search (static) query=%q hits=%d", p.Query, len(results))
+					logger("facade").Info("search", "mode", searchMode(p.searchFilters), "query", p.Query, "hits", len(results))
 				} else {
-					log.Printf("<facade> search (static) query=%q", p.Query)
+					logger("facade").Info("search", "mode", searchMode(p.searchFilters), "query", p.Query)
 				}
 				return
 
 			case "tools/call":
 				// ensure we have an index; rebuild lazily if empty
 				indexMu.RLock()
-				idxEmpty := len(toolIndex) == 0
+				idxEmpty := len(index.tools) == 0
 				indexMu.RUnlock()
 				if idxEmpty {
 					rebuildIndex()
@@ -1189,31 +2486,48 @@ func startHTTPServer(config *Config) error {
 				}
 				if p.Name == "" {
 					w.Header().Set("Content-Type", "application/json")
-					_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32602, "Missing tool name"))
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": "tool name"}))
 					return
 				}
 
 				incomingName := p.Name
-				if toolOverrides != nil {
-					if original, ok := toolOverrides.OriginalForAlias(p.Name); ok {
+				annotatePanicContext(r.Context(), req.Method, incomingName)
+				if overrides := facadeOverrides(r); overrides != nil {
+					if original, ok := overrides.OriginalForAlias(p.Name); ok {
 						p.Name = original
 					}
 				}
 
+				if isAdminToolName(p.Name) && adminTools.authorized(r) {
+					actor := "anonymous"
+					if info, ok := callerFromContext(r.Context()); ok {
+						actor = info.Identity
+					}
+					result, err := adminTools.call(p.Name, actor, p.Arguments)
+					w.Header().Set("Content-Type", "application/json")
+					if err != nil {
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownTool, map[string]string{"name": p.Name}))
+						return
+					}
+					_ = px.json.encode(w, rpcOK(req.ID, result))
+					return
+				}
+
 				if p.Name == facadeSearchToolName {
 					var searchArgs struct {
 						Query string `json:"query"`
+						searchFilters
 					}
 					if len(p.Arguments) > 0 {
 						_ = json.Unmarshal(p.Arguments, &searchArgs)
 					}
 					w.Header().Set("Content-Type", "application/json")
-					payload := buildFacadeSearchPayload(searchArgs.Query)
-					_ = json.NewEncoder(w).Encode(rpcOK(req.ID, payload))
+					payload := buildFilteredSearchPayload(searchArgs.Query, searchArgs.searchFilters, searchCandidates)
+					_ = px.json.encode(w, rpcOK(req.ID, payload))
 					if results, ok := payload["results"].([]map[string]any); ok {
-						log.Printf("<facade> tools/call search (static) query=%q hits=%d", searchArgs.Query, len(results))
+						logger("facade").Info("tools/call search", "mode", searchMode(searchArgs.searchFilters), "query", searchArgs.Query, "hits", len(results))
 					} else {
-						log.Printf("<facade> tools/call search (static) query=%q", searchArgs.Query)
+						logger("facade").Info("tools/call search", "mode", searchMode(searchArgs.searchFilters), "query", searchArgs.Query)
 					}
 					return
 				}
@@ -1221,125 +2535,362 @@ func startHTTPServer(config *Config) error {
 				if p.Name == facadeFetchToolName {
 					var fetchArgs struct {
 						ID string `json:"id"`
+						fetchPageArgs
 					}
 					if len(p.Arguments) > 0 {
 						_ = json.Unmarshal(p.Arguments, &fetchArgs)
 					}
 					if fetchArgs.ID == "" {
 						w.Header().Set("Content-Type", "application/json")
-						_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32602, "Missing fetch id"))
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": "fetch id"}))
+						return
+					}
+					window, windowErr := fetchArgs.window()
+					if windowErr != nil {
+						w.Header().Set("Content-Type", "application/json")
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameInvalidFetchRange, map[string]string{"detail": windowErr.Error()}))
+						return
+					}
+					page := func(payload map[string]any) map[string]any {
+						if !fetchArgs.requested() {
+							return payload
+						}
+						return applyFetchWindow(payload, window)
+					}
+					if fetcher != nil && looksLikeURL(fetchArgs.ID) {
+						doc, err := fetcher.fetch(r.Context(), fetchArgs.ID)
+						w.Header().Set("Content-Type", "application/json")
+						if err != nil {
+							name := errNameFetchFailed
+							if errors.Is(err, errFetchNotAllowed) {
+								name = errNameFetchNotAllowed
+							}
+							_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, name, map[string]string{"detail": err.Error()}))
+							logger("facade").Warn("tools/call fetch failed", "url", redactURL(fetchArgs.ID), "err", withoutURL(err))
+							return
+						}
+						if doc.Truncated {
+							warn(r.Context(), responseWarning{Code: warnTruncated, Message: fmt.Sprintf("document cut at %d bytes (mcpProxy.fetch.maxBytes)", doc.Bytes)})
+						}
+						_ = px.json.encode(w, rpcOK(req.ID, attachWarnings(r.Context(), page(doc.payload(fetchArgs.ID)))))
+						logger("facade").Info("tools/call fetch", "url", redactURL(doc.URL), "bytes", doc.Bytes, "truncated", doc.Truncated)
+						return
+					}
+					if payload, ok := buildCatalogFetchPayload(fetchArgs.ID, searchCandidates()); ok {
+						w.Header().Set("Content-Type", "application/json")
+						_ = px.json.encode(w, rpcOK(req.ID, page(payload)))
+						logger("facade").Info("tools/call fetch", "source", "catalog", "id", fetchArgs.ID)
 						return
 					}
 					if payload, ok := buildFacadeFetchPayload(fetchArgs.ID); ok {
 						w.Header().Set("Content-Type", "application/json")
-						_ = json.NewEncoder(w).Encode(rpcOK(req.ID, payload))
-						log.Printf("<facade> tools/call fetch (static) id=%q", fetchArgs.ID)
+						_ = px.json.encode(w, rpcOK(req.ID, page(payload)))
+						logger("facade").Info("tools/call fetch", "source", "static", "id", fetchArgs.ID)
 						return
 					}
 					w.Header().Set("Content-Type", "application/json")
-					_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32005, "Unknown fetch id"))
-					log.Printf("<facade> tools/call fetch unknown id=%s", fetchArgs.ID)
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownFetchID, nil))
+					logger("facade").Warn("tools/call fetch unknown id", "id", fetchArgs.ID)
 					return
 				}
 
-				indexMu.RLock()
-				serverName, ok := toolIndex[p.Name]
-				indexMu.RUnlock()
-				if !ok {
-					// last-ditch: rebuild and check again
-					rebuildIndex()
-					indexMu.RLock()
-					serverName, ok = toolIndex[p.Name]
-					indexMu.RUnlock()
+				if p.Name == facadeFanOutToolName && px.fanOut != nil {
+					var fanOutArgs struct {
+						Tool      string          `json:"tool"`
+						Arguments json.RawMessage `json:"arguments"`
+						Servers   []string        `json:"servers"`
+					}
+					if len(p.Arguments) > 0 {
+						_ = json.Unmarshal(p.Arguments, &fanOutArgs)
+					}
+					w.Header().Set("Content-Type", "application/json")
+					if fanOutArgs.Tool == "" {
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": "tool"}))
+						return
+					}
+					if overrides := facadeOverrides(r); overrides != nil {
+						if original, ok := overrides.OriginalForAlias(fanOutArgs.Tool); ok {
+							fanOutArgs.Tool = original
+						}
+					}
+					if fanOutArgs.Tool == facadeFanOutToolName {
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameInvalidRequest, map[string]string{"detail": "fan_out cannot call itself"}))
+						return
+					}
+					targets := fanOutTargets(r, fanOutArgs.Tool, fanOutArgs.Servers)
+					if len(targets) == 0 {
+						_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownTool, map[string]string{"name": fanOutArgs.Tool}))
+						logger("facade").Warn("tools/call fan_out unknown tool", "tool", fanOutArgs.Tool)
+						return
+					}
+					// each leg goes back through the facade pinned to its
+					// server, so every per-call policy applies
+					results := runFanOut(r.Context(), targets, fanOutArgs.Tool, fanOutArgs.Arguments, px.fanOut.Parallelism, func(ctx context.Context, server string, entry []byte) []byte {
+						reply, _ := dispatchFacadeMessage(withFanOutTarget(ctx, server), httpMux, mcpPath, r.Header, entry)
+						return reply
+					})
+					result, failed := fanOutToolResult(fanOutArgs.Tool, results)
+					_ = px.json.encode(w, rpcOK(req.ID, result))
+					logger("facade").Info("tools/call fan_out", "tool", fanOutArgs.Tool, "servers", len(results), "failed", failed)
+					return
 				}
-				if !ok {
+
+				_, lookupSpan := tracer.Start(r.Context(), "index lookup", trace.WithAttributes(traceKeyTarget.String(p.Name)))
+				route, routeErr := catalogToolRoute(r, p.Name)
+				lookupSpan.SetAttributes(traceKeyServer.String(route.server))
+				if routeErr != nil {
+					lookupSpan.SetStatus(codes.Error, routeErr.Error())
+				}
+				lookupSpan.End()
+				var conflict *toolConflictError
+				if errors.As(routeErr, &conflict) {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, withErrorData(localizedErrors(r.Context()).response(req.ID, errNameToolConflict, map[string]string{"name": p.Name, "servers": strings.Join(conflict.servers, ", ")}), map[string]any{"servers": conflict.servers}))
+					logger("facade").Warn("tools/call conflicting tool", "tool", incomingName, "servers", conflict.servers)
+					return
+				}
+				if routeErr != nil {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownTool, map[string]string{"name": p.Name}))
+					logger("facade").Warn("tools/call unknown tool", "tool", incomingName)
+					return
+				}
+				if route.shared > 1 {
+					warn(r.Context(), responseWarning{
+						Code:    warnToolConflict,
+						Message: fmt.Sprintf("%d servers expose this tool; conflictPolicy %s routed the call to %s", route.shared, px.conflicts.mode, route.server),
+						Server:  route.server,
+						Tool:    p.Name,
+					})
+				}
+				// a virtual server's tool goes to the server it came from
+				route = px.virtual.source(route)
+				serverName := route.server
+				// a conflict-prefixed name reaches the server under its own name
+				p.Name = route.tool
+				if overrides := callerOverrides(r); !serverEnabled(overrides, serverName) || !toolEnabled(overrides, serverName, p.Name) {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUnknownTool, map[string]string{"name": p.Name}))
+					logger("facade").Warn("tools/call disabled tool", "tool", incomingName, "server", serverName)
+					return
+				}
+				if readOnly.active() && !readOnlyAllows(facadeServers(r)[serverName], facadeOverrides(r), p.Name) {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameReadOnlyMode, map[string]string{"name": incomingName}))
+					logger("facade").Warn("tools/call blocked by read-only mode", "tool", incomingName, "server", serverName)
+					return
+				}
+				if missing := scopes.missing(r, facadeServers(r)[serverName], facadeOverrides(r), p.Name); len(missing) > 0 {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, scopes.response(r, req.ID, incomingName, missing))
+					logger("facade").Warn("tools/call missing scopes", "tool", incomingName, "server", serverName, "missing", missing)
+					return
+				}
+				if iso, ok := replayIsolationFromContext(r.Context()); ok && iso.holds(facadeServers(r)[serverName], facadeOverrides(r), p.Name) {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, rpcOK(req.ID, replaySkippedResult(incomingName)))
+					logger("facade").Info("tools/call replay held", "tool", incomingName, "server", serverName)
+					return
+				}
+				caller, _ := callerFromContext(r.Context())
+				if state, blocked := loops.blocked(caller.sessionKey(), incomingName, p.Arguments, time.Now()); blocked {
+					resp := localizedErrors(r.Context()).response(req.ID, errNameLoopDetected, map[string]string{"name": incomingName, "count": strconv.Itoa(state.Count)})
 					w.Header().Set("Content-Type", "application/json")
-					_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32601, "Unknown tool: "+p.Name))
-					log.Printf("<facade> tools/call unknown tool=%s", incomingName)
+					_ = px.json.encode(w, withErrorData(resp, map[string]any{"count": state.Count, "lastError": state.LastError, "retryAfter": state.RetryAfter}))
+					logger("facade").Warn("tools/call loop short-circuited", "tool", incomingName, "session", caller.sessionKey(), "count", state.Count)
 					return
 				}
+				if budgets != nil {
+					hints, _ := resolveToolHints(facadeServers(r)[serverName], facadeOverrides(r), p.Name)
+					if limit, usage := budgets.charge(caller.sessionKey(), hints.Destructive); limit != "" {
+						ceiling := usage.MaxCalls
+						if limit == "destructiveCalls" {
+							ceiling = usage.MaxDestructiveCalls
+						}
+						resp := localizedErrors(r.Context()).response(req.ID, errNameBudgetExceeded, map[string]string{"limit": limit, "max": strconv.Itoa(ceiling)})
+						w.Header().Set("Content-Type", "application/json")
+						_ = px.json.encode(w, withErrorData(resp, map[string]any{"usage": usage}))
+						logger("facade").Warn("tools/call session budget exceeded", "tool", incomingName, "session", usage.Session, "limit", limit)
+						return
+					}
+				}
 
-				// forward to the server using adaptive path candidates
-				rr := newResponseRecorder()
-				chosen, status := tryDispatch(serverName, body, r, rr)
+				// forward to the owning server's client
+				if p.Name != incomingName {
+					body = withToolName(body, p.Name)
+				}
+				policy := callPolicy{timeout: toolTimeout(facadeOverrides(r), serverName, p.Name), stream: true}
+				if retries != nil {
+					hints, _ := resolveToolHints(facadeServers(r)[serverName], facadeOverrides(r), p.Name)
+					policy.retry = toolRetryable(facadeOverrides(r), serverName, p.Name, hints)
+				}
+				rr, status, handled := dispatchCall(w, r, &req, body, serverName, incomingName, policy)
+				if handled {
+					return
+				}
+
+				digest := rr.Digest()
+				failed := status < 200 || status > 204 || digest.Failed
+				observeDeadTool(serverName, p.Name, p.Arguments, digest, failed)
+				if state, tripped := loops.observe(caller.sessionKey(), incomingName, p.Arguments, digest, failed, time.Now()); tripped {
+					logger("facade").Warn("tools/call loop detected", "tool", incomingName, "session", caller.sessionKey(), "count", state.Count)
+					events.emit("session.loop_detected", map[string]any{
+						"session":   caller.sessionKey(),
+						"caller":    caller.Identity,
+						"tool":      incomingName,
+						"server":    serverName,
+						"count":     state.Count,
+						"lastError": state.LastError,
+					})
+				}
 
-				w.Header().Set("X-Proxy-Dispatched-Server", serverName)
-				w.Header().Set("X-Proxy-Internal-Path", chosen)
-				w.Header().Set("X-Proxy-Internal-Status", http.StatusText(status))
+				if flakiness != nil && !failed {
+					if hints, ok := resolveToolHints(facadeServers(r)[serverName], facadeOverrides(r), p.Name); ok && hints.ReadOnly {
+						flakiness.observe(serverName, incomingName, p.Arguments, digest, time.Now())
+					}
+				}
 
+				if rr.Streamed() {
+					// already structured and sent as it was encoded; the
+					// policies above read its digest
+					_, adaptSpan := tracer.Start(r.Context(), "adapter", trace.WithAttributes(traceKeyServer.String(serverName), traceKeyTarget.String(incomingName)))
+					if sc := digest.StructuredContent; sc != nil {
+						adaptPassThrough(serverName, incomingName, manifestCfg, sc)
+						adaptSpan.SetAttributes(traceKeyAdapter.String("pass_through"))
+					}
+					adaptSpan.End()
+					logger("facade").Info("tools/call", "tool", incomingName, "server", serverName, "status", status, "adapter", "pass_through", "streamed", rr.Len())
+					return
+				}
 				if status >= 200 && status <= 204 {
-					// Adapt call result if needed
+					// Already structured: forward the recorded body as it was
+					// encoded, without decoding it for the adapter
+					_, adaptSpan := tracer.Start(r.Context(), "adapter", trace.WithAttributes(traceKeyServer.String(serverName), traceKeyTarget.String(incomingName)))
+					if sc := digest.StructuredContent; sc != nil {
+						adaptPassThrough(serverName, incomingName, manifestCfg, sc)
+						adaptSpan.SetAttributes(traceKeyAdapter.String("pass_through"))
+						adaptSpan.End()
+						attachWarningsToRecorder(r.Context(), rr)
+						rr.FlushTo(w)
+						logger("facade").Info("tools/call", "tool", incomingName, "server", serverName, "status", status, "adapter", "pass_through")
+						return
+					}
+					// Adapt call result if needed; a spilled result is
+					// decoded from its file like any other
 					var payload map[string]any
-					if err := json.Unmarshal(rr.Body.Bytes(), &payload); err == nil {
+					if err := px.json.decodeFrom(rr.Body.Reader(), &payload); err == nil {
 						if _, ok := payload["result"].(map[string]any); ok {
-							if modified, used, schema, err := adaptCallResult(serverName, incomingName, toolOverrides, manifestCfg, payload); err == nil {
+							modified, used, schema, err := adaptCallResult(serverName, incomingName, facadeOverrides(r), manifestCfg, payload)
+							if err == nil {
+								adaptSpan.SetAttributes(traceKeyAdapter.String(used))
+								adaptSpan.End()
 								if modified {
 									// persist overrides when schema chosen differs
 									_ = writeServerToolOutputSchema(manifestCfg.ToolOverridesPath, serverName, incomingName, schema)
 								}
+								if used == "generic" {
+									warn(r.Context(), responseWarning{Code: warnAdapterFallback, Message: `no structuredContent or declared output schema; text wrapped as {"result": text}`, Server: serverName, Tool: incomingName})
+								}
+								payload["result"] = attachWarnings(r.Context(), payload["result"].(map[string]any))
 								// write adapted response
 								w.Header().Set("Content-Type", "application/json")
-								_ = json.NewEncoder(w).Encode(payload)
-								log.Printf("<facade> tools/call tool=%s server=%s path=%s status=%d adapter=%s", incomingName, serverName, chosen, status, used)
+								_ = px.json.encode(w, payload)
+								logger("facade").Info("tools/call", "tool", incomingName, "server", serverName, "status", status, "adapter", used)
 								return
 							}
 						}
 					}
 					// Fallback: flush upstream as-is
+					adaptSpan.End()
+					attachWarningsToRecorder(r.Context(), rr)
 					rr.FlushTo(w)
-					log.Printf("<facade> tools/call tool=%s server=%s path=%s status=%d", incomingName, serverName, chosen, status)
+					logger("facade").Info("tools/call", "tool", incomingName, "server", serverName, "status", status)
 					return
 				}
 
-				// none succeeded: protocol-level error rather than transport 404
+				// the server's client was missing or refused the call: report
+				// it as a JSON-RPC error, not the recorder's HTTP status
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32004, "Upstream rejected all candidate endpoints for server "+serverName))
-				log.Printf("<facade> tools/call failed tool=%s server=%s path=%s status=%d", p.Name, serverName, chosen, status)
+				_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameUpstreamRejected, map[string]string{"server": serverName}))
+				logger("facade").Warn("tools/call failed", "tool", p.Name, "server", serverName, "status", status)
+				return
+
+			case stelaeBatchMethod:
+				var p struct {
+					Operations []batchOperation `json:"operations"`
+				}
+				if len(req.Params) > 0 {
+					_ = json.Unmarshal(req.Params, &p)
+				}
+				if len(p.Operations) == 0 {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMissingParam, map[string]string{"param": "operations"}))
+					return
+				}
+				if len(p.Operations) > maxBatchOperations {
+					w.Header().Set("Content-Type", "application/json")
+					_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameInvalidRequest, map[string]string{"detail": fmt.Sprintf("at most %d operations per stelae/batch", maxBatchOperations)}))
+					return
+				}
+				// each operation goes back through the facade with this
+				// request's headers, so every per-call policy applies
+				results := runBatchOperations(r.Context(), p.Operations, config.McpProxy.BatchParallelism, func(ctx context.Context, entry []byte) []byte {
+					reply, _ := dispatchFacadeMessage(ctx, httpMux, mcpPath, r.Header, entry)
+					return reply
+				})
+				failed := 0
+				for _, result := range results {
+					if result.Error != nil {
+						failed++
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = px.json.encode(w, rpcOK(req.ID, map[string]any{"results": results}))
+				logger("facade").Info(stelaeBatchMethod, "operations", len(results), "failed", failed)
 				return
 
 			default:
 				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(rpcError(req.ID, -32601, "Method not found"))
-				log.Printf("<facade> unsupported method=%s", req.Method)
+				_ = px.json.encode(w, localizedErrors(r.Context()).response(req.ID, errNameMethodNotFound, nil))
+				logger("facade").Warn("unsupported method", "method", req.Method)
 				return
 			}
 
+		case http.MethodDelete:
+			id := facadeSessionID(r)
+			status := http.StatusNoContent
+			found, owned := false, false
+			if id != "" {
+				found, owned = sessions.closeAs(id, callerIdentity(r))
+			}
+			switch {
+			case id == "":
+				status = http.StatusBadRequest
+				http.Error(w, "Missing Mcp-Session-Id", status)
+			case !found:
+				status = http.StatusNotFound
+				http.Error(w, "Session not found", status)
+			case !owned:
+				status = http.StatusForbidden
+				http.Error(w, "Session belongs to another caller", status)
+			default:
+				w.WriteHeader(status)
+			}
+			logger("facade").Info("session request", "method", r.Method, "path", r.URL.Path, "session", id, "status", status)
+			return
+
 		case http.MethodOptions:
-			w.Header().Set("Allow", "GET, HEAD, POST, OPTIONS")
+			w.Header().Set("Allow", "GET, HEAD, POST, DELETE, OPTIONS")
 			w.WriteHeader(http.StatusNoContent)
 			return
 
 		default:
-			w.Header().Set("Allow", "GET, HEAD, POST, OPTIONS")
+			w.Header().Set("Allow", "GET, HEAD, POST, DELETE, OPTIONS")
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-			log.Printf("<facade> %s %s?%s -> %d", r.Method, r.URL.Path, r.URL.RawQuery, http.StatusMethodNotAllowed)
+			logger("facade").Warn("method not allowed", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL.RawQuery), "status", http.StatusMethodNotAllowed)
 			return
 		}
-	})
-
-	// ---- start & shutdown ----
-	httpServer := &http.Server{
-		Addr:    config.McpProxy.Addr,
-		Handler: httpMux,
-	}
-
-	go func() {
-		log.Printf("Starting %s server", config.McpProxy.Type)
-		log.Printf("%s server listening on %s", config.McpProxy.Type, config.McpProxy.Addr)
-		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("ListenAndServe: %v", err)
-		}
-	}()
-
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-	<-sig
-	log.Println("Shutdown signal received")
+	}), applier.rolloutMiddleware(), transcripts.middleware(), recoverMiddleware("facade"), callerContextMiddleware(), facadeAuth, tracingMiddleware("facade"), cors.middleware("facade")))
 
-	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelShutdown()
-	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return err
-	}
-	return nil
+	return serve(ctx, httpMux, mcpPath)
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+)
+
+const defaultHTTP2MaxConcurrentStreams = 250
+
+// HTTP2Config controls HTTP/2 on the main listener. Enabled adds h2c (HTTP/2
+// with prior knowledge on cleartext connections) for reverse proxies that
+// speak HTTP/2 to their upstreams; false limits the server to HTTP/1.1. When
+// unset the net/http defaults apply.
+type HTTP2Config struct {
+	Enabled              *bool `json:"enabled,omitempty"`
+	MaxConcurrentStreams int   `json:"maxConcurrentStreams,omitempty"`
+}
+
+// configureHTTP2 sets the protocols and HTTP/2 limits of srv.
+func configureHTTP2(srv *http.Server, conf *HTTP2Config) {
+	if conf == nil {
+		return
+	}
+	if conf.MaxConcurrentStreams > 0 {
+		srv.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: conf.MaxConcurrentStreams}
+	}
+	if conf.Enabled == nil {
+		return
+	}
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	if *conf.Enabled {
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		if srv.HTTP2 == nil {
+			srv.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: defaultHTTP2MaxConcurrentStreams}
+		}
+		logger("http2").Info("HTTP/2 enabled (h2c on cleartext)", "maxConcurrentStreams", srv.HTTP2.MaxConcurrentStreams)
+	} else {
+		logger("http2").Info("HTTP/2 disabled; serving HTTP/1.1 only")
+	}
+	srv.Protocols = &protocols
+}
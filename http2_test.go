@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigureHTTP2ServesH2C(t *testing.T) {
+	enabled := true
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+	}))
+	configureHTTP2(srv.Config, &HTTP2Config{Enabled: &enabled, MaxConcurrentStreams: 10})
+	srv.Start()
+	defer srv.Close()
+	if srv.Config.HTTP2 == nil || srv.Config.HTTP2.MaxConcurrentStreams != 10 {
+		t.Fatalf("unexpected HTTP/2 config %+v", srv.Config.HTTP2)
+	}
+
+	var h2c http.Protocols
+	h2c.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &h2c}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("h2c request: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Proto"); got != "HTTP/2.0" {
+		t.Fatalf("expected HTTP/2.0, got %s", got)
+	}
+
+	resp, err = http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("HTTP/1.1 request: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Proto"); got != "HTTP/1.1" {
+		t.Fatalf("expected HTTP/1.1 to stay available, got %s", got)
+	}
+}
+
+func TestConfigureHTTP2Disabled(t *testing.T) {
+	disabled := false
+	srv := &http.Server{}
+	configureHTTP2(srv, &HTTP2Config{Enabled: &disabled})
+	if srv.Protocols == nil || srv.Protocols.HTTP2() || srv.Protocols.UnencryptedHTTP2() || !srv.Protocols.HTTP1() {
+		t.Fatalf("expected HTTP/1.1 only, got %v", srv.Protocols)
+	}
+	untouched := &http.Server{}
+	configureHTTP2(untouched, nil)
+	if untouched.Protocols != nil || untouched.HTTP2 != nil {
+		t.Fatal("expected net/http defaults without config")
+	}
+}
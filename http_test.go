@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"sync/atomic"
 	"testing"
 
+	"github.com/TBXark/optional-go"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -52,7 +54,7 @@ func TestBuildInitializeResultIncludesServerInfo(t *testing.T) {
 		},
 	}
 
-	result := buildInitializeResult(cfg, servers, nil, nil)
+	result := testProxy().buildInitializeResult(cfg, servers, nil, nil)
 
 	serverInfoValue, ok := result["serverInfo"]
 	if !ok {
@@ -162,7 +164,7 @@ func TestBuildManifestDocumentIncludesFacadeAndServerTools(t *testing.T) {
 		{Name: "extra"},
 	}
 
-	doc := buildManifestDocument(manifestCfg, baseURL, req, allTools, nil, nil, nil)
+	doc := testProxy().buildManifestDocument(manifestCfg, baseURL, req, allTools, nil, nil, nil)
 
 	rawTools, ok := doc["tools"].([]any)
 	if !ok {
@@ -269,7 +271,7 @@ func TestToolOverridesApplyAnnotations(t *testing.T) {
 		Renamed:       make(map[string]string),
 	}
 	sanitizeToolOverrideSet(set)
-	tools := collectTools(servers, set, nil)
+	tools := testProxy().collectTools(servers, set, nil)
 	if len(tools) == 0 {
 		t.Fatalf("expected tools from collectTools")
 	}
@@ -333,7 +335,7 @@ func TestToolOverridesApplyAnnotations(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to parse base URL: %v", err)
 	}
-	doc := buildManifestDocumentWithOverrides(manifestCfg, baseURL, nil, []mcp.Tool{{Name: "read_file"}}, nil, nil, nil, set)
+	doc := testProxy().buildManifestDocumentWithOverrides(manifestCfg, baseURL, nil, []mcp.Tool{{Name: "read_file"}}, nil, nil, nil, set)
 	rawTools, ok := doc["tools"].([]any)
 	if !ok {
 		t.Fatalf("expected tools array in manifest")
@@ -369,7 +371,7 @@ func TestToolOverridesApplyAnnotations(t *testing.T) {
 		t.Fatalf("read_file tool not found in manifest output")
 	}
 
-	init := buildInitializeResult(config, servers, set, nil)
+	init := testProxy().buildInitializeResult(config, servers, set, nil)
 	initTools, ok := init["tools"].([]map[string]any)
 	if !ok || len(initTools) == 0 {
 		t.Fatalf("expected tools in initialize result")
@@ -408,7 +410,7 @@ func TestToolsListHTTPHandlerReturnsCatalog(t *testing.T) {
 			tools:     []mcp.Tool{{Name: "fetch"}},
 		},
 	}
-	handler := toolsListHTTPHandler(&ready, servers, nil, nil)
+	handler := testProxy().toolsListHTTPHandler(&ready, func() map[string]*Server { return servers }, nil, nil)
 	req := httptest.NewRequest(http.MethodGet, "/tools/list", nil)
 	resp := httptest.NewRecorder()
 	handler(resp, req)
@@ -439,7 +441,7 @@ func TestToolsListHTTPHandlerReturnsCatalog(t *testing.T) {
 }
 
 func TestToolsListHTTPHandlerRejectsNonGET(t *testing.T) {
-	handler := toolsListHTTPHandler(&atomic.Bool{}, map[string]*Server{}, nil, nil)
+	handler := testProxy().toolsListHTTPHandler(&atomic.Bool{}, func() map[string]*Server { return nil }, nil, nil)
 	req := httptest.NewRequest(http.MethodPost, "/tools/list", nil)
 	resp := httptest.NewRecorder()
 	handler(resp, req)
@@ -494,7 +496,7 @@ func TestCollectToolsIncludesFacadeAndServerCatalog(t *testing.T) {
 		},
 	}
 
-	tools := collectTools(servers, nil, nil)
+	tools := testProxy().collectTools(servers, nil, nil)
 	if len(tools) != 3 {
 		t.Fatalf("expected facade search/fetch plus summarize, got %d", len(tools))
 	}
@@ -528,7 +530,7 @@ func TestCollectToolsIncludesFacadeAndServerCatalog(t *testing.T) {
 }
 
 func TestCollectToolsProvidesFacadeFallbacks(t *testing.T) {
-	tools := collectTools(map[string]*Server{}, nil, nil)
+	tools := testProxy().collectTools(map[string]*Server{}, nil, nil)
 	if len(tools) != 2 {
 		t.Fatalf("expected facade fallback tools, got %d entries", len(tools))
 	}
@@ -618,3 +620,107 @@ func TestBuildFacadeSearchPayloadReturnsDeterministicHits(t *testing.T) {
 		t.Fatalf("expected exactly %d hits, got %d", len(expectedIDs), len(results))
 	}
 }
+
+func TestCollectToolsAttachesProvenance(t *testing.T) {
+	reviewed := &ProvenanceConfig{Source: "https://github.com/org/fs-server", Version: "1.4.2", SecurityReview: "approved"}
+	servers := map[string]*Server{
+		"fs":  {name: "fs", provenance: reviewed, tools: []mcp.Tool{{Name: "read_file"}, {Name: "stat"}}},
+		"alt": {name: "alt", tools: []mcp.Tool{{Name: "stat"}, {Name: "list"}}},
+	}
+	byName := make(map[string]map[string]any)
+	for _, tool := range testProxy().collectTools(servers, nil, nil) {
+		name, _ := tool["name"].(string)
+		byName[name] = tool
+	}
+	provenanceOf := func(name string) map[string]*ProvenanceConfig {
+		meta, _ := byName[name]["x-stelae"].(map[string]any)
+		provenance, _ := meta["provenance"].(map[string]*ProvenanceConfig)
+		return provenance
+	}
+	if got := provenanceOf("read_file"); len(got) != 1 || got["fs"] != reviewed {
+		t.Fatalf("read_file provenance = %v", got)
+	}
+	if got := provenanceOf("stat"); len(got) != 1 || got["fs"] != reviewed {
+		t.Fatalf("stat provenance should list only servers that declare it, got %v", got)
+	}
+	if meta, _ := byName["list"]["x-stelae"].(map[string]any); meta["provenance"] != nil {
+		t.Fatalf("list should carry no provenance, got %v", meta)
+	}
+}
+
+func TestCatalogOrderAndStableToolIDs(t *testing.T) {
+	build := func(schema mcp.ToolInputSchema) map[string]*Server {
+		servers := make(map[string]*Server)
+		for _, name := range []string{"fs", "alt", "web", "db"} {
+			servers[name] = &Server{
+				name:      name,
+				tools:     []mcp.Tool{{Name: name + "_query", InputSchema: schema}, {Name: "stat", InputSchema: schema}},
+				prompts:   []mcp.Prompt{{Name: name + "-prompt"}, {Name: "shared"}},
+				resources: []mcp.Resource{{URI: "file:///" + name, Name: name}},
+			}
+		}
+		return servers
+	}
+	schema := mcp.ToolInputSchema{Type: "object", Properties: map[string]any{"path": map[string]any{"type": "string"}}}
+	first, _ := json.Marshal(testProxy().buildInitializeResult(nil, build(schema), nil, nil))
+	for range 10 {
+		again, _ := json.Marshal(testProxy().buildInitializeResult(nil, build(schema), nil, nil))
+		if string(again) != string(first) {
+			t.Fatalf("catalog order changed between builds:\n%s\n%s", first, again)
+		}
+	}
+
+	idOf := func(servers map[string]*Server, name string) string {
+		for _, tool := range testProxy().collectTools(servers, nil, nil) {
+			if tool["name"] == name {
+				meta, _ := tool["x-stelae"].(map[string]any)
+				id, _ := meta["id"].(string)
+				return id
+			}
+		}
+		return ""
+	}
+	id := idOf(build(schema), "stat")
+	if !strings.HasPrefix(id, "alt:stat:") || len(id) != len("alt:stat:")+12 {
+		t.Fatalf("stat id = %q", id)
+	}
+	if idOf(build(schema), "stat") != id {
+		t.Fatal("id differs between identical builds")
+	}
+	schema.Required = []string{"path"}
+	if idOf(build(schema), "stat") == id {
+		t.Fatal("a schema change kept the same id")
+	}
+}
+
+func TestPrefixedToolsDoNotShadow(t *testing.T) {
+	prefixed := &MCPClientConfigV2{Options: &OptionsV2{PrefixTools: optional.NewField(true)}}
+	servers := map[string]*Server{}
+	for _, name := range []string{"docs", "code"} {
+		srv, err := newMCPServer(name, &MCPProxyConfigV2{Type: MCPServerTypeStreamable}, prefixed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.addTool(mcp.Tool{Name: "lookup"})
+		servers[name] = srv
+	}
+	index := testProxy().indexCatalog(servers, nil)
+	for _, name := range []string{"docs", "code"} {
+		if owner, ok := index.owner("tool", name+"_lookup"); !ok || owner != name {
+			t.Fatalf("%s_lookup owner = %q, %t", name, owner, ok)
+		}
+		if got := servers[name].downstreamToolName(name + "_lookup"); got != "lookup" {
+			t.Fatalf("downstream name = %q", got)
+		}
+	}
+	if _, ok := index.owner("tool", "lookup"); ok {
+		t.Fatal("the unprefixed name should not resolve")
+	}
+	names := map[string]bool{}
+	for _, tool := range testProxy().collectTools(servers, nil, nil) {
+		names[tool["name"].(string)] = true
+	}
+	if !names["docs_lookup"] || !names["code_lookup"] || names["lookup"] {
+		t.Fatalf("collectTools names = %v", names)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// InstructionsConfig composes the instructions of the facade's initialize
+// result from those downstream servers gave at initialize. Text is the
+// proxy's own instructions and comes first. Include limits the servers to
+// those listed, Exclude drops servers, and Order lists servers to put first;
+// the rest follow by name. Disabled drops downstream instructions, leaving
+// only Text.
+type InstructionsConfig struct {
+	Text     string   `json:"text,omitempty"`
+	Include  []string `json:"include,omitempty"`
+	Exclude  []string `json:"exclude,omitempty"`
+	Order    []string `json:"order,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// composeInstructions joins the proxy text and each server's instructions
+// under a "## <server>" heading, separated by blank lines. It returns ""
+// when there is nothing to say.
+func composeInstructions(conf *InstructionsConfig, servers map[string]*Server) string {
+	if conf == nil {
+		conf = &InstructionsConfig{}
+	}
+	var parts []string
+	if text := strings.TrimSpace(conf.Text); text != "" {
+		parts = append(parts, text)
+	}
+	if conf.Disabled {
+		return strings.Join(parts, "\n\n")
+	}
+	for _, name := range instructionOrder(conf, servers) {
+		if text := serverInstructions(servers[name]); text != "" {
+			parts = append(parts, fmt.Sprintf("## %s\n\n%s", name, text))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// instructionOrder lists the servers whose instructions are included:
+// conf.Order first, then the others by name.
+func instructionOrder(conf *InstructionsConfig, servers map[string]*Server) []string {
+	included := func(name string) bool {
+		if _, ok := servers[name]; !ok || slices.Contains(conf.Exclude, name) {
+			return false
+		}
+		return len(conf.Include) == 0 || slices.Contains(conf.Include, name)
+	}
+	var names []string
+	for _, name := range conf.Order {
+		if included(name) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	rest := make([]string, 0, len(servers))
+	for name := range servers {
+		if included(name) && !slices.Contains(names, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// serverInstructions is what srv's server said at its latest initialize.
+func serverInstructions(srv *Server) string {
+	if srv == nil || srv.client == nil {
+		return ""
+	}
+	result, _ := srv.client.initialized()
+	if result == nil {
+		return ""
+	}
+	return strings.TrimSpace(result.Instructions)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestComposeInstructions(t *testing.T) {
+	withInstructions := func(name, text string) *Server {
+		return &Server{name: name, client: &Client{name: name, initResult: &mcp.InitializeResult{Instructions: text}}}
+	}
+	servers := map[string]*Server{
+		"fs":     withInstructions("fs", "Paths are relative to /srv.\n"),
+		"git":    withInstructions("git", "Never force-push."),
+		"search": withInstructions("search", "Prefer exact phrases."),
+		"quiet":  withInstructions("quiet", ""),
+		"down":   {name: "down"},
+	}
+
+	if got := composeInstructions(nil, servers); got != "## fs\n\nPaths are relative to /srv.\n\n## git\n\nNever force-push.\n\n## search\n\nPrefer exact phrases." {
+		t.Fatalf("default = %q", got)
+	}
+	conf := &InstructionsConfig{Text: "Use the facade tools.", Exclude: []string{"fs"}, Order: []string{"search", "fs"}}
+	if got := composeInstructions(conf, servers); got != "Use the facade tools.\n\n## search\n\nPrefer exact phrases.\n\n## git\n\nNever force-push." {
+		t.Fatalf("ordered = %q", got)
+	}
+	conf = &InstructionsConfig{Include: []string{"git", "missing"}}
+	if got := composeInstructions(conf, servers); got != "## git\n\nNever force-push." {
+		t.Fatalf("included = %q", got)
+	}
+	conf = &InstructionsConfig{Text: "Proxy only.", Disabled: true}
+	if got := composeInstructions(conf, servers); got != "Proxy only." {
+		t.Fatalf("disabled = %q", got)
+	}
+
+	result := testProxy().buildInitializeResult(&Config{McpProxy: &MCPProxyConfigV2{Instructions: &InstructionsConfig{Disabled: true}}}, servers, nil, nil)
+	if _, ok := result["instructions"]; ok {
+		t.Fatalf("empty instructions advertised: %v", result["instructions"])
+	}
+	if result := testProxy().buildInitializeResult(nil, servers, nil, nil); result["instructions"] == nil {
+		t.Fatal("downstream instructions dropped")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONEncodingConfig controls how the proxy formats the JSON it writes:
+// facade and admin responses, in-process dispatch results, and the catalog
+// snapshots under the state home.
+type JSONEncodingConfig struct {
+	// SortKeys orders every object's keys, struct fields included, so
+	// output diffs cleanly.
+	SortKeys bool `json:"sortKeys,omitempty"`
+	// Indent pretty-prints responses with this string ("  ", "\t");
+	// responses are compact by default.
+	Indent string `json:"indent,omitempty"`
+	// CompactSnapshots writes snapshots on one line instead of indented.
+	CompactSnapshots bool `json:"compactSnapshots,omitempty"`
+	// PreserveNumbers keeps numbers as written when the proxy decodes and
+	// re-encodes a downstream result, so integers past 2^53 stay exact.
+	PreserveNumbers bool `json:"preserveNumbers,omitempty"`
+}
+
+const defaultSnapshotIndent = "  "
+
+func newJSONEncoding(conf *JSONEncodingConfig) (JSONEncodingConfig, error) {
+	if conf == nil {
+		return JSONEncodingConfig{}, nil
+	}
+	if strings.Trim(conf.Indent, " \t") != "" {
+		return JSONEncodingConfig{}, fmt.Errorf("json.indent must contain only spaces or tabs, got %q", conf.Indent)
+	}
+	return *conf, nil
+}
+
+// encode writes v to w as one response document, newline terminated.
+func (c JSONEncodingConfig) encode(w io.Writer, v any) error {
+	if c.SortKeys {
+		sorted, err := sortedJSONValue(v)
+		if err != nil {
+			return err
+		}
+		v = sorted
+	}
+	enc := json.NewEncoder(w)
+	if c.Indent != "" {
+		enc.SetIndent("", c.Indent)
+	}
+	return enc.Encode(v)
+}
+
+// marshalSnapshot encodes v for a file written under the state home.
+// Snapshots are indented unless configured compact.
+func (c JSONEncodingConfig) marshalSnapshot(v any) ([]byte, error) {
+	if c.SortKeys {
+		sorted, err := sortedJSONValue(v)
+		if err != nil {
+			return nil, err
+		}
+		v = sorted
+	}
+	if c.CompactSnapshots {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", defaultSnapshotIndent)
+}
+
+// decode unmarshals a downstream payload, keeping numbers as json.Number
+// when PreserveNumbers is set.
+func (c JSONEncodingConfig) decode(data []byte, v any) error {
+	if !c.PreserveNumbers {
+		return json.Unmarshal(data, v)
+	}
+	return c.decodeFrom(bytes.NewReader(data), v)
+}
+
+// decodeFrom is decode for a payload read from r, such as a recorded body
+// spilled to disk.
+func (c JSONEncodingConfig) decodeFrom(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	if c.PreserveNumbers {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid character after top-level value")
+	}
+	return nil
+}
+
+// sortedJSONValue round-trips v through generic maps, which encoding/json
+// writes with sorted keys. Numbers survive as json.Number.
+func sortedJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
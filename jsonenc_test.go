@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	resp := rpcOK(json.RawMessage(`7`), map[string]any{"b": 1, "a": json.RawMessage(`9007199254740993`)})
+
+	var out bytes.Buffer
+	if err := (JSONEncodingConfig{}).encode(&out, resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != `{"jsonrpc":"2.0","id":7,"result":{"a":9007199254740993,"b":1}}`+"\n" {
+		t.Fatalf("default encoding = %s", got)
+	}
+
+	out.Reset()
+	if err := (JSONEncodingConfig{SortKeys: true, Indent: " "}).encode(&out, resp); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n \"id\": 7,\n \"jsonrpc\": \"2.0\",\n \"result\": {\n  \"a\": 9007199254740993,\n  \"b\": 1\n }\n}\n"
+	if out.String() != want {
+		t.Fatalf("sorted, indented encoding = %q", out.String())
+	}
+
+	if data, _ := (JSONEncodingConfig{CompactSnapshots: true}).marshalSnapshot(map[string]any{"x": []int{1}}); string(data) != `{"x":[1]}` {
+		t.Fatalf("compact snapshot = %s", data)
+	}
+
+	preserve := JSONEncodingConfig{PreserveNumbers: true}
+	var payload map[string]any
+	if err := preserve.decode([]byte(`{"id":9007199254740993}`), &payload); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	_ = preserve.encode(&out, payload)
+	if out.String() != `{"id":9007199254740993}`+"\n" {
+		t.Fatalf("number lost precision: %s", out.String())
+	}
+
+	payload = nil
+	if err := preserve.decodeFrom(strings.NewReader(`{"id":9007199254740993}`+"\n"), &payload); err != nil || payload["id"] != json.Number("9007199254740993") {
+		t.Fatalf("decodeFrom = %v, %v", payload, err)
+	}
+	if err := (JSONEncodingConfig{}).decodeFrom(strings.NewReader(`{} {}`), &payload); err == nil {
+		t.Fatal("decodeFrom accepted trailing data")
+	}
+
+	if _, err := newJSONEncoding(&JSONEncodingConfig{Indent: "xx"}); err == nil {
+		t.Fatal("accepted a non-whitespace indent")
+	}
+}
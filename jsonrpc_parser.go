@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	rpcCodeParseError = -32700
+
+	defaultMaxRequestBytes = stdioMaxLineBytes
+	defaultMaxRequestDepth = 64
+	defaultMaxBatchEntries = 100
+)
+
+// RequestLimitsConfig bounds the JSON-RPC messages the facade and the
+// per-server routes accept. Zero fields take the defaults.
+type RequestLimitsConfig struct {
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+	MaxDepth int   `json:"maxDepth,omitempty"`
+	MaxBatch int   `json:"maxBatch,omitempty"`
+}
+
+// rpcParser checks a message before anything else reads it: size, UTF-8,
+// syntax, nesting depth, duplicate object keys at any level, and the
+// envelope fields. Messages that pass decode the same way under every
+// JSON parser, so policies and downstream servers cannot disagree on what
+// a request says.
+type rpcParser struct {
+	maxBytes int64
+	maxDepth int
+	maxBatch int
+}
+
+func newRPCParser(conf *RequestLimitsConfig) (*rpcParser, error) {
+	p := &rpcParser{maxBytes: defaultMaxRequestBytes, maxDepth: defaultMaxRequestDepth, maxBatch: defaultMaxBatchEntries}
+	if conf == nil {
+		return p, nil
+	}
+	if conf.MaxBytes < 0 || conf.MaxDepth < 0 || conf.MaxBatch < 0 {
+		return nil, errors.New("requestLimits: limits must not be negative")
+	}
+	if conf.MaxBytes > 0 {
+		p.maxBytes = conf.MaxBytes
+	}
+	if conf.MaxDepth > 0 {
+		p.maxDepth = conf.MaxDepth
+	}
+	if conf.MaxBatch > 0 {
+		p.maxBatch = conf.MaxBatch
+	}
+	return p, nil
+}
+
+// rpcParseError is why a message was refused: errNameParseError when it is
+// not JSON, errNameInvalidRequest when it is not an acceptable request.
+type rpcParseError struct {
+	name   string
+	detail string
+}
+
+func (e *rpcParseError) Error() string { return e.name + ": " + e.detail }
+
+func parseErrorf(format string, args ...any) *rpcParseError {
+	return &rpcParseError{name: errNameParseError, detail: fmt.Sprintf(format, args...)}
+}
+
+func invalidRequestf(format string, args ...any) *rpcParseError {
+	return &rpcParseError{name: errNameInvalidRequest, detail: fmt.Sprintf(format, args...)}
+}
+
+// errRequestTooLarge is returned by read for bodies over maxBytes.
+var errRequestTooLarge = errors.New("request body too large")
+
+// read reads the body of r, refusing more than maxBytes.
+func (p *rpcParser) read(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, p.maxBytes))
+	_ = r.Body.Close()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, errRequestTooLarge
+	}
+	return body, err
+}
+
+// parse checks body and returns the request it holds, or the raw entries
+// of a batch. Batch entries are checked as a whole here and one by one
+// when they are dispatched.
+func (p *rpcParser) parse(body []byte) (*jsonrpcRequest, []json.RawMessage, *rpcParseError) {
+	if int64(len(body)) > p.maxBytes {
+		return nil, nil, invalidRequestf("message exceeds %d bytes", p.maxBytes)
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, nil, parseErrorf("empty message")
+	}
+	if !utf8.Valid(trimmed) {
+		return nil, nil, parseErrorf("message is not valid UTF-8")
+	}
+	if err := p.scan(trimmed); err != nil {
+		return nil, nil, err
+	}
+	if trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return nil, nil, parseErrorf("%v", err)
+		}
+		if len(batch) > p.maxBatch {
+			return nil, nil, invalidRequestf("batch of %d entries exceeds %d", len(batch), p.maxBatch)
+		}
+		return nil, batch, nil
+	}
+	req, err := parseEnvelope(trimmed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, nil, nil
+}
+
+// scanFrame is an open object or array during scan.
+type scanFrame struct {
+	object  bool
+	wantKey bool
+	// fold compares keys ignoring case, as encoding/json binds them
+	fold bool
+	keys map[string]struct{}
+}
+
+// scan walks every token of data once, rejecting syntax errors, trailing
+// values, nesting deeper than maxDepth, and objects with duplicate keys.
+// Keys of the envelope and of params also may not differ only in case.
+func (p *rpcParser) scan(data []byte) *rpcParseError {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var stack []*scanFrame
+	values := 0
+	// closed records a complete value in its parent
+	closed := func() {
+		if len(stack) == 0 {
+			values++
+			return
+		}
+		if top := stack[len(stack)-1]; top.object {
+			top.wantKey = true
+		}
+	}
+	for {
+		if len(stack) == 0 && values == 1 {
+			if _, err := dec.Token(); err != io.EOF {
+				return parseErrorf("unexpected data after the message")
+			}
+			return nil
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return parseErrorf("%v", err)
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			switch v {
+			case '{', '[':
+				if len(stack) >= p.maxDepth {
+					return invalidRequestf("nesting exceeds depth %d", p.maxDepth)
+				}
+				if len(stack) > 0 {
+					stack[len(stack)-1].wantKey = false
+				}
+				frame := &scanFrame{object: v == '{', wantKey: v == '{', fold: len(stack) < 2}
+				if frame.object {
+					frame.keys = make(map[string]struct{})
+				}
+				stack = append(stack, frame)
+			default:
+				stack = stack[:len(stack)-1]
+				closed()
+			}
+		case string:
+			if len(stack) > 0 && stack[len(stack)-1].wantKey {
+				top := stack[len(stack)-1]
+				key := v
+				if top.fold {
+					key = strings.ToLower(strings.ToUpper(v))
+				}
+				if _, dup := top.keys[key]; dup {
+					return invalidRequestf("duplicate key %q", v)
+				}
+				top.keys[key] = struct{}{}
+				top.wantKey = false
+				continue
+			}
+			closed()
+		default:
+			closed()
+		}
+	}
+}
+
+// rpcEnvelopeFields checks each member of a message envelope. Members not
+// listed are ignored; members differing from one only in case are refused,
+// since encoding/json would bind them to the field.
+var rpcEnvelopeFields = map[string]func(raw json.RawMessage) error{
+	"jsonrpc": func(raw json.RawMessage) error {
+		var v string
+		if json.Unmarshal(raw, &v) != nil || v != "2.0" {
+			return errors.New(`jsonrpc must be "2.0"`)
+		}
+		return nil
+	},
+	"id": func(raw json.RawMessage) error {
+		switch jsonKind(raw) {
+		case '"', '0', 'n':
+			return nil
+		}
+		return errors.New("id must be a string, number, or null")
+	},
+	"method": func(raw json.RawMessage) error {
+		var v string
+		if json.Unmarshal(raw, &v) != nil || v == "" {
+			return errors.New("method must be a non-empty string")
+		}
+		return nil
+	},
+	"params": func(raw json.RawMessage) error {
+		// null is not JSON-RPC 2.0, but some clients send it for no params
+		switch jsonKind(raw) {
+		case '{', '[', 'n':
+			return nil
+		}
+		return errors.New("params must be an object or array")
+	},
+	"result": func(raw json.RawMessage) error { return nil },
+	"error": func(raw json.RawMessage) error {
+		if jsonKind(raw) != '{' {
+			return errors.New("error must be an object")
+		}
+		return nil
+	},
+	"sessionId": func(raw json.RawMessage) error {
+		if jsonKind(raw) != '"' {
+			return errors.New("sessionId must be a string")
+		}
+		return nil
+	},
+}
+
+// parseEnvelope checks a scanned message against rpcEnvelopeFields and
+// decodes it. Requests need a method; responses an id and one of result
+// or error.
+func parseEnvelope(data []byte) (*jsonrpcRequest, *rpcParseError) {
+	if jsonKind(data) != '{' {
+		return nil, invalidRequestf("message must be an object or array")
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, parseErrorf("%v", err)
+	}
+	for key, raw := range members {
+		check, ok := rpcEnvelopeFields[key]
+		if !ok {
+			for field := range rpcEnvelopeFields {
+				if strings.EqualFold(key, field) {
+					return nil, invalidRequestf("unexpected member %q", key)
+				}
+			}
+			continue
+		}
+		if err := check(raw); err != nil {
+			return nil, invalidRequestf("%v", err)
+		}
+	}
+	if _, ok := members["jsonrpc"]; !ok {
+		return nil, invalidRequestf(`jsonrpc must be "2.0"`)
+	}
+	_, hasMethod := members["method"]
+	_, hasResult := members["result"]
+	_, hasError := members["error"]
+	_, hasID := members["id"]
+	switch {
+	case hasMethod && (hasResult || hasError):
+		return nil, invalidRequestf("a request cannot carry result or error")
+	case !hasMethod && (!hasID || hasResult == hasError):
+		return nil, invalidRequestf("method is required")
+	}
+	var req jsonrpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, invalidRequestf("%v", err)
+	}
+	return &req, nil
+}
+
+// jsonKind classifies a JSON value by its first byte: '{', '[', '"', 'n'
+// (null), 't' (boolean), or '0' (number).
+func jsonKind(raw json.RawMessage) byte {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return 0
+	}
+	switch c := raw[0]; c {
+	case '{', '[', '"', 'n':
+		return c
+	case 't', 'f':
+		return 't'
+	}
+	return '0'
+}
+
+// reject answers a refused message with its JSON-RPC error, as a 400 since
+// its id may be unknown.
+func (p *rpcParser) reject(enc JSONEncodingConfig, w http.ResponseWriter, r *http.Request, err *rpcParseError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = enc.encode(w, localizedErrors(r.Context()).response(nil, err.name, map[string]string{"detail": err.detail}))
+}
+
+// middleware checks every POSTed message of a per-server route before its
+// policies or the downstream server read it.
+func (p *rpcParser) middleware(enc JSONEncodingConfig, prefix string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := p.read(w, r)
+			if errors.Is(err, errRequestTooLarge) {
+				http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+				routeLogger(prefix).Warn("request body too large", "method", r.Method, "path", r.URL.Path, "maxBytes", p.maxBytes, "status", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			_, batch, perr := p.parse(body)
+			for i := 0; perr == nil && i < len(batch); i++ {
+				var req *jsonrpcRequest
+				if req, _, perr = p.parse(batch[i]); perr == nil && req == nil {
+					perr = invalidRequestf("nested batch")
+				}
+				if perr != nil {
+					perr.detail = fmt.Sprintf("batch entry %d: %s", i, perr.detail)
+				}
+			}
+			if perr != nil {
+				p.reject(enc, w, r, perr)
+				routeLogger(prefix).Warn("refused message", "method", r.Method, "path", r.URL.Path, "err", perr)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
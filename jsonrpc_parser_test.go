@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRPCParser(t *testing.T) {
+	p, err := newRPCParser(&RequestLimitsConfig{MaxBytes: 256, MaxDepth: 4, MaxBatch: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		body string
+		want string // "" accepted, else the error name
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, ""},
+		{` {"jsonrpc":"2.0","method":"notifications/initialized","params":{}} `, ""},
+		{`{"jsonrpc":"2.0","id":"a","result":{}}`, ""},
+		{`{"jsonrpc":"2.0","id":2,"error":{"code":1,"message":"x"}}`, ""},
+		{`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`, ""},
+		{`{"jsonrpc":"2.0","id":1,"method":"x","extra":true}`, ""},
+		{``, errNameParseError},
+		{`{"jsonrpc":"2.0",`, errNameParseError},
+		{`{"jsonrpc":"2.0","id":1,"method":"ping"} {}`, errNameParseError},
+		{"{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"\xff\"}", errNameParseError},
+		{`{"jsonrpc":"2.0","id":1,"method":"ping","method":"tools/call"}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"a","Name":"b"}}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"a","name":"b"}}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"Method":"tools/call"}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"x","params":{"a":{"b":{"c":{}}}}}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"x","params":{"a":[1,2]}}`, ""},
+		{`{"id":1,"method":"ping"}`, errNameInvalidRequest},
+		{`{"jsonrpc":"1.0","id":1,"method":"ping"}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":{},"method":"ping"}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":""}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"ping","params":"x"}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"ping","result":{}}`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1}`, errNameInvalidRequest},
+		{`"ping"`, errNameInvalidRequest},
+		{`[1,2,3]`, errNameInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"x","params":{"pad":"` + strings.Repeat("a", 256) + `"}}`, errNameInvalidRequest},
+	}
+	for _, c := range cases {
+		req, batch, perr := p.parse([]byte(c.body))
+		got := ""
+		if perr != nil {
+			got = perr.name
+		} else if (req == nil) == (batch == nil) {
+			t.Errorf("parse(%s) returned neither a request nor a batch", c.body)
+		}
+		if got != c.want {
+			t.Errorf("parse(%s) = %q (%v), want %q", c.body, got, perr, c.want)
+		}
+	}
+
+	req, _, _ := p.parse([]byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"echo"}}`))
+	if req.Method != "tools/call" || req.ID != float64(7) || string(req.Params) != `{"name":"echo"}` {
+		t.Fatalf("decoded %+v", req)
+	}
+	if _, err := newRPCParser(&RequestLimitsConfig{MaxDepth: -1}); err == nil {
+		t.Fatal("negative limit accepted")
+	}
+}
+
+func TestRPCParserMiddleware(t *testing.T) {
+	p, err := newRPCParser(&RequestLimitsConfig{MaxBytes: 128})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen string
+	handler := p.middleware(JSONEncodingConfig{}, "fs")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(body)
+		seen = string(body)
+	}))
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/fs/mcp", strings.NewReader(body)))
+		return rec
+	}
+
+	ok := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	if rec := post(ok); rec.Code != http.StatusOK || seen != ok {
+		t.Fatalf("valid message: %d, downstream saw %q", rec.Code, seen)
+	}
+	seen = ""
+	rec := post(`[{"jsonrpc":"2.0","id":1,"method":"ping"},[]]`)
+	var resp jsonrpcResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusBadRequest || resp.Error == nil || resp.Error.Code != -32600 || seen != "" {
+		t.Fatalf("nested batch: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(`{"jsonrpc":"2.0","id":1,"method":"x","params":{"pad":"` + strings.Repeat("a", 200) + `"}}`); rec.Code != http.StatusRequestEntityTooLarge || seen != "" {
+		t.Fatalf("oversized body: %d", rec.Code)
+	}
+}
+
+func FuzzRPCParser(f *testing.F) {
+	for _, seed := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`,
+		`{"jsonrpc":"2.0","id":"x","result":{"content":[]}}`,
+		`{"jsonrpc":"2.0","id":1,"method":"a","method":"b"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"a","params":{"name":"a","NAME":"b"}}`,
+		`[[[[[[[[[[[[[[[[[[[[]]]]]]]]]]]]]]]]]]]]`,
+		`{"a":1}{"b":2}`,
+		"\"\xc3\x28\"",
+	} {
+		f.Add([]byte(seed))
+	}
+	p, err := newRPCParser(&RequestLimitsConfig{MaxDepth: 16, MaxBatch: 8})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		req, batch, perr := p.parse(body)
+		if perr != nil {
+			if perr.name != errNameParseError && perr.name != errNameInvalidRequest {
+				t.Fatalf("unexpected error name %q", perr.name)
+			}
+			return
+		}
+		if !json.Valid(body) {
+			t.Fatalf("accepted invalid JSON %q", body)
+		}
+		if req == nil {
+			if batch == nil || len(batch) > p.maxBatch {
+				t.Fatalf("accepted batch of %d entries", len(batch))
+			}
+			return
+		}
+		if req.JSONRPC != "2.0" || (req.Method == "" && req.ID == nil) {
+			t.Fatalf("accepted envelope %+v", req)
+		}
+		// what was accepted decodes the same when members are read one by one
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(body, &members); err != nil {
+			t.Fatal(err)
+		}
+		if method, ok := members["method"]; ok {
+			var m string
+			if json.Unmarshal(method, &m) != nil || m != req.Method {
+				t.Fatalf("method %s decoded as %q", method, req.Method)
+			}
+		}
+	})
+}
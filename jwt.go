@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultJWKSCacheTTL = 10 * time.Minute
+	// jwksRefreshInterval bounds how often an unknown key id, or a failing
+	// key set endpoint, refetches the key set.
+	jwksRefreshInterval = 30 * time.Second
+	// jwtLeeway absorbs clock skew in exp and nbf checks.
+	jwtLeeway = time.Minute
+)
+
+// jwtAlgorithms are the signature algorithms tokens may use; in particular
+// not none and not the HMAC ones, whose key would be public.
+var jwtAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// JWTConfig accepts JWT bearer tokens signed by a key of the JWKS at
+// JWKSURL. The key set is cached for CacheTTL and refetched early when a
+// token names an unknown key. Issuer, when set, must equal the iss claim,
+// and one of Audience must be in aud. Scopes come from the scope (or scp)
+// claim; ScopeMappings add scopes for other claim values.
+type JWTConfig struct {
+	JWKSURL       string             `json:"jwksURL"`
+	Issuer        string             `json:"issuer,omitempty"`
+	Audience      []string           `json:"audience,omitempty"`
+	CacheTTL      time.Duration      `json:"cacheTTL,omitempty"`
+	ScopeMappings []*JWTScopeMapping `json:"scopeMappings,omitempty"`
+}
+
+// JWTScopeMapping grants Scopes to tokens whose Claim is, or contains,
+// Value.
+type JWTScopeMapping struct {
+	Claim  string   `json:"claim"`
+	Value  string   `json:"value"`
+	Scopes []string `json:"scopes"`
+}
+
+// jwtValidator checks JWT signatures and claims against a JWKS.
+type jwtValidator struct {
+	conf   JWTConfig
+	client *http.Client
+	now    func() time.Time
+	// fetches lets concurrent requests that need the key set share one
+	// fetch, which is made without holding mu.
+	fetches singleflight.Group
+
+	mu        sync.Mutex
+	keys      map[string]jose.JSONWebKey
+	fetchedAt time.Time
+	// attemptedAt is when the key set was last fetched, successfully or
+	// not.
+	attemptedAt time.Time
+}
+
+func newJWTValidator(conf *JWTConfig) (*jwtValidator, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.JWKSURL == "" {
+		return nil, errors.New("jwt: jwksURL is required")
+	}
+	if len(conf.Audience) == 0 {
+		return nil, errors.New("jwt: audience is required")
+	}
+	for i, m := range conf.ScopeMappings {
+		if m == nil || m.Claim == "" || len(m.Scopes) == 0 {
+			return nil, fmt.Errorf("jwt: scopeMappings[%d] needs a claim and scopes", i)
+		}
+	}
+	c := *conf
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = defaultJWKSCacheTTL
+	}
+	return &jwtValidator{conf: c, client: &http.Client{Timeout: 10 * time.Second}, now: time.Now}, nil
+}
+
+// jwtValidators shares one validator, and so one key cache, between the
+// servers that inherit the same options.jwt.
+var jwtValidators = struct {
+	mu sync.Mutex
+	m  map[*JWTConfig]*jwtValidator
+}{m: make(map[*JWTConfig]*jwtValidator)}
+
+func sharedJWTValidator(conf *JWTConfig) (*jwtValidator, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	jwtValidators.mu.Lock()
+	defer jwtValidators.mu.Unlock()
+	if v, ok := jwtValidators.m[conf]; ok {
+		return v, nil
+	}
+	v, err := newJWTValidator(conf)
+	if err != nil {
+		return nil, err
+	}
+	jwtValidators.m[conf] = v
+	return v, nil
+}
+
+// looksLikeJWT reports whether token has the three segments of a JWS.
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// validate verifies token and its claims. Tokens that fail verification
+// are errOAuthInvalidToken; other errors mean the key set could not be
+// fetched.
+func (v *jwtValidator) validate(ctx context.Context, token string) (oauthToken, error) {
+	parsed, err := jwt.ParseSigned(token, jwtAlgorithms)
+	if err != nil || len(parsed.Headers) != 1 {
+		return oauthToken{}, errOAuthInvalidToken
+	}
+	header := parsed.Headers[0]
+	key, err := v.key(ctx, header.KeyID, header.Algorithm)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	var registered jwt.Claims
+	var claims map[string]any
+	if err := parsed.Claims(key.Key, &registered, &claims); err != nil {
+		return oauthToken{}, errOAuthInvalidToken
+	}
+	return v.checkClaims(registered, claims)
+}
+
+// checkClaims applies the time, issuer, and audience checks and collects
+// the token's scopes. A token must carry exp, so that none is valid
+// forever.
+func (v *jwtValidator) checkClaims(registered jwt.Claims, claims map[string]any) (oauthToken, error) {
+	if registered.Expiry == nil {
+		return oauthToken{}, errOAuthInvalidToken
+	}
+	expected := jwt.Expected{Issuer: v.conf.Issuer, AnyAudience: v.conf.Audience, Time: v.now()}
+	if err := registered.ValidateWithLeeway(expected, jwtLeeway); err != nil {
+		return oauthToken{}, errOAuthInvalidToken
+	}
+	tok := oauthToken{Subject: registered.Subject, Expiry: registered.Expiry.Time()}
+	tok.ClientID, _ = claims["client_id"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		tok.Scopes = strings.Fields(scope)
+	} else {
+		tok.Scopes = jwtClaimStrings(claims["scp"])
+	}
+	for _, m := range v.conf.ScopeMappings {
+		if slices.Contains(jwtClaimStrings(claims[m.Claim]), m.Value) {
+			for _, scope := range m.Scopes {
+				if !slices.Contains(tok.Scopes, scope) {
+					tok.Scopes = append(tok.Scopes, scope)
+				}
+			}
+		}
+	}
+	return tok, nil
+}
+
+// jwtClaimStrings reads a claim that is a string or a list of strings.
+func jwtClaimStrings(claim any) []string {
+	switch c := claim.(type) {
+	case string:
+		return []string{c}
+	case []any:
+		out := make([]string, 0, len(c))
+		for _, item := range c {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// key returns the key kid for alg. The key set is fetched when the cache
+// is stale or, when kid is unknown, so that rotated keys are picked up; a
+// set that is present is refetched at most every jwksRefreshInterval. When
+// a fetch fails, the last key set fetched stays in use.
+func (v *jwtValidator) key(ctx context.Context, kid, alg string) (jose.JSONWebKey, error) {
+	now := v.now()
+	v.mu.Lock()
+	keys, fetchedAt, attemptedAt := v.keys, v.fetchedAt, v.attemptedAt
+	v.mu.Unlock()
+
+	key, ok := lookupJWK(keys, kid, alg)
+	stale := keys == nil || now.Sub(fetchedAt) > v.conf.CacheTTL
+	if (stale || !ok) && (keys == nil || now.Sub(attemptedAt) > jwksRefreshInterval) {
+		fresh, err := v.refresh(ctx)
+		switch {
+		case err == nil:
+			keys = fresh
+		case keys == nil:
+			return jose.JSONWebKey{}, err
+		default:
+			logger("jwt").Warn("key set refresh failed; keeping the last key set", "age", now.Sub(fetchedAt).Round(time.Second), "err", err)
+		}
+		key, ok = lookupJWK(keys, kid, alg)
+	}
+	if !ok {
+		return jose.JSONWebKey{}, errOAuthInvalidToken
+	}
+	return key, nil
+}
+
+// lookupJWK finds kid in keys. Without a kid the only key of the set is
+// used.
+func lookupJWK(keys map[string]jose.JSONWebKey, kid, alg string) (jose.JSONWebKey, bool) {
+	key, ok := keys[kid]
+	if kid == "" && len(keys) == 1 {
+		for _, only := range keys {
+			key, ok = only, true
+		}
+	}
+	if !ok || (key.Algorithm != "" && key.Algorithm != alg) {
+		return jose.JSONWebKey{}, false
+	}
+	return key, true
+}
+
+// refresh fetches the key set and, when that succeeds, caches it. Callers
+// waiting on one fetch each stop waiting when their own ctx ends; the fetch
+// itself is bounded by the client's timeout.
+func (v *jwtValidator) refresh(ctx context.Context) (map[string]jose.JSONWebKey, error) {
+	ch := v.fetches.DoChan("", func() (any, error) {
+		keys, err := v.fetch(context.WithoutCancel(ctx))
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.attemptedAt = v.now()
+		if err != nil {
+			return nil, err
+		}
+		v.keys, v.fetchedAt = keys, v.attemptedAt
+		return keys, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(map[string]jose.JSONWebKey), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch reads the signing keys of the key set; keys of other uses,
+// unsupported types, and symmetric keys are skipped.
+func (v *jwtValidator) fetch(ctx context.Context) (map[string]jose.JSONWebKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.conf.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: %s", resp.Status)
+	}
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	keys := make(map[string]jose.JSONWebKey, len(set.Keys))
+	for _, raw := range set.Keys {
+		var key jose.JSONWebKey
+		if key.UnmarshalJSON(raw) != nil || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		if _, symmetric := key.Key.([]byte); symmetric {
+			continue
+		}
+		if !key.IsPublic() {
+			key = key.Public()
+		}
+		keys[key.KeyID] = key
+	}
+	return keys, nil
+}
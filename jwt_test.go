@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testJWKS serves a key set that tests can rotate.
+type testJWKS struct {
+	mu      sync.Mutex
+	keys    []map[string]any
+	fetches atomic.Int32
+	failing atomic.Bool
+}
+
+func (s *testJWKS) set(keys ...map[string]any) {
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+func (s *testJWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.fetches.Add(1)
+	if s.failing.Load() {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+}
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]any {
+	return map[string]any{"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]any{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func TestJWTValidator(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	jwks := &testJWKS{}
+	jwks.set(
+		rsaJWK("rsa-1", rsaKey),
+		map[string]any{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		map[string]any{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": b64(edPub)},
+	)
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	v, err := newJWTValidator(&JWTConfig{
+		JWKSURL:       server.URL,
+		Issuer:        "https://auth.example.com",
+		Audience:      []string{"mcp-proxy"},
+		ScopeMappings: []*JWTScopeMapping{{Claim: "groups", Value: "admins", Scopes: []string{"mcp:admin"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	v.now = func() time.Time { return now }
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{"iss": "https://auth.example.com", "aud": []string{"mcp-proxy"}, "sub": "alice", "exp": now.Add(time.Hour).Unix(), "scope": "mcp:tools"}
+		for k, val := range extra {
+			c[k] = val
+		}
+		return c
+	}
+
+	tok, err := v.validate(t.Context(), signJWT(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"groups": []string{"admins"}})))
+	if err != nil || tok.Subject != "alice" || !slices.Equal(tok.Scopes, []string{"mcp:tools", "mcp:admin"}) {
+		t.Fatalf("RS256 token = %+v, %v", tok, err)
+	}
+	if _, err := v.validate(t.Context(), signJWT(t, "ES256", "ec-1", ecKey, claims(nil))); err != nil {
+		t.Fatalf("ES256 token: %v", err)
+	}
+	if _, err := v.validate(t.Context(), signJWT(t, "EdDSA", "ed-1", edKey, claims(nil))); err != nil {
+		t.Fatalf("EdDSA token: %v", err)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rejected := map[string]string{
+		"wrong issuer":   signJWT(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"iss": "https://evil.example.com"})),
+		"wrong audience": signJWT(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"aud": "someone-else"})),
+		"expired":        signJWT(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})),
+		"not yet valid":  signJWT(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})),
+		"bad signature":  signJWT(t, "RS256", "rsa-1", otherKey, claims(nil)),
+		"alg mismatch":   signJWT(t, "ES256", "rsa-1", ecKey, claims(nil)),
+		"no exp":         signJWT(t, "RS256", "rsa-1", rsaKey, map[string]any{"iss": "https://auth.example.com", "aud": "mcp-proxy", "sub": "alice"}),
+		"alg none":       b64([]byte(`{"alg":"none","kid":"rsa-1"}`)) + "." + b64([]byte(`{"sub":"alice"}`)) + ".",
+	}
+	for name, token := range rejected {
+		if _, err := v.validate(t.Context(), token); err != errOAuthInvalidToken {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+
+	// a rotated key is picked up once the refresh interval has passed
+	fetches := jwks.fetches.Load()
+	jwks.set(rsaJWK("rsa-2", otherKey))
+	rotated := signJWT(t, "RS256", "rsa-2", otherKey, claims(nil))
+	if _, err := v.validate(t.Context(), rotated); err != errOAuthInvalidToken || jwks.fetches.Load() != fetches {
+		t.Fatalf("unknown key refetched within the refresh interval: %v", err)
+	}
+	now = now.Add(2 * jwksRefreshInterval)
+	if _, err := v.validate(t.Context(), rotated); err != nil || jwks.fetches.Load() != fetches+1 {
+		t.Fatalf("rotated key: %v, fetches %d", err, jwks.fetches.Load()-fetches)
+	}
+
+	// a key set that cannot be refetched leaves the last one in use, and is
+	// retried no more than every refresh interval
+	jwks.failing.Store(true)
+	now = now.Add(defaultJWKSCacheTTL + time.Second)
+	if _, err := v.validate(t.Context(), signJWT(t, "RS256", "rsa-2", otherKey, claims(nil))); err != nil || jwks.fetches.Load() != fetches+2 {
+		t.Fatalf("after a failed refresh: %v, fetches %d", err, jwks.fetches.Load()-fetches)
+	}
+	if _, err := v.validate(t.Context(), signJWT(t, "RS256", "rsa-2", otherKey, claims(nil))); err != nil || jwks.fetches.Load() != fetches+2 {
+		t.Fatalf("failing key set refetched within the refresh interval: %v", err)
+	}
+
+	if _, err := newJWTValidator(&JWTConfig{Audience: []string{"mcp-proxy"}}); err == nil {
+		t.Fatal("config without jwksURL accepted")
+	}
+	if _, err := newJWTValidator(&JWTConfig{JWKSURL: server.URL}); err == nil {
+		t.Fatal("config without audience accepted")
+	}
+}
+
+func TestAuthMiddlewareJWT(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := &testJWKS{}
+	jwks.set(rsaJWK("k", key))
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+	v, err := newJWTValidator(&JWTConfig{JWKSURL: server.URL, Audience: []string{"mcp-proxy"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subject string
+	handler := newAuthMiddleware(newRouteTokens([]string{"static"}, nil, "x"), v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tok, _ := oauthTokenFromContext(r.Context())
+		subject = tok.Subject
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/fs/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := call("static"); code != http.StatusNoContent {
+		t.Fatalf("static token: %d", code)
+	}
+	good := signJWT(t, "RS256", "k", key, map[string]any{"sub": "bob", "aud": "mcp-proxy", "exp": time.Now().Add(time.Hour).Unix()})
+	if code := call(good); code != http.StatusNoContent || subject != "bob" {
+		t.Fatalf("jwt: %d subject=%q", code, subject)
+	}
+	for _, token := range []string{"unknown", signJWT(t, "RS256", "k", key, map[string]any{"aud": "other"})} {
+		if code := call(token); code != http.StatusUnauthorized {
+			t.Fatalf("%q: %d", token, code)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Clients of the 2024-11-05 HTTP+SSE transport open a GET stream first,
+// with no Mcp-Session-Id, and are told in an endpoint event where to POST
+// their messages. The facade serves them from the same path: the endpoint
+// is the facade path with the session in the sessionId query parameter,
+// POSTs there are answered 202, and their replies go out on the stream.
+// Streamable HTTP clients always send Mcp-Session-Id after initialize, so
+// the header tells the two apart.
+
+// legacySSETransport is the session transport of HTTP+SSE clients.
+const legacySSETransport = "sse"
+
+// isLegacySSEStream reports whether a GET opens an HTTP+SSE stream rather
+// than the event stream of a streamable HTTP session.
+func isLegacySSEStream(r *http.Request) bool {
+	return strings.TrimSpace(r.Header.Get(sessionIDHeader)) == "" && !isWebSocketUpgrade(r)
+}
+
+// legacySSEEndpoint is the data of the endpoint event of session id.
+func legacySSEEndpoint(mcpPath, id string) string {
+	return fmt.Sprintf("%s?sessionId=%s", mcpPath, id)
+}
+
+// legacySSESession returns the HTTP+SSE session a POST was sent to; POSTs
+// carrying Mcp-Session-Id are streamable HTTP.
+func legacySSESession(r *http.Request, sessions *facadeSessionTable) (string, bool) {
+	if strings.TrimSpace(r.Header.Get(sessionIDHeader)) != "" {
+		return "", false
+	}
+	id := r.URL.Query().Get("sessionId")
+	if id == "" || sessions.transport(id) != legacySSETransport || !sessions.touch(id) {
+		return "", false
+	}
+	return id, true
+}
+
+// serveLegacySSEMessage accepts a message POSTed by an HTTP+SSE client and
+// dispatches it through the facade as part of session id, sending the
+// reply on the session's stream. The dispatch outlives the POST.
+func serveLegacySSEMessage(w http.ResponseWriter, r *http.Request, id string, body []byte, handler http.Handler, mcpPath string, streams *sessionStreams) {
+	header := r.Header.Clone()
+	header.Set(sessionIDHeader, id)
+	ctx := context.WithoutCancel(r.Context())
+	w.WriteHeader(http.StatusAccepted)
+	go func() {
+		reply, _ := dispatchFacadeMessage(ctx, handler, mcpPath, header, body)
+		if len(reply) == 0 {
+			return
+		}
+		if streams.send(id, reply) == 0 {
+			logger("facade").Warn("SSE dropped reply: no open stream", "session", id)
+		}
+	}()
+}
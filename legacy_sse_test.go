@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLegacySSEDetection(t *testing.T) {
+	get := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	if !isLegacySSEStream(get) {
+		t.Fatal("GET without a session id is not HTTP+SSE")
+	}
+	get.Header.Set(sessionIDHeader, "abc")
+	if isLegacySSEStream(get) {
+		t.Fatal("GET with Mcp-Session-Id taken for HTTP+SSE")
+	}
+
+	sessions := newFacadeSessionTable(0)
+	legacy := sessions.open(legacySSETransport, "anonymous")
+	streamable := sessions.open("streamable-http", "anonymous")
+	post := func(query string, header bool) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/mcp"+query, nil)
+		if header {
+			r.Header.Set(sessionIDHeader, legacy)
+		}
+		return r
+	}
+	if id, ok := legacySSESession(post("?sessionId="+legacy, false), sessions); !ok || id != legacy {
+		t.Fatalf("legacy POST = %q, %v", id, ok)
+	}
+	for name, r := range map[string]*http.Request{
+		"streamable session": post("?sessionId="+streamable, false),
+		"unknown session":    post("?sessionId=nope", false),
+		"session header":     post("?sessionId="+legacy, true),
+		"no session":         post("", false),
+	} {
+		if _, ok := legacySSESession(r, sessions); ok {
+			t.Errorf("%s taken for HTTP+SSE", name)
+		}
+	}
+}
+
+func TestServeLegacySSEMessage(t *testing.T) {
+	facade := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpcRequest
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = (JSONEncodingConfig{}).encode(w, rpcOK(req.ID, map[string]any{"session": r.Header.Get(sessionIDHeader)}))
+	})
+	streams := newSessionStreams()
+	replies, unsubscribe := streams.subscribe("sess-1")
+	defer unsubscribe()
+
+	send := func(body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/mcp?sessionId=sess-1", strings.NewReader(body))
+		ctx, cancel := context.WithCancel(r.Context())
+		rec := httptest.NewRecorder()
+		serveLegacySSEMessage(rec, r.WithContext(ctx), "sess-1", []byte(body), facade, "/mcp", streams)
+		// the dispatch outlives the POST
+		cancel()
+		return rec.Code
+	}
+	if code := send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`); code != http.StatusAccepted {
+		t.Fatalf("notification status = %d", code)
+	}
+	if code := send(`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`); code != http.StatusAccepted {
+		t.Fatalf("request status = %d", code)
+	}
+	select {
+	case msg := <-replies:
+		var reply struct {
+			ID     int               `json:"id"`
+			Result map[string]string `json:"result"`
+		}
+		if err := json.Unmarshal(msg, &reply); err != nil || reply.ID != 3 || reply.Result["session"] != "sess-1" {
+			t.Fatalf("reply = %s", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reply on the stream")
+	}
+	select {
+	case msg := <-replies:
+		t.Fatalf("unexpected message %s", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHandleSSEEndpointEvent(t *testing.T) {
+	for endpoint, want := range map[string]bool{"/mcp?sessionId=0b6c-41": true, "": false} {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := httptest.NewRequest(http.MethodGet, "/mcp", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			handleSSE(rec, r, endpoint, nil, nil)
+			close(done)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		<-done
+		got := strings.Contains(rec.Body.String(), "event: endpoint\ndata: "+endpoint+"\n\n")
+		if got != want || (!want && strings.Contains(rec.Body.String(), "event: endpoint")) {
+			t.Fatalf("endpoint %q: stream %q", endpoint, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Load-balancing strategies for servers that share mcpServers.*.group.
+const (
+	// balanceRoundRobin rotates calls across the group's healthy replicas.
+	// This is the default.
+	balanceRoundRobin = "round-robin"
+	// balanceLeastConnections sends each call to the healthy replica with
+	// the fewest calls in flight, breaking ties by rotation.
+	balanceLeastConnections = "least-connections"
+)
+
+// LoadBalancingConfig is mcpProxy.loadBalancing. Strategy applies to every
+// group; Groups sets it per group.
+type LoadBalancingConfig struct {
+	Strategy string            `json:"strategy,omitempty"`
+	Groups   map[string]string `json:"groups,omitempty"`
+}
+
+// Session affinity keys and hashes.
+const (
+	affinityKeySession = "session"
+	affinityKeyCaller  = "caller"
+	affinityKeyHeader  = "header"
+
+	// affinityHashRendezvous scores every replica against the key and takes
+	// the best, so losing a replica only moves the sessions it held.
+	affinityHashRendezvous = "rendezvous"
+	// affinityHashModulo takes the key's hash modulo the replica count.
+	affinityHashModulo = "modulo"
+)
+
+// SessionAffinityConfig pins calls to one replica of a server group per
+// key. Key is session (the facade session, else the caller), caller (the
+// bearer token identity), or header (the value of Header). Hash is
+// rendezvous (default) or modulo.
+type SessionAffinityConfig struct {
+	Key    string `json:"key,omitempty"`
+	Header string `json:"header,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+func (c *SessionAffinityConfig) validate() error {
+	switch c.Key {
+	case "", affinityKeySession, affinityKeyCaller:
+	case affinityKeyHeader:
+		if strings.TrimSpace(c.Header) == "" {
+			return fmt.Errorf("sessionAffinity: key header needs a header name")
+		}
+	default:
+		return fmt.Errorf("sessionAffinity: unknown key %q (want session, caller, or header)", c.Key)
+	}
+	switch c.Hash {
+	case "", affinityHashRendezvous, affinityHashModulo:
+		return nil
+	}
+	return fmt.Errorf("sessionAffinity: unknown hash %q (want rendezvous or modulo)", c.Hash)
+}
+
+// callAffinity is what a call offers session affinity to hash on.
+type callAffinity struct {
+	caller callerInfo
+	header http.Header
+}
+
+// key returns the value conf hashes for the call, "" when it has none.
+func (a callAffinity) key(conf *SessionAffinityConfig) string {
+	switch conf.Key {
+	case affinityKeyCaller:
+		return a.caller.Identity
+	case affinityKeyHeader:
+		return strings.TrimSpace(a.header.Get(conf.Header))
+	}
+	return a.caller.sessionKey()
+}
+
+// replicaBalancer treats servers of one group as replicas of a logical
+// server: they count as one server for conflict policies, and each call
+// to the group goes to one healthy replica.
+type replicaBalancer struct {
+	strategy string
+	groups   map[string]string
+	// groupOf returns a server's group, "" for none.
+	groupOf func(server string) string
+	// healthy reports whether a replica should take calls; nil means all do.
+	healthy func(server string) bool
+	// affinityOf returns a replica's session affinity, nil for none.
+	affinityOf func(server string) *SessionAffinityConfig
+
+	mu       sync.Mutex
+	next     map[string]uint64
+	inFlight map[string]int
+}
+
+func mustReplicaBalancer(conf *LoadBalancingConfig) *replicaBalancer {
+	b, err := newReplicaBalancer(conf)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func newReplicaBalancer(conf *LoadBalancingConfig) (*replicaBalancer, error) {
+	b := &replicaBalancer{
+		strategy: balanceRoundRobin,
+		groups:   make(map[string]string),
+		next:     make(map[string]uint64),
+		inFlight: make(map[string]int),
+	}
+	if conf == nil {
+		return b, nil
+	}
+	check := func(strategy string) error {
+		switch strategy {
+		case balanceRoundRobin, balanceLeastConnections:
+			return nil
+		}
+		return fmt.Errorf("loadBalancing: unknown strategy %q (want round-robin or least-connections)", strategy)
+	}
+	if conf.Strategy != "" {
+		if err := check(conf.Strategy); err != nil {
+			return nil, err
+		}
+		b.strategy = conf.Strategy
+	}
+	for group, strategy := range conf.Groups {
+		if err := check(strategy); err != nil {
+			return nil, err
+		}
+		b.groups[group] = strategy
+	}
+	return b, nil
+}
+
+// logical returns the name a server counts as for conflicts and prefixes:
+// its group, or its own name.
+func (b *replicaBalancer) logical(server string) string {
+	if b.groupOf != nil {
+		if group := b.groupOf(server); group != "" {
+			return group
+		}
+	}
+	return server
+}
+
+// pick chooses the replica of group for one call among servers, which all
+// belong to it. Unhealthy replicas are skipped unless none is healthy. With
+// session affinity set on any replica, a call with an affinity key always
+// lands on the same healthy replica.
+func (b *replicaBalancer) pick(group string, servers []string, affinity callAffinity) string {
+	if len(servers) == 1 {
+		return servers[0]
+	}
+	candidates := make([]string, 0, len(servers))
+	if b.healthy != nil {
+		for _, server := range servers {
+			if b.healthy(server) {
+				candidates = append(candidates, server)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = append(candidates, servers...)
+	}
+	sort.Strings(candidates)
+
+	if conf := b.affinity(servers); conf != nil {
+		if key := affinity.key(conf); key != "" {
+			return affinityPick(conf.Hash, key, candidates)
+		}
+	}
+
+	strategy := b.strategy
+	if s, ok := b.groups[group]; ok {
+		strategy = s
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.next[group]
+	b.next[group] = n + 1
+	if strategy == balanceLeastConnections {
+		best := ""
+		for i := range candidates {
+			server := candidates[(int(n%uint64(len(candidates)))+i)%len(candidates)]
+			if best == "" || b.inFlight[server] < b.inFlight[best] {
+				best = server
+			}
+		}
+		return best
+	}
+	return candidates[n%uint64(len(candidates))]
+}
+
+// affinity returns the session affinity of the first replica, by name, that
+// sets one.
+func (b *replicaBalancer) affinity(servers []string) *SessionAffinityConfig {
+	if b.affinityOf == nil {
+		return nil
+	}
+	ordered := append([]string(nil), servers...)
+	sort.Strings(ordered)
+	for _, server := range ordered {
+		if conf := b.affinityOf(server); conf != nil {
+			return conf
+		}
+	}
+	return nil
+}
+
+func affinityPick(hash, key string, candidates []string) string {
+	sum := func(parts ...string) uint64 {
+		h := fnv.New64a()
+		for _, part := range parts {
+			_, _ = h.Write([]byte(part))
+			_, _ = h.Write([]byte{0})
+		}
+		return h.Sum64()
+	}
+	if hash == affinityHashModulo {
+		return candidates[sum(key)%uint64(len(candidates))]
+	}
+	best, bestScore := "", uint64(0)
+	for _, server := range candidates {
+		if score := sum(key, server); best == "" || score > bestScore {
+			best, bestScore = server, score
+		}
+	}
+	return best
+}
+
+// begin counts a call in flight on server until the returned func runs.
+func (b *replicaBalancer) begin(server string) func() {
+	b.mu.Lock()
+	b.inFlight[server]++
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		if b.inFlight[server]--; b.inFlight[server] <= 0 {
+			delete(b.inFlight, server)
+		}
+		b.mu.Unlock()
+	}
+}
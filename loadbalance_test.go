@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestReplicaBalancerPick(t *testing.T) {
+	if _, err := newReplicaBalancer(&LoadBalancingConfig{Strategy: "random"}); err == nil {
+		t.Fatal("expected an unknown strategy to be rejected")
+	}
+	b := mustReplicaBalancer(&LoadBalancingConfig{Groups: map[string]string{"db": balanceLeastConnections}})
+	down := map[string]bool{}
+	b.healthy = func(server string) bool { return !down[server] }
+
+	var seen []string
+	for range 4 {
+		seen = append(seen, b.pick("search", []string{"search-2", "search-1", "search-3"}, callAffinity{}))
+	}
+	if !reflect.DeepEqual(seen, []string{"search-1", "search-2", "search-3", "search-1"}) {
+		t.Fatalf("round-robin = %v", seen)
+	}
+	down["search-2"] = true
+	for range 4 {
+		if got := b.pick("search", []string{"search-1", "search-2", "search-3"}, callAffinity{}); got == "search-2" {
+			t.Fatal("an unhealthy replica took a call")
+		}
+	}
+	down["search-1"], down["search-3"] = true, true
+	if got := b.pick("search", []string{"search-1", "search-2"}, callAffinity{}); got == "" {
+		t.Fatal("with every replica unhealthy the group should still be tried")
+	}
+
+	done := b.begin("db-1")
+	b.begin("db-1")
+	if got := b.pick("db", []string{"db-1", "db-2"}, callAffinity{}); got != "db-2" {
+		t.Fatalf("least-connections picked %s", got)
+	}
+	done()
+	b.begin("db-2")
+	b.begin("db-2")
+	if got := b.pick("db", []string{"db-1", "db-2"}, callAffinity{}); got != "db-1" {
+		t.Fatalf("least-connections picked %s", got)
+	}
+}
+
+func TestConflictPolicyTreatsReplicasAsOneServer(t *testing.T) {
+	p, _ := newToolConflictPolicy(&ConflictPolicyConfig{Mode: conflictPolicyError})
+	groups := map[string]string{"search-1": "search", "search-2": "search"}
+	p.replicas.groupOf = func(server string) string { return groups[server] }
+	tools := map[string][]string{"query": {"search-1", "search-2"}, "read": {"fs", "search-1", "search-2"}}
+	var seen []string
+	for range 2 {
+		route, err := p.route(tools, "query", nil)
+		if err != nil {
+			t.Fatalf("replicas conflicted: %v", err)
+		}
+		seen = append(seen, route.server)
+	}
+	if !reflect.DeepEqual(seen, []string{"search-1", "search-2"}) {
+		t.Fatalf("query routes = %v", seen)
+	}
+	var conflict *toolConflictError
+	if _, err := p.route(tools, "read", nil); !errors.As(err, &conflict) || !reflect.DeepEqual(conflict.servers, []string{"fs", "search"}) {
+		t.Fatalf("read = %v", err)
+	}
+
+	prefix, _ := newToolConflictPolicy(&ConflictPolicyConfig{Mode: conflictPolicyPrefix})
+	prefix.replicas = p.replicas
+	if route, err := prefix.route(tools, "search_read", nil); err != nil || route.tool != "read" || groups[route.server] != "search" {
+		t.Fatalf("search_read = %+v, %v", route, err)
+	}
+}
+
+func TestReplicaBalancerSessionAffinity(t *testing.T) {
+	if err := (&SessionAffinityConfig{Key: affinityKeyHeader}).validate(); err == nil {
+		t.Fatal("expected key header without a header name to be rejected")
+	}
+	if err := (&SessionAffinityConfig{Hash: "crc"}).validate(); err == nil {
+		t.Fatal("expected an unknown hash to be rejected")
+	}
+	b := mustReplicaBalancer(nil)
+	down := map[string]bool{}
+	b.healthy = func(server string) bool { return !down[server] }
+	affinity := map[string]*SessionAffinityConfig{"kv-2": {}}
+	b.affinityOf = func(server string) *SessionAffinityConfig { return affinity[server] }
+	replicas := []string{"kv-1", "kv-2", "kv-3"}
+
+	session := callAffinity{caller: callerInfo{Identity: "alice", SessionID: "s-1"}}
+	first := b.pick("kv", replicas, session)
+	for range 5 {
+		if got := b.pick("kv", replicas, session); got != first {
+			t.Fatalf("session moved from %s to %s", first, got)
+		}
+	}
+	if got := b.pick("kv", replicas, callAffinity{}); got == "" {
+		t.Fatal("a call without a session was not balanced")
+	}
+
+	// rendezvous hashing keeps the session when another replica goes away
+	for _, server := range replicas {
+		if server != first {
+			down[server] = true
+		}
+	}
+	if got := b.pick("kv", replicas, session); got != first {
+		t.Fatalf("session moved to %s when other replicas went down", got)
+	}
+	down = map[string]bool{first: true}
+	if got := b.pick("kv", replicas, session); got == first {
+		t.Fatal("session stayed on an unhealthy replica")
+	}
+
+	affinity["kv-2"] = &SessionAffinityConfig{Key: affinityKeyHeader, Header: "X-Tenant", Hash: affinityHashModulo}
+	down = map[string]bool{}
+	tenant := callAffinity{header: http.Header{"X-Tenant": {"acme"}}}
+	want := b.pick("kv", replicas, tenant)
+	tenant.caller = callerInfo{SessionID: "other"}
+	if got := b.pick("kv", replicas, tenant); got != want {
+		t.Fatalf("header affinity picked %s then %s", want, got)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LoggingConfig shapes the proxy's own log. Format is "text" (default, the
+// familiar "<component> message" lines with a level) or "json". Level is
+// the default threshold (default info); Levels overrides it per component
+// (facade, adapter, client, ...) or per server name. Each level is debug,
+// info, warn, error, or off.
+type LoggingConfig struct {
+	Format string            `json:"format,omitempty"`
+	Level  string            `json:"level,omitempty"`
+	Levels map[string]string `json:"levels,omitempty"`
+}
+
+// logComponents are the parts of the proxy that log; a downstream server's
+// lines are logged by component "client" with the server's name.
+var logComponents = map[string]bool{
+	"access": true, "adapter": true, "admin": true, "apply": true, "audit": true, "auth": true,
+	"catalog": true, "compression": true, "config": true, "diagnostics": true, "events": true,
+	"facade": true, "grpc": true, "health": true, "http2": true, "jwt": true, "manifest": true,
+	"notifications": true, "probe": true, "proxy": true, "quarantine": true, "rollout": true,
+	"spill": true, "startup": true, "stdio": true, "tls": true, "tracing": true,
+	"transcripts": true, "wire": true,
+}
+
+// logger returns the logger of a proxy component.
+func logger(component string) *slog.Logger {
+	return slog.Default().With("component", component)
+}
+
+// clientLogger returns the logger of a downstream server's lines, which
+// follow the server's logLevel and logPrefix options.
+func clientLogger(server string) *slog.Logger {
+	return slog.Default().With("component", "client", "server", server)
+}
+
+// routeLogger returns the logger of the component or server a route
+// belongs to, named as in its path.
+func routeLogger(name string) *slog.Logger {
+	if logComponents[name] {
+		return logger(name)
+	}
+	return clientLogger(name)
+}
+
+const logLevelOff = slog.Level(100)
+
+func parseLogLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "off", "none":
+		return logLevelOff, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// logServer is how one server's lines are leveled and prefixed.
+type logServer struct {
+	level  *slog.Level
+	prefix string
+}
+
+// logHandler is the slog handler behind both slog and the log package.
+// Records name their component, and for a downstream server the server,
+// in attributes; lines from the log package, which has neither, belong to
+// component proxy.
+type logHandler struct {
+	mu         *sync.Mutex
+	w          io.Writer
+	json       bool
+	level      slog.Level
+	lowest     slog.Level
+	components map[string]slog.Level
+	servers    map[string]logServer
+	attrs      []slog.Attr
+}
+
+// newLogHandler builds a handler writing to w from conf, the STELAE_LOG_*
+// environment (which wins over conf), and the servers' logLevel and
+// logPrefix options.
+func newLogHandler(w io.Writer, conf *LoggingConfig, servers map[string]*MCPClientConfigV2) (*logHandler, error) {
+	if conf == nil {
+		conf = &LoggingConfig{}
+	}
+	format, level := conf.Format, conf.Level
+	if v := os.Getenv("STELAE_LOG_FORMAT"); v != "" {
+		format = v
+	}
+	if v := os.Getenv("STELAE_LOG_LEVEL"); v != "" {
+		level = v
+	}
+	levels := make(map[string]string, len(conf.Levels))
+	for name, l := range conf.Levels {
+		levels[name] = l
+	}
+	for _, pair := range strings.Split(os.Getenv("STELAE_LOG_LEVELS"), ",") {
+		if name, l, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+			levels[strings.TrimSpace(name)] = l
+		}
+	}
+
+	h := &logHandler{mu: &sync.Mutex{}, w: w, components: make(map[string]slog.Level), servers: make(map[string]logServer)}
+	switch strings.ToLower(format) {
+	case "", "text":
+	case "json":
+		h.json = true
+	default:
+		return nil, fmt.Errorf("logging.format: unknown format %q", format)
+	}
+	var err error
+	if h.level, err = parseLogLevel(level); err != nil {
+		return nil, fmt.Errorf("logging.level: %w", err)
+	}
+	for name, conf := range servers {
+		if conf == nil || conf.Options == nil {
+			continue
+		}
+		s := logServer{prefix: conf.Options.LogPrefix}
+		if conf.Options.LogLevel != "" {
+			l, err := parseLogLevel(conf.Options.LogLevel)
+			if err != nil {
+				return nil, fmt.Errorf("mcpServers.%s.options.logLevel: %w", name, err)
+			}
+			s.level = &l
+		}
+		h.servers[name] = s
+	}
+	for name, l := range levels {
+		parsed, err := parseLogLevel(l)
+		if err != nil {
+			return nil, fmt.Errorf("logging.levels.%s: %w", name, err)
+		}
+		if _, ok := servers[name]; ok {
+			s := h.servers[name]
+			s.level = &parsed
+			h.servers[name] = s
+			continue
+		}
+		h.components[name] = parsed
+	}
+	h.lowest = h.level
+	for _, l := range h.components {
+		h.lowest = min(h.lowest, l)
+	}
+	for _, s := range h.servers {
+		if s.level != nil {
+			h.lowest = min(h.lowest, *s.level)
+		}
+	}
+	return h, nil
+}
+
+// configureLogging routes slog and the log package through the handler
+// config asks for.
+func configureLogging(config *Config) error {
+	h, err := newLogHandler(os.Stderr, config.McpProxy.Logging, config.McpServers)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// Enabled lets a record through to Handle when some component or server
+// would log it.
+func (h *logHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.lowest
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+func (h *logHandler) WithGroup(string) slog.Handler { return h }
+
+func (h *logHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+r.NumAttrs())
+	component, server := "proxy", ""
+	collect := func(a slog.Attr) bool {
+		switch a.Key {
+		case "component":
+			component = a.Value.String()
+		case "server":
+			if component == "client" && server == "" {
+				server = a.Value.String()
+				return true
+			}
+			attrs = append(attrs, a)
+		default:
+			attrs = append(attrs, a)
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		collect(a)
+	}
+	r.Attrs(collect)
+
+	threshold, ok := h.components[component]
+	if !ok {
+		threshold = h.level
+	}
+	if s, ok := h.servers[server]; ok && s.level != nil {
+		threshold = *s.level
+	}
+	if r.Level < threshold {
+		return nil
+	}
+
+	var b strings.Builder
+	if h.json {
+		fields := []slog.Attr{
+			slog.Time("time", r.Time),
+			slog.String("level", r.Level.String()),
+			slog.String("component", component),
+		}
+		if server != "" {
+			fields = append(fields, slog.String("server", server))
+		}
+		fields = append(fields, slog.String("msg", r.Message))
+		seen := map[string]bool{"time": true, "level": true, "component": true, "server": server != "", "msg": true}
+		for _, a := range attrs {
+			if !seen[a.Key] {
+				seen[a.Key] = true
+				fields = append(fields, a)
+			}
+		}
+		b.WriteByte('{')
+		for i, a := range fields {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(jsonLogString(a.Key))
+			b.WriteByte(':')
+			b.WriteString(jsonLogValue(a.Value))
+		}
+		b.WriteString("}\n")
+	} else {
+		b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+		b.WriteString(r.Level.String())
+		b.WriteByte(' ')
+		switch prefix := component; {
+		case component == "client":
+			prefix = server
+			if s, ok := h.servers[server]; ok && s.prefix != "" {
+				prefix = s.prefix
+			}
+			fallthrough
+		case component != "proxy":
+			b.WriteString("<" + prefix + "> ")
+		}
+		b.WriteString(r.Message)
+		for _, a := range attrs {
+			b.WriteString(" " + a.Key + "=" + textLogValue(a.Value))
+		}
+		b.WriteByte('\n')
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// textLogValue quotes a value that would not read as one token.
+func textLogValue(v slog.Value) string {
+	s := v.String()
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == '"' || r == '=' }) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func jsonLogValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return jsonLogString(v.Time().Format("2006-01-02T15:04:05.000Z07:00"))
+	case slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindBool:
+		return v.String()
+	}
+	return jsonLogString(v.String())
+}
+
+func jsonLogString(s string) string {
+	out, _ := json.Marshal(s)
+	return string(out)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogHandlerJSON(t *testing.T) {
+	var out bytes.Buffer
+	h, err := newLogHandler(&out, &LoggingConfig{Format: "json", Levels: map[string]string{"facade": "warn"}}, map[string]*MCPClientConfigV2{
+		"github": {Options: &OptionsV2{LogLevel: "debug"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(h)
+	log.With("component", "facade").Info("tools/call", "tool", "search", "server", "web", "status", 200)
+	log.With("component", "facade").Warn("tools/call failed", "tool", "search", "server", "web", "status", 502)
+	log.With("component", "client", "server", "github").Debug("Adding tool", "tool", "search")
+	log.With("component", "catalog").Info("search", "query", "two words", "hits", 3)
+	log.With("component", "catalog").Debug("refreshed index")
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("logged %d lines:\n%s", len(lines), out.String())
+	}
+	failed, github, search := lines[0], lines[1], lines[2]
+	if failed["level"] != "WARN" || failed["component"] != "facade" || failed["msg"] != "tools/call failed" ||
+		failed["tool"] != "search" || failed["server"] != "web" || failed["status"] != float64(502) {
+		t.Fatalf("failure line = %v", failed)
+	}
+	if github["level"] != "DEBUG" || github["component"] != "client" || github["server"] != "github" || github["tool"] != "search" {
+		t.Fatalf("server line = %v", github)
+	}
+	if search["query"] != "two words" || search["hits"] != float64(3) {
+		t.Fatalf("catalog line = %v", search)
+	}
+}
+
+func TestLogHandlerText(t *testing.T) {
+	t.Setenv("STELAE_LOG_LEVELS", "noisy=off, client=warn")
+	var out bytes.Buffer
+	h, err := newLogHandler(&out, nil, map[string]*MCPClientConfigV2{
+		"github-enterprise": {Options: &OptionsV2{LogPrefix: "gh"}},
+		"noisy":             {Options: &OptionsV2{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(h)
+	gh := log.With("component", "client", "server", "github-enterprise")
+	gh.Info("Connected")
+	gh.Warn("MCP Ping failed", "err", errors.New("EOF"), "count", 1)
+	log.With("component", "client", "server", "noisy").Warn("Connection lost; reconnecting")
+	log.With("component", "facade").Error("panic", "err", "boom", "route", "/mcp")
+	log.Info("Shutdown signal received")
+	logged := out.String()
+	if strings.Contains(logged, "Connected") || strings.Contains(logged, "reconnecting") {
+		t.Fatalf("filtered lines logged:\n%s", logged)
+	}
+	for _, want := range []string{
+		" WARN <gh> MCP Ping failed err=EOF count=1\n",
+		" ERROR <facade> panic err=boom route=/mcp\n",
+		" INFO Shutdown signal received\n",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Fatalf("missing %q in:\n%s", want, logged)
+		}
+	}
+
+	out.Reset()
+	log.With("component", "catalog").Info("search", "query", "two words", "empty", "")
+	if !strings.HasSuffix(out.String(), ` INFO <catalog> search query="two words" empty=""`+"\n") {
+		t.Fatalf("quoted values = %q", out.String())
+	}
+
+	if _, err := newLogHandler(&out, &LoggingConfig{Level: "loud"}, nil); err == nil {
+		t.Fatal("unknown level accepted")
+	}
+	if _, err := newLogHandler(&out, &LoggingConfig{Format: "xml"}, nil); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
+
+// captureLog makes a text handler at level the slog default for the rest
+// of the test and returns what it writes.
+func captureLog(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	h, err := newLogHandler(&out, &LoggingConfig{Level: level}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &out
+}
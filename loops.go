@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	rpcCodeLoopDetected = -32010
+
+	defaultLoopThreshold = 3
+	defaultLoopWindow    = 10 * time.Minute
+	defaultLoopMaxKeys   = 1000
+)
+
+// LoopDetectionConfig short-circuits a session that keeps retrying the same
+// failing tools/call.
+type LoopDetectionConfig struct {
+	Enabled   bool          `json:"enabled,omitempty"`
+	Threshold int           `json:"threshold,omitempty"`
+	Window    time.Duration `json:"window,omitempty"`
+}
+
+type loopEntry struct {
+	errorHash string
+	lastError string
+	count     int
+	lastSeen  time.Time
+}
+
+// loopState is what the facade reports when it refuses a looping call.
+type loopState struct {
+	Count      int       `json:"count"`
+	LastError  string    `json:"lastError,omitempty"`
+	RetryAfter time.Time `json:"retryAfter"`
+}
+
+// loopDetector counts consecutive identical failures per (session, tool,
+// arguments). Once a call has failed threshold times in a row with the same
+// error, further identical calls are refused until window has passed since
+// the last real failure. Any success, or a different error, starts over.
+type loopDetector struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	entries   map[string]*loopEntry
+}
+
+func newLoopDetector(conf *LoopDetectionConfig) *loopDetector {
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+	d := &loopDetector{
+		threshold: conf.Threshold,
+		window:    conf.Window,
+		entries:   make(map[string]*loopEntry),
+	}
+	if d.threshold <= 0 {
+		d.threshold = defaultLoopThreshold
+	}
+	if d.window <= 0 {
+		d.window = defaultLoopWindow
+	}
+	return d
+}
+
+func loopKey(session, tool string, args json.RawMessage) string {
+	return session + "|" + tool + "|" + canonicalHash(args)
+}
+
+// blocked reports whether the call should be refused without dispatching it.
+func (d *loopDetector) blocked(session, tool string, args json.RawMessage, now time.Time) (loopState, bool) {
+	if d == nil {
+		return loopState{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.entries[loopKey(session, tool, args)]
+	if !ok || entry.count < d.threshold || now.Sub(entry.lastSeen) > d.window {
+		return loopState{}, false
+	}
+	return loopState{Count: entry.count, LastError: entry.lastError, RetryAfter: entry.lastSeen.Add(d.window).UTC()}, true
+}
+
+// observe records a dispatched call's outcome and reports whether this
+// failure is the one that reached the threshold.
+func (d *loopDetector) observe(session, tool string, args json.RawMessage, digest responseDigest, failed bool, now time.Time) (loopState, bool) {
+	if d == nil {
+		return loopState{}, false
+	}
+	key := loopKey(session, tool, args)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !failed {
+		delete(d.entries, key)
+		return loopState{}, false
+	}
+	hash, summary := digest.ErrorHash, digest.ErrorSummary
+	entry, ok := d.entries[key]
+	if !ok || entry.errorHash != hash || now.Sub(entry.lastSeen) > d.window {
+		if !ok {
+			d.evictLocked(now)
+		}
+		entry = &loopEntry{errorHash: hash}
+		d.entries[key] = entry
+	}
+	entry.count++
+	entry.lastError = summary
+	entry.lastSeen = now
+	state := loopState{Count: entry.count, LastError: summary, RetryAfter: now.Add(d.window).UTC()}
+	return state, entry.count == d.threshold
+}
+
+// evictLocked drops stale entries, then the oldest ones beyond the key cap.
+func (d *loopDetector) evictLocked(now time.Time) {
+	for k, e := range d.entries {
+		if now.Sub(e.lastSeen) > d.window {
+			delete(d.entries, k)
+		}
+	}
+	for len(d.entries) >= defaultLoopMaxKeys {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range d.entries {
+			if oldestKey == "" || e.lastSeen.Before(oldest) {
+				oldestKey, oldest = k, e.lastSeen
+			}
+		}
+		delete(d.entries, oldestKey)
+	}
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
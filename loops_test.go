@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLoopDetectorTripsOnRepeatedIdenticalFailures(t *testing.T) {
+	d := newLoopDetector(&LoopDetectionConfig{Enabled: true, Threshold: 3, Window: time.Minute})
+	now := time.Unix(1_700_000_000, 0)
+	args := json.RawMessage(`{"path":"/missing","mode":"r"}`)
+	reordered := json.RawMessage(`{"mode":"r","path":"/missing"}`)
+	failure := digestBytes([]byte(`{"jsonrpc":"2.0","id":1,"result":{"isError":true,"content":[{"type":"text","text":"ENOENT: /missing"}]}}`))
+
+	for i := 1; i <= 2; i++ {
+		if _, tripped := d.observe("s1", "read_file", args, failure, true, now); tripped {
+			t.Fatalf("tripped too early at failure %d", i)
+		}
+	}
+	if _, blocked := d.blocked("s1", "read_file", args, now); blocked {
+		t.Fatalf("should not block below the threshold")
+	}
+	state, tripped := d.observe("s1", "read_file", reordered, failure, true, now)
+	if !tripped || state.Count != 3 || state.LastError != "ENOENT: /missing" {
+		t.Fatalf("expected third identical failure to trip, got %+v tripped=%t", state, tripped)
+	}
+	if _, blocked := d.blocked("s1", "read_file", args, now.Add(30*time.Second)); !blocked {
+		t.Fatalf("expected identical call to be blocked")
+	}
+	if _, blocked := d.blocked("s2", "read_file", args, now); blocked {
+		t.Fatalf("other sessions must not be blocked")
+	}
+	if _, blocked := d.blocked("s1", "read_file", json.RawMessage(`{"path":"/other"}`), now); blocked {
+		t.Fatalf("different arguments must not be blocked")
+	}
+	if _, blocked := d.blocked("s1", "read_file", args, now.Add(2*time.Minute)); blocked {
+		t.Fatalf("expected block to lapse after the window")
+	}
+}
+
+func TestLoopDetectorResetsOnSuccessOrNewError(t *testing.T) {
+	d := newLoopDetector(&LoopDetectionConfig{Enabled: true, Threshold: 2})
+	now := time.Now()
+	args := json.RawMessage(`{}`)
+	errA := digestBytes([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"a"}}`))
+	errB := digestBytes([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"b"}}`))
+
+	d.observe("s", "t", args, errA, true, now)
+	if _, tripped := d.observe("s", "t", args, errB, true, now); tripped {
+		t.Fatalf("a different error must start a new streak")
+	}
+	d.observe("s", "t", args, digestBytes([]byte(`{"result":{}}`)), false, now)
+	if _, tripped := d.observe("s", "t", args, errB, true, now); tripped {
+		t.Fatalf("a success must reset the streak")
+	}
+	if _, tripped := d.observe("s", "t", args, errB, true, now); !tripped {
+		t.Fatalf("expected second identical failure to trip")
+	}
+
+	var disabled *loopDetector
+	if _, blocked := disabled.blocked("s", "t", args, now); blocked {
+		t.Fatalf("nil detector must never block")
+	}
+}
@@ -1,19 +1,36 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
 )
 
 var BuildVersion = "dev"
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "schema":
+			if err := printConfigSchema(os.Stdout); err != nil {
+				log.Fatalf("Failed to print config schema: %v", err)
+			}
+			return
+		}
+	}
 	conf := flag.String("config", "config.json", "path to config file or a http(s) url")
 	insecure := flag.Bool("insecure", false, "allow insecure HTTPS connections by skipping TLS certificate verification")
 	expandEnv := flag.Bool("expand-env", true, "expand environment variables in config file")
 	httpHeaders := flag.String("http-headers", "", "optional HTTP headers for config URL, format: 'Key1:Value1;Key2:Value2'")
 	httpTimeout := flag.Int("http-timeout", 10, "HTTP timeout in seconds when fetching config from URL")
+	profile := flag.String("profile", "", "config profile to activate (defaults to $STELAE_PROFILE)")
+	stdio := flag.Bool("stdio", false, "serve the aggregated facade over stdin/stdout instead of HTTP")
+	captureDir := flag.String("capture-fixtures", "", "connect to the configured servers, write mock-server fixtures of them to this directory, and exit")
+	fixture := flag.String("serve-fixture", "", "serve a captured fixture file as a stdio MCP server")
 
 	version := flag.Bool("version", false, "print version and exit")
 	help := flag.Bool("help", false, "print help and exit")
@@ -26,12 +43,32 @@ func main() {
 		fmt.Println(BuildVersion)
 		return
 	}
-	config, err := load(*conf, *insecure, *expandEnv, *httpHeaders, *httpTimeout)
+	if *fixture != "" {
+		if err := serveFixture(context.Background(), *fixture, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Failed to serve fixture: %v", err)
+		}
+		return
+	}
+	config, err := load(*conf, *insecure, *expandEnv, *httpHeaders, *httpTimeout, activeProfile(*profile))
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatalStartup("Failed to load config: %v", err)
+	}
+	if err := configureLogging(config); err != nil {
+		fatalStartup("Invalid logging config: %v", err)
+	}
+	startupDiag.setConfig(config)
+	if *captureDir != "" {
+		if err := captureFixtures(context.Background(), config, *captureDir); err != nil {
+			log.Fatalf("Failed to capture fixtures: %v", err)
+		}
+		return
+	}
+	if *stdio {
+		err = startStdioServer(config)
+	} else {
+		err = startHTTPServer(config)
 	}
-	err = startHTTPServer(config)
 	if err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		fatalStartup("Failed to start server: %v", err)
 	}
 }
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MessagesConfig localizes the strings agents and users see from the proxy:
+// error messages and the descriptions of the facade's own tools. Requests
+// pick a locale with Accept-Language; DefaultLocale serves the rest and
+// may itself be one of Locales.
+type MessagesConfig struct {
+	DefaultLocale string                           `json:"defaultLocale,omitempty"`
+	Locales       map[string]*LocaleMessagesConfig `json:"locales,omitempty"`
+}
+
+// LocaleMessagesConfig is one locale's catalog. Errors are message templates
+// keyed by error name, as in mcpProxy.errors.messages; Strings are keyed by
+// message key. Missing entries fall back to the built-in English text.
+type LocaleMessagesConfig struct {
+	Errors  map[string]string `json:"errors,omitempty"`
+	Strings map[string]string `json:"strings,omitempty"`
+}
+
+const (
+	defaultMessageLocale  = "en"
+	acceptLanguageHeader  = "Accept-Language"
+	contentLanguageHeader = "Content-Language"
+)
+
+// Message keys of the client-visible strings outside the error registry.
+const (
+	msgSearchDescription = "search.description"
+	msgSearchServer      = "search.server"
+	msgSearchType        = "search.type"
+	msgSearchMimeType    = "search.mimeType"
+	msgFetchDescription  = "fetch.description"
+	msgFetchID           = "fetch.id"
+	msgFetchOffset       = "fetch.offset"
+	msgFetchLength       = "fetch.length"
+	msgFetchCursor       = "fetch.cursor"
+	msgFanOutDescription = "fan_out.description"
+	msgFanOutTool        = "fan_out.tool"
+	msgFanOutArguments   = "fan_out.arguments"
+	msgFanOutServers     = "fan_out.servers"
+)
+
+var builtinMessages = map[string]string{
+	msgSearchDescription: "Lightweight search placeholder exposed for ChatGPT connector verification.",
+	msgSearchServer:      "Only return items from this downstream server.",
+	msgSearchType:        "Only return items of this kind.",
+	msgSearchMimeType:    "Only return resources with this MIME type; accepts wildcards such as text/*.",
+	msgFetchDescription:  "Connector-compliant fetch placeholder used when no upstream descriptor is available.",
+	msgFetchID:           "Document id from search results, or an absolute URL when URL fetching is enabled.",
+	msgFetchOffset:       "Byte offset into the document text to start from.",
+	msgFetchLength:       "Maximum bytes of text to return; the response metadata carries nextCursor when more remains.",
+	msgFetchCursor:       "metadata.nextCursor from a previous fetch; continues where that page ended.",
+	msgFanOutDescription: "Call the same tool on every server that provides it, concurrently, and return the results grouped by server.",
+	msgFanOutTool:        "Tool name as the downstream servers expose it, without a conflict prefix.",
+	msgFanOutArguments:   "Arguments passed unchanged to every server.",
+	msgFanOutServers:     "Only call these servers (or server groups).",
+}
+
+// facadeToolDescription is the facadeToolMessages entry of a tool's own
+// description rather than a parameter's.
+const facadeToolDescription = ""
+
+// facadeToolMessages maps each facade tool to the keys of its description
+// and of its parameter descriptions.
+var facadeToolMessages = map[string]map[string]string{
+	facadeSearchToolName: {facadeToolDescription: msgSearchDescription, "server": msgSearchServer, "type": msgSearchType, "mimeType": msgSearchMimeType},
+	facadeFetchToolName:  {facadeToolDescription: msgFetchDescription, "id": msgFetchID, "offset": msgFetchOffset, "length": msgFetchLength, "cursor": msgFetchCursor},
+	facadeFanOutToolName: {facadeToolDescription: msgFanOutDescription, "tool": msgFanOutTool, "arguments": msgFanOutArguments, "servers": msgFanOutServers},
+}
+
+// messageCatalog holds the strings of every configured locale, each merged
+// over the built-in English text.
+type messageCatalog struct {
+	defaultLocale string
+	locales       map[string]map[string]string
+}
+
+// messages is the process-wide catalog; runProxy configures it from
+// mcpProxy.messages before serving.
+var messages, _ = newMessageCatalog(nil)
+
+func newMessageCatalog(conf *MessagesConfig) (*messageCatalog, error) {
+	c := &messageCatalog{defaultLocale: defaultMessageLocale, locales: map[string]map[string]string{defaultMessageLocale: builtinMessages}}
+	if conf == nil {
+		return c, nil
+	}
+	for locale, entry := range conf.Locales {
+		texts := make(map[string]string, len(builtinMessages))
+		for key, text := range builtinMessages {
+			texts[key] = text
+		}
+		if entry != nil {
+			for key, text := range entry.Strings {
+				if _, ok := builtinMessages[key]; !ok {
+					return nil, fmt.Errorf("messages.locales.%s.strings: unknown key %q", locale, key)
+				}
+				texts[key] = text
+			}
+		}
+		c.locales[normalizeLocale(locale)] = texts
+	}
+	if conf.DefaultLocale != "" {
+		c.defaultLocale = normalizeLocale(conf.DefaultLocale)
+		if _, ok := c.locales[c.defaultLocale]; !ok {
+			return nil, fmt.Errorf("messages.defaultLocale %q is not in messages.locales", conf.DefaultLocale)
+		}
+	}
+	return c, nil
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// text returns key in locale, falling back to the default locale.
+func (c *messageCatalog) text(locale, key string) string {
+	if texts, ok := c.locales[locale]; ok {
+		return texts[key]
+	}
+	return c.locales[c.defaultLocale][key]
+}
+
+// defaultText returns key in the default locale.
+func (c *messageCatalog) defaultText(key string) string {
+	return c.locales[c.defaultLocale][key]
+}
+
+// negotiate picks the configured locale that best matches an
+// Accept-Language header: the highest-weighted tag that names a locale
+// exactly or by its language, else the default locale.
+func (c *messageCatalog) negotiate(acceptLanguage string) string {
+	type tag struct {
+		locale string
+		q      float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		locale := normalizeLocale(fields[0])
+		if locale == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{locale, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	for _, t := range tags {
+		if _, ok := c.locales[t.locale]; ok {
+			return t.locale
+		}
+		if language, _, found := strings.Cut(t.locale, "-"); found {
+			if _, ok := c.locales[language]; ok {
+				return language
+			}
+		}
+	}
+	return c.defaultLocale
+}
+
+// localizeTools rewrites tools/list items for locale. Facade tool texts
+// still at their default-locale value are translated, so tool overrides
+// keep precedence, and tools with x-stelae.localizations for the locale
+// take their title and description from it. Items are copied, not
+// modified.
+func (c *messageCatalog) localizeTools(items []map[string]any, locale string) []map[string]any {
+	out := make([]map[string]any, len(items))
+	for i, item := range items {
+		name, _ := item["name"].(string)
+		if keys, ok := facadeToolMessages[name]; ok && locale != c.defaultLocale {
+			item = c.localizeFacadeTool(item, keys, locale)
+		}
+		out[i] = localizeToolDoc(item, locale)
+	}
+	return out
+}
+
+func (c *messageCatalog) localizeFacadeTool(item map[string]any, keys map[string]string, locale string) map[string]any {
+	item = copyStringAnyMap(item)
+	translate := func(current any, key string) (string, bool) {
+		text, _ := current.(string)
+		if text != c.text(c.defaultLocale, key) {
+			return "", false
+		}
+		return c.text(locale, key), true
+	}
+	if text, ok := translate(item["description"], keys[facadeToolDescription]); ok {
+		item["description"] = text
+	}
+	schema, _ := item["inputSchema"].(map[string]any)
+	props, _ := schema["properties"].(map[string]any)
+	if props == nil {
+		return item
+	}
+	schema = copyStringAnyMap(schema)
+	props = copyStringAnyMap(props)
+	for param, key := range keys {
+		prop, _ := props[param].(map[string]any)
+		if param == facadeToolDescription || prop == nil {
+			continue
+		}
+		if text, ok := translate(prop["description"], key); ok {
+			prop = copyStringAnyMap(prop)
+			prop["description"] = text
+			props[param] = prop
+		}
+	}
+	schema["properties"] = props
+	item["inputSchema"] = schema
+	return item
+}
+
+// localizeToolDoc applies the locale's entry of a tool's
+// x-stelae.localizations (see manifest.toolDocsDir).
+func localizeToolDoc(item map[string]any, locale string) map[string]any {
+	meta, _ := item["x-stelae"].(map[string]any)
+	locales, _ := meta["localizations"].(map[string]any)
+	var entry toolDocLocale
+	found := false
+	for key, value := range locales {
+		if normalizeLocale(key) == locale {
+			entry, found = value.(toolDocLocale)
+			break
+		}
+	}
+	if !found {
+		return item
+	}
+	item = copyStringAnyMap(item)
+	if entry.Description != "" {
+		item["description"] = entry.Description
+	}
+	if entry.Title != "" {
+		annotations, _ := item["annotations"].(map[string]any)
+		annotations = copyStringAnyMap(annotations)
+		if annotations == nil {
+			annotations = make(map[string]any)
+		}
+		annotations["title"] = entry.Title
+		item["annotations"] = annotations
+	}
+	return item
+}
+
+type localeKey struct{}
+
+// withLocale records the locale negotiated for a facade request.
+func withLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// localeFromContext returns the request's locale, or the default locale.
+func localeFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return messages.defaultLocale
+}
+
+// localizedErrors is the error registry for the request's locale.
+func localizedErrors(ctx context.Context) *rpcErrorRegistry {
+	return rpcErrors.in(localeFromContext(ctx))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMessageCatalogNegotiate(t *testing.T) {
+	c, err := newMessageCatalog(&MessagesConfig{Locales: map[string]*LocaleMessagesConfig{"fr": nil, "pt_BR": nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"":                          "en",
+		"fr-CA, en;q=0.5":           "fr",
+		"de, pt-br;q=0.8, fr;q=0.7": "pt-br",
+		"fr;q=0, de":                "en",
+		"*":                         "en",
+	}
+	for header, want := range cases {
+		if got := c.negotiate(header); got != want {
+			t.Errorf("negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+
+	for _, conf := range []*MessagesConfig{
+		{Locales: map[string]*LocaleMessagesConfig{"fr": {Strings: map[string]string{"nope": "x"}}}},
+		{DefaultLocale: "de", Locales: map[string]*LocaleMessagesConfig{"fr": nil}},
+	} {
+		if _, err := newMessageCatalog(conf); err == nil {
+			t.Errorf("catalog %+v accepted", conf)
+		}
+	}
+}
+
+func TestMessageCatalogLocalizeTools(t *testing.T) {
+	c, err := newMessageCatalog(&MessagesConfig{Locales: map[string]*LocaleMessagesConfig{"fr": {Strings: map[string]string{
+		msgSearchDescription: "Recherche",
+		msgSearchServer:      "Serveur",
+		msgFetchDescription:  "Récupération",
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	search := searchToolDescriptor()
+	fetch := fetchToolDescriptor()
+	fetch["description"] = "Overridden by the operator"
+	docs := applyToolDoc(map[string]any{"name": "echo", "description": "Echo"}, &toolDoc{
+		Localizations: map[string]toolDocLocale{"fr": {Title: "Écho", Description: "Renvoie le texte"}},
+	})
+	items := c.localizeTools([]map[string]any{search, fetch, docs}, "fr")
+
+	if items[0]["description"] != "Recherche" {
+		t.Fatalf("search description = %v", items[0]["description"])
+	}
+	props := items[0]["inputSchema"].(map[string]any)["properties"].(map[string]any)
+	if props["server"].(map[string]any)["description"] != "Serveur" || props["type"].(map[string]any)["description"] != builtinMessages[msgSearchType] {
+		t.Fatalf("search parameters = %v", props)
+	}
+	if search["description"] != builtinMessages[msgSearchDescription] {
+		t.Fatal("localizeTools modified its input")
+	}
+	if items[1]["description"] != "Overridden by the operator" {
+		t.Fatalf("override replaced: %v", items[1]["description"])
+	}
+	if items[2]["description"] != "Renvoie le texte" || items[2]["annotations"].(map[string]any)["title"] != "Écho" {
+		t.Fatalf("tool doc localization = %v", items[2])
+	}
+	if got := c.localizeTools([]map[string]any{docs}, "en"); got[0]["description"] != "Echo" {
+		t.Fatalf("default locale description = %v", got[0]["description"])
+	}
+}
+
+func TestRPCErrorRegistryLocales(t *testing.T) {
+	base, err := newRPCErrorRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err := base.withLocales(&MessagesConfig{DefaultLocale: "fr", Locales: map[string]*LocaleMessagesConfig{
+		"fr": {Errors: map[string]string{errNameUnknownTool: "Outil inconnu : {{name}}"}},
+		"de": {Errors: map[string]string{
+			errNameUnknownTool:       "Unbekanntes Werkzeug: {{name}}",
+			errNameRequestTimeout:    "Zeitüberschreitung bei {{target}}",
+			errNameProtocolViolation: "Protokollverstoß von {{server}}",
+			errNameInvalidRequest:    "Ungültige Anfrage: {{detail}}",
+		}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := func(reg *rpcErrorRegistry) string {
+		return reg.response(1, errNameUnknownTool, map[string]string{"name": "echo"}).Error.Message
+	}
+	if got := message(reg); got != "Outil inconnu : echo" {
+		t.Fatalf("default locale message = %q", got)
+	}
+	if got := message(reg.in("de")); got != "Unbekanntes Werkzeug: echo" {
+		t.Fatalf("de message = %q", got)
+	}
+	if got := message(reg.in("en")); got != "Unknown tool: echo" {
+		t.Fatalf("en message = %q", got)
+	}
+	if got := message(reg.in("es")); got != "Outil inconnu : echo" {
+		t.Fatalf("unconfigured locale message = %q", got)
+	}
+
+	_, err = base.withLocales(&MessagesConfig{Locales: map[string]*LocaleMessagesConfig{"fr": {Errors: map[string]string{errNameUnknownTool: "{{tool}}"}}}})
+	if err == nil || !strings.Contains(err.Error(), "unknown variable") {
+		t.Fatalf("bad template: %v", err)
+	}
+
+	prev := rpcErrors
+	defer func() { rpcErrors = prev }()
+	rpcErrors = reg
+	ctx := withLocale(context.Background(), "de")
+	if got := message(localizedErrors(ctx)); got != "Unbekanntes Werkzeug: echo" {
+		t.Fatalf("context locale message = %q", got)
+	}
+	// errors built outside the facade handler follow the request locale too
+	if got := (callDeadline{}).timeoutError(ctx, 1, "fs").Error.Message; got != "Zeitüberschreitung bei fs" {
+		t.Fatalf("timeout message = %q", got)
+	}
+	if got := violationError(ctx, 1, "fs", "tools/call", errors.New("bad"), "").Error.Message; got != "Protokollverstoß von fs" {
+		t.Fatalf("violation message = %q", got)
+	}
+	if got := string(batchInvalidEntry(ctx, 1, "nope")); !strings.Contains(got, "Ungültige Anfrage: nope") {
+		t.Fatalf("batch entry error = %s", got)
+	}
+}
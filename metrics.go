@@ -0,0 +1,369 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultMetricsWindow = time.Hour
+
+// callKey identifies a dispatch target for metrics: the owning server, the
+// JSON-RPC method, and the tool name / prompt name / resource uri.
+type callKey struct {
+	Server string
+	Method string
+	Target string
+}
+
+type callSample struct {
+	At            time.Time
+	Duration      time.Duration
+	Failed        bool
+	RequestBytes  int
+	ResponseBytes int
+}
+
+const (
+	// metricsBuckets is how many slices of window/metricsBuckets each call
+	// target counts its calls in.
+	metricsBuckets = 60
+	// metricsLatencySamples is how many recent durations each call target
+	// keeps for its percentiles.
+	metricsLatencySamples = 128
+	// maxMetricsKeys caps the call targets tracked at once. Past it, calls
+	// to new targets are counted under their server and method with target
+	// metricsOverflowTarget, one more key per server and method.
+	maxMetricsKeys        = 1024
+	metricsOverflowTarget = "(other)"
+)
+
+// callBucket counts the calls of one slice of the window.
+type callBucket struct {
+	index           int64
+	calls           int
+	errors          int
+	requestBytes    int64
+	responseBytes   int64
+	maxRequestBytes int
+	// largest is the call with the largest response.
+	largest callSample
+}
+
+// callSeries is one call target's lifetime counters, its calls counted
+// per slice of the window, and a ring of its latest samples.
+type callSeries struct {
+	total   uint64
+	errors  uint64
+	last    time.Time
+	buckets [metricsBuckets]callBucket
+	recent  [metricsLatencySamples]callSample
+	next    int
+}
+
+// serverTotals are the lifetime counters of a server's evicted targets.
+type serverTotals struct {
+	total  uint64
+	errors uint64
+}
+
+// callMetrics keeps lifetime counters plus rolling counts and recent
+// latencies per call target, in memory fixed per target. The number of
+// targets is capped, and targets idle for a whole window are dropped; their
+// lifetime counts stay in their server's totals.
+type callMetrics struct {
+	mu      sync.Mutex
+	window  time.Duration
+	series  map[callKey]*callSeries
+	retired map[string]serverTotals
+	swept   time.Time
+}
+
+func newCallMetrics(window time.Duration) *callMetrics {
+	if window <= 0 {
+		window = defaultMetricsWindow
+	}
+	return &callMetrics{window: window, series: make(map[callKey]*callSeries), retired: make(map[string]serverTotals)}
+}
+
+func (m *callMetrics) bucketWidth() time.Duration {
+	return max(m.window/metricsBuckets, time.Nanosecond)
+}
+
+func (m *callMetrics) record(key callKey, sample callSample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sample.At.Sub(m.swept) > m.window {
+		m.evictIdle(sample.At)
+	}
+	s := m.series[key]
+	if s == nil {
+		if len(m.series) >= maxMetricsKeys {
+			m.evictIdle(sample.At)
+		}
+		if len(m.series) >= maxMetricsKeys {
+			key.Target = metricsOverflowTarget
+			s = m.series[key]
+		}
+		if s == nil {
+			s = &callSeries{}
+			m.series[key] = s
+		}
+	}
+	s.total++
+	if sample.Failed {
+		s.errors++
+	}
+	if sample.At.After(s.last) {
+		s.last = sample.At
+	}
+	s.recent[s.next] = sample
+	s.next = (s.next + 1) % metricsLatencySamples
+
+	index := sample.At.UnixNano() / int64(m.bucketWidth())
+	b := &s.buckets[index%metricsBuckets]
+	if b.index > index {
+		// older than the window the slot now counts
+		return
+	}
+	if b.index < index {
+		*b = callBucket{index: index}
+	}
+	b.calls++
+	if sample.Failed {
+		b.errors++
+	}
+	b.requestBytes += int64(sample.RequestBytes)
+	b.responseBytes += int64(sample.ResponseBytes)
+	b.maxRequestBytes = max(b.maxRequestBytes, sample.RequestBytes)
+	if b.calls == 1 || sample.ResponseBytes > b.largest.ResponseBytes {
+		b.largest = sample
+	}
+}
+
+// evictIdle drops the targets without a call in the window before now. It
+// must be called with m.mu held.
+func (m *callMetrics) evictIdle(now time.Time) {
+	m.swept = now
+	for key, s := range m.series {
+		if now.Sub(s.last) <= m.window {
+			continue
+		}
+		retired := m.retired[key.Server]
+		retired.total += s.total
+		retired.errors += s.errors
+		m.retired[key.Server] = retired
+		delete(m.series, key)
+	}
+}
+
+// windowBuckets calls fn for each bucket of s that ends after since and
+// lies within the window of s's latest call.
+func (m *callMetrics) windowBuckets(s *callSeries, since time.Time, fn func(*callBucket)) {
+	width := int64(m.bucketWidth())
+	first := max(since.UnixNano()/width, s.last.UnixNano()/width-metricsBuckets+1)
+	for i := range s.buckets {
+		if b := &s.buckets[i]; b.calls > 0 && b.index >= first {
+			fn(b)
+		}
+	}
+}
+
+// durationsSince returns the durations of the recent samples of s at or
+// after since.
+func (s *callSeries) durationsSince(since time.Time) []time.Duration {
+	var out []time.Duration
+	for _, sample := range s.recent {
+		if !sample.At.IsZero() && !sample.At.Before(since) {
+			out = append(out, sample.Duration)
+		}
+	}
+	return out
+}
+
+// payloadStats summarises request/response sizes for one call target.
+type payloadStats struct {
+	Server           string `json:"server"`
+	Method           string `json:"method"`
+	Target           string `json:"target"`
+	Calls            int    `json:"calls"`
+	AvgRequestBytes  int    `json:"avgRequestBytes"`
+	AvgResponseBytes int    `json:"avgResponseBytes"`
+	MaxRequestBytes  int    `json:"maxRequestBytes"`
+	MaxResponseBytes int    `json:"maxResponseBytes"`
+}
+
+// payloadSample is a single call in the largest-payloads list.
+type payloadSample struct {
+	Server        string    `json:"server"`
+	Method        string    `json:"method"`
+	Target        string    `json:"target"`
+	At            time.Time `json:"at"`
+	RequestBytes  int       `json:"requestBytes"`
+	ResponseBytes int       `json:"responseBytes"`
+}
+
+// payloadReport returns per-target size stats ordered by largest response and
+// the top individual calls by response size, both limited to top entries.
+// The window is counted in whole slices of the metrics window, and each
+// slice contributes its largest call.
+func (m *callMetrics) payloadReport(since time.Time, top int) ([]payloadStats, []payloadSample) {
+	m.mu.Lock()
+	var targets []payloadStats
+	var largest []payloadSample
+	for key, s := range m.series {
+		stats := payloadStats{Server: key.Server, Method: key.Method, Target: key.Target}
+		var reqTotal, respTotal int64
+		m.windowBuckets(s, since, func(b *callBucket) {
+			stats.Calls += b.calls
+			reqTotal += b.requestBytes
+			respTotal += b.responseBytes
+			stats.MaxRequestBytes = max(stats.MaxRequestBytes, b.maxRequestBytes)
+			stats.MaxResponseBytes = max(stats.MaxResponseBytes, b.largest.ResponseBytes)
+			largest = append(largest, payloadSample{
+				Server:        key.Server,
+				Method:        key.Method,
+				Target:        key.Target,
+				At:            b.largest.At.UTC(),
+				RequestBytes:  b.largest.RequestBytes,
+				ResponseBytes: b.largest.ResponseBytes,
+			})
+		})
+		if stats.Calls == 0 {
+			continue
+		}
+		stats.AvgRequestBytes = int(reqTotal / int64(stats.Calls))
+		stats.AvgResponseBytes = int(respTotal / int64(stats.Calls))
+		targets = append(targets, stats)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].MaxResponseBytes != targets[j].MaxResponseBytes {
+			return targets[i].MaxResponseBytes > targets[j].MaxResponseBytes
+		}
+		return targets[i].Target < targets[j].Target
+	})
+	sort.Slice(largest, func(i, j int) bool {
+		if largest[i].ResponseBytes != largest[j].ResponseBytes {
+			return largest[i].ResponseBytes > largest[j].ResponseBytes
+		}
+		return largest[i].RequestBytes > largest[j].RequestBytes
+	})
+	if top > 0 && len(targets) > top {
+		targets = targets[:top]
+	}
+	if top > 0 && len(largest) > top {
+		largest = largest[:top]
+	}
+	return targets, largest
+}
+
+func latencyPercentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	idx := int(p*float64(len(sorted)-1) + 0.5)
+	return sorted[idx]
+}
+
+// callUsage is one call target's traffic: lifetime totals plus counts and
+// sizes since the report's cutoff, and latency over the target's latest
+// calls since then.
+type callUsage struct {
+	Server           string `json:"server"`
+	Method           string `json:"method"`
+	Target           string `json:"target"`
+	TotalCalls       uint64 `json:"totalCalls"`
+	TotalErrors      uint64 `json:"totalErrors"`
+	Calls            int    `json:"calls"`
+	Errors           int    `json:"errors"`
+	P50Ms            int64  `json:"p50Ms"`
+	P95Ms            int64  `json:"p95Ms"`
+	AvgRequestBytes  int    `json:"avgRequestBytes"`
+	AvgResponseBytes int    `json:"avgResponseBytes"`
+}
+
+// usageReport returns usage for every key accepted by match, ordered by
+// server, method, and target.
+func (m *callMetrics) usageReport(since time.Time, match func(callKey) bool) []callUsage {
+	m.mu.Lock()
+	out := make([]callUsage, 0)
+	for key, s := range m.series {
+		if !match(key) {
+			continue
+		}
+		usage := callUsage{Server: key.Server, Method: key.Method, Target: key.Target, TotalCalls: s.total, TotalErrors: s.errors}
+		var reqTotal, respTotal int64
+		m.windowBuckets(s, since, func(b *callBucket) {
+			usage.Calls += b.calls
+			usage.Errors += b.errors
+			reqTotal += b.requestBytes
+			respTotal += b.responseBytes
+		})
+		if usage.Calls > 0 {
+			durations := s.durationsSince(since)
+			usage.P50Ms = latencyPercentile(durations, 0.5).Milliseconds()
+			usage.P95Ms = latencyPercentile(durations, 0.95).Milliseconds()
+			usage.AvgRequestBytes = int(reqTotal / int64(usage.Calls))
+			usage.AvgResponseBytes = int(respTotal / int64(usage.Calls))
+		}
+		out = append(out, usage)
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Server != b.Server {
+			return a.Server < b.Server
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Target < b.Target
+	})
+	return out
+}
+
+// serverUsage is one server's traffic summed over its call targets:
+// lifetime totals plus counts since the report's cutoff, and latency over
+// its targets' latest calls since then.
+type serverUsage struct {
+	TotalCalls  uint64
+	TotalErrors uint64
+	Calls       int
+	Errors      int
+	P50         time.Duration
+	P95         time.Duration
+}
+
+// serverUsageReport returns usage for every server that has been called.
+func (m *callMetrics) serverUsageReport(since time.Time) map[string]serverUsage {
+	m.mu.Lock()
+	out := make(map[string]serverUsage)
+	for server, retired := range m.retired {
+		out[server] = serverUsage{TotalCalls: retired.total, TotalErrors: retired.errors}
+	}
+	durations := make(map[string][]time.Duration)
+	for key, s := range m.series {
+		usage := out[key.Server]
+		usage.TotalCalls += s.total
+		usage.TotalErrors += s.errors
+		m.windowBuckets(s, since, func(b *callBucket) {
+			usage.Calls += b.calls
+			usage.Errors += b.errors
+		})
+		durations[key.Server] = append(durations[key.Server], s.durationsSince(since)...)
+		out[key.Server] = usage
+	}
+	m.mu.Unlock()
+	for server, window := range durations {
+		usage := out[server]
+		usage.P50 = latencyPercentile(window, 0.5)
+		usage.P95 = latencyPercentile(window, 0.95)
+		out[server] = usage
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPayloadReportRanksLargestResponses(t *testing.T) {
+	m := newCallMetrics(time.Hour)
+	now := time.Now()
+	search := callKey{Server: "web", Method: "tools/call", Target: "search"}
+	read := callKey{Server: "fs", Method: "tools/call", Target: "read"}
+	m.record(search, callSample{At: now, RequestBytes: 100, ResponseBytes: 1000})
+	m.record(search, callSample{At: now, RequestBytes: 300, ResponseBytes: 3000})
+	m.record(read, callSample{At: now, RequestBytes: 50, ResponseBytes: 90000})
+	m.record(read, callSample{At: now.Add(-30 * time.Minute), RequestBytes: 50, ResponseBytes: 500000})
+
+	targets, largest := m.payloadReport(now.Add(-10*time.Minute), 2)
+	if len(targets) != 2 || targets[0].Target != "read" || targets[0].Calls != 1 {
+		t.Fatalf("unexpected targets %+v", targets)
+	}
+	if s := targets[1]; s.Calls != 2 || s.AvgRequestBytes != 200 || s.AvgResponseBytes != 2000 || s.MaxResponseBytes != 3000 {
+		t.Fatalf("unexpected search stats %+v", s)
+	}
+	if len(largest) != 2 || largest[0].ResponseBytes != 90000 || largest[1].ResponseBytes != 3000 {
+		t.Fatalf("unexpected largest %+v", largest)
+	}
+}
+
+func TestCallMetricsBoundKeys(t *testing.T) {
+	m := newCallMetrics(time.Hour)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < maxMetricsKeys+10; i++ {
+		m.record(callKey{Server: "web", Method: "resources/read", Target: fmt.Sprintf("page-%d", i)}, callSample{At: now, Duration: time.Millisecond})
+	}
+	usage := m.usageReport(now.Add(-time.Hour), func(key callKey) bool { return key.Target == metricsOverflowTarget })
+	if len(m.series) != maxMetricsKeys+1 || len(usage) != 1 || usage[0].Calls != 10 {
+		t.Fatalf("%d keys, overflow usage %+v", len(m.series), usage)
+	}
+
+	// idle targets are dropped, their counts kept in their server's totals
+	later := now.Add(2 * time.Hour)
+	m.record(callKey{Server: "fs", Method: "tools/call", Target: "read"}, callSample{At: later, Duration: time.Millisecond, Failed: true})
+	if len(m.series) != 1 {
+		t.Fatalf("%d keys after a window of idleness", len(m.series))
+	}
+	servers := m.serverUsageReport(later.Add(-time.Hour))
+	if web := servers["web"]; web.TotalCalls != maxMetricsKeys+10 || web.Calls != 0 {
+		t.Fatalf("web usage = %+v", web)
+	}
+	if fs := servers["fs"]; fs.TotalCalls != 1 || fs.Calls != 1 || fs.Errors != 1 {
+		t.Fatalf("fs usage = %+v", fs)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+)
+
+// ClientAuthConfig requires client certificates signed by CAFile on a TLS
+// listener. With Optional, clients without a certificate are let through
+// to the usual bearer token checks. Identities map certificates to a
+// tenant and token scopes; the first rule matching a certificate applies,
+// and with rules set a certificate matching none is refused.
+type ClientAuthConfig struct {
+	CAFile     string                `json:"caFile"`
+	Optional   bool                  `json:"optional,omitempty"`
+	Identities []*ClientCertIdentity `json:"identities,omitempty"`
+}
+
+// ClientCertIdentity matches certificates by subject CN or any DNS, email,
+// or URI SAN; both are path.Match patterns and empty matches all.
+type ClientCertIdentity struct {
+	CN     string   `json:"cn,omitempty"`
+	SAN    string   `json:"san,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// clientCertAuth verifies client certificates and maps them to callers.
+type clientCertAuth struct {
+	conf *ClientAuthConfig
+	pool *x509.CertPool
+}
+
+func newClientCertAuth(conf *ClientAuthConfig) (*clientCertAuth, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.CAFile == "" {
+		return nil, errors.New("clientAuth.caFile is required")
+	}
+	pem, err := os.ReadFile(conf.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("clientAuth.caFile: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("clientAuth.caFile %s: no PEM certificates", conf.CAFile)
+	}
+	for i, rule := range conf.Identities {
+		if rule == nil {
+			return nil, fmt.Errorf("clientAuth.identities[%d]: empty rule", i)
+		}
+		for _, pattern := range []string{rule.CN, rule.SAN} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("clientAuth.identities[%d]: pattern %q: %w", i, pattern, err)
+			}
+		}
+	}
+	return &clientCertAuth{conf: conf, pool: pool}, nil
+}
+
+// apply makes config ask for and verify client certificates.
+func (a *clientCertAuth) apply(config *tls.Config) {
+	if a == nil {
+		config.ClientAuth, config.ClientCAs = tls.NoClientCert, nil
+		return
+	}
+	config.ClientCAs = a.pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	if a.conf.Optional {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+}
+
+// identify returns the identity rule matching cert; any certificate
+// matches when there are no rules.
+func (a *clientCertAuth) identify(cert *x509.Certificate) (*ClientCertIdentity, bool) {
+	if len(a.conf.Identities) == 0 {
+		return &ClientCertIdentity{}, true
+	}
+	sans := append([]string(nil), cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	for _, rule := range a.conf.Identities {
+		if !globMatch(rule.CN, cert.Subject.CommonName) {
+			continue
+		}
+		if rule.SAN == "" {
+			return rule, true
+		}
+		for _, san := range sans {
+			if globMatch(rule.SAN, san) {
+				return rule, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// clientCert is the verified certificate a request came with.
+type clientCert struct {
+	CN     string
+	Tenant string
+}
+
+// identity is the caller identity of the certificate.
+func (c clientCert) identity() string {
+	if c.Tenant != "" {
+		return "tenant:" + c.Tenant
+	}
+	return "cert:" + c.CN
+}
+
+type clientCertKey struct{}
+
+func clientCertFromContext(ctx context.Context) (clientCert, bool) {
+	cert, ok := ctx.Value(clientCertKey{}).(clientCert)
+	return cert, ok
+}
+
+// middleware admits requests by their verified client certificate: they
+// carry the identity's scopes like an access token, so bearer token checks
+// let them through, and are attributed to the tenant (or the CN). Requests
+// without a certificate, on an optional listener, are left to those checks.
+func (a *clientCertAuth) middleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if a == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			leaf := r.TLS.VerifiedChains[0][0]
+			rule, ok := a.identify(leaf)
+			if !ok {
+				logger("tls").Warn("refused client certificate: no identity matches", "cn", leaf.Subject.CommonName)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			cert := clientCert{CN: leaf.Subject.CommonName, Tenant: rule.Tenant}
+			ctx := context.WithValue(r.Context(), clientCertKey{}, cert)
+			ctx = withOAuthToken(ctx, oauthToken{Subject: cert.identity(), ClientID: cert.CN, Scopes: rule.Scopes})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA issues client certificates for the mTLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, dir string) (*testCA, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}, caFile
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, dnsNames ...string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca, caFile := newTestCA(t, dir)
+	certFile, keyFile := writeTestCert(t, dir, "proxy")
+	serverTLS, err := newProxyTLS(&TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientAuth: &ClientAuthConfig{
+		CAFile: caFile,
+		Identities: []*ClientCertIdentity{
+			{CN: "agent-*", Tenant: "acme", Scopes: []string{"mcp:tools"}},
+			{SAN: "*.ops.example.com", Scopes: []string{"mcp:admin"}},
+		},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverTLS.config.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("client auth = %v", serverTLS.config.ClientAuth)
+	}
+
+	handler := chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := callerFromContext(r.Context())
+		tok, _ := oauthTokenFromContext(r.Context())
+		_, _ = io.WriteString(w, caller.Identity+" "+strings.Join(tok.Scopes, ","))
+	}), newAuthMiddleware(newRouteTokens([]string{"static"}, nil, "fs"), nil), callerContextMiddleware())
+	server := httptest.NewUnstartedServer(serverTLS.clientAuth.middleware()(handler))
+	server.TLS = serverTLS.config
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(cert *tls.Certificate) (int, string, error) {
+		config := &tls.Config{InsecureSkipVerify: true}
+		if cert != nil {
+			config.Certificates = []tls.Certificate{*cert}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), nil
+	}
+
+	agent := ca.issue(t, "agent-7")
+	if code, body, err := get(&agent); err != nil || code != http.StatusOK || body != "tenant:acme mcp:tools" {
+		t.Fatalf("agent cert: %d %q %v", code, body, err)
+	}
+	ops := ca.issue(t, "jo", "jo.ops.example.com")
+	if code, body, err := get(&ops); err != nil || code != http.StatusOK || body != "cert:jo mcp:admin" {
+		t.Fatalf("ops cert: %d %q %v", code, body, err)
+	}
+	stranger := ca.issue(t, "stranger")
+	if code, _, err := get(&stranger); err != nil || code != http.StatusForbidden {
+		t.Fatalf("unmapped cert: %d %v", code, err)
+	}
+	if _, _, err := get(nil); err == nil {
+		t.Fatal("handshake without a client certificate succeeded")
+	}
+	other, _ := newTestCA(t, t.TempDir())
+	forged := other.issue(t, "agent-1")
+	if _, _, err := get(&forged); err == nil {
+		t.Fatal("certificate from another CA accepted")
+	}
+
+	// other listeners choose their own requirement
+	plain, err := serverTLS.listenerConfig(nil)
+	if err != nil || plain.ClientAuth != tls.NoClientCert || serverTLS.config.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("listener config = %v, %v", plain.ClientAuth, err)
+	}
+	optional, err := serverTLS.listenerConfig(&ClientAuthConfig{CAFile: caFile, Optional: true})
+	if err != nil || optional.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Fatalf("optional listener config = %v, %v", optional, err)
+	}
+
+	if _, err := newProxyTLS(&TLSConfig{ClientAuth: &ClientAuthConfig{CAFile: caFile}}, nil); err == nil {
+		t.Fatal("clientAuth without TLS accepted")
+	}
+	if _, err := newClientCertAuth(&ClientAuthConfig{}); err == nil {
+		t.Fatal("clientAuth without caFile accepted")
+	}
+}
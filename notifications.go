@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	notificationActionForward = "forward"
+	notificationActionDrop    = "drop"
+
+	resourceUpdatedNotification = "notifications/resources/updated"
+)
+
+// NotificationRule decides whether a downstream notification reaches client
+// sessions. Rules are evaluated in order; the first match wins and unmatched
+// notifications are forwarded.
+type NotificationRule struct {
+	Server string `json:"server,omitempty"`
+	// Method is a glob such as "notifications/message" or "notifications/resources/*".
+	Method string `json:"method,omitempty"`
+	// URIs limits the rule to notifications whose params.uri matches one of the globs.
+	URIs []string `json:"uris,omitempty"`
+	// MinLevel drops notifications/message entries below this level.
+	MinLevel string `json:"minLevel,omitempty"`
+	Action   string `json:"action,omitempty"`
+}
+
+// logLevelRank orders MCP (syslog) logging levels.
+var logLevelRank = map[string]int{
+	"debug": 0, "info": 1, "notice": 2, "warning": 3,
+	"error": 4, "critical": 5, "alert": 6, "emergency": 7,
+}
+
+type notificationCounts struct {
+	Server    string `json:"server"`
+	Method    string `json:"method"`
+	Forwarded uint64 `json:"forwarded"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+// resourceSubscription is a resource uri a facade session subscribed to,
+// and the server that owns it.
+type resourceSubscription struct {
+	Server string
+	URI    string
+}
+
+// notificationFilter applies NotificationRule to downstream notifications and
+// tracks per-session resource subscriptions. Progress and resource updates
+// are relayed to facade sessions when the rules forward them; for
+// everything else the filter only observes and counts what the rules would
+// do.
+type notificationFilter struct {
+	rules []*NotificationRule
+
+	mu sync.Mutex
+	// subscriptions maps a session to the resources it subscribed to.
+	subscriptions map[string]map[resourceSubscription]struct{}
+	counts        map[[2]string]*notificationCounts
+}
+
+func newNotificationFilter(rules []*NotificationRule) *notificationFilter {
+	valid := make([]*NotificationRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		if rule.Action != notificationActionDrop && rule.Action != notificationActionForward {
+			if rule.Action != "" {
+				logger("notifications").Warn("unknown rule action, treating as forward", "action", rule.Action)
+			}
+			rule.Action = notificationActionForward
+		}
+		valid = append(valid, rule)
+	}
+	return &notificationFilter{
+		rules:         valid,
+		subscriptions: make(map[string]map[resourceSubscription]struct{}),
+		counts:        make(map[[2]string]*notificationCounts),
+	}
+}
+
+func globMatch(pattern, value string) bool {
+	if pattern == "" || pattern == value {
+		return true
+	}
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
+
+func notificationURI(n mcp.JSONRPCNotification) string {
+	uri, _ := n.Params.AdditionalFields["uri"].(string)
+	return uri
+}
+
+func (r *NotificationRule) matches(server string, n mcp.JSONRPCNotification) bool {
+	if r.Server != "" && r.Server != server {
+		return false
+	}
+	if !globMatch(r.Method, n.Method) {
+		return false
+	}
+	if len(r.URIs) > 0 {
+		uri := notificationURI(n)
+		matched := false
+		for _, pattern := range r.URIs {
+			if globMatch(pattern, uri) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// allow reports whether the rules forward n from server.
+func (f *notificationFilter) allow(server string, n mcp.JSONRPCNotification) bool {
+	for _, rule := range f.rules {
+		if !rule.matches(server, n) {
+			continue
+		}
+		if rule.Action == notificationActionDrop {
+			return false
+		}
+		if rule.MinLevel != "" && n.Method == "notifications/message" {
+			level, _ := n.Params.AdditionalFields["level"].(string)
+			if rank, ok := logLevelRank[strings.ToLower(level)]; ok && rank < logLevelRank[strings.ToLower(rule.MinLevel)] {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// subscribe records that sessionID wants updates to uri from server.
+func (f *notificationFilter) subscribe(sessionID, server, uri string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subscriptions[sessionID] == nil {
+		f.subscriptions[sessionID] = make(map[resourceSubscription]struct{})
+	}
+	f.subscriptions[sessionID][resourceSubscription{Server: server, URI: uri}] = struct{}{}
+}
+
+// unsubscribe forgets sessionID's subscription to uri from server and
+// reports whether no other session is still subscribed to it.
+func (f *notificationFilter) unsubscribe(sessionID, server, uri string) bool {
+	sub := resourceSubscription{Server: server, URI: uri}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subscriptions[sessionID], sub)
+	if len(f.subscriptions[sessionID]) == 0 {
+		delete(f.subscriptions, sessionID)
+	}
+	return !f.subscribedLocked(sub)
+}
+
+// dropSession forgets the subscriptions of a session that ended and
+// returns those no other session still holds.
+func (f *notificationFilter) dropSession(sessionID string) []resourceSubscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs := f.subscriptions[sessionID]
+	delete(f.subscriptions, sessionID)
+	var orphaned []resourceSubscription
+	for sub := range subs {
+		if !f.subscribedLocked(sub) {
+			orphaned = append(orphaned, sub)
+		}
+	}
+	return orphaned
+}
+
+// subscribers returns the sessions subscribed to sub.
+func (f *notificationFilter) subscribers(sub resourceSubscription) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for session, subs := range f.subscriptions {
+		if _, ok := subs[sub]; ok {
+			out = append(out, session)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// subscribedLocked must be called with f.mu held.
+func (f *notificationFilter) subscribedLocked(sub resourceSubscription) bool {
+	for _, subs := range f.subscriptions {
+		if _, ok := subs[sub]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// relayUpdate delivers a downstream notifications/resources/updated to the
+// facade sessions subscribed to its uri, and reports whether n was one.
+func (f *notificationFilter) relayUpdate(streams *sessionStreams, server string, n mcp.JSONRPCNotification) bool {
+	if n.Method != resourceUpdatedNotification {
+		return false
+	}
+	sessions := f.subscribers(resourceSubscription{Server: server, URI: notificationURI(n)})
+	if len(sessions) == 0 {
+		return true
+	}
+	msg, err := json.Marshal(n)
+	if err != nil {
+		return true
+	}
+	for _, session := range sessions {
+		streams.send(session, msg)
+	}
+	return true
+}
+
+// subscribeResource subscribes to sub on its server; servers that cannot
+// take it fail.
+func subscribeResource(ctx context.Context, c *Client, sub resourceSubscription) error {
+	if c == nil {
+		return errors.New("server not connected")
+	}
+	return c.subscribe(ctx, sub.URI)
+}
+
+// unsubscribeResource cancels the subscription to sub on its server once no
+// facade session holds it.
+func unsubscribeResource(c *Client, sub resourceSubscription) {
+	if c == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.unsubscribe(ctx, sub.URI); err != nil {
+		clientLogger(sub.Server).Warn("resources/unsubscribe failed", "uri", sub.URI, "err", err)
+	}
+}
+
+// observe evaluates a downstream notification and counts the outcome.
+func (f *notificationFilter) observe(server string, n mcp.JSONRPCNotification) bool {
+	allowed := f.allow(server, n)
+	f.mu.Lock()
+	key := [2]string{server, n.Method}
+	c := f.counts[key]
+	if c == nil {
+		c = &notificationCounts{Server: server, Method: n.Method}
+		f.counts[key] = c
+	}
+	if allowed {
+		c.Forwarded++
+	} else {
+		c.Dropped++
+	}
+	f.mu.Unlock()
+	return allowed
+}
+
+func (f *notificationFilter) snapshot() []notificationCounts {
+	f.mu.Lock()
+	out := make([]notificationCounts, 0, len(f.counts))
+	for _, c := range f.counts {
+		out = append(out, *c)
+	}
+	f.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func testNotification(method string, fields map[string]any) mcp.JSONRPCNotification {
+	n := mcp.JSONRPCNotification{JSONRPC: mcp.JSONRPC_VERSION}
+	n.Method = method
+	n.Params.AdditionalFields = fields
+	return n
+}
+
+func TestNotificationFilterRules(t *testing.T) {
+	f := newNotificationFilter([]*NotificationRule{
+		{Server: "noisy", Method: "notifications/message", Action: "drop"},
+		{Method: "notifications/message", MinLevel: "warning"},
+		{Method: "notifications/resources/updated", URIs: []string{"file:///tmp/*"}, Action: "drop"},
+	})
+
+	cases := []struct {
+		name   string
+		server string
+		n      mcp.JSONRPCNotification
+		want   bool
+	}{
+		{"noisy server logs dropped", "noisy", testNotification("notifications/message", map[string]any{"level": "error"}), false},
+		{"info below min level", "fs", testNotification("notifications/message", map[string]any{"level": "info"}), false},
+		{"error passes min level", "fs", testNotification("notifications/message", map[string]any{"level": "error"}), true},
+		{"tmp uri dropped", "fs", testNotification("notifications/resources/updated", map[string]any{"uri": "file:///tmp/x"}), false},
+		{"other uri forwarded", "fs", testNotification("notifications/resources/updated", map[string]any{"uri": "file:///a"}), true},
+		{"unmatched forwarded", "fs", testNotification("notifications/tools/list_changed", nil), true},
+	}
+	for _, tc := range cases {
+		if got := f.allow(tc.server, tc.n); got != tc.want {
+			t.Errorf("%s: allow = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNotificationFilterRelaysUpdatesToSubscribers(t *testing.T) {
+	f := newNotificationFilter(nil)
+	streams := newSessionStreams()
+	s1, unsubscribe1 := streams.subscribe("s1")
+	defer unsubscribe1()
+	s2, unsubscribe2 := streams.subscribe("s2")
+	defer unsubscribe2()
+
+	f.subscribe("s1", "fs", "file:///a")
+	f.subscribe("s2", "fs", "file:///a")
+	f.subscribe("s2", "fs", "file:///b")
+	update := testNotification(resourceUpdatedNotification, map[string]any{"uri": "file:///b"})
+	if !f.relayUpdate(streams, "fs", update) || f.relayUpdate(streams, "fs", testNotification("notifications/message", nil)) {
+		t.Fatal("relayUpdate should take only resource updates")
+	}
+	f.relayUpdate(streams, "other", update)
+	if len(s1) != 0 || len(s2) != 1 {
+		t.Fatalf("update reached s1 %d and s2 %d times, want 0 and 1", len(s1), len(s2))
+	}
+	if msg := string(<-s2); msg != `{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///b"}}` {
+		t.Fatalf("relayed %s", msg)
+	}
+
+	if f.unsubscribe("s1", "fs", "file:///a") {
+		t.Fatal("s2 still holds file:///a")
+	}
+	orphaned := f.dropSession("s2")
+	if len(orphaned) != 2 {
+		t.Fatalf("dropSession orphaned %v", orphaned)
+	}
+	f.relayUpdate(streams, "fs", testNotification(resourceUpdatedNotification, map[string]any{"uri": "file:///a"}))
+	if len(s1) != 0 || len(s2) != 0 {
+		t.Fatal("update relayed after its subscriptions ended")
+	}
+}
+
+func TestNotificationFilterCounts(t *testing.T) {
+	f := newNotificationFilter([]*NotificationRule{{Method: "notifications/message", Action: "drop"}})
+	f.observe("fs", testNotification("notifications/message", nil))
+	f.observe("fs", testNotification("notifications/message", nil))
+	f.observe("fs", testNotification("notifications/progress", nil))
+	got := f.snapshot()
+	if len(got) != 2 || got[0].Method != "notifications/message" || got[0].Dropped != 2 || got[1].Forwarded != 1 {
+		t.Fatalf("unexpected counts %+v", got)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauthMetadataPath is where the protected resource metadata (RFC 9728) is
+// served.
+const oauthMetadataPath = "/.well-known/oauth-protected-resource"
+
+const (
+	defaultOAuthCacheTTL = time.Minute
+	oauthCacheMaxEntries = 1024
+)
+
+// OAuthConfig puts the facade and the per-server routes behind OAuth 2.1
+// bearer access tokens. Resource is the canonical URL of the proxy that
+// tokens must be issued for; AuthorizationServers are advertised to clients
+// in the protected resource metadata. Tokens are validated by introspection
+// (RFC 7662) at IntrospectionEndpoint, authenticating with ClientID and
+// ClientSecret; active tokens are cached for CacheTTL. With JWT set, JWT
+// access tokens are validated locally against the authorization server's
+// JWKS instead, and introspection is only needed for opaque tokens.
+type OAuthConfig struct {
+	Resource              string        `json:"resource"`
+	AuthorizationServers  []string      `json:"authorizationServers"`
+	ScopesSupported       []string      `json:"scopesSupported,omitempty"`
+	IntrospectionEndpoint string        `json:"introspectionEndpoint"`
+	ClientID              string        `json:"clientId,omitempty"`
+	ClientSecret          string        `json:"clientSecret,omitempty"`
+	CacheTTL              time.Duration `json:"cacheTTL,omitempty"`
+	JWT                   *JWTConfig    `json:"jwt,omitempty"`
+}
+
+// oauthToken is what introspection said about a valid access token.
+type oauthToken struct {
+	Subject  string
+	ClientID string
+	Scopes   []string
+	Expiry   time.Time
+}
+
+// errOAuthInvalidToken is returned for tokens the authorization server does
+// not vouch for; other validation errors mean it could not be asked.
+var errOAuthInvalidToken = errors.New("invalid token")
+
+// oauthResource validates access tokens for the proxy and answers requests
+// without one with a challenge pointing at its metadata.
+type oauthResource struct {
+	conf        OAuthConfig
+	jwt         *jwtValidator
+	metadataURL string
+	client      *http.Client
+	now         func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]oauthToken
+}
+
+func newOAuthResource(conf *OAuthConfig) (*oauthResource, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	resource, err := url.Parse(conf.Resource)
+	if err != nil || resource.Scheme == "" || resource.Host == "" || resource.Fragment != "" {
+		return nil, fmt.Errorf("oauth: resource %q must be an absolute URL without a fragment", conf.Resource)
+	}
+	if len(conf.AuthorizationServers) == 0 {
+		return nil, errors.New("oauth: authorizationServers is required")
+	}
+	if conf.IntrospectionEndpoint == "" && conf.JWT == nil {
+		return nil, errors.New("oauth: introspectionEndpoint or jwt is required")
+	}
+	c := *conf
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = defaultOAuthCacheTTL
+	}
+	var jwt *jwtValidator
+	if c.JWT != nil {
+		jwtConf := *c.JWT
+		if len(jwtConf.Audience) == 0 {
+			jwtConf.Audience = []string{c.Resource}
+		}
+		if jwt, err = newJWTValidator(&jwtConf); err != nil {
+			return nil, fmt.Errorf("oauth: %w", err)
+		}
+	}
+	metadata := url.URL{Scheme: resource.Scheme, Host: resource.Host, Path: oauthMetadataPath + strings.TrimSuffix(resource.Path, "/")}
+	return &oauthResource{
+		conf:        c,
+		jwt:         jwt,
+		metadataURL: metadata.String(),
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+		cache:       make(map[string]oauthToken),
+	}, nil
+}
+
+// metadata is the protected resource metadata document.
+func (o *oauthResource) metadata() map[string]any {
+	doc := map[string]any{
+		"resource":                 o.conf.Resource,
+		"authorization_servers":    o.conf.AuthorizationServers,
+		"bearer_methods_supported": []string{"header"},
+	}
+	if len(o.conf.ScopesSupported) > 0 {
+		doc["scopes_supported"] = o.conf.ScopesSupported
+	}
+	return doc
+}
+
+// register serves the metadata at the root well-known path and at the one
+// suffixed with the resource path, which is where clients look first.
+func (o *oauthResource) register(mux *http.ServeMux) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=3600")
+		_ = json.NewEncoder(w).Encode(o.metadata())
+	}
+	mux.HandleFunc(oauthMetadataPath, handler)
+	if u, err := url.Parse(o.metadataURL); err == nil && u.Path != oauthMetadataPath {
+		mux.HandleFunc(u.Path, handler)
+	}
+}
+
+// validate checks JWTs against the JWKS when configured and introspects
+// other tokens, serving active ones from the cache until the TTL or their
+// expiry, whichever comes first.
+func (o *oauthResource) validate(ctx context.Context, token string) (oauthToken, error) {
+	if o.jwt != nil && looksLikeJWT(token) {
+		return o.jwt.validate(ctx, token)
+	}
+	if o.conf.IntrospectionEndpoint == "" {
+		return oauthToken{}, errOAuthInvalidToken
+	}
+	key := tokenFingerprint(token)
+	now := o.now()
+	o.mu.Lock()
+	cached, ok := o.cache[key]
+	if ok && now.After(cached.Expiry) {
+		delete(o.cache, key)
+		ok = false
+	}
+	o.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	tok, err := o.introspect(ctx, token, now)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	cached = tok
+	if ttl := now.Add(o.conf.CacheTTL); cached.Expiry.IsZero() || ttl.Before(cached.Expiry) {
+		cached.Expiry = ttl
+	}
+	o.mu.Lock()
+	if len(o.cache) >= oauthCacheMaxEntries {
+		for k, v := range o.cache {
+			if now.After(v.Expiry) {
+				delete(o.cache, k)
+			}
+		}
+		if len(o.cache) >= oauthCacheMaxEntries {
+			clear(o.cache)
+		}
+	}
+	o.cache[key] = cached
+	o.mu.Unlock()
+	return tok, nil
+}
+
+// introspect asks the authorization server about token. Inactive, expired,
+// and tokens not issued for the resource, including those without an
+// audience, are errOAuthInvalidToken.
+func (o *oauthResource) introspect(ctx context.Context, token string, now time.Time) (oauthToken, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.conf.IntrospectionEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauthToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.conf.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(o.conf.ClientID), url.QueryEscape(o.conf.ClientSecret))
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("introspection: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return oauthToken{}, fmt.Errorf("introspection: %s", resp.Status)
+	}
+	var body struct {
+		Active   bool            `json:"active"`
+		Scope    string          `json:"scope"`
+		ClientID string          `json:"client_id"`
+		Subject  string          `json:"sub"`
+		Expiry   int64           `json:"exp"`
+		Audience json.RawMessage `json:"aud"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return oauthToken{}, fmt.Errorf("introspection: %w", err)
+	}
+	if !body.Active {
+		return oauthToken{}, errOAuthInvalidToken
+	}
+	tok := oauthToken{Subject: body.Subject, ClientID: body.ClientID, Scopes: strings.Fields(body.Scope)}
+	if body.Expiry > 0 {
+		tok.Expiry = time.Unix(body.Expiry, 0)
+		if !now.Before(tok.Expiry) {
+			return oauthToken{}, errOAuthInvalidToken
+		}
+	}
+	if !slices.Contains(oauthAudience(body.Audience), o.conf.Resource) {
+		return oauthToken{}, errOAuthInvalidToken
+	}
+	return tok, nil
+}
+
+// oauthAudience reads an aud claim, which is a string or a list of them.
+func oauthAudience(raw json.RawMessage) []string {
+	var one string
+	if json.Unmarshal(raw, &one) == nil && one != "" {
+		return []string{one}
+	}
+	var many []string
+	_ = json.Unmarshal(raw, &many)
+	return many
+}
+
+// challenge answers 401 with the WWW-Authenticate header of RFC 6750 and
+// RFC 9728; errCode is empty when the request carried no token.
+func (o *oauthResource) challenge(w http.ResponseWriter, errCode, description string) {
+	params := []string{fmt.Sprintf("resource_metadata=%q", o.metadataURL)}
+	if errCode != "" {
+		params = append(params, fmt.Sprintf("error=%q", errCode))
+	}
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", description))
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer "+strings.Join(params, ", "))
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// middleware admits requests with a valid access token or one of the static
+// tokens. Re-entrant requests keep the token of the outer request. Without
+// OAuth configured it admits everything.
+func (o *oauthResource) middleware(prefix string, static routeTokens) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if o == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := oauthTokenFromContext(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			token := bearerToken(r)
+			if token == "" {
+				o.challenge(w, "", "")
+				return
+			}
+			if static.accepts(token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			tok, err := o.validate(r.Context(), token)
+			switch {
+			case errors.Is(err, errOAuthInvalidToken):
+				o.challenge(w, "invalid_token", "The access token is invalid or expired")
+				return
+			case err != nil:
+				routeLogger(prefix).Error("oauth token validation failed", "err", err)
+				http.Error(w, "Authorization server unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withOAuthToken(r.Context(), tok)))
+		})
+	}
+}
+
+type oauthTokenKey struct{}
+
+func withOAuthToken(ctx context.Context, tok oauthToken) context.Context {
+	return context.WithValue(ctx, oauthTokenKey{}, tok)
+}
+
+// oauthTokenFromContext returns the access token the request was admitted
+// with.
+func oauthTokenFromContext(ctx context.Context) (oauthToken, bool) {
+	tok, ok := ctx.Value(oauthTokenKey{}).(oauthToken)
+	return tok, ok
+}
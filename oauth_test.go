@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOAuthResourceMetadata(t *testing.T) {
+	o, err := newOAuthResource(&OAuthConfig{
+		Resource:              "https://proxy.example.com/mcp",
+		AuthorizationServers:  []string{"https://auth.example.com"},
+		ScopesSupported:       []string{"mcp:tools"},
+		IntrospectionEndpoint: "https://auth.example.com/introspect",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	o.register(mux)
+	for _, path := range []string{oauthMetadataPath, oauthMetadataPath + "/mcp"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var doc map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d, %v", path, rec.Code, err)
+		}
+		if doc["resource"] != "https://proxy.example.com/mcp" || doc["authorization_servers"].([]any)[0] != "https://auth.example.com" || doc["scopes_supported"] == nil {
+			t.Fatalf("%s: metadata = %v", path, doc)
+		}
+	}
+
+	for _, conf := range []*OAuthConfig{
+		{Resource: "/mcp", AuthorizationServers: []string{"https://auth.example.com"}, IntrospectionEndpoint: "https://auth.example.com/introspect"},
+		{Resource: "https://proxy.example.com/mcp", IntrospectionEndpoint: "https://auth.example.com/introspect"},
+		{Resource: "https://proxy.example.com/mcp", AuthorizationServers: []string{"https://auth.example.com"}},
+	} {
+		if _, err := newOAuthResource(conf); err == nil {
+			t.Errorf("config %+v accepted", conf)
+		}
+	}
+}
+
+func TestOAuthMiddleware(t *testing.T) {
+	var introspections atomic.Int32
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspections.Add(1)
+		if id, secret, _ := r.BasicAuth(); id != "proxy" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body := map[string]any{"active": false}
+		switch r.FormValue("token") {
+		case "good":
+			body = map[string]any{"active": true, "sub": "alice", "scope": "mcp:tools mcp:prompts", "aud": []string{"https://proxy.example.com/mcp"}, "exp": now.Add(time.Hour).Unix()}
+		case "other-audience":
+			body = map[string]any{"active": true, "sub": "bob", "aud": "https://elsewhere.example.com"}
+		case "no-audience":
+			body = map[string]any{"active": true, "sub": "dave"}
+		case "expired":
+			body = map[string]any{"active": true, "sub": "carol", "exp": now.Add(-time.Minute).Unix()}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer authServer.Close()
+
+	o, err := newOAuthResource(&OAuthConfig{
+		Resource:              "https://proxy.example.com/mcp",
+		AuthorizationServers:  []string{"https://auth.example.com"},
+		IntrospectionEndpoint: authServer.URL,
+		ClientID:              "proxy",
+		ClientSecret:          "s3cret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.now = func() time.Time { return now }
+	var subject string
+	handler := o.middleware("test", newRouteTokens([]string{"static"}, nil, "test"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tok, _ := oauthTokenFromContext(r.Context())
+		subject = tok.Subject
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("")
+	challenge := rec.Header().Get("WWW-Authenticate")
+	if rec.Code != http.StatusUnauthorized || challenge != `Bearer resource_metadata="https://proxy.example.com/.well-known/oauth-protected-resource/mcp"` {
+		t.Fatalf("no token: %d %q", rec.Code, challenge)
+	}
+	for _, token := range []string{"unknown", "other-audience", "no-audience", "expired"} {
+		rec := call(token)
+		if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Header().Get("WWW-Authenticate"), `error="invalid_token"`) {
+			t.Fatalf("%s: %d %q", token, rec.Code, rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	before := introspections.Load()
+	for i := 0; i < 3; i++ {
+		if rec := call("good"); rec.Code != http.StatusNoContent || subject != "alice" {
+			t.Fatalf("good token: %d subject=%q", rec.Code, subject)
+		}
+	}
+	if got := introspections.Load() - before; got != 1 {
+		t.Fatalf("introspections for a cached token = %d", got)
+	}
+	now = now.Add(2 * defaultOAuthCacheTTL)
+	if call("good"); introspections.Load()-before != 2 {
+		t.Fatal("token not introspected again after the cache TTL")
+	}
+
+	before = introspections.Load()
+	if rec := call("static"); rec.Code != http.StatusNoContent || introspections.Load() != before {
+		t.Fatalf("static token: %d", rec.Code)
+	}
+
+	o.conf.ClientSecret = "wrong"
+	if rec := call("fresh"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("introspection failure: %d", rec.Code)
+	}
+}
+
+func TestOAuthResourceJWT(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := &testJWKS{}
+	jwks.set(rsaJWK("k", key))
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+	o, err := newOAuthResource(&OAuthConfig{
+		Resource:             "https://proxy.example.com/mcp",
+		AuthorizationServers: []string{"https://auth.example.com"},
+		JWT:                  &JWTConfig{JWKSURL: server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	if _, err := o.validate(t.Context(), signJWT(t, "RS256", "k", key, map[string]any{"aud": "https://proxy.example.com/mcp", "exp": exp})); err != nil {
+		t.Fatalf("token for the resource: %v", err)
+	}
+	if _, err := o.validate(t.Context(), signJWT(t, "RS256", "k", key, map[string]any{"aud": "https://elsewhere.example.com", "exp": exp})); err != errOAuthInvalidToken {
+		t.Fatalf("token for another resource: %v", err)
+	}
+	if _, err := o.validate(t.Context(), "opaque"); err != errOAuthInvalidToken {
+		t.Fatalf("opaque token without introspection: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const panicRecentLimit = 20
+
+// PanicReportConfig controls crash report files for recovered handler panics.
+type PanicReportConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Dir     string `json:"dir,omitempty"`
+}
+
+// panicReport describes one recovered panic with the context the handler
+// had annotated before it blew up.
+type panicReport struct {
+	ID        string    `json:"id"`
+	At        time.Time `json:"at"`
+	Route     string    `json:"route"`
+	Prefix    string    `json:"prefix"`
+	Method    string    `json:"method,omitempty"`
+	Target    string    `json:"target,omitempty"`
+	Caller    string    `json:"caller,omitempty"`
+	SessionID string    `json:"sessionId,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	Value     string    `json:"value"`
+	Stack     string    `json:"stack"`
+}
+
+// panicContext is placed on the request context by recoverMiddleware so the
+// handler can say what it was doing (JSON-RPC method, tool name, ...).
+type panicContext struct {
+	mu     sync.Mutex
+	method string
+	target string
+}
+
+type panicContextKey struct{}
+
+// annotatePanicContext records the current operation; no-op outside
+// recoverMiddleware.
+func annotatePanicContext(ctx context.Context, method, target string) {
+	pc, ok := ctx.Value(panicContextKey{}).(*panicContext)
+	if !ok {
+		return
+	}
+	pc.mu.Lock()
+	pc.method, pc.target = method, target
+	pc.mu.Unlock()
+}
+
+// panicRecorder counts recovered panics per route and keeps the most recent
+// reports for the admin API. recoverMiddleware is shared by every route, so
+// the recorder is process-wide.
+type panicRecorder struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+	recent []panicReport
+	dir    string
+}
+
+var panicStats = &panicRecorder{counts: make(map[string]uint64)}
+
+// configure enables crash report files; the directory defaults to
+// stateHome()/crash/panics.
+func (p *panicRecorder) configure(conf *PanicReportConfig) error {
+	dir := ""
+	if conf != nil && conf.Enabled {
+		dir = conf.Dir
+		if dir == "" {
+			dir = filepath.Join(stateHome(), "crash", "panics")
+		}
+		guarded, err := resolveGuardedPath(dir)
+		if err != nil {
+			return fmt.Errorf("panicReports.dir: %w", err)
+		}
+		dir = guarded
+	}
+	p.mu.Lock()
+	p.dir = dir
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *panicRecorder) record(report panicReport) string {
+	p.mu.Lock()
+	p.counts[report.Route]++
+	p.recent = append(p.recent, report)
+	if len(p.recent) > panicRecentLimit {
+		p.recent = append(p.recent[:0], p.recent[len(p.recent)-panicRecentLimit:]...)
+	}
+	dir := p.dir
+	p.mu.Unlock()
+	if dir == "" {
+		return ""
+	}
+	path, err := writePanicReport(dir, report)
+	if err != nil {
+		routeLogger(report.Prefix).Error("failed to write panic report", "err", err)
+		return ""
+	}
+	return path
+}
+
+func writePanicReport(dir string, report panicReport) (string, error) {
+	name := fmt.Sprintf("panic-%s-%s.json", report.At.UTC().Format("20060102-150405"), report.ID[:8])
+	path, err := mkdirAllUnder(dir, filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, writeAtomic(path, append(data, '\n'))
+}
+
+// snapshot returns per-route counts and the most recent reports, newest first.
+func (p *panicRecorder) snapshot() map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	counts := make(map[string]uint64, len(p.counts))
+	var total uint64
+	for route, n := range p.counts {
+		counts[route] = n
+		total += n
+	}
+	recent := append([]panicReport(nil), p.recent...)
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].At.After(recent[j].At) })
+	return map[string]any{"total": total, "byRoute": counts, "recent": recent}
+}
+
+func recoverMiddleware(prefix string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pc := &panicContext{}
+			defer func() {
+				if err := recover(); err != nil {
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+					report := newPanicReport(prefix, r, pc, err, debug.Stack())
+					path := panicStats.record(report)
+					routeLogger(prefix).Error("panic", "err", err, "route", report.Route, "method", report.Method, "target", report.Target, "requestId", report.RequestID, "id", report.ID, "stack", report.Stack)
+					if path != "" {
+						routeLogger(prefix).Error("wrote panic report", "path", path)
+					}
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), panicContextKey{}, pc)))
+		})
+	}
+}
+
+// panicRoute prefers the mux pattern so token-bearing paths are not counted
+// (or logged) one by one.
+func panicRoute(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+func newPanicReport(prefix string, r *http.Request, pc *panicContext, value any, stack []byte) panicReport {
+	report := panicReport{
+		ID:     uuid.New().String(),
+		At:     time.Now().UTC(),
+		Route:  panicRoute(r),
+		Prefix: prefix,
+		Value:  fmt.Sprint(value),
+		Stack:  string(stack),
+	}
+	pc.mu.Lock()
+	report.Method, report.Target = pc.method, pc.target
+	pc.mu.Unlock()
+	if info, ok := callerFromContext(r.Context()); ok {
+		report.Caller = info.Identity
+		report.SessionID = info.SessionID
+		report.RequestID = info.RequestID
+	}
+	return report
+}
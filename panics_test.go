@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecoverMiddlewareRecordsPanic(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("STELAE_STATE_HOME", base)
+	if err := panicStats.configure(&PanicReportConfig{Enabled: true}); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	t.Cleanup(func() { _ = panicStats.configure(nil) })
+	before := panicStats.snapshot()["byRoute"].(map[string]uint64)["POST /boom"]
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /boom", chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		annotatePanicContext(r.Context(), "tools/call", "explode")
+		panic("kaboom")
+	}), recoverMiddleware("facade"), callerContextMiddleware()))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+
+	snap := panicStats.snapshot()
+	if got := snap["byRoute"].(map[string]uint64)["POST /boom"]; got != before+1 {
+		t.Fatalf("route count = %d, want %d", got, before+1)
+	}
+	recent := snap["recent"].([]panicReport)
+	latest := recent[0]
+	if latest.Method != "tools/call" || latest.Target != "explode" || latest.Value != "kaboom" {
+		t.Fatalf("unexpected report %+v", latest)
+	}
+	if latest.RequestID == "" || !strings.Contains(latest.Stack, "panics_test.go") {
+		t.Fatalf("report missing request id or stack: %+v", latest)
+	}
+
+	files, err := filepath.Glob(filepath.Join(base, "crash", "panics", "panic-*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one panic report file, got %v (%v)", files, err)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), latest.ID) {
+		t.Fatalf("report file does not match recorded panic")
+	}
+}
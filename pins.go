@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolSchemas returns a tool's input and output schemas as plain JSON
+// objects, whichever form the server reported them in.
+func toolSchemas(tool mcp.Tool) (input, output map[string]any) {
+	descriptor := toolDescriptorFromServer(tool)
+	decode := func(v any) map[string]any {
+		if v == nil {
+			return nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var out map[string]any
+		_ = json.Unmarshal(data, &out)
+		return out
+	}
+	return decode(descriptor["inputSchema"]), decode(descriptor["outputSchema"])
+}
+
+// toolSchemaHash is the hash a pin compares against. Only the schemas count;
+// description or annotation edits are not drift.
+func toolSchemaHash(tool mcp.Tool) string {
+	input, output := toolSchemas(tool)
+	return hashSchema(map[string]any{"inputSchema": input, "outputSchema": output})
+}
+
+func newToolPin(tool mcp.Tool, serve bool, now time.Time) *ToolPinConfig {
+	input, output := toolSchemas(tool)
+	return &ToolPinConfig{
+		SchemaHash:   toolSchemaHash(tool),
+		InputSchema:  input,
+		OutputSchema: output,
+		PinnedAt:     now.UTC().Format(time.RFC3339),
+		Serve:        serve,
+	}
+}
+
+// toolPin returns the pin for a tool under its server's own name.
+func toolPin(set *ToolOverrideSet, server, tool string) *ToolPinConfig {
+	if set == nil {
+		return nil
+	}
+	if frag := set.Servers[server]; frag != nil {
+		if cfg := frag.Tools[tool]; cfg != nil {
+			return cfg.Pin
+		}
+	}
+	return nil
+}
+
+// toolDrift is a pinned tool whose live schemas no longer match the pin.
+type toolDrift struct {
+	Server     string `json:"server"`
+	Tool       string `json:"tool"`
+	PinnedHash string `json:"pinnedHash"`
+	LiveHash   string `json:"liveHash"`
+	PinnedAt   string `json:"pinnedAt,omitempty"`
+	// Serving is true when the facade still advertises the pinned schemas.
+	Serving bool `json:"servingPinned"`
+}
+
+// schemaDrift compares a server's tool with its pin. tool carries the
+// server's own name, not a facade prefix.
+func schemaDrift(set *ToolOverrideSet, server string, tool mcp.Tool) (*ToolPinConfig, *toolDrift) {
+	pin := toolPin(set, server, tool.Name)
+	if pin == nil || pin.SchemaHash == "" {
+		return pin, nil
+	}
+	live := toolSchemaHash(tool)
+	if live == pin.SchemaHash {
+		return pin, nil
+	}
+	return pin, &toolDrift{
+		Server:     server,
+		Tool:       tool.Name,
+		PinnedHash: pin.SchemaHash,
+		LiveHash:   live,
+		PinnedAt:   pin.PinnedAt,
+		Serving:    pin.Serve,
+	}
+}
+
+// servePinnedSchemas swaps a drifted descriptor's schemas for the pinned ones.
+func servePinnedSchemas(descriptor map[string]any, pin *ToolPinConfig) map[string]any {
+	delete(descriptor, "inputSchema")
+	delete(descriptor, "outputSchema")
+	if pin.InputSchema != nil {
+		descriptor["inputSchema"] = copySchemaMap(pin.InputSchema)
+	}
+	if pin.OutputSchema != nil {
+		descriptor["outputSchema"] = copySchemaMap(pin.OutputSchema)
+	}
+	return descriptor
+}
+
+// attachDrift marks a descriptor with the drift of the servers behind it
+// under x-stelae.drift.
+func attachDrift(descriptor map[string]any, drift []toolDrift) map[string]any {
+	if descriptor == nil || len(drift) == 0 {
+		return descriptor
+	}
+	meta, _ := descriptor["x-stelae"].(map[string]any)
+	meta = copyStringAnyMap(meta)
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	meta["drift"] = drift
+	descriptor["x-stelae"] = meta
+	return descriptor
+}
+
+// serverToolDrift lists the drifted tools of one server.
+func serverToolDrift(set *ToolOverrideSet, name string, srv *Server) []toolDrift {
+	if srv == nil {
+		return nil
+	}
+	var out []toolDrift
+	for _, tool := range srv.tools {
+		tool.Name = srv.downstreamToolName(tool.Name)
+		if _, drift := schemaDrift(set, name, tool); drift != nil {
+			out = append(out, *drift)
+		}
+	}
+	return out
+}
+
+// schemaPinMonitor tracks drifted tools so a change is reported once, when a
+// server connects or the catalog is rebuilt, rather than on every listing.
+type schemaPinMonitor struct {
+	events *eventBus
+
+	mu      sync.Mutex
+	drifted map[toolToggleKey]toolDrift
+}
+
+func newSchemaPinMonitor(events *eventBus) *schemaPinMonitor {
+	return &schemaPinMonitor{events: events, drifted: make(map[toolToggleKey]toolDrift)}
+}
+
+// check re-evaluates the pins of the given servers; servers not in the map
+// keep their last result.
+func (m *schemaPinMonitor) check(servers map[string]*Server, set *ToolOverrideSet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, srv := range servers {
+		current := make(map[toolToggleKey]toolDrift)
+		for _, drift := range serverToolDrift(set, name, srv) {
+			current[toolToggleKey{Server: drift.Server, Tool: drift.Tool}] = drift
+		}
+		for key, prev := range m.drifted {
+			if key.Server != name {
+				continue
+			}
+			if _, still := current[key]; !still {
+				logger("catalog").Info("schema drift resolved", "server", key.Server, "tool", key.Tool)
+				delete(m.drifted, key)
+				continue
+			}
+			if prev.LiveHash == current[key].LiveHash {
+				m.drifted[key] = current[key]
+				delete(current, key)
+			}
+		}
+		for key, drift := range current {
+			m.drifted[key] = drift
+			logger("catalog").Warn("schema drift", "server", drift.Server, "tool", drift.Tool, "pinned", drift.PinnedHash, "live", drift.LiveHash, "servingPinned", drift.Serving)
+			m.events.emit("tool.schema_drift", map[string]any{
+				"server":        drift.Server,
+				"tool":          drift.Tool,
+				"pinnedHash":    drift.PinnedHash,
+				"liveHash":      drift.LiveHash,
+				"pinnedAt":      drift.PinnedAt,
+				"servingPinned": drift.Serving,
+			})
+		}
+	}
+}
+
+func (m *schemaPinMonitor) list() []toolDrift {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]toolDrift, 0, len(m.drifted))
+	for _, drift := range m.drifted {
+		out = append(out, drift)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].Tool < out[j].Tool
+	})
+	return out
+}
+
+var errNoOverridesPath = errors.New("manifest.toolOverridesPath is not set")
+
+// registerPinRoutes lists drifted tools and pins a tool's live schemas into
+// the overrides file.
+func registerPinRoutes(api *adminAPI, monitor *schemaPinMonitor, pin func(server, tool string, serve bool) (*ToolPinConfig, error)) {
+	api.handle(http.MethodGet, "tools/drift", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"drift": monitor.list()})
+	})
+	api.handle(http.MethodPost, "tools/{server}/{tool}/pin", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Serve bool `json:"serve"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+				return
+			}
+		}
+		server, tool := r.PathValue("server"), r.PathValue("tool")
+		pinned, err := pin(server, tool, body.Serve)
+		switch {
+		case errors.Is(err, errNoOverridesPath):
+			api.writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+			return
+		case errors.Is(err, errUnknownTool):
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+			return
+		case err != nil:
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		logger("admin").Info("pinned tool", "server", server, "tool", tool, "schema", pinned.SchemaHash, "by", "admin:"+tokenFingerprint(bearerToken(r)))
+		api.writeJSON(w, http.StatusOK, pinned)
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolPinDrift(t *testing.T) {
+	pinned := mcp.NewTool("read_file", mcp.WithString("path", mcp.Required()))
+	pin := newToolPin(pinned, true, time.Now())
+	set := &ToolOverrideSet{Servers: map[string]*toolOverrideFragment{
+		"fs": {Tools: map[string]*ToolOverrideConfig{"read_file": {Pin: pin}}},
+	}}
+	srv := &Server{name: "fs", tools: []mcp.Tool{pinned}}
+	servers := map[string]*Server{"fs": srv}
+
+	monitor := newSchemaPinMonitor(nil)
+	monitor.check(servers, set)
+	if drift := monitor.list(); len(drift) != 0 {
+		t.Fatalf("expected no drift for an unchanged tool, got %+v", drift)
+	}
+
+	srv.tools = []mcp.Tool{mcp.NewTool("read_file", mcp.WithString("path", mcp.Required()), mcp.WithNumber("limit"), mcp.WithDescription("now paginated"))}
+	monitor.check(servers, set)
+	drift := monitor.list()
+	if len(drift) != 1 || drift[0].Tool != "read_file" || drift[0].PinnedHash != pin.SchemaHash || !drift[0].Serving {
+		t.Fatalf("drift = %+v", drift)
+	}
+
+	tools := testProxy().collectTools(servers, set, nil)
+	if len(tools) != 3 {
+		t.Fatalf("expected read_file plus the facade tools, got %d", len(tools))
+	}
+	var descriptor map[string]any
+	for _, tool := range tools {
+		if tool["name"] == "read_file" {
+			descriptor = tool
+		}
+	}
+	props, _ := descriptor["inputSchema"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := props["limit"]; ok || props["path"] == nil {
+		t.Fatalf("expected the pinned input schema to be served, got %v", descriptor["inputSchema"])
+	}
+	if descriptor["description"] != "now paginated" {
+		t.Fatalf("expected the live description, got %v", descriptor["description"])
+	}
+	meta, _ := descriptor["x-stelae"].(map[string]any)
+	if marked, _ := meta["drift"].([]toolDrift); len(marked) != 1 || marked[0].Server != "fs" {
+		t.Fatalf("x-stelae = %v", meta)
+	}
+
+	set.Servers["fs"].Tools["read_file"].Pin = newToolPin(srv.tools[0], false, time.Now())
+	monitor.check(servers, set)
+	if drift := monitor.list(); len(drift) != 0 {
+		t.Fatalf("expected re-pinning to resolve the drift, got %+v", drift)
+	}
+}
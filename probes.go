@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProbeInterval = time.Minute
+	defaultProbeTimeout  = 10 * time.Second
+
+	// probeMethod is the callKey method probe results are recorded under, so
+	// they show in the call metrics without counting toward tools/call SLOs.
+	probeMethod = "probe"
+)
+
+// ProbeConfig is one synthetic call, made on a schedule to check that a tool
+// works end to end. Arguments must be safe to send repeatedly.
+type ProbeConfig struct {
+	Name      string         `json:"name"`
+	Server    string         `json:"server"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Interval  time.Duration  `json:"interval,omitempty"`
+	Timeout   time.Duration  `json:"timeout,omitempty"`
+	Expect    *ProbeExpect   `json:"expect,omitempty"`
+}
+
+// ProbeExpect is what a passing probe returns on top of a non-error result.
+type ProbeExpect struct {
+	// Contains must appear in the result's text content or, failing that,
+	// its structuredContent as JSON.
+	Contains   string        `json:"contains,omitempty"`
+	MaxLatency time.Duration `json:"maxLatency,omitempty"`
+}
+
+// probeResult is the latest outcome of one probe.
+type probeResult struct {
+	Name                string     `json:"name"`
+	Server              string     `json:"server"`
+	Tool                string     `json:"tool"`
+	OK                  bool       `json:"ok"`
+	Error               string     `json:"error,omitempty"`
+	Duration            float64    `json:"durationMs"`
+	At                  *time.Time `json:"at,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+}
+
+// probeDispatch runs one tools/call body against a server and returns the
+// JSON-RPC response and HTTP status, as dispatchToClient does.
+type probeDispatch func(ctx context.Context, server string, body []byte) ([]byte, int)
+
+type probeRunner struct {
+	probes   []*ProbeConfig
+	dispatch probeDispatch
+	metrics  *callMetrics
+	events   *eventBus
+
+	mu      sync.Mutex
+	results map[string]*probeResult
+}
+
+func newProbeRunner(probes []*ProbeConfig, dispatch probeDispatch, metrics *callMetrics, events *eventBus) (*probeRunner, error) {
+	if len(probes) == 0 {
+		return nil, nil
+	}
+	p := &probeRunner{dispatch: dispatch, metrics: metrics, events: events, results: make(map[string]*probeResult)}
+	for i, conf := range probes {
+		if conf == nil || conf.Server == "" || conf.Tool == "" {
+			return nil, fmt.Errorf("probes[%d]: server and tool are required", i)
+		}
+		probe := *conf
+		if probe.Name == "" {
+			probe.Name = probe.Server + "/" + probe.Tool
+		}
+		if _, dup := p.results[probe.Name]; dup {
+			return nil, fmt.Errorf("probes[%d]: duplicate name %q", i, probe.Name)
+		}
+		if probe.Interval <= 0 {
+			probe.Interval = defaultProbeInterval
+		}
+		if probe.Timeout <= 0 {
+			probe.Timeout = defaultProbeTimeout
+		}
+		p.probes = append(p.probes, &probe)
+		p.results[probe.Name] = &probeResult{Name: probe.Name, Server: probe.Server, Tool: probe.Tool}
+	}
+	return p, nil
+}
+
+// run probes each target every interval, the first time right away, until
+// ctx ends.
+func (p *probeRunner) run(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	for _, probe := range p.probes {
+		go func() {
+			ticker := time.NewTicker(probe.Interval)
+			defer ticker.Stop()
+			for {
+				p.probe(ctx, probe)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+func (p *probeRunner) probe(ctx context.Context, probe *ProbeConfig) {
+	params := map[string]any{"name": probe.Tool}
+	if probe.Arguments != nil {
+		params["arguments"] = probe.Arguments
+	}
+	body, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": "probe-" + probe.Name, "method": "tools/call", "params": params})
+	callCtx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+	started := time.Now()
+	resp, status := p.dispatch(callCtx, probe.Server, body)
+	elapsed := time.Since(started)
+	err := checkProbeResponse(resp, status, elapsed, probe.Expect)
+	if ctx.Err() != nil {
+		return
+	}
+	p.metrics.record(callKey{Server: probe.Server, Method: probeMethod, Target: probe.Name}, callSample{
+		At:            started.Add(elapsed),
+		Duration:      elapsed,
+		Failed:        err != nil,
+		RequestBytes:  len(body),
+		ResponseBytes: len(resp),
+	})
+	p.record(probe, err, elapsed, started.Add(elapsed))
+}
+
+// checkProbeResponse returns why a probe failed, or nil if it passed.
+func checkProbeResponse(resp []byte, status int, elapsed time.Duration, expect *ProbeExpect) error {
+	if status < 200 || status > 204 {
+		return fmt.Errorf("dispatch failed with status %d", status)
+	}
+	if digest := digestBytes(resp); digest.Failed {
+		return errors.New(digest.ErrorSummary)
+	}
+	if expect == nil {
+		return nil
+	}
+	if expect.MaxLatency > 0 && elapsed > expect.MaxLatency {
+		return fmt.Errorf("took %s, over maxLatency %s", elapsed.Round(time.Millisecond), expect.MaxLatency)
+	}
+	if expect.Contains != "" {
+		var envelope struct {
+			Result map[string]any `json:"result"`
+		}
+		_ = json.Unmarshal(resp, &envelope)
+		if !strings.Contains(extractTextContent(envelope.Result), expect.Contains) {
+			structured, _ := json.Marshal(envelope.Result["structuredContent"])
+			if !strings.Contains(string(structured), expect.Contains) {
+				return fmt.Errorf("result does not contain %q", expect.Contains)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *probeRunner) record(probe *ProbeConfig, err error, elapsed time.Duration, at time.Time) {
+	p.mu.Lock()
+	result := p.results[probe.Name]
+	wasOK, probed := result.OK, result.At != nil
+	at = at.UTC()
+	result.At = &at
+	result.Duration = float64(elapsed.Microseconds()) / 1000
+	result.OK = err == nil
+	if err != nil {
+		result.Error = err.Error()
+		result.ConsecutiveFailures++
+	} else {
+		result.Error = ""
+		result.ConsecutiveFailures = 0
+		result.LastSuccessAt = &at
+	}
+	snapshot := *result
+	p.mu.Unlock()
+
+	switch {
+	case err != nil && (wasOK || !probed):
+		logger("probe").Warn("probe failed", "probe", probe.Name, "server", probe.Server, "tool", probe.Tool, "err", err)
+		p.events.emit("probe.failed", map[string]any{
+			"probe":  snapshot.Name,
+			"server": snapshot.Server,
+			"tool":   snapshot.Tool,
+			"error":  snapshot.Error,
+		})
+	case err == nil && probed && !wasOK:
+		logger("probe").Info("probe recovered", "probe", probe.Name, "server", probe.Server, "tool", probe.Tool, "after", elapsed.Round(time.Millisecond))
+		p.events.emit("probe.recovered", map[string]any{
+			"probe":  snapshot.Name,
+			"server": snapshot.Server,
+			"tool":   snapshot.Tool,
+		})
+	}
+}
+
+// list returns every probe's latest result, by name.
+func (p *probeRunner) list() []probeResult {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]probeResult, 0, len(p.results))
+	for _, result := range p.results {
+		out = append(out, *result)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// health summarizes the probes for the health report: how many pass and
+// which ones fail. Probes that have not run yet count as neither.
+func (p *probeRunner) health() map[string]any {
+	passing := 0
+	failing := make([]string, 0)
+	for _, result := range p.list() {
+		switch {
+		case result.At == nil:
+		case result.OK:
+			passing++
+		default:
+			failing = append(failing, result.Name)
+		}
+	}
+	return map[string]any{"passing": passing, "failing": failing}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeRunner(t *testing.T) {
+	reply := `{"jsonrpc":"2.0","id":"probe","result":{"content":[{"type":"text","text":"status: ok"}]}}`
+	status := http.StatusOK
+	var sent map[string]any
+	metrics := newCallMetrics(time.Hour)
+	runner, err := newProbeRunner([]*ProbeConfig{{
+		Server:    "search",
+		Tool:      "query",
+		Arguments: map[string]any{"q": "health"},
+		Expect:    &ProbeExpect{Contains: "ok"},
+	}}, func(ctx context.Context, server string, body []byte) ([]byte, int) {
+		_ = json.Unmarshal(body, &sent)
+		return []byte(reply), status
+	}, metrics, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe := runner.probes[0]
+	if probe.Name != "search/query" || probe.Interval != defaultProbeInterval {
+		t.Fatalf("defaults not applied: %+v", probe)
+	}
+	if health := runner.health(); health["passing"] != 0 || len(health["failing"].([]string)) != 0 {
+		t.Fatalf("probes that have not run should not count, got %v", health)
+	}
+
+	runner.probe(context.Background(), probe)
+	if params, _ := sent["params"].(map[string]any); params["name"] != "query" || params["arguments"].(map[string]any)["q"] != "health" {
+		t.Fatalf("probe sent %v", sent)
+	}
+	if results := runner.list(); !results[0].OK || results[0].LastSuccessAt == nil {
+		t.Fatalf("results = %+v", results)
+	}
+
+	reply = `{"jsonrpc":"2.0","id":"probe","result":{"content":[{"type":"text","text":"degraded"}]}}`
+	runner.probe(context.Background(), probe)
+	status = http.StatusBadGateway
+	runner.probe(context.Background(), probe)
+	results := runner.list()
+	if results[0].OK || results[0].ConsecutiveFailures != 2 || !strings.Contains(results[0].Error, "status 502") {
+		t.Fatalf("results = %+v", results)
+	}
+	if health := runner.health(); health["passing"] != 0 || len(health["failing"].([]string)) != 1 {
+		t.Fatalf("health = %v", health)
+	}
+	usage := metrics.usageReport(time.Now().Add(-time.Minute), func(key callKey) bool {
+		return key.Method == probeMethod && key.Target == "search/query"
+	})
+	if len(usage) != 1 || usage[0].Calls != 3 || usage[0].Errors != 2 {
+		t.Fatalf("usage = %+v", usage)
+	}
+
+	if _, err := newProbeRunner([]*ProbeConfig{{Server: "a", Tool: "t"}, {Server: "a", Tool: "t"}}, nil, metrics, nil); err == nil {
+		t.Fatal("expected duplicate probe names to be rejected")
+	}
+}
+
+func TestCheckProbeResponse(t *testing.T) {
+	structured := []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[],"structuredContent":{"state":"green"}}}`)
+	if err := checkProbeResponse(structured, http.StatusOK, time.Millisecond, &ProbeExpect{Contains: `"green"`}); err != nil {
+		t.Fatalf("structured content should satisfy contains: %v", err)
+	}
+	if err := checkProbeResponse(structured, http.StatusOK, time.Second, &ProbeExpect{MaxLatency: 100 * time.Millisecond}); err == nil || !strings.Contains(err.Error(), "maxLatency") {
+		t.Fatalf("expected a latency failure, got %v", err)
+	}
+	isError := []byte(`{"jsonrpc":"2.0","id":1,"result":{"isError":true,"content":[{"type":"text","text":"index offline"}]}}`)
+	if err := checkProbeResponse(isError, http.StatusOK, time.Millisecond, nil); err == nil || !strings.Contains(err.Error(), "index offline") {
+		t.Fatalf("expected the tool error, got %v", err)
+	}
+}
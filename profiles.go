@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+const profileEnv = "STELAE_PROFILE"
+
+// ProfileConfig overrides listener settings when its profile is active.
+type ProfileConfig struct {
+	Addr    string `json:"addr,omitempty"`
+	BaseURL string `json:"baseURL,omitempty"`
+}
+
+// activeProfile returns the profile chosen by flag, falling back to $STELAE_PROFILE.
+func activeProfile(flagValue string) string {
+	if v := strings.TrimSpace(flagValue); v != "" {
+		return v
+	}
+	return strings.TrimSpace(os.Getenv(profileEnv))
+}
+
+// inProfile reports whether an entry scoped to profiles is enabled. Entries
+// without profiles are always enabled; scoped entries need a matching profile.
+func inProfile(profiles []string, active string) bool {
+	if len(profiles) == 0 {
+		return true
+	}
+	return active != "" && slices.Contains(profiles, active)
+}
+
+// applyProfile drops servers and tool overrides scoped to other profiles and
+// applies the active profile's listener settings.
+func applyProfile(conf *FullConfig, active string) {
+	if active != "" {
+		if _, ok := conf.Profiles[active]; !ok {
+			logger("config").Warn("profile has no profiles entry; only scoped servers/overrides apply", "profile", active)
+		}
+		logger("config").Info("active profile", "profile", active)
+	}
+	for name, server := range conf.McpServers {
+		if server != nil && !inProfile(server.Profiles, active) {
+			logger("config").Info("server disabled: not in profile", "server", name, "profile", active)
+			delete(conf.McpServers, name)
+		}
+	}
+	if conf.Manifest != nil {
+		for name, override := range conf.Manifest.ToolOverrides {
+			if override != nil && !inProfile(override.Profiles, active) {
+				delete(conf.Manifest.ToolOverrides, name)
+			}
+		}
+	}
+	if profile := conf.Profiles[active]; profile != nil && conf.McpProxy != nil {
+		if profile.Addr != "" {
+			conf.McpProxy.Addr = profile.Addr
+		}
+		if profile.BaseURL != "" {
+			conf.McpProxy.BaseURL = profile.BaseURL
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestApplyProfileScopesServersOverridesAndListener(t *testing.T) {
+	conf := &FullConfig{
+		McpProxy: &MCPProxyConfigV2{Addr: ":9090", BaseURL: "http://localhost:9090"},
+		Manifest: &ManifestConfig{ToolOverrides: map[string]*ToolOverrideConfig{
+			"always":   {},
+			"prodOnly": {Profiles: []string{"prod"}},
+			"devOnly":  {Profiles: []string{"dev"}},
+		}},
+		McpServers: map[string]*MCPClientConfigV2{
+			"shared":  {},
+			"mock":    {Profiles: []string{"dev", "staging"}},
+			"billing": {Profiles: []string{"prod"}},
+		},
+		Profiles: map[string]*ProfileConfig{
+			"prod": {Addr: ":443", BaseURL: "https://mcp.example.com"},
+		},
+	}
+
+	applyProfile(conf, "prod")
+
+	if _, ok := conf.McpServers["mock"]; ok {
+		t.Fatal("dev/staging server should be dropped in prod")
+	}
+	if _, ok := conf.McpServers["billing"]; !ok {
+		t.Fatal("prod server should be kept")
+	}
+	if _, ok := conf.McpServers["shared"]; !ok {
+		t.Fatal("unscoped server should be kept")
+	}
+	if _, ok := conf.Manifest.ToolOverrides["devOnly"]; ok {
+		t.Fatal("dev override should be dropped in prod")
+	}
+	if len(conf.Manifest.ToolOverrides) != 2 {
+		t.Fatalf("unexpected overrides: %v", conf.Manifest.ToolOverrides)
+	}
+	if conf.McpProxy.Addr != ":443" || conf.McpProxy.BaseURL != "https://mcp.example.com" {
+		t.Fatalf("listener not overridden: %+v", conf.McpProxy)
+	}
+}
+
+func TestApplyProfileWithoutActiveProfileKeepsOnlyUnscoped(t *testing.T) {
+	conf := &FullConfig{
+		McpProxy: &MCPProxyConfigV2{Addr: ":9090"},
+		McpServers: map[string]*MCPClientConfigV2{
+			"shared": {},
+			"mock":   {Profiles: []string{"dev"}},
+		},
+	}
+	applyProfile(conf, "")
+	if len(conf.McpServers) != 1 || conf.McpServers["shared"] == nil {
+		t.Fatalf("expected only unscoped server, got %v", conf.McpServers)
+	}
+}
+
+func TestActiveProfileFallsBackToEnv(t *testing.T) {
+	t.Setenv(profileEnv, "staging")
+	if got := activeProfile(""); got != "staging" {
+		t.Fatalf("activeProfile(\"\") = %q", got)
+	}
+	if got := activeProfile("dev"); got != "dev" {
+		t.Fatalf("activeProfile(\"dev\") = %q", got)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	progressNotification = "notifications/progress"
+	// progressTokenPrefix marks the tokens the proxy sends downstream in
+	// place of the client's, so tokens from different sessions never meet.
+	progressTokenPrefix = "stelae-progress-"
+)
+
+// progressCall is a dispatched request whose client asked for progress.
+type progressCall struct {
+	session string
+	token   json.RawMessage
+}
+
+// sessionStreams fans messages out to the open facade event streams of a
+// session. Streams that are not keeping up miss messages rather than
+// blocking the sender.
+type sessionStreams struct {
+	mu   sync.Mutex
+	next uint64
+	subs map[string]map[uint64]chan []byte
+}
+
+func newSessionStreams() *sessionStreams {
+	return &sessionStreams{subs: make(map[string]map[uint64]chan []byte)}
+}
+
+// subscribe registers a stream of session. An empty session returns a nil
+// channel, which never delivers.
+func (s *sessionStreams) subscribe(session string) (<-chan []byte, func()) {
+	if session == "" {
+		return nil, func() {}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.next
+	s.next++
+	ch := make(chan []byte, 64)
+	if s.subs[session] == nil {
+		s.subs[session] = make(map[uint64]chan []byte)
+	}
+	s.subs[session][id] = ch
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs[session], id)
+		if len(s.subs[session]) == 0 {
+			delete(s.subs, session)
+		}
+	}
+}
+
+// send queues msg on every stream of session and returns how many took it.
+func (s *sessionStreams) send(session string, msg []byte) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sent := 0
+	for _, ch := range s.subs[session] {
+		select {
+		case ch <- msg:
+			sent++
+		default:
+		}
+	}
+	return sent
+}
+
+// progressRelay forwards downstream notifications/progress to the facade
+// session whose request carried the progressToken. Requests are sent
+// downstream with a proxy token that maps back to the session and the
+// client's own token.
+type progressRelay struct {
+	streams *sessionStreams
+
+	mu    sync.Mutex
+	next  uint64
+	calls map[string]progressCall
+}
+
+func newProgressRelay(streams *sessionStreams) *progressRelay {
+	return &progressRelay{streams: streams, calls: make(map[string]progressCall)}
+}
+
+// track swaps the progressToken in body's params._meta for a proxy token
+// until the returned func runs. Bodies without a token, or calls without a
+// session to deliver to, are returned unchanged.
+func (p *progressRelay) track(session string, body []byte) ([]byte, func()) {
+	if p == nil || session == "" {
+		return body, func() {}
+	}
+	var envelope map[string]json.RawMessage
+	var params map[string]json.RawMessage
+	var meta map[string]json.RawMessage
+	if json.Unmarshal(body, &envelope) != nil || json.Unmarshal(envelope["params"], &params) != nil || json.Unmarshal(params["_meta"], &meta) != nil {
+		return body, func() {}
+	}
+	token := meta["progressToken"]
+	if len(token) == 0 || string(token) == "null" {
+		return body, func() {}
+	}
+
+	p.mu.Lock()
+	p.next++
+	proxyToken := fmt.Sprintf("%s%d", progressTokenPrefix, p.next)
+	p.calls[proxyToken] = progressCall{session: session, token: token}
+	p.mu.Unlock()
+	release := func() {
+		p.mu.Lock()
+		delete(p.calls, proxyToken)
+		p.mu.Unlock()
+	}
+
+	meta["progressToken"], _ = json.Marshal(proxyToken)
+	var err error
+	if params["_meta"], err = json.Marshal(meta); err == nil {
+		if envelope["params"], err = json.Marshal(params); err == nil {
+			if out, err := json.Marshal(envelope); err == nil {
+				return out, release
+			}
+		}
+	}
+	release()
+	return body, func() {}
+}
+
+// forward delivers a downstream progress notification to the session that
+// owns its token, under the client's token. It reports whether the
+// notification belonged to a tracked call.
+func (p *progressRelay) forward(n mcp.JSONRPCNotification) bool {
+	if p == nil || n.Method != progressNotification {
+		return false
+	}
+	proxyToken, _ := n.Params.AdditionalFields["progressToken"].(string)
+	p.mu.Lock()
+	call, ok := p.calls[proxyToken]
+	p.mu.Unlock()
+	if !ok {
+		return false
+	}
+	params := make(map[string]any, len(n.Params.AdditionalFields)+1)
+	for k, v := range n.Params.AdditionalFields {
+		params[k] = v
+	}
+	params["progressToken"] = call.token
+	if len(n.Params.Meta) > 0 {
+		params["_meta"] = n.Params.Meta
+	}
+	msg, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": progressNotification, "params": params})
+	if err != nil {
+		return true
+	}
+	p.streams.send(call.session, msg)
+	return true
+}
+
+// mergeNotices fans several notice channels into one until ctx ends. Nil
+// channels are skipped.
+func mergeNotices(ctx context.Context, channels ...<-chan []byte) <-chan []byte {
+	out := make(chan []byte, 16)
+	for _, ch := range channels {
+		if ch == nil {
+			continue
+		}
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-ch:
+					select {
+					case out <- msg:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestProgressRelay(t *testing.T) {
+	streams := newSessionStreams()
+	p := newProgressRelay(streams)
+	body := []byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"index","_meta":{"progressToken":42,"trace":"x"}}}`)
+	if out, _ := p.track("", body); string(out) != string(body) {
+		t.Fatal("a call without a session was rewritten")
+	}
+	plain := []byte(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"index"}}`)
+	if out, _ := p.track("sess-1", plain); string(out) != string(plain) {
+		t.Fatal("a call without a progressToken was rewritten")
+	}
+
+	out, release := p.track("sess-1", body)
+	var sent struct {
+		Params struct {
+			Meta map[string]any `json:"_meta"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(out, &sent); err != nil {
+		t.Fatal(err)
+	}
+	proxyToken, _ := sent.Params.Meta["progressToken"].(string)
+	if !strings.HasPrefix(proxyToken, progressTokenPrefix) || sent.Params.Meta["trace"] != "x" {
+		t.Fatalf("forwarded _meta = %v", sent.Params.Meta)
+	}
+
+	notices, unsubscribe := streams.subscribe("sess-1")
+	defer unsubscribe()
+	other, unsubscribeOther := streams.subscribe("sess-2")
+	defer unsubscribeOther()
+
+	progressFor := func(token string) mcp.JSONRPCNotification {
+		n := mcp.JSONRPCNotification{JSONRPC: "2.0"}
+		n.Method = progressNotification
+		n.Params.AdditionalFields = map[string]any{"progressToken": token, "progress": 5, "total": 10}
+		return n
+	}
+	if !p.forward(progressFor(proxyToken)) {
+		t.Fatal("tracked progress not forwarded")
+	}
+	select {
+	case msg := <-notices:
+		var got struct {
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		_ = json.Unmarshal(msg, &got)
+		if got.Method != progressNotification || got.Params["progressToken"] != float64(42) || got.Params["progress"] != float64(5) {
+			t.Fatalf("forwarded %s", msg)
+		}
+	default:
+		t.Fatal("session stream got no progress")
+	}
+	select {
+	case msg := <-other:
+		t.Fatalf("another session got %s", msg)
+	default:
+	}
+
+	release()
+	if p.forward(progressFor(proxyToken)) {
+		t.Fatal("progress forwarded after the call finished")
+	}
+	if p.forward(progressFor("client-token")) {
+		t.Fatal("untracked progress forwarded")
+	}
+}
+
+func TestMergeNotices(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, b := make(chan []byte, 1), make(chan []byte, 1)
+	merged := mergeNotices(ctx, a, nil, b)
+	a <- []byte("a")
+	b <- []byte("b")
+	seen := map[string]bool{}
+	for range 2 {
+		select {
+		case msg := <-merged:
+			seen[string(msg)] = true
+		case <-time.After(time.Second):
+			t.Fatal("merged channel stalled")
+		}
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("merged %v", seen)
+	}
+}
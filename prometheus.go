@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMetricsPath = "/metrics"
+
+// MetricsConfig controls the Prometheus endpoint, served at Path (default
+// /metrics) unless Disabled. When AuthTokens is set scrapes need one of them
+// as a bearer token.
+type MetricsConfig struct {
+	Disabled   bool     `json:"disabled,omitempty"`
+	Path       string   `json:"path,omitempty"`
+	AuthTokens []string `json:"authTokens,omitempty"`
+}
+
+// callDurationBuckets are the upper bounds, in seconds, of the call latency
+// histogram.
+var callDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// promMetrics is the process-wide set of metric families. Counters and
+// histograms are updated as calls are made; gauges are read at scrape time.
+var promMetrics = &promRegistry{}
+
+var (
+	promCalls = promMetrics.counter("stelae_calls_total",
+		"Calls dispatched to downstream servers by outcome.", "server", "method", "target", "status")
+	promCallDuration = promMetrics.histogram("stelae_call_duration_seconds",
+		"Latency of calls dispatched to downstream servers.", callDurationBuckets, "server", "method", "target")
+	promDispatchOutcomes = promMetrics.counter("stelae_dispatch_outcomes_total",
+		"Facade dispatches by outcome, including calls refused before reaching the server.", "server", "outcome")
+	promDisconnects = promMetrics.counter("stelae_downstream_disconnects_total",
+		"Downstream clients that dropped and were handed to the reconnect supervisor.", "server")
+	promReconnects = promMetrics.counter("stelae_downstream_reconnects_total",
+		"Downstream servers reconnected after dropping.", "server")
+	promAdoptions = promMetrics.counter("stelae_adapter_adoptions_total",
+		"Output adapter adoption transitions by state.", "server", "tool", "adapter", "state")
+	promSessions = promMetrics.gauge("stelae_facade_sessions",
+		"Live facade sessions by transport.", "transport")
+	promStreams = promMetrics.gauge("stelae_facade_streams",
+		"Open facade event streams (SSE and WebSocket) by transport.", "transport")
+)
+
+// promRegistry holds metric families in registration order.
+type promRegistry struct {
+	mu       sync.Mutex
+	families []*promFamily
+}
+
+// promFamily is one metric and its labelled series. A gauge has no stored
+// series; collect reports its current values.
+type promFamily struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu      sync.Mutex
+	series  map[string]*promSeries
+	collect func(emit func(value float64, labels ...string))
+}
+
+type promSeries struct {
+	labels []string
+	value  float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (r *promRegistry) register(f *promFamily) *promFamily {
+	f.series = make(map[string]*promSeries)
+	r.mu.Lock()
+	r.families = append(r.families, f)
+	r.mu.Unlock()
+	return f
+}
+
+func (r *promRegistry) counter(name, help string, labels ...string) *promFamily {
+	return r.register(&promFamily{name: name, help: help, kind: "counter", labels: labels})
+}
+
+func (r *promRegistry) histogram(name, help string, buckets []float64, labels ...string) *promFamily {
+	return r.register(&promFamily{name: name, help: help, kind: "histogram", labels: labels, buckets: buckets})
+}
+
+func (r *promRegistry) gauge(name, help string, labels ...string) *promFamily {
+	return r.register(&promFamily{name: name, help: help, kind: "gauge", labels: labels})
+}
+
+func (f *promFamily) seriesFor(values []string) *promSeries {
+	key := strings.Join(values, "\xff")
+	s := f.series[key]
+	if s == nil {
+		s = &promSeries{labels: append([]string(nil), values...)}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// inc adds one to the counter series with the given label values.
+func (f *promFamily) inc(values ...string) {
+	f.mu.Lock()
+	f.seriesFor(values).value++
+	f.mu.Unlock()
+}
+
+// observe records v in the histogram series with the given label values.
+func (f *promFamily) observe(v float64, values ...string) {
+	f.mu.Lock()
+	s := f.seriesFor(values)
+	for i, bound := range f.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+	f.mu.Unlock()
+}
+
+// setCollector makes the gauge report what fn emits at each scrape,
+// replacing any earlier collector.
+func (f *promFamily) setCollector(fn func(emit func(value float64, labels ...string))) {
+	f.mu.Lock()
+	f.collect = fn
+	f.mu.Unlock()
+}
+
+// write renders every family in the Prometheus text exposition format.
+// Series are sorted by label values so scrapes are stable.
+func (r *promRegistry) write(w *bufio.Writer) {
+	r.mu.Lock()
+	families := append([]*promFamily(nil), r.families...)
+	r.mu.Unlock()
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+func (f *promFamily) write(w *bufio.Writer) {
+	f.mu.Lock()
+	series := make([]promSeries, 0, len(f.series))
+	for _, s := range f.series {
+		copied := *s
+		copied.counts = append([]uint64(nil), s.counts...)
+		series = append(series, copied)
+	}
+	collect := f.collect
+	f.mu.Unlock()
+	if collect != nil {
+		collect(func(value float64, labels ...string) {
+			series = append(series, promSeries{labels: labels, value: value})
+		})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return strings.Join(series[i].labels, "\xff") < strings.Join(series[j].labels, "\xff")
+	})
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	for _, s := range series {
+		labels := promLabels(f.labels, s.labels)
+		if f.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labels, promFloat(s.value))
+			continue
+		}
+		names := append(append([]string(nil), f.labels...), "le")
+		values := append(append([]string(nil), s.labels...), "")
+		for i, bound := range f.buckets {
+			values[len(values)-1] = promFloat(bound)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, promLabels(names, values), s.counts[i])
+		}
+		values[len(values)-1] = "+Inf"
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, promLabels(names, values), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labels, promFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels, s.count)
+	}
+}
+
+func promLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(promEscaper.Replace(value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// recordCallMetrics counts a dispatched call and its latency. status is the
+// audit status: ok, error, timeout, or cancelled.
+func recordCallMetrics(key callKey, status string, elapsed time.Duration) {
+	promCalls.inc(key.Server, key.Method, key.Target, status)
+	promCallDuration.observe(elapsed.Seconds(), key.Server, key.Method, key.Target)
+	promDispatchOutcomes.inc(key.Server, status)
+}
+
+// collectSessionMetrics reports live facade sessions and open streams per
+// transport from list at each scrape.
+func collectSessionMetrics(list func() []facadeSession) {
+	count := func(value func(facadeSession) int) func(emit func(float64, ...string)) {
+		return func(emit func(float64, ...string)) {
+			byTransport := make(map[string]int)
+			for _, s := range list() {
+				byTransport[s.Transport] += value(s)
+			}
+			for transport, n := range byTransport {
+				emit(float64(n), transport)
+			}
+		}
+	}
+	promSessions.setCollector(count(func(facadeSession) int { return 1 }))
+	promStreams.setCollector(count(func(s facadeSession) int { return s.Streams }))
+}
+
+// metricsHandler serves the registry in the Prometheus text format.
+func metricsHandler(registry *promRegistry, tokens []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if len(tokens) > 0 && !metricsTokenAllowed(tokens, bearerToken(r)) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if r.Method == http.MethodHead {
+			return
+		}
+		buf := bufio.NewWriter(w)
+		registry.write(buf)
+		_ = buf.Flush()
+	}
+}
+
+func metricsTokenAllowed(tokens []string, got string) bool {
+	if got == "" {
+		return false
+	}
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(got)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusExposition(t *testing.T) {
+	registry := &promRegistry{}
+	calls := registry.counter("test_calls_total", "Calls.", "server", "target")
+	latency := registry.histogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "server")
+	sessions := registry.gauge("test_sessions", "Sessions.", "transport")
+	calls.inc("fs", "read")
+	calls.inc("fs", "read")
+	calls.inc("web", `say "hi"`)
+	latency.observe(0.05, "fs")
+	latency.observe(0.5, "fs")
+	latency.observe(3, "fs")
+	sessions.setCollector(func(emit func(float64, ...string)) {
+		emit(1, "streamable-http")
+		emit(2, "sse")
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metricsHandler(registry, []string{"scrape-secret"}))
+	scrape := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	if w := scrape(""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous scrape: %d", w.Code)
+	}
+	if w := scrape("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", w.Code)
+	}
+	w := scrape("scrape-secret")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("scrape: %d %v", w.Code, w.Header())
+	}
+	want := `# HELP test_calls_total Calls.
+# TYPE test_calls_total counter
+test_calls_total{server="fs",target="read"} 2
+test_calls_total{server="web",target="say \"hi\""} 1
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{server="fs",le="0.1"} 1
+test_latency_seconds_bucket{server="fs",le="1"} 2
+test_latency_seconds_bucket{server="fs",le="+Inf"} 3
+test_latency_seconds_sum{server="fs"} 3.55
+test_latency_seconds_count{server="fs"} 3
+# HELP test_sessions Sessions.
+# TYPE test_sessions gauge
+test_sessions{transport="sse"} 2
+test_sessions{transport="streamable-http"} 1
+`
+	if got := w.Body.String(); got != want {
+		t.Fatalf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRecordCallMetrics(t *testing.T) {
+	key := callKey{Server: "prom-test", Method: "tools/call", Target: "search"}
+	recordCallMetrics(key, "ok", 0)
+	recordCallMetrics(key, "timeout", 0)
+	promDispatchOutcomes.inc("prom-test", "circuit_open")
+	table := newFacadeSessionTable(0)
+	table.open("sse", "alice")
+	table.open("sse", "bob")
+	collectSessionMetrics(table.list)
+	defer collectSessionMetrics(func() []facadeSession { return nil })
+
+	w := httptest.NewRecorder()
+	metricsHandler(promMetrics, nil)(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, line := range []string{
+		`stelae_calls_total{server="prom-test",method="tools/call",target="search",status="ok"} 1`,
+		`stelae_calls_total{server="prom-test",method="tools/call",target="search",status="timeout"} 1`,
+		`stelae_call_duration_seconds_count{server="prom-test",method="tools/call",target="search"} 2`,
+		`stelae_dispatch_outcomes_total{server="prom-test",outcome="circuit_open"} 1`,
+		`stelae_facade_sessions{transport="sse"} 2`,
+		`stelae_facade_streams{transport="sse"} 0`,
+		"# TYPE stelae_downstream_reconnects_total counter",
+		"# TYPE stelae_adapter_adoptions_total counter",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("missing %q in\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import "fmt"
+
+// proxy holds the policies runProxy builds from mcpProxy that the catalog,
+// the facade, and admin handlers share: how shared tool names and replica
+// groups are routed, virtual servers, fan_out, completion caching, and
+// JSON encoding. They are passed from here rather than kept in package
+// variables, so tests can build their own.
+type proxy struct {
+	conflicts *toolConflictPolicy
+	replicas  *replicaBalancer
+	// virtual composes mcpProxy.virtualServers into the catalog; nil for
+	// none. virtualConf is kept to check applied server sets against.
+	virtual     *virtualServerSet
+	virtualConf map[string]*VirtualServerConfig
+	// fanOut is mcpProxy.fanOut; nil hides the fan_out tool.
+	fanOut      *FanOutConfig
+	completions *completionCache
+	json        JSONEncodingConfig
+}
+
+// newProxy validates conf against the configured servers. The replica
+// balancer's groupOf, affinityOf, and healthy hooks are left for runProxy,
+// which owns the registry and health checks they read.
+func newProxy(conf *MCPProxyConfigV2, servers map[string]*MCPClientConfigV2) (*proxy, error) {
+	if conf == nil {
+		conf = &MCPProxyConfigV2{}
+	}
+	p := &proxy{fanOut: conf.FanOut, completions: newCompletionCache(conf.Completion), virtualConf: conf.VirtualServers}
+	var err error
+	if p.conflicts, err = newToolConflictPolicy(conf.ConflictPolicy); err != nil {
+		return nil, err
+	}
+	if p.replicas, err = newReplicaBalancer(conf.LoadBalancing); err != nil {
+		return nil, err
+	}
+	p.conflicts.replicas = p.replicas
+	if err := p.checkServers(servers); err != nil {
+		return nil, err
+	}
+	if p.virtual, err = newVirtualServerSet(conf.VirtualServers, servers); err != nil {
+		return nil, err
+	}
+	if p.json, err = newJSONEncoding(conf.JSON); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// indexCatalog indexes servers, with the virtual servers composed in, for
+// routing under the conflict policy.
+func (p *proxy) indexCatalog(servers map[string]*Server, overrides *ToolOverrideSet) catalogIndex {
+	return newCatalogIndex(p.conflicts, p.virtual.compose(servers), overrides)
+}
+
+// checkServers validates a server set against the proxy: names, groups,
+// session affinity, and the virtual servers' names. Startup and
+// /admin/apply both run it.
+func (p *proxy) checkServers(servers map[string]*MCPClientConfigV2) error {
+	for name, clientConfig := range servers {
+		if err := validServerName(name); err != nil {
+			return err
+		}
+		if clientConfig == nil {
+			continue
+		}
+		if clientConfig.Group != "" && servers[clientConfig.Group] != nil {
+			return fmt.Errorf("server %s: group %q is also a server name", name, clientConfig.Group)
+		}
+		if clientConfig.Options == nil {
+			continue
+		}
+		if affinity := clientConfig.Options.SessionAffinity; affinity != nil {
+			if err := affinity.validate(); err != nil {
+				return fmt.Errorf("server %s: %w", name, err)
+			}
+		}
+	}
+	_, err := newVirtualServerSet(p.virtualConf, servers)
+	return err
+}
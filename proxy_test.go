@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// testProxy is a proxy with the default policies.
+func testProxy() *proxy {
+	p, err := newProxy(nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestNewProxyChecksServers(t *testing.T) {
+	virtual := map[string]*VirtualServerConfig{"kb": {Tools: []*VirtualToolConfig{{Server: "docs", Tool: "search"}}}}
+	p, err := newProxy(&MCPProxyConfigV2{VirtualServers: virtual}, map[string]*MCPClientConfigV2{"docs": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.conflicts.replicas != p.replicas {
+		t.Fatal("conflict policy does not share the replica balancer")
+	}
+	// an applied server set is checked against the same virtual servers
+	if err := p.checkServers(map[string]*MCPClientConfigV2{"docs": {}, "kb": {}}); err == nil || !strings.Contains(err.Error(), "kb is also a server") {
+		t.Fatalf("expected a virtual server name clash, got %v", err)
+	}
+	if err := p.checkServers(map[string]*MCPClientConfigV2{"a": {Group: "b"}, "b": {}}); err == nil || !strings.Contains(err.Error(), "also a server name") {
+		t.Fatalf("expected a group name clash, got %v", err)
+	}
+	if _, err := newProxy(&MCPProxyConfigV2{JSON: &JSONEncodingConfig{Indent: "x"}}, nil); err == nil {
+		t.Fatal("accepted an invalid json.indent")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const rpcCodeReadOnlyMode = -32008
+
+// readOnlyState is the current read-only switch with who flipped it last.
+type readOnlyState struct {
+	Enabled bool       `json:"enabled"`
+	By      string     `json:"by,omitempty"`
+	At      *time.Time `json:"at,omitempty"`
+	Reason  string     `json:"reason,omitempty"`
+}
+
+// readOnlyMode is the proxy-wide safe mode: while enabled only tools
+// annotated readOnlyHint can be called, on the facade and on the per-server
+// routes alike. Listing, prompts, and resources stay available.
+type readOnlyMode struct {
+	mu    sync.RWMutex
+	state readOnlyState
+}
+
+func newReadOnlyMode(enabled bool) *readOnlyMode {
+	return &readOnlyMode{state: readOnlyState{Enabled: enabled, By: "config"}}
+}
+
+func (m *readOnlyMode) active() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.Enabled
+}
+
+func (m *readOnlyMode) snapshot() readOnlyState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+func (m *readOnlyMode) set(enabled bool, by, reason string) readOnlyState {
+	now := time.Now().UTC()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = readOnlyState{Enabled: enabled, By: by, At: &now, Reason: reason}
+	return m.state
+}
+
+// readOnlyAllows reports whether a tool may run in read-only mode. Tools
+// without a readOnlyHint (after overrides) are treated as writes.
+func readOnlyAllows(srv *Server, overrides *ToolOverrideSet, toolName string) bool {
+	hints, ok := resolveToolHints(srv, overrides, toolName)
+	return ok && hints.ReadOnly
+}
+
+// middleware guards a per-server route so clients talking to a server
+// directly cannot bypass read-only mode.
+func (m *readOnlyMode) middleware(enc JSONEncodingConfig, srv *Server, store *toolOverrideStore) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || !m.active() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			var req jsonrpcRequest
+			if json.Unmarshal(body, &req) != nil || req.Method != "tools/call" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var p struct {
+				Name string `json:"name"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			if readOnlyAllows(srv, store.current(), p.Name) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			clientLogger(srv.name).Warn("read-only mode blocked tools/call", "tool", p.Name)
+			w.Header().Set("Content-Type", "application/json")
+			_ = enc.encode(w, localizedErrors(r.Context()).response(req.ID, errNameReadOnlyMode, map[string]string{"name": p.Name}))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	trueVal := true
+	srv := &Server{
+		name: "db",
+		tools: []mcp.Tool{
+			{Name: "query", Annotations: mcp.ToolAnnotation{ReadOnlyHint: &trueVal}},
+			{Name: "drop"},
+		},
+	}
+	mode := newReadOnlyMode(true)
+	reached := 0
+	handler := chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+		w.WriteHeader(http.StatusOK)
+	}), mode.middleware(JSONEncodingConfig{}, srv, newToolOverrideStore(nil)))
+
+	call := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/db/mcp", strings.NewReader(body)))
+		return rec
+	}
+
+	call(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"query"}}`)
+	call(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if reached != 2 {
+		t.Fatalf("expected read-only call and listing to pass, reached=%d", reached)
+	}
+
+	rec := call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"drop"}}`)
+	if reached != 2 {
+		t.Fatalf("expected write tool to be blocked")
+	}
+	var resp jsonrpcResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error == nil {
+		t.Fatalf("expected JSON-RPC error, got %s", rec.Body.String())
+	}
+	if resp.Error.Code != rpcCodeReadOnlyMode || !strings.Contains(resp.Error.Message, "drop") {
+		t.Fatalf("unexpected error %+v", resp.Error)
+	}
+
+	mode.set(false, "admin:test", "incident over")
+	call(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"drop"}}`)
+	if reached != 3 {
+		t.Fatalf("expected calls to pass once read-only mode is off")
+	}
+	if state := mode.snapshot(); state.Enabled || state.By != "admin:test" || state.At == nil {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
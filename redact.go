@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const redactedValue = "[REDACTED]"
+
+// defaultRedactHeaders are request headers whose values are never logged.
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// RedactionConfig adds header names and object keys (also query
+// parameters) to mask wherever the proxy logs or records requests. Fields
+// extend the built-in keys of every redacting component.
+type RedactionConfig struct {
+	Headers []string `json:"headers,omitempty"`
+	Fields  []string `json:"fields,omitempty"`
+}
+
+// redaction is the process-wide redaction setup, installed once at startup
+// before any component builds its key set.
+var redaction = struct {
+	sync.RWMutex
+	headers map[string]struct{}
+	fields  []string
+}{headers: headerSet(nil)}
+
+func headerSet(extra []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(defaultRedactHeaders)+len(extra))
+	for _, h := range append(append([]string(nil), defaultRedactHeaders...), extra...) {
+		set[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	return set
+}
+
+// configureRedaction installs conf; nil restores the defaults.
+func configureRedaction(conf *RedactionConfig) {
+	redaction.Lock()
+	defer redaction.Unlock()
+	redaction.headers, redaction.fields = headerSet(nil), nil
+	if conf != nil {
+		redaction.headers = headerSet(conf.Headers)
+		redaction.fields = append([]string(nil), conf.Fields...)
+	}
+}
+
+// redactHeaders returns h as "Name: value" pairs with sensitive values
+// masked, for logging.
+func redactHeaders(h http.Header) map[string]string {
+	redaction.RLock()
+	defer redaction.RUnlock()
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if _, ok := redaction.headers[http.CanonicalHeaderKey(name)]; ok {
+			value = redactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// redactQuery masks the values of sensitive parameters in a raw query.
+func redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	// a malformed query still yields the pairs that parsed
+	values, _ := url.ParseQuery(raw)
+	keys := newRedactKeySet(nil)
+	for name, vs := range values {
+		if _, ok := keys[normalizeRedactKey(name)]; ok {
+			for i := range vs {
+				vs[i] = redactedValue
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// redactURL masks the password and sensitive query parameters of a URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	u.RawQuery = redactQuery(u.RawQuery)
+	return u.Redacted()
+}
+
+// withoutURL strips the unredacted URL a *url.Error repeats.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// redactJSON masks sensitive members of a JSON payload; other payloads
+// are returned whole.
+func redactJSON(raw []byte) string {
+	var decoded any
+	if json.Unmarshal(raw, &decoded) != nil {
+		return string(raw)
+	}
+	out, _ := json.Marshal(redactValue(decoded, newRedactKeySet(nil)))
+	return string(out)
+}
+
+// redactedJSON encodes v with sensitive members masked.
+func redactedJSON(v any, keys map[string]struct{}) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactValue(decoded, keys))
+}
+
+// redactRawJSON masks sensitive members of a JSON payload. A payload with
+// none, or that is not JSON, is returned as it is, byte for byte.
+func redactRawJSON(raw []byte, keys map[string]struct{}) []byte {
+	var decoded any
+	if json.Unmarshal(raw, &decoded) != nil || !hasRedactKey(decoded, keys) {
+		return raw
+	}
+	out, err := json.Marshal(redactValue(decoded, keys))
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// hasRedactKey reports whether a decoded JSON value has a member
+// redactValue would mask.
+func hasRedactKey(v any, keys map[string]struct{}) bool {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, inner := range val {
+			if _, ok := keys[normalizeRedactKey(k)]; ok || hasRedactKey(inner, keys) {
+				return true
+			}
+		}
+	case []any:
+		for _, inner := range val {
+			if hasRedactKey(inner, keys) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// defaultRedactKeys are object keys whose values never leave the proxy in
+// diagnostics. Matching ignores case, '-' and '_'.
+var defaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "accesstoken", "refreshtoken",
+	"apikey", "authorization", "cookie", "setcookie", "privatekey", "clientsecret",
+}
+
+func normalizeRedactKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "-", "")
+	return strings.ReplaceAll(key, "_", "")
+}
+
+func newRedactKeySet(extra []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(defaultRedactKeys)+len(extra))
+	for _, key := range defaultRedactKeys {
+		set[key] = struct{}{}
+	}
+	redaction.RLock()
+	for _, key := range redaction.fields {
+		set[normalizeRedactKey(key)] = struct{}{}
+	}
+	redaction.RUnlock()
+	for _, key := range extra {
+		set[normalizeRedactKey(key)] = struct{}{}
+	}
+	return set
+}
+
+// redactValue returns a copy of a decoded JSON value with sensitive object
+// members replaced by [REDACTED].
+func redactValue(v any, keys map[string]struct{}) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, inner := range val {
+			if _, ok := keys[normalizeRedactKey(k)]; ok {
+				out[k] = redactedValue
+				continue
+			}
+			out[k] = redactValue(inner, keys)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, inner := range val {
+			out[i] = redactValue(inner, keys)
+		}
+		return out
+	default:
+		return v
+	}
+}
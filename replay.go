@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonDiff is one differing path between a recorded and a replayed payload.
+type jsonDiff struct {
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// comparableResponse strips the parts of a JSON-RPC response that always
+// differ between runs (id, _meta) so diffs show only substantive changes.
+func comparableResponse(raw []byte) any {
+	var envelope map[string]any
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return string(raw)
+	}
+	if result, ok := envelope["result"].(map[string]any); ok {
+		delete(result, "_meta")
+		return map[string]any{"result": result}
+	}
+	if rpcErr, ok := envelope["error"]; ok {
+		return map[string]any{"error": rpcErr}
+	}
+	return envelope
+}
+
+// diffJSON walks two decoded JSON values and reports differing paths.
+func diffJSON(path string, before, after any) []jsonDiff {
+	if path == "" {
+		path = "$"
+	}
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]struct{}, len(a)+len(b))
+		for k := range b {
+			keys[k] = struct{}{}
+		}
+		for k := range a {
+			keys[k] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		var out []jsonDiff
+		for _, k := range sorted {
+			out = append(out, diffJSON(path+"."+k, b[k], a[k])...)
+		}
+		return out
+	case []any:
+		a, ok := after.([]any)
+		if !ok {
+			break
+		}
+		n := max(len(a), len(b))
+		var out []jsonDiff
+		for i := 0; i < n; i++ {
+			var bi, ai any
+			if i < len(b) {
+				bi = b[i]
+			}
+			if i < len(a) {
+				ai = a[i]
+			}
+			out = append(out, diffJSON(path+"["+strconv.Itoa(i)+"]", bi, ai)...)
+		}
+		return out
+	}
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	return []jsonDiff{{Path: path, Before: before, After: after}}
+}
+
+type replayReport struct {
+	EntryID    string     `json:"entryId"`
+	Method     string     `json:"method"`
+	Server     string     `json:"server"`
+	Target     string     `json:"target"`
+	DurationMs int64      `json:"durationMs"`
+	Identical  bool       `json:"identical"`
+	Diff       []jsonDiff `json:"diff,omitempty"`
+	Recorded   any        `json:"recorded"`
+	Replayed   any        `json:"replayed"`
+}
+
+func buildReplayReport(entry *auditEntry, replayed []byte, durationMs int64) replayReport {
+	before := comparableResponse(entry.Response)
+	after := comparableResponse(replayed)
+	diff := diffJSON("", before, after)
+	return replayReport{
+		EntryID:    entry.ID,
+		Method:     entry.Method,
+		Server:     entry.Server,
+		Target:     entry.Target,
+		DurationMs: durationMs,
+		Identical:  len(diff) == 0,
+		Diff:       diff,
+		Recorded:   before,
+		Replayed:   after,
+	}
+}
+
+// replayRequestBody rebuilds the JSON-RPC request for an audit entry.
+func replayRequestBody(entry *auditEntry) ([]byte, error) {
+	if entry.Method == "" {
+		return nil, fmt.Errorf("audit entry %s has no method", entry.ID)
+	}
+	if strings.HasPrefix(entry.Method, "stelae/") {
+		return nil, fmt.Errorf("audit entry %s records a proxy action and cannot be replayed", entry.ID)
+	}
+	if entry.Redacted {
+		return nil, fmt.Errorf("audit entry %s was recorded without its full arguments and cannot be replayed", entry.ID)
+	}
+	return json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      "replay-" + entry.ID,
+		"method":  entry.Method,
+		"params":  entry.Params,
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestBuildReplayReportIgnoresIDAndMeta(t *testing.T) {
+	entry := &auditEntry{
+		ID:       "e1",
+		Method:   "tools/call",
+		Response: json.RawMessage(`{"jsonrpc":"2.0","id":7,"result":{"content":[{"type":"text","text":"42"}],"_meta":{"took":3}}}`),
+	}
+	report := buildReplayReport(entry, []byte(`{"jsonrpc":"2.0","id":"replay-e1","result":{"content":[{"type":"text","text":"42"}],"_meta":{"took":9}}}`), 5)
+	if !report.Identical || len(report.Diff) != 0 {
+		t.Fatalf("expected identical replay, got %+v", report.Diff)
+	}
+}
+
+func TestBuildReplayReportListsChangedPaths(t *testing.T) {
+	entry := &auditEntry{
+		ID:       "e2",
+		Response: json.RawMessage(`{"result":{"content":[{"type":"text","text":"old"}],"isError":false}}`),
+	}
+	report := buildReplayReport(entry, []byte(`{"result":{"content":[{"type":"text","text":"new"},{"type":"text","text":"extra"}],"isError":false}}`), 5)
+	if report.Identical {
+		t.Fatal("expected differences")
+	}
+	paths := map[string]bool{}
+	for _, d := range report.Diff {
+		paths[d.Path] = true
+	}
+	if !paths["$.result.content[0].text"] || !paths["$.result.content[1]"] || len(paths) != 2 {
+		t.Fatalf("unexpected diff paths: %+v", report.Diff)
+	}
+}
+
+func TestReplayRequestBody(t *testing.T) {
+	body, err := replayRequestBody(&auditEntry{ID: "e3", Method: "tools/call", Params: json.RawMessage(`{"name":"x"}`)})
+	if err != nil {
+		t.Fatalf("replayRequestBody: %v", err)
+	}
+	var req jsonrpcRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.Method != "tools/call" || req.ID != "replay-e3" || string(req.Params) != `{"name":"x"}` {
+		t.Fatalf("unexpected request %s (%v)", body, err)
+	}
+	if _, err := replayRequestBody(&auditEntry{ID: "e4"}); err == nil {
+		t.Fatal("expected error for entry without method")
+	}
+}
+
+func TestAuditReplayRouteHoldsWritesUnlessForced(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	audit, err := newAuditLog(&AuditConfig{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnly := true
+	srv := &Server{name: "srv", tools: []mcp.Tool{
+		{Name: "read", Annotations: mcp.ToolAnnotation{ReadOnlyHint: &readOnly}},
+		{Name: "write"},
+	}}
+	ids := map[string]string{}
+	for _, tool := range []string{"read", "write"} {
+		audit.record(auditEntry{At: time.Now(), Method: "tools/call", Server: "srv", Target: tool, Params: json.RawMessage(`{"name":"` + tool + `"}`), Response: []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)})
+		recent, _ := audit.recent(1)
+		ids[tool] = recent[0].ID
+	}
+	dispatched := 0
+	mux := http.NewServeMux()
+	registerAuditRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), audit, func(ctx context.Context, entry *auditEntry) ([]byte, error) {
+		if iso, ok := replayIsolationFromContext(ctx); ok && iso.holds(srv, nil, replayTarget(entry.Params)) {
+			return nil, errReplayHeld
+		}
+		dispatched++
+		return entry.Response, nil
+	})
+	replay := func(tool, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/admin/audit/"+ids[tool]+"/replay", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := replay("read", ""); code != http.StatusOK || dispatched != 1 {
+		t.Fatalf("read-only replay = %d, dispatched %d", code, dispatched)
+	}
+	if code := replay("write", ""); code != http.StatusConflict || dispatched != 1 {
+		t.Fatalf("unforced write replay = %d, dispatched %d", code, dispatched)
+	}
+	if code := replay("write", `{"force":true}`); code != http.StatusOK || dispatched != 2 {
+		t.Fatalf("forced write replay = %d, dispatched %d", code, dispatched)
+	}
+}
@@ -9,6 +9,9 @@ import (
 type aggregatedTool struct {
 	descriptor map[string]any
 	servers    map[string]struct{}
+	// original is the server's name for a conflict-prefixed tool.
+	original string
+	drift    []toolDrift
 }
 
 func newAggregatedTool(descriptor map[string]any) *aggregatedTool {
@@ -22,7 +25,8 @@ func (a *aggregatedTool) addServer(name string) {
 	a.servers[name] = struct{}{}
 }
 
-func (a *aggregatedTool) serverList() []string {
+// serverList returns the servers exposing the tool in policy order.
+func (a *aggregatedTool) serverList(policy *toolConflictPolicy) []string {
 	if len(a.servers) == 0 {
 		return nil
 	}
@@ -30,45 +34,56 @@ func (a *aggregatedTool) serverList() []string {
 	for name := range a.servers {
 		list = append(list, name)
 	}
-	sort.Strings(list)
-	return list
+	return policy.order(list)
 }
 
-func collectTools(servers map[string]*Server, overrides *ToolOverrideSet, intended *catalogFile) []map[string]any {
+func (p *proxy) collectTools(servers map[string]*Server, overrides *ToolOverrideSet, intended *catalogFile) []map[string]any {
 	seen := make(map[string]*aggregatedTool)
-	for serverName, srv := range servers {
-		if !serverEnabled(overrides, serverName) {
+	include := func(serverName string, tool mcp.Tool) bool {
+		return serverEnabled(overrides, serverName) && toolEnabled(overrides, serverName, tool.Name)
+	}
+	for _, et := range p.conflicts.exposedTools(servers, include) {
+		serverName, tool := et.server, et.tool
+		descriptor := toolDescriptorFromServer(tool)
+		if intended != nil {
+			if intendedTool := intended.ToolsByName[tool.Name]; intendedTool != nil {
+				descriptor = mergeToolDescriptors(copyStringAnyMap(intendedTool), descriptor)
+			}
+		}
+		if tool.Name == facadeSearchToolName {
+			descriptor = ensureSearchDescriptor(descriptor)
+		} else if tool.Name == facadeFetchToolName {
+			descriptor = ensureFetchDescriptor(descriptor)
+		}
+		if descriptor == nil {
 			continue
 		}
-		for _, tool := range srv.tools {
-			if !toolEnabled(overrides, serverName, tool.Name) {
-				continue
-			}
-			descriptor := toolDescriptorFromServer(tool)
-			if intended != nil {
-				if intendedTool := intended.ToolsByName[tool.Name]; intendedTool != nil {
-					descriptor = mergeToolDescriptors(copyStringAnyMap(intendedTool), descriptor)
-				}
+		var drift *toolDrift
+		if srv := servers[serverName]; srv != nil {
+			own := tool
+			if et.original != "" {
+				own.Name = et.original
 			}
-			if tool.Name == facadeSearchToolName {
-				descriptor = ensureSearchDescriptor(descriptor)
-			} else if tool.Name == facadeFetchToolName {
-				descriptor = ensureFetchDescriptor(descriptor)
-			}
-			if descriptor == nil {
-				continue
-			}
-			entry, exists := seen[tool.Name]
-			if exists {
-				entry.descriptor = mergeToolDescriptors(entry.descriptor, descriptor)
-				entry.addServer(serverName)
-			} else {
-				copyDescriptor := descriptor
-				entry = newAggregatedTool(copyDescriptor)
-				entry.addServer(serverName)
-				seen[tool.Name] = entry
+			own.Name = srv.downstreamToolName(own.Name)
+			var pin *ToolPinConfig
+			if pin, drift = schemaDrift(overrides, serverName, own); drift != nil && pin.Serve {
+				descriptor = servePinnedSchemas(descriptor, pin)
 			}
 		}
+		entry, exists := seen[tool.Name]
+		if exists {
+			entry.descriptor = mergeToolDescriptors(entry.descriptor, descriptor)
+			entry.addServer(serverName)
+		} else {
+			copyDescriptor := descriptor
+			entry = newAggregatedTool(copyDescriptor)
+			entry.original = et.original
+			entry.addServer(serverName)
+			seen[tool.Name] = entry
+		}
+		if drift != nil {
+			entry.drift = append(entry.drift, *drift)
+		}
 	}
 
 	if _, ok := seen[facadeSearchToolName]; !ok && toolEnabled(overrides, "facade", facadeSearchToolName) {
@@ -81,6 +96,11 @@ func collectTools(servers map[string]*Server, overrides *ToolOverrideSet, intend
 		entry.addServer("facade")
 		seen[facadeFetchToolName] = entry
 	}
+	if _, ok := seen[facadeFanOutToolName]; !ok && p.fanOut != nil && toolEnabled(overrides, "facade", facadeFanOutToolName) {
+		entry := newAggregatedTool(fanOutToolDescriptor())
+		entry.addServer("facade")
+		seen[facadeFanOutToolName] = entry
+	}
 
 	names := make([]string, 0, len(seen))
 	for name := range seen {
@@ -91,10 +111,22 @@ func collectTools(servers map[string]*Server, overrides *ToolOverrideSet, intend
 	result := make([]map[string]any, 0, len(names))
 	for _, name := range names {
 		entry := seen[name]
-		descriptor := applyToolOverride(name, entry.descriptor, overrides)
-		descriptor = attachStelaeMetadata(descriptor, entry.serverList())
+		var descriptor map[string]any
+		if entry.original != "" {
+			// conflict-prefixed copies take the tool's overrides but keep
+			// their prefixed name
+			descriptor = applyToolOverride(entry.original, entry.descriptor, overrides)
+			descriptor["name"] = name
+		} else {
+			descriptor = applyToolOverride(name, entry.descriptor, overrides)
+		}
+		descriptor = attachStelaeMetadata(descriptor, entry.serverList(p.conflicts))
+		descriptor = attachProvenance(descriptor, serverProvenance(servers, entry.serverList(p.conflicts)))
+		descriptor = attachDrift(descriptor, entry.drift)
 		result = append(result, descriptor)
 	}
+	// aliases can move a tool away from its server name's position
+	sortCatalogItems(result, "name")
 	return result
 }
 
@@ -102,7 +134,9 @@ func attachStelaeMetadata(descriptor map[string]any, servers []string) map[strin
 	if descriptor == nil || len(servers) == 0 {
 		return descriptor
 	}
+	name, _ := descriptor["name"].(string)
 	meta := map[string]any{
+		"id":            stableToolID(servers[0], name, descriptor),
 		"servers":       servers,
 		"primaryServer": servers[0],
 	}
@@ -116,6 +150,55 @@ func attachStelaeMetadata(descriptor map[string]any, servers []string) map[strin
 	return descriptor
 }
 
+// stableToolID identifies an advertised tool across restarts by its primary
+// server, exposed name, and advertised schemas, as
+// <server>:<name>:<hash prefix>. A schema change gives the tool a new ID.
+func stableToolID(server, name string, descriptor map[string]any) string {
+	schemas, err := sortedJSONValue(map[string]any{
+		"inputSchema":  descriptor["inputSchema"],
+		"outputSchema": descriptor["outputSchema"],
+	})
+	hash := ""
+	if err == nil {
+		if m, ok := schemas.(map[string]any); ok {
+			hash = hashSchema(m)
+		}
+	}
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	return server + ":" + name + ":" + hash
+}
+
+// serverProvenance returns the configured provenance of the named servers.
+// Servers without provenance are left out; nil means none has any.
+func serverProvenance(servers map[string]*Server, names []string) map[string]*ProvenanceConfig {
+	var out map[string]*ProvenanceConfig
+	for _, name := range names {
+		srv := servers[name]
+		if srv == nil || srv.provenance == nil {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]*ProvenanceConfig)
+		}
+		out[name] = srv.provenance
+	}
+	return out
+}
+
+// attachProvenance adds x-stelae.provenance, keyed by server, to a
+// descriptor that already carries x-stelae metadata.
+func attachProvenance(descriptor map[string]any, provenance map[string]*ProvenanceConfig) map[string]any {
+	if len(provenance) == 0 {
+		return descriptor
+	}
+	if meta, ok := descriptor["x-stelae"].(map[string]any); ok && meta != nil {
+		meta["provenance"] = provenance
+	}
+	return descriptor
+}
+
 func toolDescriptorFromServer(tool mcp.Tool) map[string]any {
 	descriptor := map[string]any{
 		"name": tool.Name,
@@ -191,6 +274,9 @@ func applyToolOverride(name string, descriptor map[string]any, set *ToolOverride
 	if descriptor == nil || set == nil {
 		return descriptor
 	}
+	if doc := set.Docs[name]; doc != nil {
+		descriptor = applyToolDoc(descriptor, doc)
+	}
 	if master := set.ToolOverrides["*"]; master != nil {
 		descriptor = applySingleOverride(descriptor, master, false)
 	}
@@ -219,6 +305,15 @@ func applySingleOverride(descriptor map[string]any, override *ToolOverrideConfig
 	if override.OutputSchema != nil {
 		descriptor["outputSchema"] = copySchemaMap(override.OutputSchema)
 	}
+	if len(override.Examples) > 0 {
+		meta, _ := descriptor["x-stelae"].(map[string]any)
+		meta = copyStringAnyMap(meta)
+		if meta == nil {
+			meta = make(map[string]any)
+		}
+		meta["examples"] = copyToolExamples(override.Examples)
+		descriptor["x-stelae"] = meta
+	}
 	return descriptor
 }
 
@@ -294,10 +389,31 @@ func toBool(v any) (bool, bool) {
 	}
 }
 
+// sortedServerNames returns the server names in order, so catalogs built
+// from them do not depend on map iteration.
+func sortedServerNames(servers map[string]*Server) []string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortCatalogItems orders catalog entries by a string key. The sort is
+// stable, so entries sharing a key keep their server order.
+func sortCatalogItems(items []map[string]any, key string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, _ := items[i][key].(string)
+		b, _ := items[j][key].(string)
+		return a < b
+	})
+}
+
 func collectPrompts(servers map[string]*Server) []map[string]any {
 	prompts := make([]map[string]any, 0)
-	for _, srv := range servers {
-		for _, prompt := range srv.prompts {
+	for _, name := range sortedServerNames(servers) {
+		for _, prompt := range servers[name].prompts {
 			item := map[string]any{"name": prompt.Name}
 			if prompt.Description != "" {
 				item["description"] = prompt.Description
@@ -308,13 +424,14 @@ func collectPrompts(servers map[string]*Server) []map[string]any {
 			prompts = append(prompts, item)
 		}
 	}
+	sortCatalogItems(prompts, "name")
 	return prompts
 }
 
 func collectResources(servers map[string]*Server) []map[string]any {
 	resources := make([]map[string]any, 0)
-	for _, srv := range servers {
-		for _, resource := range srv.resources {
+	for _, name := range sortedServerNames(servers) {
+		for _, resource := range servers[name].resources {
 			item := map[string]any{
 				"uri":  resource.URI,
 				"name": resource.Name,
@@ -328,13 +445,14 @@ func collectResources(servers map[string]*Server) []map[string]any {
 			resources = append(resources, item)
 		}
 	}
+	sortCatalogItems(resources, "uri")
 	return resources
 }
 
 func collectResourceTemplates(servers map[string]*Server) []map[string]any {
 	templates := make([]map[string]any, 0)
-	for _, srv := range servers {
-		for _, tpl := range srv.resourceTemplates {
+	for _, name := range sortedServerNames(servers) {
+		for _, tpl := range servers[name].resourceTemplates {
 			item := map[string]any{
 				"name": tpl.Name,
 			}
@@ -350,11 +468,26 @@ func collectResourceTemplates(servers map[string]*Server) []map[string]any {
 			templates = append(templates, item)
 		}
 	}
+	sortCatalogItems(templates, "name")
 	return templates
 }
 
-func buildInitializeResult(config *Config, servers map[string]*Server, overrides *ToolOverrideSet, intended *catalogFile) map[string]any {
-	tools := collectTools(servers, overrides, intended)
+// resourcesSubscribable reports whether a server takes resources/subscribe,
+// which the facade then relays.
+func resourcesSubscribable(servers map[string]*Server) bool {
+	for _, srv := range servers {
+		if srv == nil || srv.client == nil {
+			continue
+		}
+		if result, _ := srv.client.initialized(); result != nil && result.Capabilities.Resources != nil && result.Capabilities.Resources.Subscribe {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *proxy) buildInitializeResult(config *Config, servers map[string]*Server, overrides *ToolOverrideSet, intended *catalogFile) map[string]any {
+	tools := p.collectTools(servers, overrides, intended)
 	prompts := collectPrompts(servers)
 	resources := collectResources(servers)
 	resourceTemplates := collectResourceTemplates(servers)
@@ -367,16 +500,22 @@ func buildInitializeResult(config *Config, servers map[string]*Server, overrides
 		capabilities["prompts"] = map[string]any{"listChanged": false}
 	}
 	if len(resources) > 0 || len(resourceTemplates) > 0 {
-		capabilities["resources"] = map[string]any{"subscribe": false, "listChanged": false}
+		capabilities["resources"] = map[string]any{"subscribe": resourcesSubscribable(servers), "listChanged": false}
+	}
+	if len(prompts) > 0 || len(resourceTemplates) > 0 {
+		// completion/complete is routed to the prompt's or template's server
+		capabilities["completions"] = map[string]any{}
 	}
 
 	serverInfo := map[string]any{
 		"name":    "",
 		"version": "",
 	}
+	var instructions *InstructionsConfig
 	if config != nil && config.McpProxy != nil {
 		serverInfo["name"] = config.McpProxy.Name
 		serverInfo["version"] = config.McpProxy.Version
+		instructions = config.McpProxy.Instructions
 	}
 
 	result := map[string]any{
@@ -385,6 +524,9 @@ func buildInitializeResult(config *Config, servers map[string]*Server, overrides
 		"capabilities":    capabilities,
 		"tools":           tools,
 	}
+	if text := composeInstructions(instructions, servers); text != "" {
+		result["instructions"] = text
+	}
 	if len(prompts) > 0 {
 		result["prompts"] = prompts
 	}
@@ -405,7 +547,7 @@ const (
 func searchToolDescriptor() map[string]any {
 	return map[string]any{
 		"name":        facadeSearchToolName,
-		"description": "Lightweight search placeholder exposed for ChatGPT connector verification.",
+		"description": messages.defaultText(msgSearchDescription),
 		"inputSchema": map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -413,6 +555,22 @@ func searchToolDescriptor() map[string]any {
 					"title": "Query",
 					"type":  "string",
 				},
+				"server": map[string]any{
+					"title":       "Server",
+					"type":        "string",
+					"description": messages.defaultText(msgSearchServer),
+				},
+				"type": map[string]any{
+					"title":       "Type",
+					"type":        "string",
+					"enum":        []string{searchTypeTool, searchTypePrompt, searchTypeResource, searchTypeDocument},
+					"description": messages.defaultText(msgSearchType),
+				},
+				"mimeType": map[string]any{
+					"title":       "MIME type",
+					"type":        "string",
+					"description": messages.defaultText(msgSearchMimeType),
+				},
 			},
 			"required": []string{"query"},
 		},
@@ -422,13 +580,31 @@ func searchToolDescriptor() map[string]any {
 func fetchToolDescriptor() map[string]any {
 	return map[string]any{
 		"name":        facadeFetchToolName,
-		"description": "Connector-compliant fetch placeholder used when no upstream descriptor is available.",
+		"description": messages.defaultText(msgFetchDescription),
 		"inputSchema": map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"id": map[string]any{
-					"title": "Id",
-					"type":  "string",
+					"title":       "Id",
+					"type":        "string",
+					"description": messages.defaultText(msgFetchID),
+				},
+				"offset": map[string]any{
+					"title":       "Offset",
+					"type":        "integer",
+					"minimum":     0,
+					"description": messages.defaultText(msgFetchOffset),
+				},
+				"length": map[string]any{
+					"title":       "Length",
+					"type":        "integer",
+					"minimum":     1,
+					"description": messages.defaultText(msgFetchLength),
+				},
+				"cursor": map[string]any{
+					"title":       "Cursor",
+					"type":        "string",
+					"description": messages.defaultText(msgFetchCursor),
 				},
 			},
 			"required": []string{"id"},
@@ -446,8 +622,10 @@ func ensureSearchDescriptor(descriptor map[string]any) map[string]any {
 	props := ensurePropertiesMap(schema)
 	fallbackProps, _ := searchToolDescriptor()["inputSchema"].(map[string]any)["properties"].(map[string]any)
 	if fallbackProps != nil {
-		if _, ok := props["query"]; !ok {
-			props["query"] = fallbackProps["query"]
+		for _, key := range []string{"query", "server", "type", "mimeType"} {
+			if _, ok := props[key]; !ok {
+				props[key] = fallbackProps[key]
+			}
 		}
 	}
 	ensureRequiredField(schema, "query")
@@ -465,6 +643,11 @@ func ensureFetchDescriptor(descriptor map[string]any) map[string]any {
 	fallbackSchema, _ := fetchToolDescriptor()["inputSchema"].(map[string]any)
 	if fallbackProps, ok := fallbackSchema["properties"].(map[string]any); ok {
 		props["id"] = fallbackProps["id"]
+		for _, key := range []string{"offset", "length", "cursor"} {
+			if _, exists := props[key]; !exists {
+				props[key] = fallbackProps[key]
+			}
+		}
 	}
 	removeRequiredField(schema, "url")
 	ensureRequiredField(schema, "id")
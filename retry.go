@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 200 * time.Millisecond
+	defaultRetryMaxBackoff     = 2 * time.Second
+)
+
+// RetryConfig retries tools/call dispatches that failed at the transport
+// level (see dispatchOutcome) for tools annotated idempotentHint: true, or
+// whose override sets retry. MaxAttempts counts the first call.
+type RetryConfig struct {
+	MaxAttempts    int           `json:"maxAttempts,omitempty"`
+	InitialBackoff time.Duration `json:"initialBackoff,omitempty"`
+	MaxBackoff     time.Duration `json:"maxBackoff,omitempty"`
+}
+
+// callPolicy is what the facade resolved for one tool before dispatching
+// it: its configured timeout, whether a failed attempt may be repeated,
+// and whether a pass-through result may be streamed to the client.
+type callPolicy struct {
+	timeout time.Duration
+	retry   bool
+	stream  bool
+}
+
+// retryPolicy paces repeated attempts. A nil policy makes one attempt.
+type retryPolicy struct {
+	attempts int
+	initial  time.Duration
+	max      time.Duration
+}
+
+func newRetryPolicy(conf *RetryConfig) *retryPolicy {
+	if conf == nil {
+		return nil
+	}
+	p := &retryPolicy{attempts: conf.MaxAttempts, initial: conf.InitialBackoff, max: conf.MaxBackoff}
+	if p.attempts <= 0 {
+		p.attempts = defaultRetryMaxAttempts
+	}
+	if p.initial <= 0 {
+		p.initial = defaultRetryInitialBackoff
+	}
+	if p.max <= 0 {
+		p.max = defaultRetryMaxBackoff
+	}
+	return p
+}
+
+// toolRetryable reports whether failed calls to a tool may be retried: the
+// most specific override's retry setting, else its idempotentHint.
+func toolRetryable(overrides *ToolOverrideSet, serverName, toolName string, hints toolHints) bool {
+	if retry, ok := toolRetry(overrides, serverName, toolName); ok {
+		return retry
+	}
+	return hints.Idempotent
+}
+
+// run calls dispatch until it succeeds, fails in a way that is not the
+// server's fault, ctx ends, or the attempts run out, and returns the last
+// response with the number of attempts made. Superseded responses are
+// released.
+func (p *retryPolicy) run(ctx context.Context, target string, dispatch func() (*responseRecorder, int)) (*responseRecorder, int, int) {
+	rr, status := dispatch()
+	if p == nil {
+		return rr, status, 1
+	}
+	backoff := p.initial
+	attempt := 1
+	for ; attempt < p.attempts && dispatchOutcome(status, rr) == callFailed && ctx.Err() == nil; attempt++ {
+		wait := jitterBackoff(backoff)
+		logger("facade").Warn("tools/call retrying", "target", target, "attempt", attempt+1, "status", status, "in", wait.Round(time.Millisecond))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return rr, status, attempt
+		case <-timer.C:
+		}
+		rr.Body.Close()
+		rr, status = dispatch()
+		backoff = min(backoff*2, p.max)
+	}
+	return rr, status, attempt
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyRun(t *testing.T) {
+	p := newRetryPolicy(&RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	responses := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusBadGateway, ``},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"connection reset"}}`},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`},
+	}
+	calls := 0
+	dispatch := func() (*responseRecorder, int) {
+		next := responses[min(calls, len(responses)-1)]
+		calls++
+		rr := newResponseRecorder()
+		_, _ = rr.Write([]byte(next.body))
+		return rr, next.status
+	}
+
+	rr, status, attempts := p.run(context.Background(), "echo", dispatch)
+	if attempts != 3 || calls != 3 || status != http.StatusOK || dispatchOutcome(status, rr) != callSucceeded {
+		t.Fatalf("attempts=%d calls=%d status=%d body=%s", attempts, calls, status, rr.Body.String())
+	}
+
+	// a call that succeeds first time is not repeated
+	calls = 2
+	if _, _, attempts := p.run(context.Background(), "echo", dispatch); attempts != 1 {
+		t.Fatalf("retried a successful call: attempts=%d", attempts)
+	}
+
+	calls = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, status, attempts := p.run(ctx, "echo", dispatch); attempts != 1 || status != http.StatusBadGateway {
+		t.Fatalf("retried after the deadline: attempts=%d status=%d", attempts, status)
+	}
+
+	calls = 0
+	if _, _, attempts := (*retryPolicy)(nil).run(context.Background(), "echo", dispatch); attempts != 1 {
+		t.Fatalf("nil policy made %d attempts", attempts)
+	}
+}
+
+func TestToolRetryable(t *testing.T) {
+	no, yes := false, true
+	set := &ToolOverrideSet{
+		ToolOverrides: map[string]*ToolOverrideConfig{"write_file": {Retry: &yes}},
+		Servers: map[string]*toolOverrideFragment{
+			"fs": {Tools: map[string]*ToolOverrideConfig{"read_file": {Retry: &no}}},
+		},
+	}
+	idempotent := toolHints{Idempotent: true}
+	if toolRetryable(set, "fs", "read_file", idempotent) {
+		t.Fatal("override did not forbid retries")
+	}
+	if !toolRetryable(set, "fs", "write_file", toolHints{}) {
+		t.Fatal("override did not force retries")
+	}
+	if !toolRetryable(set, "fs", "stat", idempotent) || toolRetryable(nil, "fs", "stat", toolHints{}) {
+		t.Fatal("idempotentHint should decide without an override")
+	}
+}
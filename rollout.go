@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// rolloutCandidate is a desired config running next to the active one.
+// Facade sessions whose hash falls below percent are served from it until it
+// is promoted or rolled back. Servers the plan leaves unchanged are shared
+// with the active config.
+type rolloutCandidate struct {
+	id        string
+	by        string
+	startedAt time.Time
+	plan      applyPlan
+	percent   atomic.Int32
+
+	request applyRequest
+	entries map[string]*serverEntry
+	servers map[string]*Server
+	// overrides is the candidate's override base; nil with
+	// overridesChanged false means the active one.
+	overrides        *ToolOverrideSet
+	overridesChanged bool
+	index            catalogIndex
+
+	arms [2]rolloutArmStats
+}
+
+type rolloutArmStats struct {
+	requests    atomic.Uint64
+	calls       atomic.Uint64
+	failedCalls atomic.Uint64
+}
+
+const (
+	rolloutArmActive = iota
+	rolloutArmCandidate
+)
+
+// routes reports whether a facade session is served from the candidate.
+// The hash is stable per session, so raising percent only moves sessions
+// onto the candidate and lowering it only moves them back.
+func (c *rolloutCandidate) routes(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(c.id + ":" + sessionID))
+	return int32(h.Sum32()%100) < c.percent.Load()
+}
+
+func (c *rolloutCandidate) status() map[string]any {
+	arm := func(i int) map[string]any {
+		return map[string]any{
+			"requests":    c.arms[i].requests.Load(),
+			"calls":       c.arms[i].calls.Load(),
+			"failedCalls": c.arms[i].failedCalls.Load(),
+		}
+	}
+	return map[string]any{
+		"id":        c.id,
+		"percent":   c.percent.Load(),
+		"by":        c.by,
+		"startedAt": c.startedAt,
+		"plan":      c.plan,
+		"arms": map[string]any{
+			"active":    arm(rolloutArmActive),
+			"candidate": arm(rolloutArmCandidate),
+		},
+	}
+}
+
+type rolloutContextKey struct{}
+
+// rolloutFromContext returns the candidate a facade request was routed to,
+// or nil for the active config.
+func rolloutFromContext(ctx context.Context) *rolloutCandidate {
+	c, _ := ctx.Value(rolloutContextKey{}).(*rolloutCandidate)
+	return c
+}
+
+// rolloutMiddleware assigns each facade request to the active config or the
+// rollout candidate by its session. Re-entrant requests (batches) keep the
+// assignment they already carry.
+func (a *serverApplier) rolloutMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := a.rollout.Load()
+			if c == nil || rolloutFromContext(r.Context()) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !c.routes(facadeSessionID(r)) {
+				c.arms[rolloutArmActive].requests.Add(1)
+				next.ServeHTTP(w, r)
+				return
+			}
+			c.arms[rolloutArmCandidate].requests.Add(1)
+			w.Header().Set("X-Stelae-Rollout", c.id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rolloutContextKey{}, c)))
+		})
+	}
+}
+
+// observeCall counts a dispatched call against the arm that served it.
+func (a *serverApplier) observeCall(ctx context.Context, failed bool) {
+	c := a.rollout.Load()
+	if c == nil {
+		return
+	}
+	arm := &c.arms[rolloutArmActive]
+	if rolloutFromContext(ctx) == c {
+		arm = &c.arms[rolloutArmCandidate]
+	}
+	arm.calls.Add(1)
+	if failed {
+		arm.failedCalls.Add(1)
+	}
+}
+
+func validRolloutPercent(percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("percent must be between 0 and 100, got %d", percent)
+	}
+	return nil
+}
+
+// startRollout connects the servers req adds or changes and publishes them
+// as the candidate without touching the active config.
+func (a *serverApplier) startRollout(req applyRequest, percent int, by string) (*rolloutCandidate, applyResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rollout.Load() != nil {
+		return nil, applyResult{}, errRolloutActive
+	}
+	if err := validRolloutPercent(percent); err != nil {
+		return nil, applyResult{}, err
+	}
+	plan, err := a.plan(req)
+	if err != nil {
+		return nil, applyResult{}, err
+	}
+	result := applyResult{Plan: plan}
+	if plan.empty() {
+		return nil, applyResult{}, errors.New("the candidate config matches the active one")
+	}
+	c := &rolloutCandidate{
+		id:        newRolloutID(),
+		by:        by,
+		startedAt: time.Now().UTC(),
+		plan:      plan,
+		request:   req,
+	}
+	c.percent.Store(int32(percent))
+	if plan.ToolOverridesChanged {
+		if c.overrides, err = a.loadOverrides(req.ToolOverrides); err != nil {
+			result.Error = err.Error()
+			return nil, result, nil
+		}
+		c.overridesChanged = true
+	}
+	connected, failed, err := a.connectPlan(plan, req)
+	if err != nil {
+		return nil, applyResult{}, err
+	}
+	if len(failed) > 0 {
+		result.Failed = failed
+		result.Error = failedSummary(failed)
+		logger("rollout").Error("candidate failed to connect", "err", result.Error)
+		return nil, result, nil
+	}
+	c.entries = connected
+	c.servers = a.registry.snapshot()
+	for _, name := range plan.Remove {
+		delete(c.servers, name)
+	}
+	for name, entry := range connected {
+		c.servers[name] = entry.server
+	}
+	c.index = a.index(c.servers, a.candidateOverrides(c))
+	a.rollout.Store(c)
+	logger("rollout").Info("started", "id", c.id, "percent", percent, "add", plan.Add, "change", plan.Change,
+		"remove", plan.Remove, "toolOverridesChanged", plan.ToolOverridesChanged, "by", by)
+	return c, result, nil
+}
+
+// candidateOverrides is the override set candidate sessions see: the
+// candidate's base with the runtime tool toggles applied.
+func (a *serverApplier) candidateOverrides(c *rolloutCandidate) *ToolOverrideSet {
+	if !c.overridesChanged {
+		return a.store.current()
+	}
+	return a.store.overlay(c.overrides)
+}
+
+func (a *serverApplier) shiftRollout(percent int) (*rolloutCandidate, error) {
+	if err := validRolloutPercent(percent); err != nil {
+		return nil, err
+	}
+	c := a.rollout.Load()
+	if c == nil {
+		return nil, nil
+	}
+	c.percent.Store(int32(percent))
+	logger("rollout").Info("shifted", "id", c.id, "percent", percent)
+	return c, nil
+}
+
+// promoteRollout makes the candidate the active config.
+func (a *serverApplier) promoteRollout() *rolloutCandidate {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := a.rollout.Load()
+	if c == nil {
+		return nil
+	}
+	a.commit(c.plan, c.entries, c.request.ToolOverrides, c.overrides)
+	a.rollout.Store(nil)
+	logger("rollout").Info("promoted", "id", c.id)
+	return c
+}
+
+// rollbackRollout drops the candidate and closes the servers it started.
+func (a *serverApplier) rollbackRollout() *rolloutCandidate {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := a.rollout.Load()
+	if c == nil {
+		return nil
+	}
+	a.rollout.Store(nil)
+	for _, entry := range c.entries {
+		entry.close()
+	}
+	logger("rollout").Info("rolled back", "id", c.id)
+	return c
+}
+
+func newRolloutID() string {
+	return fmt.Sprintf("ro-%x", time.Now().UnixNano())
+}
+
+// registerRolloutRoutes manages blue/green rollouts of a candidate config.
+func registerRolloutRoutes(api *adminAPI, applier *serverApplier, ready func() bool, events *eventBus) {
+	noRollout := func(w http.ResponseWriter) {
+		api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "no rollout in progress"})
+	}
+	emit := func(r *http.Request, eventType string, c *rolloutCandidate) {
+		events.emit(eventType, map[string]any{
+			"id":      c.id,
+			"percent": c.percent.Load(),
+			"by":      "admin:" + tokenFingerprint(bearerToken(r)),
+		})
+	}
+	api.handle(http.MethodGet, "rollout", func(w http.ResponseWriter, r *http.Request) {
+		c := applier.rollout.Load()
+		if c == nil {
+			noRollout(w)
+			return
+		}
+		api.writeJSON(w, http.StatusOK, c.status())
+	})
+	api.handle(http.MethodPost, "rollout", func(w http.ResponseWriter, r *http.Request) {
+		if !ready() {
+			api.writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "servers are still starting"})
+			return
+		}
+		var body struct {
+			applyRequest
+			Percent int `json:"percent"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		c, result, err := applier.startRollout(body.applyRequest, body.Percent, "admin:"+tokenFingerprint(bearerToken(r)))
+		switch {
+		case errors.Is(err, errRolloutActive):
+			api.writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+		case err != nil:
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		case c == nil:
+			api.writeJSON(w, http.StatusBadGateway, result)
+		default:
+			emit(r, "rollout.started", c)
+			api.writeJSON(w, http.StatusCreated, c.status())
+		}
+	})
+	api.handle(http.MethodPut, "rollout", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Percent *int `json:"percent"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Percent == nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": `body must be {"percent": 0-100}`})
+			return
+		}
+		c, err := applier.shiftRollout(*body.Percent)
+		if err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		if c == nil {
+			noRollout(w)
+			return
+		}
+		emit(r, "rollout.shifted", c)
+		api.writeJSON(w, http.StatusOK, c.status())
+	})
+	api.handle(http.MethodPost, "rollout/promote", func(w http.ResponseWriter, r *http.Request) {
+		c := applier.promoteRollout()
+		if c == nil {
+			noRollout(w)
+			return
+		}
+		emit(r, "rollout.promoted", c)
+		api.writeJSON(w, http.StatusOK, c.status())
+	})
+	api.handle(http.MethodDelete, "rollout", func(w http.ResponseWriter, r *http.Request) {
+		c := applier.rollbackRollout()
+		if c == nil {
+			noRollout(w)
+			return
+		}
+		emit(r, "rollout.rolled_back", c)
+		api.writeJSON(w, http.StatusOK, c.status())
+	})
+}
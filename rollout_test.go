@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRolloutRoutesSessionsByPercent(t *testing.T) {
+	c := &rolloutCandidate{id: "ro-1"}
+	if c.routes("s1") {
+		t.Fatal("0% should route nothing to the candidate")
+	}
+	c.percent.Store(100)
+	if !c.routes("s1") || c.routes("") {
+		t.Fatal("100% should route every session, and never session-less requests")
+	}
+	c.percent.Store(30)
+	var routed []string
+	for i := 0; i < 1000; i++ {
+		if id := fmt.Sprintf("s%d", i); c.routes(id) {
+			routed = append(routed, id)
+		}
+	}
+	if len(routed) < 200 || len(routed) > 400 {
+		t.Fatalf("expected about 30%% of sessions on the candidate, got %d/1000", len(routed))
+	}
+	c.percent.Store(60)
+	for _, id := range routed {
+		if !c.routes(id) {
+			t.Fatalf("raising the percentage moved session %s off the candidate", id)
+		}
+	}
+}
+
+func TestRolloutPromoteAndRollback(t *testing.T) {
+	mux := http.NewServeMux()
+	registry := newServerRegistry(mux, "/")
+	closed := map[string]int{}
+	entry := func(name string, conf *MCPClientConfigV2) *serverEntry {
+		return &serverEntry{
+			server: &Server{name: name},
+			config: conf,
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(name + ":" + conf.URL))
+			}),
+			cancel: func() { closed[name+":"+conf.URL]++ },
+		}
+	}
+	registry.add("fs", entry("fs", &MCPClientConfigV2{URL: "v1"}))
+	if err := registry.activate("fs", registry.entries["fs"].handler); err != nil {
+		t.Fatal(err)
+	}
+	applier := &serverApplier{
+		registry:      registry,
+		store:         newToolOverrideStore(nil),
+		resolve:       func(map[string]*MCPClientConfigV2, map[string]*ToolOverrideConfig) error { return nil },
+		connect:       func(name string, conf *MCPClientConfigV2) (*serverEntry, error) { return entry(name, conf), nil },
+		overrides:     func() map[string]*ToolOverrideConfig { return nil },
+		loadOverrides: func(map[string]*ToolOverrideConfig) (*ToolOverrideSet, error) { return nil, nil },
+		setOverrides:  func(map[string]*ToolOverrideConfig) {},
+		index:         testProxy().indexCatalog,
+	}
+	// the per-server route resolves the candidate from the request context
+	facade := chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := httptest.NewRequest(http.MethodPost, "/fs/", nil).WithContext(r.Context())
+		mux.ServeHTTP(w, r2)
+		applier.observeCall(r.Context(), false)
+	}), applier.rolloutMiddleware())
+	call := func(session string) string {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set(sessionIDHeader, session)
+		facade.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	desired := applyRequest{McpServers: map[string]*MCPClientConfigV2{"fs": {URL: "v2"}}}
+
+	c, _, err := applier.startRollout(desired, 100, "admin:test")
+	if err != nil || c == nil {
+		t.Fatalf("startRollout = %v, %v", c, err)
+	}
+	if _, err := applier.apply(desired); !errors.Is(err, errRolloutActive) {
+		t.Fatalf("expected apply to be refused during a rollout, got %v", err)
+	}
+	if got := call("s1"); got != "fs:v2" {
+		t.Fatalf("candidate session got %q", got)
+	}
+	if got := call(""); got != "fs:v1" {
+		t.Fatalf("session-less request got %q", got)
+	}
+	if c.arms[rolloutArmCandidate].calls.Load() != 1 || c.arms[rolloutArmActive].calls.Load() != 1 {
+		t.Fatalf("unexpected arm stats %v", c.status()["arms"])
+	}
+
+	if _, err := applier.shiftRollout(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := call("s1"); got != "fs:v1" {
+		t.Fatalf("after shifting to 0%% got %q", got)
+	}
+	if applier.rollbackRollout() != c || closed["fs:v2"] != 1 || applier.rollout.Load() != nil {
+		t.Fatalf("rollback did not close the candidate: %v", closed)
+	}
+
+	desired.McpServers["fs"] = &MCPClientConfigV2{URL: "v3"}
+	if _, _, err := applier.startRollout(desired, 50, "admin:test"); err != nil {
+		t.Fatal(err)
+	}
+	if applier.promoteRollout() == nil || applier.rollout.Load() != nil {
+		t.Fatal("promote did not end the rollout")
+	}
+	if got := call("s1"); got != "fs:v3" || closed["fs:v1"] != 1 {
+		t.Fatalf("after promotion got %q (closed %v)", got, closed)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultRootsTimeout = 10 * time.Second
+
+	rootsListMethod              = "roots/list"
+	rootsListChangedNotification = "notifications/roots/list_changed"
+)
+
+// RootsConfig advertises roots to downstream servers and answers their
+// roots/list with the roots of a facade client that declared the roots
+// capability. Fallback is served while no such client is connected; Timeout
+// bounds the wait for the client (default 10s).
+type RootsConfig struct {
+	Fallback []mcp.Root    `json:"fallback,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty"`
+}
+
+// rootsHandler answers a downstream server's roots/list.
+type rootsHandler func(ctx context.Context) (*mcp.ListRootsResult, error)
+
+// rootsBridge serves downstream roots/list from the roots of facade
+// clients, asking a client over its event stream the first time and again
+// after it reports notifications/roots/list_changed. A nil bridge
+// advertises no roots.
+type rootsBridge struct {
+	fallback []mcp.Root
+	timeout  time.Duration
+	requests *clientRequests
+	// sessions returns the sessions whose roots may serve server, in order
+	// of preference; table caches the roots each session reported.
+	sessions func(server string) []string
+	table    *facadeSessionTable
+	// notify tells every downstream server that the roots changed.
+	notify func()
+}
+
+func newRootsBridge(conf *RootsConfig, requests *clientRequests) *rootsBridge {
+	if conf == nil {
+		return nil
+	}
+	b := &rootsBridge{fallback: conf.Fallback, timeout: conf.Timeout, requests: requests}
+	if b.timeout <= 0 {
+		b.timeout = defaultRootsTimeout
+	}
+	return b
+}
+
+// handler is the roots handler for server's client, nil without a bridge.
+func (b *rootsBridge) handler(server string) rootsHandler {
+	if b == nil {
+		return nil
+	}
+	return func(ctx context.Context) (*mcp.ListRootsResult, error) {
+		roots := b.list(ctx, server)
+		if roots == nil {
+			roots = []mcp.Root{}
+		}
+		return &mcp.ListRootsResult{Roots: roots}, nil
+	}
+}
+
+// list returns the roots of the first session that reports them, or the
+// fallback roots.
+func (b *rootsBridge) list(ctx context.Context, server string) []mcp.Root {
+	var sessions []string
+	if b.sessions != nil {
+		sessions = b.sessions(server)
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	for _, session := range sessions {
+		if roots, ok := b.table.cachedRoots(session); ok {
+			return roots
+		}
+		data, err := b.requests.call(ctx, session, rootsListMethod, nil)
+		if errors.Is(err, errNoClientStream) {
+			continue
+		}
+		var result mcp.ListRootsResult
+		if err == nil {
+			err = json.Unmarshal(data, &result)
+		}
+		if err != nil {
+			logger("facade").Warn("roots/list failed", "server", server, "session", session, "err", err)
+			continue
+		}
+		logger("facade").Info("roots/list", "server", server, "session", session, "roots", len(result.Roots))
+		b.table.storeRoots(session, result.Roots)
+		return result.Roots
+	}
+	return b.fallback
+}
+
+// changed drops the roots cached for session and tells downstream servers
+// to list them again.
+func (b *rootsBridge) changed(session string) {
+	if b == nil {
+		return
+	}
+	b.table.forgetRoots(session)
+	if b.notify != nil {
+		b.notify()
+	}
+}
+
+// rootsSessions picks the sessions whose client declared roots: those with
+// a call in flight on server, most recent call first, then the other live
+// sessions, newest first.
+func rootsSessions(calls []activeCall, server string, live []facadeSession) []string {
+	capable := make(map[string]bool)
+	for _, s := range live {
+		if s.Roots {
+			capable[s.ID] = true
+		}
+	}
+	out := activeSessions(calls, server, func(session string) bool { return capable[session] })
+	seen := make(map[string]bool, len(out))
+	for _, session := range out {
+		seen[session] = true
+	}
+	for _, s := range live {
+		if s.Roots && !seen[s.ID] {
+			out = append(out, s.ID)
+		}
+	}
+	return out
+}
+
+// notifyRootsChanged sends notifications/roots/list_changed to the server
+// when its client advertised roots.
+func (c *Client) notifyRootsChanged(ctx context.Context) {
+	if c.roots == nil {
+		return
+	}
+	n := mcp.JSONRPCNotification{JSONRPC: mcp.JSONRPC_VERSION}
+	n.Method = rootsListChangedNotification
+	if err := c.client.GetTransport().SendNotification(ctx, n); err != nil {
+		clientLogger(c.name).Warn("roots list_changed not sent", "err", err)
+	}
+}
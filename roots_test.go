@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRootsBridge(t *testing.T) {
+	if newRootsBridge(nil, newClientRequests(newSessionStreams())).handler("fs") != nil {
+		t.Fatal("a disabled bridge advertised roots")
+	}
+	streams := newSessionStreams()
+	table := newFacadeSessionTable(time.Minute)
+	session := table.open("streamable-http", "anonymous")
+	table.setRoots(session, true)
+	fallback := []mcp.Root{{URI: "file:///srv", Name: "srv"}}
+	b := newRootsBridge(&RootsConfig{Fallback: fallback, Timeout: time.Second}, newClientRequests(streams))
+	b.table = table
+	b.sessions = func(string) []string { return []string{"closed", session} }
+	notified := 0
+	b.notify = func() { notified++ }
+
+	notices, unsubscribe := streams.subscribe(session)
+	defer unsubscribe()
+	asked := 0
+	answer := func(uri string) {
+		var req struct {
+			ID     string `json:"id"`
+			Method string `json:"method"`
+		}
+		_ = json.Unmarshal(<-notices, &req)
+		asked++
+		if req.Method != rootsListMethod {
+			t.Errorf("client got %s", req.Method)
+		}
+		reply, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{
+			"roots": []map[string]any{{"uri": uri}},
+		}})
+		if !b.requests.deliver(reply) {
+			t.Error("response not delivered")
+		}
+	}
+	list := func() []mcp.Root {
+		result, err := b.handler("fs")(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Roots
+	}
+
+	go answer("file:///work")
+	if got := list(); len(got) != 1 || got[0].URI != "file:///work" {
+		t.Fatalf("roots = %v", got)
+	}
+	if got := list(); len(got) != 1 || got[0].URI != "file:///work" || asked != 1 {
+		t.Fatalf("cached roots = %v after %d requests", got, asked)
+	}
+
+	b.changed(session)
+	if notified != 1 {
+		t.Fatal("downstream servers not told the roots changed")
+	}
+	go answer("file:///other")
+	if got := list(); len(got) != 1 || got[0].URI != "file:///other" {
+		t.Fatalf("roots after list_changed = %v", got)
+	}
+
+	b.sessions = func(string) []string { return nil }
+	if got := list(); !reflect.DeepEqual(got, fallback) {
+		t.Fatalf("fallback roots = %v", got)
+	}
+}
+
+func TestRootsSessions(t *testing.T) {
+	live := []facadeSession{{ID: "new", Roots: true}, {ID: "plain"}, {ID: "busy", Roots: true}, {ID: "old", Roots: true}}
+	calls := []activeCall{{Server: "fs", SessionID: "busy"}, {Server: "git", SessionID: "old"}, {Server: "fs", SessionID: "plain"}}
+	if got := rootsSessions(calls, "fs", live); !reflect.DeepEqual(got, []string{"busy", "new", "old"}) {
+		t.Fatalf("sessions = %v", got)
+	}
+	if !initializeDeclares(json.RawMessage(`{"capabilities":{"roots":{"listChanged":true}}}`), "roots") {
+		t.Fatal("roots capability not detected")
+	}
+	if initializeDeclares(json.RawMessage(`{"capabilities":{"sampling":{}}}`), "roots") {
+		t.Fatal("roots detected without the capability")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Symbolic names of the JSON-RPC errors the facade produces. Operators refer
+// to these in mcpProxy.errors; agents see them in the manifest's x-stelae
+// section and in error.data.name.
+const (
+	errNameMethodNotFound    = "method_not_found"
+	errNameInternal          = "internal_error"
+	errNameRequestTimeout    = "request_timeout"
+	errNameUpstreamRejected  = "upstream_rejected"
+	errNameUnknownFetchID    = "unknown_fetch_id"
+	errNameCallCancelled     = "call_cancelled"
+	errNameProtocolViolation = "protocol_violation"
+	errNameUnknownTool       = "unknown_tool"
+	errNameUnknownPrompt     = "unknown_prompt"
+	errNameUnknownResource   = "unknown_resource"
+	errNameInvalidRequest    = "invalid_request"
+	errNameFetchFailed       = "fetch_failed"
+	errNameFetchNotAllowed   = "fetch_not_allowed"
+	errNameMissingParam      = "missing_param"
+	errNameInvalidFetchRange = "invalid_fetch_range"
+	errNameReadOnlyMode      = "read_only_mode"
+	errNameBudgetExceeded    = "session_budget_exceeded"
+	errNameLoopDetected      = "loop_detected"
+	errNameToolConflict      = "tool_conflict"
+	errNameServerBusy        = "server_busy"
+	errNameCircuitOpen       = "circuit_open"
+	errNameInsufficientScope = "insufficient_scope"
+	errNameParseError        = "parse_error"
+)
+
+// rpcErrorDef is one registry entry. Message is a {{var}} template expanded
+// with the variables listed in Vars.
+type rpcErrorDef struct {
+	Name        string   `json:"name"`
+	Code        int      `json:"code"`
+	Description string   `json:"description"`
+	Message     string   `json:"message"`
+	Vars        []string `json:"vars,omitempty"`
+	// Custom codes live in the JSON-RPC server-error range and may be
+	// renumbered; standard codes keep their spec value.
+	Custom bool `json:"custom"`
+}
+
+var defaultRPCErrorDefs = []rpcErrorDef{
+	{Name: errNameMethodNotFound, Code: -32601, Description: "The facade does not implement the JSON-RPC method.", Message: "Method not found"},
+	{Name: errNameUnknownTool, Code: -32601, Description: "No connected server exposes the tool.", Message: "Unknown tool: {{name}}", Vars: []string{"name"}},
+	{Name: errNameUnknownPrompt, Code: -32601, Description: "No connected server exposes the prompt.", Message: "Unknown prompt: {{name}}", Vars: []string{"name"}},
+	{Name: errNameUnknownResource, Code: -32601, Description: "No connected server exposes the resource.", Message: "Unknown resource: {{uri}}", Vars: []string{"uri"}},
+	{Name: errNameParseError, Code: rpcCodeParseError, Description: "The message is not valid JSON or not valid UTF-8.", Message: "Parse error: {{detail}}", Vars: []string{"detail"}},
+	{Name: errNameInvalidRequest, Code: -32600, Description: "The message breaks mcpProxy.requestLimits, repeats an object key, or has an invalid envelope; or a batch entry or stelae/batch operation is not a valid request, or is not allowed in a batch; or a fan_out call targets fan_out.", Message: "Invalid request: {{detail}}", Vars: []string{"detail"}},
+	{Name: errNameMissingParam, Code: -32602, Description: "A required param is missing.", Message: "Missing {{param}}", Vars: []string{"param"}},
+	{Name: errNameInvalidFetchRange, Code: -32602, Description: "fetch offset/length/cursor are invalid.", Message: "Invalid fetch range: {{detail}}", Vars: []string{"detail"}},
+	{Name: errNameFetchNotAllowed, Code: -32602, Description: "The fetch URL is outside mcpProxy.fetch's allowlist.", Message: "Fetch failed: {{detail}}", Vars: []string{"detail"}},
+	{Name: errNameInternal, Code: -32603, Description: "The proxy failed to build a response.", Message: "Internal error: {{detail}}", Vars: []string{"detail"}},
+	{Name: errNameRequestTimeout, Code: rpcCodeRequestTimeout, Custom: true, Description: "The call exceeded its deadline; error.data carries timeoutMs and elapsedMs.", Message: "Request timed out calling {{target}}", Vars: []string{"target"}},
+	{Name: errNameUpstreamRejected, Code: -32004, Custom: true, Description: "The owning server has no connected client or did not take the request.", Message: "Server {{server}} did not take the request", Vars: []string{"server"}},
+	{Name: errNameCircuitOpen, Code: -32004, Custom: true, Description: "mcpProxy.circuitBreaker opened the server's circuit after repeated failures; error.data carries retryAt.", Message: "Server {{server}} is failing; calls are paused until the circuit closes", Vars: []string{"server"}},
+	{Name: errNameFetchFailed, Code: -32004, Custom: true, Description: "Fetching an allowlisted URL failed.", Message: "Fetch failed: {{detail}}", Vars: []string{"detail"}},
+	{Name: errNameUnknownFetchID, Code: -32005, Custom: true, Description: "The fetch id matches no search result.", Message: "Unknown fetch id"},
+	{Name: errNameCallCancelled, Code: rpcCodeCallCancelled, Custom: true, Description: "An operator cancelled the call through the admin API.", Message: "Call cancelled by operator"},
+	{Name: errNameReadOnlyMode, Code: rpcCodeReadOnlyMode, Custom: true, Description: "The proxy is in read-only mode and the tool is not annotated readOnlyHint.", Message: "Tool {{name}} is blocked: the proxy is in read-only mode", Vars: []string{"name"}},
+	{Name: errNameBudgetExceeded, Code: rpcCodeBudgetExceeded, Custom: true, Description: "The session used up mcpProxy.sessionBudget; error.data carries the usage.", Message: "Session budget exceeded: at most {{max}} {{limit}} per session", Vars: []string{"limit", "max"}},
+	{Name: errNameLoopDetected, Code: rpcCodeLoopDetected, Custom: true, Description: "The session kept retrying the same failing call; error.data carries count, lastError, and retryAfter.", Message: "{{name}} failed {{count}} times in a row with these arguments and the same error; stop retrying and change the arguments or approach", Vars: []string{"name", "count"}},
+	{Name: errNameToolConflict, Code: rpcCodeToolConflict, Custom: true, Description: "Several servers expose the tool and mcpProxy.conflictPolicy is error; error.data lists the servers.", Message: "Tool {{name}} is exposed by several servers ({{servers}}); ask the operator to resolve the conflict", Vars: []string{"name", "servers"}},
+	{Name: errNameInsufficientScope, Code: rpcCodeInsufficientScope, Custom: true, Description: "The caller's token lacks scopes mcpProxy.toolScopes requires for the tool; error.data lists them.", Message: "Tool {{name}} requires scopes the caller lacks: {{scopes}}", Vars: []string{"name", "scopes"}},
+	{Name: errNameServerBusy, Code: rpcCodeServerBusy, Custom: true, Description: "The server's mcpProxy.backpressure call slots stayed full for the queue timeout; error.data carries the server's load.", Message: "Server {{server}} is busy; retry later", Vars: []string{"server"}},
+	{Name: errNameProtocolViolation, Code: rpcCodeProtocolViolation, Custom: true, Description: "The downstream response was not valid MCP; error.data names the violation and quarantineId.", Message: "Downstream server {{server}} returned an invalid response", Vars: []string{"server"}},
+}
+
+// ErrorsConfig renumbers custom codes and replaces message templates, e.g. to
+// localize what agents show to users.
+type ErrorsConfig struct {
+	Codes    map[string]int    `json:"codes,omitempty"`
+	Messages map[string]string `json:"messages,omitempty"`
+}
+
+type rpcErrorRegistry struct {
+	defs map[string]rpcErrorDef
+	// locales are the registries of the mcpProxy.messages locales.
+	locales map[string]*rpcErrorRegistry
+}
+
+// rpcErrors is the process-wide registry; runProxy configures it from
+// mcpProxy.errors before serving.
+var rpcErrors = mustRPCErrorRegistry(nil)
+
+func mustRPCErrorRegistry(conf *ErrorsConfig) *rpcErrorRegistry {
+	reg, err := newRPCErrorRegistry(conf)
+	if err != nil {
+		panic(err)
+	}
+	return reg
+}
+
+func newRPCErrorRegistry(conf *ErrorsConfig) (*rpcErrorRegistry, error) {
+	defs := make(map[string]rpcErrorDef, len(defaultRPCErrorDefs))
+	for _, def := range defaultRPCErrorDefs {
+		defs[def.Name] = def
+	}
+	if conf == nil {
+		return &rpcErrorRegistry{defs: defs}, nil
+	}
+	for name, code := range conf.Codes {
+		def, ok := defs[name]
+		if !ok {
+			return nil, fmt.Errorf("errors.codes: unknown error %q", name)
+		}
+		if !def.Custom {
+			return nil, fmt.Errorf("errors.codes: %s uses standard JSON-RPC code %d and cannot be renumbered", name, def.Code)
+		}
+		if code > -32000 || code < -32099 {
+			return nil, fmt.Errorf("errors.codes: %s code %d is outside the server error range -32099..-32000", name, code)
+		}
+		def.Code = code
+		defs[name] = def
+	}
+	for name, message := range conf.Messages {
+		if err := setRPCErrorMessage(defs, name, message); err != nil {
+			return nil, fmt.Errorf("errors.messages: %w", err)
+		}
+	}
+	return &rpcErrorRegistry{defs: defs}, nil
+}
+
+// setRPCErrorMessage replaces the message template of error name.
+func setRPCErrorMessage(defs map[string]rpcErrorDef, name, message string) error {
+	def, ok := defs[name]
+	if !ok {
+		return fmt.Errorf("unknown error %q", name)
+	}
+	allowed := make(map[string]string, len(def.Vars))
+	for _, v := range def.Vars {
+		allowed[v] = ""
+	}
+	if _, missing := expandTemplate(message, allowed); len(missing) > 0 {
+		return fmt.Errorf("%s uses unknown variable %q", name, missing[0])
+	}
+	def.Message = message
+	defs[name] = def
+	return nil
+}
+
+// withLocales returns a registry whose messages follow mcpProxy.messages:
+// the default locale's error messages replace reg's, and each locale gets
+// its own registry for in.
+func (reg *rpcErrorRegistry) withLocales(conf *MessagesConfig) (*rpcErrorRegistry, error) {
+	if conf == nil || len(conf.Locales) == 0 {
+		return reg, nil
+	}
+	localize := func(locale string, entry *LocaleMessagesConfig) (*rpcErrorRegistry, error) {
+		defs := make(map[string]rpcErrorDef, len(reg.defs))
+		for name, def := range reg.defs {
+			defs[name] = def
+		}
+		if entry != nil {
+			for name, message := range entry.Errors {
+				if err := setRPCErrorMessage(defs, name, message); err != nil {
+					return nil, fmt.Errorf("messages.locales.%s.errors: %w", locale, err)
+				}
+			}
+		}
+		return &rpcErrorRegistry{defs: defs}, nil
+	}
+	out := &rpcErrorRegistry{defs: reg.defs, locales: make(map[string]*rpcErrorRegistry, len(conf.Locales)+1)}
+	// the built-in English locale keeps reg's messages unless configured
+	out.locales[defaultMessageLocale] = reg
+	for locale, entry := range conf.Locales {
+		localized, err := localize(locale, entry)
+		if err != nil {
+			return nil, err
+		}
+		out.locales[normalizeLocale(locale)] = localized
+		if normalizeLocale(locale) == normalizeLocale(conf.DefaultLocale) {
+			out.defs = localized.defs
+		}
+	}
+	return out, nil
+}
+
+// in returns the registry for locale, or reg itself when the locale has no
+// messages of its own.
+func (reg *rpcErrorRegistry) in(locale string) *rpcErrorRegistry {
+	if localized, ok := reg.locales[locale]; ok {
+		return localized
+	}
+	return reg
+}
+
+// response builds the JSON-RPC error for name. error.data always carries the
+// symbolic name so agents can match on it regardless of code or language.
+func (reg *rpcErrorRegistry) response(id any, name string, vars map[string]string) jsonrpcResponse {
+	def, ok := reg.defs[name]
+	if !ok {
+		def = reg.defs[errNameInternal]
+		vars = map[string]string{"detail": name}
+	}
+	message, _ := expandTemplate(def.Message, vars)
+	resp := rpcError(id, def.Code, message)
+	resp.Error.Data = map[string]any{"name": def.Name}
+	return resp
+}
+
+// withErrorData merges extra fields into error.data of a registry response.
+func withErrorData(resp jsonrpcResponse, data map[string]any) jsonrpcResponse {
+	merged, _ := resp.Error.Data.(map[string]any)
+	if merged == nil {
+		merged = make(map[string]any, len(data))
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	resp.Error.Data = merged
+	return resp
+}
+
+// manifestEntries lists the registry for the manifest, ordered by code then name.
+func (reg *rpcErrorRegistry) manifestEntries() []rpcErrorDef {
+	out := make([]rpcErrorDef, 0, len(reg.defs))
+	for _, def := range reg.defs {
+		out = append(out, def)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Code != out[j].Code {
+			return out[i].Code > out[j].Code
+		}
+		return strings.Compare(out[i].Name, out[j].Name) < 0
+	})
+	return out
+}
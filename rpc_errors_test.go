@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRPCErrorRegistryDefaults(t *testing.T) {
+	reg, err := newRPCErrorRegistry(nil)
+	if err != nil {
+		t.Fatalf("newRPCErrorRegistry: %v", err)
+	}
+	resp := reg.response(7, errNameUnknownTool, map[string]string{"name": "echo"})
+	if resp.Error.Code != -32601 || resp.Error.Message != "Unknown tool: echo" {
+		t.Fatalf("unexpected error %+v", resp.Error)
+	}
+	if data := resp.Error.Data.(map[string]any); data["name"] != errNameUnknownTool {
+		t.Fatalf("expected symbolic name in data, got %v", data)
+	}
+	seen := map[string]bool{}
+	for _, def := range reg.manifestEntries() {
+		if seen[def.Name] {
+			t.Fatalf("duplicate registry entry %s", def.Name)
+		}
+		seen[def.Name] = true
+		if def.Custom && (def.Code > -32000 || def.Code < -32099) {
+			t.Fatalf("custom code %s=%d outside server error range", def.Name, def.Code)
+		}
+	}
+}
+
+func TestRPCErrorRegistryOverrides(t *testing.T) {
+	reg, err := newRPCErrorRegistry(&ErrorsConfig{
+		Codes:    map[string]int{errNameUpstreamRejected: -32040},
+		Messages: map[string]string{errNameUpstreamRejected: "Le serveur {{server}} a refusé l'appel"},
+	})
+	if err != nil {
+		t.Fatalf("newRPCErrorRegistry: %v", err)
+	}
+	resp := withErrorData(reg.response(1, errNameUpstreamRejected, map[string]string{"server": "fs"}), map[string]any{"extra": true})
+	if resp.Error.Code != -32040 || resp.Error.Message != "Le serveur fs a refusé l'appel" {
+		t.Fatalf("unexpected error %+v", resp.Error)
+	}
+	data := resp.Error.Data.(map[string]any)
+	if data["name"] != errNameUpstreamRejected || data["extra"] != true {
+		t.Fatalf("unexpected data %v", data)
+	}
+
+	cases := []struct {
+		conf *ErrorsConfig
+		want string
+	}{
+		{&ErrorsConfig{Codes: map[string]int{"nope": -32050}}, "unknown error"},
+		{&ErrorsConfig{Codes: map[string]int{errNameUnknownTool: -32050}}, "cannot be renumbered"},
+		{&ErrorsConfig{Codes: map[string]int{errNameRequestTimeout: -31000}}, "outside the server error range"},
+		{&ErrorsConfig{Messages: map[string]string{errNameUnknownTool: "Unknown {{tool}}"}}, "unknown variable"},
+	}
+	for _, tc := range cases {
+		if _, err := newRPCErrorRegistry(tc.conf); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected error containing %q, got %v", tc.want, err)
+		}
+	}
+}
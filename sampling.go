@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const defaultSamplingTimeout = 60 * time.Second
+
+// SamplingConfig advertises sampling to downstream servers and bridges their
+// sampling/createMessage requests to a facade client that declared the
+// sampling capability. Timeout bounds the wait for the client (default 60s).
+type SamplingConfig struct {
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+var errNoSamplingClient = errors.New("no connected client can serve sampling for this server")
+
+// samplingBridge relays sampling/createMessage from downstream servers to
+// the event stream of a facade session with a call in flight on that
+// server, and hands the session's POSTed response back. A nil bridge
+// advertises no sampling.
+type samplingBridge struct {
+	timeout  time.Duration
+	requests *clientRequests
+	// sessions returns the sessions that may serve sampling for server, in
+	// order of preference.
+	sessions func(server string) []string
+}
+
+func newSamplingBridge(conf *SamplingConfig, requests *clientRequests) *samplingBridge {
+	if conf == nil {
+		return nil
+	}
+	b := &samplingBridge{timeout: conf.Timeout, requests: requests}
+	if b.timeout <= 0 {
+		b.timeout = defaultSamplingTimeout
+	}
+	return b
+}
+
+// handler is the sampling handler for server's client, nil without a bridge.
+func (b *samplingBridge) handler(server string) client.SamplingHandler {
+	if b == nil {
+		return nil
+	}
+	return samplingHandler{bridge: b, server: server}
+}
+
+type samplingHandler struct {
+	bridge *samplingBridge
+	server string
+}
+
+func (h samplingHandler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	data, err := h.bridge.request(ctx, h.server, request.CreateMessageParams)
+	if err != nil {
+		return nil, err
+	}
+	var result mcp.CreateMessageResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("sampling: invalid client result: %w", err)
+	}
+	return &result, nil
+}
+
+// request sends a sampling/createMessage with params to the first session
+// with an open stream and waits for its response.
+func (b *samplingBridge) request(ctx context.Context, server string, params any) (json.RawMessage, error) {
+	var sessions []string
+	if b.sessions != nil {
+		sessions = b.sessions(server)
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	result, session, err := b.requests.first(ctx, sessions, string(mcp.MethodSamplingCreateMessage), params)
+	if errors.Is(err, errNoClientStream) {
+		return nil, errNoSamplingClient
+	}
+	logger("facade").Info("sampling/createMessage", "server", server, "session", session)
+	if err != nil {
+		return nil, fmt.Errorf("sampling: %w", err)
+	}
+	return result, nil
+}
+
+// activeSessions picks the sessions with a call in flight on server whose
+// client is capable, most recent call first.
+func activeSessions(calls []activeCall, server string, capable func(session string) bool) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for i := len(calls) - 1; i >= 0; i-- {
+		call := calls[i]
+		if call.Server != server || call.SessionID == "" || seen[call.SessionID] {
+			continue
+		}
+		seen[call.SessionID] = true
+		if capable(call.SessionID) {
+			out = append(out, call.SessionID)
+		}
+	}
+	return out
+}
+
+// initializeDeclares reports whether initialize params declare the client
+// capability name.
+func initializeDeclares(params json.RawMessage, name string) bool {
+	var p struct {
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+	if json.Unmarshal(params, &p) != nil {
+		return false
+	}
+	capability := p.Capabilities[name]
+	return len(capability) > 0 && string(capability) != "null"
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSamplingBridge(t *testing.T) {
+	if newSamplingBridge(nil, newClientRequests(newSessionStreams())).handler("llm") != nil {
+		t.Fatal("a disabled bridge advertised sampling")
+	}
+	streams := newSessionStreams()
+	b := newSamplingBridge(&SamplingConfig{Timeout: time.Second}, newClientRequests(streams))
+	b.sessions = func(server string) []string { return []string{"closed", "sess-1"} }
+	notices, unsubscribe := streams.subscribe("sess-1")
+	defer unsubscribe()
+
+	// the client answers whatever request reaches its stream
+	go func() {
+		var req struct {
+			ID     string `json:"id"`
+			Method string `json:"method"`
+		}
+		_ = json.Unmarshal(<-notices, &req)
+		if req.Method != "sampling/createMessage" {
+			t.Errorf("client got %s", req.Method)
+		}
+		if b.requests.deliver([]byte(`{"jsonrpc":"2.0","id":"other","result":{}}`)) {
+			t.Error("delivered a response to an unknown request")
+		}
+		reply, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{
+			"role": "assistant", "model": "m", "content": map[string]any{"type": "text", "text": "hi"},
+		}})
+		if !b.requests.deliver(reply) {
+			t.Error("response not delivered")
+		}
+	}()
+	request := mcp.CreateMessageRequest{}
+	request.Messages = []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.TextContent{Type: "text", Text: "hello"}}}
+	result, err := b.handler("llm").CreateMessage(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Model != "m" || result.Role != mcp.RoleAssistant {
+		t.Fatalf("result = %+v", result)
+	}
+
+	go func() {
+		var req struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(<-notices, &req)
+		b.requests.deliver([]byte(`{"jsonrpc":"2.0","id":"` + req.ID + `","error":{"code":-1,"message":"User rejected sampling request"}}`))
+	}()
+	if _, err := b.handler("llm").CreateMessage(context.Background(), request); err == nil {
+		t.Fatal("client error not returned to the server")
+	}
+
+	b.sessions = func(string) []string { return nil }
+	if _, err := b.handler("llm").CreateMessage(context.Background(), request); !errors.Is(err, errNoSamplingClient) {
+		t.Fatalf("no client: %v", err)
+	}
+}
+
+func TestSamplingSessions(t *testing.T) {
+	calls := []activeCall{
+		{Server: "llm", SessionID: "a"},
+		{Server: "fs", SessionID: "b"},
+		{Server: "llm", SessionID: "c"},
+		{Server: "llm", SessionID: "d"},
+		{Server: "llm"},
+		{Server: "llm", SessionID: "a"},
+	}
+	capable := func(session string) bool { return session != "d" }
+	if got := activeSessions(calls, "llm", capable); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Fatalf("sessions = %v", got)
+	}
+
+	if !initializeDeclares(json.RawMessage(`{"capabilities":{"sampling":{}}}`), "sampling") {
+		t.Fatal("sampling capability not detected")
+	}
+	if initializeDeclares(json.RawMessage(`{"capabilities":{"roots":{}}}`), "sampling") || initializeDeclares(nil, "sampling") {
+		t.Fatal("sampling detected without the capability")
+	}
+
+	table := newFacadeSessionTable(time.Minute)
+	id := table.open("streamable-http", "anonymous")
+	table.setSampling(id, true)
+	if !table.sampling(id) || table.sampling("unknown") {
+		t.Fatal("session sampling flag not tracked")
+	}
+}
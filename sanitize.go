@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// sanitizeDownstreamResponse repairs common deviations in a downstream
+// JSON-RPC response so mcp-go can decode it: a missing jsonrpc member, an
+// id echoed with the wrong JSON type, and content given as a bare string or a
+// single object. It returns the rewritten body and the fixes applied; the body
+// is returned unchanged when nothing needed fixing or it is not JSON.
+func sanitizeDownstreamResponse(method string, reqID any, body []byte) ([]byte, []string) {
+	var envelope map[string]any
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return body, nil
+	}
+	var fixes []string
+	if v, _ := envelope["jsonrpc"].(string); v != "2.0" {
+		envelope["jsonrpc"] = "2.0"
+		fixes = append(fixes, "jsonrpc")
+	}
+	if id, ok := envelope["id"]; ok && idTypeMismatch(id, reqID) {
+		envelope["id"] = reqID
+		fixes = append(fixes, "id type")
+	}
+	if result, ok := envelope["result"].(map[string]any); ok {
+		switch method {
+		case "tools/call":
+			if content, fixed := sanitizeContentList(result["content"]); fixed {
+				result["content"] = content
+				fixes = append(fixes, "content")
+			}
+		case "prompts/get":
+			if messages, ok := result["messages"].([]any); ok {
+				for _, raw := range messages {
+					msg, ok := raw.(map[string]any)
+					if !ok {
+						continue
+					}
+					if block, fixed := sanitizeContentBlock(msg["content"]); fixed {
+						msg["content"] = block
+						fixes = append(fixes, "message content")
+					}
+				}
+			}
+		case "resources/read":
+			if single, ok := result["contents"].(map[string]any); ok {
+				result["contents"] = []any{single}
+				fixes = append(fixes, "contents")
+			}
+		}
+	}
+	if len(fixes) == 0 {
+		return body, nil
+	}
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return body, nil
+	}
+	return out, fixes
+}
+
+// idTypeMismatch reports a string id echoed for a numeric one or vice versa.
+func idTypeMismatch(got, want any) bool {
+	switch got.(type) {
+	case string, float64:
+	default:
+		return false
+	}
+	switch want.(type) {
+	case string, float64:
+	default:
+		return false
+	}
+	return got != want && fmt.Sprint(got) == fmt.Sprint(want)
+}
+
+// sanitizeContentList turns a bare string or single block into a block list
+// and fills in missing text block types.
+func sanitizeContentList(v any) ([]any, bool) {
+	switch content := v.(type) {
+	case string:
+		return []any{textBlock(content)}, true
+	case map[string]any:
+		block, _ := sanitizeContentBlock(content)
+		return []any{block}, true
+	case []any:
+		fixed := false
+		for i, raw := range content {
+			if block, ok := sanitizeContentBlock(raw); ok {
+				content[i] = block
+				fixed = true
+			}
+		}
+		return content, fixed
+	default:
+		return nil, false
+	}
+}
+
+func sanitizeContentBlock(v any) (any, bool) {
+	switch block := v.(type) {
+	case string:
+		return textBlock(block), true
+	case map[string]any:
+		if _, hasType := block["type"]; !hasType {
+			if _, isText := block["text"].(string); isText {
+				block["type"] = "text"
+				return block, true
+			}
+		}
+	}
+	return v, false
+}
+
+func textBlock(text string) map[string]any {
+	return map[string]any{"type": "text", "text": text}
+}
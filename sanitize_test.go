@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeDownstreamResponseFixesDeviations(t *testing.T) {
+	body := []byte(`{"id":"7","result":{"content":"plain answer"}}`)
+	out, fixes := sanitizeDownstreamResponse("tools/call", float64(7), body)
+	if !reflect.DeepEqual(fixes, []string{"jsonrpc", "id type", "content"}) {
+		t.Fatalf("unexpected fixes %v", fixes)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := map[string]any{
+		"jsonrpc": "2.0",
+		"id":      float64(7),
+		"result":  map[string]any{"content": []any{map[string]any{"type": "text", "text": "plain answer"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if err := validateDownstreamResponse("tools/call", out); err != nil {
+		t.Fatalf("sanitized response should validate: %v", err)
+	}
+}
+
+func TestSanitizeDownstreamResponseShapes(t *testing.T) {
+	cases := []struct {
+		method string
+		body   string
+		want   string
+	}{
+		{"tools/call", `{"jsonrpc":"2.0","id":1,"result":{"content":[{"text":"x"}]}}`, `{"id":1,"jsonrpc":"2.0","result":{"content":[{"text":"x","type":"text"}]}}`},
+		{"prompts/get", `{"jsonrpc":"2.0","id":1,"result":{"messages":[{"role":"user","content":"hi"}]}}`, `{"id":1,"jsonrpc":"2.0","result":{"messages":[{"content":{"text":"hi","type":"text"},"role":"user"}]}}`},
+		{"resources/read", `{"jsonrpc":"2.0","id":1,"result":{"contents":{"uri":"file:///a","text":"x"}}}`, `{"id":1,"jsonrpc":"2.0","result":{"contents":[{"text":"x","uri":"file:///a"}]}}`},
+	}
+	for _, tc := range cases {
+		out, fixes := sanitizeDownstreamResponse(tc.method, float64(1), []byte(tc.body))
+		if len(fixes) != 1 || string(out) != tc.want {
+			t.Errorf("%s: got %s (fixes %v), want %s", tc.method, out, fixes, tc.want)
+		}
+	}
+}
+
+func TestSanitizeDownstreamResponseLeavesCleanBodiesAlone(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":"a","result":{"content":[{"type":"text","text":"ok"}]}}`)
+	out, fixes := sanitizeDownstreamResponse("tools/call", "a", body)
+	if len(fixes) != 0 || string(out) != string(body) {
+		t.Fatalf("expected untouched body, got %s (%v)", out, fixes)
+	}
+	if out, fixes := sanitizeDownstreamResponse("tools/call", 1, []byte("not json")); len(fixes) != 0 || string(out) != "not json" {
+		t.Fatalf("non-JSON body should pass through")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+const rpcCodeInsufficientScope = -32013
+
+// Annotation classes a tool scope rule can match.
+const (
+	toolClassReadOnly    = "readOnly"
+	toolClassDestructive = "destructive"
+	toolClassIdempotent  = "idempotent"
+	toolClassOpenWorld   = "openWorld"
+)
+
+// ToolScopeRule requires every one of Scopes from the bearer token of a
+// tools/call that matches. Server and Tool are path.Match patterns on the
+// owning server and the tool's downstream name; empty matches all.
+// Annotation limits the rule to tools annotated with the class
+// (readOnly, destructive, idempotent, openWorld), after tool overrides.
+type ToolScopeRule struct {
+	Server     string   `json:"server,omitempty"`
+	Tool       string   `json:"tool,omitempty"`
+	Annotation string   `json:"annotation,omitempty"`
+	Scopes     []string `json:"scopes"`
+}
+
+// toolScopes enforces the tool scope rules. Scopes come from the OAuth
+// access token or JWT the request was admitted with; other callers have
+// none.
+type toolScopes struct {
+	rules []*ToolScopeRule
+}
+
+func newToolScopes(rules []*ToolScopeRule) (*toolScopes, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	for i, rule := range rules {
+		if rule == nil || len(rule.Scopes) == 0 {
+			return nil, fmt.Errorf("toolScopes[%d]: scopes is required", i)
+		}
+		for _, pattern := range []string{rule.Server, rule.Tool} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("toolScopes[%d]: pattern %q: %w", i, pattern, err)
+			}
+		}
+		switch rule.Annotation {
+		case "", toolClassReadOnly, toolClassDestructive, toolClassIdempotent, toolClassOpenWorld:
+		default:
+			return nil, fmt.Errorf("toolScopes[%d]: unknown annotation %q", i, rule.Annotation)
+		}
+	}
+	return &toolScopes{rules: rules}, nil
+}
+
+// required lists the scopes every matching rule asks of a call to tool on
+// server.
+func (s *toolScopes) required(server, tool string, hints toolHints) []string {
+	if s == nil {
+		return nil
+	}
+	var out []string
+	for _, rule := range s.rules {
+		if !globMatch(rule.Server, server) || !globMatch(rule.Tool, tool) || !hints.is(rule.Annotation) {
+			continue
+		}
+		for _, scope := range rule.Scopes {
+			if !slices.Contains(out, scope) {
+				out = append(out, scope)
+			}
+		}
+	}
+	return out
+}
+
+// missing returns the required scopes the caller's token lacks.
+func (s *toolScopes) missing(r *http.Request, srv *Server, overrides *ToolOverrideSet, tool string) []string {
+	if s == nil || srv == nil {
+		return nil
+	}
+	hints, _ := resolveToolHints(srv, overrides, tool)
+	required := s.required(srv.name, tool, hints)
+	if len(required) == 0 {
+		return nil
+	}
+	tok, _ := oauthTokenFromContext(r.Context())
+	var out []string
+	for _, scope := range required {
+		if !slices.Contains(tok.Scopes, scope) {
+			out = append(out, scope)
+		}
+	}
+	return out
+}
+
+// response is the insufficient_scope error of a call to name.
+func (s *toolScopes) response(r *http.Request, id any, name string, missing []string) jsonrpcResponse {
+	resp := localizedErrors(r.Context()).response(id, errNameInsufficientScope, map[string]string{"name": name, "scopes": strings.Join(missing, " ")})
+	return withErrorData(resp, map[string]any{"scopes": missing})
+}
+
+// middleware guards a per-server route so clients talking to a server
+// directly need the same scopes as through the facade.
+func (s *toolScopes) middleware(enc JSONEncodingConfig, srv *Server, store *toolOverrideStore) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if s == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			var req jsonrpcRequest
+			if json.Unmarshal(body, &req) != nil || req.Method != "tools/call" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var p struct {
+				Name string `json:"name"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			missing := s.missing(r, srv, store.current(), p.Name)
+			if len(missing) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			clientLogger(srv.name).Warn("tools/call missing scopes", "tool", p.Name, "missing", missing)
+			w.Header().Set("Content-Type", "application/json")
+			_ = enc.encode(w, s.response(r, req.ID, p.Name, missing))
+		})
+	}
+}
+
+// is reports whether the tool is annotated with class; "" is every tool.
+func (h toolHints) is(class string) bool {
+	switch class {
+	case toolClassReadOnly:
+		return h.ReadOnly
+	case toolClassDestructive:
+		return h.Destructive
+	case toolClassIdempotent:
+		return h.Idempotent
+	case toolClassOpenWorld:
+		return h.OpenWorld
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolScopesRequired(t *testing.T) {
+	scopes, err := newToolScopes([]*ToolScopeRule{
+		{Tool: "*", Scopes: []string{"mcp:tools"}},
+		{Annotation: toolClassDestructive, Scopes: []string{"mcp:write"}},
+		{Server: "db", Tool: "drop_*", Scopes: []string{"db:admin", "mcp:write"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		server, tool string
+		hints        toolHints
+		want         []string
+	}{
+		{"fs", "read", toolHints{ReadOnly: true}, []string{"mcp:tools"}},
+		{"fs", "delete", toolHints{Destructive: true}, []string{"mcp:tools", "mcp:write"}},
+		{"db", "drop_table", toolHints{}, []string{"mcp:tools", "db:admin", "mcp:write"}},
+	}
+	for _, c := range cases {
+		if got := scopes.required(c.server, c.tool, c.hints); !slices.Equal(got, c.want) {
+			t.Errorf("required(%s, %s) = %v, want %v", c.server, c.tool, got, c.want)
+		}
+	}
+
+	for _, rule := range []*ToolScopeRule{
+		{Tool: "echo"},
+		{Tool: "[", Scopes: []string{"x"}},
+		{Annotation: "dangerous", Scopes: []string{"x"}},
+	} {
+		if _, err := newToolScopes([]*ToolScopeRule{rule}); err == nil {
+			t.Errorf("rule %+v accepted", rule)
+		}
+	}
+}
+
+func TestToolScopesMiddleware(t *testing.T) {
+	trueVal := true
+	srv := &Server{
+		name: "db",
+		tools: []mcp.Tool{
+			{Name: "query", Annotations: mcp.ToolAnnotation{ReadOnlyHint: &trueVal}},
+			{Name: "drop", Annotations: mcp.ToolAnnotation{DestructiveHint: &trueVal}},
+		},
+	}
+	scopes, err := newToolScopes([]*ToolScopeRule{{Annotation: toolClassDestructive, Scopes: []string{"db:write"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reached := 0
+	handler := scopes.middleware(JSONEncodingConfig{}, srv, newToolOverrideStore(nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+		w.WriteHeader(http.StatusOK)
+	}))
+	call := func(tool string, granted ...string) *httptest.ResponseRecorder {
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + tool + `"}}`
+		r := httptest.NewRequest(http.MethodPost, "/db/mcp", strings.NewReader(body))
+		if granted != nil {
+			r = r.WithContext(withOAuthToken(r.Context(), oauthToken{Subject: "alice", Scopes: granted}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	call("query")
+	call("drop", "db:read", "db:write")
+	if reached != 2 {
+		t.Fatalf("allowed calls reached=%d", reached)
+	}
+	for _, granted := range [][]string{nil, {"db:read"}} {
+		rec := call("drop", granted...)
+		var resp jsonrpcResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error == nil {
+			t.Fatalf("expected JSON-RPC error, got %s", rec.Body.String())
+		}
+		data, _ := resp.Error.Data.(map[string]any)
+		if resp.Error.Code != rpcCodeInsufficientScope || data["name"] != errNameInsufficientScope || !strings.Contains(resp.Error.Message, "db:write") {
+			t.Fatalf("unexpected error %+v", resp.Error)
+		}
+	}
+	if reached != 2 {
+		t.Fatalf("call without the scope reached the server")
+	}
+}
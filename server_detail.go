@@ -0,0 +1,188 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// serverErrorHistoryLimit is how many recent errors each server keeps.
+const serverErrorHistoryLimit = 20
+
+// Sources of server errors.
+const (
+	serverErrorConnect    = "connect"
+	serverErrorPing       = "ping"
+	serverErrorDisconnect = "disconnect"
+	serverErrorCall       = "call"
+)
+
+// serverErrorEntry is one error a downstream server caused or ran into.
+type serverErrorEntry struct {
+	At      time.Time `json:"at"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+}
+
+// serverErrorHistory keeps the latest errors of each downstream server for
+// the admin server detail view.
+type serverErrorHistory struct {
+	limit int
+
+	mu      sync.Mutex
+	servers map[string][]serverErrorEntry
+}
+
+func newServerErrorHistory(limit int) *serverErrorHistory {
+	return &serverErrorHistory{limit: limit, servers: make(map[string][]serverErrorEntry)}
+}
+
+// record adds an error to server's history, dropping the oldest past the
+// limit.
+func (h *serverErrorHistory) record(server, source, message string, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	list := append(h.servers[server], serverErrorEntry{At: at.UTC(), Source: source, Message: message})
+	if len(list) > h.limit {
+		list = append([]serverErrorEntry(nil), list[len(list)-h.limit:]...)
+	}
+	h.servers[server] = list
+}
+
+// recent returns server's errors, newest first.
+func (h *serverErrorHistory) recent(server string) []serverErrorEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	list := h.servers[server]
+	out := make([]serverErrorEntry, len(list))
+	for i, entry := range list {
+		out[len(list)-1-i] = entry
+	}
+	return out
+}
+
+// serverDetail is the GET /admin/servers/{name} view of one downstream
+// server. The initialize fields are those of the latest connection; uptime
+// is only reported while that connection is up.
+type serverDetail struct {
+	Name            string                  `json:"name"`
+	ServerInfo      *mcp.Implementation     `json:"serverInfo,omitempty"`
+	ProtocolVersion string                  `json:"protocolVersion,omitempty"`
+	Capabilities    *mcp.ServerCapabilities `json:"capabilities,omitempty"`
+	Instructions    string                  `json:"instructions,omitempty"`
+	ConnectedAt     *time.Time              `json:"connectedAt,omitempty"`
+	UptimeMs        int64                   `json:"uptimeMs,omitempty"`
+	Health          serverHealth            `json:"health"`
+	Reconnect       *reconnectState         `json:"reconnect,omitempty"`
+	RecentErrors    []serverErrorEntry      `json:"recentErrors"`
+}
+
+// newServerDetail assembles the detail view from what client captured at
+// initialize and the server's health, reconnect state, and errors.
+func newServerDetail(name string, client *Client, health serverHealth, reconnect *reconnectState, errs []serverErrorEntry, now time.Time) serverDetail {
+	detail := serverDetail{Name: name, Health: health, Reconnect: reconnect, RecentErrors: errs}
+	if client == nil {
+		return detail
+	}
+	result, connectedAt := client.initialized()
+	if result == nil {
+		return detail
+	}
+	detail.ServerInfo = &result.ServerInfo
+	detail.ProtocolVersion = result.ProtocolVersion
+	detail.Capabilities = &result.Capabilities
+	detail.Instructions = result.Instructions
+	detail.ConnectedAt, detail.UptimeMs = clientUptime(connectedAt, health.Status != serverStatusDown && reconnect == nil, now)
+	return detail
+}
+
+// clientUptime reports when a connection was made and, while it is up, for
+// how long.
+func clientUptime(connectedAt time.Time, up bool, now time.Time) (*time.Time, int64) {
+	connectedAt = connectedAt.UTC()
+	if !up {
+		return &connectedAt, 0
+	}
+	return &connectedAt, now.Sub(connectedAt).Milliseconds()
+}
+
+// registerServerDetailRoutes exposes the detail view of each configured
+// server; detail reports false for unknown names.
+func registerServerDetailRoutes(api *adminAPI, detail func(name string) (serverDetail, bool)) {
+	api.handle(http.MethodGet, "servers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		view, ok := detail(name)
+		if !ok {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown server", "server": name})
+			return
+		}
+		api.writeJSON(w, http.StatusOK, view)
+	})
+}
+
+// serverStats is one server's entry in GET /admin/servers. Calls, errors,
+// the error rate, and latency cover the metrics window; the totals cover
+// the proxy's lifetime.
+type serverStats struct {
+	Name            string     `json:"name"`
+	Status          string     `json:"status"`
+	ConnectedAt     *time.Time `json:"connectedAt,omitempty"`
+	UptimeMs        int64      `json:"uptimeMs,omitempty"`
+	Tools           int        `json:"tools"`
+	TotalCalls      uint64     `json:"totalCalls"`
+	TotalErrors     uint64     `json:"totalErrors"`
+	Calls           int        `json:"calls"`
+	Errors          int        `json:"errors"`
+	ErrorRate       float64    `json:"errorRate"`
+	P50Ms           int64      `json:"p50Ms"`
+	P95Ms           int64      `json:"p95Ms"`
+	Reconnects      int        `json:"reconnects"`
+	LastReconnectAt *time.Time `json:"lastReconnectAt,omitempty"`
+}
+
+// newServerStats assembles a server's stats from its client, health, tool
+// count, call usage, and reconnect history.
+func newServerStats(name string, client *Client, health serverHealth, reconnecting bool, tools int, usage serverUsage, reconnects reconnectRecord, now time.Time) serverStats {
+	stats := serverStats{
+		Name:        name,
+		Status:      health.Status,
+		Tools:       tools,
+		TotalCalls:  usage.TotalCalls,
+		TotalErrors: usage.TotalErrors,
+		Calls:       usage.Calls,
+		Errors:      usage.Errors,
+		P50Ms:       usage.P50.Milliseconds(),
+		P95Ms:       usage.P95.Milliseconds(),
+		Reconnects:  reconnects.Count,
+	}
+	if usage.Calls > 0 {
+		stats.ErrorRate = float64(usage.Errors) / float64(usage.Calls)
+	}
+	if client != nil {
+		if result, connectedAt := client.initialized(); result != nil {
+			stats.ConnectedAt, stats.UptimeMs = clientUptime(connectedAt, health.Status != serverStatusDown && !reconnecting, now)
+		}
+	}
+	if !reconnects.At.IsZero() {
+		at := reconnects.At.UTC()
+		stats.LastReconnectAt = &at
+	}
+	return stats
+}
+
+// registerServerStatsRoutes lists every configured server's stats, by name.
+// ?window= narrows the metrics window the call figures cover.
+func registerServerStatsRoutes(api *adminAPI, window time.Duration, stats func(since time.Time) []serverStats) {
+	api.handle(http.MethodGet, "servers", func(w http.ResponseWriter, r *http.Request) {
+		span := window
+		if v, err := time.ParseDuration(r.URL.Query().Get("window")); err == nil && v > 0 && v < span {
+			span = v
+		}
+		api.writeJSON(w, http.StatusOK, map[string]any{
+			"window":  span.String(),
+			"servers": stats(time.Now().Add(-span)),
+		})
+	})
+}
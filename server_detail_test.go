@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestServerErrorHistory(t *testing.T) {
+	h := newServerErrorHistory(3)
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		h.record("fs", serverErrorCall, fmt.Sprintf("error %d", i), start.Add(time.Duration(i)*time.Second))
+	}
+	got := h.recent("fs")
+	if len(got) != 3 || got[0].Message != "error 4" || got[2].Message != "error 2" {
+		t.Fatalf("recent = %+v", got)
+	}
+	if got := h.recent("git"); got == nil || len(got) != 0 {
+		t.Fatalf("unknown server = %#v", got)
+	}
+}
+
+func TestServerDetailRoute(t *testing.T) {
+	connectedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := &Client{name: "fs", connectedAt: connectedAt, initResult: &mcp.InitializeResult{
+		ProtocolVersion: "2025-06-18",
+		ServerInfo:      mcp.Implementation{Name: "filesystem", Version: "1.4.0"},
+		Capabilities: mcp.ServerCapabilities{Tools: &struct {
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{ListChanged: true}},
+		Instructions: "Paths are relative to /srv.",
+	}}
+	errs := newServerErrorHistory(serverErrorHistoryLimit)
+	errs.record("fs", serverErrorPing, "context deadline exceeded", connectedAt.Add(time.Minute))
+	var reconnect *reconnectState
+	status := serverStatusConnected
+
+	mux := http.NewServeMux()
+	registerServerDetailRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), func(name string) (serverDetail, bool) {
+		if name != "fs" {
+			return serverDetail{}, false
+		}
+		return newServerDetail(name, client, serverHealth{Server: name, Status: status}, reconnect, errs.recent(name), connectedAt.Add(time.Hour)), true
+	})
+	get := func(name string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/servers/"+name, nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var body map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	code, body := get("fs")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	info := body["serverInfo"].(map[string]any)
+	if info["version"] != "1.4.0" || body["protocolVersion"] != "2025-06-18" || body["instructions"] != "Paths are relative to /srv." {
+		t.Fatalf("detail = %v", body)
+	}
+	if body["capabilities"].(map[string]any)["tools"] == nil || body["uptimeMs"] != float64(time.Hour.Milliseconds()) {
+		t.Fatalf("capabilities or uptime = %v", body)
+	}
+	if errors := body["recentErrors"].([]any); len(errors) != 1 || errors[0].(map[string]any)["source"] != serverErrorPing {
+		t.Fatalf("recentErrors = %v", body["recentErrors"])
+	}
+
+	status, reconnect = serverStatusDown, &reconnectState{Server: "fs", Attempts: 2}
+	if _, body := get("fs"); body["uptimeMs"] != nil || body["reconnect"] == nil || body["connectedAt"] == nil {
+		t.Fatalf("down server detail = %v", body)
+	}
+	if code, _ := get("git"); code != http.StatusNotFound {
+		t.Fatalf("unknown server status = %d", code)
+	}
+}
+
+func TestServerStatsRoute(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	metrics := newCallMetrics(time.Hour)
+	for i := 1; i <= 20; i++ {
+		metrics.record(callKey{Server: "fs", Method: "tools/call", Target: "read"}, callSample{At: start, Duration: time.Duration(i) * 10 * time.Millisecond, Failed: i > 15})
+	}
+	metrics.record(callKey{Server: "fs", Method: "resources/read", Target: "file:///old"}, callSample{At: start.Add(-2 * time.Hour), Failed: true})
+	client := &Client{name: "fs", connectedAt: start, initResult: &mcp.InitializeResult{}}
+
+	usage := metrics.serverUsageReport(start.Add(-time.Hour))
+	if u := usage["fs"]; u.TotalCalls != 21 || u.TotalErrors != 6 || u.Calls != 20 || u.Errors != 5 || u.P50 != 110*time.Millisecond || u.P95 != 190*time.Millisecond {
+		t.Fatalf("usage = %+v", u)
+	}
+
+	mux := http.NewServeMux()
+	var since time.Time
+	registerServerStatsRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), time.Hour, func(s time.Time) []serverStats {
+		since = s
+		usage := metrics.serverUsageReport(start.Add(-time.Hour))
+		return []serverStats{
+			newServerStats("fs", client, serverHealth{Server: "fs", Status: serverStatusConnected}, false, 4, usage["fs"], reconnectRecord{Count: 2, At: start}, start.Add(time.Minute)),
+			newServerStats("git", nil, serverHealth{Server: "git", Status: serverStatusDown}, true, 0, usage["git"], reconnectRecord{}, start.Add(time.Minute)),
+		}
+	})
+	req := httptest.NewRequest(http.MethodGet, "/admin/servers?window=5m", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var body struct {
+		Window  string        `json:"window"`
+		Servers []serverStats `json:"servers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, %v", rec.Code, err)
+	}
+	if body.Window != "5m0s" || time.Since(since) < 5*time.Minute {
+		t.Fatalf("window = %s, since = %v", body.Window, since)
+	}
+	fs, git := body.Servers[0], body.Servers[1]
+	if fs.Tools != 4 || fs.Calls != 20 || fs.ErrorRate != 0.25 || fs.P95Ms != 190 || fs.UptimeMs != time.Minute.Milliseconds() || fs.Reconnects != 2 || fs.LastReconnectAt == nil {
+		t.Fatalf("fs = %+v", fs)
+	}
+	if git.Status != serverStatusDown || git.UptimeMs != 0 || git.ErrorRate != 0 || git.LastReconnectAt != nil {
+		t.Fatalf("git = %+v", git)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// serverEntry is one downstream server with the client that feeds it.
+type serverEntry struct {
+	server *Server
+	client *Client
+	config *MCPClientConfigV2
+	// handler serves the per-server route; nil until the client connects.
+	handler http.Handler
+	// cancel stops the client's background work (pings).
+	cancel context.CancelFunc
+	// down is set while the supervisor reconnects the server; down entries
+	// are left out of snapshots. Guarded by the registry's mu.
+	down bool
+}
+
+func (e *serverEntry) close() {
+	if e.cancel != nil {
+		e.cancel()
+	}
+	if e.client != nil {
+		if err := e.client.Close(); err != nil {
+			clientLogger(e.server.name).Warn("Failed to close client", "err", err)
+		}
+	}
+}
+
+// serverRegistry is the set of downstream servers the proxy serves. Servers
+// are added, replaced, and removed at runtime (admin apply), so readers take
+// a snapshot or look up one server instead of sharing a map. Per-server
+// routes are mounted on the mux once and resolve the current handler on each
+// request, since a ServeMux pattern cannot be unregistered.
+type serverRegistry struct {
+	mux      *http.ServeMux
+	basePath string
+
+	mu      sync.RWMutex
+	entries map[string]*serverEntry
+	mounted map[string]bool
+}
+
+func newServerRegistry(mux *http.ServeMux, basePath string) *serverRegistry {
+	return &serverRegistry{
+		mux:      mux,
+		basePath: basePath,
+		entries:  make(map[string]*serverEntry),
+		mounted:  make(map[string]bool),
+	}
+}
+
+func (r *serverRegistry) add(name string, entry *serverEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[name] = entry
+}
+
+func (r *serverRegistry) get(name string) *Server {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if entry := r.entries[name]; entry != nil {
+		return entry.server
+	}
+	return nil
+}
+
+func (r *serverRegistry) config(name string) *MCPClientConfigV2 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if entry := r.entries[name]; entry != nil {
+		return entry.config
+	}
+	return nil
+}
+
+// client returns name's current client, nil for unknown servers.
+func (r *serverRegistry) client(name string) *Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if entry := r.entries[name]; entry != nil {
+		return entry.client
+	}
+	return nil
+}
+
+// connected reports whether name's client connected and its route serves.
+func (r *serverRegistry) connected(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry := r.entries[name]
+	return entry != nil && entry.handler != nil && !entry.down
+}
+
+// markDown takes entry out of snapshots while it reconnects. It reports
+// false if name no longer maps to entry.
+func (r *serverRegistry) markDown(name string, entry *serverEntry) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.entries[name] != entry {
+		return false
+	}
+	entry.down = true
+	return true
+}
+
+func (r *serverRegistry) isCurrent(name string, entry *serverEntry) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.entries[name] == entry
+}
+
+// replace installs next for name if name still maps to entry.
+func (r *serverRegistry) replace(name string, entry, next *serverEntry) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.entries[name] != entry {
+		return false
+	}
+	r.entries[name] = next
+	return true
+}
+
+// snapshot returns the current servers that are up; the map is the
+// caller's.
+func (r *serverRegistry) snapshot() map[string]*Server {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]*Server, len(r.entries))
+	for name, entry := range r.entries {
+		if !entry.down {
+			out[name] = entry.server
+		}
+	}
+	return out
+}
+
+// configs returns the current server configs; the map is the caller's.
+func (r *serverRegistry) configs() map[string]*MCPClientConfigV2 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]*MCPClientConfigV2, len(r.entries))
+	for name, entry := range r.entries {
+		out[name] = entry.config
+	}
+	return out
+}
+
+func (r *serverRegistry) names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// activate makes a connected server reachable on its route.
+func (r *serverRegistry) activate(name string, handler http.Handler) error {
+	if err := r.mount(name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry := r.entries[name]; entry != nil {
+		entry.handler = handler
+	}
+	return nil
+}
+
+// serverNamePattern limits server names to a single path segment that
+// ServeMux takes literally: no slashes, wildcards, spaces, or dot segments.
+var serverNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// reservedServerNames are path segments the proxy serves itself.
+var reservedServerNames = []string{"admin", "mcp", "stream"}
+
+// validServerName reports whether name can be mounted as a server route.
+func validServerName(name string) error {
+	if !serverNamePattern.MatchString(name) || strings.Contains(name, "..") {
+		return fmt.Errorf("server name %q must match %s and not contain ..", name, serverNamePattern)
+	}
+	if slices.Contains(reservedServerNames, name) {
+		return fmt.Errorf("server name %q is reserved", name)
+	}
+	return nil
+}
+
+// mount registers the route for name unless it already is. Requests to a
+// mounted route without a connected server get 404.
+func (r *serverRegistry) mount(name string) (err error) {
+	if err := validServerName(name); err != nil {
+		return err
+	}
+	route := routeFor(r.basePath, name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mounted[route] {
+		return nil
+	}
+	// ServeMux panics on patterns that conflict with existing routes
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("server %s: route %s: %v", name, route, p)
+		}
+	}()
+	r.mux.Handle(route, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var handler http.Handler
+		if c := rolloutFromContext(req.Context()); c != nil && c.entries[name] != nil {
+			// facade sessions routed to a rollout candidate use its servers
+			handler = c.entries[name].handler
+		} else {
+			r.mu.RLock()
+			if entry := r.entries[name]; entry != nil {
+				handler = entry.handler
+			}
+			r.mu.RUnlock()
+		}
+		if handler == nil {
+			http.NotFound(w, req)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	r.mounted[route] = true
+	clientLogger(name).Info("Handling requests", "route", route)
+	return nil
+}
+
+// swap installs replacements and drops removed servers in one step, and
+// returns the entries they displaced for the caller to close.
+func (r *serverRegistry) swap(replacements map[string]*serverEntry, removed []string) []*serverEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var old []*serverEntry
+	for name, entry := range replacements {
+		if prev := r.entries[name]; prev != nil {
+			old = append(old, prev)
+		}
+		r.entries[name] = entry
+	}
+	for _, name := range removed {
+		if prev := r.entries[name]; prev != nil {
+			old = append(old, prev)
+			delete(r.entries, name)
+		}
+	}
+	return old
+}
+
+// catalogIndex maps tool names (and their aliases), prompt names, resource
+// URIs, and resource template URIs to the server that owns them.
+type catalogIndex struct {
+	// tools maps a tool name or alias to every server exposing it; which
+	// one serves a call is up to conflicts.
+	tools     map[string][]string
+	prompts   map[string]string
+	resources map[string]string
+	templates map[string]string
+	conflicts *toolConflictPolicy
+}
+
+func newCatalogIndex(conflicts *toolConflictPolicy, servers map[string]*Server, overrides *ToolOverrideSet) catalogIndex {
+	idx := catalogIndex{
+		conflicts: conflicts,
+		tools:     make(map[string][]string),
+		prompts:   make(map[string]string),
+		resources: make(map[string]string),
+		templates: make(map[string]string),
+	}
+	for name, srv := range servers {
+		idx.add(name, srv, overrides)
+	}
+	return idx
+}
+
+func (idx catalogIndex) add(name string, srv *Server, overrides *ToolOverrideSet) {
+	for _, t := range srv.tools {
+		idx.addTool(t.Name, name)
+		if overrides != nil {
+			if alias, ok := overrides.AliasForTool(t.Name); ok {
+				idx.addTool(alias, name)
+			}
+		}
+	}
+	for _, p := range srv.prompts {
+		idx.prompts[p.Name] = name
+	}
+	for _, res := range srv.resources {
+		idx.resources[res.URI] = name
+	}
+	for _, tpl := range srv.resourceTemplates {
+		if tpl.URITemplate != nil && tpl.URITemplate.Template != nil {
+			idx.templates[tpl.URITemplate.Raw()] = name
+		}
+	}
+}
+
+func (idx catalogIndex) addTool(key, server string) {
+	if !slices.Contains(idx.tools[key], server) {
+		idx.tools[key] = append(idx.tools[key], server)
+	}
+}
+
+// routeTool resolves a facade tool name under the conflict policy; include
+// filters servers whose copy the caller cannot use.
+func (idx catalogIndex) routeTool(name string, affinity callAffinity, include func(server, tool string) bool) (toolRoute, error) {
+	return idx.conflicts.routeFor(idx.tools, name, affinity, include)
+}
+
+// owner looks key up among tools, prompts, resources, or resource templates
+// by kind.
+func (idx catalogIndex) owner(kind, key string) (string, bool) {
+	var m map[string]string
+	switch kind {
+	case "tool":
+		route, err := idx.routeTool(key, callAffinity{}, nil)
+		return route.server, err == nil
+	case "prompt":
+		m = idx.prompts
+	case "resource":
+		m = idx.resources
+	case "template":
+		m = idx.templates
+	}
+	name, ok := m[key]
+	return name, ok
+}
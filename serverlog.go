@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogFileMaxSizeMB  = 10
+	defaultLogFileMaxBackups = 3
+)
+
+// rotatingFile is a size-capped log file: once a write would exceed maxSize it
+// shifts path -> path.1 -> path.2 ... keeping maxBackups old files.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	return nil
+}
+
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	for i := rf.maxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	if rf.maxBackups > 0 {
+		_ = os.Rename(rf.path, rf.path+".1")
+	} else {
+		_ = os.Remove(rf.path)
+	}
+	return rf.open()
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.file.Close()
+}
+
+// serverLog writes one server's downstream activity as JSON lines, with
+// the keys of mcpProxy.redaction and the built-in ones masked.
+type serverLog struct {
+	server string
+	out    io.WriteCloser
+	redact map[string]struct{}
+}
+
+type serverLogEntry struct {
+	At         time.Time `json:"at"`
+	Server     string    `json:"server"`
+	Kind       string    `json:"kind"`
+	Target     string    `json:"target,omitempty"`
+	DurationMs int64     `json:"durationMs,omitempty"`
+	Request    any       `json:"request,omitempty"`
+	Response   any       `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Line       string    `json:"line,omitempty"`
+}
+
+// resolveLogFilePath places relative paths under <state home>/logs and keeps
+// absolute ones inside the config or state home.
+func resolveLogFilePath(target string) (string, error) {
+	if !filepath.IsAbs(target) {
+		return mkdirAllUnder(stateHome(), filepath.Join(stateHome(), "logs", target))
+	}
+	if _, err := resolveGuardedPath(target); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Clean(target), nil
+}
+
+func openServerLog(name string, options *OptionsV2) (*serverLog, error) {
+	if options == nil || options.LogFile == "" {
+		return nil, nil
+	}
+	path, err := resolveLogFilePath(options.LogFile)
+	if err != nil {
+		return nil, fmt.Errorf("logFile %s: %w", options.LogFile, err)
+	}
+	maxSizeMB := options.LogFileMaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultLogFileMaxSizeMB
+	}
+	backups := options.LogFileMaxBackups
+	if backups <= 0 {
+		backups = defaultLogFileMaxBackups
+	}
+	rf, err := newRotatingFile(path, int64(maxSizeMB)<<20, backups)
+	if err != nil {
+		return nil, err
+	}
+	clientLogger(name).Info("Writing server log", "path", path)
+	return &serverLog{server: name, out: rf, redact: newRedactKeySet(nil)}, nil
+}
+
+func (l *serverLog) write(entry serverLogEntry) {
+	entry.At = time.Now().UTC()
+	entry.Server = l.server
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_, _ = l.out.Write(append(data, '\n'))
+}
+
+// record logs one downstream call; safe to call on a nil serverLog.
+func (l *serverLog) record(kind, target string, request, response any, callErr error, d time.Duration) {
+	if l == nil {
+		return
+	}
+	entry := serverLogEntry{Kind: kind, Target: target, DurationMs: d.Milliseconds(), Request: l.redacted(request), Response: l.redacted(response)}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+	l.write(entry)
+}
+
+// redacted encodes v for the log with sensitive members masked; nil, also
+// a nil result, is left out.
+func (l *serverLog) redacted(v any) any {
+	if v == nil {
+		return nil
+	}
+	data, err := redactedJSON(v, l.redact)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+// recordStderr logs one line of a stdio server's stderr; nil-safe.
+func (l *serverLog) recordStderr(line string) {
+	if l == nil {
+		return
+	}
+	l.write(serverLogEntry{Kind: "stderr", Line: line})
+}
+
+func (l *serverLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.out.Close()
+}
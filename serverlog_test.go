@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileRotatesAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "srv.log")
+	rf, err := newRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	for _, chunk := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := rf.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	_ = rf.Close()
+
+	want := map[string]string{path: "dddddddd\n", path + ".1": "cccccccc\n", path + ".2": "bbbbbbbb\n"}
+	for file, content := range want {
+		data, err := os.ReadFile(file)
+		if err != nil || string(data) != content {
+			t.Fatalf("%s = %q (%v), want %q", file, data, err, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only two backups, stat .3: %v", err)
+	}
+}
+
+func TestServerLogWritesJSONLines(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("STELAE_STATE_HOME", base)
+	configureRedaction(&RedactionConfig{Fields: []string{"ssn"}})
+	defer configureRedaction(nil)
+	l, err := openServerLog("fs", &OptionsV2{LogFile: "fs.log"})
+	if err != nil || l == nil {
+		t.Fatalf("openServerLog: %v", err)
+	}
+	l.record("tools/call", "read", map[string]any{"name": "read", "arguments": map[string]any{"apiKey": "k", "ssn": "123", "path": "/tmp"}}, nil, errors.New("boom"), 15*time.Millisecond)
+	drainStderr(strings.NewReader("warming cache\n"), &stderrTail{}, l)
+	_ = l.Close()
+
+	f, err := os.Open(filepath.Join(base, "logs", "fs.log"))
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	var entries []serverLogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e serverLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Kind != "tools/call" || entries[0].Target != "read" || entries[0].Error != "boom" || entries[0].DurationMs != 15 {
+		t.Fatalf("unexpected call entry %+v", entries[0])
+	}
+	args, _ := entries[0].Request.(map[string]any)["arguments"].(map[string]any)
+	if args["apiKey"] != redactedValue || args["ssn"] != redactedValue || args["path"] != "/tmp" || entries[0].Response != nil {
+		t.Fatalf("call entry not redacted: %+v", entries[0])
+	}
+	if entries[1].Kind != "stderr" || entries[1].Line != "warming cache" || entries[1].Server != "fs" {
+		t.Fatalf("unexpected stderr entry %+v", entries[1])
+	}
+}
+
+func TestOpenServerLogRejectsPathOutsideHomes(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	t.Setenv("STELAE_CONFIG_HOME", t.TempDir())
+	if _, err := openServerLog("fs", &OptionsV2{LogFile: filepath.Join(t.TempDir(), "x.log")}); err == nil {
+		t.Fatal("expected guarded path error")
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// replayIsolation marks facade requests re-run from a transcript. Unless
+// forced, calls to tools that are not read-only pass every policy check but
+// are not dispatched.
+type replayIsolation struct {
+	force   bool
+	skipped atomic.Int64
+}
+
+type replayIsolationKey struct{}
+
+func withReplayIsolation(ctx context.Context, iso *replayIsolation) context.Context {
+	return context.WithValue(ctx, replayIsolationKey{}, iso)
+}
+
+func replayIsolationFromContext(ctx context.Context) (*replayIsolation, bool) {
+	iso, ok := ctx.Value(replayIsolationKey{}).(*replayIsolation)
+	return iso, ok && iso != nil
+}
+
+// holds reports whether a call to tool on srv must not reach the server,
+// counting it as skipped.
+func (iso *replayIsolation) holds(srv *Server, overrides *ToolOverrideSet, tool string) bool {
+	if iso.force || readOnlyAllows(srv, overrides, tool) {
+		return false
+	}
+	iso.skipped.Add(1)
+	return true
+}
+
+// replaySkippedResult stands in for the result of a held call.
+func replaySkippedResult(tool string) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": "replay: " + tool + " is not read-only and was not called"}},
+	}
+}
+
+// Outcomes of a replayed request.
+const (
+	replayIdentical = "identical"
+	replayChanged   = "changed"
+	replaySkipped   = "skipped"
+)
+
+// sessionReplayStep compares one recorded request with its replay.
+// Recorded and replayed responses are included only when they differ.
+type sessionReplayStep struct {
+	Index    int        `json:"index"`
+	Method   string     `json:"method"`
+	Target   string     `json:"target,omitempty"`
+	Outcome  string     `json:"outcome"`
+	Reason   string     `json:"reason,omitempty"`
+	Redacted bool       `json:"redacted,omitempty"`
+	Diff     []jsonDiff `json:"diff,omitempty"`
+	Recorded any        `json:"recorded,omitempty"`
+	Replayed any        `json:"replayed,omitempty"`
+}
+
+type sessionReplayReport struct {
+	Session   string              `json:"session"`
+	Forced    bool                `json:"forced"`
+	Identical int                 `json:"identical"`
+	Changed   int                 `json:"changed"`
+	Skipped   int                 `json:"skipped"`
+	Steps     []sessionReplayStep `json:"steps"`
+}
+
+// sessionReplayer re-runs transcripts through the facade. dispatch sends
+// one message with header and returns the reply and its headers;
+// closeSession ends the facade session a replay opened.
+type sessionReplayer struct {
+	dispatch     func(ctx context.Context, header http.Header, msg []byte) ([]byte, http.Header)
+	closeSession func(id string)
+}
+
+// sessionReplayOptions are the knobs of one replay: Force lets calls to
+// tools that are not read-only through, and Scopes are the token scopes
+// the replay runs with.
+type sessionReplayOptions struct {
+	Force  bool     `json:"force"`
+	Scopes []string `json:"scopes"`
+}
+
+// readTranscript decodes a transcript export.
+func readTranscript(r io.Reader) ([]transcriptEntry, error) {
+	var entries []transcriptEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), int(defaultTranscriptMaxBytes))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry transcriptEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// replayTarget is the tool name, prompt name, or resource URI a request
+// names.
+func replayTarget(params json.RawMessage) string {
+	var p struct {
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	}
+	_ = json.Unmarshal(params, &p)
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.URI
+}
+
+// replay re-runs the requests and notifications of a session in order, in
+// a facade session of its own, and compares each reply with the recorded
+// one. Client replies to proxy requests are not replayed.
+func (s *sessionReplayer) replay(ctx context.Context, session string, entries []transcriptEntry, opts sessionReplayOptions) sessionReplayReport {
+	report := sessionReplayReport{Session: session, Forced: opts.Force, Steps: []sessionReplayStep{}}
+	iso := &replayIsolation{force: opts.Force}
+	ctx = withReplayIsolation(ctx, iso)
+	ctx = withOAuthToken(ctx, oauthToken{Subject: "admin:replay", ClientID: "admin:replay", Scopes: opts.Scopes})
+	replaySession := ""
+	defer func() {
+		if replaySession != "" {
+			s.closeSession(replaySession)
+		}
+	}()
+
+	for i, entry := range entries {
+		if entry.Kind != "request" && entry.Kind != "notification" {
+			continue
+		}
+		var req jsonrpcRequest
+		if json.Unmarshal(entry.Message, &req) != nil || req.Method == "" {
+			continue
+		}
+		header := http.Header{}
+		if replaySession != "" {
+			header.Set(sessionIDHeader, replaySession)
+		}
+		callCtx := withCallerInfo(ctx, callerInfo{Identity: "admin:replay", SessionID: replaySession, RequestID: "replay-" + session})
+		before := iso.skipped.Load()
+		reply, replyHeader := s.dispatch(callCtx, header, entry.Message)
+		if id := replyHeader.Get(sessionIDHeader); id != "" && replaySession == "" {
+			replaySession = id
+		}
+		if entry.Kind == "notification" {
+			continue
+		}
+
+		step := sessionReplayStep{
+			Index:    i,
+			Method:   req.Method,
+			Target:   replayTarget(req.Params),
+			Redacted: bytes.Contains(entry.Message, []byte(`"`+redactedValue+`"`)),
+		}
+		var recorded json.RawMessage
+		if i+1 < len(entries) && entries[i+1].Kind == "response" {
+			recorded = entries[i+1].Message
+		}
+		switch {
+		case iso.skipped.Load() > before:
+			step.Outcome, step.Reason = replaySkipped, "calls a tool that is not read-only"
+		case recorded == nil:
+			step.Outcome, step.Reason = replaySkipped, "no recorded response"
+		default:
+			recordedView, replayedView := comparableResponse(recorded), comparableResponse(reply)
+			step.Diff = diffJSON("", recordedView, replayedView)
+			step.Outcome = replayIdentical
+			if len(step.Diff) > 0 {
+				step.Outcome, step.Recorded, step.Replayed = replayChanged, recordedView, replayedView
+			}
+		}
+		switch step.Outcome {
+		case replayIdentical:
+			report.Identical++
+		case replayChanged:
+			report.Changed++
+		default:
+			report.Skipped++
+		}
+		report.Steps = append(report.Steps, step)
+	}
+	return report
+}
+
+// registerSessionReplayRoutes lets operators check a recorded session
+// against the current configuration.
+func registerSessionReplayRoutes(api *adminAPI, transcripts *transcriptRecorder, replayer *sessionReplayer) {
+	if transcripts == nil {
+		return
+	}
+	api.handle(http.MethodPost, "transcripts/{session}/replay", func(w http.ResponseWriter, r *http.Request) {
+		session := r.PathValue("session")
+		var opts sessionReplayOptions
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+				return
+			}
+		}
+		var buf bytes.Buffer
+		found, err := transcripts.export(session, &buf)
+		if err != nil {
+			api.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !found {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "no transcript for session", "session": session})
+			return
+		}
+		entries, err := readTranscript(&buf)
+		if err != nil {
+			api.writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "unreadable transcript: " + err.Error(), "session": session})
+			return
+		}
+		report := replayer.replay(r.Context(), session, entries, opts)
+		logger("admin").Info("replayed session", "session", session, "forced", opts.Force, "identical", report.Identical, "changed", report.Changed, "skipped", report.Skipped)
+		api.writeJSON(w, http.StatusOK, report)
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSessionReplayRoutes(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	transcripts, err := newTranscriptRecorder(&TranscriptConfig{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	record := func(method, request, response string) {
+		entries := []transcriptEntry{{At: now, Kind: "request", Method: method, Message: json.RawMessage(request)}}
+		if response != "" {
+			entries = append(entries, transcriptEntry{At: now, Kind: "response", Method: method, Status: http.StatusOK, Message: json.RawMessage(response)})
+		} else {
+			entries[0].Kind = "notification"
+		}
+		transcripts.record("sess-1", entries...)
+	}
+	record("initialize", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18"}}`)
+	record("notifications/initialized", `{"jsonrpc":"2.0","method":"notifications/initialized"}`, "")
+	record("tools/call", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"query","arguments":{"q":"a"}}}`, `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"1 row"}]}}`)
+	record("tools/call", `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"query","arguments":{"q":"b"}}}`, `{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"2 rows"}]}}`)
+	record("tools/call", `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"drop","arguments":{"password":"x"}}}`, `{"jsonrpc":"2.0","id":4,"result":{"content":[]}}`)
+
+	trueVal := true
+	srv := &Server{name: "db", tools: []mcp.Tool{
+		{Name: "query", Annotations: mcp.ToolAnnotation{ReadOnlyHint: &trueVal}},
+		{Name: "drop"},
+	}}
+	// a stand-in for the facade: "b" now matches one row
+	var dispatched []string
+	var sessions []string
+	var closed string
+	replayer := &sessionReplayer{
+		dispatch: func(ctx context.Context, header http.Header, msg []byte) ([]byte, http.Header) {
+			var req jsonrpcRequest
+			_ = json.Unmarshal(msg, &req)
+			sessions = append(sessions, header.Get(sessionIDHeader))
+			out := http.Header{}
+			var p struct {
+				Name      string            `json:"name"`
+				Arguments map[string]string `json:"arguments"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			var result any
+			switch req.Method {
+			case "initialize":
+				out.Set(sessionIDHeader, "replay-1")
+				result = map[string]any{"protocolVersion": "2025-06-18"}
+			case "tools/call":
+				if iso, ok := replayIsolationFromContext(ctx); ok && iso.holds(srv, nil, p.Name) {
+					result = replaySkippedResult(p.Name)
+					break
+				}
+				dispatched = append(dispatched, p.Name)
+				result = map[string]any{"content": []map[string]any{{"type": "text", "text": "1 row"}}}
+			default:
+				return nil, out
+			}
+			reply, _ := json.Marshal(rpcOK(req.ID, result))
+			return reply, out
+		},
+		closeSession: func(id string) { closed = id },
+	}
+	mux := http.NewServeMux()
+	registerSessionReplayRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), transcripts, replayer)
+	replay := func(session, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/transcripts/"+session+"/replay", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := replay("sess-1", "")
+	var report sessionReplayReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("replay: %d %s", w.Code, w.Body.String())
+	}
+	if report.Identical != 2 || report.Changed != 1 || report.Skipped != 1 || len(report.Steps) != 4 {
+		t.Fatalf("report = %+v", report)
+	}
+	changed, held := report.Steps[2], report.Steps[3]
+	if changed.Outcome != replayChanged || changed.Target != "query" || len(changed.Diff) != 1 || changed.Diff[0].Path != "$.result.content[0].text" {
+		t.Fatalf("changed step = %+v", changed)
+	}
+	if held.Outcome != replaySkipped || held.Target != "drop" || !held.Redacted {
+		t.Fatalf("held step = %+v", held)
+	}
+	if strings.Join(dispatched, ",") != "query,query" {
+		t.Fatalf("dispatched %v", dispatched)
+	}
+	// the replay runs in a session of its own, closed afterwards
+	if sessions[0] != "" || sessions[1] != "replay-1" || closed != "replay-1" {
+		t.Fatalf("sessions %v, closed %q", sessions, closed)
+	}
+
+	dispatched = nil
+	if err := json.Unmarshal(replay("sess-1", `{"force":true}`).Body.Bytes(), &report); err != nil || !report.Forced || report.Skipped != 0 {
+		t.Fatalf("forced report = %+v", report)
+	}
+	if strings.Join(dispatched, ",") != "query,query,drop" {
+		t.Fatalf("forced replay dispatched %v", dispatched)
+	}
+	if w := replay("nope", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown session: %d", w.Code)
+	}
+	if w := replay("sess-1", `{"force":`); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad body: %d", w.Code)
+	}
+}
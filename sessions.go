@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const defaultSessionIdleTimeout = time.Hour
+
+// facadeSession is one Mcp-Session-Id the facade has issued.
+type facadeSession struct {
+	ID        string    `json:"id"`
+	Transport string    `json:"transport"`
+	Caller    string    `json:"caller,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Streams   int       `json:"streams"`
+	// Sampling is set when the client declared the sampling capability.
+	Sampling bool `json:"sampling,omitempty"`
+	// Elicitation is set when the client declared the elicitation
+	// capability.
+	Elicitation bool `json:"elicitation,omitempty"`
+	// Roots is set when the client declared the roots capability.
+	Roots bool `json:"roots,omitempty"`
+	// Client is what the client said about itself at initialize.
+	Client *sessionClient `json:"client,omitempty"`
+	// Omit lists the features client profiles leave out for the session.
+	Omit []string `json:"omit,omitempty"`
+	// rootList caches the client's roots once rootsKnown.
+	rootList   []mcp.Root
+	rootsKnown bool
+}
+
+// facadeSessionTable tracks the session ids minted on initialize and on
+// stream opens. Sessions with no open stream expire after the idle timeout;
+// clients then get 404 and are expected to initialize again.
+type facadeSessionTable struct {
+	mu       sync.Mutex
+	sessions map[string]*facadeSession
+	idle     time.Duration
+	now      func() time.Time
+	// ended, when set, is called with t.mu held for each session that is
+	// closed or expires; it must not call back into the table.
+	ended func(id string)
+}
+
+func newFacadeSessionTable(idle time.Duration) *facadeSessionTable {
+	if idle <= 0 {
+		idle = defaultSessionIdleTimeout
+	}
+	return &facadeSessionTable{
+		sessions: make(map[string]*facadeSession),
+		idle:     idle,
+		now:      time.Now,
+	}
+}
+
+// open mints a new session id.
+func (t *facadeSessionTable) open(transport, caller string) string {
+	now := t.now()
+	id := uuid.New().String()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(now)
+	t.sessions[id] = &facadeSession{ID: id, Transport: transport, Caller: caller, CreatedAt: now.UTC(), LastSeen: now.UTC()}
+	return id
+}
+
+// touch reports whether id is a live session and marks it used.
+func (t *facadeSessionTable) touch(id string) bool {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	if !ok {
+		return false
+	}
+	if t.expired(s, now) {
+		t.end(id)
+		return false
+	}
+	s.LastSeen = now.UTC()
+	return true
+}
+
+// attach keeps a session alive while a stream (SSE, WebSocket) is open.
+// The returned release must be called when the stream ends.
+func (t *facadeSessionTable) attach(id string) (release func()) {
+	t.mu.Lock()
+	if s, ok := t.sessions[id]; ok {
+		s.Streams++
+	}
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if s, ok := t.sessions[id]; ok {
+			s.Streams--
+			s.LastSeen = t.now().UTC()
+		}
+	}
+}
+
+// setClient records the client of id and the features omitted for it.
+func (t *facadeSessionTable) setClient(id string, client sessionClient, omit []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[id]; ok {
+		s.Client, s.Omit = &client, omit
+	}
+}
+
+// omits reports whether feature is omitted for the session id.
+func (t *facadeSessionTable) omits(id, feature string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	return ok && slices.Contains(s.Omit, feature)
+}
+
+// transport returns the transport session id was opened on, or "" for
+// unknown sessions.
+func (t *facadeSessionTable) transport(id string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[id]; ok {
+		return s.Transport
+	}
+	return ""
+}
+
+// setSampling records whether the client of id declared sampling.
+func (t *facadeSessionTable) setSampling(id string, sampling bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[id]; ok {
+		s.Sampling = sampling
+	}
+}
+
+// sampling reports whether id is a session whose client declared sampling.
+func (t *facadeSessionTable) sampling(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	return ok && s.Sampling
+}
+
+// setElicitation records whether the client of id declared elicitation.
+func (t *facadeSessionTable) setElicitation(id string, elicitation bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[id]; ok {
+		s.Elicitation = elicitation
+	}
+}
+
+// elicitation reports whether id is a session whose client declared
+// elicitation.
+func (t *facadeSessionTable) elicitation(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	return ok && s.Elicitation
+}
+
+// setRoots records whether the client of id declared roots and drops any
+// roots cached for it.
+func (t *facadeSessionTable) setRoots(id string, roots bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[id]; ok {
+		s.Roots = roots
+		s.rootList, s.rootsKnown = nil, false
+	}
+}
+
+// cachedRoots returns the roots the client of id last reported.
+func (t *facadeSessionTable) cachedRoots(id string) ([]mcp.Root, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	if !ok || !s.rootsKnown {
+		return nil, false
+	}
+	return s.rootList, true
+}
+
+// storeRoots caches the roots the client of id reported.
+func (t *facadeSessionTable) storeRoots(id string, roots []mcp.Root) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[id]; ok {
+		s.rootList, s.rootsKnown = roots, true
+	}
+}
+
+// forgetRoots drops the roots cached for id.
+func (t *facadeSessionTable) forgetRoots(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[id]; ok {
+		s.rootList, s.rootsKnown = nil, false
+	}
+}
+
+// close terminates a session; it reports whether the session existed.
+func (t *facadeSessionTable) close(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.sessions[id]
+	if ok {
+		t.end(id)
+	}
+	return ok
+}
+
+// closeAs closes id for caller, the identity asking to end it. found is
+// false when id is not live; owned is false, and the session stays open,
+// when another caller opened it.
+func (t *facadeSessionTable) closeAs(id, caller string) (found, owned bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	switch {
+	case !ok:
+		return false, false
+	case s.Caller != caller:
+		return true, false
+	}
+	t.end(id)
+	return true, true
+}
+
+// list returns the live sessions, newest first.
+func (t *facadeSessionTable) list() []facadeSession {
+	now := t.now()
+	t.mu.Lock()
+	t.prune(now)
+	out := make([]facadeSession, 0, len(t.sessions))
+	for _, s := range t.sessions {
+		out = append(out, *s)
+	}
+	t.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (t *facadeSessionTable) expired(s *facadeSession, now time.Time) bool {
+	return s.Streams == 0 && now.Sub(s.LastSeen) > t.idle
+}
+
+// prune must be called with t.mu held.
+func (t *facadeSessionTable) prune(now time.Time) {
+	for id, s := range t.sessions {
+		if t.expired(s, now) {
+			t.end(id)
+		}
+	}
+}
+
+// end must be called with t.mu held.
+func (t *facadeSessionTable) end(id string) {
+	delete(t.sessions, id)
+	if t.ended != nil {
+		t.ended(id)
+	}
+}
+
+// isInitializeRequest reports whether body is a single initialize request,
+// the only message allowed to arrive without (or with a stale) session id.
+func isInitializeRequest(body []byte) bool {
+	var req struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(body, &req) == nil && req.Method == "initialize"
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFacadeSessionTable(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	table := newFacadeSessionTable(time.Minute)
+	table.now = func() time.Time { return now }
+
+	id := table.open("streamable-http", "token:abc")
+	if !table.touch(id) {
+		t.Fatalf("expected fresh session to be live")
+	}
+	if table.touch("unknown") {
+		t.Fatalf("expected unknown session to be rejected")
+	}
+
+	streamed := table.open("sse", "anonymous")
+	release := table.attach(streamed)
+
+	now = now.Add(2 * time.Minute)
+	if table.touch(id) {
+		t.Fatalf("expected idle session to expire")
+	}
+	if !table.touch(streamed) {
+		t.Fatalf("expected session with an open stream to stay live")
+	}
+	release()
+	if got := table.list(); len(got) != 1 || got[0].ID != streamed || got[0].Streams != 0 {
+		t.Fatalf("unexpected sessions %+v", got)
+	}
+
+	if found, owned := table.closeAs(streamed, "token:abc"); !found || owned {
+		t.Fatalf("closeAs by another caller = %t, %t", found, owned)
+	}
+	if found, owned := table.closeAs(streamed, "anonymous"); !found || !owned {
+		t.Fatalf("closeAs by the owner = %t, %t", found, owned)
+	}
+	if found, _ := table.closeAs(streamed, "anonymous"); found {
+		t.Fatalf("expected a closed session to be gone")
+	}
+	streamed = table.open("sse", "anonymous")
+	if !table.close(streamed) || table.close(streamed) {
+		t.Fatalf("expected close to succeed exactly once")
+	}
+	if table.touch(streamed) {
+		t.Fatalf("expected closed session to be rejected")
+	}
+}
+
+func TestFacadeSessionTableReportsEndedSessions(t *testing.T) {
+	table := newFacadeSessionTable(time.Minute)
+	now := time.Unix(1000, 0)
+	table.now = func() time.Time { return now }
+	var ended []string
+	table.ended = func(id string) { ended = append(ended, id) }
+
+	closed, idle := table.open("streamable-http", ""), table.open("streamable-http", "")
+	table.close(closed)
+	table.close(closed)
+	now = now.Add(2 * time.Minute)
+	if table.touch(idle) {
+		t.Fatal("idle session should have expired")
+	}
+	if len(ended) != 2 || ended[0] != closed || ended[1] != idle {
+		t.Fatalf("ended = %v", ended)
+	}
+}
+
+func TestIsInitializeRequest(t *testing.T) {
+	if !isInitializeRequest([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`)) {
+		t.Fatalf("expected initialize to be recognised")
+	}
+	for _, body := range []string{`{"method":"tools/list"}`, `[{"method":"initialize"}]`, `not json`} {
+		if isInitializeRequest([]byte(body)) {
+			t.Fatalf("did not expect %s to count as initialize", body)
+		}
+	}
+}
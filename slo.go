@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSLOWindow     = 5 * time.Minute
+	defaultSLOBurnRate   = 2.0
+	defaultSLOMinSamples = 10
+)
+
+// SLOConfig declares objectives for a tool or a whole server. Server and Tool
+// act as filters; an empty value matches everything.
+type SLOConfig struct {
+	Name              string        `json:"name,omitempty"`
+	Server            string        `json:"server,omitempty"`
+	Tool              string        `json:"tool,omitempty"`
+	Latency           time.Duration `json:"latency,omitempty"`
+	LatencyObjective  float64       `json:"latencyObjective,omitempty"`
+	ErrorObjective    float64       `json:"errorObjective,omitempty"`
+	Window            time.Duration `json:"window,omitempty"`
+	BurnRateThreshold float64       `json:"burnRateThreshold,omitempty"`
+	MinSamples        int           `json:"minSamples,omitempty"`
+}
+
+func (c *SLOConfig) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	server, tool := c.Server, c.Tool
+	if server == "" {
+		server = "*"
+	}
+	if tool == "" {
+		tool = "*"
+	}
+	return fmt.Sprintf("%s/%s", server, tool)
+}
+
+func (c *SLOConfig) matches(key callKey) bool {
+	if key.Method != "tools/call" {
+		return false
+	}
+	return (c.Server == "" || c.Server == key.Server) && (c.Tool == "" || c.Tool == key.Target)
+}
+
+type sloStatus struct {
+	Name          string  `json:"name"`
+	Samples       int     `json:"samples"`
+	ErrorRate     float64 `json:"errorRate"`
+	SlowRate      float64 `json:"slowRate"`
+	ErrorBurnRate float64 `json:"errorBurnRate"`
+	SlowBurnRate  float64 `json:"slowBurnRate"`
+	Compliant     bool    `json:"compliant"`
+	Alerting      bool    `json:"alerting"`
+}
+
+// sloBuckets is how many slices of its window each SLO counts calls in.
+const sloBuckets = 60
+
+// sloCounts are the calls an SLO counted, and how many of them failed or
+// were slow.
+type sloCounts struct {
+	calls  int
+	failed int
+	slow   int
+}
+
+func (c *sloCounts) add(slo *SLOConfig, sample callSample) {
+	c.calls++
+	if sample.Failed {
+		c.failed++
+	}
+	if slo.Latency > 0 && sample.Duration > slo.Latency {
+		c.slow++
+	}
+}
+
+type sloBucket struct {
+	index int64
+	sloCounts
+}
+
+// sloSeries counts one SLO's calls per slice of its window.
+type sloSeries struct {
+	slo     *SLOConfig
+	window  time.Duration
+	buckets [sloBuckets]sloBucket
+}
+
+func (s *sloSeries) width() int64 {
+	return max(int64(s.window/sloBuckets), 1)
+}
+
+func (s *sloSeries) add(sample callSample) {
+	index := sample.At.UnixNano() / s.width()
+	b := &s.buckets[index%sloBuckets]
+	if b.index > index {
+		return
+	}
+	if b.index < index {
+		*b = sloBucket{index: index}
+	}
+	b.add(s.slo, sample)
+}
+
+// counts sums the slices of the window ending at now.
+func (s *sloSeries) counts(now time.Time) sloCounts {
+	last := now.UnixNano() / s.width()
+	var out sloCounts
+	for _, b := range s.buckets {
+		if b.index > last-sloBuckets && b.index <= last {
+			out.calls += b.calls
+			out.failed += b.failed
+			out.slow += b.slow
+		}
+	}
+	return out
+}
+
+// sloTracker evaluates configured SLOs against the tools/call results it
+// observes, counted per SLO in fixed slices of the SLO's window, and emits
+// slo.burn / slo.recovered events on alert state transitions.
+type sloTracker struct {
+	mu       sync.Mutex
+	series   []*sloSeries
+	events   *eventBus
+	alerting map[string]bool
+}
+
+func newSLOTracker(slos []*SLOConfig, events *eventBus) *sloTracker {
+	series := make([]*sloSeries, 0, len(slos))
+	for _, slo := range slos {
+		if slo == nil {
+			continue
+		}
+		window := slo.Window
+		if window <= 0 {
+			window = defaultSLOWindow
+		}
+		series = append(series, &sloSeries{slo: slo, window: window})
+	}
+	return &sloTracker{series: series, events: events, alerting: make(map[string]bool)}
+}
+
+func evaluateSLO(slo *SLOConfig, counts sloCounts) sloStatus {
+	status := sloStatus{Name: slo.label(), Samples: counts.calls, Compliant: true}
+	if counts.calls == 0 {
+		return status
+	}
+	n := float64(counts.calls)
+	status.ErrorRate = float64(counts.failed) / n
+	status.SlowRate = float64(counts.slow) / n
+	if slo.ErrorObjective > 0 && slo.ErrorObjective < 1 {
+		status.ErrorBurnRate = status.ErrorRate / (1 - slo.ErrorObjective)
+		if status.ErrorRate > 1-slo.ErrorObjective {
+			status.Compliant = false
+		}
+	}
+	if slo.Latency > 0 && slo.LatencyObjective > 0 && slo.LatencyObjective < 1 {
+		status.SlowBurnRate = status.SlowRate / (1 - slo.LatencyObjective)
+		if status.SlowRate > 1-slo.LatencyObjective {
+			status.Compliant = false
+		}
+	}
+	return status
+}
+
+// observe counts a call to key toward the SLOs it matches and re-evaluates
+// them.
+func (t *sloTracker) observe(key callKey, sample callSample) {
+	if t == nil || len(t.series) == 0 {
+		return
+	}
+	for _, s := range t.series {
+		slo := s.slo
+		if !slo.matches(key) {
+			continue
+		}
+		threshold := slo.BurnRateThreshold
+		if threshold <= 0 {
+			threshold = defaultSLOBurnRate
+		}
+		minSamples := slo.MinSamples
+		if minSamples <= 0 {
+			minSamples = defaultSLOMinSamples
+		}
+
+		t.mu.Lock()
+		s.add(sample)
+		status := evaluateSLO(slo, s.counts(sample.At))
+		burning := status.Samples >= minSamples && (status.ErrorBurnRate >= threshold || status.SlowBurnRate >= threshold)
+		was := t.alerting[status.Name]
+		t.alerting[status.Name] = burning
+		t.mu.Unlock()
+
+		if burning == was {
+			continue
+		}
+		eventType := "slo.recovered"
+		if burning {
+			eventType = "slo.burn"
+		}
+		t.events.emit(eventType, map[string]any{
+			"slo":           status.Name,
+			"server":        key.Server,
+			"tool":          key.Target,
+			"samples":       status.Samples,
+			"errorRate":     status.ErrorRate,
+			"slowRate":      status.SlowRate,
+			"errorBurnRate": status.ErrorBurnRate,
+			"slowBurnRate":  status.SlowBurnRate,
+			"threshold":     threshold,
+		})
+	}
+}
+
+func (t *sloTracker) snapshot(now time.Time) []sloStatus {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]sloStatus, 0, len(t.series))
+	for _, s := range t.series {
+		status := evaluateSLO(s.slo, s.counts(now))
+		status.Alerting = t.alerting[status.Name]
+		out = append(out, status)
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateSLOBurnRates(t *testing.T) {
+	slo := &SLOConfig{Tool: "search", Latency: 100 * time.Millisecond, LatencyObjective: 0.9, ErrorObjective: 0.95}
+	samples := []callSample{
+		{Duration: 50 * time.Millisecond},
+		{Duration: 50 * time.Millisecond},
+		{Duration: 200 * time.Millisecond},
+		{Duration: 50 * time.Millisecond, Failed: true},
+	}
+	var counts sloCounts
+	for _, s := range samples {
+		counts.add(slo, s)
+	}
+	status := evaluateSLO(slo, counts)
+	if status.Samples != 4 {
+		t.Fatalf("samples = %d, want 4", status.Samples)
+	}
+	if status.ErrorRate != 0.25 || status.SlowRate != 0.25 {
+		t.Fatalf("unexpected rates: error=%v slow=%v", status.ErrorRate, status.SlowRate)
+	}
+	if status.Compliant {
+		t.Fatalf("expected SLO to be out of compliance")
+	}
+	if status.ErrorBurnRate < 4.9 || status.ErrorBurnRate > 5.1 {
+		t.Fatalf("error burn rate = %v, want ~5", status.ErrorBurnRate)
+	}
+}
+
+func TestSLOTrackerTransitionsAlertState(t *testing.T) {
+	slo := &SLOConfig{Server: "alpha", ErrorObjective: 0.9, MinSamples: 2}
+	tracker := newSLOTracker([]*SLOConfig{slo}, nil)
+	key := callKey{Server: "alpha", Method: "tools/call", Target: "echo"}
+	now := time.Now()
+
+	tracker.observe(key, callSample{At: now, Duration: time.Millisecond, Failed: true})
+	tracker.observe(key, callSample{At: now, Duration: time.Millisecond, Failed: true})
+	if snap := tracker.snapshot(now); len(snap) != 1 || !snap[0].Alerting {
+		t.Fatalf("expected alerting after failures, got %+v", snap)
+	}
+
+	for i := 0; i < 50; i++ {
+		tracker.observe(key, callSample{At: now, Duration: time.Millisecond})
+	}
+	if snap := tracker.snapshot(now); snap[0].Alerting {
+		t.Fatalf("expected recovery after successes, got %+v", snap)
+	}
+
+	// calls age out of the window
+	if snap := tracker.snapshot(now.Add(defaultSLOWindow + time.Minute)); snap[0].Samples != 0 {
+		t.Fatalf("expected an empty window, got %+v", snap)
+	}
+}
+
+func TestSLOIgnoresOtherMethods(t *testing.T) {
+	slo := &SLOConfig{Server: "alpha"}
+	if slo.matches(callKey{Server: "alpha", Method: "resources/read", Target: "x"}) {
+		t.Fatalf("expected SLOs to track tools/call only")
+	}
+}
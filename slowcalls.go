@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultSlowCallExcerptBytes = 2048
+	slowCallFileMaxSize         = 10 << 20
+	slowCallFileMaxBackups      = 3
+)
+
+// SlowCallConfig logs dispatched calls slower than a threshold, with redacted
+// and size-capped argument/result excerpts, to a diagnostics file.
+type SlowCallConfig struct {
+	Threshold       time.Duration   `json:"threshold,omitempty"`
+	File            string          `json:"file,omitempty"`
+	MaxExcerptBytes int             `json:"maxExcerptBytes,omitempty"`
+	RedactKeys      []string        `json:"redactKeys,omitempty"`
+	Rules           []*SlowCallRule `json:"rules,omitempty"`
+}
+
+// SlowCallRule overrides the threshold for a server, a tool, or both.
+type SlowCallRule struct {
+	Server    string        `json:"server,omitempty"`
+	Tool      string        `json:"tool,omitempty"`
+	Threshold time.Duration `json:"threshold"`
+}
+
+type slowCallEntry struct {
+	At          time.Time `json:"at"`
+	Method      string    `json:"method"`
+	Server      string    `json:"server"`
+	Target      string    `json:"target"`
+	DurationMs  int64     `json:"durationMs"`
+	ThresholdMs int64     `json:"thresholdMs"`
+	Caller      string    `json:"caller,omitempty"`
+	RequestID   string    `json:"requestId,omitempty"`
+	Params      string    `json:"params,omitempty"`
+	Response    string    `json:"response,omitempty"`
+}
+
+type slowCallLogger struct {
+	conf       *SlowCallConfig
+	maxExcerpt int
+	redactKeys map[string]struct{}
+	out        *rotatingFile
+}
+
+func newSlowCallLogger(conf *SlowCallConfig) (*slowCallLogger, error) {
+	if conf == nil || (conf.Threshold <= 0 && len(conf.Rules) == 0) {
+		return nil, nil
+	}
+	target := conf.File
+	if target == "" {
+		target = filepath.Join(stateHome(), "diagnostics", "slow_calls.jsonl")
+	}
+	path, err := resolveLogFilePath(target)
+	if err != nil {
+		return nil, fmt.Errorf("slowCalls.file %s: %w", target, err)
+	}
+	out, err := newRotatingFile(path, slowCallFileMaxSize, slowCallFileMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	maxExcerpt := conf.MaxExcerptBytes
+	if maxExcerpt <= 0 {
+		maxExcerpt = defaultSlowCallExcerptBytes
+	}
+	logger("diagnostics").Info("Logging slow calls", "path", path)
+	return &slowCallLogger{conf: conf, maxExcerpt: maxExcerpt, redactKeys: newRedactKeySet(conf.RedactKeys), out: out}, nil
+}
+
+// thresholdFor picks the most specific rule: server+tool, then tool, then
+// server, then the global threshold. Zero disables logging for the target.
+func (l *slowCallLogger) thresholdFor(server, target string) time.Duration {
+	best, bestScore := l.conf.Threshold, 0
+	for _, rule := range l.conf.Rules {
+		if rule == nil || (rule.Server != "" && rule.Server != server) || (rule.Tool != "" && rule.Tool != target) {
+			continue
+		}
+		score := 1
+		if rule.Tool != "" {
+			score += 2
+		}
+		if rule.Server != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rule.Threshold, score
+		}
+	}
+	return best
+}
+
+// due reports whether a call to target on server that has run for d is
+// slow enough to be logged.
+func (l *slowCallLogger) due(server, target string, d time.Duration) bool {
+	if l == nil {
+		return false
+	}
+	threshold := l.thresholdFor(server, target)
+	return threshold > 0 && d >= threshold
+}
+
+// excerpt redacts a JSON payload and caps it at maxExcerpt bytes.
+func (l *slowCallLogger) excerpt(raw []byte) string {
+	return redactedExcerpt(raw, l.redactKeys, l.maxExcerpt)
+}
+
+// redactedExcerpt redacts a JSON payload, drops its top-level _meta, and
+// caps it at max bytes.
+func redactedExcerpt(raw []byte, keys map[string]struct{}, max int) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	text := string(raw)
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err == nil {
+		if m, ok := decoded.(map[string]any); ok {
+			delete(m, "_meta")
+		}
+		if data, err := json.Marshal(redactValue(decoded, keys)); err == nil {
+			text = string(data)
+		}
+	}
+	if len(text) > max {
+		return fmt.Sprintf("%s…(truncated %d bytes)", text[:max], len(text)-max)
+	}
+	return text
+}
+
+// observe records the call when it ran longer than its threshold.
+func (l *slowCallLogger) observe(ctx context.Context, method, server, target string, params json.RawMessage, response []byte, d time.Duration) {
+	if l == nil {
+		return
+	}
+	threshold := l.thresholdFor(server, target)
+	if threshold <= 0 || d < threshold {
+		return
+	}
+	entry := slowCallEntry{
+		At:          time.Now().UTC(),
+		Method:      method,
+		Server:      server,
+		Target:      target,
+		DurationMs:  d.Milliseconds(),
+		ThresholdMs: threshold.Milliseconds(),
+		Params:      l.excerpt(params),
+		Response:    l.excerpt(response),
+	}
+	if info, ok := callerFromContext(ctx); ok {
+		entry.Caller = info.Identity
+		entry.RequestID = info.RequestID
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_, _ = l.out.Write(append(data, '\n'))
+	logger("diagnostics").Warn("slow call", "method", method, "target", target, "server", server, "took", d, "threshold", threshold)
+}
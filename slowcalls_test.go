@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowCallThresholdPrefersMostSpecificRule(t *testing.T) {
+	l := &slowCallLogger{conf: &SlowCallConfig{
+		Threshold: time.Second,
+		Rules: []*SlowCallRule{
+			{Server: "search", Threshold: 3 * time.Second},
+			{Tool: "crawl", Threshold: 10 * time.Second},
+			{Server: "search", Tool: "crawl", Threshold: 20 * time.Second},
+		},
+	}}
+	cases := []struct {
+		server, tool string
+		want         time.Duration
+	}{
+		{"fs", "read", time.Second},
+		{"search", "query", 3 * time.Second},
+		{"web", "crawl", 10 * time.Second},
+		{"search", "crawl", 20 * time.Second},
+	}
+	for _, tc := range cases {
+		if got := l.thresholdFor(tc.server, tc.tool); got != tc.want {
+			t.Errorf("thresholdFor(%s, %s) = %s, want %s", tc.server, tc.tool, got, tc.want)
+		}
+	}
+	if !l.due("search", "query", 3*time.Second) || l.due("search", "crawl", 19*time.Second) || (*slowCallLogger)(nil).due("fs", "read", time.Hour) {
+		t.Fatal("due disagrees with the thresholds")
+	}
+}
+
+func TestSlowCallLoggerWritesRedactedExcerpts(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("STELAE_STATE_HOME", base)
+	l, err := newSlowCallLogger(&SlowCallConfig{Threshold: 50 * time.Millisecond, MaxExcerptBytes: 80, RedactKeys: []string{"ssn"}})
+	if err != nil || l == nil {
+		t.Fatalf("newSlowCallLogger: %v", err)
+	}
+	params := json.RawMessage(`{"name":"lookup","arguments":{"api_key":"k-123","SSN":"123-45-6789","q":"x"}}`)
+	response := []byte(`{"result":{"content":[{"type":"text","text":"` + strings.Repeat("z", 200) + `"}]}}`)
+
+	ctx := withCallerInfo(context.Background(), callerInfo{Identity: "anonymous", RequestID: "req-1"})
+	l.observe(ctx, "tools/call", "crm", "lookup", params, response, 10*time.Millisecond)
+	l.observe(ctx, "tools/call", "crm", "lookup", params, response, 120*time.Millisecond)
+
+	data, err := os.ReadFile(filepath.Join(base, "diagnostics", "slow_calls.jsonl"))
+	if err != nil {
+		t.Fatalf("read diagnostics: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the slow call, got %d lines", len(lines))
+	}
+	var entry slowCallEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("decode entry: %v", err)
+	}
+	if entry.DurationMs != 120 || entry.ThresholdMs != 50 || entry.RequestID != "req-1" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if strings.Contains(entry.Params, "k-123") || strings.Contains(entry.Params, "6789") || !strings.Contains(entry.Params, redactedValue) {
+		t.Fatalf("params not redacted: %s", entry.Params)
+	}
+	if !strings.Contains(entry.Response, "truncated") {
+		t.Fatalf("response not truncated: %s", entry.Response)
+	}
+}
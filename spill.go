@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const defaultResponseSpillThreshold = 4 << 20
+
+// responseSpillThreshold is the body size past which response recorders
+// move to a temp file; less than 0 keeps every body in memory. Set from
+// mcpProxy.responseSpillThreshold at startup.
+var responseSpillThreshold int64 = defaultResponseSpillThreshold
+
+func spillDir() string {
+	return filepath.Join(stateHome(), "spill")
+}
+
+// clearSpillDir removes temp files a previous run left behind.
+func clearSpillDir() {
+	entries, err := os.ReadDir(spillDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		_ = os.Remove(filepath.Join(spillDir(), entry.Name()))
+	}
+	if len(entries) > 0 {
+		logger("spill").Info("removed stale response files", "count", len(entries))
+	}
+}
+
+// spillBuffer is a response body that stays in memory up to the spill
+// threshold and moves to a temp file under stateHome() past it. It offers
+// the bytes.Buffer methods the recorder's users need; Bytes on a spilled
+// body reads it back, so code that only forwards the body uses WriteTo and
+// code that only inspects it reads it through Reader.
+type spillBuffer struct {
+	mem  bytes.Buffer
+	file *os.File
+	size int
+	// cleanup removes the temp file if the buffer is dropped unclosed.
+	cleanup runtime.Cleanup
+}
+
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil {
+		limit := responseSpillThreshold
+		if limit < 0 || int64(b.mem.Len()+len(p)) <= limit {
+			n, err := b.mem.Write(p)
+			b.size += n
+			return n, err
+		}
+		if err := b.spill(); err != nil {
+			// a body we cannot spill is still better kept than lost
+			logger("spill").Warn("keeping response in memory", "bytes", b.mem.Len()+len(p), "err", err)
+			n, err := b.mem.Write(p)
+			b.size += n
+			return n, err
+		}
+	}
+	n, err := b.file.Write(p)
+	b.size += n
+	return n, err
+}
+
+func (b *spillBuffer) spill() error {
+	if err := os.MkdirAll(spillDir(), 0o700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(spillDir(), "response-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(b.mem.Bytes()); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return err
+	}
+	b.file = file
+	b.mem = bytes.Buffer{}
+	b.cleanup = runtime.AddCleanup(b, removeSpillFile, file)
+	return nil
+}
+
+func removeSpillFile(file *os.File) {
+	_ = file.Close()
+	_ = os.Remove(file.Name())
+}
+
+func (b *spillBuffer) Len() int { return b.size }
+
+// Spilled reports whether the body lives in a temp file.
+func (b *spillBuffer) Spilled() bool { return b.file != nil }
+
+// Bytes returns the whole body, reading a spilled one back from disk.
+func (b *spillBuffer) Bytes() []byte {
+	if b.file == nil {
+		return b.mem.Bytes()
+	}
+	out := make([]byte, b.size)
+	n, err := b.file.ReadAt(out, 0)
+	if err != nil && err != io.EOF {
+		logger("spill").Error("failed to read spilled response", "path", b.file.Name(), "err", err)
+	}
+	return out[:n]
+}
+
+func (b *spillBuffer) String() string { return string(b.Bytes()) }
+
+// WriteTo streams the body to w without loading a spilled one.
+func (b *spillBuffer) WriteTo(w io.Writer) (int64, error) {
+	if b.file == nil {
+		return b.mem.WriteTo(w)
+	}
+	return io.Copy(w, b.Reader())
+}
+
+// Reader reads the body from the start without loading a spilled one or
+// draining an in-memory one.
+func (b *spillBuffer) Reader() io.Reader {
+	if b.file == nil {
+		return bytes.NewReader(b.mem.Bytes())
+	}
+	return io.NewSectionReader(b.file, 0, int64(b.size))
+}
+
+// Reset empties the buffer and removes any temp file.
+func (b *spillBuffer) Reset() {
+	b.Close()
+	b.mem.Reset()
+	b.size = 0
+}
+
+// Close removes the temp file of a spilled body. The buffer is empty after
+// Close if it had spilled.
+func (b *spillBuffer) Close() {
+	if b.file == nil {
+		return
+	}
+	b.cleanup.Stop()
+	removeSpillFile(b.file)
+	b.file = nil
+	b.size = b.mem.Len()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSpillBuffer(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	defer func(prev int64) { responseSpillThreshold = prev }(responseSpillThreshold)
+	responseSpillThreshold = 16
+	spillFiles := func() int {
+		entries, _ := os.ReadDir(spillDir())
+		return len(entries)
+	}
+
+	rr := newResponseRecorder()
+	_, _ = rr.Write([]byte(`{"result":`))
+	if rr.Body.Spilled() || spillFiles() != 0 {
+		t.Fatal("a body under the threshold spilled")
+	}
+	body := `{"result":"` + strings.Repeat("x", 64) + `"}`
+	_, _ = rr.Write([]byte(body[len(`{"result":`):]))
+	if !rr.Body.Spilled() || spillFiles() != 1 {
+		t.Fatalf("spilled=%t files=%d", rr.Body.Spilled(), spillFiles())
+	}
+	if rr.Body.Len() != len(body) || string(rr.Body.Bytes()) != body {
+		t.Fatalf("body = %q (len %d)", rr.Body.Bytes(), rr.Body.Len())
+	}
+	var streamed bytes.Buffer
+	if _, err := rr.Body.WriteTo(&streamed); err != nil || streamed.String() != body {
+		t.Fatalf("WriteTo = %q, %v", streamed.String(), err)
+	}
+
+	out := httptest.NewRecorder()
+	rr.FlushTo(out)
+	if out.Body.String() != body || out.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("flushed %q %v", out.Body.String(), out.Header())
+	}
+	if spillFiles() != 0 {
+		t.Fatal("temp file left after the response was flushed")
+	}
+
+	rr = newResponseRecorder()
+	_, _ = rr.Write([]byte(body))
+	rr.Body.Reset()
+	_, _ = rr.Write([]byte(`{}`))
+	if rr.Body.Spilled() || rr.Body.String() != `{}` || spillFiles() != 0 {
+		t.Fatalf("reset left %q spilled=%t files=%d", rr.Body.String(), rr.Body.Spilled(), spillFiles())
+	}
+
+	responseSpillThreshold = -1
+	rr = newResponseRecorder()
+	_, _ = rr.Write([]byte(body))
+	if rr.Body.Spilled() {
+		t.Fatal("spilled with spilling disabled")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// startupStages orders servers by their dependsOn declarations. Every server in
+// a stage depends only on servers from earlier stages; servers within a stage
+// connect concurrently. Unknown dependencies and cycles are errors.
+func startupStages(servers map[string]*MCPClientConfigV2) ([][]string, error) {
+	remaining := make(map[string][]string, len(servers))
+	for name, conf := range servers {
+		var deps []string
+		if conf != nil {
+			deps = conf.DependsOn
+		}
+		for _, dep := range deps {
+			if dep == name {
+				return nil, fmt.Errorf("server %s depends on itself", name)
+			}
+			if _, ok := servers[dep]; !ok {
+				return nil, fmt.Errorf("server %s depends on unknown server %s", name, dep)
+			}
+		}
+		remaining[name] = deps
+	}
+
+	placed := make(map[string]bool, len(servers))
+	var stages [][]string
+	for len(remaining) > 0 {
+		var stage []string
+		for name, deps := range remaining {
+			ready := true
+			for _, dep := range deps {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				stage = append(stage, name)
+			}
+		}
+		if len(stage) == 0 {
+			blocked := make([]string, 0, len(remaining))
+			for name := range remaining {
+				blocked = append(blocked, name)
+			}
+			sort.Strings(blocked)
+			return nil, fmt.Errorf("dependency cycle between servers: %s", strings.Join(blocked, ", "))
+		}
+		sort.Strings(stage)
+		for _, name := range stage {
+			placed[name] = true
+			delete(remaining, name)
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// waitWithTimeout waits for wg, giving up after timeout. A zero timeout waits
+// indefinitely. It reports whether the group finished in time.
+func waitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	if timeout <= 0 {
+		<-done
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStartupStagesOrdersByDependencies(t *testing.T) {
+	servers := map[string]*MCPClientConfigV2{
+		"rag":      {DependsOn: []string{"vectordb", "embedder"}},
+		"vectordb": {},
+		"embedder": {DependsOn: []string{"vectordb"}},
+		"fs":       {},
+	}
+	stages, err := startupStages(servers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][]string{{"fs", "vectordb"}, {"embedder"}, {"rag"}}
+	if !reflect.DeepEqual(stages, want) {
+		t.Fatalf("stages = %v, want %v", stages, want)
+	}
+}
+
+func TestStartupStagesRejectsCyclesAndUnknownDeps(t *testing.T) {
+	_, err := startupStages(map[string]*MCPClientConfigV2{
+		"a": {DependsOn: []string{"b"}},
+		"b": {DependsOn: []string{"a"}},
+		"c": {},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cycle") || !strings.Contains(err.Error(), "a, b") {
+		t.Fatalf("expected cycle error naming a and b, got %v", err)
+	}
+
+	_, err = startupStages(map[string]*MCPClientConfigV2{"a": {DependsOn: []string{"missing"}}})
+	if err == nil || !strings.Contains(err.Error(), "unknown server missing") {
+		t.Fatalf("expected unknown dependency error, got %v", err)
+	}
+}
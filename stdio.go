@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+const stdioMaxLineBytes = 16 * 1024 * 1024
+
+// startStdioServer exposes the aggregated facade over stdin/stdout so the
+// proxy can itself be launched as a stdio MCP server. Logs stay on stderr.
+func startStdioServer(config *Config) error {
+	return runProxy(config, nil, func(ctx context.Context, mux *http.ServeMux, mcpPath string) error {
+		ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		logger("proxy").Info("Starting stdio server")
+		return serveStdio(ctx, mux, mcpPath, os.Stdin, os.Stdout)
+	})
+}
+
+// serveStdio reads newline-delimited JSON-RPC messages from in, runs each
+// through the facade handler as an in-process POST, and writes the responses
+// to out one per line. Requests are handled concurrently; responses are
+// written as they complete. It returns once in reaches EOF and every
+// in-flight request has been answered, or when ctx is cancelled.
+func serveStdio(ctx context.Context, handler http.Handler, mcpPath string, in io.Reader, out io.Writer) error {
+	var (
+		writeMu   sync.Mutex
+		inflight  sync.WaitGroup
+		sessionMu sync.RWMutex
+		sessionID string
+	)
+	// the facade issues the session id on initialize; later messages carry it
+	header := func() http.Header {
+		h := http.Header{}
+		sessionMu.RLock()
+		defer sessionMu.RUnlock()
+		if sessionID != "" {
+			h.Set(sessionIDHeader, sessionID)
+		}
+		return h
+	}
+	write := func(line []byte) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := out.Write(append(line, '\n')); err != nil {
+			logger("stdio").Error("write failed", "err", err)
+		}
+	}
+
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), stdioMaxLineBytes)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case lines <- append([]byte(nil), line...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger("proxy").Info("Shutdown signal received")
+			inflight.Wait()
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				inflight.Wait()
+				select {
+				case err := <-scanErr:
+					return err
+				default:
+					return nil
+				}
+			}
+			inflight.Add(1)
+			go func() {
+				defer inflight.Done()
+				resp, respHeader := dispatchFacadeMessage(ctx, handler, mcpPath, header(), line)
+				if id := respHeader.Get(sessionIDHeader); id != "" {
+					sessionMu.Lock()
+					sessionID = id
+					sessionMu.Unlock()
+				}
+				if len(resp) > 0 {
+					write(resp)
+				}
+			}()
+		}
+	}
+}
+
+// dispatchFacadeMessage runs one JSON-RPC message from a message-oriented
+// transport (stdio, WebSocket) through the facade as an in-process POST
+// carrying header, and returns the reply (nil for notifications) with the
+// response headers. Non-JSON replies (e.g. a recovered panic or an unknown
+// session) become an internal_error for the request's id.
+func dispatchFacadeMessage(ctx context.Context, handler http.Handler, mcpPath string, header http.Header, line []byte) ([]byte, http.Header) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, mcpPath, bytes.NewReader(line))
+	if err != nil {
+		return nil, nil
+	}
+	r.Header = header.Clone()
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json")
+	rr := newResponseRecorder()
+	handler.ServeHTTP(rr, r)
+
+	body := bytes.TrimSpace(rr.Body.Bytes())
+	if len(body) > 0 && json.Valid(body) {
+		return body, rr.HeaderMap
+	}
+	var req struct {
+		ID any `json:"id"`
+	}
+	if json.Unmarshal(line, &req) != nil || req.ID == nil {
+		if len(body) > 0 || rr.StatusCode >= http.StatusBadRequest {
+			logger("facade").Warn("dropped reply", "status", rr.StatusCode, "body", redactJSON(body))
+		}
+		return nil, rr.HeaderMap
+	}
+	if len(body) == 0 && rr.StatusCode < http.StatusBadRequest {
+		return nil, rr.HeaderMap
+	}
+	// the locale the facade would have negotiated for the message
+	errs := rpcErrors.in(messages.negotiate(header.Get(acceptLanguageHeader)))
+	resp, _ := json.Marshal(errs.response(req.ID, errNameInternal, map[string]string{"detail": http.StatusText(rr.StatusCode)}))
+	return resp, rr.HeaderMap
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestServeStdio(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		sessions = make(map[string]string)
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/mcp" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req jsonrpcRequest
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		mu.Lock()
+		sessions[req.Method] = r.Header.Get(sessionIDHeader)
+		mu.Unlock()
+		switch req.Method {
+		case "initialize":
+			w.Header().Set(sessionIDHeader, "sess-1")
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case "boom":
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{"method": req.Method}})
+	})
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- serveStdio(context.Background(), handler, "/mcp", inR, outW)
+		outW.Close()
+	}()
+	out := bufio.NewScanner(outR)
+
+	// Like a real client, wait for the initialize reply before going on.
+	_, _ = io.WriteString(inW, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`+"\n")
+	if !out.Scan() || !strings.Contains(out.Text(), `"initialize"`) {
+		t.Fatalf("expected initialize reply, got %q", out.Text())
+	}
+	_, _ = io.WriteString(inW, strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"boom"}`,
+	}, "\n")+"\n")
+	inW.Close()
+
+	byID := make(map[string]map[string]any)
+	for out.Scan() {
+		var resp map[string]any
+		if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response line %q: %v", out.Text(), err)
+		}
+		id, _ := json.Marshal(resp["id"])
+		byID[string(id)] = resp
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("serveStdio: %v", err)
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "2,3" {
+		t.Fatalf("unexpected response ids %v", ids)
+	}
+	if result, _ := byID["2"]["result"].(map[string]any); result["method"] != "tools/list" {
+		t.Fatalf("unexpected tools/list response %v", byID["2"])
+	}
+	rpcErr, _ := byID["3"]["error"].(map[string]any)
+	if data, _ := rpcErr["data"].(map[string]any); data["name"] != errNameInternal {
+		t.Fatalf("expected internal_error for failed request, got %v", byID["3"])
+	}
+
+	if sessions["initialize"] != "" {
+		t.Fatalf("initialize should be sent without a session id, got %q", sessions["initialize"])
+	}
+	for _, method := range []string{"notifications/initialized", "tools/list", "boom"} {
+		if sessions[method] != "sess-1" {
+			t.Fatalf("expected %s to carry the issued session id, got %q", method, sessions[method])
+		}
+	}
+}
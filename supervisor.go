@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconnectInitialBackoff = time.Second
+	defaultReconnectMaxBackoff     = 2 * time.Minute
+	// reconnectJitter spreads attempts by up to this fraction either way so
+	// servers that dropped together do not reconnect in lockstep.
+	reconnectJitter = 0.2
+)
+
+// ReconnectConfig paces reconnecting downstream servers whose client
+// dropped. Reconnecting is on unless Disabled is set.
+type ReconnectConfig struct {
+	Disabled       bool          `json:"disabled,omitempty"`
+	InitialBackoff time.Duration `json:"initialBackoff,omitempty"`
+	MaxBackoff     time.Duration `json:"maxBackoff,omitempty"`
+}
+
+// reconnectState is one server being reconnected.
+type reconnectState struct {
+	Server        string    `json:"server"`
+	Since         time.Time `json:"since"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"lastError,omitempty"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+}
+
+// serverSupervisor reconnects servers whose client dropped (the stdio
+// process exited, the stream closed, or pings marked it down). While it
+// retries, the server is left out of the catalog indexes.
+type serverSupervisor struct {
+	ctx            context.Context
+	registry       *serverRegistry
+	events         *eventBus
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	// connect builds and connects a fresh entry; changed runs after the
+	// registry's server set changes so the indexes can be rebuilt.
+	connect func(name string, conf *MCPClientConfigV2) (*serverEntry, error)
+	changed func()
+
+	mu          sync.Mutex
+	servers     map[string]*reconnectState
+	reconnected map[string]reconnectRecord
+}
+
+// reconnectRecord counts a server's successful reconnects and when the
+// latest one happened.
+type reconnectRecord struct {
+	Count int
+	At    time.Time
+}
+
+func newServerSupervisor(ctx context.Context, conf *ReconnectConfig, registry *serverRegistry, events *eventBus) *serverSupervisor {
+	if conf != nil && conf.Disabled {
+		return nil
+	}
+	s := &serverSupervisor{
+		ctx:            ctx,
+		registry:       registry,
+		events:         events,
+		initialBackoff: defaultReconnectInitialBackoff,
+		maxBackoff:     defaultReconnectMaxBackoff,
+		servers:        make(map[string]*reconnectState),
+		reconnected:    make(map[string]reconnectRecord),
+	}
+	if conf != nil {
+		if conf.InitialBackoff > 0 {
+			s.initialBackoff = conf.InitialBackoff
+		}
+		if conf.MaxBackoff > 0 {
+			s.maxBackoff = conf.MaxBackoff
+		}
+	}
+	s.maxBackoff = max(s.maxBackoff, s.initialBackoff)
+	return s
+}
+
+// lost takes entry out of service and reconnects name in the background.
+// It does nothing if name already moved on to another entry (apply replaced
+// or removed it) or is already reconnecting.
+func (s *serverSupervisor) lost(name string, entry *serverEntry, cause error) {
+	if s == nil || s.ctx.Err() != nil {
+		return
+	}
+	s.mu.Lock()
+	if _, busy := s.servers[name]; busy {
+		s.mu.Unlock()
+		return
+	}
+	if !s.registry.markDown(name, entry) {
+		s.mu.Unlock()
+		return
+	}
+	state := &reconnectState{Server: name, Since: time.Now().UTC()}
+	if cause != nil {
+		state.LastError = cause.Error()
+	}
+	s.servers[name] = state
+	s.mu.Unlock()
+
+	clientLogger(name).Warn("Connection lost; reconnecting", "err", cause)
+	s.events.emit("server.disconnected", map[string]any{"server": name, "error": state.LastError})
+	promDisconnects.inc(name)
+	if s.changed != nil {
+		s.changed()
+	}
+	go s.reconnect(name, entry)
+}
+
+func (s *serverSupervisor) reconnect(name string, entry *serverEntry) {
+	s.mu.Lock()
+	state := s.servers[name]
+	s.mu.Unlock()
+	// a reconnect that succeeded has already handed name back to lost,
+	// which may be reconnecting it again
+	defer func() {
+		s.mu.Lock()
+		if s.servers[name] == state {
+			delete(s.servers, name)
+		}
+		s.mu.Unlock()
+	}()
+	entry.close()
+	backoff := s.initialBackoff
+	for attempt := 1; ; attempt++ {
+		wait := jitterBackoff(backoff)
+		s.mu.Lock()
+		state.NextAttemptAt = time.Now().Add(wait).UTC()
+		s.mu.Unlock()
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !s.registry.isCurrent(name, entry) {
+			clientLogger(name).Info("Reconnect abandoned: server was replaced or removed")
+			return
+		}
+
+		next, err := s.connect(name, entry.config)
+		if err == nil {
+			if !s.registry.replace(name, entry, next) {
+				next.close()
+				return
+			}
+			// the new entry may drop while the indexes rebuild; lost must
+			// see name as no longer reconnecting by then
+			s.mu.Lock()
+			since := state.Since
+			delete(s.servers, name)
+			s.reconnected[name] = reconnectRecord{Count: s.reconnected[name].Count + 1, At: time.Now().UTC()}
+			s.mu.Unlock()
+			clientLogger(name).Info("Reconnected", "attempts", attempt)
+			promReconnects.inc(name)
+			s.events.emit("server.reconnected", map[string]any{
+				"server":     name,
+				"attempts":   attempt,
+				"downtimeMs": time.Since(since).Milliseconds(),
+			})
+			if s.changed != nil {
+				s.changed()
+			}
+			return
+		}
+
+		clientLogger(name).Warn("Reconnect attempt failed", "attempt", attempt, "err", err)
+		s.mu.Lock()
+		state.Attempts = attempt
+		state.LastError = err.Error()
+		s.mu.Unlock()
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
+
+// list returns the servers being reconnected, by name.
+func (s *serverSupervisor) list() []reconnectState {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]reconnectState, 0, len(s.servers))
+	for _, state := range s.servers {
+		out = append(out, *state)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Server < out[j].Server })
+	return out
+}
+
+// lastReconnect reports how often name was reconnected and when it last
+// was; ok is false if it never was.
+func (s *serverSupervisor) lastReconnect(name string) (reconnectRecord, bool) {
+	if s == nil {
+		return reconnectRecord{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.reconnected[name]
+	return record, ok
+}
+
+func jitterBackoff(d time.Duration) time.Duration {
+	return d + time.Duration((rand.Float64()*2-1)*reconnectJitter*float64(d))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServerSupervisorReconnects(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry := newServerRegistry(http.NewServeMux(), "/")
+	handler := http.NotFoundHandler()
+	first := &serverEntry{server: &Server{name: "web"}, config: &MCPClientConfigV2{}, handler: handler}
+	registry.add("web", first)
+
+	s := newServerSupervisor(ctx, &ReconnectConfig{InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}, registry, nil)
+	var attempts, changes atomic.Int32
+	second := &serverEntry{server: &Server{name: "web"}, config: first.config, handler: handler}
+	s.connect = func(name string, conf *MCPClientConfigV2) (*serverEntry, error) {
+		if attempts.Add(1) < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return second, nil
+	}
+	s.changed = func() { changes.Add(1) }
+
+	s.lost("web", first, errors.New("process exited"))
+	if _, ok := registry.snapshot()["web"]; ok && !registry.isCurrent("web", second) {
+		t.Fatal("a lost server stayed in the snapshot")
+	}
+	s.lost("web", first, errors.New("process exited"))
+
+	deadline := time.Now().Add(time.Second)
+	for !registry.isCurrent("web", second) {
+		if time.Now().After(deadline) {
+			t.Fatalf("server not reconnected after %d attempts", attempts.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !registry.connected("web") || registry.snapshot()["web"] != second.server {
+		t.Fatal("reconnected server is not serving")
+	}
+	if attempts.Load() != 3 || changes.Load() != 2 {
+		t.Fatalf("attempts=%d changes=%d", attempts.Load(), changes.Load())
+	}
+	for len(s.list()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if list := s.list(); len(list) != 0 {
+		t.Fatalf("reconnect state not cleared: %+v", list)
+	}
+	if record, ok := s.lastReconnect("web"); !ok || record.Count != 1 || record.At.IsZero() {
+		t.Fatalf("lastReconnect = %+v, %v", record, ok)
+	}
+
+	s.lost("web", first, errors.New("stale"))
+	if !registry.connected("web") || len(s.list()) != 0 {
+		t.Fatal("a replaced entry took the server down")
+	}
+	if newServerSupervisor(ctx, &ReconnectConfig{Disabled: true}, registry, nil) != nil {
+		t.Fatal("disabled supervisor was built")
+	}
+}
+
+func TestServerSupervisorReconnectsADropDuringRebuild(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry := newServerRegistry(http.NewServeMux(), "/")
+	handler := http.NotFoundHandler()
+	first := &serverEntry{server: &Server{name: "web"}, config: &MCPClientConfigV2{}, handler: handler}
+	registry.add("web", first)
+
+	s := newServerSupervisor(ctx, &ReconnectConfig{InitialBackoff: time.Millisecond}, registry, nil)
+	second := &serverEntry{server: &Server{name: "web"}, config: first.config, handler: handler}
+	third := &serverEntry{server: &Server{name: "web"}, config: first.config, handler: handler}
+	var connects atomic.Int32
+	s.connect = func(name string, conf *MCPClientConfigV2) (*serverEntry, error) {
+		if connects.Add(1) == 1 {
+			return second, nil
+		}
+		return third, nil
+	}
+	var dropped atomic.Bool
+	s.changed = func() {
+		// the reconnected entry drops while the indexes rebuild
+		if registry.isCurrent("web", second) && dropped.CompareAndSwap(false, true) {
+			s.lost("web", second, errors.New("stream closed"))
+		}
+	}
+
+	s.lost("web", first, errors.New("process exited"))
+	deadline := time.Now().Add(time.Second)
+	for !registry.isCurrent("web", third) {
+		if time.Now().After(deadline) {
+			t.Fatalf("second drop not reconnected: connects=%d connected=%t", connects.Load(), registry.connected("web"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJitterBackoff(t *testing.T) {
+	for range 100 {
+		if d := jitterBackoff(time.Second); d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("jittered backoff %s out of range", d)
+		}
+	}
+}
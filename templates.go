@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// templateVarPattern matches {{name}} references. The braces syntax keeps
+// template variables apart from ${ENV} expansion, which runs first.
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// expandTemplate substitutes {{name}} references from vars and returns the
+// names it could not resolve.
+func expandTemplate(s string, vars map[string]string) (string, []string) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	var missing []string
+	out := templateVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := templateVarPattern.FindStringSubmatch(match)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		missing = append(missing, name)
+		return match
+	})
+	return out, missing
+}
+
+// resolveTemplateVariables expands references between variables so
+// {"apiBase": "https://{{host}}/v1"} works; cycles are reported as errors.
+func resolveTemplateVariables(vars map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(vars))
+	resolving := make(map[string]bool)
+	var resolve func(name string) (string, error)
+	resolve = func(name string) (string, error) {
+		if v, ok := resolved[name]; ok {
+			return v, nil
+		}
+		raw, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("undefined variable %q", name)
+		}
+		if resolving[name] {
+			return "", fmt.Errorf("variable %q references itself", name)
+		}
+		resolving[name] = true
+		var err error
+		out := templateVarPattern.ReplaceAllStringFunc(raw, func(match string) string {
+			if err != nil {
+				return match
+			}
+			var v string
+			v, err = resolve(templateVarPattern.FindStringSubmatch(match)[1])
+			return v
+		})
+		resolving[name] = false
+		if err != nil {
+			return "", fmt.Errorf("variable %q: %w", name, err)
+		}
+		resolved[name] = out
+		return out, nil
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := resolve(name); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// expandServerTemplates applies conf.Variables to command, args, env, url and
+// headers of every server. All undefined references are reported together.
+func expandServerTemplates(conf *FullConfig) error {
+	vars, err := resolveTemplateVariables(conf.Variables)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	expand := func(server, field, value string) string {
+		out, missing := expandTemplate(value, vars)
+		for _, name := range missing {
+			problems = append(problems, fmt.Sprintf("%s.%s: undefined variable %q", server, field, name))
+		}
+		return out
+	}
+	for name, server := range conf.McpServers {
+		if server == nil {
+			continue
+		}
+		server.Command = expand(name, "command", server.Command)
+		for i, arg := range server.Args {
+			server.Args[i] = expand(name, fmt.Sprintf("args[%d]", i), arg)
+		}
+		for key, value := range server.Env {
+			server.Env[key] = expand(name, "env."+key, value)
+		}
+		server.URL = expand(name, "url", server.URL)
+		for key, value := range server.Headers {
+			server.Headers[key] = expand(name, "headers."+key, value)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("config variables: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandServerTemplates(t *testing.T) {
+	conf := &FullConfig{
+		Variables: map[string]string{
+			"workspaceRoot": "/srv/work",
+			"host":          "api.example.com",
+			"apiBase":       "https://{{host}}/v1",
+		},
+		McpServers: map[string]*MCPClientConfigV2{
+			"fs": {
+				Command: "mcp-fs",
+				Args:    []string{"--root", "{{ workspaceRoot }}/repo"},
+				Env:     map[string]string{"CACHE": "{{workspaceRoot}}/.cache"},
+			},
+			"api": {
+				URL:     "{{apiBase}}/mcp",
+				Headers: map[string]string{"X-Origin": "{{host}}"},
+			},
+		},
+	}
+	if err := expandServerTemplates(conf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fs := conf.McpServers["fs"]
+	if fs.Args[1] != "/srv/work/repo" || fs.Env["CACHE"] != "/srv/work/.cache" {
+		t.Fatalf("fs not expanded: %+v", fs)
+	}
+	api := conf.McpServers["api"]
+	if api.URL != "https://api.example.com/v1/mcp" || api.Headers["X-Origin"] != "api.example.com" {
+		t.Fatalf("api not expanded: %+v", api)
+	}
+}
+
+func TestExpandServerTemplatesReportsUndefined(t *testing.T) {
+	conf := &FullConfig{
+		McpServers: map[string]*MCPClientConfigV2{
+			"fs": {Command: "mcp-fs", Args: []string{"{{root}}"}},
+		},
+	}
+	err := expandServerTemplates(conf)
+	if err == nil || !strings.Contains(err.Error(), `fs.args[0]: undefined variable "root"`) {
+		t.Fatalf("expected undefined variable error, got %v", err)
+	}
+}
+
+func TestResolveTemplateVariablesRejectsCycles(t *testing.T) {
+	_, err := resolveTemplateVariables(map[string]string{"a": "{{b}}", "b": "{{a}}"})
+	if err == nil || !strings.Contains(err.Error(), "references itself") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
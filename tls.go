@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig terminates HTTPS with a certificate and key from disk. The pair
+// is reloaded when either file changes, so renewals need no restart.
+type TLSConfig struct {
+	CertFile   string `json:"certFile,omitempty"`
+	KeyFile    string `json:"keyFile,omitempty"`
+	MinVersion string `json:"minVersion,omitempty"`
+	// ClientAuth requires client certificates on the main listener.
+	ClientAuth *ClientAuthConfig `json:"clientAuth,omitempty"`
+}
+
+// ACMEConfig obtains and renews certificates for hosts automatically.
+// Challenges are answered with TLS-ALPN-01 on the main listener, and with
+// HTTP-01 on httpAddr when set; that listener also redirects plain HTTP to
+// HTTPS.
+type ACMEConfig struct {
+	Enabled      bool     `json:"enabled,omitempty"`
+	Hosts        []string `json:"hosts,omitempty"`
+	Email        string   `json:"email,omitempty"`
+	CacheDir     string   `json:"cacheDir,omitempty"`
+	DirectoryURL string   `json:"directoryURL,omitempty"`
+	HTTPAddr     string   `json:"httpAddr,omitempty"`
+}
+
+// proxyTLS is the resolved TLS setup for the main listener.
+type proxyTLS struct {
+	config *tls.Config
+	// challenge serves HTTP-01 challenges and redirects; nil without ACME
+	// or httpAddr.
+	challenge *http.Server
+	// clientAuth verifies client certificates on the main listener; nil
+	// without tls.clientAuth.
+	clientAuth *clientCertAuth
+}
+
+func tlsMinVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported tls.minVersion %q (use 1.2 or 1.3)", v)
+}
+
+// newProxyTLS returns nil when neither tls nor acme is configured.
+func newProxyTLS(tlsConf *TLSConfig, acmeConf *ACMEConfig) (*proxyTLS, error) {
+	useFiles := tlsConf != nil && (tlsConf.CertFile != "" || tlsConf.KeyFile != "")
+	useACME := acmeConf != nil && acmeConf.Enabled
+	switch {
+	case useFiles && useACME:
+		return nil, errors.New("tls certificate files and acme are mutually exclusive")
+	case !useFiles && !useACME && tlsConf != nil && tlsConf.ClientAuth != nil:
+		return nil, errors.New("tls.clientAuth needs tls certificate files or acme")
+	case !useFiles && !useACME:
+		return nil, nil
+	}
+	minVersion, err := tlsMinVersion("")
+	if tlsConf != nil {
+		minVersion, err = tlsMinVersion(tlsConf.MinVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var clientAuth *clientCertAuth
+	if tlsConf != nil {
+		if clientAuth, err = newClientCertAuth(tlsConf.ClientAuth); err != nil {
+			return nil, fmt.Errorf("tls.%w", err)
+		}
+	}
+
+	if useFiles {
+		if tlsConf.CertFile == "" || tlsConf.KeyFile == "" {
+			return nil, errors.New("tls needs both certFile and keyFile")
+		}
+		reloader, err := newCertReloader(tlsConf.CertFile, tlsConf.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		logger("tls").Info("Serving HTTPS", "certFile", tlsConf.CertFile)
+		out := &proxyTLS{config: &tls.Config{MinVersion: minVersion, GetCertificate: reloader.getCertificate}, clientAuth: clientAuth}
+		out.applyClientAuth()
+		return out, nil
+	}
+
+	if len(acmeConf.Hosts) == 0 {
+		return nil, errors.New("acme.hosts must list at least one hostname")
+	}
+	dir := acmeConf.CacheDir
+	if dir == "" {
+		dir = "acme"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(stateHome(), dir)
+	}
+	guarded, err := resolveGuardedPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("acme.cacheDir %s: %w", acmeConf.CacheDir, err)
+	}
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(acmeConf.Hosts...),
+		Cache:      autocert.DirCache(guarded),
+		Email:      acmeConf.Email,
+	}
+	if acmeConf.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: acmeConf.DirectoryURL}
+	}
+	config := manager.TLSConfig()
+	config.MinVersion = minVersion
+	out := &proxyTLS{config: config, clientAuth: clientAuth}
+	out.applyClientAuth()
+	if acmeConf.HTTPAddr != "" {
+		out.challenge = &http.Server{
+			Addr:              acmeConf.HTTPAddr,
+			Handler:           manager.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+	logger("tls").Info("Serving HTTPS with ACME certificates", "hosts", acmeConf.Hosts, "cache", guarded)
+	return out, nil
+}
+
+func (t *proxyTLS) applyClientAuth() {
+	if t.clientAuth != nil {
+		t.clientAuth.apply(t.config)
+		logger("tls").Info("Requiring client certificates", "caFile", t.clientAuth.conf.CAFile)
+	}
+}
+
+// listenerConfig is the TLS setup of another listener on the same
+// certificate, with its own client certificate requirement.
+func (t *proxyTLS) listenerConfig(conf *ClientAuthConfig) (*tls.Config, error) {
+	auth, err := newClientCertAuth(conf)
+	if err != nil {
+		return nil, err
+	}
+	config := t.config.Clone()
+	auth.apply(config)
+	return config, nil
+}
+
+// certReloader serves a key pair from disk, re-reading it at most once per
+// reloadInterval when a file's modification time has changed. A pair that
+// fails to load keeps the previous certificate in service.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+const certReloadInterval = 30 * time.Second
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("tls.certFile: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls.keyFile: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls key pair: %w", err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now := time.Now(); now.Sub(r.lastCheck) >= certReloadInterval {
+		r.lastCheck = now
+		certInfo, certErr := os.Stat(r.certFile)
+		keyInfo, keyErr := os.Stat(r.keyFile)
+		if certErr == nil && keyErr == nil && (!certInfo.ModTime().Equal(r.certMod) || !keyInfo.ModTime().Equal(r.keyMod)) {
+			if err := r.load(); err != nil {
+				logger("tls").Error("certificate reload failed; keeping previous certificate", "err", err)
+			} else {
+				logger("tls").Info("reloaded certificate", "certFile", r.certFile)
+			}
+		}
+	}
+	return r.cert, nil
+}
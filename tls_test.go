@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestCert(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloaderPicksUpRenewal(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "first")
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+	cert, _ := reloader.getCertificate(nil)
+	if got := leafCommonName(t, cert); got != "first" {
+		t.Fatalf("expected first certificate, got %s", got)
+	}
+
+	writeTestCert(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, later, later)
+	reloader.lastCheck = time.Time{}
+	cert, _ = reloader.getCertificate(nil)
+	if got := leafCommonName(t, cert); got != "second" {
+		t.Fatalf("expected renewed certificate, got %s", got)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(keyFile, later.Add(time.Minute), later.Add(time.Minute))
+	reloader.lastCheck = time.Time{}
+	cert, _ = reloader.getCertificate(nil)
+	if got := leafCommonName(t, cert); got != "second" {
+		t.Fatalf("expected previous certificate to stay in service, got %s", got)
+	}
+}
+
+func TestNewProxyTLS(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	if got, err := newProxyTLS(nil, &ACMEConfig{}); got != nil || err != nil {
+		t.Fatalf("expected no TLS, got %v, %v", got, err)
+	}
+	cases := []struct {
+		tls  *TLSConfig
+		acme *ACMEConfig
+		want string
+	}{
+		{&TLSConfig{CertFile: "c.pem", KeyFile: "k.pem"}, &ACMEConfig{Enabled: true, Hosts: []string{"a"}}, "mutually exclusive"},
+		{&TLSConfig{CertFile: "c.pem"}, nil, "both certFile and keyFile"},
+		{&TLSConfig{MinVersion: "1.0"}, &ACMEConfig{Enabled: true, Hosts: []string{"a"}}, "minVersion"},
+		{nil, &ACMEConfig{Enabled: true}, "acme.hosts"},
+		{nil, &ACMEConfig{Enabled: true, Hosts: []string{"a"}, CacheDir: "/etc/acme"}, "acme.cacheDir"},
+	}
+	for _, tc := range cases {
+		if _, err := newProxyTLS(tc.tls, tc.acme); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected error containing %q, got %v", tc.want, err)
+		}
+	}
+
+	got, err := newProxyTLS(&TLSConfig{MinVersion: "1.3"}, &ACMEConfig{Enabled: true, Hosts: []string{"mcp.example.com"}, HTTPAddr: ":0"})
+	if err != nil {
+		t.Fatalf("newProxyTLS: %v", err)
+	}
+	if got.config.MinVersion != tls.VersionTLS13 || got.challenge == nil {
+		t.Fatalf("unexpected ACME setup %+v", got)
+	}
+	found := false
+	for _, proto := range got.config.NextProtos {
+		found = found || proto == "acme-tls/1"
+	}
+	if !found {
+		t.Fatalf("expected TLS-ALPN-01 support, got %v", got.config.NextProtos)
+	}
+}
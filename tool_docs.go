@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// toolDoc is supplementary descriptor metadata for one tool, read from the
+// files in manifest.toolDocsDir. Tool overrides still win for the fields
+// they set.
+type toolDoc struct {
+	Description     string                   `json:"description,omitempty"`
+	LongDescription string                   `json:"longDescription,omitempty"`
+	Examples        []ToolExampleConfig      `json:"examples,omitempty"`
+	Tags            []string                 `json:"tags,omitempty"`
+	Localizations   map[string]toolDocLocale `json:"localizations,omitempty"`
+}
+
+// toolDocLocale is a tool's title and description in one locale.
+type toolDocLocale struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// loadToolDocs reads <tool>.json and <tool>.md files from dir. A Markdown
+// file supplies the tool's longDescription unless its JSON file sets one.
+// Other files are ignored.
+func loadToolDocs(dir string) (map[string]*toolDoc, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]*toolDoc)
+	markdown := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		ext := filepath.Ext(entry.Name())
+		tool := strings.TrimSuffix(entry.Name(), ext)
+		if tool == "" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		switch ext {
+		case ".json":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			var doc toolDoc
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return nil, fmt.Errorf("parse tool doc %s: %w", path, err)
+			}
+			docs[tool] = &doc
+		case ".md":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			markdown[tool] = strings.TrimSpace(string(data))
+		}
+	}
+	for tool, text := range markdown {
+		doc := docs[tool]
+		if doc == nil {
+			doc = &toolDoc{}
+			docs[tool] = doc
+		}
+		if doc.LongDescription == "" {
+			doc.LongDescription = text
+		}
+	}
+	return docs, nil
+}
+
+// applyToolDoc merges doc into descriptor: the description directly, the
+// rest under x-stelae.
+func applyToolDoc(descriptor map[string]any, doc *toolDoc) map[string]any {
+	if descriptor == nil || doc == nil {
+		return descriptor
+	}
+	if doc.Description != "" {
+		descriptor["description"] = doc.Description
+	}
+	meta, _ := descriptor["x-stelae"].(map[string]any)
+	meta = copyStringAnyMap(meta)
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	if doc.LongDescription != "" {
+		meta["longDescription"] = doc.LongDescription
+	}
+	if len(doc.Examples) > 0 {
+		meta["examples"] = copyToolExamples(doc.Examples)
+	}
+	if len(doc.Tags) > 0 {
+		tags := append([]string(nil), doc.Tags...)
+		sort.Strings(tags)
+		meta["tags"] = tags
+	}
+	if len(doc.Localizations) > 0 {
+		locales := make(map[string]any, len(doc.Localizations))
+		for locale, text := range doc.Localizations {
+			locales[locale] = text
+		}
+		meta["localizations"] = locales
+	}
+	if len(meta) > 0 {
+		descriptor["x-stelae"] = meta
+	}
+	return descriptor
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadToolDocs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"search.json": `{"description":"Search the workspace","tags":["search","files"],"examples":[{"arguments":{"query":"todo"}}],
+			"localizations":{"de":{"title":"Suche","description":"Arbeitsbereich durchsuchen"}}}`,
+		"search.md":    "# search\n\nLonger text.\n",
+		"read_file.md": "Reads one file.",
+		"notes.txt":    "ignored",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	docs, err := loadToolDocs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs["read_file"].LongDescription != "Reads one file." || docs["search"].LongDescription != "# search\n\nLonger text." {
+		t.Fatalf("docs = %+v", docs)
+	}
+
+	overridden := "Overridden"
+	set, err := loadManifestToolOverrides(&ManifestConfig{
+		ToolDocsDir:   dir,
+		ToolOverrides: map[string]*ToolOverrideConfig{"search": {Description: &overridden}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptor := applyToolOverride("search", map[string]any{"name": "search", "description": "raw"}, set)
+	if descriptor["description"] != "Overridden" {
+		t.Fatalf("override lost to the doc file: %v", descriptor["description"])
+	}
+	meta := descriptor["x-stelae"].(map[string]any)
+	if meta["longDescription"] != "# search\n\nLonger text." || !reflect.DeepEqual(meta["tags"], []string{"files", "search"}) {
+		t.Fatalf("x-stelae = %v", meta)
+	}
+	if examples := meta["examples"].([]ToolExampleConfig); len(examples) != 1 || examples[0].Arguments["query"] != "todo" {
+		t.Fatalf("examples = %v", meta["examples"])
+	}
+	if locales := meta["localizations"].(map[string]any); locales["de"] != (toolDocLocale{Title: "Suche", Description: "Arbeitsbereich durchsuchen"}) {
+		t.Fatalf("localizations = %v", locales)
+	}
+	if docs := cloneOverrideSet(set).Docs; docs["search"] == nil {
+		t.Fatal("clone dropped the tool docs")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadToolDocs(dir); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type toolOverrideFile struct {
@@ -28,6 +31,9 @@ type ToolOverrideSet struct {
 	Aliases       map[string]string
 	Renamed       map[string]string
 	Warnings      []string
+	// Docs is the enrichment read from manifest.toolDocsDir, keyed by tool
+	// name. It is never modified after loading, so clones share it.
+	Docs map[string]*toolDoc
 }
 
 func loadToolOverridesFromPath(path string) (*ToolOverrideSet, error) {
@@ -174,6 +180,38 @@ func copyToolOverrideConfig(in *ToolOverrideConfig) *ToolOverrideConfig {
 	if in.OutputSchema != nil {
 		out.OutputSchema = copySchemaMap(in.OutputSchema)
 	}
+	if in.Profiles != nil {
+		out.Profiles = append([]string(nil), in.Profiles...)
+	}
+	if in.Examples != nil {
+		out.Examples = copyToolExamples(in.Examples)
+	}
+	if in.Pin != nil {
+		out.Pin = copyToolPin(in.Pin)
+	}
+	out.Timeout = in.Timeout
+	if in.Retry != nil {
+		out.Retry = copyBoolPointer(in.Retry)
+	}
+	return out
+}
+
+func copyToolPin(in *ToolPinConfig) *ToolPinConfig {
+	out := *in
+	out.InputSchema = copySchemaMap(in.InputSchema)
+	out.OutputSchema = copySchemaMap(in.OutputSchema)
+	return &out
+}
+
+func copyToolExamples(in []ToolExampleConfig) []ToolExampleConfig {
+	out := make([]ToolExampleConfig, len(in))
+	for i, example := range in {
+		out[i] = ToolExampleConfig{
+			Description: example.Description,
+			Arguments:   copySchemaMap(example.Arguments),
+			Result:      example.Result,
+		}
+	}
 	return out
 }
 
@@ -220,6 +258,18 @@ func mergeOverrideConfig(base, extra *ToolOverrideConfig) *ToolOverrideConfig {
 	if extra.OutputSchema != nil {
 		result.OutputSchema = copySchemaMap(extra.OutputSchema)
 	}
+	if extra.Examples != nil {
+		result.Examples = copyToolExamples(extra.Examples)
+	}
+	if extra.Pin != nil {
+		result.Pin = copyToolPin(extra.Pin)
+	}
+	if extra.Timeout > 0 {
+		result.Timeout = extra.Timeout
+	}
+	if extra.Retry != nil {
+		result.Retry = copyBoolPointer(extra.Retry)
+	}
 	return result
 }
 
@@ -437,6 +487,13 @@ func toolEnabled(set *ToolOverrideSet, serverName, toolName string) bool {
 	if flag := fragmentToolEnabled(set.Master, toolName); flag != nil {
 		enabled = *flag
 	}
+	if cfg, ok := set.ToolOverrides["*"]; ok && cfg != nil && cfg.Enabled != nil {
+		enabled = *cfg.Enabled
+	}
+	if cfg, ok := set.ToolOverrides[toolName]; ok && cfg != nil && cfg.Enabled != nil {
+		enabled = *cfg.Enabled
+	}
+	// a server's own fragment is the most specific scope
 	if fragment := set.Servers[serverName]; fragment != nil {
 		if fragment.Enabled != nil {
 			enabled = *fragment.Enabled
@@ -445,13 +502,57 @@ func toolEnabled(set *ToolOverrideSet, serverName, toolName string) bool {
 			enabled = *flag
 		}
 	}
-	if cfg, ok := set.ToolOverrides["*"]; ok && cfg != nil && cfg.Enabled != nil {
-		enabled = *cfg.Enabled
+	return enabled
+}
+
+// toolTimeout returns the configured tools/call timeout for a tool, or 0.
+func toolTimeout(set *ToolOverrideSet, serverName, toolName string) time.Duration {
+	timeout, _ := resolveToolSetting(set, serverName, toolName, func(cfg *ToolOverrideConfig) (time.Duration, bool) {
+		return cfg.Timeout, cfg.Timeout > 0
+	})
+	return timeout
+}
+
+// toolRetry returns the tool's retry override, if any scope sets one.
+func toolRetry(set *ToolOverrideSet, serverName, toolName string) (bool, bool) {
+	return resolveToolSetting(set, serverName, toolName, func(cfg *ToolOverrideConfig) (bool, bool) {
+		if cfg.Retry == nil {
+			return false, false
+		}
+		return *cfg.Retry, true
+	})
+}
+
+// resolveToolSetting returns the most specific value get finds for a tool,
+// consulting scopes in the same order as toolEnabled: master, then the
+// server's fragment (each by name, else "*"), then top-level "*" and name.
+func resolveToolSetting[T any](set *ToolOverrideSet, serverName, toolName string, get func(*ToolOverrideConfig) (T, bool)) (T, bool) {
+	var value T
+	found := false
+	if set == nil {
+		return value, false
 	}
-	if cfg, ok := set.ToolOverrides[toolName]; ok && cfg != nil && cfg.Enabled != nil {
-		enabled = *cfg.Enabled
+	for _, fragment := range []*toolOverrideFragment{set.Master, set.Servers[serverName]} {
+		if fragment == nil || fragment.Tools == nil {
+			continue
+		}
+		for _, name := range []string{toolName, "*"} {
+			if cfg := fragment.Tools[name]; cfg != nil {
+				if v, ok := get(cfg); ok {
+					value, found = v, true
+					break
+				}
+			}
+		}
 	}
-	return enabled
+	for _, name := range []string{"*", toolName} {
+		if cfg := set.ToolOverrides[name]; cfg != nil {
+			if v, ok := get(cfg); ok {
+				value, found = v, true
+			}
+		}
+	}
+	return value, found
 }
 
 func copyFragment(src *toolOverrideFragment) *toolOverrideFragment {
@@ -558,6 +659,7 @@ func cloneOverrideSet(src *ToolOverrideSet) *ToolOverrideSet {
 		Aliases:       make(map[string]string, len(src.Aliases)),
 		Renamed:       make(map[string]string, len(src.Renamed)),
 		Warnings:      append([]string{}, src.Warnings...),
+		Docs:          src.Docs,
 	}
 	if src.Master != nil {
 		clone.Master = copyFragment(src.Master)
@@ -573,3 +675,159 @@ func cloneOverrideSet(src *ToolOverrideSet) *ToolOverrideSet {
 	}
 	return clone
 }
+
+// loadManifestToolOverrides builds the override set from the manifest's inline
+// overrides plus its toolOverridesPath file and toolDocsDir. A file error is returned together
+// with the inline-only set so callers can decide whether to keep going.
+func loadManifestToolOverrides(manifestCfg *ManifestConfig) (*ToolOverrideSet, error) {
+	var set *ToolOverrideSet
+	if len(manifestCfg.ToolOverrides) > 0 {
+		set = &ToolOverrideSet{
+			ToolOverrides: copyToolOverrideMap(manifestCfg.ToolOverrides),
+			Servers:       make(map[string]*toolOverrideFragment),
+			Aliases:       make(map[string]string),
+			Renamed:       make(map[string]string),
+		}
+		sanitizeToolOverrideSet(set)
+	}
+	if manifestCfg.ToolOverridesPath != "" {
+		fileOverrides, err := loadToolOverridesFromPath(manifestCfg.ToolOverridesPath)
+		if err != nil {
+			return set, fmt.Errorf("load tool overrides from %s: %w", manifestCfg.ToolOverridesPath, err)
+		}
+		set = mergeOverrideSets(set, fileOverrides)
+	}
+	if manifestCfg.ToolDocsDir != "" {
+		docs, err := loadToolDocs(manifestCfg.ToolDocsDir)
+		if err != nil {
+			return set, fmt.Errorf("load tool docs from %s: %w", manifestCfg.ToolDocsDir, err)
+		}
+		if len(docs) > 0 {
+			if set == nil {
+				set = &ToolOverrideSet{
+					Servers: make(map[string]*toolOverrideFragment),
+					Aliases: make(map[string]string),
+					Renamed: make(map[string]string),
+				}
+			}
+			set.Docs = docs
+		}
+	}
+	return set, nil
+}
+
+type toolToggleKey struct {
+	Server string
+	Tool   string
+}
+
+// toolOverrideStore holds the active override set. Runtime edits (tool
+// toggles, reloads) publish a new set instead of mutating the current one, so
+// request handlers can read it without locking.
+type toolOverrideStore struct {
+	mu      sync.Mutex
+	base    *ToolOverrideSet
+	toggles map[toolToggleKey]toolToggle
+	history []toolToggle
+	// suspended are disables made by the proxy itself, such as for dead
+	// tools; they are applied after toggles and never saved.
+	suspended map[toolToggleKey]toolToggle
+	statePath string
+	active    atomic.Pointer[ToolOverrideSet]
+}
+
+func newToolOverrideStore(base *ToolOverrideSet) *toolOverrideStore {
+	store := &toolOverrideStore{base: base, toggles: make(map[toolToggleKey]toolToggle), suspended: make(map[toolToggleKey]toolToggle)}
+	store.active.Store(base)
+	return store
+}
+
+// current is nil-safe; a nil store behaves like "no overrides".
+func (s *toolOverrideStore) current() *ToolOverrideSet {
+	if s == nil {
+		return nil
+	}
+	return s.active.Load()
+}
+
+// setToolEnabled records a runtime toggle that survives reloads and, with a
+// state file, restarts. The in-memory change applies even if saving fails.
+func (s *toolOverrideStore) setToolEnabled(change toolToggle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := toolToggleKey{Server: change.Server, Tool: change.Tool}
+	s.toggles[key] = change
+	if change.Enabled {
+		delete(s.suspended, key)
+	}
+	s.history = append(s.history, change)
+	if len(s.history) > toolStateHistoryLimit {
+		s.history = append(s.history[:0], s.history[len(s.history)-toolStateHistoryLimit:]...)
+	}
+	s.publish()
+	return s.save()
+}
+
+// replaceBase swaps in a freshly loaded set and re-applies runtime toggles.
+func (s *toolOverrideStore) replaceBase(base *ToolOverrideSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.base = base
+	s.publish()
+}
+
+func (s *toolOverrideStore) publish() {
+	s.active.Store(s.withToggles(s.base))
+}
+
+// overlay applies the runtime toggles to another base, such as a rollout
+// candidate's, without publishing it.
+func (s *toolOverrideStore) overlay(base *ToolOverrideSet) *ToolOverrideSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.withToggles(base)
+}
+
+func (s *toolOverrideStore) withToggles(base *ToolOverrideSet) *ToolOverrideSet {
+	if len(s.toggles) == 0 && len(s.suspended) == 0 {
+		return base
+	}
+	set := cloneOverrideSet(base)
+	for key, toggle := range s.toggles {
+		set = applyToolToggle(set, key, toggle.Enabled)
+	}
+	for key := range s.suspended {
+		set = applyToolToggle(set, key, false)
+	}
+	return set
+}
+
+// applyToolToggle forces one server tool on or off in set, which must not be
+// shared with readers; a nil set is allocated.
+func applyToolToggle(set *ToolOverrideSet, key toolToggleKey, enabled bool) *ToolOverrideSet {
+	if set == nil {
+		set = &ToolOverrideSet{
+			Servers: make(map[string]*toolOverrideFragment),
+			Aliases: make(map[string]string),
+			Renamed: make(map[string]string),
+		}
+	}
+	if set.Servers == nil {
+		set.Servers = make(map[string]*toolOverrideFragment)
+	}
+	fragment := set.Servers[key.Server]
+	if fragment == nil {
+		fragment = &toolOverrideFragment{}
+		set.Servers[key.Server] = fragment
+	}
+	if fragment.Tools == nil {
+		fragment.Tools = make(map[string]*ToolOverrideConfig)
+	}
+	flag := enabled
+	if cfg := fragment.Tools[key.Tool]; cfg != nil {
+		cfg.Enabled = &flag
+	} else {
+		fragment.Tools[key.Tool] = &ToolOverrideConfig{Enabled: &flag}
+	}
+	return set
+}
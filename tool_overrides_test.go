@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadToolOverridesFromPath(t *testing.T) {
@@ -156,3 +157,119 @@ func TestMergeToolOverrideMaps(t *testing.T) {
 		t.Fatalf("expected destructiveHint to be false")
 	}
 }
+
+func TestToolOverrideStoreTogglesSurviveReload(t *testing.T) {
+	store := newToolOverrideStore(nil)
+	if store.current() != nil {
+		t.Fatal("expected empty store to have no overrides")
+	}
+	_ = store.setToolEnabled(toolToggle{Server: "alpha", Tool: "echo", Enabled: false})
+	if toolEnabled(store.current(), "alpha", "echo") {
+		t.Fatal("echo should be disabled after toggle")
+	}
+	if !toolEnabled(store.current(), "alpha", "other") {
+		t.Fatal("untoggled tool should stay enabled")
+	}
+	if !toolEnabled(store.current(), "beta", "echo") {
+		t.Fatal("a toggle must only apply to its own server")
+	}
+
+	description := "reloaded"
+	store.replaceBase(&ToolOverrideSet{
+		ToolOverrides: map[string]*ToolOverrideConfig{"echo": {Description: &description}},
+		Servers:       map[string]*toolOverrideFragment{},
+		Aliases:       map[string]string{},
+		Renamed:       map[string]string{},
+	})
+	current := store.current()
+	if toolEnabled(current, "alpha", "echo") {
+		t.Fatal("toggle should survive replaceBase")
+	}
+	if cfg := current.ToolOverrides["echo"]; cfg == nil || cfg.Description == nil || *cfg.Description != description {
+		t.Fatalf("reloaded override lost: %#v", cfg)
+	}
+
+	_ = store.setToolEnabled(toolToggle{Server: "alpha", Tool: "echo", Enabled: true})
+	if !toolEnabled(store.current(), "alpha", "echo") {
+		t.Fatal("echo should be enabled again")
+	}
+
+	var nilStore *toolOverrideStore
+	if nilStore.current() != nil {
+		t.Fatal("nil store should report no overrides")
+	}
+}
+
+func TestServerFragmentOutranksNameKeyedOverride(t *testing.T) {
+	off, on := false, true
+	set := &ToolOverrideSet{
+		ToolOverrides: map[string]*ToolOverrideConfig{"search": {Enabled: &off}},
+		Servers: map[string]*toolOverrideFragment{
+			"a": {Tools: map[string]*ToolOverrideConfig{"search": {Enabled: &on}}},
+		},
+	}
+	if !toolEnabled(set, "a", "search") {
+		t.Fatal("a/search is enabled by its server fragment")
+	}
+	if toolEnabled(set, "b", "search") {
+		t.Fatal("b/search is disabled by the name-keyed override")
+	}
+}
+
+func TestToolOverrideExamples(t *testing.T) {
+	examples := []ToolExampleConfig{{
+		Description: "Find TODOs in Go files",
+		Arguments:   map[string]any{"query": "TODO", "glob": "*.go"},
+		Result:      map[string]any{"matches": []any{map[string]any{"path": "main.go", "line": 12}}},
+	}}
+	base := map[string]*ToolOverrideConfig{"grep": {Examples: []ToolExampleConfig{{Arguments: map[string]any{"query": "old"}}}}}
+	merged := mergeToolOverrideMaps(base, map[string]*ToolOverrideConfig{"grep": {Examples: examples}})
+	if got := merged["grep"].Examples; len(got) != 1 || got[0].Description != "Find TODOs in Go files" {
+		t.Fatalf("expected the narrower examples to replace the base ones, got %+v", got)
+	}
+	examples[0].Arguments["query"] = "changed"
+	if merged["grep"].Examples[0].Arguments["query"] != "TODO" {
+		t.Fatal("merged examples share arguments with the input")
+	}
+
+	set := &ToolOverrideSet{ToolOverrides: merged}
+	descriptor := applyToolOverride("grep", map[string]any{"name": "grep"}, set)
+	descriptor = attachStelaeMetadata(descriptor, []string{"fs"})
+	meta, _ := descriptor["x-stelae"].(map[string]any)
+	got, _ := meta["examples"].([]ToolExampleConfig)
+	if len(got) != 1 || got[0].Arguments["glob"] != "*.go" || meta["primaryServer"] != "fs" {
+		t.Fatalf("x-stelae = %v", meta)
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	base := map[string]*ToolOverrideConfig{"build": {Timeout: time.Minute}}
+	merged := mergeToolOverrideMaps(base, map[string]*ToolOverrideConfig{"build": {Profiles: []string{"ci"}}})
+	if merged["build"].Timeout != time.Minute {
+		t.Fatalf("merge dropped the timeout: %+v", merged["build"])
+	}
+
+	set := &ToolOverrideSet{
+		ToolOverrides: map[string]*ToolOverrideConfig{"build": merged["build"]},
+		Master:        &toolOverrideFragment{Tools: map[string]*ToolOverrideConfig{"*": {Timeout: 5 * time.Second}}},
+		Servers: map[string]*toolOverrideFragment{
+			"ci": {Tools: map[string]*ToolOverrideConfig{"test": {Timeout: 10 * time.Minute}}},
+		},
+	}
+	cases := []struct {
+		server, tool string
+		want         time.Duration
+	}{
+		{"fs", "read_file", 5 * time.Second},
+		{"ci", "test", 10 * time.Minute},
+		{"ci", "build", time.Minute},
+	}
+	for _, c := range cases {
+		if got := toolTimeout(set, c.server, c.tool); got != c.want {
+			t.Errorf("toolTimeout(%s, %s) = %s, want %s", c.server, c.tool, got, c.want)
+		}
+	}
+	if got := toolTimeout(nil, "fs", "read_file"); got != 0 {
+		t.Fatalf("toolTimeout without overrides = %s", got)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const toolStateHistoryLimit = 500
+
+// toolToggle is a runtime enable/disable decision with its provenance; the
+// same record doubles as an audit trail entry.
+type toolToggle struct {
+	Server  string    `json:"server"`
+	Tool    string    `json:"tool"`
+	Enabled bool      `json:"enabled"`
+	By      string    `json:"by,omitempty"`
+	At      time.Time `json:"at"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+type toolStateFile struct {
+	Toggles []toolToggle `json:"toggles"`
+	History []toolToggle `json:"history"`
+}
+
+func defaultToolStatePath() string {
+	return filepath.Join(stateHome(), "tool_state.json")
+}
+
+// loadState attaches a state file to the store and restores the toggles and
+// history it holds. A missing file is not an error.
+func (s *toolOverrideStore) loadState(path string) error {
+	resolved, err := mkdirAllUnder(stateHome(), path)
+	if err != nil {
+		return fmt.Errorf("tool state %s: %w", path, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statePath = resolved
+	data, err := os.ReadFile(resolved)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state toolStateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parse tool state %s: %w", resolved, err)
+	}
+	for _, toggle := range state.Toggles {
+		s.toggles[toolToggleKey{Server: toggle.Server, Tool: toggle.Tool}] = toggle
+	}
+	s.history = state.History
+	s.publish()
+	return nil
+}
+
+// save must be called with s.mu held.
+func (s *toolOverrideStore) save() error {
+	if s.statePath == "" {
+		return nil
+	}
+	state := toolStateFile{Toggles: s.toggleList(), History: s.history}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(s.statePath, append(data, '\n'))
+}
+
+// toggleList must be called with s.mu held.
+func (s *toolOverrideStore) toggleList() []toolToggle {
+	out := make([]toolToggle, 0, len(s.toggles))
+	for _, toggle := range s.toggles {
+		out = append(out, toggle)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].Tool < out[j].Tool
+	})
+	return out
+}
+
+func (s *toolOverrideStore) toggle(server, tool string) (toolToggle, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	toggle, ok := s.toggles[toolToggleKey{Server: server, Tool: tool}]
+	return toggle, ok
+}
+
+// suspend hides a tool until resume; unlike setToolEnabled the change is
+// neither saved nor kept in the history.
+func (s *toolOverrideStore) suspend(change toolToggle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change.Enabled = false
+	s.suspended[toolToggleKey{Server: change.Server, Tool: change.Tool}] = change
+	s.publish()
+}
+
+// resume lifts a suspension and reports whether there was one.
+func (s *toolOverrideStore) resume(server, tool string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := toolToggleKey{Server: server, Tool: tool}
+	if _, ok := s.suspended[key]; !ok {
+		return false
+	}
+	delete(s.suspended, key)
+	s.publish()
+	return true
+}
+
+func (s *toolOverrideStore) suspension(server, tool string) (toolToggle, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change, ok := s.suspended[toolToggleKey{Server: server, Tool: tool}]
+	return change, ok
+}
+
+// recentHistory returns up to limit toggle changes, newest first.
+func (s *toolOverrideStore) recentHistory(limit int) []toolToggle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]toolToggle, 0, min(limit, len(s.history)))
+	for i := len(s.history) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, s.history[i])
+	}
+	return out
+}
+
+// catalogTool is one row of the admin catalog view.
+type catalogTool struct {
+	Server     string     `json:"server"`
+	Tool       string     `json:"tool"`
+	Source     string     `json:"source,omitempty"`
+	DisabledBy string     `json:"disabledBy,omitempty"`
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// adminCatalogView splits every downstream tool into enabled and disabled.
+// Disabled rows say whether a runtime toggle, the tool overrides, or the
+// server-level switch hid the tool, with who/when/why for runtime toggles.
+func adminCatalogView(servers map[string]*Server, store *toolOverrideStore) map[string]any {
+	current := store.current()
+	enabled := make([]catalogTool, 0)
+	disabled := make([]catalogTool, 0)
+	for serverName, srv := range servers {
+		serverOn := serverEnabled(current, serverName)
+		for _, tool := range srv.tools {
+			row := catalogTool{Server: serverName, Tool: tool.Name}
+			if serverOn && toolEnabled(current, serverName, tool.Name) {
+				enabled = append(enabled, row)
+				continue
+			}
+			suspended, isSuspended := store.suspension(serverName, tool.Name)
+			switch toggle, ok := store.toggle(serverName, tool.Name); {
+			case !serverOn:
+				row.Source = "server"
+			case isSuspended:
+				row.Source = "proxy"
+				row.DisabledBy = suspended.By
+				at := suspended.At
+				row.DisabledAt = &at
+				row.Reason = suspended.Reason
+			case ok && !toggle.Enabled:
+				row.Source = "runtime"
+				row.DisabledBy = toggle.By
+				at := toggle.At
+				row.DisabledAt = &at
+				row.Reason = toggle.Reason
+			default:
+				row.Source = "overrides"
+			}
+			disabled = append(disabled, row)
+		}
+	}
+	sortCatalogTools(enabled)
+	sortCatalogTools(disabled)
+	return map[string]any{"enabled": enabled, "disabled": disabled}
+}
+
+func sortCatalogTools(rows []catalogTool) {
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Server != rows[j].Server {
+			return rows[i].Server < rows[j].Server
+		}
+		return rows[i].Tool < rows[j].Tool
+	})
+}
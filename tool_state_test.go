@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolStatePersistsAcrossStores(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("STELAE_STATE_HOME", base)
+	path := filepath.Join(base, "tool_state.json")
+
+	store := newToolOverrideStore(nil)
+	if err := store.loadState(path); err != nil {
+		t.Fatalf("loadState (missing file): %v", err)
+	}
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.setToolEnabled(toolToggle{Server: "fs", Tool: "delete", By: "token:abc", At: at, Reason: "destructive"}); err != nil {
+		t.Fatalf("setToolEnabled: %v", err)
+	}
+
+	restored := newToolOverrideStore(nil)
+	if err := restored.loadState(path); err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if toolEnabled(restored.current(), "fs", "delete") {
+		t.Fatal("restored store should keep fs/delete disabled")
+	}
+	history := restored.recentHistory(10)
+	if len(history) != 1 || history[0].Reason != "destructive" || history[0].By != "token:abc" {
+		t.Fatalf("unexpected history %+v", history)
+	}
+}
+
+func TestAdminCatalogViewReportsDisabledSources(t *testing.T) {
+	disabled := false
+	store := newToolOverrideStore(&ToolOverrideSet{
+		ToolOverrides: map[string]*ToolOverrideConfig{"write": {Enabled: &disabled}},
+		Servers:       map[string]*toolOverrideFragment{"legacy": {Enabled: &disabled}},
+		Aliases:       map[string]string{},
+		Renamed:       map[string]string{},
+	})
+	_ = store.setToolEnabled(toolToggle{Server: "fs", Tool: "delete", By: "admin:123", At: time.Now().UTC(), Reason: "cleanup"})
+	servers := map[string]*Server{
+		"fs":     {tools: []mcp.Tool{{Name: "read"}, {Name: "write"}, {Name: "delete"}}},
+		"legacy": {tools: []mcp.Tool{{Name: "old"}}},
+	}
+
+	view := adminCatalogView(servers, store)
+	enabled := view["enabled"].([]catalogTool)
+	if len(enabled) != 1 || enabled[0].Tool != "read" {
+		t.Fatalf("unexpected enabled rows %+v", enabled)
+	}
+	sources := map[string]catalogTool{}
+	for _, row := range view["disabled"].([]catalogTool) {
+		sources[row.Server+"/"+row.Tool] = row
+	}
+	if row := sources["fs/delete"]; row.Source != "runtime" || row.DisabledBy != "admin:123" || row.Reason != "cleanup" || row.DisabledAt == nil {
+		t.Fatalf("unexpected runtime row %+v", row)
+	}
+	if sources["fs/write"].Source != "overrides" || sources["legacy/old"].Source != "server" {
+		t.Fatalf("unexpected sources %+v", sources)
+	}
+
+	_ = store.setToolEnabled(toolToggle{Server: "fs", Tool: "write", Enabled: true, By: "admin:123", At: time.Now().UTC()})
+	if !toolEnabled(store.current(), "fs", "write") {
+		t.Fatal("restore should re-enable a tool disabled by overrides")
+	}
+}
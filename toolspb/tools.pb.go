@@ -0,0 +1,493 @@
+// Tool invocation over gRPC. ToolService mirrors the facade's tools/list and
+// tools/call against the aggregated catalog and is served on the main
+// listener with mcpProxy.grpcTools. Calls go through the facade, so tool
+// overrides, grants, read-only mode, budgets, and the audit log apply as
+// for JSON-RPC callers. Send the facade token as
+// "authorization: Bearer <token>" metadata and, optionally, a session id as
+// "mcp-session-id".
+//
+// Regenerate the Go bindings with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative toolspb/tools.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: toolspb/tools.proto
+
+package toolspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListToolsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsRequest) Reset() {
+	*x = ListToolsRequest{}
+	mi := &file_toolspb_tools_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsRequest) ProtoMessage() {}
+
+func (x *ListToolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_toolspb_tools_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsRequest.ProtoReflect.Descriptor instead.
+func (*ListToolsRequest) Descriptor() ([]byte, []int) {
+	return file_toolspb_tools_proto_rawDescGZIP(), []int{0}
+}
+
+type Tool struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Name         string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description  string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	InputSchema  *structpb.Struct       `protobuf:"bytes,3,opt,name=input_schema,json=inputSchema,proto3" json:"input_schema,omitempty"`
+	OutputSchema *structpb.Struct       `protobuf:"bytes,4,opt,name=output_schema,json=outputSchema,proto3" json:"output_schema,omitempty"`
+	Annotations  *structpb.Struct       `protobuf:"bytes,5,opt,name=annotations,proto3" json:"annotations,omitempty"`
+	// The complete MCP tool descriptor, for fields not mapped above.
+	Descriptor_   *structpb.Struct `protobuf:"bytes,6,opt,name=descriptor,proto3" json:"descriptor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tool) Reset() {
+	*x = Tool{}
+	mi := &file_toolspb_tools_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tool) ProtoMessage() {}
+
+func (x *Tool) ProtoReflect() protoreflect.Message {
+	mi := &file_toolspb_tools_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tool.ProtoReflect.Descriptor instead.
+func (*Tool) Descriptor() ([]byte, []int) {
+	return file_toolspb_tools_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Tool) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tool) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tool) GetInputSchema() *structpb.Struct {
+	if x != nil {
+		return x.InputSchema
+	}
+	return nil
+}
+
+func (x *Tool) GetOutputSchema() *structpb.Struct {
+	if x != nil {
+		return x.OutputSchema
+	}
+	return nil
+}
+
+func (x *Tool) GetAnnotations() *structpb.Struct {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+func (x *Tool) GetDescriptor_() *structpb.Struct {
+	if x != nil {
+		return x.Descriptor_
+	}
+	return nil
+}
+
+type ListToolsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tools         []*Tool                `protobuf:"bytes,1,rep,name=tools,proto3" json:"tools,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsResponse) Reset() {
+	*x = ListToolsResponse{}
+	mi := &file_toolspb_tools_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsResponse) ProtoMessage() {}
+
+func (x *ListToolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_toolspb_tools_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsResponse.ProtoReflect.Descriptor instead.
+func (*ListToolsResponse) Descriptor() ([]byte, []int) {
+	return file_toolspb_tools_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListToolsResponse) GetTools() []*Tool {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+type CallToolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Arguments     *structpb.Struct       `protobuf:"bytes,2,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallToolRequest) Reset() {
+	*x = CallToolRequest{}
+	mi := &file_toolspb_tools_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallToolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallToolRequest) ProtoMessage() {}
+
+func (x *CallToolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_toolspb_tools_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallToolRequest.ProtoReflect.Descriptor instead.
+func (*CallToolRequest) Descriptor() ([]byte, []int) {
+	return file_toolspb_tools_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CallToolRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CallToolRequest) GetArguments() *structpb.Struct {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+type Content struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "text", "image", "audio", "resource", or "resource_link".
+	Type     string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Text     string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	MimeType string `protobuf:"bytes,3,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	// Base64 data of image and audio content.
+	Data string `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// The complete MCP content block.
+	Raw           *structpb.Struct `protobuf:"bytes,5,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Content) Reset() {
+	*x = Content{}
+	mi := &file_toolspb_tools_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Content) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Content) ProtoMessage() {}
+
+func (x *Content) ProtoReflect() protoreflect.Message {
+	mi := &file_toolspb_tools_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Content.ProtoReflect.Descriptor instead.
+func (*Content) Descriptor() ([]byte, []int) {
+	return file_toolspb_tools_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Content) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Content) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Content) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Content) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *Content) GetRaw() *structpb.Struct {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+type CallToolResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Content           []*Content             `protobuf:"bytes,1,rep,name=content,proto3" json:"content,omitempty"`
+	StructuredContent *structpb.Struct       `protobuf:"bytes,2,opt,name=structured_content,json=structuredContent,proto3" json:"structured_content,omitempty"`
+	IsError           bool                   `protobuf:"varint,3,opt,name=is_error,json=isError,proto3" json:"is_error,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CallToolResponse) Reset() {
+	*x = CallToolResponse{}
+	mi := &file_toolspb_tools_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallToolResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallToolResponse) ProtoMessage() {}
+
+func (x *CallToolResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_toolspb_tools_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallToolResponse.ProtoReflect.Descriptor instead.
+func (*CallToolResponse) Descriptor() ([]byte, []int) {
+	return file_toolspb_tools_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CallToolResponse) GetContent() []*Content {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *CallToolResponse) GetStructuredContent() *structpb.Struct {
+	if x != nil {
+		return x.StructuredContent
+	}
+	return nil
+}
+
+func (x *CallToolResponse) GetIsError() bool {
+	if x != nil {
+		return x.IsError
+	}
+	return false
+}
+
+var File_toolspb_tools_proto protoreflect.FileDescriptor
+
+const file_toolspb_tools_proto_rawDesc = "" +
+	"\n" +
+	"\x13toolspb/tools.proto\x12\x0fstelae.tools.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x12\n" +
+	"\x10ListToolsRequest\"\xaa\x02\n" +
+	"\x04Tool\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12:\n" +
+	"\finput_schema\x18\x03 \x01(\v2\x17.google.protobuf.StructR\vinputSchema\x12<\n" +
+	"\routput_schema\x18\x04 \x01(\v2\x17.google.protobuf.StructR\foutputSchema\x129\n" +
+	"\vannotations\x18\x05 \x01(\v2\x17.google.protobuf.StructR\vannotations\x127\n" +
+	"\n" +
+	"descriptor\x18\x06 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"descriptor\"@\n" +
+	"\x11ListToolsResponse\x12+\n" +
+	"\x05tools\x18\x01 \x03(\v2\x15.stelae.tools.v1.ToolR\x05tools\"\\\n" +
+	"\x0fCallToolRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x125\n" +
+	"\targuments\x18\x02 \x01(\v2\x17.google.protobuf.StructR\targuments\"\x8d\x01\n" +
+	"\aContent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1b\n" +
+	"\tmime_type\x18\x03 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\tR\x04data\x12)\n" +
+	"\x03raw\x18\x05 \x01(\v2\x17.google.protobuf.StructR\x03raw\"\xa9\x01\n" +
+	"\x10CallToolResponse\x122\n" +
+	"\acontent\x18\x01 \x03(\v2\x18.stelae.tools.v1.ContentR\acontent\x12F\n" +
+	"\x12structured_content\x18\x02 \x01(\v2\x17.google.protobuf.StructR\x11structuredContent\x12\x19\n" +
+	"\bis_error\x18\x03 \x01(\bR\aisError2\xb2\x01\n" +
+	"\vToolService\x12R\n" +
+	"\tListTools\x12!.stelae.tools.v1.ListToolsRequest\x1a\".stelae.tools.v1.ListToolsResponse\x12O\n" +
+	"\bCallTool\x12 .stelae.tools.v1.CallToolRequest\x1a!.stelae.tools.v1.CallToolResponseB%Z#github.com/TBXark/mcp-proxy/toolspbb\x06proto3"
+
+var (
+	file_toolspb_tools_proto_rawDescOnce sync.Once
+	file_toolspb_tools_proto_rawDescData []byte
+)
+
+func file_toolspb_tools_proto_rawDescGZIP() []byte {
+	file_toolspb_tools_proto_rawDescOnce.Do(func() {
+		file_toolspb_tools_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_toolspb_tools_proto_rawDesc), len(file_toolspb_tools_proto_rawDesc)))
+	})
+	return file_toolspb_tools_proto_rawDescData
+}
+
+var file_toolspb_tools_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_toolspb_tools_proto_goTypes = []any{
+	(*ListToolsRequest)(nil),  // 0: stelae.tools.v1.ListToolsRequest
+	(*Tool)(nil),              // 1: stelae.tools.v1.Tool
+	(*ListToolsResponse)(nil), // 2: stelae.tools.v1.ListToolsResponse
+	(*CallToolRequest)(nil),   // 3: stelae.tools.v1.CallToolRequest
+	(*Content)(nil),           // 4: stelae.tools.v1.Content
+	(*CallToolResponse)(nil),  // 5: stelae.tools.v1.CallToolResponse
+	(*structpb.Struct)(nil),   // 6: google.protobuf.Struct
+}
+var file_toolspb_tools_proto_depIdxs = []int32{
+	6,  // 0: stelae.tools.v1.Tool.input_schema:type_name -> google.protobuf.Struct
+	6,  // 1: stelae.tools.v1.Tool.output_schema:type_name -> google.protobuf.Struct
+	6,  // 2: stelae.tools.v1.Tool.annotations:type_name -> google.protobuf.Struct
+	6,  // 3: stelae.tools.v1.Tool.descriptor:type_name -> google.protobuf.Struct
+	1,  // 4: stelae.tools.v1.ListToolsResponse.tools:type_name -> stelae.tools.v1.Tool
+	6,  // 5: stelae.tools.v1.CallToolRequest.arguments:type_name -> google.protobuf.Struct
+	6,  // 6: stelae.tools.v1.Content.raw:type_name -> google.protobuf.Struct
+	4,  // 7: stelae.tools.v1.CallToolResponse.content:type_name -> stelae.tools.v1.Content
+	6,  // 8: stelae.tools.v1.CallToolResponse.structured_content:type_name -> google.protobuf.Struct
+	0,  // 9: stelae.tools.v1.ToolService.ListTools:input_type -> stelae.tools.v1.ListToolsRequest
+	3,  // 10: stelae.tools.v1.ToolService.CallTool:input_type -> stelae.tools.v1.CallToolRequest
+	2,  // 11: stelae.tools.v1.ToolService.ListTools:output_type -> stelae.tools.v1.ListToolsResponse
+	5,  // 12: stelae.tools.v1.ToolService.CallTool:output_type -> stelae.tools.v1.CallToolResponse
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_toolspb_tools_proto_init() }
+func file_toolspb_tools_proto_init() {
+	if File_toolspb_tools_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_toolspb_tools_proto_rawDesc), len(file_toolspb_tools_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_toolspb_tools_proto_goTypes,
+		DependencyIndexes: file_toolspb_tools_proto_depIdxs,
+		MessageInfos:      file_toolspb_tools_proto_msgTypes,
+	}.Build()
+	File_toolspb_tools_proto = out.File
+	file_toolspb_tools_proto_goTypes = nil
+	file_toolspb_tools_proto_depIdxs = nil
+}
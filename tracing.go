@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultTracingServiceName = "mcp-proxy"
+	tracingShutdownTimeout    = 5 * time.Second
+)
+
+// TracingConfig exports OpenTelemetry spans for the dispatch path to an
+// OTLP/HTTP collector. Endpoint is the collector URL; a URL without a path
+// posts to /v1/traces. SampleRatio (default 1) is the fraction of new
+// traces recorded; traces started by a caller follow the caller's decision.
+type TracingConfig struct {
+	Endpoint    string            `json:"endpoint"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ServiceName string            `json:"serviceName,omitempty"`
+	SampleRatio float64           `json:"sampleRatio,omitempty"`
+}
+
+// tracer records the proxy's spans. Until setupTracing installs a provider
+// its spans are not recorded, but incoming trace context still reaches
+// downstream servers.
+var tracer = otel.Tracer("github.com/TBXark/mcp-proxy")
+
+// traceKey names span attributes.
+const (
+	traceKeyMethod  = attribute.Key("mcp.method")
+	traceKeyServer  = attribute.Key("mcp.server")
+	traceKeyTarget  = attribute.Key("mcp.target")
+	traceKeyStatus  = attribute.Key("mcp.status")
+	traceKeyAdapter = attribute.Key("mcp.adapter")
+)
+
+// setupTracing installs the W3C trace context propagator and, when conf
+// names an endpoint, a provider exporting to it. The returned function
+// flushes and stops the exporter.
+func setupTracing(ctx context.Context, conf *TracingConfig) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if conf == nil || conf.Endpoint == "" {
+		return func() {}, nil
+	}
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("tracing.endpoint must be an http or https URL, got %q", conf.Endpoint)
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = "/v1/traces"
+	}
+	if conf.SampleRatio < 0 || conf.SampleRatio > 1 {
+		return nil, errors.New("tracing.sampleRatio must be between 0 and 1")
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint.String())}
+	if len(conf.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(conf.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("tracing exporter: %w", err)
+	}
+	name := conf.ServiceName
+	if name == "" {
+		name = defaultTracingServiceName
+	}
+	ratio := conf.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", name))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger("tracing").Info("exporting spans", "endpoint", redactURL(endpoint.String()), "service", name, "sampleRatio", ratio)
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			logger("tracing").Warn("shutdown failed", "err", err)
+		}
+	}, nil
+}
+
+// tracingMiddleware starts a server span for each request, continuing the
+// caller's trace when it sent trace context. Requests the proxy re-sends
+// to itself (batch entries, fan-out legs) continue the span they came from.
+func tracingMiddleware(name string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+			}
+			ctx, span := tracer.Start(ctx, name+" "+r.Method, trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attribute.String("http.request.method", r.Method), attribute.String("url.path", r.URL.Path)))
+			defer span.End()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// traceHeaders returns the trace context headers for a downstream request
+// made under ctx.
+func traceHeaders(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// endSpanWithStatus records a call status (ok, error, timeout, cancelled,
+// or a refusal) on span and marks it failed unless the call succeeded.
+func endSpanWithStatus(span trace.Span, status string) {
+	span.SetAttributes(traceKeyStatus.String(status))
+	if status != "ok" {
+		span.SetStatus(codes.Error, status)
+	}
+	span.End()
+}
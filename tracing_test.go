@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingPropagatesToDownstream(t *testing.T) {
+	if _, err := setupTracing(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := tracer
+	tracer = provider.Tracer("test")
+	defer func() { tracer = prev }()
+
+	const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var sent map[string]string
+	handler := tracingMiddleware("facade")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetName("facade tools/call")
+		ctx, span := tracer.Start(r.Context(), "downstream tools/call")
+		sent = downstreamHeaderFunc(nil)(ctx)
+		endSpanWithStatus(span, "timeout")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Traceparent", incoming)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans", len(spans))
+	}
+	downstream, facade := spans[0], spans[1]
+	if facade.Name != "facade tools/call" || facade.SpanKind != trace.SpanKindServer || facade.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("facade span = %s kind=%v parent=%s", facade.Name, facade.SpanKind, facade.Parent.SpanID())
+	}
+	if downstream.Parent.SpanID() != facade.SpanContext.SpanID() || downstream.Status.Code != codes.Error {
+		t.Fatalf("downstream span parent=%s status=%v", downstream.Parent.SpanID(), downstream.Status)
+	}
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + downstream.SpanContext.SpanID().String() + "-01"
+	if sent["traceparent"] != want {
+		t.Fatalf("downstream headers = %v, want traceparent %s", sent, want)
+	}
+
+	// no trace context, no headers
+	if headers := downstreamHeaderFunc(nil)(context.Background()); len(headers) != 0 {
+		t.Fatalf("headers without a trace = %v", headers)
+	}
+}
+
+func TestTracingConfig(t *testing.T) {
+	for _, conf := range []*TracingConfig{
+		{Endpoint: "collector:4318"},
+		{Endpoint: "ftp://collector"},
+		{Endpoint: "http://collector:4318", SampleRatio: 2},
+	} {
+		if _, err := setupTracing(context.Background(), conf); err == nil || !strings.HasPrefix(err.Error(), "tracing.") {
+			t.Fatalf("%+v accepted: %v", conf, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTranscriptMaxBytes = 10 << 20
+
+// TranscriptConfig records every facade JSON-RPC exchange per session so it
+// can be exported for postmortems.
+type TranscriptConfig struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Dir      string `json:"dir,omitempty"`
+	MaxBytes int64  `json:"maxBytes,omitempty"`
+}
+
+// transcriptEntry is one line of a session transcript. Kind is request,
+// notification, or response; messages are redacted before they are written.
+type transcriptEntry struct {
+	At      time.Time       `json:"at"`
+	Kind    string          `json:"kind"`
+	Method  string          `json:"method,omitempty"`
+	Caller  string          `json:"caller,omitempty"`
+	Status  int             `json:"status,omitempty"`
+	Message json.RawMessage `json:"message,omitempty"`
+}
+
+type transcriptSummary struct {
+	Session  string    `json:"session"`
+	Bytes    int64     `json:"bytes"`
+	Updated  time.Time `json:"updated"`
+	Complete bool      `json:"complete"`
+}
+
+// transcriptRecorder appends to one JSONL file per session under dir. A
+// session that reaches maxBytes stops being recorded.
+type transcriptRecorder struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	full     map[string]bool
+	redact   map[string]struct{}
+}
+
+func newTranscriptRecorder(conf *TranscriptConfig) (*transcriptRecorder, error) {
+	if conf == nil || !conf.Enabled {
+		return nil, nil
+	}
+	dir := conf.Dir
+	if dir == "" {
+		dir = "transcripts"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(stateHome(), dir)
+	}
+	guarded, err := resolveGuardedPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("transcripts.dir %s: %w", conf.Dir, err)
+	}
+	if err := os.MkdirAll(guarded, 0o755); err != nil {
+		return nil, err
+	}
+	maxBytes := conf.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultTranscriptMaxBytes
+	}
+	logger("transcripts").Info("Recording facade sessions", "dir", guarded)
+	return &transcriptRecorder{dir: guarded, maxBytes: maxBytes, full: make(map[string]bool), redact: newRedactKeySet(nil)}, nil
+}
+
+// transcriptFileName maps a session key to a safe file name.
+func transcriptFileName(session string) string {
+	var b strings.Builder
+	for _, r := range session {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(b.String(), ".")
+	if name == "" {
+		name = "_"
+	}
+	return name + ".jsonl"
+}
+
+func (t *transcriptRecorder) path(session string) string {
+	return filepath.Join(t.dir, transcriptFileName(session))
+}
+
+func (t *transcriptRecorder) record(session string, entries ...transcriptEntry) {
+	if t == nil || session == "" {
+		return
+	}
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		entry.At = entry.At.UTC()
+		if len(entry.Message) > 0 {
+			var decoded any
+			if err := json.Unmarshal(entry.Message, &decoded); err == nil {
+				entry.Message, _ = json.Marshal(redactValue(decoded, t.redact))
+			} else {
+				entry.Message, _ = json.Marshal(string(entry.Message))
+			}
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.full[session] {
+		return
+	}
+	path := t.path(session)
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	if size+int64(buf.Len()) > t.maxBytes {
+		t.full[session] = true
+		logger("transcripts").Warn("session reached the size limit; recording stopped", "session", session, "maxBytes", t.maxBytes)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		logger("transcripts").Error("failed to open transcript", "path", path, "err", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		logger("transcripts").Error("failed to write transcript", "path", path, "err", err)
+	}
+}
+
+// list summarizes the recorded sessions, most recently updated first.
+func (t *transcriptRecorder) list() ([]transcriptSummary, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]transcriptSummary, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		session := strings.TrimSuffix(e.Name(), ".jsonl")
+		out = append(out, transcriptSummary{Session: session, Bytes: info.Size(), Updated: info.ModTime().UTC(), Complete: !t.full[session]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Updated.After(out[j].Updated) })
+	return out, nil
+}
+
+// export copies a session's transcript to w; it reports false when there is
+// none.
+func (t *transcriptRecorder) export(session string, w io.Writer) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, err := os.Open(t.path(session))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return true, err
+}
+
+func (t *transcriptRecorder) remove(session string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.full, session)
+	return os.Remove(t.path(session)) == nil
+}
+
+// transcriptCapture tees a facade reply so it can be recorded.
+type transcriptCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *transcriptCapture) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *transcriptCapture) Write(b []byte) (int, error) {
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// middleware records single JSON-RPC POSTs and their replies. Batches are
+// recorded entry by entry as they are re-dispatched; streams (GET) are not
+// recorded. It must run inside callerContextMiddleware.
+func (t *transcriptRecorder) middleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if t == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			trimmed := bytes.TrimSpace(body)
+			if len(trimmed) > 0 && trimmed[0] == '[' {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var req jsonrpcRequest
+			_ = json.Unmarshal(body, &req)
+			capture := &transcriptCapture{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(capture, r)
+
+			info, _ := callerFromContext(r.Context())
+			session := info.sessionKey()
+			// initialize is keyed by the session it created
+			if id := capture.Header().Get(sessionIDHeader); id != "" && info.SessionID == "" {
+				session = id
+			}
+			kind := "request"
+			if req.ID == nil {
+				kind = "notification"
+			}
+			entries := []transcriptEntry{{At: start, Kind: kind, Method: req.Method, Caller: info.Identity, Message: body}}
+			if reply := bytes.TrimSpace(capture.body.Bytes()); len(reply) > 0 || kind == "request" {
+				entries = append(entries, transcriptEntry{At: time.Now(), Kind: "response", Method: req.Method, Status: capture.status, Message: reply})
+			}
+			t.record(session, entries...)
+		})
+	}
+}
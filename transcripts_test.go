@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranscriptMiddlewareRecordsExchanges(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	rec, err := newTranscriptRecorder(&TranscriptConfig{Enabled: true})
+	if err != nil || rec == nil {
+		t.Fatalf("newTranscriptRecorder: %v", err)
+	}
+	handler := chainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if isInitializeRequest(body) {
+			w.Header().Set(sessionIDHeader, "sess-1")
+		}
+		if bytes.Contains(body, []byte(`"id"`)) {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}), rec.middleware(), callerContextMiddleware())
+
+	post := func(session, body string) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		if session != "" {
+			req.Header.Set(sessionIDHeader, session)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	post("", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	post("sess-1", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	post("sess-1", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"t","arguments":{"token":"hunter2"}}}`)
+
+	var buf bytes.Buffer
+	found, err := rec.export("sess-1", &buf)
+	if err != nil || !found {
+		t.Fatalf("export = %v, %v", found, err)
+	}
+	var kinds []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry transcriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("bad line %q: %v", scanner.Text(), err)
+		}
+		kinds = append(kinds, entry.Method+":"+entry.Kind)
+	}
+	want := "initialize:request initialize:response notifications/initialized:notification tools/call:request tools/call:response"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("kinds = %s, want %s", got, want)
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("transcript was not redacted: %s", buf.String())
+	}
+
+	list, err := rec.list()
+	if err != nil || len(list) != 1 || list[0].Session != "sess-1" {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if !rec.remove("sess-1") || rec.remove("sess-1") {
+		t.Fatal("remove should succeed exactly once")
+	}
+}
+
+func TestTranscriptRecorderStopsAtMaxBytes(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	rec, err := newTranscriptRecorder(&TranscriptConfig{Enabled: true, MaxBytes: 200})
+	if err != nil {
+		t.Fatalf("newTranscriptRecorder: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		rec.record("s", transcriptEntry{Kind: "request", Method: "ping", Message: json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)})
+	}
+	list, _ := rec.list()
+	if len(list) != 1 || list[0].Complete || list[0].Bytes > 200 {
+		t.Fatalf("list = %+v", list)
+	}
+}
+
+func TestTranscriptFileNameIsSafe(t *testing.T) {
+	if got := transcriptFileName("../../etc/passwd"); strings.Contains(got, "/") || strings.HasPrefix(got, ".") {
+		t.Fatalf("unsafe file name %q", got)
+	}
+	if got := transcriptFileName("token:ab12"); got != "token_ab12.jsonl" {
+		t.Fatalf("file name = %q", got)
+	}
+}
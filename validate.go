@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-sphere/confstore"
+	"github.com/go-sphere/confstore/codec"
+)
+
+// validateConfig checks the config at path before it is deployed: its
+// shape against the config schema (types, unknown keys, duplicate keys),
+// that of each configDir file, and then the constraints between fields the
+// proxy would otherwise only report at startup. It returns one message per
+// problem; the error is for a config that cannot be read at all.
+func validateConfig(path string, insecure, expandEnv bool, httpHeaders string, httpTimeout int, profile string) ([]string, error) {
+	pro, err := newConfProvider(path, insecure, expandEnv, httpHeaders, httpTimeout)
+	if err != nil {
+		return nil, err
+	}
+	data, err := pro.Read(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	problems, err := checkConfigSchema(data)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := confstore.Load[FullConfig](pro, codec.JsonCodec())
+	if err != nil {
+		if len(problems) > 0 {
+			// a type mismatch the schema check already reported
+			return problems, nil
+		}
+		return nil, err
+	}
+	dir, names, err := configDirFiles(conf, path, expandEnv)
+	if err != nil {
+		return append(problems, err.Error()), nil
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("configDir: %w", err)
+		}
+		if expandEnv {
+			data = []byte(os.ExpandEnv(string(data)))
+		}
+		fileProblems, err := checkServerConfigSchema(data)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("configDir %s: %v", name, err))
+			continue
+		}
+		for _, problem := range fileProblems {
+			problems = append(problems, "configDir "+name+": "+problem)
+		}
+	}
+	if len(problems) > 0 {
+		// the rest assumes a config of the right shape
+		return problems, nil
+	}
+
+	config, err := load(path, insecure, expandEnv, httpHeaders, httpTimeout, profile)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	return checkConfigConstraints(config), nil
+}
+
+// checkConfigConstraints reports what a loaded config gets wrong across
+// fields: the checks runProxy makes before it connects anything, run
+// without side effects.
+func checkConfigConstraints(config *Config) []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	proxy := config.McpProxy
+
+	if u, err := url.Parse(proxy.BaseURL); err != nil {
+		add("mcpProxy.baseURL: %v", err)
+	} else if u.Scheme != "" && ((u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		add("mcpProxy.baseURL: %q is not an http(s) URL with a host", proxy.BaseURL)
+	}
+	if proxy.Addr != "" {
+		if _, _, err := net.SplitHostPort(proxy.Addr); err != nil {
+			add("mcpProxy.addr: %v", err)
+		}
+	}
+	switch proxy.Type {
+	case MCPServerTypeSSE, MCPServerTypeStreamable:
+	default:
+		add("mcpProxy.type: unknown type %q (want sse or streamable-http)", proxy.Type)
+	}
+	if conf := proxy.AccessLog; conf != nil {
+		switch strings.ToLower(conf.Format) {
+		case "", accessLogCommon, accessLogJSON:
+		default:
+			add("mcpProxy.accessLog.format: unknown format %q", conf.Format)
+		}
+	}
+	// the constructors name the field they reject
+	for _, check := range []func() error{
+		func() error { _, err := newToolScopes(proxy.ToolScopes); return err },
+		func() error { _, err := newRPCParser(proxy.RequestLimits); return err },
+		func() error { _, err := newCORSPolicy(proxy.CORS); return err },
+		func() error { _, err := newHeartbeatTuner(proxy.TrustedProxies); return err },
+		func() error { _, err := newToolConflictPolicy(proxy.ConflictPolicy); return err },
+		func() error { _, err := newReplicaBalancer(proxy.LoadBalancing); return err },
+		func() error { _, err := newVirtualServerSet(proxy.VirtualServers, config.McpServers); return err },
+		func() error { _, err := newLogHandler(io.Discard, proxy.Logging, config.McpServers); return err },
+	} {
+		if err := check(); err != nil && strings.HasPrefix(err.Error(), "mcpServers.") {
+			add("%v", err)
+		} else if err != nil {
+			add("mcpProxy.%v", err)
+		}
+	}
+
+	names := make([]string, 0, len(config.McpServers))
+	for name := range config.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		clientConfig := config.McpServers[name]
+		if err := validServerName(name); err != nil {
+			add("mcpServers: %v", err)
+		}
+		if _, err := parseMCPClientConfigV2(clientConfig); err != nil {
+			add("mcpServers.%s: %v", name, err)
+		}
+		if clientConfig.URL != "" {
+			if u, err := url.Parse(clientConfig.URL); err != nil || u.Host == "" {
+				add("mcpServers.%s.url: %q is not a URL with a host", name, clientConfig.URL)
+			}
+		}
+		if clientConfig.Group != "" && config.McpServers[clientConfig.Group] != nil {
+			add("mcpServers.%s.group: %q is also a server name", name, clientConfig.Group)
+		}
+		if affinity := clientConfig.Options.SessionAffinity; affinity != nil {
+			if err := affinity.validate(); err != nil {
+				add("mcpServers.%s.options.%v", name, err)
+			}
+		}
+	}
+	return problems
+}
+
+// runValidate is the validate subcommand: it checks the config files given
+// as arguments and exits non-zero if any has a problem.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	insecure := flags.Bool("insecure", false, "allow insecure HTTPS connections by skipping TLS certificate verification")
+	expandEnv := flags.Bool("expand-env", true, "expand environment variables in config file")
+	httpHeaders := flags.String("http-headers", "", "optional HTTP headers for config URL, format: 'Key1:Value1;Key2:Value2'")
+	httpTimeout := flags.Int("http-timeout", 10, "HTTP timeout in seconds when fetching config from URL")
+	profile := flags.String("profile", "", "config profile to validate (defaults to $STELAE_PROFILE)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s validate [flags] <config>...\n", filepath.Base(os.Args[0]))
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// load narrates what it reads; only the problems matter here
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	status := 0
+	for _, path := range flags.Args() {
+		problems, err := validateConfig(path, *insecure, *expandEnv, *httpHeaders, *httpTimeout, activeProfile(*profile))
+		if err != nil {
+			problems = []string{err.Error()}
+		}
+		if len(problems) == 0 {
+			fmt.Printf("%s: ok\n", path)
+			continue
+		}
+		status = 1
+		for _, problem := range problems {
+			fmt.Printf("%s: %s\n", path, problem)
+		}
+	}
+	return status
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	validate := func(path string) []string {
+		t.Helper()
+		problems, err := validateConfig(path, false, false, "", 0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return problems
+	}
+
+	ok := write("ok.json", `{
+		"mcpProxy": {"baseURL": "https://proxy.example.com", "addr": ":9090", "type": "streamable-http"},
+		"mcpServers": {
+			"fs-1": {"command": "fs-server", "group": "fs"},
+			"fs-2": {"command": "fs-server", "group": "fs"},
+			"web": {"url": "http://web/mcp", "transportType": "streamable-http"}
+		}
+	}`)
+	if problems := validate(ok); len(problems) != 0 {
+		t.Fatalf("valid config problems = %v", problems)
+	}
+
+	bad := write("bad.json", `{
+		"mcpProxy": {"baseURL": "localhost:9090/mcp", "addr": "9090", "type": "websocket",
+			"loadBalancing": {"strategy": "random"}},
+		"mcpServers": {
+			"admin": {"command": "admin-server"},
+			"fs": {"command": "fs-server"},
+			"fs-1": {"command": "fs-server", "group": "fs", "options": {"sessionAffinity": {"key": "cookie"}}},
+			"web": {"url": "/mcp"}
+		}
+	}`)
+	want := []string{
+		`mcpProxy.baseURL: "localhost:9090/mcp" is not an http(s) URL with a host`,
+		"mcpProxy.addr: address 9090: missing port in address",
+		`mcpProxy.type: unknown type "websocket" (want sse or streamable-http)`,
+		`mcpProxy.loadBalancing: unknown strategy "random" (want round-robin or least-connections)`,
+		`mcpServers: server name "admin" is reserved`,
+		`mcpServers.fs-1.group: "fs" is also a server name`,
+		`mcpServers.fs-1.options.sessionAffinity: unknown key "cookie" (want session, caller, or header)`,
+		`mcpServers.web.url: "/mcp" is not a URL with a host`,
+	}
+	if problems := validate(bad); strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("problems =\n%s\nwant\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+
+	// shape problems are reported before the config is loaded
+	withDir := write("dir.json", `{"configDir": "servers.d", "mcpProxy": {"addr": ":9090"}, "mcpServers": {}}`)
+	write("servers.d/git.json", `{"command": "git-server", "timeout": "10s"}`)
+	if problems := validate(withDir); len(problems) != 1 || problems[0] != "configDir git.json: timeout: expected integer, got string" {
+		t.Fatalf("configDir problems = %v", problems)
+	}
+	write("servers.d/git.json", `{"command": "git-server", "dependsOn": ["missing"]}`)
+	if problems := validate(withDir); len(problems) != 1 || !strings.Contains(problems[0], "missing") {
+		t.Fatalf("load problems = %v", problems)
+	}
+}
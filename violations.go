@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	rpcCodeProtocolViolation = -32007
+
+	defaultQuarantineMaxBytes = 64 << 10
+	quarantineFileMaxSize     = 20 << 20
+	quarantineFileMaxBackups  = 3
+)
+
+// validateDownstreamResponse checks a response message as a downstream
+// server sent it for the JSON-RPC envelope and the result shape the method
+// promises.
+func validateDownstreamResponse(method string, body []byte) error {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("malformed JSON-RPC response: %v", err)
+	}
+	rawErr, hasErr := envelope["error"]
+	rawResult, hasResult := envelope["result"]
+	if hasErr {
+		var rpcErr jsonrpcError
+		if err := json.Unmarshal(rawErr, &rpcErr); err != nil {
+			return fmt.Errorf("malformed error object: %v", err)
+		}
+		return nil
+	}
+	if !hasResult {
+		return fmt.Errorf("response has neither result nor error")
+	}
+	var result map[string]any
+	if err := json.Unmarshal(rawResult, &result); err != nil {
+		return fmt.Errorf("result is not an object: %v", err)
+	}
+	switch method {
+	case "tools/call":
+		blocks, ok := result["content"].([]any)
+		if !ok {
+			return fmt.Errorf("tools/call result.content must be an array")
+		}
+		return validateContentBlocks("result.content", blocks)
+	case "prompts/get":
+		messages, ok := result["messages"].([]any)
+		if !ok {
+			return fmt.Errorf("prompts/get result.messages must be an array")
+		}
+		for i, raw := range messages {
+			msg, ok := raw.(map[string]any)
+			if !ok {
+				return fmt.Errorf("result.messages[%d] must be an object", i)
+			}
+			content, ok := msg["content"].(map[string]any)
+			if !ok {
+				return fmt.Errorf("result.messages[%d].content must be an object", i)
+			}
+			if err := validateContentBlocks(fmt.Sprintf("result.messages[%d]", i), []any{content}); err != nil {
+				return err
+			}
+		}
+	case "resources/read":
+		contents, ok := result["contents"].([]any)
+		if !ok {
+			return fmt.Errorf("resources/read result.contents must be an array")
+		}
+		for i, raw := range contents {
+			item, ok := raw.(map[string]any)
+			if !ok {
+				return fmt.Errorf("result.contents[%d] must be an object", i)
+			}
+			if uri, _ := item["uri"].(string); uri == "" {
+				return fmt.Errorf("result.contents[%d].uri is required", i)
+			}
+		}
+	}
+	return nil
+}
+
+// validateContentBlocks checks each block's type and its required members.
+func validateContentBlocks(at string, blocks []any) error {
+	for i, raw := range blocks {
+		block, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s[%d] must be an object", at, i)
+		}
+		typ, _ := block["type"].(string)
+		var required []string
+		switch typ {
+		case "text":
+			required = []string{"text"}
+		case "image", "audio":
+			required = []string{"data", "mimeType"}
+		case "resource_link":
+			required = []string{"uri"}
+		case "resource":
+			if _, ok := block["resource"].(map[string]any); !ok {
+				return fmt.Errorf("%s[%d].resource must be an object", at, i)
+			}
+		case "":
+			return fmt.Errorf("%s[%d].type is required", at, i)
+		default:
+			return fmt.Errorf("%s[%d] has unknown type %q", at, i, typ)
+		}
+		for _, field := range required {
+			if _, ok := block[field].(string); !ok {
+				return fmt.Errorf("%s[%d] (%s) requires string %s", at, i, typ, field)
+			}
+		}
+	}
+	return nil
+}
+
+type violationStats struct {
+	Server        string    `json:"server"`
+	Count         uint64    `json:"count"`
+	LastAt        time.Time `json:"lastAt"`
+	LastViolation string    `json:"lastViolation"`
+	LastMethod    string    `json:"lastMethod"`
+}
+
+type quarantineEntry struct {
+	ID        string    `json:"id"`
+	At        time.Time `json:"at"`
+	Server    string    `json:"server"`
+	Method    string    `json:"method"`
+	Target    string    `json:"target"`
+	Violation string    `json:"violation"`
+	Bytes     int       `json:"bytes"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Payload   string    `json:"payload"`
+}
+
+// protocolViolations counts invalid downstream responses per server and keeps
+// a size-capped copy of each offending payload in a quarantine file. Payloads
+// that are JSON have sensitive members masked first.
+type protocolViolations struct {
+	mu       sync.Mutex
+	counts   map[string]*violationStats
+	out      *rotatingFile
+	maxBytes int
+	redact   map[string]struct{}
+}
+
+func newProtocolViolations() *protocolViolations {
+	v := &protocolViolations{counts: make(map[string]*violationStats), maxBytes: defaultQuarantineMaxBytes, redact: newRedactKeySet(nil)}
+	path, err := resolveLogFilePath(filepath.Join(stateHome(), "quarantine", "violations.jsonl"))
+	if err == nil {
+		v.out, err = newRotatingFile(path, quarantineFileMaxSize, quarantineFileMaxBackups)
+	}
+	if err != nil {
+		logger("quarantine").Error("disabled", "err", err)
+	}
+	return v
+}
+
+// record counts a violation, quarantines the payload, and returns the entry id.
+func (v *protocolViolations) record(server, method, target string, violation error, payload []byte) string {
+	entry := quarantineEntry{
+		ID:        uuid.New().String(),
+		At:        time.Now().UTC(),
+		Server:    server,
+		Method:    method,
+		Target:    target,
+		Violation: violation.Error(),
+		Bytes:     len(payload),
+	}
+	payload = redactRawJSON(payload, v.redact)
+	if len(payload) > v.maxBytes {
+		payload = payload[:v.maxBytes]
+		entry.Truncated = true
+	}
+	entry.Payload = string(payload)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	stats := v.counts[server]
+	if stats == nil {
+		stats = &violationStats{Server: server}
+		v.counts[server] = stats
+	}
+	stats.Count++
+	stats.LastAt = entry.At
+	stats.LastViolation = entry.Violation
+	stats.LastMethod = method
+	if v.out != nil {
+		if data, err := json.Marshal(entry); err == nil {
+			_, _ = v.out.Write(append(data, '\n'))
+		}
+	}
+	logger("quarantine").Warn("protocol violation", "method", method, "server", server, "target", target, "id", entry.ID, "violation", entry.Violation)
+	return entry.ID
+}
+
+func (v *protocolViolations) snapshot() []violationStats {
+	v.mu.Lock()
+	out := make([]violationStats, 0, len(v.counts))
+	for _, s := range v.counts {
+		out = append(out, *s)
+	}
+	v.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Server < out[j].Server })
+	return out
+}
+
+// downstreamViolation fails a call whose response broke the protocol; the
+// response as the server sent it is quarantined under quarantineID.
+type downstreamViolation struct {
+	server       string
+	method       string
+	violation    error
+	quarantineID string
+}
+
+func (e *downstreamViolation) Error() string {
+	return fmt.Sprintf("%s %s response violates the protocol: %v", e.server, e.method, e.violation)
+}
+
+// violationError is the facade error returned instead of the invalid payload,
+// in the request's locale.
+func violationError(ctx context.Context, id any, server, method string, violation error, quarantineID string) jsonrpcResponse {
+	return withErrorData(localizedErrors(ctx).response(id, errNameProtocolViolation, map[string]string{"server": server}), map[string]any{
+		"server":       server,
+		"method":       method,
+		"violation":    violation.Error(),
+		"quarantineId": quarantineID,
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateDownstreamResponse(t *testing.T) {
+	cases := []struct {
+		name    string
+		method  string
+		body    string
+		wantErr string
+	}{
+		{"valid tool result", "tools/call", `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"ok"}]}}`, ""},
+		{"valid error", "tools/call", `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"bad args"}}`, ""},
+		{"malformed json", "tools/call", `{"jsonrpc":"2.0",`, "malformed JSON-RPC"},
+		{"downstream error passes", "tools/call", `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"failed to unmarshal response: invalid character 'x'"}}`, ""},
+		{"malformed error object", "tools/call", `{"jsonrpc":"2.0","id":1,"error":{"code":"oops"}}`, "malformed error object"},
+		{"missing result", "tools/call", `{"jsonrpc":"2.0","id":1}`, "neither result nor error"},
+		{"content not array", "tools/call", `{"result":{"content":"hello"}}`, "must be an array"},
+		{"unknown block type", "tools/call", `{"result":{"content":[{"type":"video"}]}}`, `unknown type "video"`},
+		{"image without data", "tools/call", `{"result":{"content":[{"type":"image","mimeType":"image/png"}]}}`, "requires string data"},
+		{"prompt message content", "prompts/get", `{"result":{"messages":[{"role":"user","content":{"type":"text"}}]}}`, "requires string text"},
+		{"resource without uri", "resources/read", `{"result":{"contents":[{"text":"x"}]}}`, "uri is required"},
+	}
+	for _, tc := range cases {
+		err := validateDownstreamResponse(tc.method, []byte(tc.body))
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestProtocolViolationsQuarantineAndCount(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("STELAE_STATE_HOME", base)
+	v := newProtocolViolations()
+	v.maxBytes = 8
+	id := v.record("flaky", "tools/call", "search", errors.New("malformed"), []byte(`{"broken payload`))
+	v.record("flaky", "tools/call", "search", errors.New("again"), []byte(`x`))
+
+	stats := v.snapshot()
+	if len(stats) != 1 || stats[0].Count != 2 || stats[0].LastViolation != "again" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	f, err := os.Open(filepath.Join(base, "quarantine", "violations.jsonl"))
+	if err != nil {
+		t.Fatalf("open quarantine: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("expected quarantine entry")
+	}
+	var entry quarantineEntry
+	if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+		t.Fatalf("decode entry: %v", err)
+	}
+	if entry.ID != id || !entry.Truncated || entry.Payload != `{"broken` || entry.Bytes != 16 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+
+	secret := []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":"x","password":"hunter2"}}`)
+	v.maxBytes = defaultQuarantineMaxBytes
+	v.record("flaky", "tools/call", "login", errors.New("bad content"), secret)
+	scanner.Scan()
+	scanner.Scan()
+	if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+		t.Fatalf("decode entry: %v", err)
+	}
+	if strings.Contains(entry.Payload, "hunter2") || !strings.Contains(entry.Payload, redactedValue) || entry.Bytes != len(secret) {
+		t.Fatalf("quarantined payload not redacted: %+v", entry)
+	}
+
+	resp := violationError(context.Background(), 1, "flaky", "tools/call", errors.New("malformed"), id)
+	if resp.Error.Code != rpcCodeProtocolViolation || !strings.Contains(resp.Error.Message, "flaky") {
+		t.Fatalf("unexpected facade error %+v", resp.Error)
+	}
+}
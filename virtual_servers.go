@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VirtualServerConfig composes a catalog server from tools of several
+// downstream servers. The virtual server lists the tools under its own name,
+// and the source servers stop listing them; calls still go to the source.
+type VirtualServerConfig struct {
+	Description string               `json:"description,omitempty"`
+	Tools       []*VirtualToolConfig `json:"tools"`
+}
+
+// VirtualToolConfig adopts one tool. Tool is its name as Server lists it;
+// Name renames it (default Tool) and Description replaces its description.
+type VirtualToolConfig struct {
+	Server      string `json:"server"`
+	Tool        string `json:"tool"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (t *VirtualToolConfig) exposedName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Tool
+}
+
+// virtualServerSet maps virtual servers onto their sources. A nil set has no
+// virtual servers.
+type virtualServerSet struct {
+	conf  map[string]*VirtualServerConfig
+	names []string
+	// adopted holds, per source server, the tools virtual servers took.
+	adopted map[string]map[string]bool
+	// sources maps a virtual server's tool to its source.
+	sources map[string]map[string]toolRoute
+}
+
+// newVirtualServerSet validates conf against the configured servers: a
+// virtual server may not share its name with a server or group, and each of
+// its tools needs a source and a name of its own.
+func newVirtualServerSet(conf map[string]*VirtualServerConfig, servers map[string]*MCPClientConfigV2) (*virtualServerSet, error) {
+	if len(conf) == 0 {
+		return nil, nil
+	}
+	groups := make(map[string]bool)
+	for _, clientConfig := range servers {
+		if clientConfig != nil && clientConfig.Group != "" {
+			groups[clientConfig.Group] = true
+		}
+	}
+	v := &virtualServerSet{
+		conf:    conf,
+		adopted: make(map[string]map[string]bool),
+		sources: make(map[string]map[string]toolRoute),
+	}
+	for name, vs := range conf {
+		if strings.TrimSpace(name) == "" || vs == nil {
+			return nil, fmt.Errorf("virtualServers: empty entry %q", name)
+		}
+		if servers[name] != nil || groups[name] {
+			return nil, fmt.Errorf("virtualServers: %s is also a server or group name", name)
+		}
+		sources := make(map[string]toolRoute, len(vs.Tools))
+		for i, tool := range vs.Tools {
+			if tool == nil || tool.Server == "" || tool.Tool == "" {
+				return nil, fmt.Errorf("virtualServers.%s.tools[%d]: server and tool are required", name, i)
+			}
+			if _, dup := sources[tool.exposedName()]; dup {
+				return nil, fmt.Errorf("virtualServers.%s: tool %s is listed twice", name, tool.exposedName())
+			}
+			sources[tool.exposedName()] = toolRoute{server: tool.Server, tool: tool.Tool}
+			if v.adopted[tool.Server] == nil {
+				v.adopted[tool.Server] = make(map[string]bool)
+			}
+			v.adopted[tool.Server][tool.Tool] = true
+		}
+		v.sources[name] = sources
+		v.names = append(v.names, name)
+	}
+	sort.Strings(v.names)
+	return v, nil
+}
+
+// compose returns the catalog view of snapshot: source servers without the
+// adopted tools, plus one entry per virtual server listing the tools its
+// sources currently have. snapshot is not modified.
+func (v *virtualServerSet) compose(snapshot map[string]*Server) map[string]*Server {
+	if v == nil {
+		return snapshot
+	}
+	out := make(map[string]*Server, len(snapshot)+len(v.names))
+	for name, srv := range snapshot {
+		adopted := v.adopted[name]
+		if len(adopted) == 0 {
+			out[name] = srv
+			continue
+		}
+		view := *srv
+		view.tools = nil
+		for _, tool := range srv.tools {
+			if !adopted[tool.Name] {
+				view.tools = append(view.tools, tool)
+			}
+		}
+		out[name] = &view
+	}
+	for _, name := range v.names {
+		virtual := &Server{name: name}
+		for _, vt := range v.conf[name].Tools {
+			src := snapshot[vt.Server]
+			if src == nil {
+				continue
+			}
+			for _, tool := range src.tools {
+				if tool.Name != vt.Tool {
+					continue
+				}
+				tool.Name = vt.exposedName()
+				if vt.Description != "" {
+					tool.Description = vt.Description
+				}
+				virtual.tools = append(virtual.tools, tool)
+				break
+			}
+		}
+		out[name] = virtual
+	}
+	return out
+}
+
+// source maps a route to a virtual server's tool onto the server and tool
+// that serve it; other routes are returned unchanged.
+func (v *virtualServerSet) source(route toolRoute) toolRoute {
+	if v == nil {
+		return route
+	}
+	if src, ok := v.sources[route.server][route.tool]; ok {
+		return src
+	}
+	return route
+}
+
+// manifestEntries describes each virtual server for the manifest's servers
+// list, reached through the facade endpoint of base.
+func (v *virtualServerSet) manifestEntries(base map[string]any, catalog map[string]*Server) []map[string]any {
+	if v == nil {
+		return nil
+	}
+	out := make([]map[string]any, 0, len(v.names))
+	for _, name := range v.names {
+		entry := make(map[string]any, len(base)+3)
+		for k, val := range base {
+			entry[k] = val
+		}
+		entry["name"] = name
+		if desc := v.conf[name].Description; desc != "" {
+			entry["description"] = desc
+		}
+		tools := []string{}
+		if srv := catalog[name]; srv != nil {
+			for _, tool := range srv.tools {
+				tools = append(tools, tool.Name)
+			}
+		}
+		entry["tools"] = tools
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestVirtualServerSet(t *testing.T) {
+	configs := map[string]*MCPClientConfigV2{"github": {}, "fs": {}, "kb-1": {Group: "kb"}}
+	for name, conf := range map[string]map[string]*VirtualServerConfig{
+		"server name": {"fs": {Tools: []*VirtualToolConfig{{Server: "github", Tool: "create_review"}}}},
+		"group name":  {"kb": {Tools: []*VirtualToolConfig{{Server: "github", Tool: "create_review"}}}},
+		"no source":   {"review": {Tools: []*VirtualToolConfig{{Tool: "create_review"}}}},
+		"duplicate": {"review": {Tools: []*VirtualToolConfig{
+			{Server: "github", Tool: "create_review", Name: "read"},
+			{Server: "fs", Tool: "read"},
+		}}},
+	} {
+		if _, err := newVirtualServerSet(conf, configs); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if v, err := newVirtualServerSet(nil, configs); err != nil || v != nil {
+		t.Fatalf("no virtual servers = %v, %v", v, err)
+	}
+
+	v, err := newVirtualServerSet(map[string]*VirtualServerConfig{
+		"code-review": {Description: "Review pull requests", Tools: []*VirtualToolConfig{
+			{Server: "github", Tool: "create_review", Name: "submit_review", Description: "Submit the review."},
+			{Server: "fs", Tool: "read_file"},
+			{Server: "gone", Tool: "lint"},
+		}},
+	}, configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := map[string]*Server{
+		"github": {name: "github", tools: []mcp.Tool{{Name: "create_review", Description: "Create a review"}, {Name: "list_issues"}}},
+		"fs":     {name: "fs", tools: []mcp.Tool{{Name: "read_file"}, {Name: "write_file"}}},
+	}
+	catalog := v.compose(snapshot)
+	toolNames := func(srv *Server) []string {
+		var names []string
+		for _, tool := range srv.tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	if got := toolNames(catalog["code-review"]); !reflect.DeepEqual(got, []string{"submit_review", "read_file"}) {
+		t.Fatalf("virtual tools = %v", got)
+	}
+	if catalog["code-review"].tools[0].Description != "Submit the review." {
+		t.Fatalf("description = %q", catalog["code-review"].tools[0].Description)
+	}
+	if got := toolNames(catalog["github"]); !reflect.DeepEqual(got, []string{"list_issues"}) {
+		t.Fatalf("github still lists %v", got)
+	}
+	if len(snapshot["github"].tools) != 2 || snapshot["github"].tools[0].Description != "Create a review" {
+		t.Fatal("compose modified the snapshot")
+	}
+
+	if got := v.source(toolRoute{server: "code-review", tool: "submit_review"}); got != (toolRoute{server: "github", tool: "create_review"}) {
+		t.Fatalf("source = %+v", got)
+	}
+	if got := v.source(toolRoute{server: "fs", tool: "write_file"}); got != (toolRoute{server: "fs", tool: "write_file"}) {
+		t.Fatalf("a plain route changed: %+v", got)
+	}
+
+	entries := v.manifestEntries(map[string]any{"name": "stelae", "url": "http://localhost/mcp"}, catalog)
+	if len(entries) != 1 || entries[0]["name"] != "code-review" || entries[0]["url"] != "http://localhost/mcp" ||
+		entries[0]["description"] != "Review pull requests" || !reflect.DeepEqual(entries[0]["tools"], []string{"submit_review", "read_file"}) {
+		t.Fatalf("manifest entries = %v", entries)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Warning codes of non-fatal conditions met while serving a facade request.
+const (
+	// warnToolConflict: several servers expose the called tool and the
+	// conflict policy picked one.
+	warnToolConflict = "tool_conflict"
+	// warnSchemaPinned: a tool's live schemas drifted from its pin and the
+	// facade still lists the pinned ones.
+	warnSchemaPinned = "schema_pinned"
+	// warnResponseSanitized: the downstream response was repaired before
+	// it was returned (options.sanitizeResponses).
+	warnResponseSanitized = "response_sanitized"
+	// warnAdapterFallback: the result had no structuredContent and no
+	// declared output schema, so its text was wrapped as {"result": text}.
+	warnAdapterFallback = "adapter_fallback"
+	// warnTruncated: the content was cut at a size limit.
+	warnTruncated = "truncated"
+)
+
+// responseWarning is one entry of result._meta["x-stelae"].warnings.
+type responseWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Server  string `json:"server,omitempty"`
+	Tool    string `json:"tool,omitempty"`
+}
+
+// responseWarnings collects the warnings of one facade request.
+type responseWarnings struct {
+	mu   sync.Mutex
+	list []responseWarning
+}
+
+type warningsKey struct{}
+
+// withWarnings gives a facade request a fresh warning collector. Requests
+// re-entering the facade (batch entries, fan_out legs) get their own.
+func withWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warningsKey{}, &responseWarnings{})
+}
+
+// warn records a warning for the request of ctx; without a collector it
+// does nothing.
+func warn(ctx context.Context, w responseWarning) {
+	c, _ := ctx.Value(warningsKey{}).(*responseWarnings)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.list = append(c.list, w)
+	c.mu.Unlock()
+}
+
+// warnings returns the warnings recorded for the request of ctx.
+func warnings(ctx context.Context) []responseWarning {
+	c, _ := ctx.Value(warningsKey{}).(*responseWarnings)
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]responseWarning(nil), c.list...)
+}
+
+// attachWarnings adds the request's warnings to result._meta["x-stelae"],
+// keeping any _meta the result already has.
+func attachWarnings(ctx context.Context, result map[string]any) map[string]any {
+	list := warnings(ctx)
+	if result == nil || len(list) == 0 {
+		return result
+	}
+	meta, _ := result["_meta"].(map[string]any)
+	meta = copyStringAnyMap(meta)
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	stelae, _ := meta["x-stelae"].(map[string]any)
+	stelae = copyStringAnyMap(stelae)
+	if stelae == nil {
+		stelae = make(map[string]any)
+	}
+	stelae["warnings"] = list
+	meta["x-stelae"] = stelae
+	result["_meta"] = meta
+	return result
+}
+
+// attachWarningsToMeta is attachWarnings for the _meta of a result still
+// to be encoded.
+func attachWarningsToMeta(ctx context.Context, meta *mcp.Meta) *mcp.Meta {
+	if len(warnings(ctx)) == 0 {
+		return meta
+	}
+	fields := make(map[string]any)
+	if meta != nil {
+		maps.Copy(fields, meta.AdditionalFields)
+		if meta.ProgressToken != nil {
+			fields["progressToken"] = meta.ProgressToken
+		}
+	}
+	result := attachWarnings(ctx, map[string]any{"_meta": fields})
+	return mcp.NewMetaFromMap(result["_meta"].(map[string]any))
+}
+
+// attachWarningsToBody is attachWarnings for a recorded JSON-RPC response.
+// It reports false, leaving body to be forwarded as is, when there is
+// nothing to attach or body is not a result.
+func attachWarningsToBody(ctx context.Context, body []byte) ([]byte, bool) {
+	return attachWarningsFrom(ctx, bytes.NewReader(body))
+}
+
+// attachWarningsFrom is attachWarningsToBody reading the response from r.
+func attachWarningsFrom(ctx context.Context, r io.Reader) ([]byte, bool) {
+	if len(warnings(ctx)) == 0 {
+		return nil, false
+	}
+	var envelope map[string]json.RawMessage
+	var result map[string]any
+	if json.NewDecoder(r).Decode(&envelope) != nil || json.Unmarshal(envelope["result"], &result) != nil || result == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(attachWarnings(ctx, result))
+	if err != nil {
+		return nil, false
+	}
+	envelope["result"] = data
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// warnDrift records a schema_pinned warning for every listed tool served
+// with its pinned schemas.
+func warnDrift(ctx context.Context, items []map[string]any) {
+	for _, item := range items {
+		meta, _ := item["x-stelae"].(map[string]any)
+		drift, _ := meta["drift"].([]toolDrift)
+		for _, d := range drift {
+			if d.Serving {
+				warn(ctx, responseWarning{
+					Code:    warnSchemaPinned,
+					Message: "live schemas changed since the pin; listing the pinned schemas",
+					Server:  d.Server,
+					Tool:    d.Tool,
+				})
+			}
+		}
+	}
+}
+
+// attachWarningsToRecorder rewrites a recorded result to carry the
+// request's warnings. A spilled body is decoded from its file, so the
+// response has the same shape whatever its size.
+func attachWarningsToRecorder(ctx context.Context, rr *responseRecorder) {
+	if len(warnings(ctx)) == 0 {
+		return
+	}
+	if body, ok := attachWarningsFrom(ctx, rr.Body.Reader()); ok {
+		rr.Body.Reset()
+		_, _ = rr.Body.Write(body)
+		rr.HeaderMap.Del("Content-Length")
+	}
+}
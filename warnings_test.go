@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResponseWarnings(t *testing.T) {
+	result := map[string]any{"content": []any{}, "_meta": map[string]any{"trace": "abc"}}
+	if got := attachWarnings(context.Background(), result); got["_meta"].(map[string]any)["x-stelae"] != nil {
+		t.Fatal("warnings attached without a collector")
+	}
+	warn(context.Background(), responseWarning{Code: warnTruncated})
+
+	ctx := withWarnings(context.Background())
+	if _, ok := attachWarningsToBody(ctx, []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)); ok {
+		t.Fatal("body rewritten without warnings")
+	}
+	warn(ctx, responseWarning{Code: warnToolConflict, Message: "2 servers expose this tool", Server: "docs", Tool: "search"})
+	warnDrift(ctx, []map[string]any{
+		{"name": "read", "x-stelae": map[string]any{"drift": []toolDrift{{Server: "fs", Tool: "read", Serving: true}, {Server: "git", Tool: "read"}}}},
+		{"name": "write"},
+	})
+	if got := warnings(withWarnings(ctx)); len(got) != 0 {
+		t.Fatalf("nested request inherited warnings %v", got)
+	}
+
+	result = attachWarnings(ctx, result)
+	meta := result["_meta"].(map[string]any)
+	list := meta["x-stelae"].(map[string]any)["warnings"].([]responseWarning)
+	if meta["trace"] != "abc" || len(list) != 2 || list[0].Code != warnToolConflict || list[1].Code != warnSchemaPinned || list[1].Server != "fs" {
+		t.Fatalf("_meta = %+v", meta)
+	}
+
+	body, ok := attachWarningsToBody(ctx, []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[],"_meta":{"x-stelae":{"servers":["docs"]}}}}`))
+	if !ok {
+		t.Fatal("warnings not attached to the recorded body")
+	}
+	var reply struct {
+		ID     int `json:"id"`
+		Result struct {
+			Meta struct {
+				Stelae struct {
+					Servers  []string          `json:"servers"`
+					Warnings []responseWarning `json:"warnings"`
+				} `json:"x-stelae"`
+			} `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != 1 || len(reply.Result.Meta.Stelae.Servers) != 1 || len(reply.Result.Meta.Stelae.Warnings) != 2 {
+		t.Fatalf("body = %s", body)
+	}
+	if _, ok := attachWarningsToBody(ctx, []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"x"}}`)); ok {
+		t.Fatal("warnings attached to an error response")
+	}
+}
+
+func TestResponseWarningsOnSpilledBody(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	defer func(prev int64) { responseSpillThreshold = prev }(responseSpillThreshold)
+	responseSpillThreshold = 64
+
+	ctx := withWarnings(context.Background())
+	warn(ctx, responseWarning{Code: warnToolConflict, Server: "docs", Tool: "search"})
+	rr := newResponseRecorder()
+	_, _ = rr.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"` + strings.Repeat("x", 256) + `"}]}}`))
+	if !rr.Body.Spilled() {
+		t.Fatal("body did not spill")
+	}
+	attachWarningsToRecorder(ctx, rr)
+
+	var reply struct {
+		Result struct {
+			Content []map[string]any `json:"content"`
+			Meta    struct {
+				Stelae struct {
+					Warnings []responseWarning `json:"warnings"`
+				} `json:"x-stelae"`
+			} `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Result.Content) != 1 || len(reply.Result.Meta.Stelae.Warnings) != 1 {
+		t.Fatalf("spilled body = %.200s", rr.Body.String())
+	}
+	rr.Body.Close()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+)
+
+const (
+	wsSubprotocol    = "mcp"
+	wsMaxMessageSize = 16 * 1024 * 1024
+	wsWriteTimeout   = 10 * time.Second
+	// wsMaxInflight caps the requests one socket dispatches at once; the
+	// socket is not read while it is full.
+	wsMaxInflight = 32
+)
+
+func isWebSocketUpgrade(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsOriginAllowed reports whether a browser on the request's Origin may
+// open a socket: same-origin pages always may, others only when the CORS
+// policy allows their origin. Requests without an Origin are not from a
+// browser page and are left to authentication.
+func wsOriginAllowed(r *http.Request, cors *corsPolicy) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || sameOrigin(r) {
+		return true
+	}
+	return cors != nil && cors.allows(origin)
+}
+
+// upgradeWebSocket checks the upgrade's origin and completes the handshake.
+// Handshake errors are written as plain HTTP responses.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, cors *corsPolicy) (*websocket.Conn, error) {
+	if !wsOriginAllowed(r, cors) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, errors.New("cross-origin upgrade from " + r.Header.Get("Origin"))
+	}
+	ws, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols: []string{wsSubprotocol},
+		// wsOriginAllowed has checked the origin against the CORS policy,
+		// whose patterns are not the library's
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ws.SetReadLimit(wsMaxMessageSize)
+	return ws, nil
+}
+
+// serveFacadeWebSocket upgrades a facade GET and speaks JSON-RPC over the
+// socket: every text message is dispatched through the facade like a POST
+// with the upgrade request's headers and the identity, scopes, and trace
+// its middleware established, and replies go back as text messages.
+// Requests on one socket are handled concurrently, up to wsMaxInflight,
+// and share sessionID.
+func serveFacadeWebSocket(w http.ResponseWriter, r *http.Request, handler http.Handler, mcpPath, sessionID string, cors *corsPolicy) {
+	w.Header().Set(sessionIDHeader, sessionID)
+	ws, err := upgradeWebSocket(w, r, cors)
+	if err != nil {
+		logger("facade").Warn("websocket upgrade failed", "err", err)
+		return
+	}
+	logger("facade").Info("websocket opened", "session", sessionID)
+
+	header := r.Header.Clone()
+	for _, h := range []string{"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Protocol", "Sec-Websocket-Extensions"} {
+		header.Del(h)
+	}
+	header.Set(sessionIDHeader, sessionID)
+
+	// Messages keep the upgrade request's context values, as legacy SSE
+	// posts do, but not its cancellation: in-flight calls are cancelled
+	// when the socket goes away. Each message gets its own request id.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+	caller, hasCaller := callerFromContext(ctx)
+	caller.SessionID = sessionID
+	var inflight sync.WaitGroup
+	slots := make(chan struct{}, wsMaxInflight)
+	for {
+		typ, message, err := ws.Read(ctx)
+		if err != nil {
+			switch {
+			case websocket.CloseStatus(err) != -1, errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+				// the peer closed or went away; a close has been answered
+				_ = ws.CloseNow()
+			default:
+				// a frame the library refused, such as an unmasked one
+				logger("facade").Warn("websocket closing", "session", sessionID, "err", err)
+				_ = ws.Close(websocket.StatusProtocolError, "protocol error")
+			}
+			break
+		}
+		if typ != websocket.MessageText {
+			logger("facade").Warn("websocket closing: binary message", "session", sessionID)
+			_ = ws.Close(websocket.StatusUnsupportedData, "binary messages are not supported")
+			break
+		}
+		msgCtx := ctx
+		if hasCaller {
+			caller.RequestID = uuid.New().String()
+			msgCtx = withCallerInfo(ctx, caller)
+		}
+		slots <- struct{}{}
+		inflight.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				inflight.Done()
+			}()
+			if resp, _ := dispatchFacadeMessage(msgCtx, handler, mcpPath, header, message); len(resp) > 0 {
+				writeCtx, cancelWrite := context.WithTimeout(ctx, wsWriteTimeout)
+				defer cancelWrite()
+				if err := ws.Write(writeCtx, websocket.MessageText, resp); err != nil {
+					logger("facade").Warn("websocket write failed", "session", sessionID, "err", err)
+				}
+			}
+		}()
+	}
+	cancel()
+	inflight.Wait()
+	logger("facade").Info("websocket closed", "session", sessionID)
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Frame opcodes and close codes the raw test client speaks.
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsCloseProtocolError = 1002
+)
+
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestWebSocketAcceptKey(t *testing.T) {
+	// Example handshake from RFC 6455 section 1.3.
+	if got := wsAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+}
+
+// wsTestClient is the client half of the protocol, written frame by frame
+// so tests can send what a conforming client would not.
+type wsTestClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialWSTest(t *testing.T, srv *httptest.Server, header http.Header) (*wsTestClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/mcp", nil)
+	for k, vv := range header {
+		req.Header[k] = vv
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+	req.Header.Set("Sec-WebSocket-Protocol", "mcp")
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(req.Header.Get("Sec-WebSocket-Key")) {
+		t.Fatalf("bad accept key %q", resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+	return &wsTestClient{conn: conn, br: br}, resp
+}
+
+func (c *wsTestClient) send(t *testing.T, opcode byte, fin bool, payload []byte) {
+	t.Helper()
+	head := []byte{opcode, 0x80 | byte(len(payload))}
+	if fin {
+		head[0] |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	masked := make([]byte, len(payload))
+	for i := range payload {
+		masked[i] = payload[i] ^ mask[i%4]
+	}
+	if _, err := c.conn.Write(append(append(head, mask...), masked...)); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+}
+
+func (c *wsTestClient) recv(t *testing.T) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		_, _ = io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		t.Fatalf("recv payload: %v", err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestServeFacadeWebSocket(t *testing.T) {
+	facade := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && isWebSocketUpgrade(r) {
+			// as clientCertAuth admits an mTLS client on the upgrade
+			r = r.WithContext(withOAuthToken(r.Context(), oauthToken{Subject: "cert:agent", Scopes: []string{"tools:call"}}))
+			serveFacadeWebSocket(w, r, r.Context().Value(http.ServerContextKey).(*http.Server).Handler, "/mcp", facadeSessionID(r), nil)
+			return
+		}
+		var req jsonrpcRequest
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		tok, _ := oauthTokenFromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": map[string]any{
+				"method":  req.Method,
+				"auth":    r.Header.Get("Authorization"),
+				"session": r.Header.Get(sessionIDHeader),
+				"subject": tok.Subject,
+			},
+		})
+	})
+	srv := httptest.NewServer(facade)
+	defer srv.Close()
+
+	client, resp := dialWSTest(t, srv, http.Header{"Authorization": {"Bearer secret"}, sessionIDHeader: {"sess-1"}})
+	if resp.Header.Get("Sec-WebSocket-Protocol") != "mcp" || resp.Header.Get(sessionIDHeader) != "sess-1" {
+		t.Fatalf("unexpected handshake headers %v", resp.Header)
+	}
+
+	client.send(t, wsOpText, true, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	client.send(t, wsOpPing, true, []byte("hi"))
+	if op, payload := client.recv(t); op != wsOpPong || string(payload) != "hi" {
+		t.Fatalf("expected pong, got op=%d %q", op, payload)
+	}
+
+	// A fragmented request is reassembled before dispatch.
+	msg := []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/list"}`)
+	client.send(t, wsOpText, false, msg[:10])
+	client.send(t, wsOpContinuation, true, msg[10:])
+	op, payload := client.recv(t)
+	if op != wsOpText {
+		t.Fatalf("expected text frame, got op=%d", op)
+	}
+	var reply struct {
+		ID     int               `json:"id"`
+		Result map[string]string `json:"result"`
+	}
+	if err := json.Unmarshal(payload, &reply); err != nil {
+		t.Fatalf("decode reply %q: %v", payload, err)
+	}
+	if reply.ID != 7 || reply.Result["method"] != "tools/list" || reply.Result["auth"] != "Bearer secret" || reply.Result["session"] != "sess-1" || reply.Result["subject"] != "cert:agent" {
+		t.Fatalf("unexpected reply %s", payload)
+	}
+
+	client.send(t, wsOpClose, true, binary.BigEndian.AppendUint16(nil, 1000))
+	if op, payload := client.recv(t); op != wsOpClose || binary.BigEndian.Uint16(payload) != 1000 {
+		t.Fatalf("expected close echo, got op=%d %v", op, payload)
+	}
+}
+
+func TestWebSocketRejectsUnmaskedFrames(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveFacadeWebSocket(w, r, http.NotFoundHandler(), "/mcp", "sess-2", nil)
+	}))
+	defer srv.Close()
+
+	client, _ := dialWSTest(t, srv, nil)
+	payload := []byte(`{}`)
+	frame := append([]byte{0x80 | wsOpText, byte(len(payload))}, payload...)
+	if _, err := client.conn.Write(frame); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	op, body := client.recv(t)
+	if op != wsOpClose || binary.BigEndian.Uint16(body) != wsCloseProtocolError {
+		t.Fatalf("expected protocol error close, got op=%d %s", op, fmt.Sprint(body))
+	}
+	// the server waits for the close handshake until the socket goes
+	client.conn.Close()
+}
+
+func TestWebSocketUpgradeChecksOrigin(t *testing.T) {
+	cors, err := newCORSPolicy(&CORSConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		origin string
+		cors   *corsPolicy
+		want   int
+	}{
+		{"", nil, http.StatusSwitchingProtocols},
+		{"http://" + "HOST", nil, http.StatusSwitchingProtocols},
+		{"https://evil.example", nil, http.StatusForbidden},
+		{"https://evil.example", cors, http.StatusForbidden},
+		{"https://app.example.com", cors, http.StatusSwitchingProtocols},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveFacadeWebSocket(w, r, http.NotFoundHandler(), "/mcp", "sess-3", tc.cors)
+		}))
+		conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/mcp", nil)
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		if tc.origin != "" {
+			req.Header.Set("Origin", strings.Replace(tc.origin, "HOST", req.Host, 1))
+		}
+		if err := req.Write(conn); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("origin %q: status %d, want %d", tc.origin, resp.StatusCode, tc.want)
+		}
+		conn.Close()
+		srv.Close()
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// wireTap sees every JSON-RPC message a downstream server sends before
+// mcp-go decodes it. It pairs responses with the requests the client sent,
+// repairs them when the server's sanitizeResponses option is set, and
+// checks them against the shape the method promises. A response that fails
+// is quarantined as the server sent it and replaced by an error response,
+// so the waiting call fails with the violation instead of mcp-go's decode
+// error or a hang.
+type wireTap struct {
+	server     string
+	violations *protocolViolations
+	// sanitize repairs responses before they are checked.
+	sanitize bool
+
+	mu       sync.Mutex
+	pending  map[string]wireCall
+	outcomes map[string]wireOutcome
+}
+
+// wireCall is a request sent downstream and still awaiting its response.
+type wireCall struct {
+	id     json.RawMessage
+	method string
+	target string
+}
+
+// wireOutcome is what the tap did to the response of one call.
+type wireOutcome struct {
+	fixes        []string
+	violation    error
+	quarantineID string
+}
+
+func newWireTap(server string, violations *protocolViolations) *wireTap {
+	return &wireTap{
+		server:     server,
+		violations: violations,
+		pending:    make(map[string]wireCall),
+		outcomes:   make(map[string]wireOutcome),
+	}
+}
+
+// sent notes a message the client writes to the server; it returns the call
+// when the message is a request.
+func (t *wireTap) sent(data []byte) *wireCall {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			Name string `json:"name"`
+			URI  string `json:"uri"`
+		} `json:"params"`
+	}
+	if t == nil || json.Unmarshal(data, &msg) != nil || msg.Method == "" || !hasWireID(msg.ID) {
+		return nil
+	}
+	call := wireCall{id: msg.ID, method: msg.Method, target: msg.Params.Name}
+	if call.target == "" {
+		call.target = msg.Params.URI
+	}
+	t.mu.Lock()
+	t.pending[wireIDKey(msg.ID)] = call
+	t.mu.Unlock()
+	return &call
+}
+
+// received checks a message from the server and returns the message to
+// hand to mcp-go in its place. call is the request the message answers
+// when the transport knows it, as for a JSON body answering a POST.
+func (t *wireTap) received(data []byte, call *wireCall) []byte {
+	if t == nil || len(bytes.TrimSpace(data)) == 0 {
+		return data
+	}
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return t.reject(call, data, fmt.Errorf("malformed JSON-RPC message: %v", err))
+	}
+	if msg.Method != "" {
+		// a request or notification from the server
+		return data
+	}
+	if hasWireID(msg.ID) {
+		t.mu.Lock()
+		pending, ok := t.pending[wireIDKey(msg.ID)]
+		t.mu.Unlock()
+		if ok {
+			call = &pending
+		}
+	}
+	if call == nil {
+		return data
+	}
+	checked := data
+	var fixes []string
+	if t.sanitize {
+		var id any
+		_ = json.Unmarshal(call.id, &id)
+		checked, fixes = sanitizeDownstreamResponse(call.method, id, data)
+	}
+	if err := validateDownstreamResponse(call.method, checked); err != nil {
+		return t.reject(call, data, err)
+	}
+	if len(fixes) > 0 {
+		t.mu.Lock()
+		t.outcomes[wireIDKey(call.id)] = wireOutcome{fixes: fixes}
+		t.mu.Unlock()
+	}
+	return checked
+}
+
+// reject quarantines raw. When the call it answers is known, the call's
+// outcome records the violation and an error response takes raw's place;
+// otherwise raw is passed on for mcp-go to drop.
+func (t *wireTap) reject(call *wireCall, raw []byte, violation error) []byte {
+	method, target := "unknown", ""
+	if call != nil {
+		method, target = call.method, call.target
+	}
+	var quarantineID string
+	if t.violations != nil {
+		quarantineID = t.violations.record(t.server, method, target, violation, raw)
+	}
+	if call == nil {
+		return raw
+	}
+	t.mu.Lock()
+	t.outcomes[wireIDKey(call.id)] = wireOutcome{violation: violation, quarantineID: quarantineID}
+	t.mu.Unlock()
+	out, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      call.id,
+		"error":   map[string]any{"code": rpcCodeProtocolViolation, "message": violation.Error()},
+	})
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// take ends the call with id and returns what happened to its response.
+func (t *wireTap) take(id mcp.RequestId) (wireOutcome, bool) {
+	if t == nil {
+		return wireOutcome{}, false
+	}
+	data, err := json.Marshal(id)
+	if err != nil {
+		return wireOutcome{}, false
+	}
+	key := wireIDKey(data)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	outcome, ok := t.outcomes[key]
+	delete(t.outcomes, key)
+	delete(t.pending, key)
+	return outcome, ok
+}
+
+func hasWireID(id json.RawMessage) bool {
+	return len(id) > 0 && string(id) != "null"
+}
+
+// wireIDKey keys an id the same whether it was sent as a number or a string.
+func wireIDKey(id json.RawMessage) string {
+	if s, err := strconv.Unquote(string(bytes.TrimSpace(id))); err == nil {
+		return s
+	}
+	return string(bytes.TrimSpace(id))
+}
+
+// wireLines passes a newline-delimited stream of messages, a stdio
+// server's stdout, through a tap.
+type wireLines struct {
+	src *bufio.Reader
+	tap *wireTap
+	buf []byte
+	err error
+}
+
+func newWireLines(src io.Reader, tap *wireTap) *wireLines {
+	return &wireLines{src: bufio.NewReader(src), tap: tap}
+}
+
+func (l *wireLines) Read(p []byte) (int, error) {
+	for len(l.buf) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+		line, err := l.src.ReadBytes('\n')
+		l.err = err
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			l.buf = append(l.tap.received(trimmed, nil), '\n')
+		}
+	}
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
+
+// wireWriter notes each message a stdio client writes to its server.
+type wireWriter struct {
+	io.WriteCloser
+	tap *wireTap
+}
+
+func (w wireWriter) Write(p []byte) (int, error) {
+	w.tap.sent(bytes.TrimSpace(p))
+	return w.WriteCloser.Write(p)
+}
+
+// wireEvents passes the message events of a server-sent event stream
+// through a tap; other events and comments are passed on as they are.
+type wireEvents struct {
+	src  *bufio.Reader
+	body io.Closer
+	tap  *wireTap
+	buf  []byte
+	err  error
+}
+
+func (e *wireEvents) Read(p []byte) (int, error) {
+	for len(e.buf) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		e.buf = e.next()
+	}
+	n := copy(p, e.buf)
+	e.buf = e.buf[n:]
+	return n, nil
+}
+
+func (e *wireEvents) Close() error {
+	return e.body.Close()
+}
+
+// next reads one event and returns it as it is to be passed on.
+func (e *wireEvents) next() []byte {
+	var fields, data [][]byte
+	event := "message"
+	for {
+		line, err := e.src.ReadBytes('\n')
+		if err != nil {
+			e.err = err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			break
+		}
+		if rest, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			data = append(data, bytes.TrimPrefix(rest, []byte(" ")))
+		} else {
+			if rest, ok := bytes.CutPrefix(line, []byte("event:")); ok {
+				event = string(bytes.TrimSpace(rest))
+			}
+			fields = append(fields, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(fields) == 0 && len(data) == 0 {
+		return nil
+	}
+	var out bytes.Buffer
+	for _, field := range fields {
+		out.Write(field)
+		out.WriteByte('\n')
+	}
+	if len(data) > 0 {
+		payload := bytes.Join(data, []byte("\n"))
+		if event == "message" {
+			payload = e.tap.received(payload, nil)
+		}
+		for _, line := range bytes.Split(payload, []byte("\n")) {
+			out.WriteString("data: ")
+			out.Write(line)
+			out.WriteByte('\n')
+		}
+	}
+	out.WriteByte('\n')
+	return out.Bytes()
+}
+
+// wireRoundTripper taps the requests an HTTP client sends a server and the
+// JSON bodies and event streams the server answers with.
+type wireRoundTripper struct {
+	base http.RoundTripper
+	tap  *wireTap
+}
+
+// wireHTTPClient returns the client an HTTP transport of server uses.
+func wireHTTPClient(tap *wireTap) *http.Client {
+	return &http.Client{Transport: &wireRoundTripper{base: http.DefaultTransport, tap: tap}}
+}
+
+func (rt *wireRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var call *wireCall
+	if req.Method == http.MethodPost && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		call = rt.tap.sent(body)
+	}
+	resp, err := rt.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		data, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		data = rt.tap.received(data, call)
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		resp.ContentLength = int64(len(data))
+		resp.Header.Del("Content-Length")
+	case "text/event-stream":
+		resp.Body = &wireEvents{src: bufio.NewReader(resp.Body), body: resp.Body, tap: rt.tap}
+	}
+	return resp, nil
+}
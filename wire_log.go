@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWireLogMaxBytes  = 4096
+	defaultWireLogPerMinute = 60
+)
+
+// WireLogConfig logs the full, redacted JSON-RPC request and response of
+// dispatched calls that match a rule. Rules can also be added and removed
+// at runtime through the admin API.
+type WireLogConfig struct {
+	RedactKeys []string       `json:"redactKeys,omitempty"`
+	Rules      []*WireLogRule `json:"rules,omitempty"`
+}
+
+// WireLogRule picks the calls to Server whose tool name, prompt name, or
+// resource URI matches Tool (a path.Match pattern; empty matches all).
+// SampleRate (default 1) is the share of matching calls logged, at most
+// PerMinute (default 60) of them a minute; bodies are capped at MaxBytes
+// (default 4096) each.
+type WireLogRule struct {
+	ID         string     `json:"id"`
+	Server     string     `json:"server"`
+	Tool       string     `json:"tool,omitempty"`
+	SampleRate float64    `json:"sampleRate,omitempty"`
+	MaxBytes   int        `json:"maxBytes,omitempty"`
+	PerMinute  int        `json:"perMinute,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	// Logged and Limited count the sampled calls written and those skipped
+	// over PerMinute.
+	Logged  int64 `json:"logged"`
+	Limited int64 `json:"limited"`
+}
+
+// wireLogRule is a rule with its rate window.
+type wireLogRule struct {
+	WireLogRule
+	windowStart time.Time
+	inWindow    int
+}
+
+type wireLogger struct {
+	keys   map[string]struct{}
+	now    func() time.Time
+	sample func() float64
+
+	mu    sync.Mutex
+	rules []*wireLogRule
+}
+
+func newWireLogger(conf *WireLogConfig) (*wireLogger, error) {
+	l := &wireLogger{now: time.Now, sample: rand.Float64}
+	if conf == nil {
+		l.keys = newRedactKeySet(nil)
+		return l, nil
+	}
+	l.keys = newRedactKeySet(conf.RedactKeys)
+	for i, rule := range conf.Rules {
+		if rule == nil {
+			return nil, fmt.Errorf("wireLog.rules[%d]: empty rule", i)
+		}
+		if _, err := l.add(*rule, 0); err != nil {
+			return nil, fmt.Errorf("wireLog.rules[%d]: %w", i, err)
+		}
+	}
+	return l, nil
+}
+
+// add validates rule, fills its defaults, and activates it for ttl (for
+// good when zero).
+func (l *wireLogger) add(rule WireLogRule, ttl time.Duration) (WireLogRule, error) {
+	if rule.Server == "" {
+		return WireLogRule{}, errors.New("server is required")
+	}
+	if _, err := path.Match(rule.Tool, ""); err != nil {
+		return WireLogRule{}, fmt.Errorf("tool %q: %w", rule.Tool, err)
+	}
+	if rule.SampleRate < 0 || rule.SampleRate > 1 || rule.MaxBytes < 0 || rule.PerMinute < 0 {
+		return WireLogRule{}, errors.New("sampleRate must be within 0..1 and limits must not be negative")
+	}
+	if rule.SampleRate == 0 {
+		rule.SampleRate = 1
+	}
+	if rule.MaxBytes == 0 {
+		rule.MaxBytes = defaultWireLogMaxBytes
+	}
+	if rule.PerMinute == 0 {
+		rule.PerMinute = defaultWireLogPerMinute
+	}
+	rule.ID = uuid.New().String()
+	rule.ExpiresAt, rule.Logged, rule.Limited = nil, 0, 0
+	if ttl > 0 {
+		expires := l.now().Add(ttl).UTC()
+		rule.ExpiresAt = &expires
+	}
+	l.mu.Lock()
+	l.rules = append(l.rules, &wireLogRule{WireLogRule: rule})
+	l.mu.Unlock()
+	logger("wire").Info("logging", "server", rule.Server, "tool", rule.Tool, "sampleRate", rule.SampleRate, "perMinute", rule.PerMinute, "id", rule.ID)
+	return rule, nil
+}
+
+// remove deactivates rule id and returns it.
+func (l *wireLogger) remove(id string) (WireLogRule, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, rule := range l.rules {
+		if rule.ID == id {
+			l.rules = append(l.rules[:i], l.rules[i+1:]...)
+			logger("wire").Info("stopped logging", "server", rule.Server, "tool", rule.Tool, "id", id)
+			return rule.WireLogRule, true
+		}
+	}
+	return WireLogRule{}, false
+}
+
+// list returns the active rules.
+func (l *wireLogger) list() []WireLogRule {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pruneLocked(l.now())
+	out := make([]WireLogRule, 0, len(l.rules))
+	for _, rule := range l.rules {
+		out = append(out, rule.WireLogRule)
+	}
+	return out
+}
+
+func (l *wireLogger) pruneLocked(now time.Time) {
+	kept := l.rules[:0]
+	for _, rule := range l.rules {
+		if rule.ExpiresAt == nil || now.Before(*rule.ExpiresAt) {
+			kept = append(kept, rule)
+		}
+	}
+	l.rules = kept
+}
+
+// pick returns the cap of the first rule logging a call to target on
+// server, counting the call against that rule's rate.
+func (l *wireLogger) pick(server, target string) (int, bool) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pruneLocked(now)
+	for _, rule := range l.rules {
+		if rule.Server != server || !globMatch(rule.Tool, target) {
+			continue
+		}
+		if l.sample() >= rule.SampleRate {
+			return 0, false
+		}
+		if now.Sub(rule.windowStart) >= time.Minute {
+			rule.windowStart, rule.inWindow = now, 0
+		}
+		if rule.inWindow >= rule.PerMinute {
+			rule.Limited++
+			return 0, false
+		}
+		rule.inWindow++
+		rule.Logged++
+		return rule.MaxBytes, true
+	}
+	return 0, false
+}
+
+// watches reports whether a live rule covers calls to target on server,
+// without counting a call against its rate.
+func (l *wireLogger) watches(server, target string) bool {
+	if l == nil {
+		return false
+	}
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, rule := range l.rules {
+		if rule.Server == server && globMatch(rule.Tool, target) && (rule.ExpiresAt == nil || now.Before(*rule.ExpiresAt)) {
+			return true
+		}
+	}
+	return false
+}
+
+// observe logs a dispatched call when a rule picks it.
+func (l *wireLogger) observe(ctx context.Context, method, server, target string, request, response []byte, d time.Duration) {
+	if l == nil {
+		return
+	}
+	max, ok := l.pick(server, target)
+	if !ok {
+		return
+	}
+	caller := ""
+	if info, ok := callerFromContext(ctx); ok {
+		caller = info.Identity
+	}
+	logger("wire").Info(method, "target", target, "server", server, "caller", caller, "took", d, "request", redactedExcerpt(request, l.keys, max), "response", redactedExcerpt(response, l.keys, max))
+}
+
+// registerWireLogRoutes toggles wire logging at runtime. known reports
+// whether a server is configured. Changes are audited, since they expose
+// call bodies in the log.
+func registerWireLogRoutes(api *adminAPI, wire *wireLogger, known func(server string) bool, audit *auditLog) {
+	record := func(r *http.Request, action string, rule WireLogRule) {
+		params, _ := json.Marshal(rule)
+		audit.record(auditEntry{At: time.Now(), Method: "stelae/wire_log." + action, Target: rule.ID, Caller: "admin:" + tokenFingerprint(bearerToken(r)), Params: params})
+	}
+	api.handle(http.MethodGet, "wire-log", func(w http.ResponseWriter, r *http.Request) {
+		api.writeJSON(w, http.StatusOK, map[string]any{"rules": wire.list()})
+	})
+	api.handle(http.MethodPost, "wire-log", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			WireLogRule
+			TTL string `json:"ttl"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		var ttl time.Duration
+		if body.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(body.TTL); err != nil || ttl <= 0 {
+				api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("invalid ttl %q", body.TTL)})
+				return
+			}
+		}
+		if body.Server != "" && !known(body.Server) {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown server", "server": body.Server})
+			return
+		}
+		rule, err := wire.add(body.WireLogRule, ttl)
+		if err != nil {
+			api.writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		record(r, "add", rule)
+		api.writeJSON(w, http.StatusCreated, rule)
+	})
+	api.handle(http.MethodDelete, "wire-log/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := wire.remove(r.PathValue("id"))
+		if !ok {
+			api.writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown rule", "id": r.PathValue("id")})
+			return
+		}
+		record(r, "remove", rule)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWireLoggerSamplesAndLimits(t *testing.T) {
+	out := captureLog(t, "info")
+
+	l, err := newWireLogger(&WireLogConfig{RedactKeys: []string{"ssn"}, Rules: []*WireLogRule{
+		{Server: "crm", Tool: "lookup*", MaxBytes: 120, PerMinute: 2},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+	ctx := withCallerInfo(context.Background(), callerInfo{Identity: "agent"})
+	request := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"lookup","arguments":{"api_key":"k-123","SSN":"123-45-6789"}}}`)
+	response := []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"` + strings.Repeat("z", 300) + `"}]}}`)
+
+	out.Reset()
+	l.observe(ctx, "tools/call", "fs", "lookup", request, response, time.Millisecond)
+	l.observe(ctx, "tools/call", "crm", "search", request, response, time.Millisecond)
+	if out.Len() != 0 {
+		t.Fatalf("unmatched calls logged: %s", out.String())
+	}
+	for range 3 {
+		l.observe(ctx, "tools/call", "crm", "lookup", request, response, time.Millisecond)
+	}
+	logged := out.String()
+	if n := strings.Count(logged, "<wire> tools/call "); n != 2 {
+		t.Fatalf("logged %d calls over a limit of 2:\n%s", n, logged)
+	}
+	if strings.Contains(logged, "k-123") || strings.Contains(logged, "6789") || !strings.Contains(logged, "caller=agent") || !strings.Contains(logged, "truncated") {
+		t.Fatalf("unexpected log:\n%s", logged)
+	}
+	rules := l.list()
+	if len(rules) != 1 || rules[0].Logged != 2 || rules[0].Limited != 1 {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if !l.watches("crm", "lookup") || l.watches("crm", "search") || (*wireLogger)(nil).watches("crm", "lookup") {
+		t.Fatal("watches disagrees with the rules")
+	}
+	if rules := l.list(); rules[0].Logged != 2 || rules[0].Limited != 1 {
+		t.Fatalf("watches counted a call: %+v", rules)
+	}
+
+	// the window reopens after a minute; unsampled calls are not counted
+	now = now.Add(time.Minute)
+	l.sample = func() float64 { return 0.99 }
+	l.rules[0].SampleRate = 0.5
+	l.observe(ctx, "tools/call", "crm", "lookup", request, response, time.Millisecond)
+	l.sample = func() float64 { return 0.1 }
+	l.observe(ctx, "tools/call", "crm", "lookup", request, response, time.Millisecond)
+	if rules := l.list(); rules[0].Logged != 3 || rules[0].Limited != 1 {
+		t.Fatalf("after window: %+v", rules)
+	}
+
+	for _, bad := range []WireLogRule{{}, {Server: "crm", SampleRate: 2}, {Server: "crm", Tool: "["}} {
+		if _, err := l.add(bad, 0); err == nil {
+			t.Errorf("rule %+v accepted", bad)
+		}
+	}
+}
+
+func TestWireLogRoutes(t *testing.T) {
+	l, err := newWireLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+	mux := http.NewServeMux()
+	registerWireLogRoutes(newAdminAPI(mux, "/", []string{"admin-secret"}, JSONEncodingConfig{}), l, func(server string) bool { return server == "crm" }, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/admin/wire-log", `{"server":"crm","tool":"lookup","sampleRate":0.25,"ttl":"10m"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add: %d %s", w.Code, w.Body.String())
+	}
+	var rule WireLogRule
+	if err := json.Unmarshal(w.Body.Bytes(), &rule); err != nil || rule.ID == "" || rule.SampleRate != 0.25 || rule.MaxBytes != defaultWireLogMaxBytes || rule.ExpiresAt == nil {
+		t.Fatalf("add body %s", w.Body.String())
+	}
+	if w := do(http.MethodPost, "/admin/wire-log", `{"server":"nope"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown server: %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/admin/wire-log", `{"server":"crm","ttl":"soon"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad ttl: %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/admin/wire-log", ""); !strings.Contains(w.Body.String(), rule.ID) {
+		t.Fatalf("list: %s", w.Body.String())
+	}
+
+	// rules lapse after their ttl
+	now = now.Add(11 * time.Minute)
+	if rules := l.list(); len(rules) != 0 {
+		t.Fatalf("expired rule still listed: %+v", rules)
+	}
+	w = do(http.MethodPost, "/admin/wire-log", `{"server":"crm"}`)
+	_ = json.Unmarshal(w.Body.Bytes(), &rule)
+	if w := do(http.MethodDelete, "/admin/wire-log/"+rule.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("remove: %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/admin/wire-log/"+rule.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("remove again: %d", w.Code)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TBXark/optional-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// malformedReplies are what the fake servers answer each tool with.
+var malformedReplies = map[string]string{
+	"shape":   `{"jsonrpc":"2.0","id":%s,"result":{"content":"hello"}}`,
+	"garbage": `{"jsonrpc":"2.0","id":%s,"result":`,
+	"ok":      `{"jsonrpc":"2.0","id":%s,"result":{"content":[{"type":"text","text":"fine"}]}}`,
+	// no jsonrpc member, the id echoed as a string and bare string content
+	"sloppy": `{"id":"%s","result":{"content":"hello"}}`,
+}
+
+// fakeServerReply answers one request line the way the malformed fake
+// server does; notifications get no reply.
+func fakeServerReply(line []byte) (string, bool) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			Name string `json:"name"`
+		} `json:"params"`
+	}
+	if json.Unmarshal(line, &req) != nil || len(req.ID) == 0 {
+		return "", false
+	}
+	switch req.Method {
+	case "initialize":
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":%q,"capabilities":{"tools":{}},"serverInfo":{"name":"fake","version":"1"}}}`, req.ID, mcp.LATEST_PROTOCOL_VERSION), true
+	case "tools/call":
+		return fmt.Sprintf(malformedReplies[req.Params.Name], req.ID), true
+	}
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{}}`, req.ID), true
+}
+
+// TestWireHelperProcess is the malformed stdio server the wire tests run.
+func TestWireHelperProcess(t *testing.T) {
+	if os.Getenv("STELAE_WIRE_HELPER") != "1" {
+		return
+	}
+	fmt.Println("starting fake server")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if reply, ok := fakeServerReply(scanner.Bytes()); ok {
+			fmt.Println(reply)
+		}
+	}
+	os.Exit(0)
+}
+
+func readQuarantine(t *testing.T) []quarantineEntry {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(stateHome(), "quarantine", "violations.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []quarantineEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry quarantineEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func connectWireClient(t *testing.T, conf *MCPClientConfigV2, violations *protocolViolations) *Client {
+	t.Helper()
+	c, err := newMCPClient("fake", conf, violations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.client.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.client.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func callFakeTool(c *Client, name string) (*mcp.CallToolResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var call mcp.CallToolRequest
+	call.Params.Name = name
+	return c.callTool(ctx, call)
+}
+
+func TestWireTapQuarantinesStdioServerBytes(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	t.Setenv("STELAE_WIRE_HELPER", "1")
+	violations := newProtocolViolations()
+	c := connectWireClient(t, &MCPClientConfigV2{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestWireHelperProcess$"},
+		Options: &OptionsV2{},
+	}, violations)
+
+	_, err := callFakeTool(c, "shape")
+	var violation *downstreamViolation
+	if !errors.As(err, &violation) || violation.quarantineID == "" || !strings.Contains(violation.violation.Error(), "must be an array") {
+		t.Fatalf("shape call error = %v", err)
+	}
+	if result, err := callFakeTool(c, "ok"); err != nil || len(result.Content) != 1 {
+		t.Fatalf("ok call = %+v, %v", result, err)
+	}
+
+	entries := readQuarantine(t)
+	if len(entries) != 2 {
+		t.Fatalf("quarantine = %+v", entries)
+	}
+	// the banner on stdout is not JSON-RPC and answers nothing
+	if entries[0].Method != "unknown" || entries[0].Payload != "starting fake server" || !strings.HasPrefix(entries[0].Violation, "malformed JSON-RPC message") {
+		t.Fatalf("banner entry = %+v", entries[0])
+	}
+	if entries[1].ID != violation.quarantineID || entries[1].Target != "shape" || !strings.Contains(entries[1].Payload, `"content":"hello"`) {
+		t.Fatalf("shape entry = %+v", entries[1])
+	}
+}
+
+func TestWireTapSanitizesServerBytes(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	t.Setenv("STELAE_WIRE_HELPER", "1")
+	c := connectWireClient(t, &MCPClientConfigV2{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestWireHelperProcess$"},
+		Options: &OptionsV2{SanitizeResponses: optional.NewField(true)},
+	}, newProtocolViolations())
+
+	ctx, cancel := context.WithTimeout(withWarnings(context.Background()), 5*time.Second)
+	defer cancel()
+	var call mcp.CallToolRequest
+	call.Params.Name = "sloppy"
+	result, err := c.callTool(ctx, call)
+	if err != nil || len(result.Content) != 1 {
+		t.Fatalf("sloppy call = %+v, %v", result, err)
+	}
+	if text, ok := result.Content[0].(mcp.TextContent); !ok || text.Text != "hello" {
+		t.Fatalf("content = %+v", result.Content[0])
+	}
+	got := warnings(ctx)
+	if len(got) != 1 || got[0].Code != warnResponseSanitized || got[0].Message != "downstream response repaired: jsonrpc, id type, content" {
+		t.Fatalf("warnings = %+v", got)
+	}
+	// only the banner, which answers nothing, is quarantined
+	if entries := readQuarantine(t); len(entries) != 1 || entries[0].Method != "unknown" {
+		t.Fatalf("quarantine = %+v", entries)
+	}
+}
+
+func TestWireTapQuarantinesHTTPServerBytes(t *testing.T) {
+	t.Setenv("STELAE_STATE_HOME", t.TempDir())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reply, ok := fakeServerReply(body)
+		if !ok {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, reply)
+	}))
+	defer ts.Close()
+	violations := newProtocolViolations()
+	c := connectWireClient(t, &MCPClientConfigV2{TransportType: MCPClientTypeStreamable, URL: ts.URL, Options: &OptionsV2{}}, violations)
+
+	_, err := callFakeTool(c, "garbage")
+	var violation *downstreamViolation
+	if !errors.As(err, &violation) || !strings.HasPrefix(violation.violation.Error(), "malformed JSON-RPC message") {
+		t.Fatalf("garbage call error = %v", err)
+	}
+	entries := readQuarantine(t)
+	if len(entries) != 1 || entries[0].Target != "garbage" || !strings.HasSuffix(entries[0].Payload, `"result":`) {
+		t.Fatalf("quarantine = %+v", entries)
+	}
+
+	resp, _ := testProxy().dispatchToClient(context.Background(), &Server{name: "fake", client: c}, []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"shape"}}`))
+	var facade jsonrpcResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &facade); err != nil || facade.Error == nil || facade.Error.Code != rpcCodeProtocolViolation {
+		t.Fatalf("facade response = %s", resp.Body.Bytes())
+	}
+}
+
+func TestWireEventsTapMessageEventsOnly(t *testing.T) {
+	tap := newWireTap("fake", nil)
+	tap.sent([]byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"shape"}}`))
+	stream := "event: endpoint\ndata: /messages?session=1\n\n: keepalive\n\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":3,\"result\":{\"content\":\"hello\"}}\n\n"
+	events := &wireEvents{src: bufio.NewReader(strings.NewReader(stream)), body: io.NopCloser(nil), tap: tap}
+	out, err := io.ReadAll(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	if !strings.HasPrefix(got, "event: endpoint\ndata: /messages?session=1\n\n: keepalive\n\n") || !strings.Contains(got, `"code":-32007`) {
+		t.Fatalf("stream = %q", got)
+	}
+}